@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"gorm.io/gorm"
+)
+
+// Demo mode
+//
+// With --demo the server needs neither PostgreSQL nor a .env file: it runs on an in-memory SQLite database
+// seeded with a sample team, and every request acts as the demo user without logging in. Nothing is kept
+// once the server stops.
+
+// demoEmployees is the sample team loaded at startup, the two employees of an optician shop.
+//
+//go:embed demo/employees.json
+var demoEmployees []byte
+
+// demoDSN is the in-memory database of the demo mode, shared by the connections of the pool.
+const demoDSN = "file:demo?mode=memory&cache=shared"
+
+// demoUsername and demoPassword are the account every request of the demo mode acts as, which can also log in.
+const (
+	demoUsername = "demo"
+	demoPassword = "demo-password"
+)
+
+// openDemoDB opens the in-memory database of the demo mode.
+func openDemoDB() (*gorm.DB, error) {
+	return repo.Open(repo.DriverSQLite, demoDSN, &gorm.Config{})
+}
+
+// demoSecret returns a random JWT secret, the tokens of a demo server being worthless once it stops.
+func demoSecret() string {
+	var secret [32]byte
+	rand.Read(secret[:])
+	return hex.EncodeToString(secret[:])
+}
+
+// seedDemo loads the sample team and lets the requests without a token through as the demo user.
+func seedDemo(ctx context.Context, serv *service.EmployeeService, authService *auth.Service) error {
+	if _, err := serv.ImportEmployees(ctx, demoEmployees, false); err != nil {
+		return fmt.Errorf("failed to load the sample team: %w", err)
+	}
+	if err := authService.EnsureUser(ctx, demoUsername, demoPassword); err != nil {
+		return fmt.Errorf("failed to create the demo user: %w", err)
+	}
+	return authService.AllowAnonymous(ctx, demoUsername)
+}
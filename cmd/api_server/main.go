@@ -1,28 +1,69 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/gcal"
+	"github.com/lichensio/api_server/pkg/api/health"
+	"github.com/lichensio/api_server/pkg/api/holiday"
 	lhttp "github.com/lichensio/api_server/pkg/api/http"
+	"github.com/lichensio/api_server/pkg/api/payroll"
 	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/lichensio/api_server/pkg/api/slack"
+	"github.com/lichensio/api_server/pkg/api/storage"
+	"github.com/lichensio/api_server/pkg/api/support"
+	"github.com/lichensio/api_server/pkg/api/tenant"
 	log "github.com/sirupsen/logrus"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// configKeys are the environment variables shown in the support bundles, the secrets among them redacted.
+var configKeys = []string{"PORT", "READ_ONLY", "TENANT_MODE", "TENANT_BASE_DOMAIN", "HOLIDAY_PROVIDER",
+	"HOLIDAY_COUNTRY", "HOLIDAY_FILE", "HOLIDAY_REGION", "HOLIDAY_LOCATION_REGIONS", "HOLIDAY_PAY_MULTIPLIER",
+	"HOLIDAY_PAID_IF_NOT_WORKED", "TIME_ZONE", "LOCATION_TIME_ZONES", "DB_DRIVER", "DB_HOST", "DB_PORT",
+	"DB_NAME", "DB_USER", "DB_PASSWORD", "DB_SSLMODE", "CHANGE_FEED", "CHANGE_FEED_DSN", "JWT_SECRET",
+	"JWT_TTL", "PASSWORD_HASH", "PASSWORD_MIN_LENGTH", "PASSWORD_CHARACTER_CLASSES", "ADMIN_USERNAME",
+	"ADMIN_PASSWORD", "ADMIN_API_TOKENS", "INTERNAL_API_TOKENS", "REPORT_TIMEOUT", "ROUTE_TIMEOUT",
+	"HEAVY_ROUTE_TIMEOUT", "HEAVY_CONCURRENCY", "HEAVY_QUEUE", "SHUTDOWN_TIMEOUT", "SCHEDULE_SNAPSHOTS",
+	"PAYROLL_CSV_COLUMNS", "PAYROLL_CSV_SEPARATOR", "PRINT_STORAGE_DIR", "PRINT_WORKERS", "PRINT_LINK_SECRET",
+	"PRINT_LINK_TTL", "PUBLIC_BASE_URL", "WEBHOOK_WORKERS", "SCHEDULE_VALIDATORS", "SCHEDULE_VALIDATOR_URL",
+	"SLACK_WEBHOOK_URL", "SLACK_REMINDER_AT", "GOOGLE_CLIENT_ID", "GOOGLE_CLIENT_SECRET",
+	"GOOGLE_REFRESH_TOKEN", "GOOGLE_CALENDAR_TIME_ZONE", "GOOGLE_CALENDAR_MONTHS"}
+
 func main() {
 
 	log.SetFormatter(&log.JSONFormatter{})
 	log.SetLevel(log.InfoLevel)
+	// The last warnings and errors go into the support bundles.
+	recentLogs := support.NewLogRecorder(500)
+	log.AddHook(recentLogs)
+
+	demo := flag.Bool("demo", false, "serve a sample team from an in-memory database, without .env, PostgreSQL or login")
+	flag.Parse()
 
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	if !*demo {
+		if err := godotenv.Load(); err != nil {
+			log.Fatal("Error loading .env file")
+		}
 	}
 
+	// DB_DRIVER=sqlite runs on the SQLite file DB_NAME, for local runs without PostgreSQL; the other DB_
+	// settings are then ignored.
+	driver := os.Getenv("DB_DRIVER")
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_USER"),
@@ -31,18 +72,368 @@ func main() {
 		os.Getenv("DB_PORT"),
 		os.Getenv("DB_SSLMODE"),
 	)
-	dbname, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if driver == repo.DriverSQLite {
+		dsn = os.Getenv("DB_NAME")
+	}
+	var dbname *gorm.DB
+	var err error
+	if *demo {
+		dbname, err = openDemoDB()
+	} else {
+		dbname, err = repo.Open(driver, dsn, &gorm.Config{})
+	}
 
+	if err != nil {
+		log.Fatalf("failed to create repository: %v", err)
+	}
 	// Setup repository
 	nrepo := repo.NewRepositoryWithDB(dbname)
+
+	// Setup authentication
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" && *demo {
+		jwtSecret = demoSecret()
+	}
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+	tokenTTL := 24 * time.Hour
+	if ttl := os.Getenv("JWT_TTL"); ttl != "" {
+		if tokenTTL, err = time.ParseDuration(ttl); err != nil {
+			log.Fatalf("invalid JWT_TTL: %v", err)
+		}
+	}
+	// A read-only instance serves calendar reads from a replica database and must not write at startup either.
+	readOnly := os.Getenv("READ_ONLY") == "true"
+
+	authService := auth.NewService(nrepo, jwtSecret, tokenTTL)
+	switch hash := os.Getenv("PASSWORD_HASH"); hash {
+	case "", "argon2id":
+	case "bcrypt":
+		authService.UsePasswordHasher(auth.DefaultBcrypt)
+	default:
+		log.Fatalf("invalid PASSWORD_HASH %q, expected argon2id or bcrypt", hash)
+	}
+	policy := auth.DefaultPasswordPolicy
+	if length := os.Getenv("PASSWORD_MIN_LENGTH"); length != "" {
+		if policy.MinLength, err = strconv.Atoi(length); err != nil {
+			log.Fatalf("invalid PASSWORD_MIN_LENGTH: %v", err)
+		}
+	}
+	if classes := os.Getenv("PASSWORD_CHARACTER_CLASSES"); classes != "" {
+		if policy.CharacterClasses, err = strconv.Atoi(classes); err != nil {
+			log.Fatalf("invalid PASSWORD_CHARACTER_CLASSES: %v", err)
+		}
+	}
+	authService.SetPasswordPolicy(policy)
+	// Public holidays are fetched from HOLIDAY_PROVIDER: the French government API by default, Nager.Date for
+	// the country HOLIDAY_COUNTRY, or the JSON file HOLIDAY_FILE. They are those of HOLIDAY_REGION, by default
+	// mainland France, the whole country or the only region of the file, unless the tenant or the request
+	// names another region.
+	var holidays holiday.Provider
+	switch provider := os.Getenv("HOLIDAY_PROVIDER"); provider {
+	case "", "gouv":
+		holidays = holiday.Gouv{Client: &http.Client{Timeout: 10 * time.Second}}
+	case "nager":
+		country := strings.ToUpper(os.Getenv("HOLIDAY_COUNTRY"))
+		if len(country) != 2 {
+			log.Fatalf("invalid HOLIDAY_COUNTRY %q, expected an ISO 3166-1 alpha-2 country code such as DE", country)
+		}
+		holidays = holiday.NagerDate{Country: country, Client: &http.Client{Timeout: 10 * time.Second}}
+	case "file":
+		if holidays, err = holiday.LoadFile(os.Getenv("HOLIDAY_FILE")); err != nil {
+			log.Fatalf("invalid HOLIDAY_FILE: %v", err)
+		}
+	default:
+		log.Fatalf("invalid HOLIDAY_PROVIDER %q, expected gouv, nager or file", provider)
+	}
+	holidayRegion := os.Getenv("HOLIDAY_REGION")
+	if holidayRegion == "" {
+		if holidayRegion = holidays.DefaultRegion(); holidayRegion == "" {
+			log.Fatal("HOLIDAY_REGION must name one of the regions of HOLIDAY_FILE")
+		}
+	} else if !holidays.IsRegion(holidayRegion) {
+		log.Fatalf("invalid HOLIDAY_REGION %q, unknown to the holiday provider", holidayRegion)
+	}
+	// In multi-tenant mode every company has its own users, created along with the tenant by
+	// "api_server [flags] create-tenant <name> <subdomain> [region]" with ADMIN_USERNAME and ADMIN_PASSWORD as
+	// first account; the holiday region defaults to metropole.
+	multiTenant := os.Getenv("TENANT_MODE") == "multi"
+	if flag.Arg(0) == "create-tenant" {
+		args := flag.Args()
+		if len(args) != 3 && len(args) != 4 {
+			log.Fatal("usage: api_server [flags] create-tenant <name> <subdomain> [region]")
+		}
+		region := ""
+		if len(args) == 4 {
+			region = args[3]
+		}
+		createTenant(nrepo, authService, holidays, args[1], args[2], region)
+		return
+	}
+	// The primary brings the schema to the version of the binary before serving; replicas follow it.
+	if !readOnly {
+		if err := nrepo.DBCreate(context.Background()); err != nil {
+			log.Fatalf("failed to migrate the database: %v", err)
+		}
+	}
+	if username := os.Getenv("ADMIN_USERNAME"); username != "" && !readOnly && !multiTenant {
+		if err := authService.EnsureUser(context.Background(), username, os.Getenv("ADMIN_PASSWORD")); err != nil {
+			log.Fatalf("failed to create admin user: %v", err)
+		}
+	}
+
+	// Setup dependency health checks
+	sqlDB, err := dbname.DB()
 	if err != nil {
-		log.Fatalf("failed to create repository: %v", err)
+		log.Fatalf("failed to access database pool: %v", err)
+	}
+	checkers := []health.Checker{health.DBChecker{DB: sqlDB}}
+	if remote, ok := holidays.(holiday.Remote); ok {
+		checkers = append(checkers, health.HTTPChecker{CheckName: "holiday-provider", URL: remote.URL(holidayRegion, time.Now().Year())})
 	}
+	checks := health.NewAggregator(2*time.Second, 30*time.Second, checkers...)
 
 	// Setup service
+	var reportTimeout time.Duration
+	if timeout := os.Getenv("REPORT_TIMEOUT"); timeout != "" {
+		if reportTimeout, err = time.ParseDuration(timeout); err != nil {
+			log.Fatalf("invalid REPORT_TIMEOUT: %v", err)
+		}
+	}
+	timeouts := lhttp.Timeouts{Default: 30 * time.Second, Heavy: 2 * time.Minute}
+	if timeout := os.Getenv("ROUTE_TIMEOUT"); timeout != "" {
+		if timeouts.Default, err = time.ParseDuration(timeout); err != nil {
+			log.Fatalf("invalid ROUTE_TIMEOUT: %v", err)
+		}
+	}
+	if timeout := os.Getenv("HEAVY_ROUTE_TIMEOUT"); timeout != "" {
+		if timeouts.Heavy, err = time.ParseDuration(timeout); err != nil {
+			log.Fatalf("invalid HEAVY_ROUTE_TIMEOUT: %v", err)
+		}
+	}
+	heavyLimit := lhttp.Limit{Concurrency: 4, Queue: 8, RetryAfter: 5 * time.Second}
+	if concurrency := os.Getenv("HEAVY_CONCURRENCY"); concurrency != "" {
+		if heavyLimit.Concurrency, err = strconv.Atoi(concurrency); err != nil {
+			log.Fatalf("invalid HEAVY_CONCURRENCY: %v", err)
+		}
+	}
+	if queue := os.Getenv("HEAVY_QUEUE"); queue != "" {
+		if heavyLimit.Queue, err = strconv.Atoi(queue); err != nil {
+			log.Fatalf("invalid HEAVY_QUEUE: %v", err)
+		}
+	}
+	payrollCSV := payroll.CSV{}
+	if mapping := os.Getenv("PAYROLL_CSV_COLUMNS"); mapping != "" {
+		if payrollCSV.Columns, err = payroll.ParseCSVColumns(mapping); err != nil {
+			log.Fatalf("invalid PAYROLL_CSV_COLUMNS: %v", err)
+		}
+	}
+	if separator := os.Getenv("PAYROLL_CSV_SEPARATOR"); separator != "" {
+		if len([]rune(separator)) != 1 {
+			log.Fatalf("invalid PAYROLL_CSV_SEPARATOR %q, expected a single character", separator)
+		}
+		payrollCSV.Comma = []rune(separator)[0]
+	}
+	payroll.Replace("csv", payrollCSV)
 	serv := service.NewEmployeeService(nrepo)
+	serv.UseHolidayProvider(holidays)
+	// The locations observing the holidays of another region than the deployment, as comma-separated
+	// location=region pairs such as "Strasbourg=alsace-moselle,Fort-de-France=martinique".
+	if pairs := os.Getenv("HOLIDAY_LOCATION_REGIONS"); pairs != "" {
+		regions := make(map[string]string)
+		for _, pair := range strings.Split(pairs, ",") {
+			location, region, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(location) == "" {
+				log.Fatalf("invalid HOLIDAY_LOCATION_REGIONS %q, expected location=region pairs", pairs)
+			}
+			regions[strings.TrimSpace(location)] = strings.TrimSpace(region)
+		}
+		if err := serv.UseLocationHolidayRegions(regions); err != nil {
+			log.Fatalf("invalid HOLIDAY_LOCATION_REGIONS: %v", err)
+		}
+	}
+	// The hours worked on public holidays are paid HOLIDAY_PAY_MULTIPLIER times the base rate, and the public
+	// holidays of HOLIDAY_PAID_IF_NOT_WORKED, comma-separated dates such as "05-01", are days off paid as if
+	// worked.
+	holidayPay := service.HolidayPay{WorkedMultiplier: 1}
+	if multiplier := os.Getenv("HOLIDAY_PAY_MULTIPLIER"); multiplier != "" {
+		if holidayPay.WorkedMultiplier, err = strconv.ParseFloat(multiplier, 64); err != nil {
+			log.Fatalf("invalid HOLIDAY_PAY_MULTIPLIER %q, expected a number such as 2", multiplier)
+		}
+	}
+	if dates := os.Getenv("HOLIDAY_PAID_IF_NOT_WORKED"); dates != "" {
+		for _, date := range strings.Split(dates, ",") {
+			holidayPay.PaidIfNotWorked = append(holidayPay.PaidIfNotWorked, strings.TrimSpace(date))
+		}
+	}
+	if err := serv.UseHolidayPay(holidayPay); err != nil {
+		log.Fatalf("invalid holiday pay: %v", err)
+	}
+	// The slots are worked in the IANA time zone TIME_ZONE, UTC by default, and at the locations of
+	// LOCATION_TIME_ZONES, comma-separated location=zone pairs such as "Fort-de-France=America/Martinique", in
+	// their own.
+	timeZone := time.UTC
+	if zone := os.Getenv("TIME_ZONE"); zone != "" {
+		if timeZone, err = time.LoadLocation(zone); err != nil {
+			log.Fatalf("invalid TIME_ZONE: %v", err)
+		}
+	}
+	var locationZones map[string]*time.Location
+	if pairs := os.Getenv("LOCATION_TIME_ZONES"); pairs != "" {
+		locationZones = make(map[string]*time.Location)
+		for _, pair := range strings.Split(pairs, ",") {
+			location, zone, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(location) == "" {
+				log.Fatalf("invalid LOCATION_TIME_ZONES %q, expected location=zone pairs", pairs)
+			}
+			if locationZones[strings.TrimSpace(location)], err = time.LoadLocation(strings.TrimSpace(zone)); err != nil {
+				log.Fatalf("invalid LOCATION_TIME_ZONES: %v", err)
+			}
+		}
+	}
+	serv.UseTimeZones(timeZone, locationZones)
+	if readOnly {
+		serv.UseReadOnly()
+	}
+	if os.Getenv("SCHEDULE_SNAPSHOTS") == "true" {
+		if readOnly {
+			serv.UseStoredScheduleSnapshots()
+		} else if err := serv.UseScheduleSnapshots(context.Background()); err != nil {
+			log.Fatalf("failed to build schedule snapshots: %v", err)
+		}
+	}
+	// The validators of the tenants without validation settings of their own, comma-separated.
+	var validators []string
+	for _, name := range strings.Split(os.Getenv("SCHEDULE_VALIDATORS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			validators = append(validators, name)
+		}
+	}
+	if err := serv.UseValidators(validators, os.Getenv("SCHEDULE_VALIDATOR_URL")); err != nil {
+		log.Fatalf("invalid SCHEDULE_VALIDATORS or SCHEDULE_VALIDATOR_URL: %v", err)
+	}
+	// Large exports are rendered by the workers of the print queue, on the primary only.
+	printsCtx, stopPrints := context.WithCancel(context.Background())
+	defer stopPrints()
+	if !readOnly {
+		printDir := os.Getenv("PRINT_STORAGE_DIR")
+		if printDir == "" {
+			printDir = filepath.Join(os.TempDir(), "api_server-prints")
+		}
+		store, err := storage.NewDir(printDir)
+		if err != nil {
+			log.Fatalf("invalid PRINT_STORAGE_DIR: %v", err)
+		}
+		queue := service.PrintQueue{Store: store, Workers: 2, Secret: []byte(jwtSecret), BaseURL: os.Getenv("PUBLIC_BASE_URL")}
+		if secret := os.Getenv("PRINT_LINK_SECRET"); secret != "" {
+			queue.Secret = []byte(secret)
+		}
+		if workers := os.Getenv("PRINT_WORKERS"); workers != "" {
+			if queue.Workers, err = strconv.Atoi(workers); err != nil {
+				log.Fatalf("invalid PRINT_WORKERS: %v", err)
+			}
+		}
+		if ttl := os.Getenv("PRINT_LINK_TTL"); ttl != "" {
+			if queue.LinkTTL, err = time.ParseDuration(ttl); err != nil {
+				log.Fatalf("invalid PRINT_LINK_TTL: %v", err)
+			}
+		}
+		serv.StartPrintQueue(printsCtx, queue)
+		// So are the webhook deliveries, sent by the workers of the webhook queue.
+		hooks := service.WebhookQueue{Workers: 2}
+		if workers := os.Getenv("WEBHOOK_WORKERS"); workers != "" {
+			if hooks.Workers, err = strconv.Atoi(workers); err != nil {
+				log.Fatalf("invalid WEBHOOK_WORKERS: %v", err)
+			}
+		}
+		serv.StartWebhookQueue(printsCtx, hooks)
+		// And the slots are synced to the Google Calendars of the employees with the OAuth client of the
+		// deployment and the refresh token of an account allowed to write their calendars.
+		if refreshToken := os.Getenv("GOOGLE_REFRESH_TOKEN"); refreshToken != "" {
+			credentials := gcal.Credentials{ClientID: os.Getenv("GOOGLE_CLIENT_ID"), ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"), RefreshToken: refreshToken}
+			calendars := service.CalendarSync{Client: gcal.NewClient(credentials, &http.Client{Timeout: 30 * time.Second})}
+			if zone := os.Getenv("GOOGLE_CALENDAR_TIME_ZONE"); zone != "" {
+				if calendars.TimeZone, err = time.LoadLocation(zone); err != nil {
+					log.Fatalf("invalid GOOGLE_CALENDAR_TIME_ZONE: %v", err)
+				}
+			}
+			if months := os.Getenv("GOOGLE_CALENDAR_MONTHS"); months != "" {
+				if calendars.Months, err = strconv.Atoi(months); err != nil {
+					log.Fatalf("invalid GOOGLE_CALENDAR_MONTHS: %v", err)
+				}
+			}
+			serv.StartCalendarSync(printsCtx, calendars)
+		}
+	}
+	// The changes notified by the database, made by any instance or by hand, are published on the event bus.
+	// A read-only instance cannot listen on its replica and needs CHANGE_FEED_DSN to listen on the primary.
+	// SQLite notifies nothing.
+	changeDSN := os.Getenv("CHANGE_FEED_DSN")
+	if changeDSN == "" && !readOnly && !*demo && driver != repo.DriverSQLite {
+		changeDSN = dsn
+	}
+	feedCtx, stopFeed := context.WithCancel(context.Background())
+	defer stopFeed()
+	if changeDSN != "" && os.Getenv("CHANGE_FEED") != "false" {
+		go repo.ListenChanges(feedCtx, changeDSN, serv.PublishDataChange)
+	}
+	if *demo {
+		if err := seedDemo(context.Background(), serv, authService); err != nil {
+			log.Fatalf("failed to seed the demo: %v", err)
+		}
+		log.Info("Starting in demo mode: sample data in memory, every request acts as user ", demoUsername)
+	}
+	// The event streams end when the server shuts down, rather than hold it until the shutdown timeout.
+	closing := make(chan struct{})
 	services := &lhttp.Service{
 		EmployeeService: serv,
+		Auth:            authService,
+		Health:          checks,
+		ReportTimeout:   reportTimeout,
+		HeavyLimit:      heavyLimit,
+		Timeouts:        timeouts,
+		ReadOnly:        readOnly,
+		HolidayRegion:   holidayRegion,
+		Holidays:        holidays,
+		Logs:            recentLogs,
+		Config:          support.Config(os.Getenv, configKeys...),
+		Closing:         closing,
+	}
+	if multiTenant {
+		services.Tenants = tenant.NewResolver(nrepo, os.Getenv("TENANT_BASE_DOMAIN"))
+		log.Info("Starting in multi-tenant mode, requests are resolved to a tenant by API key or subdomain")
+	}
+	// The roster of the day is posted to the Slack channel of the company every morning, by the primary, and
+	// on demand. The tenants of a multi-tenant deployment have no channel in common to post to.
+	if url := os.Getenv("SLACK_WEBHOOK_URL"); url != "" {
+		if multiTenant {
+			log.Warn("SLACK_WEBHOOK_URL is ignored in multi-tenant mode: the rosters of every tenant would be posted to one channel")
+		} else {
+			reminder := &slack.Reminder{Service: serv, URL: url, At: 7*time.Hour + 30*time.Minute, Client: &http.Client{Timeout: 10 * time.Second}}
+			if at := os.Getenv("SLACK_REMINDER_AT"); at != "" {
+				if reminder.At, err = slack.ParseAt(at); err != nil {
+					log.Fatalf("invalid SLACK_REMINDER_AT: %v", err)
+				}
+			}
+			services.Slack = reminder
+			if !readOnly {
+				go reminder.Run(printsCtx)
+			}
+		}
+	}
+	// The sibling services call the internal routes with one of these comma-separated tokens.
+	for _, token := range strings.Split(os.Getenv("INTERNAL_API_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			services.InternalTokens = append(services.InternalTokens, token)
+		}
+	}
+
+	// The operators migrate and drop the schema with one of these comma-separated tokens.
+	for _, token := range strings.Split(os.Getenv("ADMIN_API_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			services.AdminTokens = append(services.AdminTokens, token)
+		}
 	}
 
 	port := os.Getenv("PORT")
@@ -51,16 +442,72 @@ func main() {
 	}
 
 	r := lhttp.NewRouter(services)
+	if readOnly {
+		log.Info("Starting in read-only mode, every write is rejected")
+	}
 
 	// Middlewares
-	// r.Use(middleware.RequestID)
 	// r.Use(middleware.RealIP)
 	// r.Use(lmiddleware.LoggingMiddleware)
 	// r.Use(middleware.Recoverer)
 	// r.Use(lmiddleware.AuthMiddleware) // Custom Auth middleware
 
-	log.Info("Starting server on ", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal(err)
+	shutdownTimeout := 15 * time.Second
+	if timeout := os.Getenv("SHUTDOWN_TIMEOUT"); timeout != "" {
+		if shutdownTimeout, err = time.ParseDuration(timeout); err != nil {
+			log.Fatalf("invalid SHUTDOWN_TIMEOUT: %v", err)
+		}
+	}
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+	server.RegisterOnShutdown(func() { close(closing) })
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Info("Starting server on ", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Info("Shutting down, draining connections for up to ", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("graceful shutdown failed: %v", err)
+	}
+	// The jobs and deliveries interrupted are claimed again once they are stale.
+	stopPrints()
+	if err := sqlDB.Close(); err != nil {
+		log.Errorf("failed to close database connection: %v", err)
+	}
+	log.Info("Server stopped")
+}
+
+// createTenant creates a tenant and its first user, and prints the API key of the tenant.
+func createTenant(repository repo.Repository, authService *auth.Service, holidays holiday.Provider, name, subdomain, region string) {
+	username, password := os.Getenv("ADMIN_USERNAME"), os.Getenv("ADMIN_PASSWORD")
+	if username == "" {
+		log.Fatal("ADMIN_USERNAME and ADMIN_PASSWORD must be set to create the first user of the tenant")
+	}
+	ctx := context.Background()
+	if err := repository.DBCreate(ctx); err != nil {
+		log.Fatalf("failed to migrate the database: %v", err)
+	}
+	created, key, err := tenant.Create(ctx, repository, holidays, name, subdomain, region)
+	if err != nil {
+		log.Fatalf("failed to create tenant: %v", err)
+	}
+	if _, err := authService.CreateUser(repo.WithTenant(ctx, created.ID), username, password); err != nil {
+		log.Fatalf("failed to create the first user of the tenant: %v", err)
 	}
+	fmt.Printf("Created tenant %s (%s) with user %s.\nAPI key, shown only once: %s\n", created.Name, created.Subdomain, username, key)
 }
@@ -2,30 +2,47 @@ package model
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"gorm.io/gorm"
 	"time"
 )
 
-// CustomTime wraps time.Time for handling PostgreSQL 'time without time zone' fields.
+// CustomTime wraps time.Time for handling PostgreSQL 'time without time zone' fields, which SQLite stores as text.
 type CustomTime struct {
 	time.Time
 }
 
+// customTimeLayouts are the texts CustomTime scans: the times of PostgreSQL, those written by hand in SQLite,
+// and the timestamps SQLite returns for its datetime columns. Fractional seconds are accepted after the seconds.
+var customTimeLayouts = []string{"15:04:05", "15:04", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05",
+	time.RFC3339Nano}
+
 // Scan implements the sql.Scanner interface for CustomTime,
 // allowing custom parsing of time data from the database.
 func (ct *CustomTime) Scan(value interface{}) error {
-	var err error
 	switch v := value.(type) {
 	case []byte:
-		ct.Time, err = time.Parse("15:04:05", string(v))
+		return ct.parse(string(v))
 	case string:
-		ct.Time, err = time.Parse("15:04:05", v)
+		return ct.parse(v)
 	case time.Time:
 		ct.Time = v
 	default:
 		return fmt.Errorf("cannot scan type %T into CustomTime", value)
 	}
-	return err
+	return nil
+}
+
+// parse reads s in the first of customTimeLayouts it follows.
+func (ct *CustomTime) parse(s string) error {
+	for _, layout := range customTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			ct.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as a time of day", s)
 }
 
 // Value implements the driver.Valuer interface for CustomTime,
@@ -34,30 +51,290 @@ func (ct CustomTime) Value() (driver.Value, error) {
 	return ct.Format("15:04:05"), nil
 }
 
+// MarshalJSON renders CustomTime as "HH:MM", the format used by the JSON inputs.
+func (ct CustomTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ct.Format("15:04"))
+}
+
+// UnmarshalJSON parses CustomTime from either "HH:MM" or "HH:MM:SS".
+func (ct *CustomTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		if t, err = time.Parse("15:04:05", s); err != nil {
+			return fmt.Errorf("invalid time %q, expected HH:MM", s)
+		}
+	}
+	ct.Time = t
+	return nil
+}
+
 // Employee represents an employee record in the database and the JSON structure.
 type Employee struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"not null;default:0;index" json:"-"`
+	UUID      string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
 	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
 	StartDate time.Time `gorm:"type:date;not null" json:"startDate"`
+	// EndDate is the last day worked by a deactivated employee; its slots stop generating after that day.
+	EndDate *time.Time `gorm:"type:date" json:"endDate,omitempty"`
+	// Department groups employees in capacity reports.
+	Department string `gorm:"type:varchar(100);not null;default:''" json:"department"`
+	// ContractWeeklyHours is the number of hours per week the employee is contracted for.
+	ContractWeeklyHours float64 `gorm:"not null;default:0" json:"contractWeeklyHours"`
+	// HourlyRate is the gross pay of one hour of work, in euros, used by the payroll exports.
+	HourlyRate float64 `gorm:"not null;default:0" json:"hourlyRate"`
+	// Email is where the notifications of the employee are sent, and Phone how the shop reaches them; both may
+	// be empty.
+	Email string `gorm:"type:varchar(254);not null;default:''" json:"email"`
+	Phone string `gorm:"type:varchar(30);not null;default:''" json:"phone"`
+	// Role is the job title shown on the rosters, such as optician; it is neither the role template the
+	// employee inherits slots from nor the role of a user of the API.
+	Role string `gorm:"type:varchar(100);not null;default:''" json:"role"`
+	// Color is the color of the employee in calendar UIs, written #RRGGBB; empty leaves the choice to them.
+	Color string `gorm:"type:varchar(7);not null;default:''" json:"color"`
 	// GORM automatically interprets the Schedules slice as a one-to-many relationship based on the foreign key.
 	Schedules []Schedule `gorm:"foreignKey:EmployeeID" json:"schedules,omitempty"`
+	// RoleTemplateID links the employee to the role template whose slots it inherits, if any.
+	RoleTemplateID *uint           `gorm:"index" json:"roleTemplateId,omitempty"`
+	RoleTemplate   *RoleTemplate   `json:"roleTemplate,omitempty"`
+	Deltas         []ScheduleDelta `gorm:"foreignKey:EmployeeID" json:"deltas,omitempty"`
+	// RotationPatternID is the rotation the weeks of the employee's slots follow; nil means the A/B rotation.
+	RotationPatternID *uint `gorm:"index" json:"rotationPatternId,omitempty"`
+	// RotationAnchor is a date of the first week of the employee's rotation; nil means the start date.
+	RotationAnchor *time.Time `gorm:"type:date" json:"rotationAnchor,omitempty"`
+	// ScheduleVersions are the patterns the slots replaced from an effective date, oldest first; they are loaded
+	// with the calendar of the employee, see SchedulesOn.
+	ScheduleVersions []ScheduleVersion `gorm:"foreignKey:EmployeeID" json:"-"`
+	// Overrides and LeaveDays are only loaded for the team roster, restricted to its month.
+	Overrides []ScheduleOverride `gorm:"foreignKey:EmployeeID" json:"-"`
+	LeaveDays []EmployeeHoliday  `gorm:"foreignKey:EmployeeID" json:"-"`
+	// CreatedAt and UpdatedAt are maintained by gorm; removing one of the employee's slots also bumps UpdatedAt.
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	// DeletedAt is set when the employee is archived. Archived employees are hidden from every query but
+	// the archive listing and the delta-sync feed, and can be restored.
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt"`
+	// ScheduleSnapshot is a denormalized copy of the resolved calendar, nil until first built.
+	ScheduleSnapshot *ScheduleSnapshot `gorm:"type:jsonb" json:"-"`
+	// Unscheduled is set in listings on the employees pending a template, see PendingTemplate.
+	Unscheduled bool `gorm:"-" json:"unscheduled"`
+}
+
+// EndedBefore reports whether the employee was deactivated with an end date earlier than date.
+func (e *Employee) EndedBefore(date time.Time) bool {
+	return e.EndDate != nil && e.EndDate.Format("2006-01-02") < date.Format("2006-01-02")
+}
+
+// PendingTemplate reports whether the employee, loaded with its schedules, has neither slots of its own nor a
+// role template yet: it was created before its schedules were imported and has nothing planned.
+func (e *Employee) PendingTemplate() bool {
+	return e.RoleTemplateID == nil && len(e.Schedules) == 0
+}
+
+// SchedulesOn returns the resolved slots in force on date: those of the schedule version covering it, else the
+// current Schedules.
+func (e *Employee) SchedulesOn(date time.Time) []Schedule {
+	day := date.Format("2006-01-02")
+	for _, version := range e.ScheduleVersions {
+		if day < version.EffectiveUntil.Format("2006-01-02") {
+			if version.Pattern == nil {
+				return nil
+			}
+			return version.Pattern.Schedules
+		}
+	}
+	return e.Schedules
+}
+
+// SchedulesFrom returns the date the current Schedules are in force from: the end of the last schedule
+// version, else the start date.
+func (e *Employee) SchedulesFrom() time.Time {
+	if n := len(e.ScheduleVersions); n > 0 {
+		return e.ScheduleVersions[n-1].EffectiveUntil
+	}
+	return e.StartDate
+}
+
+// RotationStart returns the date the rotation of the employee is anchored on: the week of that date is the
+// first week of the cycle. It is the employee's own anchor, else the anchor of the rotation calendar of the
+// tenant that rotation carries, else the employee's start date.
+func (e *Employee) RotationStart(rotation *RotationPattern) time.Time {
+	return rotation.startOf(e.RotationAnchor, e.StartDate)
+}
+
+// ScheduleSnapshot is the resolved A/B calendar of an employee (own slots, inherited slots and deltas) and the
+// last modification it reflects, stored as JSON next to the employee so that calendar reads load one row.
+type ScheduleSnapshot struct {
+	LastModified time.Time  `json:"lastModified"`
+	Schedules    []Schedule `json:"schedules"`
+}
+
+// Scan implements the sql.Scanner interface for ScheduleSnapshot.
+func (s *ScheduleSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan type %T into ScheduleSnapshot", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for ScheduleSnapshot.
+func (s ScheduleSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
 }
 
 // Schedule represents the schedule of an employee, aligning with the schedules table.
 type Schedule struct {
 	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"not null;default:0;index" json:"-"`
+	UUID       string     `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
 	EmployeeID uint       `gorm:"not null" json:"employeeId"`
-	WeekType   string     `gorm:"type:char(1);not null" json:"weekType"`
+	WeekType   string     `gorm:"type:varchar(8);not null" json:"weekType"`
 	DayName    string     `gorm:"type:varchar(10);not null" json:"dayName"`
-	StartTime  CustomTime `gorm:"type:time without time zone;not null"` // Custom handling
-	EndTime    CustomTime `gorm:"type:time without time zone;not null"` // Custom handling
+	StartTime  CustomTime `gorm:"type:time without time zone;not null" json:"startTime"` // Custom handling
+	EndTime    CustomTime `gorm:"type:time without time zone;not null" json:"endTime"`   // Custom handling
+	// CrossesMidnight is set on the overnight slots, ending before they start: the slot is worked from StartTime
+	// on its day until EndTime on the next day. It is derived from the times whenever the slot is saved.
+	CrossesMidnight bool `gorm:"not null;default:false" json:"crossesMidnight"`
+	// Location is the store the slot is worked at; empty means the employee's home location.
+	Location string `gorm:"type:varchar(100);not null;default:''" json:"location"`
+	// Task is the station the employee is assigned to during the slot (cash desk, lab, floor...).
+	Task string `gorm:"type:varchar(50);not null;default:''" json:"task"`
+	// Source is how the slot came to exist, one of Sources; the slots stored before it was recorded count as
+	// imported. ChangedByID is the user who created or last changed the slot, nil when unknown.
+	Source      string    `gorm:"type:varchar(20);not null;default:'import';index" json:"source"`
+	ChangedByID *uint     `json:"changedById,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// BeforeSave records whether the slot crosses midnight.
+func (s *Schedule) BeforeSave(tx *gorm.DB) error {
+	s.CrossesMidnight = CrossesMidnight(s.StartTime, s.EndTime)
+	return nil
+}
+
+// End returns the end of the slot on the clock of StartTime, the day after for a slot crossing midnight, so
+// that it compares with the start and the ends of the other slots of the day.
+func (s Schedule) End() time.Time {
+	return slotEnd(s.StartTime, s.EndTime)
+}
+
+// CrossesMidnight reports whether a slot from start to end is worked overnight, ending the next day.
+func CrossesMidnight(start, end CustomTime) bool {
+	return end.Before(start.Time)
+}
+
+// slotEnd returns end on the clock of start, moved to the next day when the slot crosses midnight.
+func slotEnd(start, end CustomTime) time.Time {
+	if CrossesMidnight(start, end) {
+		return end.AddDate(0, 0, 1)
+	}
+	return end.Time
+}
+
+// ScheduleVersion is a recurring pattern of an employee replaced by another from an effective date: its
+// resolved slots, in force from EffectiveFrom until the day before EffectiveUntil. The versions of an
+// employee follow each other, and its current slots are in force from the EffectiveUntil of the last one.
+type ScheduleVersion struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	TenantID       uint      `gorm:"not null;default:0;index" json:"-"`
+	EmployeeID     uint      `gorm:"not null;index" json:"employeeId"`
+	EffectiveFrom  time.Time `gorm:"type:date;not null" json:"effectiveFrom"`
+	EffectiveUntil time.Time `gorm:"type:date;not null" json:"effectiveUntil"`
+	// Pattern holds the slots of the version as they were resolved when it was replaced.
+	Pattern *ScheduleSnapshot `gorm:"type:jsonb;not null" json:"pattern"`
+	// ChangedByID is the user who replaced the version, nil when unknown.
+	ChangedByID *uint     `json:"changedById,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// Slot sources, recording how a slot came to exist.
+const (
+	// SourceImport slots were loaded from an employee import.
+	SourceImport = "import"
+	// SourceManual slots were created or last edited one by one through the API.
+	SourceManual = "manual"
+	// SourceTemplate slots are inherited from a role template, or were copied from it when the employee was
+	// detached from the template.
+	SourceTemplate = "template"
+	// SourceOverride slots replace the recurring slots of an employee on one date.
+	SourceOverride = "override"
+)
+
+// Sources lists the slot sources.
+var Sources = []string{SourceImport, SourceManual, SourceTemplate, SourceOverride}
+
+// Kinds of stored slots listed by the audit API.
+const (
+	SlotKindSchedule = "schedule"
+	SlotKindOverride = "override"
+	SlotKindDelta    = "delta"
+)
+
+// SlotProvenance tells how a stored slot came to exist and who last changed it: a recurring slot of an
+// employee, the override of a date (with all its slots) or a deviation from a role template.
+type SlotProvenance struct {
+	Kind       string `json:"kind"`
+	ID         uint   `json:"id"`
+	UUID       string `json:"uuid"`
+	EmployeeID uint   `json:"employeeId"`
+	// WeekType and DayName locate recurring slots and deltas, Date overrides.
+	WeekType    string     `json:"weekType,omitempty"`
+	DayName     string     `json:"dayName,omitempty"`
+	Date        string     `json:"date,omitempty"`
+	Action      string     `json:"action,omitempty"`
+	Off         bool       `json:"off,omitempty"`
+	TimeSlots   []TimeSlot `json:"timeSlots"`
+	Source      string     `json:"source"`
+	ChangedByID *uint      `json:"changedById,omitempty"`
+	// ChangedBy is the username of the user ChangedByID.
+	ChangedBy string    `json:"changedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ProvenanceFilter selects the slots listed by the audit API; zero fields do not filter.
+type ProvenanceFilter struct {
+	EmployeeID  uint
+	Source      string
+	ChangedByID *uint
+	// Since keeps the slots changed at or after it.
+	Since time.Time
+}
+
+// Tenant is a company served by a multi-tenant deployment. Every table but the public holidays, shared by all
+// companies, carries the ID of the tenant a row belongs to; a single-tenant deployment leaves it at 0.
+// Requests are attributed to a tenant by their API key or by the subdomain they are sent to.
+type Tenant struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UUID      string `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Name      string `gorm:"type:varchar(255);not null" json:"name"`
+	Subdomain string `gorm:"type:varchar(63);not null;uniqueIndex" json:"subdomain"`
+	// APIKeyHash is the SHA-256 hash, hex encoded, of the API key of the tenant; the key itself is not stored.
+	APIKeyHash string `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	// HolidayRegion is the holiday region of the stores of the tenant, which requests may override.
+	HolidayRegion string    `gorm:"type:varchar(40);not null;default:'metropole'" json:"holidayRegion"`
+	CreatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
 }
 
 // JSON model
 
 type ScheduleInput struct {
-	Start string `json:"start"`
-	End   string `json:"end"`
+	Start    string `json:"start" validate:"required,time"`
+	End      string `json:"end" validate:"required,time"`
+	Location string `json:"location,omitempty" validate:"max=100"`
+	Task     string `json:"task,omitempty" validate:"max=50"`
 }
 
 type WeeklyScheduleInput struct {
@@ -70,38 +347,732 @@ type WeeklyScheduleInput struct {
 	Sunday    []ScheduleInput `json:"Sunday"`
 }
 
+// EmployeeInput is an employee of the /loadEmployees format. The validate tags are checked by the handlers
+// before the import runs; the week keys are checked against the rotation of the employee by the import.
 type EmployeeInput struct {
-	Name      string                         `json:"name"`
-	StartDate string                         `json:"startDate"`
-	Weeks     map[string]WeeklyScheduleInput `json:"weeks"`
+	Name                string                         `json:"name" validate:"required,max=255"`
+	RoleTemplate        string                         `json:"roleTemplate,omitempty"`
+	StartDate           string                         `json:"startDate" validate:"required,date"`
+	Department          string                         `json:"department,omitempty" validate:"max=100"`
+	ContractWeeklyHours float64                        `json:"contractWeeklyHours,omitempty" validate:"min=0,max=168"`
+	HourlyRate          float64                        `json:"hourlyRate,omitempty" validate:"min=0"`
+	Email               string                         `json:"email,omitempty" validate:"email"`
+	Phone               string                         `json:"phone,omitempty" validate:"phone"`
+	Role                string                         `json:"role,omitempty" validate:"max=100"`
+	Color               string                         `json:"color,omitempty" validate:"color"`
+	Weeks               map[string]WeeklyScheduleInput `json:"weeks" validate:"keys,required,alphanum,max=8,endkeys"`
+	// EndDate (YYYY-MM-DD) is the last day worked, see Employee.EndDate; empty keeps the end date of an employee
+	// replaced, if any.
+	EndDate string `json:"endDate,omitempty" validate:"date"`
+	// Rotation is the name of the rotation pattern the weeks follow; empty means the A/B rotation.
+	Rotation string `json:"rotation,omitempty"`
+	// RotationAnchor (YYYY-MM-DD) is a date of the first week of the rotation; empty means StartDate.
+	RotationAnchor string `json:"rotationAnchor,omitempty" validate:"date"`
+	// LinkTo is the ID of an existing employee the record is the same person as, such as a match candidate
+	// of a dry run: the record replaces the attributes and own schedules of that employee, whose name and
+	// start date are kept, instead of creating another employee.
+	LinkTo uint `json:"linkTo,omitempty"`
 }
 
 type EmployeesInput []EmployeeInput
 
+// EmployeeImport records an employee import payload that was loaded, identified by the SHA-256 hash of its
+// normalized JSON, so that replaying the same payload does not create the employees twice.
+type EmployeeImport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"not null;default:0;uniqueIndex:idx_employee_import_tenant_hash" json:"-"`
+	Hash      string    `gorm:"type:char(64);not null;uniqueIndex:idx_employee_import_tenant_hash" json:"hash"`
+	Employees int       `gorm:"not null" json:"employees"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	// Changes are the employees the import created or replaced, which its rollback reverses.
+	Changes []EmployeeImportChange `gorm:"foreignKey:ImportID" json:"changes,omitempty"`
+}
+
+// Actions of an import on an employee.
+const (
+	ImportCreated  = "created"
+	ImportReplaced = "replaced"
+)
+
+// EmployeeImportChange is an employee created or replaced by an import. A replaced employee keeps in Previous
+// the state the import overwrote.
+type EmployeeImportChange struct {
+	ID         uint           `gorm:"primaryKey" json:"-"`
+	TenantID   uint           `gorm:"not null;default:0;index" json:"-"`
+	ImportID   uint           `gorm:"not null;index" json:"-"`
+	EmployeeID uint           `gorm:"not null;index" json:"employeeId"`
+	Action     string         `gorm:"type:varchar(10);not null" json:"action"`
+	Previous   *EmployeeState `gorm:"type:jsonb" json:"-"`
+}
+
+// EmployeeState is what an import overwrites on the employees it replaces: their attributes and own slots.
+type EmployeeState struct {
+	Department          string     `json:"department"`
+	ContractWeeklyHours float64    `json:"contractWeeklyHours"`
+	HourlyRate          float64    `json:"hourlyRate"`
+	Email               string     `json:"email"`
+	Phone               string     `json:"phone"`
+	Role                string     `json:"role"`
+	Color               string     `json:"color"`
+	RoleTemplateID      *uint      `json:"roleTemplateId,omitempty"`
+	RotationPatternID   *uint      `json:"rotationPatternId,omitempty"`
+	RotationAnchor      *time.Time `json:"rotationAnchor,omitempty"`
+	EndDate             *time.Time `json:"endDate,omitempty"`
+	Schedules           []Schedule `json:"schedules"`
+}
+
+// Scan implements the sql.Scanner interface for EmployeeState.
+func (s *EmployeeState) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan type %T into EmployeeState", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for EmployeeState.
+func (s EmployeeState) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 // MonthltSchedule wraps a list of ScheduleEntry items for a single employee.
 type MonthlySchedule struct {
-	Date        string     `json:"date"`
-	DayName     string     `json:"dayName"`
-	HolidayName string     `json:"holiday_name"`
+	Date        string `json:"date"`
+	DayName     string `json:"dayName"`
+	HolidayName string `json:"holiday_name"`
+	// HolidaySource tells where HolidayName comes from, HolidayPublic or HolidayCompany.
+	HolidaySource string `json:"holiday_source,omitempty"`
+	// Closed is set on the closure days clearing the slots of the day, see ClosureDay.
+	Closed bool `json:"closed,omitempty"`
+	// Leave is set on the days the employee is on personal leave; the slots of those days are not worked.
+	Leave *Leave `json:"leave,omitempty"`
+	// Overridden is set on the days whose slots come from a schedule override instead of the A/B weeks.
+	Overridden bool `json:"overridden,omitempty"`
+	// Unscheduled is set on every day of the calendar of an employee pending a template: the day is empty
+	// because no schedule was imported yet, not because the employee is off.
+	Unscheduled bool       `json:"unscheduled,omitempty"`
 	TimeSlots   []TimeSlot `json:"timeSlots"`
 }
 
-// TimeSlot represents a single working period within a day.
+// PublicHoliday reports whether the day is a public holiday, whose hours are paid at a premium; the closure
+// days of the company are not.
+func (m MonthlySchedule) PublicHoliday() bool {
+	return m.HolidayName != "" && m.HolidaySource != HolidayCompany
+}
+
+// Leave describes a day of personal leave in a monthly calendar.
+type Leave struct {
+	ID          uint   `json:"id"`
+	Description string `json:"description,omitempty"`
+	WithoutPay  bool   `json:"withoutPay"`
+}
+
+// TimeSlot represents a single working period within a day. The calendars split the overnight slots at
+// midnight: the day a slot starts lists it until "00:00" with CrossesMidnight set, and the next day lists the
+// rest from "00:00" with Continued set, so that the hours of each part count on their own day.
 type TimeSlot struct {
-	Start string `json:"start"`
-	End   string `json:"end"`
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	Location        string `json:"location,omitempty"`
+	Task            string `json:"task,omitempty"`
+	CrossesMidnight bool   `json:"crossesMidnight,omitempty"`
+	Continued       bool   `json:"continued,omitempty"`
+}
+
+// Clock parses the start and end of the slot as times of day, the end of a slot ending at midnight, or
+// later on the next day, falling on the day after the start.
+func (t TimeSlot) Clock() (start, end time.Time, err error) {
+	if start, err = time.Parse("15:04", t.Start); err != nil {
+		return start, end, err
+	}
+	if end, err = time.Parse("15:04", t.End); err != nil {
+		return start, end, err
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
 }
 
-// Holiday represents a holiday record in the french_holidays table
+// Holiday represents a holiday record in the french_holidays table. Public holidays are the same for every
+// company of a region, so the table is shared by all tenants.
 type Holiday struct {
+	// Region is the holiday region the holiday is observed in, as named by the holiday provider, such as one of
+	// HolidayRegions.
+	Region      string    `gorm:"type:varchar(40);primary_key;default:'metropole'" json:"region"`
 	HolidayDate time.Time `gorm:"primary_key" json:"holiday_date"`
 	HolidayName string    `json:"holiday_name"`
 }
 
+// Sources of the holidays marked in the calendars: the public holidays of the holiday provider, and the
+// closure days of the company.
+const (
+	HolidayPublic  = "public"
+	HolidayCompany = "company"
+)
+
+// ClosureDay is a day the company closes on top of the public holidays, such as an inventory day or the
+// company party, marked in the calendars like a public holiday. The slots of the day are still worked unless
+// ClearSlots is set.
+type ClosureDay struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TenantID   uint      `gorm:"not null;default:0;index" json:"-"`
+	UUID       string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Date       time.Time `gorm:"type:date;not null;index" json:"date"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	ClearSlots bool      `gorm:"not null;default:false" json:"clearSlots"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// ClosureDayInput declares a closure day on Date, written YYYY-MM-DD.
+type ClosureDayInput struct {
+	Date       string `json:"date"`
+	Name       string `json:"name"`
+	ClearSlots bool   `json:"clearSlots"`
+}
+
+// RegionMetropole is the holiday region of mainland France, the default one.
+const RegionMetropole = "metropole"
+
+// HolidayRegions lists the holiday regions: mainland France, Alsace-Moselle with its two extra public
+// holidays, and the overseas departments and collectivities, named as by the public holiday API.
+var HolidayRegions = []string{
+	RegionMetropole, "alsace-moselle", "guadeloupe", "guyane", "la-reunion", "martinique", "mayotte",
+	"nouvelle-caledonie", "polynesie-francaise", "saint-barthelemy", "saint-martin", "saint-pierre-et-miquelon",
+	"wallis-et-futuna",
+}
+
+// IsHolidayRegion reports whether region is one of HolidayRegions.
+func IsHolidayRegion(region string) bool {
+	for _, known := range HolidayRegions {
+		if region == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Leave statuses. Leave is requested pending and only counts once approved.
+const (
+	LeavePending  = "pending"
+	LeaveApproved = "approved"
+	LeaveRejected = "rejected"
+)
+
+// EmployeeHoliday is a day of personal leave of an employee. Once approved, its slots still appear in the
+// monthly calendar but its hours are not counted.
 type EmployeeHoliday struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
-	EmployeeID  uint      `gorm:"not null;index" json:"employeeId"`
-	HolidayDate time.Time `gorm:"type:date;not null" json:"holidayDate"`
+	TenantID    uint      `gorm:"not null;default:0;index" json:"-"`
+	UUID        string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	EmployeeID  uint      `gorm:"not null;index:idx_employee_holiday_date" json:"employeeId"`
+	HolidayDate time.Time `gorm:"type:date;not null;index:idx_employee_holiday_date" json:"holidayDate"`
 	Description string    `gorm:"type:varchar(255)" json:"description"`     // Optional description of the holiday
 	WithoutPay  bool      `gorm:"not null;default:false" json:"withoutPay"` // Indicates if the holiday is without pay
+	// RequestID is the ID of the first day of the leave request the day belongs to; the days of a request
+	// are approved or rejected together.
+	RequestID uint   `gorm:"not null;default:0;index" json:"requestId"`
+	Status    string `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	// ApproverID is the user who approved or rejected the request.
+	ApproverID *uint      `json:"approverId,omitempty"`
+	DecidedAt  *time.Time `json:"decidedAt,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// LeaveInput is a request for personal leave from From to To included, both written YYYY-MM-DD. To defaults
+// to From.
+type LeaveInput struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Description string `json:"description"`
+	WithoutPay  bool   `json:"withoutPay"`
+}
+
+// Unavailability is a time an employee declared it cannot work. Unlike leave it does not take the employee
+// off: the slots colliding with it are reported as conflicts, and no slot is suggested to the employee
+// during it. It recurs every DayName between ValidFrom and ValidTo, both optional, or, without DayName,
+// covers the days from ValidFrom to ValidTo. Without StartTime and EndTime it lasts the whole day.
+type Unavailability struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	TenantID   uint        `gorm:"not null;default:0;index" json:"-"`
+	UUID       string      `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	EmployeeID uint        `gorm:"not null;index" json:"employeeId"`
+	DayName    string      `gorm:"type:varchar(10);not null;default:''" json:"dayName,omitempty"`
+	ValidFrom  *time.Time  `gorm:"type:date" json:"from,omitempty"`
+	ValidTo    *time.Time  `gorm:"type:date" json:"to,omitempty"`
+	StartTime  *CustomTime `gorm:"type:time without time zone" json:"startTime,omitempty"`
+	EndTime    *CustomTime `gorm:"type:time without time zone" json:"endTime,omitempty"`
+	Reason     string      `gorm:"type:varchar(255);not null;default:''" json:"reason"`
+	CreatedAt  time.Time   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time   `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// UnavailabilityInput declares an unavailability: every DayName, from From and until To if given, or without
+// DayName on the days from From to To included, To defaulting to From. Dates are written YYYY-MM-DD, and Start
+// and End, both or neither, HH:MM.
+type UnavailabilityInput struct {
+	DayName string `json:"dayName"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Reason  string `json:"reason"`
+}
+
+// DeactivationInput deactivates several employees at once: EndDate (YYYY-MM-DD) is the last day they work.
+type DeactivationInput struct {
+	EmployeeIDs []uint `json:"employeeIds"`
+	EndDate     string `json:"endDate"`
+}
+
+// User is an account allowed to call the API. Only a hash of the password is stored, argon2id or, for
+// accounts that have not logged in since argon2id was introduced, bcrypt.
+type User struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	TenantID     uint   `gorm:"not null;default:0;uniqueIndex:idx_user_tenant_username" json:"-"`
+	Username     string `gorm:"type:varchar(100);not null;uniqueIndex:idx_user_tenant_username" json:"username"`
+	PasswordHash string `gorm:"type:varchar(255);not null" json:"-"`
+}
+
+// DemandForecast is the staffing demand, in full-time equivalents, forecast for a department during
+// the week starting on WeekStart (a Monday).
+type DemandForecast struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    uint      `gorm:"not null;default:0;uniqueIndex:idx_forecast_tenant_department_week" json:"-"`
+	Department  string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_forecast_tenant_department_week" json:"department"`
+	WeekStart   time.Time `gorm:"type:date;not null;uniqueIndex:idx_forecast_tenant_department_week" json:"weekStart"`
+	RequiredFTE float64   `gorm:"not null" json:"requiredFte"`
+}
+
+// DailyRevenue is the revenue of the store on one date, imported from the till for the KPI report.
+type DailyRevenue struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	TenantID uint      `gorm:"not null;default:0;uniqueIndex:idx_revenue_tenant_date" json:"-"`
+	Date     time.Time `gorm:"type:date;not null;uniqueIndex:idx_revenue_tenant_date" json:"date"`
+	Amount   float64   `gorm:"not null" json:"amount"`
+}
+
+// EmployeeWeekTypeHours is the total of scheduled hours of one employee for one week type,
+// as aggregated by the database.
+type EmployeeWeekTypeHours struct {
+	EmployeeID        uint
+	Department        string
+	StartDate         time.Time
+	EndDate           *time.Time
+	RotationPatternID *uint
+	RotationAnchor    *time.Time
+	WeekType          string
+	Hours             float64
+}
+
+// RotationStart returns the date the rotation of the employee is anchored on, see Employee.RotationStart.
+func (h *EmployeeWeekTypeHours) RotationStart(rotation *RotationPattern) time.Time {
+	return rotation.startOf(h.RotationAnchor, h.StartDate)
+}
+
+// RotationPattern is a cycle of named weeks the recurring slots of employees follow: the first week of the
+// cycle is the week of the employee's start date, the next one the following week, and so on until the
+// cycle starts over. Employees without a pattern follow DefaultRotation.
+type RotationPattern struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UUID     string `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	TenantID uint   `gorm:"not null;default:0;uniqueIndex:idx_rotation_pattern_tenant_name" json:"-"`
+	Name     string `gorm:"type:varchar(100);not null;uniqueIndex:idx_rotation_pattern_tenant_name" json:"name"`
+	// CycleLength is the number of weeks in the cycle, one per entry of Weeks.
+	CycleLength int            `gorm:"not null" json:"cycleLength"`
+	Weeks       []RotationWeek `gorm:"foreignKey:RotationPatternID" json:"weeks"`
+	CreatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	// Anchor is the anchor of the rotation calendar of the tenant, set on the patterns resolved for an
+	// employee; nil when the tenant has none.
+	Anchor *time.Time `gorm:"-" json:"-"`
+}
+
+// startOf returns the anchor of a rotation following p, see Employee.RotationStart. A nil p has no calendar.
+func (p *RotationPattern) startOf(own *time.Time, startDate time.Time) time.Time {
+	switch {
+	case own != nil:
+		return *own
+	case p != nil && p.Anchor != nil:
+		return *p.Anchor
+	}
+	return startDate
+}
+
+// RotationCalendar is the canonical mapping of the calendar weeks of a tenant to the weeks of rotation: the
+// week of Anchor is the first week of every cycle, "week A starts on". The employees with an anchor of their
+// own keep it; without a calendar, the cycle of each employee starts on the week of its start date.
+type RotationCalendar struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	TenantID uint `gorm:"not null;default:0;uniqueIndex" json:"-"`
+	// Anchor is the Monday of a week that is the first week of the cycles.
+	Anchor    time.Time `gorm:"type:date;not null" json:"anchor"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// ValidationSettings are the validators checking the schedules written for a tenant, on top of the checks
+// of the API: the names of validators compiled into the server and the URL of an external one. A tenant
+// without settings gets the validators of the deployment.
+type ValidationSettings struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	TenantID   uint      `gorm:"not null;default:0;uniqueIndex" json:"-"`
+	Validators []string  `gorm:"type:text;serializer:json" json:"validators"`
+	URL        string    `gorm:"type:varchar(2048);not null;default:''" json:"url,omitempty"`
+	UpdatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// CalendarLink is the Google Calendar the slots of an employee are written to, with the outcome of the last
+// sync of the calendar.
+type CalendarLink struct {
+	ID         uint   `gorm:"primaryKey" json:"-"`
+	TenantID   uint   `gorm:"not null;default:0;index" json:"-"`
+	EmployeeID uint   `gorm:"not null;uniqueIndex" json:"employeeId"`
+	CalendarID string `gorm:"type:varchar(1024);not null" json:"calendarId"`
+	// SyncedAt is the end of the last sync that succeeded; LastError is the error of the last sync, empty
+	// once one succeeds.
+	SyncedAt  *time.Time `json:"syncedAt,omitempty"`
+	LastError string     `gorm:"type:text;not null;default:''" json:"lastError,omitempty"`
+	CreatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// RotationWeek is one week of a rotation pattern, named as in the week type of the slots worked that week.
+type RotationWeek struct {
+	ID                uint   `gorm:"primaryKey" json:"-"`
+	TenantID          uint   `gorm:"not null;default:0;index" json:"-"`
+	RotationPatternID uint   `gorm:"not null;uniqueIndex:idx_rotation_week_position" json:"-"`
+	Position          int    `gorm:"not null;uniqueIndex:idx_rotation_week_position" json:"position"`
+	Name              string `gorm:"type:varchar(8);not null" json:"name"`
+}
+
+// DefaultRotationName is the name of the rotation pattern seeded for the historical Week A/Week B cycle.
+const DefaultRotationName = "A/B"
+
+// DefaultRotation is the two-week A/B cycle followed by employees without a rotation pattern.
+var DefaultRotation = RotationPattern{Name: DefaultRotationName, CycleLength: 2, Weeks: []RotationWeek{{Position: 0, Name: "A"}, {Position: 1, Name: "B"}}}
+
+// WeekNames returns the names of the weeks of the pattern in cycle order.
+func (p *RotationPattern) WeekNames() []string {
+	names := make([]string, len(p.Weeks))
+	for i, week := range p.Weeks {
+		names[i] = week.Name
+	}
+	return names
+}
+
+// HasWeek reports whether name is one of the weeks of the pattern.
+func (p *RotationPattern) HasWeek(name string) bool {
+	for _, week := range p.Weeks {
+		if week.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleTemplate is a default A/B weekly pattern shared by the employees of a role (e.g. "weekend seller").
+type RoleTemplate struct {
+	ID       uint               `gorm:"primaryKey" json:"id"`
+	UUID     string             `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	TenantID uint               `gorm:"not null;default:0;uniqueIndex:idx_role_template_tenant_name" json:"-"`
+	Name     string             `gorm:"type:varchar(100);not null;uniqueIndex:idx_role_template_tenant_name" json:"name"`
+	Slots    []RoleTemplateSlot `gorm:"foreignKey:RoleTemplateID" json:"slots"`
+	// CreatedAt and UpdatedAt are maintained by gorm; replacing the slots also bumps UpdatedAt.
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// RoleTemplateSlot is one recurring time slot of a role template.
+type RoleTemplateSlot struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	TenantID       uint       `gorm:"not null;default:0;index" json:"-"`
+	RoleTemplateID uint       `gorm:"not null;index" json:"roleTemplateId"`
+	WeekType       string     `gorm:"type:varchar(8);not null" json:"weekType"`
+	DayName        string     `gorm:"type:varchar(10);not null" json:"dayName"`
+	StartTime      CustomTime `gorm:"type:time without time zone;not null" json:"startTime"`
+	EndTime        CustomTime `gorm:"type:time without time zone;not null" json:"endTime"`
+	Location       string     `gorm:"type:varchar(100);not null;default:''" json:"location"`
+	Task           string     `gorm:"type:varchar(50);not null;default:''" json:"task"`
+	CreatedAt      time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// ScheduleOverride replaces the recurring A/B slots of an employee on one date: by its own slots, or by none
+// when Off (a one-off closure, a shift covered by someone else).
+type ScheduleOverride struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	TenantID   uint                   `gorm:"not null;default:0;index" json:"-"`
+	UUID       string                 `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	EmployeeID uint                   `gorm:"not null;uniqueIndex:idx_schedule_override_date" json:"employeeId"`
+	Date       time.Time              `gorm:"type:date;not null;uniqueIndex:idx_schedule_override_date" json:"date"`
+	Off        bool                   `gorm:"not null;default:false" json:"off"`
+	Reason     string                 `gorm:"type:varchar(255);not null;default:''" json:"reason"`
+	Slots      []ScheduleOverrideSlot `gorm:"foreignKey:OverrideID" json:"slots"`
+	// Source is always SourceOverride; ChangedByID is the user who set the override, nil when unknown.
+	Source      string    `gorm:"type:varchar(20);not null;default:'override'" json:"source"`
+	ChangedByID *uint     `json:"changedById,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// ScheduleOverrideSlot is a slot worked on the date of a schedule override.
+type ScheduleOverrideSlot struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"not null;default:0;index" json:"-"`
+	OverrideID uint       `gorm:"not null;index" json:"overrideId"`
+	StartTime  CustomTime `gorm:"type:time without time zone;not null" json:"startTime"`
+	EndTime    CustomTime `gorm:"type:time without time zone;not null" json:"endTime"`
+	// CrossesMidnight is set on the overnight slots, see Schedule.
+	CrossesMidnight bool   `gorm:"not null;default:false" json:"crossesMidnight"`
+	Location        string `gorm:"type:varchar(100);not null;default:''" json:"location"`
+	Task            string `gorm:"type:varchar(50);not null;default:''" json:"task"`
+}
+
+// BeforeSave records whether the slot crosses midnight.
+func (s *ScheduleOverrideSlot) BeforeSave(tx *gorm.DB) error {
+	s.CrossesMidnight = CrossesMidnight(s.StartTime, s.EndTime)
+	return nil
+}
+
+// End returns the end of the slot on the clock of StartTime, see Schedule.End.
+func (s ScheduleOverrideSlot) End() time.Time {
+	return slotEnd(s.StartTime, s.EndTime)
+}
+
+// Schedule delta actions.
+const (
+	DeltaAdd    = "add"
+	DeltaRemove = "remove"
+)
+
+// ScheduleDelta is a per-employee deviation from the inherited role template: either an extra slot
+// ("add") or an inherited slot the employee does not work ("remove").
+type ScheduleDelta struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"not null;default:0;index" json:"-"`
+	UUID       string     `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	EmployeeID uint       `gorm:"not null;index" json:"employeeId"`
+	Action     string     `gorm:"type:varchar(10);not null" json:"action"`
+	WeekType   string     `gorm:"type:varchar(8);not null" json:"weekType"`
+	DayName    string     `gorm:"type:varchar(10);not null" json:"dayName"`
+	StartTime  CustomTime `gorm:"type:time without time zone;not null" json:"startTime"`
+	EndTime    CustomTime `gorm:"type:time without time zone;not null" json:"endTime"`
+	Location   string     `gorm:"type:varchar(100);not null;default:''" json:"location"`
+	Task       string     `gorm:"type:varchar(50);not null;default:''" json:"task"`
+	// Source is always SourceManual; ChangedByID is the user who recorded the delta, nil when unknown.
+	Source      string    `gorm:"type:varchar(20);not null;default:'manual'" json:"source"`
+	ChangedByID *uint     `json:"changedById,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// Pairing rule kinds.
+const (
+	// PairingTogether requires the other employee to be at work whenever the employee is (a trainee and
+	// their mentor).
+	PairingTogether = "together"
+	// PairingApart forbids the two employees from working at the same time.
+	PairingApart = "apart"
+	// PairingNotClosingTogether forbids the two employees from both working until the shop closes, the
+	// latest end of a slot of the day.
+	PairingNotClosingTogether = "not-closing-together"
+)
+
+// PairingRule constrains when two employees may be scheduled relative to each other. For PairingTogether
+// the rule is directional: EmployeeID needs OtherEmployeeID, not the reverse.
+type PairingRule struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TenantID        uint      `gorm:"not null;default:0;index" json:"-"`
+	UUID            string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Kind            string    `gorm:"type:varchar(30);not null" json:"kind"`
+	EmployeeID      uint      `gorm:"not null;index" json:"employeeId"`
+	OtherEmployeeID uint      `gorm:"not null;index" json:"otherEmployeeId"`
+	Note            string    `gorm:"type:varchar(255);not null;default:''" json:"note"`
+	CreatedAt       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// StaffingRequirement is the number of employees that must be scheduled at Location every DayName from
+// StartTime to EndTime. An empty Location counts the slots worked at every location.
+type StaffingRequirement struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TenantID  uint       `gorm:"not null;default:0;index" json:"-"`
+	UUID      string     `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Location  string     `gorm:"type:varchar(100);not null;default:''" json:"location"`
+	DayName   string     `gorm:"type:varchar(10);not null" json:"dayName"`
+	StartTime CustomTime `gorm:"type:time without time zone;not null" json:"startTime"`
+	EndTime   CustomTime `gorm:"type:time without time zone;not null" json:"endTime"`
+	Headcount int        `gorm:"not null" json:"headcount"`
+	CreatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// WebhookTemplateFormat is the format of webhooks whose payload is rendered with their own Template rather
+// than with a preset.
+const WebhookTemplateFormat = "template"
+
+// Webhook is a URL the API posts events to. Format is the name of a payload preset (json, slack, zapier)
+// or WebhookTemplateFormat, in which case Template is a Go template over the JSON form of the event.
+// Events are the types of the events delivered, none but the test deliveries without them. Secret signs the
+// deliveries; it is only shown when the webhook is created and when it is rotated.
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"not null;default:0;index" json:"-"`
+	UUID      string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Name      string    `gorm:"type:varchar(100);not null;default:''" json:"name"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Format    string    `gorm:"type:varchar(30);not null;default:'json'" json:"format"`
+	Template  string    `gorm:"type:text;not null;default:''" json:"template,omitempty"`
+	Events    []string  `gorm:"type:text;serializer:json" json:"events"`
+	Secret    string    `gorm:"type:varchar(64);not null;default:''" json:"secret,omitempty"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// Subscribes reports whether the events of type eventType are delivered to the webhook.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a webhook delivery.
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event queued for a webhook, with its payload rendered when the event occurred. A
+// delivery the consumer did not accept is tried again at NextAttemptAt, waiting longer after every attempt,
+// until it is delivered or failed for good.
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TenantID      uint       `gorm:"not null;default:0;index" json:"-"`
+	UUID          string     `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	WebhookID     uint       `gorm:"not null;index" json:"webhookId"`
+	EventID       string     `gorm:"type:varchar(32);not null" json:"eventId"`
+	EventType     string     `gorm:"type:varchar(50);not null" json:"eventType"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"type:varchar(10);not null;default:'pending';index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"nextAttemptAt"`
+	LastStatus    int        `gorm:"not null;default:0" json:"lastStatus,omitempty"`
+	LastError     string     `gorm:"type:text;not null;default:''" json:"lastError,omitempty"`
+	CreatedAt     time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+}
+
+// Punch kinds of a time entry.
+const (
+	PunchIn  = "in"
+	PunchOut = "out"
+)
+
+// TimeEntry is a punch of an employee on a time clock. DeviceID and Nonce identify the request of the kiosk
+// that recorded it, so that a retried request is recognized; Sequence is the request counter of the kiosk.
+type TimeEntry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UUID       string    `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	TenantID   uint      `gorm:"not null;default:0;uniqueIndex:idx_time_entry_tenant_nonce" json:"-"`
+	EmployeeID uint      `gorm:"not null;index:idx_time_entry_employee" json:"employeeId"`
+	Kind       string    `gorm:"type:varchar(3);not null" json:"kind"`
+	PunchedAt  time.Time `gorm:"not null;index:idx_time_entry_employee" json:"punchedAt"`
+	DeviceID   string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_time_entry_tenant_nonce" json:"deviceId"`
+	Nonce      string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_time_entry_tenant_nonce" json:"nonce"`
+	Sequence   int64     `gorm:"not null;default:0" json:"sequence,omitempty"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// Print job statuses. A job is queued when requested, running while a worker renders it, then done with a
+// stored artifact or failed.
+const (
+	PrintQueued  = "queued"
+	PrintRunning = "running"
+	PrintDone    = "done"
+	PrintFailed  = "failed"
+)
+
+// Kinds of print jobs: the monthly schedule export and the payroll export of a month.
+const (
+	PrintSchedules = "schedules"
+	PrintPayroll   = "payroll"
+)
+
+// PrintJob is the generation of a large export, run by a worker of the print queue instead of the request
+// asking for it. The rendered file is kept in the artifact storage under ArtifactKey and downloaded through
+// a signed link; the calendars are built for Region, the holiday region of the request.
+type PrintJob struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	TenantID      uint   `gorm:"not null;default:0;index" json:"-"`
+	UUID          string `gorm:"type:varchar(36);uniqueIndex" json:"uuid"`
+	Kind          string `gorm:"type:varchar(20);not null" json:"kind"`
+	Format        string `gorm:"type:varchar(20);not null" json:"format"`
+	Month         string `gorm:"type:varchar(20);not null" json:"month"`
+	Year          int    `gorm:"not null" json:"year"`
+	Region        string `gorm:"type:varchar(40);not null;default:''" json:"region,omitempty"`
+	Status        string `gorm:"type:varchar(10);not null;default:'queued';index" json:"status"`
+	Error         string `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+	RequestedByID *uint  `json:"requestedById,omitempty"`
+	// CallbackURL, when set, receives a print.completed or print.failed event once the job is over.
+	CallbackURL string     `gorm:"type:varchar(2048);not null;default:''" json:"callbackUrl,omitempty"`
+	ArtifactKey string     `gorm:"type:varchar(255);not null;default:''" json:"-"`
+	FileName    string     `gorm:"type:varchar(255);not null;default:''" json:"fileName,omitempty"`
+	ContentType string     `gorm:"type:varchar(100);not null;default:''" json:"contentType,omitempty"`
+	Size        int64      `gorm:"not null;default:0" json:"size,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// DownloadURL is the signed link to the artifact of a done job, computed when the job is returned.
+	DownloadURL string `gorm:"-" json:"downloadUrl,omitempty"`
+}
+
+// SchemaMigration records a migration applied to the database, see the migrations of the repository.
+type SchemaMigration struct {
+	ID          string    `gorm:"type:varchar(100);primaryKey" json:"id"`
+	Description string    `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	AppliedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"appliedAt"`
+}
+
+// MigrationStatus tells whether a migration known to the server was applied to its database.
+type MigrationStatus struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Applied     bool       `json:"applied"`
+	AppliedAt   *time.Time `json:"appliedAt,omitempty"`
+}
+
+// TableRowCount is the number of rows of a table, as reported in a support bundle.
+type TableRowCount struct {
+	Table   string `json:"table"`
+	Rows    int64  `json:"rows"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// ChangeResync is the Op of the DataChange passed once the change feed reconnected: the changes made while
+// it was disconnected were missed.
+const ChangeResync = "resync"
+
+// DataChange is a row of an employee or schedule table written by any instance of the API or by hand,
+// as notified by the database. Op is insert, update or delete; EmployeeID is the employee the row is about.
+type DataChange struct {
+	Table      string `json:"table"`
+	Op         string `json:"op"`
+	ID         uint   `json:"id"`
+	EmployeeID uint   `json:"employeeId,omitempty"`
+	TenantID   uint   `json:"-"`
 }
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+	"gorm.io/gorm"
+	"regexp"
+)
+
+// Resources exposed by the API carry a random UUID next to their sequential primary key. The key stays the
+// internal reference between tables; the UUID is the identifier clients can store and use in URLs without
+// learning how many rows exist or clashing with the rows of another instance.
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// NewUUID returns a random (version 4) UUID in its canonical lower-case form.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("model: reading random bytes: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsUUID reports whether value is a UUID in canonical lower-case form.
+func IsUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
+
+func assignUUID(uuid *string) {
+	if *uuid == "" {
+		*uuid = NewUUID()
+	}
+}
+
+func (e *Employee) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&e.UUID)
+	return nil
+}
+
+func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&s.UUID)
+	return nil
+}
+
+func (h *EmployeeHoliday) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&h.UUID)
+	return nil
+}
+
+func (t *RoleTemplate) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&t.UUID)
+	return nil
+}
+
+func (d *ScheduleDelta) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&d.UUID)
+	return nil
+}
+
+func (p *PairingRule) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&p.UUID)
+	return nil
+}
+
+func (r *StaffingRequirement) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&r.UUID)
+	return nil
+}
+
+func (u *Unavailability) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&u.UUID)
+	return nil
+}
+
+func (c *ClosureDay) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&c.UUID)
+	return nil
+}
+
+func (o *ScheduleOverride) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&o.UUID)
+	return nil
+}
+
+func (p *RotationPattern) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&p.UUID)
+	return nil
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&w.UUID)
+	return nil
+}
+
+func (e *TimeEntry) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&e.UUID)
+	return nil
+}
+
+func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&t.UUID)
+	return nil
+}
+
+// WithUUID lists the models that carry a UUID, for the migration backfilling existing rows.
+var WithUUID = []interface{}{&Employee{}, &Schedule{}, &EmployeeHoliday{}, &RoleTemplate{}, &ScheduleDelta{}, &PairingRule{}, &ScheduleOverride{}, &RotationPattern{}, &Webhook{}, &TimeEntry{}, &Tenant{}, &PrintJob{}}
+
+func (j *PrintJob) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&j.UUID)
+	return nil
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	assignUUID(&d.UUID)
+	return nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation on the links of the employees to their Google Calendar
+
+// migrateCalendarLinks creates the table of the calendar links
+func migrateCalendarLinks(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.CalendarLink{})
+}
+
+// CalendarLinkGet retrieves the calendar link of an employee
+func (repo *repository) CalendarLinkGet(ctx context.Context, employeeID uint) (*model.CalendarLink, error) {
+	var link model.CalendarLink
+	if err := repo.db.WithContext(ctx).Where("employee_id = ?", employeeID).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CalendarLinkList retrieves the calendar links of the tenant, those of every tenant outside of one
+func (repo *repository) CalendarLinkList(ctx context.Context) ([]model.CalendarLink, error) {
+	var links []model.CalendarLink
+	err := repo.db.WithContext(ctx).Order("employee_id").Find(&links).Error
+	return links, err
+}
+
+// CalendarLinkSave creates the calendar link of an employee, or replaces the one it has
+func (repo *repository) CalendarLinkSave(ctx context.Context, link *model.CalendarLink) error {
+	if link.ID == 0 {
+		existing, err := repo.CalendarLinkGet(ctx, link.EmployeeID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repo.db.WithContext(ctx).Create(link).Error
+		}
+		if err != nil {
+			return err
+		}
+		link.ID, link.TenantID, link.CreatedAt = existing.ID, existing.TenantID, existing.CreatedAt
+	}
+	return repo.db.WithContext(ctx).Save(link).Error
+}
+
+// CalendarLinkDelete deletes the calendar link of an employee
+func (repo *repository) CalendarLinkDelete(ctx context.Context, employeeID uint) error {
+	result := repo.db.WithContext(ctx).Where("employee_id = ?", employeeID).Delete(&model.CalendarLink{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on the closure days of the company
+
+// migrateClosureDays creates the table of the closure days
+func migrateClosureDays(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.ClosureDay{})
+}
+
+// closureDayTaken returns gorm.ErrDuplicatedKey if a closure day other than id falls on date
+func closureDayTaken(tx *gorm.DB, id uint, date time.Time) error {
+	var count int64
+	if err := tx.Model(&model.ClosureDay{}).Where("date = ? AND id <> ?", date, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return nil
+}
+
+// ClosureDayCreate inserts a closure day, returning gorm.ErrDuplicatedKey if the company already closes on its
+// date
+func (repo *repository) ClosureDayCreate(ctx context.Context, closure *model.ClosureDay) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := closureDayTaken(tx, 0, closure.Date); err != nil {
+			return err
+		}
+		return tx.Create(closure).Error
+	})
+}
+
+// ClosureDayUpdate replaces a closure day, returning gorm.ErrRecordNotFound if it does not exist and
+// gorm.ErrDuplicatedKey if the company already closes on its new date
+func (repo *repository) ClosureDayUpdate(ctx context.Context, closure *model.ClosureDay) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := closureDayTaken(tx, closure.ID, closure.Date); err != nil {
+			return err
+		}
+		closure.UpdatedAt = time.Now()
+		result := tx.Model(&model.ClosureDay{}).Where("id = ?", closure.ID).
+			Select("date", "name", "clear_slots", "updated_at").Updates(closure)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.First(closure, closure.ID).Error
+	})
+}
+
+// ClosureDayFindBetween retrieves the closure days from from to to included, ordered by date
+func (repo *repository) ClosureDayFindBetween(ctx context.Context, from, to time.Time) ([]model.ClosureDay, error) {
+	var closures []model.ClosureDay
+	err := repo.db.WithContext(ctx).Where("date BETWEEN ? AND ?", from, to).Order("date").Find(&closures).Error
+	return closures, err
+}
+
+// ClosureDayDelete removes a closure day, returning gorm.ErrRecordNotFound if it does not exist
+func (repo *repository) ClosureDayDelete(ctx context.Context, id uint) error {
+	result := repo.db.WithContext(ctx).Delete(&model.ClosureDay{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
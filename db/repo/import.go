@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on the imports of employees and their rollback
+
+// ErrImportSuperseded is returned by RollbackImport when a later import changed one of the employees of the
+// import: that one must be rolled back first
+var ErrImportSuperseded = errors.New("a later import changed the same employees")
+
+// migrateImportChanges creates the table of the employees changed by each import
+func migrateImportChanges(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.EmployeeImportChange{})
+}
+
+// employeeState returns the attributes and own slots of an employee that an import overwrites
+func employeeState(tx *gorm.DB, employeeID uint) (*model.EmployeeState, error) {
+	var employee model.Employee
+	if err := tx.First(&employee, employeeID).Error; err != nil {
+		return nil, err
+	}
+	state := &model.EmployeeState{
+		Department:          employee.Department,
+		ContractWeeklyHours: employee.ContractWeeklyHours,
+		HourlyRate:          employee.HourlyRate,
+		Email:               employee.Email,
+		Phone:               employee.Phone,
+		Role:                employee.Role,
+		Color:               employee.Color,
+		RoleTemplateID:      employee.RoleTemplateID,
+		RotationPatternID:   employee.RotationPatternID,
+		RotationAnchor:      employee.RotationAnchor,
+		EndDate:             employee.EndDate,
+	}
+	if err := tx.Where("employee_id = ?", employeeID).Order("id").Find(&state.Schedules).Error; err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// recordImportChanges records the employees of an import as its changes: replaced when previous holds the
+// state it overwrote, created otherwise
+func recordImportChanges(tx *gorm.DB, record *model.EmployeeImport, employees []*model.Employee, previous map[*model.Employee]*model.EmployeeState) error {
+	record.Changes = make([]model.EmployeeImportChange, 0, len(employees))
+	for _, employee := range employees {
+		change := model.EmployeeImportChange{ImportID: record.ID, EmployeeID: employee.ID, Action: model.ImportCreated}
+		if state, ok := previous[employee]; ok {
+			change.Action, change.Previous = model.ImportReplaced, state
+		}
+		record.Changes = append(record.Changes, change)
+	}
+	if len(record.Changes) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&record.Changes, insertBatchSize).Error
+}
+
+// ImportList retrieves the last imports, with their changes, the latest first
+func (repo *repository) ImportList(ctx context.Context, limit int) ([]model.EmployeeImport, error) {
+	var records []model.EmployeeImport
+	err := repo.db.WithContext(ctx).Preload("Changes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Order("id DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// RollbackImport reverses the import of id in a single transaction: the employees it replaced get back the
+// attributes and own slots it overwrote, the employees it created are archived, and the import is forgotten
+// so that its payload can be loaded again. It returns the import rolled back, gorm.ErrRecordNotFound when
+// there is none with id, and ErrImportSuperseded when a later import changed one of its employees
+func (repo *repository) RollbackImport(ctx context.Context, id uint) (*model.EmployeeImport, error) {
+	var record model.EmployeeImport
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Changes", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&record, id).Error; err != nil {
+			return err
+		}
+		employeeIDs := make([]uint, len(record.Changes))
+		for i, change := range record.Changes {
+			employeeIDs[i] = change.EmployeeID
+		}
+		if len(employeeIDs) > 0 {
+			var later model.EmployeeImportChange
+			err := tx.Where("import_id > ? AND employee_id IN ?", id, employeeIDs).Order("import_id").Take(&later).Error
+			if err == nil {
+				return fmt.Errorf("import %d changed employee %d since: %w", later.ImportID, later.EmployeeID, ErrImportSuperseded)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		for _, change := range record.Changes {
+			if err := revertImportChange(tx, change); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("import_id = ?", id).Delete(&model.EmployeeImportChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.EmployeeImport{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// revertImportChange puts an employee back as it was before the import of change
+func revertImportChange(tx *gorm.DB, change model.EmployeeImportChange) error {
+	if change.Action == model.ImportCreated {
+		// Archived rather than deleted, so that nothing recorded for the employee since is lost.
+		if err := tx.Model(&model.Employee{}).Where("id = ?", change.EmployeeID).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Employee{}, change.EmployeeID).Error
+	}
+	state := change.Previous
+	if state == nil {
+		return fmt.Errorf("employee %d replaced by import %d has no previous state", change.EmployeeID, change.ImportID)
+	}
+	if err := tx.Model(&model.Employee{ID: change.EmployeeID}).Updates(map[string]interface{}{
+		"department":            state.Department,
+		"contract_weekly_hours": state.ContractWeeklyHours,
+		"hourly_rate":           state.HourlyRate,
+		"email":                 state.Email,
+		"phone":                 state.Phone,
+		"role":                  state.Role,
+		"color":                 state.Color,
+		"role_template_id":      state.RoleTemplateID,
+		"rotation_pattern_id":   state.RotationPatternID,
+		"rotation_anchor":       state.RotationAnchor,
+		"end_date":              state.EndDate,
+		// Moved forward, so that the calendars computed since the import are not served again.
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("employee_id = ?", change.EmployeeID).Delete(&model.Schedule{}).Error; err != nil {
+		return err
+	}
+	if len(state.Schedules) == 0 {
+		return nil
+	}
+	// The slots come back with their IDs and UUIDs, which the import had deleted.
+	return tx.CreateInBatches(&state.Schedules, insertBatchSize).Error
+}
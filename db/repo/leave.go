@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on employee leave
+
+// LeaveCreate inserts the days of a leave request of an employee in one transaction, returning
+// gorm.ErrDuplicatedKey if one of the days is already requested and not rejected
+func (repo *repository) LeaveCreate(ctx context.Context, employeeID uint, days []model.EmployeeHoliday) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range days {
+			var count int64
+			if err := tx.Model(&model.EmployeeHoliday{}).
+				Where("employee_id = ? AND holiday_date = ? AND status <> ?", employeeID, days[i].HolidayDate, model.LeaveRejected).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return gorm.ErrDuplicatedKey
+			}
+			days[i].EmployeeID = employeeID
+		}
+		if len(days) == 0 {
+			return nil
+		}
+		if err := tx.Create(&days).Error; err != nil {
+			return err
+		}
+		ids := make([]uint, len(days))
+		for i := range days {
+			ids[i] = days[i].ID
+			days[i].RequestID = days[0].ID
+		}
+		if err := tx.Model(&model.EmployeeHoliday{}).Where("id IN ?", ids).Update("request_id", days[0].ID).Error; err != nil {
+			return err
+		}
+		return touchEmployee(tx, employeeID)
+	})
+}
+
+// LeaveFindByID retrieves a leave day by its ID
+func (repo *repository) LeaveFindByID(ctx context.Context, id uint) (*model.EmployeeHoliday, error) {
+	var day model.EmployeeHoliday
+	if err := repo.db.WithContext(ctx).First(&day, id).Error; err != nil {
+		return nil, err
+	}
+	return &day, nil
+}
+
+// LeaveFindBetween retrieves the leave days of an employee from from to to included, ordered by date. An
+// empty status matches every status
+func (repo *repository) LeaveFindBetween(ctx context.Context, employeeID uint, from, to time.Time, status string) ([]model.EmployeeHoliday, error) {
+	var days []model.EmployeeHoliday
+	query := repo.db.WithContext(ctx).Where("employee_id = ? AND holiday_date BETWEEN ? AND ?", employeeID, from, to)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("holiday_date").Find(&days).Error
+	return days, err
+}
+
+// LeaveFindAllBetween retrieves the leave days of every employee from from to to included. An empty status
+// matches every status
+func (repo *repository) LeaveFindAllBetween(ctx context.Context, from, to time.Time, status string) ([]model.EmployeeHoliday, error) {
+	var days []model.EmployeeHoliday
+	query := repo.db.WithContext(ctx).Where("holiday_date BETWEEN ? AND ?", from, to)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("employee_id, holiday_date").Find(&days).Error
+	return days, err
+}
+
+// LeaveDecide sets the status of the pending days of a leave request, returning gorm.ErrRecordNotFound if
+// none is pending. Days recorded before requests were grouped only match their own ID
+func (repo *repository) LeaveDecide(ctx context.Context, requestID uint, status string, approverID *uint) ([]model.EmployeeHoliday, error) {
+	var days []model.EmployeeHoliday
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.EmployeeHoliday{}).Where("(request_id = ? OR id = ?) AND status = ?", requestID, requestID, model.LeavePending).
+			Updates(map[string]interface{}{"status": status, "approver_id": approverID, "decided_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("request_id = ? OR id = ?", requestID, requestID).Order("holiday_date").Find(&days).Error; err != nil {
+			return err
+		}
+		return touchEmployee(tx, days[0].EmployeeID)
+	})
+	return days, err
+}
+
+// LeaveDeleteBetween removes the leave days of an employee from from to to included, returning
+// gorm.ErrRecordNotFound if there are none
+func (repo *repository) LeaveDeleteBetween(ctx context.Context, employeeID uint, from, to time.Time) (int64, error) {
+	var deleted int64
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("employee_id = ? AND holiday_date BETWEEN ? AND ?", employeeID, from, to).Delete(&model.EmployeeHoliday{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		deleted = result.RowsAffected
+		return touchEmployee(tx, employeeID)
+	})
+	return deleted, err
+}
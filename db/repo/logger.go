@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+// slowQuery is how long a query runs before it is logged as slow
+const slowQuery = 200 * time.Millisecond
+
+// sqlLogger logs the failed and slow queries of gorm through logrus, with the ID of the request that ran
+// them. The queries are logged without their parameters, which may hold personal data or secrets
+type sqlLogger struct {
+	level logger.LogLevel
+}
+
+// newSQLLogger returns the logger of the failed and slow queries, the one Open installs
+func newSQLLogger() logger.Interface {
+	return sqlLogger{level: logger.Warn}
+}
+
+func (l sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	l.level = level
+	return l
+}
+
+func (l sqlLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Info {
+		reqlog.From(ctx).Infof(msg, data...)
+	}
+}
+
+func (l sqlLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Warn {
+		reqlog.From(ctx).Warnf(msg, data...)
+	}
+}
+
+func (l sqlLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= logger.Error {
+		reqlog.From(ctx).Errorf(msg, data...)
+	}
+}
+
+// Trace logs the query that just ran if it failed or was slow; the records not found are not failures
+func (l sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= logger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		reqlog.From(ctx).WithFields(log.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()}).
+			Errorf("Query failed: %v", err)
+	case elapsed > slowQuery && l.level >= logger.Warn:
+		sql, rows := fc()
+		reqlog.From(ctx).WithFields(log.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()}).
+			Warn("Slow query")
+	case l.level >= logger.Info:
+		sql, rows := fc()
+		reqlog.From(ctx).WithFields(log.Fields{"sql": sql, "rows": rows, "elapsed": elapsed.String()}).Info("Query")
+	}
+}
+
+// ParamsFilter leaves the parameters out of the queries logged
+func (l sqlLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
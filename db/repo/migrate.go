@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Operation on the schema
+//
+// The schema is versioned: the migrations below are applied in order, each at most once, and recorded in
+// schema_migrations. A change to the models that AutoMigrate cannot make safely, such as a rename or a
+// backfill, is a new migration appended to the list; the list is never reordered and an applied migration
+// is never edited.
+
+// migration changes the schema from one version to the next. Up runs within the transaction recording it,
+// so that a migration that fails leaves nothing behind and is tried again on the next start
+type migration struct {
+	ID          string
+	Description string
+	Up          func(ctx context.Context, tx *repository) error
+}
+
+// migrations are the versions of the schema, oldest first. The baseline creates the tables from the current
+// models, so the migrations after it also run on databases created with later models: they check what they
+// change, as dropRegionlessHolidays does, rather than assume the state left by the previous one
+var migrations = []migration{
+	{ID: "0001_baseline", Description: "create the tables, or bring a database created by AutoMigrate up to date", Up: migrateBaseline},
+	{ID: "0002_change_notify", Description: "notify the changes of employees and schedules on the data_changes channel", Up: migrateChangeNotify},
+	{ID: "0003_rotation_calendar", Description: "create the rotation calendar anchoring the weeks of rotation of each tenant", Up: migrateRotationCalendar},
+	{ID: "0004_webhook_deliveries", Description: "subscribe the webhooks to events and queue their signed deliveries", Up: migrateWebhookDeliveries},
+	{ID: "0005_validation_settings", Description: "create the settings of the validators checking the schedules of each tenant", Up: migrateValidationSettings},
+	{ID: "0006_calendar_links", Description: "create the links of the employees to the Google Calendars their slots are synced to", Up: migrateCalendarLinks},
+	{ID: "0007_schedule_versions", Description: "keep the patterns of the employees replaced from an effective date", Up: migrateScheduleVersions},
+	{ID: "0008_import_changes", Description: "record the employees created or replaced by each import, for its rollback", Up: migrateImportChanges},
+	{ID: "0009_employee_profile", Description: "add the email, phone, role and display color of the employees", Up: migrateEmployeeProfile},
+	{ID: "0010_staffing_requirements", Description: "create the headcounts required by location, day of the week and time range", Up: migrateStaffingRequirements},
+	{ID: "0011_unavailabilities", Description: "create the times the employees declared they cannot work", Up: migrateUnavailabilities},
+	{ID: "0012_closure_days", Description: "create the days the companies close on top of the public holidays", Up: migrateClosureDays},
+	{ID: "0013_overnight_slots", Description: "flag the slots crossing midnight", Up: migrateOvernightSlots},
+	{ID: "0014_employee_search", Description: "index the names of the employees by trigrams for the fuzzy search", Up: migrateEmployeeSearch},
+}
+
+// migrationLock is the key of the PostgreSQL advisory lock serializing the instances migrating at startup
+const migrationLock = 7_245_130_481
+
+// migrateBaseline is the schema as DBCreate used to make it with AutoMigrate, along with the fixes it ran
+// on every start
+func migrateBaseline(ctx context.Context, tx *repository) error {
+	if err := tx.dropRegionlessHolidays(ctx); err != nil {
+		return fmt.Errorf("failed to drop the holidays predating regions: %w", err)
+	}
+	if err := tx.db.WithContext(ctx).AutoMigrate(&model.RotationPattern{}, &model.RotationWeek{}, &model.RoleTemplate{}, &model.RoleTemplateSlot{}, &model.Employee{}, &model.Schedule{},
+		&model.ScheduleDelta{}, &model.Holiday{}, &model.User{}, &model.DemandForecast{}, &model.EmployeeImport{}, &model.PairingRule{}, &model.EmployeeHoliday{},
+		&model.ScheduleOverride{}, &model.ScheduleOverrideSlot{}, &model.DailyRevenue{}, &model.Webhook{}, &model.TimeEntry{}, &model.Tenant{}, &model.PrintJob{}); err != nil {
+		return fmt.Errorf("failed to create the tables: %w", err)
+	}
+	if err := tx.dropTenantlessIndexes(ctx); err != nil {
+		return fmt.Errorf("failed to drop the indexes predating tenants: %w", err)
+	}
+	if err := tx.backfillUUIDs(ctx); err != nil {
+		return fmt.Errorf("failed to backfill UUIDs: %w", err)
+	}
+	// The week types stored before rotation patterns existed are the weeks of the A/B rotation.
+	if err := tx.seedDefaultRotation(ctx); err != nil {
+		return fmt.Errorf("failed to seed the A/B rotation: %w", err)
+	}
+	return nil
+}
+
+// migrateEmployeeProfile adds the contact and display fields of the employees, empty for the employees
+// already stored
+func migrateEmployeeProfile(ctx context.Context, tx *repository) error {
+	migrator := tx.db.WithContext(ctx).Migrator()
+	for _, field := range []string{"Email", "Phone", "Role", "Color"} {
+		if migrator.HasColumn(&model.Employee{}, field) {
+			continue
+		}
+		if err := migrator.AddColumn(&model.Employee{}, field); err != nil {
+			return fmt.Errorf("failed to add the %s of the employees: %w", strings.ToLower(field), err)
+		}
+	}
+	return nil
+}
+
+// migrateOvernightSlots adds the flag of the slots crossing midnight and sets it on those ending before they
+// start
+func migrateOvernightSlots(ctx context.Context, tx *repository) error {
+	db := tx.db.WithContext(ctx)
+	for _, slots := range []interface{}{&model.Schedule{}, &model.ScheduleOverrideSlot{}} {
+		if !db.Migrator().HasColumn(slots, "CrossesMidnight") {
+			if err := db.Migrator().AddColumn(slots, "CrossesMidnight"); err != nil {
+				return fmt.Errorf("failed to add the overnight flag of the slots: %w", err)
+			}
+		}
+		if err := db.Model(slots).Where("end_time < start_time").UpdateColumn("crosses_midnight", true).Error; err != nil {
+			return fmt.Errorf("failed to flag the overnight slots: %w", err)
+		}
+	}
+	return nil
+}
+
+// DBCreate applies the migrations not applied yet, in order. Instances starting together on PostgreSQL
+// take turns: each migration is applied by the first of them, the others find it recorded
+func (r *repository) DBCreate(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).AutoMigrate(&model.SchemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+	for _, m := range migrations {
+		applied, err := r.applyMigration(ctx, m)
+		if err != nil {
+			reqlog.From(ctx).Errorf("Failed to apply migration %s: %v", m.ID, err)
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		if applied {
+			reqlog.From(ctx).Infof("Applied migration %s: %s", m.ID, m.Description)
+		}
+	}
+	reqlog.From(ctx).Info("Database schema migrated successfully.")
+	return nil
+}
+
+// applyMigration applies m unless it was already, and reports whether it did
+func (r *repository) applyMigration(ctx context.Context, m migration) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error; err != nil {
+				return err
+			}
+		}
+		var count int64
+		if err := tx.Model(&model.SchemaMigration{}).Where("id = ?", m.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := m.Up(ctx, &repository{db: tx}); err != nil {
+			return err
+		}
+		applied = true
+		return tx.Create(&model.SchemaMigration{ID: m.ID, Description: m.Description}).Error
+	})
+	return applied, err
+}
+
+// MigrationStatus lists the migrations known to the server, oldest first, telling which were applied
+func (r *repository) MigrationStatus(ctx context.Context) ([]model.MigrationStatus, error) {
+	db := r.db.WithContext(ctx)
+	var records []model.SchemaMigration
+	if db.Migrator().HasTable(&model.SchemaMigration{}) {
+		if err := db.Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+	appliedAt := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		appliedAt[record.ID] = record.AppliedAt
+	}
+	status := make([]model.MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i] = model.MigrationStatus{ID: m.ID, Description: m.Description}
+		if at, ok := appliedAt[m.ID]; ok {
+			status[i].Applied, status[i].AppliedAt = true, &at
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+func TestDBCreateAppliesEachMigrationOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:TestDBCreateAppliesEachMigrationOnce?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	repo := &repository{db: db}
+	ctx := context.Background()
+
+	status, err := repo.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, len(migrations))
+	assert.Equal(t, "0001_baseline", status[0].ID)
+	for _, migration := range status {
+		assert.False(t, migration.Applied, "nothing is applied to an empty database")
+	}
+
+	require.NoError(t, repo.DBCreate(ctx))
+	require.NoError(t, repo.DBCreate(ctx), "migrating again finds nothing to apply")
+	var count int64
+	require.NoError(t, db.Model(&model.SchemaMigration{}).Count(&count).Error)
+	assert.EqualValues(t, len(migrations), count)
+	status, err = repo.MigrationStatus(ctx)
+	require.NoError(t, err)
+	for _, migration := range status {
+		assert.True(t, migration.Applied, migration.ID)
+		assert.NotNil(t, migration.AppliedAt, migration.ID)
+	}
+	assert.True(t, db.Migrator().HasTable(&model.Employee{}))
+
+	// A migration that fails leaves nothing behind and is tried again on the next start.
+	failure := fmt.Errorf("disk full")
+	broken := migration{ID: "9999_broken", Description: "fail half way", Up: func(ctx context.Context, tx *repository) error {
+		require.NoError(t, tx.db.Exec("CREATE TABLE half_done (id integer)").Error)
+		return failure
+	}}
+	defer func(known []migration) { migrations = known }(migrations)
+	migrations = append(migrations[:len(migrations):len(migrations)], broken)
+	require.ErrorIs(t, repo.DBCreate(ctx), failure)
+	assert.False(t, db.Migrator().HasTable("half_done"))
+	status, err = repo.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.False(t, status[len(status)-1].Applied)
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/lichensio/api_server/db/model"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// Change feed
+//
+// On PostgreSQL, triggers notify every write to the employee and schedule tables, whoever made it: an
+// instance of the API, a script or an operator in psql. ListenChanges passes them on to the server
+
+// ChangeChannel is the channel the change triggers notify
+const ChangeChannel = "data_changes"
+
+// changeTables are the tables whose writes are notified
+var changeTables = []string{"employees", "schedules", "schedule_delta", "schedule_overrides", "employee_holidays"}
+
+// migrateChangeNotify creates the triggers notifying the changes, on PostgreSQL only. The payload is kept
+// small, well under the 8000 bytes a notification can carry: the listeners read the rows they need
+func migrateChangeNotify(ctx context.Context, tx *repository) error {
+	db := tx.db.WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := db.Exec(`CREATE OR REPLACE FUNCTION notify_data_change() RETURNS trigger AS $$
+DECLARE
+	data jsonb;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		data := to_jsonb(OLD);
+	ELSE
+		data := to_jsonb(NEW);
+	END IF;
+	PERFORM pg_notify('` + ChangeChannel + `', json_build_object(
+		'table', TG_TABLE_NAME,
+		'op', lower(TG_OP),
+		'id', data->'id',
+		'employeeId', CASE WHEN TG_TABLE_NAME = 'employees' THEN data->'id' ELSE data->'employee_id' END,
+		'tenantId', data->'tenant_id')::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`).Error; err != nil {
+		return err
+	}
+	for _, table := range changeTables {
+		trigger := "notify_" + table
+		if err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", trigger, table)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE PROCEDURE notify_data_change()", trigger, table)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changePayload is a notification of the change triggers
+type changePayload struct {
+	Table      string `json:"table"`
+	Op         string `json:"op"`
+	ID         uint   `json:"id"`
+	EmployeeID uint   `json:"employeeId"`
+	TenantID   uint   `json:"tenantId"`
+}
+
+// ListenChanges listens to the changes notified on the PostgreSQL database at dsn and passes them to handle,
+// one at a time, until ctx is done. A lost connection is opened again, waiting longer after every failure;
+// handle then gets a change of Op model.ChangeResync, as the changes made in between were missed. LISTEN
+// is not available on a hot standby: a read-only instance listens on the primary
+func ListenChanges(ctx context.Context, dsn string, handle func(model.DataChange)) {
+	backoff, connected := time.Second, false
+	for {
+		err := listenChanges(ctx, dsn, func() {
+			if connected {
+				handle(model.DataChange{Op: model.ChangeResync})
+			}
+			backoff, connected = time.Second, true
+		}, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorf("Lost the database change feed, listening again in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// listenChanges listens on one connection until it fails, calling listening once it listens
+func listenChanges(ctx context.Context, dsn string, listening func(), handle func(model.DataChange)) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+ChangeChannel); err != nil {
+		return err
+	}
+	listening()
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var payload changePayload
+		if err := json.Unmarshal([]byte(notification.Payload), &payload); err != nil {
+			log.Warnf("Ignored the malformed change notification %q: %v", notification.Payload, err)
+			continue
+		}
+		handle(model.DataChange{Table: payload.Table, Op: payload.Op, ID: payload.ID, EmployeeID: payload.EmployeeID, TenantID: payload.TenantID})
+	}
+}
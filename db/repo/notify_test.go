@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/joho/godotenv"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"os"
+	"testing"
+	"time"
+)
+
+// postgresDSN returns the DSN of the Postgres server of the .env file, skipping the test without one: the
+// change feed only exists on PostgreSQL.
+func postgresDSN(t *testing.T) string {
+	if godotenv.Load() != nil && os.Getenv("DB_HOST") == "" {
+		t.Skip("the change feed needs the Postgres server of a .env file")
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"), os.Getenv("DB_PORT"), os.Getenv("DB_SSLMODE"))
+}
+
+func TestListenChanges(t *testing.T) {
+	dsn := postgresDSN(t)
+	db, err := Open(DriverPostgres, dsn, &gorm.Config{})
+	require.NoError(t, err)
+	r := &repository{db: db}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, r.DBCreate(ctx))
+	// The other tests of the package drop and recreate some tables, taking their triggers with them.
+	require.NoError(t, db.AutoMigrate(&model.Employee{}, &model.ScheduleDelta{}))
+	require.NoError(t, migrateChangeNotify(ctx, r))
+
+	changes := make(chan model.DataChange, 16)
+	go ListenChanges(ctx, dsn, func(change model.DataChange) { changes <- change })
+
+	// LISTEN runs in the background: write until the feed hears about it.
+	employee := &model.Employee{Name: "Change Feed", StartDate: time.Now().UTC()}
+	require.NoError(t, db.Create(employee).Error)
+	defer db.Unscoped().Delete(employee)
+	await := func(table string, write func()) model.DataChange {
+		tick := time.NewTicker(200 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case change := <-changes:
+				if change.Table == table {
+					return change
+				}
+			case <-tick.C:
+				write()
+			case <-ctx.Done():
+				t.Fatalf("no change of %s notified", table)
+			}
+		}
+	}
+
+	change := await("employees", func() { db.Model(employee).UpdateColumn("department", "feed") })
+	require.Contains(t, []string{"insert", "update"}, change.Op)
+	require.Equal(t, employee.ID, change.ID)
+	require.Equal(t, employee.ID, change.EmployeeID)
+
+	nine, err := time.Parse("15:04", "09:00")
+	require.NoError(t, err)
+	delta := &model.ScheduleDelta{EmployeeID: employee.ID, Action: model.DeltaAdd, WeekType: "A", DayName: "Monday",
+		StartTime: model.CustomTime{Time: nine}, EndTime: model.CustomTime{Time: nine.Add(3 * time.Hour)}}
+	require.NoError(t, db.Create(delta).Error)
+	defer db.Delete(delta)
+	change = await("schedule_delta", func() { db.Model(delta).UpdateColumn("location", "feed") })
+	require.Equal(t, delta.ID, change.ID)
+	require.Equal(t, employee.ID, change.EmployeeID)
+}
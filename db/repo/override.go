@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on schedule overrides
+
+// OverrideSave creates the override of an employee for its date, or replaces the existing one and its slots
+func (repo *repository) OverrideSave(ctx context.Context, override *model.ScheduleOverride) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		slots := override.Slots
+		override.Slots = nil
+		defer func() { override.Slots = slots }()
+
+		var existing model.ScheduleOverride
+		err := tx.Where("employee_id = ? AND date = ?", override.EmployeeID, override.Date).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(override).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			override.ID, override.UUID, override.CreatedAt = existing.ID, existing.UUID, existing.CreatedAt
+			override.UpdatedAt = time.Now()
+			if err := tx.Model(&existing).Updates(map[string]interface{}{
+				"off": override.Off, "reason": override.Reason, "changed_by_id": override.ChangedByID, "updated_at": override.UpdatedAt,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("override_id = ?", existing.ID).Delete(&model.ScheduleOverrideSlot{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range slots {
+			slots[i].ID = 0
+			slots[i].OverrideID = override.ID
+		}
+		if len(slots) > 0 {
+			if err := tx.Create(&slots).Error; err != nil {
+				return err
+			}
+		}
+		return touchEmployee(tx, override.EmployeeID)
+	})
+}
+
+// OverrideFindBetween retrieves the overrides of an employee from from to to included with their slots,
+// ordered by date
+func (repo *repository) OverrideFindBetween(ctx context.Context, employeeID uint, from, to time.Time) ([]model.ScheduleOverride, error) {
+	var overrides []model.ScheduleOverride
+	err := repo.db.WithContext(ctx).
+		Preload("Slots", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).
+		Where("employee_id = ? AND date BETWEEN ? AND ?", employeeID, from, to).
+		Order("date").Find(&overrides).Error
+	return overrides, err
+}
+
+// OverrideDelete removes the override of an employee on date, returning gorm.ErrRecordNotFound if there is none
+func (repo *repository) OverrideDelete(ctx context.Context, employeeID uint, date time.Time) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var override model.ScheduleOverride
+		if err := tx.Where("employee_id = ? AND date = ?", employeeID, date).First(&override).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("override_id = ?", override.ID).Delete(&model.ScheduleOverrideSlot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&override).Error; err != nil {
+			return err
+		}
+		return touchEmployee(tx, employeeID)
+	})
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation on pairing rules
+
+// PairingRuleCreate inserts a pairing rule
+func (repo *repository) PairingRuleCreate(ctx context.Context, rule *model.PairingRule) error {
+	return repo.db.WithContext(ctx).Create(rule).Error
+}
+
+// PairingRuleList retrieves every pairing rule
+func (repo *repository) PairingRuleList(ctx context.Context) ([]model.PairingRule, error) {
+	var rules []model.PairingRule
+	err := repo.db.WithContext(ctx).Order("id").Find(&rules).Error
+	return rules, err
+}
+
+// PairingRuleDelete removes a pairing rule, returning gorm.ErrRecordNotFound if it does not exist
+func (repo *repository) PairingRuleDelete(ctx context.Context, id uint) error {
+	result := repo.db.WithContext(ctx).Delete(&model.PairingRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
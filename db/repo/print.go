@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"time"
+)
+
+// Operation on print jobs
+
+// PrintJobCreate inserts a print job
+func (repo *repository) PrintJobCreate(ctx context.Context, job *model.PrintJob) error {
+	return repo.db.WithContext(ctx).Create(job).Error
+}
+
+// PrintJobFindByID retrieves a print job
+func (repo *repository) PrintJobFindByID(ctx context.Context, id uint) (*model.PrintJob, error) {
+	var job model.PrintJob
+	if err := repo.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// PrintJobFindByUUID retrieves a print job by its UUID
+func (repo *repository) PrintJobFindByUUID(ctx context.Context, uuid string) (*model.PrintJob, error) {
+	var job model.PrintJob
+	if err := repo.db.WithContext(ctx).Where("uuid = ?", uuid).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// PrintJobClaim marks the oldest queued print job running and returns it, or nil if there is none. A job
+// still running since before staleBefore is taken to be abandoned by a worker that stopped, and claimed
+// again. Several workers, of several instances, may claim concurrently: a job is only claimed by one of them
+func (repo *repository) PrintJobClaim(ctx context.Context, staleBefore time.Time) (*model.PrintJob, error) {
+	db := repo.db.WithContext(ctx)
+	claimable := db.Where("status = ? OR (status = ? AND updated_at < ?)", model.PrintQueued, model.PrintRunning, staleBefore)
+	for {
+		var job model.PrintJob
+		if err := db.Where(claimable).Order("id").Limit(1).Find(&job).Error; err != nil || job.ID == 0 {
+			return nil, err
+		}
+		now := time.Now()
+		result := db.Model(&model.PrintJob{}).Where("id = ?", job.ID).Where(claimable).
+			Updates(map[string]interface{}{"status": model.PrintRunning, "updated_at": now})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status, job.UpdatedAt = model.PrintRunning, now
+			return &job, nil
+		}
+		// Another worker claimed it first.
+	}
+}
+
+// PrintJobSave updates a print job
+func (repo *repository) PrintJobSave(ctx context.Context, job *model.PrintJob) error {
+	return repo.db.WithContext(ctx).Save(job).Error
+}
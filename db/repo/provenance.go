@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// Operation backing the audit API
+
+// provenanceScope applies the filter to a query on one of the slot tables
+func provenanceScope(filter model.ProvenanceFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.EmployeeID != 0 {
+			db = db.Where("employee_id = ?", filter.EmployeeID)
+		}
+		if filter.Source != "" {
+			db = db.Where("source = ?", filter.Source)
+		}
+		if filter.ChangedByID != nil {
+			db = db.Where("changed_by_id = ?", *filter.ChangedByID)
+		}
+		if !filter.Since.IsZero() {
+			db = db.Where("updated_at >= ?", filter.Since)
+		}
+		return db
+	}
+}
+
+// ProvenanceFind lists the recurring slots, overrides and deltas matching the filter with their provenance,
+// the most recently changed first
+func (repo *repository) ProvenanceFind(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error) {
+	db := repo.db.WithContext(ctx)
+	var schedules []model.Schedule
+	if err := db.Scopes(provenanceScope(filter)).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	var overrides []model.ScheduleOverride
+	if err := db.Scopes(provenanceScope(filter)).Preload("Slots").Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	var deltas []model.ScheduleDelta
+	if err := db.Scopes(provenanceScope(filter)).Find(&deltas).Error; err != nil {
+		return nil, err
+	}
+
+	slots := make([]model.SlotProvenance, 0, len(schedules)+len(overrides)+len(deltas))
+	for _, s := range schedules {
+		slots = append(slots, model.SlotProvenance{
+			Kind: model.SlotKindSchedule, ID: s.ID, UUID: s.UUID, EmployeeID: s.EmployeeID, WeekType: s.WeekType, DayName: s.DayName,
+			TimeSlots: []model.TimeSlot{timeSlot(s.StartTime, s.EndTime, s.Location, s.Task)},
+			Source:    s.Source, ChangedByID: s.ChangedByID, UpdatedAt: s.UpdatedAt,
+		})
+	}
+	for _, o := range overrides {
+		timeSlots := make([]model.TimeSlot, len(o.Slots))
+		for i, slot := range o.Slots {
+			timeSlots[i] = timeSlot(slot.StartTime, slot.EndTime, slot.Location, slot.Task)
+		}
+		slots = append(slots, model.SlotProvenance{
+			Kind: model.SlotKindOverride, ID: o.ID, UUID: o.UUID, EmployeeID: o.EmployeeID, Date: o.Date.Format("2006-01-02"),
+			DayName: o.Date.Weekday().String(), Off: o.Off, TimeSlots: timeSlots,
+			Source: o.Source, ChangedByID: o.ChangedByID, UpdatedAt: o.UpdatedAt,
+		})
+	}
+	for _, d := range deltas {
+		slots = append(slots, model.SlotProvenance{
+			Kind: model.SlotKindDelta, ID: d.ID, UUID: d.UUID, EmployeeID: d.EmployeeID, WeekType: d.WeekType, DayName: d.DayName,
+			Action: d.Action, TimeSlots: []model.TimeSlot{timeSlot(d.StartTime, d.EndTime, d.Location, d.Task)},
+			Source: d.Source, ChangedByID: d.ChangedByID, UpdatedAt: d.UpdatedAt,
+		})
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].UpdatedAt.After(slots[j].UpdatedAt) })
+
+	// Name the users who changed the slots.
+	var userIDs []uint
+	for _, slot := range slots {
+		if slot.ChangedByID != nil {
+			userIDs = append(userIDs, *slot.ChangedByID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return slots, nil
+	}
+	var users []model.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	for i, slot := range slots {
+		if slot.ChangedByID != nil {
+			slots[i].ChangedBy = usernames[*slot.ChangedByID]
+		}
+	}
+	return slots, nil
+}
+
+func timeSlot(start, end model.CustomTime, location, task string) model.TimeSlot {
+	return model.TimeSlot{Start: start.Format("15:04"), End: end.Format("15:04"), Location: location, Task: task}
+}
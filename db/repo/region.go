@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+)
+
+// Holiday regions
+//
+// The public holidays differ between regions: Alsace-Moselle has two more than mainland France and each
+// overseas department its own. The request context carries the region its calendars are built for, and the
+// operations on the holidays table only see the holidays of that region, mainland France by default.
+
+type holidayRegionKey struct{}
+
+// WithHolidayRegion returns a copy of ctx confining the holiday operations to region
+func WithHolidayRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, holidayRegionKey{}, region)
+}
+
+// HolidayRegionFromContext returns the holiday region of ctx, and whether one was set
+func HolidayRegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(holidayRegionKey{}).(string)
+	return region, ok && region != ""
+}
+
+// holidayRegion returns the holiday region of ctx, mainland France when none was set
+func holidayRegion(ctx context.Context) string {
+	if region, ok := HolidayRegionFromContext(ctx); ok {
+		return region
+	}
+	return model.RegionMetropole
+}
+
+// dropRegionlessHolidays drops the holidays table created before regions existed, keyed by date alone. The
+// table only caches the public holiday API, so it is refilled on demand.
+func (repo *repository) dropRegionlessHolidays(ctx context.Context) error {
+	migrator := repo.db.WithContext(ctx).Migrator()
+	if !migrator.HasTable(&model.Holiday{}) || migrator.HasColumn(&model.Holiday{}, "Region") {
+		return nil
+	}
+	return migrator.DropTable(&model.Holiday{})
+}
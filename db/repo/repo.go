@@ -1,31 +1,156 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/lichensio/api_server/db/model"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
+//go:generate go run ../../internal/mockgen -interface Repository -out repository_mock.go
+
+// Repository is the storage of the service. The tests use repotest.NewInMemoryRepository, or RepositoryMock
+// to script the answers of the database; run go generate after changing it.
 type Repository interface {
-	LoadEmployees(employees []*model.Employee) error
-	UpdateEmployee(employee model.Employee) error
-	UpdateSchedule(schedule model.Schedule) error
-	GetSchedule(employeeID uint, weekType string) ([]model.Schedule, error)
-	GetEmployees() ([]model.Employee, error)
-	GetEmployeeWithSchedulesByWeekType(employeeID uint, weekType string) (*model.Employee, error)
-	CleanupDatabase()
-	GetEmployeeByID(id uint, emp *model.Employee) error
-	GetEmployeeWithSchedules(id uint) (*model.Employee, error)
-	DBCreate() error
-	DBDelete() error
-	HolidayCreate(holiday *model.Holiday) error
-	HolidayFindByDate(date time.Time) (*model.Holiday, error)
-	HolidayUpdate(holiday *model.Holiday) error
-	HolidayListAll() ([]model.Holiday, error)
-	HolidayFindByMonthAndYear(year int, month time.Month) ([]model.Holiday, error)
+	Transaction(ctx context.Context, fn func(tx Repository) error) error
+	LoadEmployees(ctx context.Context, employees []*model.Employee) error
+	CreateSchedulesBatch(ctx context.Context, schedules []model.Schedule) error
+	ImportEmployees(ctx context.Context, record *model.EmployeeImport, employees []*model.Employee, upsert bool) (int, error)
+	ImportFindByHash(ctx context.Context, hash string) (*model.EmployeeImport, error)
+	ImportList(ctx context.Context, limit int) ([]model.EmployeeImport, error)
+	RollbackImport(ctx context.Context, id uint) (*model.EmployeeImport, error)
+	UpdateEmployee(ctx context.Context, employee model.Employee) error
+	UpdateSchedule(ctx context.Context, schedule model.Schedule) error
+	GetScheduleByID(ctx context.Context, id uint) (*model.Schedule, error)
+	DeleteSchedule(ctx context.Context, id uint) error
+	ReplaceSchedules(ctx context.Context, employeeID uint, weekType, dayName string, schedules []model.Schedule) error
+	ReplaceSchedulesFrom(ctx context.Context, version *model.ScheduleVersion, weekType, dayName string, schedules []model.Schedule) error
+	ScheduleVersionList(ctx context.Context, employeeID uint) ([]model.ScheduleVersion, error)
+	ScheduleVersionCreate(ctx context.Context, version *model.ScheduleVersion) error
+	DeleteSchedules(ctx context.Context, employeeID uint, weekType, dayName string) (int64, error)
+	UpdateScheduleTask(ctx context.Context, id uint, task string, changedByID *uint) error
+	GetSchedule(ctx context.Context, employeeID uint, weekType string) ([]model.Schedule, error)
+	GetEmployees(ctx context.Context) ([]model.Employee, error)
+	SearchEmployees(ctx context.Context, query string, limit int) ([]model.Employee, error)
+	GetEmployeeWithSchedulesByWeekType(ctx context.Context, employeeID uint, weekType string) (*model.Employee, error)
+	CleanupDatabase(ctx context.Context)
+	GetEmployeeByID(ctx context.Context, id uint, emp *model.Employee) error
+	GetEmployeeWithSchedules(ctx context.Context, id uint) (*model.Employee, error)
+	GetEmployeeIncluding(ctx context.Context, id uint, relations ...string) (*model.Employee, error)
+	GetEmployeesWithSchedules(ctx context.Context) ([]model.Employee, error)
+	GetEmployeesWithCalendar(ctx context.Context, ids []uint, from, to time.Time) ([]model.Employee, error)
+	EmployeesLastModified(ctx context.Context) (time.Time, error)
+	UnscheduledEmployeeIDs(ctx context.Context) ([]uint, error)
+	EmployeesChangedSince(ctx context.Context, since time.Time) ([]model.Employee, error)
+	TouchEmployees(ctx context.Context, ids []uint) (int64, error)
+	ArchiveEmployee(ctx context.Context, id uint) error
+	DeactivateEmployees(ctx context.Context, ids []uint, endDate time.Time) error
+	ArchivedEmployees(ctx context.Context) ([]model.Employee, error)
+	RestoreEmployee(ctx context.Context, id uint) error
+	SaveScheduleSnapshot(ctx context.Context, employeeID uint, snapshot *model.ScheduleSnapshot) error
+	DBCreate(ctx context.Context) error
+	MigrationStatus(ctx context.Context) ([]model.MigrationStatus, error)
+	TableRowCounts(ctx context.Context) ([]model.TableRowCount, error)
+	IDByUUID(ctx context.Context, resource interface{}, uuid string) (uint, error)
+	DBDelete(ctx context.Context) error
+	HolidayCreate(ctx context.Context, holiday *model.Holiday) error
+	HolidayFindByDate(ctx context.Context, date time.Time) (*model.Holiday, error)
+	HolidayUpdate(ctx context.Context, holiday *model.Holiday) error
+	HolidayListAll(ctx context.Context) ([]model.Holiday, error)
+	HolidayFindByMonthAndYear(ctx context.Context, year int, month time.Month) ([]model.Holiday, error)
+	HolidayFindByYear(ctx context.Context, year int) ([]model.Holiday, error)
+	HolidayUpsert(ctx context.Context, holidays []model.Holiday) error
+	HolidayDeleteYear(ctx context.Context, year int) (int64, error)
+	UserCreate(ctx context.Context, user *model.User) error
+	UserFindByUsername(ctx context.Context, username string) (*model.User, error)
+	UserUpdatePasswordHash(ctx context.Context, id uint, hash string) error
+	PairingRuleCreate(ctx context.Context, rule *model.PairingRule) error
+	PairingRuleList(ctx context.Context) ([]model.PairingRule, error)
+	PairingRuleDelete(ctx context.Context, id uint) error
+	StaffingRequirementCreate(ctx context.Context, requirement *model.StaffingRequirement) error
+	StaffingRequirementList(ctx context.Context) ([]model.StaffingRequirement, error)
+	StaffingRequirementDelete(ctx context.Context, id uint) error
+	UnavailabilityCreate(ctx context.Context, unavailability *model.Unavailability) error
+	UnavailabilityUpdate(ctx context.Context, unavailability *model.Unavailability) error
+	UnavailabilityList(ctx context.Context) ([]model.Unavailability, error)
+	UnavailabilityListByEmployee(ctx context.Context, employeeID uint) ([]model.Unavailability, error)
+	UnavailabilityDelete(ctx context.Context, employeeID, id uint) error
+	ClosureDayCreate(ctx context.Context, closure *model.ClosureDay) error
+	ClosureDayUpdate(ctx context.Context, closure *model.ClosureDay) error
+	ClosureDayFindBetween(ctx context.Context, from, to time.Time) ([]model.ClosureDay, error)
+	ClosureDayDelete(ctx context.Context, id uint) error
+	PlannedHoursByWeekType(ctx context.Context) ([]model.EmployeeWeekTypeHours, error)
+	ContractedHoursByDepartment(ctx context.Context) (map[string]float64, error)
+	ForecastUpsert(ctx context.Context, forecasts []model.DemandForecast) error
+	ForecastFindBetween(ctx context.Context, from, to time.Time) ([]model.DemandForecast, error)
+	RevenueUpsert(ctx context.Context, revenues []model.DailyRevenue) error
+	RevenueFindBetween(ctx context.Context, from, to time.Time) ([]model.DailyRevenue, error)
+	RotationCreate(ctx context.Context, pattern *model.RotationPattern) error
+	RotationList(ctx context.Context) ([]model.RotationPattern, error)
+	RotationFindByID(ctx context.Context, id uint) (*model.RotationPattern, error)
+	RotationFindByName(ctx context.Context, name string) (*model.RotationPattern, error)
+	SetEmployeeRotation(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error
+	RotationCalendarGet(ctx context.Context) (*model.RotationCalendar, error)
+	RotationCalendarSet(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error)
+	ValidationSettingsGet(ctx context.Context) (*model.ValidationSettings, error)
+	ValidationSettingsSet(ctx context.Context, settings *model.ValidationSettings) error
+	CalendarLinkGet(ctx context.Context, employeeID uint) (*model.CalendarLink, error)
+	CalendarLinkList(ctx context.Context) ([]model.CalendarLink, error)
+	CalendarLinkSave(ctx context.Context, link *model.CalendarLink) error
+	CalendarLinkDelete(ctx context.Context, employeeID uint) error
+	WebhookCreate(ctx context.Context, hook *model.Webhook) error
+	WebhookList(ctx context.Context) ([]model.Webhook, error)
+	WebhookFindByID(ctx context.Context, id uint) (*model.Webhook, error)
+	WebhookUpdate(ctx context.Context, hook *model.Webhook) error
+	WebhookSetSecret(ctx context.Context, id uint, secret string) error
+	WebhookDelete(ctx context.Context, id uint) error
+	WebhookDeliveryCreate(ctx context.Context, deliveries []model.WebhookDelivery) error
+	WebhookDeliveryList(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	WebhookDeliveryClaim(ctx context.Context, now, staleBefore time.Time) (*model.WebhookDelivery, error)
+	WebhookDeliverySave(ctx context.Context, delivery *model.WebhookDelivery) error
+	TimeEntryCreate(ctx context.Context, entry *model.TimeEntry) error
+	TimeEntryFindByNonce(ctx context.Context, deviceID, nonce string) (*model.TimeEntry, error)
+	TimeEntryLastSequence(ctx context.Context, deviceID string) (int64, error)
+	TimeEntryFindNear(ctx context.Context, employeeID uint, kind string, from, to time.Time) (*model.TimeEntry, error)
+	TimeEntryFindBetween(ctx context.Context, employeeID uint, from, to time.Time) ([]model.TimeEntry, error)
+	TimeEntryListBetween(ctx context.Context, from, to time.Time) ([]model.TimeEntry, error)
+	PrintJobCreate(ctx context.Context, job *model.PrintJob) error
+	PrintJobFindByID(ctx context.Context, id uint) (*model.PrintJob, error)
+	PrintJobFindByUUID(ctx context.Context, uuid string) (*model.PrintJob, error)
+	PrintJobClaim(ctx context.Context, staleBefore time.Time) (*model.PrintJob, error)
+	PrintJobSave(ctx context.Context, job *model.PrintJob) error
+	ClockedHours(ctx context.Context, employeeIDs []uint, from, to time.Time) (map[uint]float64, error)
+	RoleTemplateCreate(ctx context.Context, template *model.RoleTemplate) error
+	RoleTemplateList(ctx context.Context) ([]model.RoleTemplate, error)
+	RoleTemplateFindByID(ctx context.Context, id uint) (*model.RoleTemplate, error)
+	RoleTemplateFindByName(ctx context.Context, name string) (*model.RoleTemplate, error)
+	RoleTemplateReplaceSlots(ctx context.Context, id uint, slots []model.RoleTemplateSlot) error
+	EmployeesByRoleTemplate(ctx context.Context, templateID uint) ([]model.Employee, error)
+	SetEmployeeRoleTemplate(ctx context.Context, employeeID uint, templateID *uint) error
+	DetachRoleTemplate(ctx context.Context, employeeID uint, inherited []model.Schedule) error
+	DeltaCreate(ctx context.Context, delta *model.ScheduleDelta) error
+	DeltaListByEmployee(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error)
+	DeltaDelete(ctx context.Context, employeeID, id uint) error
+	LeaveCreate(ctx context.Context, employeeID uint, days []model.EmployeeHoliday) error
+	LeaveFindByID(ctx context.Context, id uint) (*model.EmployeeHoliday, error)
+	LeaveFindBetween(ctx context.Context, employeeID uint, from, to time.Time, status string) ([]model.EmployeeHoliday, error)
+	LeaveFindAllBetween(ctx context.Context, from, to time.Time, status string) ([]model.EmployeeHoliday, error)
+	LeaveDecide(ctx context.Context, requestID uint, status string, approverID *uint) ([]model.EmployeeHoliday, error)
+	LeaveDeleteBetween(ctx context.Context, employeeID uint, from, to time.Time) (int64, error)
+	OverrideSave(ctx context.Context, override *model.ScheduleOverride) error
+	OverrideFindBetween(ctx context.Context, employeeID uint, from, to time.Time) ([]model.ScheduleOverride, error)
+	OverrideDelete(ctx context.Context, employeeID uint, date time.Time) error
+	ProvenanceFind(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error)
+	TenantCreate(ctx context.Context, tenant *model.Tenant) error
+	TenantFindByAPIKeyHash(ctx context.Context, hash string) (*model.Tenant, error)
+	TenantFindBySubdomain(ctx context.Context, subdomain string) (*model.Tenant, error)
 	// Define more methods for analytics or other operations as needed
 }
 
@@ -33,162 +158,738 @@ type repository struct {
 	db *gorm.DB
 }
 
-func (r *repository) GetEmployeeByID(id uint, emp *model.Employee) error {
-	result := r.db.First(emp, id)
+func (r *repository) GetEmployeeByID(ctx context.Context, id uint, emp *model.Employee) error {
+	result := r.db.WithContext(ctx).First(emp, id)
 	return result.Error
 }
 
 func NewRepositoryWithDB(db *gorm.DB) Repository {
+	registerTenantScope(db)
 	return &repository{db: db}
 }
 
-func NewRepository(dsn string) (Repository, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Drivers of the databases Open can open
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Open opens the database at dsn with driver, DriverPostgres or DriverSQLite for which dsn is the path of the
+// database file or a file: URI. SQLite waits for the locks of the other connections rather than failing at once.
+// Unless config has a logger, the failed and slow queries are logged with the ID of their request
+func Open(driver, dsn string, config *gorm.Config) (*gorm.DB, error) {
+	if config.Logger == nil {
+		config.Logger = newSQLLogger()
+	}
+	switch driver {
+	case "", DriverPostgres:
+		return gorm.Open(postgres.Open(dsn), config)
+	case DriverSQLite:
+		if !strings.Contains(dsn, "_busy_timeout") {
+			sep := "?"
+			if strings.Contains(dsn, "?") {
+				sep = "&"
+			}
+			dsn += sep + "_busy_timeout=5000"
+		}
+		return gorm.Open(sqlite.Open(dsn), config)
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, expected %s or %s", driver, DriverPostgres, DriverSQLite)
+	}
+}
+
+// NewRepository opens the database at dsn with driver, see Open, and migrates its schema
+func NewRepository(driver, dsn string) (Repository, error) {
+	db, err := Open(driver, dsn, &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 
+	registerTenantScope(db)
+	r := &repository{db: db}
 	// Migrate the schema
-	err = db.AutoMigrate(&model.Employee{}, &model.Schedule{})
-	if err != nil {
+	if err := r.DBCreate(context.Background()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Transaction runs fn with a repository whose operations all belong to one transaction, committed if fn
+// returns nil and rolled back otherwise. The operations that run in a transaction of their own join it,
+// through a savepoint
+func (r *repository) Transaction(ctx context.Context, fn func(tx Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx})
+	})
+}
+
+// insertBatchSize is the number of rows per INSERT of a bulk insert, well under the 65535 parameters of a
+// PostgreSQL statement for the widest of the tables written in bulk
+const insertBatchSize = 500
+
+// LoadEmployees creates the employees along with their schedules in a single transaction
+func (r *repository) LoadEmployees(ctx context.Context, employees []*model.Employee) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createEmployees(ctx, &repository{db: tx}, employees)
+	})
+}
+
+// CreateSchedulesBatch creates the schedule slots with a few multi-row INSERTs rather than one per slot, in
+// a single transaction. The IDs and UUIDs of the slots are set
+func (r *repository) CreateSchedulesBatch(ctx context.Context, schedules []model.Schedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(&schedules, insertBatchSize).Error
+}
+
+// createEmployees creates the employees in the transaction tx, then all their schedules with
+// CreateSchedulesBatch: saved as associations, the slots of a large roster would go in one INSERT over the
+// parameter limit
+func createEmployees(ctx context.Context, tx *repository, employees []*model.Employee) error {
+	if len(employees) == 0 {
+		return nil
+	}
+	if err := tx.db.WithContext(ctx).Omit("Schedules").CreateInBatches(&employees, insertBatchSize).Error; err != nil {
+		return err
+	}
+	var schedules []model.Schedule
+	for _, employee := range employees {
+		for i := range employee.Schedules {
+			employee.Schedules[i].EmployeeID = employee.ID
+		}
+		schedules = append(schedules, employee.Schedules...)
+	}
+	if err := tx.CreateSchedulesBatch(ctx, schedules); err != nil {
+		return err
+	}
+	// The slots created carry their IDs and UUIDs, which the slots of the employees get back
+	n := 0
+	for _, employee := range employees {
+		n += copy(employee.Schedules, schedules[n:])
+	}
+	return nil
+}
+
+// ImportEmployees creates the employees and records the import they come from in a single transaction.
+// An employee with an ID replaces the existing employee with that ID, and with upsert, an employee matching
+// an existing one by name and start date replaces it too: its attributes and own schedules are overwritten
+// and its ID is set. Each employee is recorded as a change of the import, with the state it overwrote for
+// those replaced, so that RollbackImport can reverse it. It returns the number of employees replaced
+func (r *repository) ImportEmployees(ctx context.Context, record *model.EmployeeImport, employees []*model.Employee, upsert bool) (int, error) {
+	replaced := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		previous := make(map[*model.Employee]*model.EmployeeState)
+		replace := func(employee *model.Employee) error {
+			state, err := employeeState(tx, employee.ID)
+			if err != nil {
+				return err
+			}
+			previous[employee] = state
+			return replaceEmployee(ctx, tx, employee)
+		}
+		created := make([]*model.Employee, 0, len(employees))
+		for _, employee := range employees {
+			if employee.ID != 0 {
+				if err := replace(employee); err != nil {
+					return err
+				}
+				replaced++
+				continue
+			}
+			if !upsert {
+				created = append(created, employee)
+				continue
+			}
+			var existing model.Employee
+			err := tx.Where("name = ? AND start_date = ?", employee.Name, employee.StartDate).Order("id").First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				created = append(created, employee)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			employee.ID = existing.ID
+			if err := replace(employee); err != nil {
+				return err
+			}
+			replaced++
+		}
+		if err := createEmployees(ctx, &repository{db: tx}, created); err != nil {
+			return err
+		}
+		return recordImportChanges(tx, record, employees, previous)
+	})
+	return replaced, err
+}
+
+// replaceEmployee overwrites the attributes and own schedules of the existing employee with the ID of employee.
+// Its end date is only overwritten by another one, an import does not reactivate an employee
+func replaceEmployee(ctx context.Context, tx *gorm.DB, employee *model.Employee) error {
+	columns := map[string]interface{}{
+		"department":            employee.Department,
+		"contract_weekly_hours": employee.ContractWeeklyHours,
+		"hourly_rate":           employee.HourlyRate,
+		"email":                 employee.Email,
+		"phone":                 employee.Phone,
+		"role":                  employee.Role,
+		"color":                 employee.Color,
+		"role_template_id":      employee.RoleTemplateID,
+		"rotation_pattern_id":   employee.RotationPatternID,
+		"rotation_anchor":       employee.RotationAnchor,
+	}
+	if employee.EndDate != nil {
+		columns["end_date"] = employee.EndDate
+	}
+	if err := tx.Model(&model.Employee{ID: employee.ID}).Updates(columns).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("employee_id = ?", employee.ID).Delete(&model.Schedule{}).Error; err != nil {
+		return err
+	}
+	for i := range employee.Schedules {
+		employee.Schedules[i].EmployeeID = employee.ID
+	}
+	return (&repository{db: tx}).CreateSchedulesBatch(ctx, employee.Schedules)
+}
+
+// ImportFindByHash retrieves the import of the payload with the given hash
+func (r *repository) ImportFindByHash(ctx context.Context, hash string) (*model.EmployeeImport, error) {
+	var record model.EmployeeImport
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&record).Error; err != nil {
 		return nil, err
 	}
+	return &record, nil
+}
 
-	return &repository{db: db}, nil
+// UpdateEmployee saves every column of an employee but its UUID, which never changes
+func (r *repository) UpdateEmployee(ctx context.Context, employee model.Employee) error {
+	return r.db.WithContext(ctx).Omit("uuid").Save(&employee).Error
 }
 
-func (r *repository) LoadEmployees(employees []*model.Employee) error {
-	return r.db.Create(&employees).Error
+// UpdateSchedule saves every column of a schedule slot but its UUID, which never changes
+func (r *repository) UpdateSchedule(ctx context.Context, schedule model.Schedule) error {
+	return r.db.WithContext(ctx).Omit("uuid").Save(&schedule).Error
+}
+
+// GetScheduleByID retrieves a single schedule slot by its primary key.
+func (r *repository) GetScheduleByID(ctx context.Context, id uint) (*model.Schedule, error) {
+	var schedule model.Schedule
+	if err := r.db.WithContext(ctx).First(&schedule, id).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule removes a schedule slot, returning gorm.ErrRecordNotFound if it does not exist.
+func (r *repository) DeleteSchedule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var schedule model.Schedule
+		if err := tx.First(&schedule, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&schedule).Error; err != nil {
+			return err
+		}
+		return touchEmployee(tx, schedule.EmployeeID)
+	})
+}
+
+// ReplaceSchedules replaces the slots of an employee of weekType on dayName, empty for any, with schedules in
+// a single transaction. The IDs and UUIDs of the new slots are set
+func (r *repository) ReplaceSchedules(ctx context.Context, employeeID uint, weekType, dayName string, schedules []model.Schedule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scheduleScope(tx, employeeID, weekType, dayName).Delete(&model.Schedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) > 0 {
+			if err := tx.CreateInBatches(&schedules, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return touchEmployee(tx, employeeID)
+	})
+}
+
+// DeleteSchedules removes the slots of an employee of weekType on dayName, empty for any, and returns how
+// many were removed
+func (r *repository) DeleteSchedules(ctx context.Context, employeeID uint, weekType, dayName string) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := scheduleScope(tx, employeeID, weekType, dayName).Delete(&model.Schedule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if deleted = result.RowsAffected; deleted == 0 {
+			return nil
+		}
+		return touchEmployee(tx, employeeID)
+	})
+	return deleted, err
+}
+
+// scheduleScope selects the slots of an employee of weekType on dayName, empty for any
+func scheduleScope(tx *gorm.DB, employeeID uint, weekType, dayName string) *gorm.DB {
+	tx = tx.Where("employee_id = ?", employeeID)
+	if weekType != "" {
+		tx = tx.Where("week_type = ?", weekType)
+	}
+	if dayName != "" {
+		tx = tx.Where("day_name = ?", dayName)
+	}
+	return tx
 }
 
-func (r *repository) UpdateEmployee(employee model.Employee) error {
-	return r.db.Save(&employee).Error
+// touchEmployee bumps the modification time of an employee whose slots changed without the employee row itself
+// being updated, so that its Last-Modified reflects removals
+func touchEmployee(tx *gorm.DB, employeeID uint) error {
+	return tx.Model(&model.Employee{}).Where("id = ?", employeeID).Update("updated_at", time.Now()).Error
 }
 
-func (r *repository) UpdateSchedule(schedule model.Schedule) error {
-	return r.db.Save(&schedule).Error
+// UpdateScheduleTask sets the task of a schedule slot, which becomes a manual edit of changedByID, returning
+// gorm.ErrRecordNotFound if it does not exist.
+func (r *repository) UpdateScheduleTask(ctx context.Context, id uint, task string, changedByID *uint) error {
+	result := r.db.WithContext(ctx).Model(&model.Schedule{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"task": task, "source": model.SourceManual, "changed_by_id": changedByID, "updated_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-func (r *repository) GetSchedule(employeeID uint, weekType string) ([]model.Schedule, error) {
+func (r *repository) GetSchedule(ctx context.Context, employeeID uint, weekType string) ([]model.Schedule, error) {
 	var schedules []model.Schedule
-	err := r.db.Where("employee_id = ? AND week_type = ?", employeeID, weekType).Find(&schedules).Error
+	err := r.db.WithContext(ctx).Where("employee_id = ? AND week_type = ?", employeeID, weekType).Find(&schedules).Error
 	return schedules, err
 }
 
-func (r *repository) GetEmployees() ([]model.Employee, error) {
+func (r *repository) GetEmployees(ctx context.Context) ([]model.Employee, error) {
 	var employees []model.Employee
-	err := r.db.Find(&employees).Error
+	err := r.db.WithContext(ctx).Find(&employees).Error
 	return employees, err
 }
 
-func (r *repository) GetEmployeeWithSchedules(employeeID uint) (*model.Employee, error) {
+// PreloadError reports a relation of an employee that failed to load, as opposed to a relation that has no
+// rows: the employee exists, but its calendar would be incomplete
+type PreloadError struct {
+	EmployeeID uint
+	// Relation is what failed to load: "schedules", "role template" or "deltas"
+	Relation string
+	Err      error
+}
+
+func (e *PreloadError) Error() string {
+	return fmt.Sprintf("failed to load the %s of employee %d: %v", e.Relation, e.EmployeeID, e.Err)
+}
+
+func (e *PreloadError) Unwrap() error { return e.Err }
+
+// GetEmployeeWithSchedules retrieves an employee with its own slots, its role template with its slots, its
+// deltas and its schedule versions. Each relation is loaded by a query of its own, so that a relation that
+// failed to load is reported as a *PreloadError rather than left empty
+func (r *repository) GetEmployeeWithSchedules(ctx context.Context, employeeID uint) (*model.Employee, error) {
+	db := r.db.WithContext(ctx)
 	var employee model.Employee
-	if err := r.db.Preload("Schedules").First(&employee, employeeID).Error; err != nil {
+	if err := db.First(&employee, employeeID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get employee %d: %w", employeeID, err)
+	}
+	if err := db.Where("employee_id = ?", employee.ID).Order("id").Find(&employee.Schedules).Error; err != nil {
+		return nil, &PreloadError{EmployeeID: employee.ID, Relation: "schedules", Err: err}
+	}
+	if employee.RoleTemplateID != nil {
+		var template model.RoleTemplate
+		err := db.Preload("Slots").Take(&template, *employee.RoleTemplateID).Error
+		switch {
+		case err == nil:
+			employee.RoleTemplate = &template
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, &PreloadError{EmployeeID: employee.ID, Relation: "role template", Err: err}
+		}
+	}
+	if err := db.Where("employee_id = ?", employee.ID).Order("id").Find(&employee.Deltas).Error; err != nil {
+		return nil, &PreloadError{EmployeeID: employee.ID, Relation: "deltas", Err: err}
+	}
+	if err := orderVersions(db.Where("employee_id = ?", employee.ID)).Find(&employee.ScheduleVersions).Error; err != nil {
+		return nil, &PreloadError{EmployeeID: employee.ID, Relation: "schedule versions", Err: err}
+	}
+	return &employee, nil
+}
+
+// GetEmployeeIncluding retrieves an employee with the relations named preloaded, by the names of the fields
+// of model.Employee such as "Schedules" or "LeaveDays", each ordered by ID
+func (r *repository) GetEmployeeIncluding(ctx context.Context, id uint, relations ...string) (*model.Employee, error) {
+	db := r.db.WithContext(ctx)
+	for _, relation := range relations {
+		db = db.Preload(relation, func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	}
+	var employee model.Employee
+	if err := db.First(&employee, id).Error; err != nil {
 		return nil, err
 	}
 	return &employee, nil
 }
 
-// Create DB
+// SaveScheduleSnapshot stores the denormalized calendar of an employee; a nil snapshot clears it. The
+// employee's updated_at is left alone since the calendar itself did not change
+func (r *repository) SaveScheduleSnapshot(ctx context.Context, employeeID uint, snapshot *model.ScheduleSnapshot) error {
+	return r.db.WithContext(ctx).Model(&model.Employee{ID: employeeID}).UpdateColumn("schedule_snapshot", snapshot).Error
+}
 
-func (r *repository) DBCreate() error {
-	if err := r.db.AutoMigrate(&model.Employee{}, &model.Schedule{}, &model.Holiday{}); err != nil {
-		log.Printf("Failed to migrate database schema: %v", err)
-		return err
+// GetEmployeesWithSchedules returns every employee with its own, inherited and delta slots and its schedule
+// versions preloaded
+func (r *repository) GetEmployeesWithSchedules(ctx context.Context) ([]model.Employee, error) {
+	var employees []model.Employee
+	err := r.db.WithContext(ctx).Preload("Schedules").Preload("RoleTemplate.Slots").Preload("Deltas").
+		Preload("ScheduleVersions", orderVersions).Order("id").Find(&employees).Error
+	return employees, err
+}
+
+// GetEmployeesWithCalendar returns the employees of ids, or every employee when ids is nil, with their slots
+// preloaded like GetEmployeesWithSchedules, together with their schedule overrides and approved leave days
+// from from to to included
+func (r *repository) GetEmployeesWithCalendar(ctx context.Context, ids []uint, from, to time.Time) ([]model.Employee, error) {
+	var employees []model.Employee
+	db := r.db.WithContext(ctx)
+	if ids != nil {
+		db = db.Where("id IN ?", ids)
+	}
+	err := db.Preload("Schedules").Preload("RoleTemplate.Slots").Preload("Deltas").Preload("ScheduleVersions", orderVersions).
+		Preload("Overrides", "date BETWEEN ? AND ?", from, to).
+		Preload("Overrides.Slots", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).
+		Preload("LeaveDays", "holiday_date BETWEEN ? AND ? AND status = ?", from, to, model.LeaveApproved).
+		Order("id").Find(&employees).Error
+	return employees, err
+}
+
+// EmployeesLastModified returns the latest modification time of the employees table, or the zero time if it is empty
+func (r *repository) EmployeesLastModified(ctx context.Context) (time.Time, error) {
+	var employee model.Employee
+	// Archived employees count: archiving one changes the list.
+	err := r.db.WithContext(ctx).Unscoped().Select("updated_at").Order("updated_at DESC").Take(&employee).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	return employee.UpdatedAt, err
+}
+
+// UnscheduledEmployeeIDs returns the IDs of the active employees pending a template: without a role template
+// nor a slot of their own
+func (r *repository) UnscheduledEmployeeIDs(ctx context.Context) ([]uint, error) {
+	db := r.db.WithContext(ctx)
+	var ids []uint
+	err := db.Model(&model.Employee{}).Where("role_template_id IS NULL").
+		Where("id NOT IN (?)", db.Model(&model.Schedule{}).Select("employee_id")).
+		Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// TouchEmployees bumps the updated_at of the given employees, or of every active employee if ids is empty, so
+// that caches and the delta-sync feed see them as changed. It returns the number of employees touched
+func (r *repository) TouchEmployees(ctx context.Context, ids []uint) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Employee{})
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	} else {
+		query = query.Where("1 = 1")
+	}
+	result := query.UpdateColumn("updated_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// EmployeesChangedSince returns, with their slots and deltas, the employees whose calendar changed after since:
+// the employee itself, one of its slots or deltas, or the role template it inherits from. Employees archived
+// since are included with their DeletedAt set
+func (r *repository) EmployeesChangedSince(ctx context.Context, since time.Time) ([]model.Employee, error) {
+	db := r.db.WithContext(ctx)
+	var employees []model.Employee
+	err := db.Unscoped().Preload("Schedules").Preload("Deltas").
+		Where("updated_at > ?", since).
+		Or("id IN (?)", db.Model(&model.Schedule{}).Select("employee_id").Where("updated_at > ?", since)).
+		Or("id IN (?)", db.Model(&model.ScheduleDelta{}).Select("employee_id").Where("updated_at > ?", since)).
+		Or("role_template_id IN (?)", db.Model(&model.RoleTemplate{}).Select("id").Where("updated_at > ?", since)).
+		Order("id").Find(&employees).Error
+	return employees, err
+}
+
+// DeactivateEmployees sets the end date of the given employees in a single transaction, returning
+// gorm.ErrRecordNotFound and changing nothing unless every one of them is active
+func (r *repository) DeactivateEmployees(ctx context.Context, ids []uint, endDate time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Employee{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"end_date": endDate, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ArchiveEmployee soft-deletes an employee, returning gorm.ErrRecordNotFound if there is no such active employee
+func (r *repository) ArchiveEmployee(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Bump updated_at first so that caches and the delta-sync feed see the change.
+		result := tx.Model(&model.Employee{}).Where("id = ?", id).Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Delete(&model.Employee{}, id).Error
+	})
+}
+
+// ArchivedEmployees retrieves the soft-deleted employees, most recently archived first
+func (r *repository) ArchivedEmployees(ctx context.Context) ([]model.Employee, error) {
+	var employees []model.Employee
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&employees).Error
+	return employees, err
+}
+
+// RestoreEmployee brings an archived employee back, returning gorm.ErrRecordNotFound if it is not archived
+func (r *repository) RestoreEmployee(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.Employee{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
-	log.Println("Database schema migrated successfully.")
 	return nil
 }
 
 // CleanupDatabase deletes all entries from the schedules and then the employees tables, holidays table.
 
-func (r *repository) CleanupDatabase() {
+func (r *repository) CleanupDatabase(ctx context.Context) {
+	db := r.db.WithContext(ctx)
 	// First, delete all entries from the schedules table.
-	if err := r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Schedule{}).Error; err != nil {
+	if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Schedule{}).Error; err != nil {
 		log.Fatalf("Failed to clean up schedules table: %v", err)
 	}
 
-	// Then, delete all entries from the employees table.
-	if err := r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Employee{}).Error; err != nil {
+	// Deltas also reference employees; the table only exists once role templates have been migrated.
+	if db.Migrator().HasTable(&model.ScheduleDelta{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ScheduleDelta{}).Error; err != nil {
+			log.Fatalf("Failed to clean up schedule deltas table: %v", err)
+		}
+	}
+
+	// So do the schedule versions.
+	if db.Migrator().HasTable(&model.ScheduleVersion{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ScheduleVersion{}).Error; err != nil {
+			log.Fatalf("Failed to clean up schedule versions table: %v", err)
+		}
+	}
+
+	// Leave days reference employees too.
+	if db.Migrator().HasTable(&model.EmployeeHoliday{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.EmployeeHoliday{}).Error; err != nil {
+			log.Fatalf("Failed to clean up employee holidays table: %v", err)
+		}
+	}
+
+	// So do schedule overrides, through their slots.
+	if db.Migrator().HasTable(&model.ScheduleOverride{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ScheduleOverrideSlot{}).Error; err != nil {
+			log.Fatalf("Failed to clean up schedule override slots table: %v", err)
+		}
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.ScheduleOverride{}).Error; err != nil {
+			log.Fatalf("Failed to clean up schedule overrides table: %v", err)
+		}
+	}
+
+	// And time entries.
+	if db.Migrator().HasTable(&model.TimeEntry{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.TimeEntry{}).Error; err != nil {
+			log.Fatalf("Failed to clean up time entries table: %v", err)
+		}
+	}
+
+	// And unavailabilities.
+	if db.Migrator().HasTable(&model.Unavailability{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Unavailability{}).Error; err != nil {
+			log.Fatalf("Failed to clean up unavailabilities table: %v", err)
+		}
+	}
+
+	// Forget the recorded imports so that the same payloads can be loaded again.
+	if db.Migrator().HasTable(&model.EmployeeImportChange{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.EmployeeImportChange{}).Error; err != nil {
+			log.Fatalf("Failed to clean up employee import changes table: %v", err)
+		}
+	}
+	if db.Migrator().HasTable(&model.EmployeeImport{}) {
+		if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.EmployeeImport{}).Error; err != nil {
+			log.Fatalf("Failed to clean up employee imports table: %v", err)
+		}
+	}
+
+	// Then, delete all entries from the employees table, archived ones included.
+	if err := db.Unscoped().Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Employee{}).Error; err != nil {
 		log.Fatalf("Failed to clean up employees table: %v", err)
 	}
 	// Then, delete all entries from the holidays table.
-	if err := r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Holiday{}).Error; err != nil {
+	if err := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&model.Holiday{}).Error; err != nil {
 		log.Fatalf("Failed to clean up holidays table: %v", err)
 	}
 }
 
-func (r *repository) GetEmployeeWithSchedulesByWeekType(employeeID uint, weekType string) (*model.Employee, error) {
+func (r *repository) GetEmployeeWithSchedulesByWeekType(ctx context.Context, employeeID uint, weekType string) (*model.Employee, error) {
 	var employee model.Employee
 
-	// Validate weekType input to ensure it's either "A" or "B".
-	if weekType != "A" && weekType != "B" {
-		return nil, fmt.Errorf("weekType must be either 'A' or 'B', got: %s", weekType)
-	}
-
 	// Preload schedules with a condition on the week type.
-	if err := r.db.Preload("Schedules", "week_type = ?", weekType).First(&employee, employeeID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Schedules", "week_type = ?", weekType).First(&employee, employeeID).Error; err != nil {
 		return nil, err
 	}
 
 	return &employee, nil
 }
 
-func (r *repository) DBDelete() error {
-	// Drop `schedules` table first due to the foreign key constraint with `employees`
-	if err := r.db.Migrator().DropTable(&model.Schedule{}); err != nil {
+func (r *repository) DBDelete(ctx context.Context) error {
+	// Dropping the tables would wipe every tenant, not only the one of the request.
+	if _, ok := TenantFromContext(ctx); ok {
+		return ErrTenantScoped
+	}
+	db := r.db.WithContext(ctx)
+	// Drop the tables referencing `employees` first due to the foreign key constraints
+	if err := db.Migrator().DropTable(&model.Schedule{}, &model.ScheduleDelta{}, &model.ScheduleVersion{}, &model.EmployeeHoliday{},
+		&model.ScheduleOverrideSlot{}, &model.ScheduleOverride{}, &model.TimeEntry{}, &model.Unavailability{}); err != nil {
 		return err
 	}
-	// Then drop `employees` table
-	if err := r.db.Migrator().DropTable(&model.Employee{}); err != nil {
+	// Then drop `employees` table, which references `role_templates` and `rotation_patterns`
+	if err := db.Migrator().DropTable(&model.Employee{}); err != nil {
 		return err
 	}
-	if err := r.db.Migrator().DropTable(&model.Holiday{}); err != nil {
+	if err := db.Migrator().DropTable(&model.RoleTemplateSlot{}, &model.RoleTemplate{}, &model.RotationWeek{}, &model.RotationPattern{}, &model.RotationCalendar{}); err != nil {
+		return err
+	}
+	if err := db.Migrator().DropTable(&model.Holiday{}); err != nil {
+		return err
+	}
+	if err := db.Migrator().DropTable(&model.DemandForecast{}, &model.DailyRevenue{}, &model.EmployeeImportChange{}, &model.EmployeeImport{}, &model.PairingRule{}, &model.Webhook{}, &model.WebhookDelivery{},
+		&model.StaffingRequirement{}, &model.ClosureDay{}, &model.ValidationSettings{}, &model.CalendarLink{}, &model.Tenant{}, &model.PrintJob{}, &model.SchemaMigration{}); err != nil {
 		return err
 	}
 	return nil
 }
 
 // Operation on holidays table
+//
+// Every operation is confined to the holiday region of the context
 
 // FindByDate retrieves a holiday by its date
-func (repo *repository) HolidayFindByDate(date time.Time) (*model.Holiday, error) {
+func (repo *repository) HolidayFindByDate(ctx context.Context, date time.Time) (*model.Holiday, error) {
 	var holiday model.Holiday
-	result := repo.db.First(&holiday, "holiday_date = ?", date)
+	result := repo.db.WithContext(ctx).First(&holiday, "region = ? AND holiday_date = ?", holidayRegion(ctx), date)
 	return &holiday, result.Error
 }
 
-// Create inserts a new holiday into the database
-func (repo *repository) HolidayCreate(holiday *model.Holiday) error {
-	result := repo.db.Create(holiday)
+// Create inserts a new holiday into the database, in the region of the context unless it has one
+func (repo *repository) HolidayCreate(ctx context.Context, holiday *model.Holiday) error {
+	if holiday.Region == "" {
+		holiday.Region = holidayRegion(ctx)
+	}
+	result := repo.db.WithContext(ctx).Create(holiday)
 	return result.Error
 }
 
 // Update updates an existing holiday record
-func (repo *repository) HolidayUpdate(holiday *model.Holiday) error {
-	result := repo.db.Save(holiday)
+func (repo *repository) HolidayUpdate(ctx context.Context, holiday *model.Holiday) error {
+	if holiday.Region == "" {
+		holiday.Region = holidayRegion(ctx)
+	}
+	result := repo.db.WithContext(ctx).Save(holiday)
 	return result.Error
 }
 
 // Delete removes a holiday record from the database
-func (repo *repository) HolidayDelete(date time.Time) error {
-	result := repo.db.Delete(&model.Holiday{}, "holiday_date = ?", date)
+func (repo *repository) HolidayDelete(ctx context.Context, date time.Time) error {
+	result := repo.db.WithContext(ctx).Delete(&model.Holiday{}, "region = ? AND holiday_date = ?", holidayRegion(ctx), date)
 	return result.Error
 }
 
 // ListAll retrieves all holiday records from the database
-func (repo *repository) HolidayListAll() ([]model.Holiday, error) {
+func (repo *repository) HolidayListAll(ctx context.Context) ([]model.Holiday, error) {
 	var holidays []model.Holiday
-	result := repo.db.Find(&holidays)
+	result := repo.db.WithContext(ctx).Where("region = ?", holidayRegion(ctx)).Find(&holidays)
 	return holidays, result.Error
 }
 
-func (repo *repository) HolidayFindByMonthAndYear(year int, month time.Month) ([]model.Holiday, error) {
+func (repo *repository) HolidayFindByMonthAndYear(ctx context.Context, year int, month time.Month) ([]model.Holiday, error) {
 	var holidays []model.Holiday
 	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
 	endOfMonth := startOfMonth.AddDate(0, 1, -1) // Last day of the month
 
 	// Query to find holidays within the given month and year
-	result := repo.db.Where("holiday_date BETWEEN ? AND ?", startOfMonth, endOfMonth).Find(&holidays)
+	result := repo.db.WithContext(ctx).Where("region = ? AND holiday_date BETWEEN ? AND ?", holidayRegion(ctx), startOfMonth, endOfMonth).Find(&holidays)
 	return holidays, result.Error
 }
+
+// HolidayFindByYear retrieves the holidays of a year, ordered by date
+func (repo *repository) HolidayFindByYear(ctx context.Context, year int) ([]model.Holiday, error) {
+	var holidays []model.Holiday
+	startOfYear := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	endOfYear := startOfYear.AddDate(1, 0, -1)
+	result := repo.db.WithContext(ctx).Where("region = ? AND holiday_date BETWEEN ? AND ?", holidayRegion(ctx), startOfYear, endOfYear).
+		Order("holiday_date").Find(&holidays)
+	return holidays, result.Error
+}
+
+// HolidayUpsert stores holidays in a single batch, in the region of the context unless they have one,
+// renaming those already stored for the same date
+func (repo *repository) HolidayUpsert(ctx context.Context, holidays []model.Holiday) error {
+	if len(holidays) == 0 {
+		return nil
+	}
+	for i := range holidays {
+		if holidays[i].Region == "" {
+			holidays[i].Region = holidayRegion(ctx)
+		}
+	}
+	return repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "region"}, {Name: "holiday_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"holiday_name"}),
+	}).Create(&holidays).Error
+}
+
+// HolidayDeleteYear removes the holidays cached for a year, so that they are fetched again from the API on
+// the next read, and returns how many were removed
+func (repo *repository) HolidayDeleteYear(ctx context.Context, year int) (int64, error) {
+	startOfYear := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	endOfYear := startOfYear.AddDate(1, 0, -1)
+	result := repo.db.WithContext(ctx).Where("region = ? AND holiday_date BETWEEN ? AND ?", holidayRegion(ctx), startOfYear, endOfYear).Delete(&model.Holiday{})
+	return result.RowsAffected, result.Error
+}
+
+// Operation on users table
+
+// UserCreate inserts a new user account
+func (repo *repository) UserCreate(ctx context.Context, user *model.User) error {
+	return repo.db.WithContext(ctx).Create(user).Error
+}
+
+// UserFindByUsername retrieves a user account by its unique username
+func (repo *repository) UserFindByUsername(ctx context.Context, username string) (*model.User, error) {
+	var user model.User
+	if err := repo.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UserUpdatePasswordHash replaces the password hash of a user account
+func (repo *repository) UserUpdatePasswordHash(ctx context.Context, id uint, hash string) error {
+	return repo.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("password_hash", hash).Error
+}
@@ -1,27 +1,29 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"github.com/lichensio/api_server/db/model"
 	"github.com/stretchr/testify/assert"
-	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-// setupTestDB initializes the test database, returns a gorm.DB instance and a cleanup function.
+// setupTestDB initializes the test database, returns a gorm.DB instance and a cleanup function. It is
+// the Postgres server of the .env file when there is one, a private in-memory sqlite database otherwise.
 func setupTestDB(t *testing.T) (*gorm.DB, func()) {
-	err := godotenv.Load() // Adjust to the correct path to your .env file
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	if godotenv.Load() != nil && os.Getenv("DB_HOST") == "" {
+		return setupMemoryDB(t)
 	}
-	require.NoError(t, err)
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
 		os.Getenv("DB_HOST"),
@@ -47,6 +49,17 @@ func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 	return db, cleanup
 }
 
+// setupMemoryDB returns a migrated in-memory sqlite database and a cleanup function.
+func setupMemoryDB(t *testing.T) (*gorm.DB, func()) {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, (&repository{db: db}).DBCreate(context.Background()))
+	return db, func() { sqlDB.Close() }
+}
+
 // Assuming repository and other necessary structures are correctly defined above.
 
 func TestLoadEmployees(t *testing.T) {
@@ -61,7 +74,7 @@ func TestLoadEmployees(t *testing.T) {
 		{Name: "Jane Doe", StartDate: currentTime},
 	}
 
-	err := repo.LoadEmployees(employees)
+	err := repo.LoadEmployees(context.Background(), employees)
 	require.NoError(t, err)
 
 	var dbEmployees []model.Employee
@@ -80,7 +93,7 @@ func TestGetEmployees(t *testing.T) {
 
 	repo := &repository{db: db} // Adjust according to how you instantiate the repository
 
-	repo.CleanupDatabase() // Assuming this properly cleans the test database
+	repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	currentTime := time.Now().UTC()
 
 	expectedEmployees := []model.Employee{
@@ -92,7 +105,7 @@ func TestGetEmployees(t *testing.T) {
 		require.NoError(t, db.Create(&emp).Error)
 	}
 
-	employees, err := repo.GetEmployees()
+	employees, err := repo.GetEmployees(context.Background())
 	require.NoError(t, err)
 	assert.Len(t, employees, len(expectedEmployees))
 	for _, emp := range employees {
@@ -110,13 +123,13 @@ func TestGetEmployeeByID(t *testing.T) {
 
 	// Setup: Create a test employee
 	emp := &model.Employee{Name: "Test Employee", StartDate: time.Now()}
-	err := repo.LoadEmployees([]*model.Employee{emp})
+	err := repo.LoadEmployees(context.Background(), []*model.Employee{emp})
 	require.NoError(t, err)
 	require.NotZero(t, emp.ID)
 
 	// Fetch the employee by ID
 	var fetchedEmp model.Employee
-	err = repo.GetEmployeeByID(emp.ID, &fetchedEmp)
+	err = repo.GetEmployeeByID(context.Background(), emp.ID, &fetchedEmp)
 	require.NoError(t, err)
 	assert.Equal(t, emp.Name, fetchedEmp.Name)
 }
@@ -129,24 +142,24 @@ func TestUpdateEmployee(t *testing.T) {
 	repo := &repository{db: db} // Adjust according to how you instantiate the repository
 
 	// Assuming a cleanup method on the repository interface; if not, adapt accordingly
-	repo.CleanupDatabase()
+	repo.CleanupDatabase(context.Background())
 
 	// Setup: Create an employee for testing
 	startDate := time.Now().UTC()
 	employee := &model.Employee{Name: "John Doe", StartDate: startDate}
 
-	err := repo.LoadEmployees([]*model.Employee{employee})
+	err := repo.LoadEmployees(context.Background(), []*model.Employee{employee})
 	require.NoError(t, err, "Failed to load employee")
 	require.NotZero(t, employee.ID, "Employee should have an ID after being loaded.")
 
 	// Update the employee's name
 	employee.Name = "John Updated"
-	err = repo.UpdateEmployee(*employee)
+	err = repo.UpdateEmployee(context.Background(), *employee)
 	require.NoError(t, err, "Failed to update employee")
 
 	// Retrieve and verify the updated employee
 	var updatedEmployee model.Employee
-	err = repo.GetEmployeeByID(employee.ID, &updatedEmployee) // Assuming GetEmployeeByID is correctly implemented
+	err = repo.GetEmployeeByID(context.Background(), employee.ID, &updatedEmployee) // Assuming GetEmployeeByID is correctly implemented
 	require.NoError(t, err, "Failed to retrieve updated employee")
 	assert.Equal(t, "John Updated", updatedEmployee.Name, "Employee name should be updated")
 }
@@ -159,12 +172,12 @@ func TestGetSchedule(t *testing.T) {
 	repo := &repository{db: db} // Adjust according to how you instantiate the repository
 
 	// Assuming a cleanup method on the repository interface; if not, adapt accordingly
-	repo.CleanupDatabase()
+	repo.CleanupDatabase(context.Background())
 
 	// Setup: Create an employee for testing
 	startDate := time.Now().UTC()
 	employee := &model.Employee{Name: "Jane Schedule", StartDate: startDate}
-	err := repo.LoadEmployees([]*model.Employee{employee})
+	err := repo.LoadEmployees(context.Background(), []*model.Employee{employee})
 	require.NoError(t, err)
 	require.NotZero(t, employee.ID, "Employee should have an ID after being loaded.")
 
@@ -179,11 +192,11 @@ func TestGetSchedule(t *testing.T) {
 		EndTime:    model.CustomTime{Time: formattedEndTime},
 	}
 
-	err = repo.UpdateSchedule(schedule)
+	err = repo.UpdateSchedule(context.Background(), schedule)
 	require.NoError(t, err)
 
 	// Test: Retrieve the schedule
-	schedules, err := repo.GetSchedule(employee.ID, "B")
+	schedules, err := repo.GetSchedule(context.Background(), employee.ID, "B")
 	require.NoError(t, err)
 	require.Len(t, schedules, 1, "Should retrieve exactly one schedule.")
 
@@ -200,8 +213,8 @@ func TestUpdateSchedule(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := &repository{db: db} // Adjust according to how you instantiate the repository
-	repo.CleanupDatabase()      // Assuming this properly cleans the test database
+	repo := &repository{db: db}                // Adjust according to how you instantiate the repository
+	repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Assuming an employee is already created for this test
 	employee := model.Employee{Name: "Test Employee", StartDate: time.Now()}
 	if err := db.Create(&employee).Error; err != nil {
@@ -222,7 +235,7 @@ func TestUpdateSchedule(t *testing.T) {
 
 	// Update the schedule
 	schedule.DayName = "Tuesday" // Changing the day to Tuesday
-	if err := repo.UpdateSchedule(schedule); err != nil {
+	if err := repo.UpdateSchedule(context.Background(), schedule); err != nil {
 		t.Fatalf("Failed to update schedule: %v", err)
 	}
 
@@ -239,8 +252,8 @@ func TestGetEmployeeWithSchedules(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := &repository{db: db} // Adjust according to how you instantiate the repository
-	repo.CleanupDatabase()      // Assuming this properly cleans the test database
+	repo := &repository{db: db}                // Adjust according to how you instantiate the repository
+	repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Create an employee and their schedule for testing
 	employee := model.Employee{Name: "Schedule Employee", StartDate: time.Now()}
 	if err := db.Create(&employee).Error; err != nil {
@@ -259,7 +272,7 @@ func TestGetEmployeeWithSchedules(t *testing.T) {
 	}
 
 	// Retrieve the employee with schedules
-	resultEmployee, err := repo.GetEmployeeWithSchedules(employee.ID)
+	resultEmployee, err := repo.GetEmployeeWithSchedules(context.Background(), employee.ID)
 	require.NoError(t, err)
 
 	assert.Equal(t, employee.Name, resultEmployee.Name)
@@ -267,12 +280,38 @@ func TestGetEmployeeWithSchedules(t *testing.T) {
 	assert.Equal(t, "Monday", resultEmployee.Schedules[0].DayName)
 }
 
+func TestGetEmployeeWithSchedulesReportsPreloadErrors(t *testing.T) {
+	// The test renames tables: it runs on a private database.
+	db, cleanup := setupMemoryDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	ctx := context.Background()
+	employee := &model.Employee{Name: "Unscheduled", StartDate: time.Now()}
+	require.NoError(t, repo.LoadEmployees(ctx, []*model.Employee{employee}))
+
+	// No slots is not a failure.
+	loaded, err := repo.GetEmployeeWithSchedules(ctx, employee.ID)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Schedules)
+	_, err = repo.GetEmployeeWithSchedules(ctx, employee.ID+1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	for _, relation := range []struct{ table, name string }{{"schedule_delta", "deltas"}, {"schedules", "schedules"}} {
+		require.NoError(t, db.Exec(fmt.Sprintf("ALTER TABLE %[1]s RENAME TO %[1]s_lost", relation.table)).Error)
+		_, err = repo.GetEmployeeWithSchedules(ctx, employee.ID)
+		var partial *PreloadError
+		require.ErrorAs(t, err, &partial)
+		assert.Equal(t, PreloadError{EmployeeID: employee.ID, Relation: relation.name, Err: partial.Err}, *partial)
+	}
+}
+
 func TestGetEmployeeWithSchedulesByWeekType(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := &repository{db: db} // Adjust according to how you instantiate the repository
-	repo.CleanupDatabase()      // Assuming this properly cleans the test database
+	repo := &repository{db: db}                // Adjust according to how you instantiate the repository
+	repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Create and insert a test employee
 	currentTime := time.Now().UTC()
 	employee := model.Employee{Name: "Employee With Schedules", StartDate: currentTime}
@@ -297,13 +336,13 @@ func TestGetEmployeeWithSchedulesByWeekType(t *testing.T) {
 	require.NoError(t, db.Create(&bSchedule).Error)
 
 	// Test fetching the employee with schedules for week type "A"
-	empWithSchedulesA, err := repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "A")
+	empWithSchedulesA, err := repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "A")
 	require.NoError(t, err, "Fetching employee with schedules for week type A should not error")
 	assert.Len(t, empWithSchedulesA.Schedules, 1, "Employee should have exactly one schedule for week type A")
 	assert.Equal(t, "A", empWithSchedulesA.Schedules[0].WeekType, "Schedule week type should be A")
 
 	// Test fetching the employee with schedules for week type "B"
-	empWithSchedulesB, err := repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "B")
+	empWithSchedulesB, err := repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "B")
 	require.NoError(t, err, "Fetching employee with schedules for week type B should not error")
 	assert.Len(t, empWithSchedulesB.Schedules, 1, "Employee should have exactly one schedule for week type B")
 	assert.Equal(t, "B", empWithSchedulesB.Schedules[0].WeekType, "Schedule week type should be B")
@@ -313,11 +352,11 @@ func TestLoadEmployeeWithMorningAndAfternoonSchedules(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	repo := &repository{db: db} // Adjust according to how you instantiate the repository
-	repo.CleanupDatabase()      // Assuming this properly cleans the test database
+	repo := &repository{db: db}                // Adjust according to how you instantiate the repository
+	repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Create and insert a new employee. Note the use of & to get a pointer
 	employee := &model.Employee{Name: "Full Week Employee", StartDate: time.Now().UTC()}
-	err := repo.LoadEmployees([]*model.Employee{employee})
+	err := repo.LoadEmployees(context.Background(), []*model.Employee{employee})
 	require.NoError(t, err, "Failed to load new employee")
 	require.NotZero(t, employee.ID, "Employee should have an ID after being loaded.")
 
@@ -342,20 +381,355 @@ func TestLoadEmployeeWithMorningAndAfternoonSchedules(t *testing.T) {
 					StartTime:  model.CustomTime{Time: slot.StartTime},
 					EndTime:    model.CustomTime{Time: slot.EndTime},
 				}
-				err := repo.UpdateSchedule(schedule)
+				err := repo.UpdateSchedule(context.Background(), schedule)
 				require.NoError(t, err, fmt.Sprintf("Failed to load schedule for %s of week %s", day, weekType))
 			}
 		}
 	}
 
 	// Verify that the employee has 28 schedules in total (14 for Week A and 14 for Week B)
-	loadedEmployeeWithSchedulesA, err := repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "A")
+	loadedEmployeeWithSchedulesA, err := repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "A")
 	require.NoError(t, err, "Failed to retrieve employee with schedules for Week A")
 	assert.Len(t, loadedEmployeeWithSchedulesA.Schedules, 14, "Employee should have 14 schedules for Week A")
 
-	loadedEmployeeWithSchedulesB, err := repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "B")
+	loadedEmployeeWithSchedulesB, err := repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "B")
 	require.NoError(t, err, "Failed to retrieve employee with schedules for Week B")
 	assert.Len(t, loadedEmployeeWithSchedulesB.Schedules, 14, "Employee should have 14 schedules for Week B")
 }
 
 // Additional test functions adapted for PostgreSQL
+
+func TestGetAndDeleteScheduleByID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+
+	employee := &model.Employee{Name: "Schedule Owner", StartDate: time.Now().UTC()}
+	require.NoError(t, repo.LoadEmployees(context.Background(), []*model.Employee{employee}))
+
+	schedule := model.Schedule{
+		EmployeeID: employee.ID,
+		WeekType:   "A",
+		DayName:    "Monday",
+		StartTime:  model.CustomTime{Time: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)},
+		EndTime:    model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	require.NoError(t, db.Create(&schedule).Error)
+
+	fetched, err := repo.GetScheduleByID(context.Background(), schedule.ID)
+	require.NoError(t, err, "Fetching an existing schedule should not error")
+	assert.Equal(t, "Monday", fetched.DayName)
+
+	require.NoError(t, repo.DeleteSchedule(context.Background(), schedule.ID), "Deleting an existing schedule should not error")
+	_, err = repo.GetScheduleByID(context.Background(), schedule.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Deleted schedule should no longer be found")
+	assert.ErrorIs(t, repo.DeleteSchedule(context.Background(), schedule.ID), gorm.ErrRecordNotFound, "Deleting twice should report not found")
+}
+
+func TestCapacityAggregations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	require.NoError(t, db.AutoMigrate(&model.DemandForecast{}))
+	defer db.Migrator().DropTable(&model.DemandForecast{})
+
+	repo := &repository{db: db}
+
+	// Seed two opticians and one lab technician with a fixed weekly pattern
+	startDate := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	employees := []*model.Employee{
+		{Name: "Optician 1", StartDate: startDate, Department: "optique", ContractWeeklyHours: 35},
+		{Name: "Optician 2", StartDate: startDate, Department: "optique", ContractWeeklyHours: 28},
+		{Name: "Lab Tech", StartDate: startDate, Department: "atelier", ContractWeeklyHours: 35},
+	}
+	require.NoError(t, repo.LoadEmployees(context.Background(), employees))
+
+	slot := func(employeeID uint, weekType string, startHour, endHour int) model.Schedule {
+		return model.Schedule{
+			EmployeeID: employeeID,
+			WeekType:   weekType,
+			DayName:    "Monday",
+			StartTime:  model.CustomTime{Time: time.Date(0, 1, 1, startHour, 0, 0, 0, time.UTC)},
+			EndTime:    model.CustomTime{Time: time.Date(0, 1, 1, endHour, 30, 0, 0, time.UTC)},
+		}
+	}
+	for _, schedule := range []model.Schedule{
+		slot(employees[0].ID, "A", 9, 12),  // 3.5h
+		slot(employees[0].ID, "A", 13, 17), // 4.5h
+		slot(employees[0].ID, "B", 9, 12),  // 3.5h
+		slot(employees[1].ID, "A", 10, 15), // 5.5h
+		slot(employees[2].ID, "B", 8, 16),  // 8.5h
+	} {
+		require.NoError(t, repo.UpdateSchedule(context.Background(), schedule))
+	}
+
+	rows, err := repo.PlannedHoursByWeekType(context.Background())
+	require.NoError(t, err)
+	hours := make(map[string]float64)
+	for _, row := range rows {
+		hours[fmt.Sprintf("%d-%s", row.EmployeeID, row.WeekType)] = row.Hours
+	}
+	assert.Len(t, rows, 4, "One row per employee and week type with schedules")
+	assert.InDelta(t, 8.0, hours[fmt.Sprintf("%d-A", employees[0].ID)], 0.001)
+	assert.InDelta(t, 3.5, hours[fmt.Sprintf("%d-B", employees[0].ID)], 0.001)
+	assert.InDelta(t, 5.5, hours[fmt.Sprintf("%d-A", employees[1].ID)], 0.001)
+	assert.InDelta(t, 8.5, hours[fmt.Sprintf("%d-B", employees[2].ID)], 0.001)
+
+	contracted, err := repo.ContractedHoursByDepartment(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 63.0, contracted["optique"], 0.001)
+	assert.InDelta(t, 35.0, contracted["atelier"], 0.001)
+
+	week := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.ForecastUpsert(context.Background(), []model.DemandForecast{{Department: "optique", WeekStart: week, RequiredFTE: 2}}))
+	require.NoError(t, repo.ForecastUpsert(context.Background(), []model.DemandForecast{{Department: "optique", WeekStart: week, RequiredFTE: 2.5}}))
+	forecasts, err := repo.ForecastFindBetween(context.Background(), week, week.AddDate(0, 0, 6))
+	require.NoError(t, err)
+	require.Len(t, forecasts, 1, "Upserting the same department and week should replace the forecast")
+	assert.Equal(t, 2.5, forecasts[0].RequiredFTE)
+}
+
+func TestEmployeesChangedSince(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	require.NoError(t, db.AutoMigrate(&model.RoleTemplate{}, &model.RoleTemplateSlot{}, &model.ScheduleDelta{}))
+
+	repo := &repository{db: db}
+	repo.CleanupDatabase(context.Background())
+	ctx := context.Background()
+
+	unchanged := &model.Employee{Name: "Unchanged", StartDate: time.Now()}
+	edited := &model.Employee{Name: "Edited", StartDate: time.Now(),
+		Schedules: []model.Schedule{{WeekType: "A", DayName: "Monday",
+			StartTime: model.CustomTime{Time: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)},
+			EndTime:   model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}}}}
+	require.NoError(t, repo.LoadEmployees(ctx, []*model.Employee{unchanged, edited}))
+	assert.False(t, edited.CreatedAt.IsZero(), "Timestamps should be set on creation")
+
+	since := time.Now()
+	require.NoError(t, repo.UpdateScheduleTask(ctx, edited.Schedules[0].ID, "till", nil))
+
+	changed, err := repo.EmployeesChangedSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, changed, 1, "Only the employee whose slot was edited should be returned")
+	assert.Equal(t, edited.ID, changed[0].ID)
+	require.Len(t, changed[0].Schedules, 1)
+	assert.True(t, changed[0].Schedules[0].UpdatedAt.After(since))
+}
+
+func TestArchiveEmployee(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	repo.CleanupDatabase(context.Background())
+	ctx := context.Background()
+
+	employee := &model.Employee{Name: "Leaving", StartDate: time.Now()}
+	require.NoError(t, repo.LoadEmployees(ctx, []*model.Employee{employee}))
+	since := time.Now()
+
+	require.NoError(t, repo.ArchiveEmployee(ctx, employee.ID))
+	assert.ErrorIs(t, repo.ArchiveEmployee(ctx, employee.ID), gorm.ErrRecordNotFound, "An archived employee cannot be archived again")
+
+	employees, err := repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, employees, "Archived employees are hidden")
+	archived, err := repo.ArchivedEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.True(t, archived[0].DeletedAt.Valid)
+	changed, err := repo.EmployeesChangedSince(ctx, since)
+	require.NoError(t, err)
+	require.Len(t, changed, 1, "Archiving is reported by the delta-sync feed")
+
+	require.NoError(t, repo.RestoreEmployee(ctx, employee.ID))
+	assert.ErrorIs(t, repo.RestoreEmployee(ctx, employee.ID), gorm.ErrRecordNotFound)
+	employees, err = repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	assert.Len(t, employees, 1)
+}
+
+func TestIDByUUID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	repo.CleanupDatabase(context.Background())
+	ctx := context.Background()
+
+	employee := &model.Employee{Name: "Addressed", StartDate: time.Now(), Schedules: []model.Schedule{{WeekType: "A", DayName: "Monday",
+		StartTime: model.CustomTime{Time: time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)}, EndTime: model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}}}}
+	require.NoError(t, repo.LoadEmployees(ctx, []*model.Employee{employee}))
+	require.True(t, model.IsUUID(employee.UUID), "a UUID is given on creation")
+	require.True(t, model.IsUUID(employee.Schedules[0].UUID))
+	assert.NotEqual(t, employee.UUID, employee.Schedules[0].UUID)
+
+	id, err := repo.IDByUUID(ctx, &model.Schedule{}, employee.Schedules[0].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, employee.Schedules[0].ID, id)
+	_, err = repo.IDByUUID(ctx, &model.Schedule{}, employee.UUID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "the UUID of another resource is not found")
+	require.NoError(t, repo.ArchiveEmployee(ctx, employee.ID))
+	id, err = repo.IDByUUID(ctx, &model.Employee{}, employee.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, employee.ID, id, "archived employees are found")
+
+	// The rows created before resources had a UUID get one.
+	require.NoError(t, db.Model(&model.Schedule{}).Where("id = ?", employee.Schedules[0].ID).UpdateColumn("uuid", "").Error)
+	require.NoError(t, repo.backfillUUIDs(ctx))
+	var schedule model.Schedule
+	require.NoError(t, db.First(&schedule, employee.Schedules[0].ID).Error)
+	assert.True(t, model.IsUUID(schedule.UUID))
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	ctx := context.Background()
+	failure := fmt.Errorf("snapshot failed")
+	err := repo.Transaction(ctx, func(tx Repository) error {
+		require.NoError(t, tx.LoadEmployees(ctx, []*model.Employee{{Name: "Half Imported", StartDate: time.Now().UTC()}}))
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	employees, err := repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, employees, "the employee created before the failure is rolled back")
+}
+
+func TestCreateSchedulesBatch(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	repo.CleanupDatabase(context.Background())
+	ctx := context.Background()
+
+	employee := &model.Employee{Name: "Large Roster", StartDate: time.Now()}
+	require.NoError(t, repo.LoadEmployees(ctx, []*model.Employee{employee}))
+	schedules := make([]model.Schedule, insertBatchSize+3)
+	for i := range schedules {
+		schedules[i] = model.Schedule{EmployeeID: employee.ID, WeekType: "A", DayName: "Monday",
+			StartTime: model.CustomTime{Time: time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)}, EndTime: model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}}
+	}
+	require.NoError(t, repo.CreateSchedulesBatch(ctx, schedules))
+	assert.NotZero(t, schedules[len(schedules)-1].ID, "the IDs of the slots are set")
+
+	var count int64
+	require.NoError(t, db.Model(&model.Schedule{}).Where("employee_id = ?", employee.ID).Count(&count).Error)
+	assert.EqualValues(t, len(schedules), count, "the slots of every batch are created")
+}
+
+func TestImportEmployeesCreatesSchedulesInBatches(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := &repository{db: db}
+	repo.CleanupDatabase(context.Background())
+	ctx := context.Background()
+
+	start := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	roster := func(name string, slots int) *model.Employee {
+		employee := &model.Employee{Name: name, StartDate: start, Schedules: make([]model.Schedule, slots)}
+		for i := range employee.Schedules {
+			employee.Schedules[i] = model.Schedule{WeekType: "A", DayName: "Monday",
+				StartTime: model.CustomTime{Time: time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC)}, EndTime: model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}}
+		}
+		return employee
+	}
+	count := func(employee *model.Employee) int64 {
+		var n int64
+		require.NoError(t, db.Model(&model.Schedule{}).Where("employee_id = ?", employee.ID).Count(&n).Error)
+		return n
+	}
+	requireStored := func(employees []*model.Employee) {
+		for _, employee := range employees {
+			assert.EqualValues(t, len(employee.Schedules), count(employee), employee.Name)
+			for _, slot := range employee.Schedules {
+				require.NotZero(t, slot.ID, "the IDs of the slots of %s are set", employee.Name)
+				require.Equal(t, employee.ID, slot.EmployeeID)
+			}
+		}
+	}
+
+	// The slots of both employees span two batches.
+	employees := []*model.Employee{roster("Large Roster", insertBatchSize), roster("Small Roster", 3)}
+	replaced, err := repo.ImportEmployees(ctx, &model.EmployeeImport{Hash: strings.Repeat("a", 64), Employees: 2}, employees, false)
+	require.NoError(t, err)
+	assert.Zero(t, replaced)
+	requireStored(employees)
+
+	// An upsert replaces the slots of the employees already there and creates the others.
+	employees = []*model.Employee{roster("Large Roster", 2), roster("New Roster", insertBatchSize+1)}
+	replaced, err = repo.ImportEmployees(ctx, &model.EmployeeImport{Hash: strings.Repeat("b", 64), Employees: 2}, employees, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replaced)
+	requireStored(employees)
+
+	// LoadEmployees goes through the same path.
+	employees = []*model.Employee{roster("Loaded Roster", insertBatchSize+2)}
+	require.NoError(t, repo.LoadEmployees(ctx, employees))
+	requireStored(employees)
+}
+
+func TestNewRepositorySQLite(t *testing.T) {
+	_, err := NewRepository("mysql", "")
+	require.EqualError(t, err, `unknown database driver "mysql", expected postgres or sqlite`)
+
+	r, err := NewRepository(DriverSQLite, t.TempDir()+"/lichens.db")
+	require.NoError(t, err)
+	ctx := context.Background()
+	employees := []*model.Employee{{Name: "Jules", StartDate: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)}}
+	require.NoError(t, r.LoadEmployees(ctx, employees))
+	start, end := model.CustomTime{Time: time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC)}, model.CustomTime{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, r.CreateSchedulesBatch(ctx, []model.Schedule{{EmployeeID: employees[0].ID, WeekType: "A", DayName: "Monday",
+		StartTime: start, EndTime: end, Task: "Front desk"}}))
+
+	hours, err := r.PlannedHoursByWeekType(ctx)
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.InDelta(t, 2.5, hours[0].Hours, 1e-9)
+}
+
+func TestPlannedHoursByWeekTypeCountsOvernightSlots(t *testing.T) {
+	db, cleanup := setupMemoryDB(t)
+	defer cleanup()
+	r := &repository{db: db}
+	ctx := context.Background()
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	template := &model.RoleTemplate{Name: "Night shift", Slots: []model.RoleTemplateSlot{
+		{WeekType: "A", DayName: "Tuesday", StartTime: at(22), EndTime: at(2)},
+		{WeekType: "A", DayName: "Thursday", StartTime: at(21), EndTime: at(1)},
+	}}
+	require.NoError(t, r.RoleTemplateCreate(ctx, template))
+	employees := []*model.Employee{{Name: "Night Guard", StartDate: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), RoleTemplateID: &template.ID}}
+	require.NoError(t, r.LoadEmployees(ctx, employees))
+	id := employees[0].ID
+	require.NoError(t, r.CreateSchedulesBatch(ctx, []model.Schedule{{EmployeeID: id, WeekType: "A", DayName: "Monday", StartTime: at(22), EndTime: at(6)}}))
+	require.NoError(t, r.DeltaCreate(ctx, &model.ScheduleDelta{EmployeeID: id, Action: model.DeltaAdd, WeekType: "A", DayName: "Wednesday", StartTime: at(23), EndTime: at(1)}))
+	require.NoError(t, r.DeltaCreate(ctx, &model.ScheduleDelta{EmployeeID: id, Action: model.DeltaRemove, WeekType: "A", DayName: "Thursday", StartTime: at(21), EndTime: at(1)}))
+
+	// 8h on Monday night, 4h inherited on Tuesday night and 2h added on Wednesday night; Thursday is removed.
+	hours, err := r.PlannedHoursByWeekType(ctx)
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.InDelta(t, 14.0, hours[0].Hours, 1e-9)
+}
+
+func TestCustomTimeScan(t *testing.T) {
+	for _, value := range []interface{}{"09:30:00", []byte("09:30"), "09:30:00.000", "0000-01-01 09:30:00+00:00",
+		"2000-01-01T09:30:00Z", time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC)} {
+		var ct model.CustomTime
+		require.NoError(t, ct.Scan(value), "%v", value)
+		assert.Equal(t, "09:30", ct.Format("15:04"), "%v", value)
+	}
+	var ct model.CustomTime
+	require.EqualError(t, ct.Scan("half past nine"), `cannot parse "half past nine" as a time of day`)
+	require.EqualError(t, ct.Scan(42), "cannot scan type int into CustomTime")
+}
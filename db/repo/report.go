@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Operations backing the reports
+
+// secondsBetween returns the SQL of the seconds elapsed from the time or timestamp column from to to, in the
+// dialect of db: SQLite stores them as text, which julianday reads as fractions of days, rounded back to the
+// millisecond
+func secondsBetween(db *gorm.DB, from, to string) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "ROUND((julianday(" + to + ") - julianday(" + from + ")) * 86400, 3)"
+	}
+	return "EXTRACT(EPOCH FROM (" + to + " - " + from + "))"
+}
+
+// slotSeconds returns the SQL of the seconds a slot from the time column start to end lasts, in the dialect
+// of db: a slot ending before it starts crosses midnight and ends the next day, see model.CrossesMidnight
+func slotSeconds(db *gorm.DB, start, end string) string {
+	seconds := secondsBetween(db, start, end)
+	return "(CASE WHEN " + end + " < " + start + " THEN " + seconds + " + 86400 ELSE " + seconds + " END)"
+}
+
+// PlannedHoursByWeekType sums the scheduled hours of every employee per week type in a single query.
+// Slots inherited from a role template count as well, corrected by the employee's deltas, and overnight
+// slots count until their end on the next day. Being raw SQL, the query is confined to the tenant of ctx by
+// hand.
+func (repo *repository) PlannedHoursByWeekType(ctx context.Context) ([]model.EmployeeWeekTypeHours, error) {
+	var rows []model.EmployeeWeekTypeHours
+	db := repo.db.WithContext(ctx)
+	tenantID, scoped := TenantFromContext(ctx)
+	err := db.Raw(`
+		SELECT e.id AS employee_id, e.department, e.start_date, e.end_date, e.rotation_pattern_id, e.rotation_anchor, slots.week_type, SUM(slots.seconds) / 3600 AS hours
+		FROM (
+			SELECT s.employee_id, s.week_type, `+slotSeconds(db, "s.start_time", "s.end_time")+` AS seconds
+			FROM schedules AS s
+			UNION ALL
+			SELECT e.id, t.week_type, `+slotSeconds(db, "t.start_time", "t.end_time")+`
+			FROM role_template_slots AS t JOIN employees AS e ON e.role_template_id = t.role_template_id
+			UNION ALL
+			SELECT d.employee_id, d.week_type,
+				CASE WHEN d.action = ? THEN -1 ELSE 1 END * `+slotSeconds(db, "d.start_time", "d.end_time")+`
+			FROM schedule_delta AS d
+		) AS slots
+		JOIN employees AS e ON e.id = slots.employee_id
+		WHERE e.deleted_at IS NULL AND (NOT ? OR e.tenant_id = ?)
+		GROUP BY e.id, e.department, e.start_date, e.end_date, e.rotation_pattern_id, e.rotation_anchor, slots.week_type`, model.DeltaRemove, scoped, tenantID).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ContractedHoursByDepartment sums the contracted weekly hours of the employees of each department.
+func (repo *repository) ContractedHoursByDepartment(ctx context.Context) (map[string]float64, error) {
+	var rows []struct {
+		Department string
+		Hours      float64
+	}
+	err := repo.db.WithContext(ctx).Model(&model.Employee{}).
+		Select("department, SUM(contract_weekly_hours) AS hours").
+		Group("department").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	hours := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		hours[row.Department] = row.Hours
+	}
+	return hours, nil
+}
+
+// ForecastUpsert stores demand forecasts, replacing any existing value for the same department and week.
+func (repo *repository) ForecastUpsert(ctx context.Context, forecasts []model.DemandForecast) error {
+	if len(forecasts) == 0 {
+		return nil
+	}
+	return repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "department"}, {Name: "week_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"required_fte"}),
+	}).Create(&forecasts).Error
+}
+
+// ForecastFindBetween retrieves the forecasts of the weeks starting between from and to, inclusive.
+func (repo *repository) ForecastFindBetween(ctx context.Context, from, to time.Time) ([]model.DemandForecast, error) {
+	var forecasts []model.DemandForecast
+	err := repo.db.WithContext(ctx).Where("week_start BETWEEN ? AND ?", from, to).Order("week_start, department").Find(&forecasts).Error
+	return forecasts, err
+}
+
+// RevenueUpsert stores daily revenues, replacing any existing value for the same date.
+func (repo *repository) RevenueUpsert(ctx context.Context, revenues []model.DailyRevenue) error {
+	if len(revenues) == 0 {
+		return nil
+	}
+	return repo.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount"}),
+	}).Create(&revenues).Error
+}
+
+// RevenueFindBetween retrieves the revenues of the dates between from and to, inclusive.
+func (repo *repository) RevenueFindBetween(ctx context.Context, from, to time.Time) ([]model.DailyRevenue, error) {
+	var revenues []model.DailyRevenue
+	err := repo.db.WithContext(ctx).Where("date BETWEEN ? AND ?", from, to).Order("date").Find(&revenues).Error
+	return revenues, err
+}
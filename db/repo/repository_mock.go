@@ -0,0 +1,1069 @@
+// Code generated by internal/mockgen from Repository. DO NOT EDIT.
+
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"time"
+)
+
+// RepositoryMock implements Repository with the functions of its fields, which panic when not set.
+type RepositoryMock struct {
+	ArchiveEmployeeFunc                    func(ctx context.Context, id uint) error
+	ArchivedEmployeesFunc                  func(ctx context.Context) ([]model.Employee, error)
+	CalendarLinkDeleteFunc                 func(ctx context.Context, employeeID uint) error
+	CalendarLinkGetFunc                    func(ctx context.Context, employeeID uint) (*model.CalendarLink, error)
+	CalendarLinkListFunc                   func(ctx context.Context) ([]model.CalendarLink, error)
+	CalendarLinkSaveFunc                   func(ctx context.Context, link *model.CalendarLink) error
+	CleanupDatabaseFunc                    func(ctx context.Context)
+	ClockedHoursFunc                       func(ctx context.Context, employeeIDs []uint, from time.Time, to time.Time) (map[uint]float64, error)
+	ClosureDayCreateFunc                   func(ctx context.Context, closure *model.ClosureDay) error
+	ClosureDayDeleteFunc                   func(ctx context.Context, id uint) error
+	ClosureDayFindBetweenFunc              func(ctx context.Context, from time.Time, to time.Time) ([]model.ClosureDay, error)
+	ClosureDayUpdateFunc                   func(ctx context.Context, closure *model.ClosureDay) error
+	ContractedHoursByDepartmentFunc        func(ctx context.Context) (map[string]float64, error)
+	CreateSchedulesBatchFunc               func(ctx context.Context, schedules []model.Schedule) error
+	DBCreateFunc                           func(ctx context.Context) error
+	DBDeleteFunc                           func(ctx context.Context) error
+	DeactivateEmployeesFunc                func(ctx context.Context, ids []uint, endDate time.Time) error
+	DeleteScheduleFunc                     func(ctx context.Context, id uint) error
+	DeleteSchedulesFunc                    func(ctx context.Context, employeeID uint, weekType string, dayName string) (int64, error)
+	DeltaCreateFunc                        func(ctx context.Context, delta *model.ScheduleDelta) error
+	DeltaDeleteFunc                        func(ctx context.Context, employeeID uint, id uint) error
+	DeltaListByEmployeeFunc                func(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error)
+	DetachRoleTemplateFunc                 func(ctx context.Context, employeeID uint, inherited []model.Schedule) error
+	EmployeesByRoleTemplateFunc            func(ctx context.Context, templateID uint) ([]model.Employee, error)
+	EmployeesChangedSinceFunc              func(ctx context.Context, since time.Time) ([]model.Employee, error)
+	EmployeesLastModifiedFunc              func(ctx context.Context) (time.Time, error)
+	ForecastFindBetweenFunc                func(ctx context.Context, from time.Time, to time.Time) ([]model.DemandForecast, error)
+	ForecastUpsertFunc                     func(ctx context.Context, forecasts []model.DemandForecast) error
+	GetEmployeeByIDFunc                    func(ctx context.Context, id uint, emp *model.Employee) error
+	GetEmployeeIncludingFunc               func(ctx context.Context, id uint, relations ...string) (*model.Employee, error)
+	GetEmployeeWithSchedulesFunc           func(ctx context.Context, id uint) (*model.Employee, error)
+	GetEmployeeWithSchedulesByWeekTypeFunc func(ctx context.Context, employeeID uint, weekType string) (*model.Employee, error)
+	GetEmployeesFunc                       func(ctx context.Context) ([]model.Employee, error)
+	GetEmployeesWithCalendarFunc           func(ctx context.Context, ids []uint, from time.Time, to time.Time) ([]model.Employee, error)
+	GetEmployeesWithSchedulesFunc          func(ctx context.Context) ([]model.Employee, error)
+	GetScheduleFunc                        func(ctx context.Context, employeeID uint, weekType string) ([]model.Schedule, error)
+	GetScheduleByIDFunc                    func(ctx context.Context, id uint) (*model.Schedule, error)
+	HolidayCreateFunc                      func(ctx context.Context, holiday *model.Holiday) error
+	HolidayDeleteYearFunc                  func(ctx context.Context, year int) (int64, error)
+	HolidayFindByDateFunc                  func(ctx context.Context, date time.Time) (*model.Holiday, error)
+	HolidayFindByMonthAndYearFunc          func(ctx context.Context, year int, month time.Month) ([]model.Holiday, error)
+	HolidayFindByYearFunc                  func(ctx context.Context, year int) ([]model.Holiday, error)
+	HolidayListAllFunc                     func(ctx context.Context) ([]model.Holiday, error)
+	HolidayUpdateFunc                      func(ctx context.Context, holiday *model.Holiday) error
+	HolidayUpsertFunc                      func(ctx context.Context, holidays []model.Holiday) error
+	IDByUUIDFunc                           func(ctx context.Context, resource interface{}, uuid string) (uint, error)
+	ImportEmployeesFunc                    func(ctx context.Context, record *model.EmployeeImport, employees []*model.Employee, upsert bool) (int, error)
+	ImportFindByHashFunc                   func(ctx context.Context, hash string) (*model.EmployeeImport, error)
+	ImportListFunc                         func(ctx context.Context, limit int) ([]model.EmployeeImport, error)
+	LeaveCreateFunc                        func(ctx context.Context, employeeID uint, days []model.EmployeeHoliday) error
+	LeaveDecideFunc                        func(ctx context.Context, requestID uint, status string, approverID *uint) ([]model.EmployeeHoliday, error)
+	LeaveDeleteBetweenFunc                 func(ctx context.Context, employeeID uint, from time.Time, to time.Time) (int64, error)
+	LeaveFindAllBetweenFunc                func(ctx context.Context, from time.Time, to time.Time, status string) ([]model.EmployeeHoliday, error)
+	LeaveFindBetweenFunc                   func(ctx context.Context, employeeID uint, from time.Time, to time.Time, status string) ([]model.EmployeeHoliday, error)
+	LeaveFindByIDFunc                      func(ctx context.Context, id uint) (*model.EmployeeHoliday, error)
+	LoadEmployeesFunc                      func(ctx context.Context, employees []*model.Employee) error
+	MigrationStatusFunc                    func(ctx context.Context) ([]model.MigrationStatus, error)
+	OverrideDeleteFunc                     func(ctx context.Context, employeeID uint, date time.Time) error
+	OverrideFindBetweenFunc                func(ctx context.Context, employeeID uint, from time.Time, to time.Time) ([]model.ScheduleOverride, error)
+	OverrideSaveFunc                       func(ctx context.Context, override *model.ScheduleOverride) error
+	PairingRuleCreateFunc                  func(ctx context.Context, rule *model.PairingRule) error
+	PairingRuleDeleteFunc                  func(ctx context.Context, id uint) error
+	PairingRuleListFunc                    func(ctx context.Context) ([]model.PairingRule, error)
+	PlannedHoursByWeekTypeFunc             func(ctx context.Context) ([]model.EmployeeWeekTypeHours, error)
+	PrintJobClaimFunc                      func(ctx context.Context, staleBefore time.Time) (*model.PrintJob, error)
+	PrintJobCreateFunc                     func(ctx context.Context, job *model.PrintJob) error
+	PrintJobFindByIDFunc                   func(ctx context.Context, id uint) (*model.PrintJob, error)
+	PrintJobFindByUUIDFunc                 func(ctx context.Context, uuid string) (*model.PrintJob, error)
+	PrintJobSaveFunc                       func(ctx context.Context, job *model.PrintJob) error
+	ProvenanceFindFunc                     func(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error)
+	ReplaceSchedulesFunc                   func(ctx context.Context, employeeID uint, weekType string, dayName string, schedules []model.Schedule) error
+	ReplaceSchedulesFromFunc               func(ctx context.Context, version *model.ScheduleVersion, weekType string, dayName string, schedules []model.Schedule) error
+	RestoreEmployeeFunc                    func(ctx context.Context, id uint) error
+	RevenueFindBetweenFunc                 func(ctx context.Context, from time.Time, to time.Time) ([]model.DailyRevenue, error)
+	RevenueUpsertFunc                      func(ctx context.Context, revenues []model.DailyRevenue) error
+	RoleTemplateCreateFunc                 func(ctx context.Context, template *model.RoleTemplate) error
+	RoleTemplateFindByIDFunc               func(ctx context.Context, id uint) (*model.RoleTemplate, error)
+	RoleTemplateFindByNameFunc             func(ctx context.Context, name string) (*model.RoleTemplate, error)
+	RoleTemplateListFunc                   func(ctx context.Context) ([]model.RoleTemplate, error)
+	RoleTemplateReplaceSlotsFunc           func(ctx context.Context, id uint, slots []model.RoleTemplateSlot) error
+	RollbackImportFunc                     func(ctx context.Context, id uint) (*model.EmployeeImport, error)
+	RotationCalendarGetFunc                func(ctx context.Context) (*model.RotationCalendar, error)
+	RotationCalendarSetFunc                func(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error)
+	RotationCreateFunc                     func(ctx context.Context, pattern *model.RotationPattern) error
+	RotationFindByIDFunc                   func(ctx context.Context, id uint) (*model.RotationPattern, error)
+	RotationFindByNameFunc                 func(ctx context.Context, name string) (*model.RotationPattern, error)
+	RotationListFunc                       func(ctx context.Context) ([]model.RotationPattern, error)
+	SaveScheduleSnapshotFunc               func(ctx context.Context, employeeID uint, snapshot *model.ScheduleSnapshot) error
+	ScheduleVersionCreateFunc              func(ctx context.Context, version *model.ScheduleVersion) error
+	ScheduleVersionListFunc                func(ctx context.Context, employeeID uint) ([]model.ScheduleVersion, error)
+	SearchEmployeesFunc                    func(ctx context.Context, query string, limit int) ([]model.Employee, error)
+	SetEmployeeRoleTemplateFunc            func(ctx context.Context, employeeID uint, templateID *uint) error
+	SetEmployeeRotationFunc                func(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error
+	StaffingRequirementCreateFunc          func(ctx context.Context, requirement *model.StaffingRequirement) error
+	StaffingRequirementDeleteFunc          func(ctx context.Context, id uint) error
+	StaffingRequirementListFunc            func(ctx context.Context) ([]model.StaffingRequirement, error)
+	TableRowCountsFunc                     func(ctx context.Context) ([]model.TableRowCount, error)
+	TenantCreateFunc                       func(ctx context.Context, tenant *model.Tenant) error
+	TenantFindByAPIKeyHashFunc             func(ctx context.Context, hash string) (*model.Tenant, error)
+	TenantFindBySubdomainFunc              func(ctx context.Context, subdomain string) (*model.Tenant, error)
+	TimeEntryCreateFunc                    func(ctx context.Context, entry *model.TimeEntry) error
+	TimeEntryFindBetweenFunc               func(ctx context.Context, employeeID uint, from time.Time, to time.Time) ([]model.TimeEntry, error)
+	TimeEntryFindByNonceFunc               func(ctx context.Context, deviceID string, nonce string) (*model.TimeEntry, error)
+	TimeEntryFindNearFunc                  func(ctx context.Context, employeeID uint, kind string, from time.Time, to time.Time) (*model.TimeEntry, error)
+	TimeEntryLastSequenceFunc              func(ctx context.Context, deviceID string) (int64, error)
+	TimeEntryListBetweenFunc               func(ctx context.Context, from time.Time, to time.Time) ([]model.TimeEntry, error)
+	TouchEmployeesFunc                     func(ctx context.Context, ids []uint) (int64, error)
+	TransactionFunc                        func(ctx context.Context, fn func(tx Repository) error) error
+	UnavailabilityCreateFunc               func(ctx context.Context, unavailability *model.Unavailability) error
+	UnavailabilityDeleteFunc               func(ctx context.Context, employeeID uint, id uint) error
+	UnavailabilityListFunc                 func(ctx context.Context) ([]model.Unavailability, error)
+	UnavailabilityListByEmployeeFunc       func(ctx context.Context, employeeID uint) ([]model.Unavailability, error)
+	UnavailabilityUpdateFunc               func(ctx context.Context, unavailability *model.Unavailability) error
+	UnscheduledEmployeeIDsFunc             func(ctx context.Context) ([]uint, error)
+	UpdateEmployeeFunc                     func(ctx context.Context, employee model.Employee) error
+	UpdateScheduleFunc                     func(ctx context.Context, schedule model.Schedule) error
+	UpdateScheduleTaskFunc                 func(ctx context.Context, id uint, task string, changedByID *uint) error
+	UserCreateFunc                         func(ctx context.Context, user *model.User) error
+	UserFindByUsernameFunc                 func(ctx context.Context, username string) (*model.User, error)
+	UserUpdatePasswordHashFunc             func(ctx context.Context, id uint, hash string) error
+	ValidationSettingsGetFunc              func(ctx context.Context) (*model.ValidationSettings, error)
+	ValidationSettingsSetFunc              func(ctx context.Context, settings *model.ValidationSettings) error
+	WebhookCreateFunc                      func(ctx context.Context, hook *model.Webhook) error
+	WebhookDeleteFunc                      func(ctx context.Context, id uint) error
+	WebhookDeliveryClaimFunc               func(ctx context.Context, now time.Time, staleBefore time.Time) (*model.WebhookDelivery, error)
+	WebhookDeliveryCreateFunc              func(ctx context.Context, deliveries []model.WebhookDelivery) error
+	WebhookDeliveryListFunc                func(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error)
+	WebhookDeliverySaveFunc                func(ctx context.Context, delivery *model.WebhookDelivery) error
+	WebhookFindByIDFunc                    func(ctx context.Context, id uint) (*model.Webhook, error)
+	WebhookListFunc                        func(ctx context.Context) ([]model.Webhook, error)
+	WebhookSetSecretFunc                   func(ctx context.Context, id uint, secret string) error
+	WebhookUpdateFunc                      func(ctx context.Context, hook *model.Webhook) error
+}
+
+func (m *RepositoryMock) ArchiveEmployee(ctx context.Context, id uint) error {
+	if m.ArchiveEmployeeFunc == nil {
+		panic("RepositoryMock.ArchiveEmployeeFunc is not set")
+	}
+	return m.ArchiveEmployeeFunc(ctx, id)
+}
+
+func (m *RepositoryMock) ArchivedEmployees(ctx context.Context) ([]model.Employee, error) {
+	if m.ArchivedEmployeesFunc == nil {
+		panic("RepositoryMock.ArchivedEmployeesFunc is not set")
+	}
+	return m.ArchivedEmployeesFunc(ctx)
+}
+
+func (m *RepositoryMock) CalendarLinkDelete(ctx context.Context, employeeID uint) error {
+	if m.CalendarLinkDeleteFunc == nil {
+		panic("RepositoryMock.CalendarLinkDeleteFunc is not set")
+	}
+	return m.CalendarLinkDeleteFunc(ctx, employeeID)
+}
+
+func (m *RepositoryMock) CalendarLinkGet(ctx context.Context, employeeID uint) (*model.CalendarLink, error) {
+	if m.CalendarLinkGetFunc == nil {
+		panic("RepositoryMock.CalendarLinkGetFunc is not set")
+	}
+	return m.CalendarLinkGetFunc(ctx, employeeID)
+}
+
+func (m *RepositoryMock) CalendarLinkList(ctx context.Context) ([]model.CalendarLink, error) {
+	if m.CalendarLinkListFunc == nil {
+		panic("RepositoryMock.CalendarLinkListFunc is not set")
+	}
+	return m.CalendarLinkListFunc(ctx)
+}
+
+func (m *RepositoryMock) CalendarLinkSave(ctx context.Context, link *model.CalendarLink) error {
+	if m.CalendarLinkSaveFunc == nil {
+		panic("RepositoryMock.CalendarLinkSaveFunc is not set")
+	}
+	return m.CalendarLinkSaveFunc(ctx, link)
+}
+
+func (m *RepositoryMock) CleanupDatabase(ctx context.Context) {
+	if m.CleanupDatabaseFunc == nil {
+		panic("RepositoryMock.CleanupDatabaseFunc is not set")
+	}
+	m.CleanupDatabaseFunc(ctx)
+}
+
+func (m *RepositoryMock) ClockedHours(ctx context.Context, employeeIDs []uint, from time.Time, to time.Time) (map[uint]float64, error) {
+	if m.ClockedHoursFunc == nil {
+		panic("RepositoryMock.ClockedHoursFunc is not set")
+	}
+	return m.ClockedHoursFunc(ctx, employeeIDs, from, to)
+}
+
+func (m *RepositoryMock) ClosureDayCreate(ctx context.Context, closure *model.ClosureDay) error {
+	if m.ClosureDayCreateFunc == nil {
+		panic("RepositoryMock.ClosureDayCreateFunc is not set")
+	}
+	return m.ClosureDayCreateFunc(ctx, closure)
+}
+
+func (m *RepositoryMock) ClosureDayDelete(ctx context.Context, id uint) error {
+	if m.ClosureDayDeleteFunc == nil {
+		panic("RepositoryMock.ClosureDayDeleteFunc is not set")
+	}
+	return m.ClosureDayDeleteFunc(ctx, id)
+}
+
+func (m *RepositoryMock) ClosureDayFindBetween(ctx context.Context, from time.Time, to time.Time) ([]model.ClosureDay, error) {
+	if m.ClosureDayFindBetweenFunc == nil {
+		panic("RepositoryMock.ClosureDayFindBetweenFunc is not set")
+	}
+	return m.ClosureDayFindBetweenFunc(ctx, from, to)
+}
+
+func (m *RepositoryMock) ClosureDayUpdate(ctx context.Context, closure *model.ClosureDay) error {
+	if m.ClosureDayUpdateFunc == nil {
+		panic("RepositoryMock.ClosureDayUpdateFunc is not set")
+	}
+	return m.ClosureDayUpdateFunc(ctx, closure)
+}
+
+func (m *RepositoryMock) ContractedHoursByDepartment(ctx context.Context) (map[string]float64, error) {
+	if m.ContractedHoursByDepartmentFunc == nil {
+		panic("RepositoryMock.ContractedHoursByDepartmentFunc is not set")
+	}
+	return m.ContractedHoursByDepartmentFunc(ctx)
+}
+
+func (m *RepositoryMock) CreateSchedulesBatch(ctx context.Context, schedules []model.Schedule) error {
+	if m.CreateSchedulesBatchFunc == nil {
+		panic("RepositoryMock.CreateSchedulesBatchFunc is not set")
+	}
+	return m.CreateSchedulesBatchFunc(ctx, schedules)
+}
+
+func (m *RepositoryMock) DBCreate(ctx context.Context) error {
+	if m.DBCreateFunc == nil {
+		panic("RepositoryMock.DBCreateFunc is not set")
+	}
+	return m.DBCreateFunc(ctx)
+}
+
+func (m *RepositoryMock) DBDelete(ctx context.Context) error {
+	if m.DBDeleteFunc == nil {
+		panic("RepositoryMock.DBDeleteFunc is not set")
+	}
+	return m.DBDeleteFunc(ctx)
+}
+
+func (m *RepositoryMock) DeactivateEmployees(ctx context.Context, ids []uint, endDate time.Time) error {
+	if m.DeactivateEmployeesFunc == nil {
+		panic("RepositoryMock.DeactivateEmployeesFunc is not set")
+	}
+	return m.DeactivateEmployeesFunc(ctx, ids, endDate)
+}
+
+func (m *RepositoryMock) DeleteSchedule(ctx context.Context, id uint) error {
+	if m.DeleteScheduleFunc == nil {
+		panic("RepositoryMock.DeleteScheduleFunc is not set")
+	}
+	return m.DeleteScheduleFunc(ctx, id)
+}
+
+func (m *RepositoryMock) DeleteSchedules(ctx context.Context, employeeID uint, weekType string, dayName string) (int64, error) {
+	if m.DeleteSchedulesFunc == nil {
+		panic("RepositoryMock.DeleteSchedulesFunc is not set")
+	}
+	return m.DeleteSchedulesFunc(ctx, employeeID, weekType, dayName)
+}
+
+func (m *RepositoryMock) DeltaCreate(ctx context.Context, delta *model.ScheduleDelta) error {
+	if m.DeltaCreateFunc == nil {
+		panic("RepositoryMock.DeltaCreateFunc is not set")
+	}
+	return m.DeltaCreateFunc(ctx, delta)
+}
+
+func (m *RepositoryMock) DeltaDelete(ctx context.Context, employeeID uint, id uint) error {
+	if m.DeltaDeleteFunc == nil {
+		panic("RepositoryMock.DeltaDeleteFunc is not set")
+	}
+	return m.DeltaDeleteFunc(ctx, employeeID, id)
+}
+
+func (m *RepositoryMock) DeltaListByEmployee(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error) {
+	if m.DeltaListByEmployeeFunc == nil {
+		panic("RepositoryMock.DeltaListByEmployeeFunc is not set")
+	}
+	return m.DeltaListByEmployeeFunc(ctx, employeeID)
+}
+
+func (m *RepositoryMock) DetachRoleTemplate(ctx context.Context, employeeID uint, inherited []model.Schedule) error {
+	if m.DetachRoleTemplateFunc == nil {
+		panic("RepositoryMock.DetachRoleTemplateFunc is not set")
+	}
+	return m.DetachRoleTemplateFunc(ctx, employeeID, inherited)
+}
+
+func (m *RepositoryMock) EmployeesByRoleTemplate(ctx context.Context, templateID uint) ([]model.Employee, error) {
+	if m.EmployeesByRoleTemplateFunc == nil {
+		panic("RepositoryMock.EmployeesByRoleTemplateFunc is not set")
+	}
+	return m.EmployeesByRoleTemplateFunc(ctx, templateID)
+}
+
+func (m *RepositoryMock) EmployeesChangedSince(ctx context.Context, since time.Time) ([]model.Employee, error) {
+	if m.EmployeesChangedSinceFunc == nil {
+		panic("RepositoryMock.EmployeesChangedSinceFunc is not set")
+	}
+	return m.EmployeesChangedSinceFunc(ctx, since)
+}
+
+func (m *RepositoryMock) EmployeesLastModified(ctx context.Context) (time.Time, error) {
+	if m.EmployeesLastModifiedFunc == nil {
+		panic("RepositoryMock.EmployeesLastModifiedFunc is not set")
+	}
+	return m.EmployeesLastModifiedFunc(ctx)
+}
+
+func (m *RepositoryMock) ForecastFindBetween(ctx context.Context, from time.Time, to time.Time) ([]model.DemandForecast, error) {
+	if m.ForecastFindBetweenFunc == nil {
+		panic("RepositoryMock.ForecastFindBetweenFunc is not set")
+	}
+	return m.ForecastFindBetweenFunc(ctx, from, to)
+}
+
+func (m *RepositoryMock) ForecastUpsert(ctx context.Context, forecasts []model.DemandForecast) error {
+	if m.ForecastUpsertFunc == nil {
+		panic("RepositoryMock.ForecastUpsertFunc is not set")
+	}
+	return m.ForecastUpsertFunc(ctx, forecasts)
+}
+
+func (m *RepositoryMock) GetEmployeeByID(ctx context.Context, id uint, emp *model.Employee) error {
+	if m.GetEmployeeByIDFunc == nil {
+		panic("RepositoryMock.GetEmployeeByIDFunc is not set")
+	}
+	return m.GetEmployeeByIDFunc(ctx, id, emp)
+}
+
+func (m *RepositoryMock) GetEmployeeIncluding(ctx context.Context, id uint, relations ...string) (*model.Employee, error) {
+	if m.GetEmployeeIncludingFunc == nil {
+		panic("RepositoryMock.GetEmployeeIncludingFunc is not set")
+	}
+	return m.GetEmployeeIncludingFunc(ctx, id, relations...)
+}
+
+func (m *RepositoryMock) GetEmployeeWithSchedules(ctx context.Context, id uint) (*model.Employee, error) {
+	if m.GetEmployeeWithSchedulesFunc == nil {
+		panic("RepositoryMock.GetEmployeeWithSchedulesFunc is not set")
+	}
+	return m.GetEmployeeWithSchedulesFunc(ctx, id)
+}
+
+func (m *RepositoryMock) GetEmployeeWithSchedulesByWeekType(ctx context.Context, employeeID uint, weekType string) (*model.Employee, error) {
+	if m.GetEmployeeWithSchedulesByWeekTypeFunc == nil {
+		panic("RepositoryMock.GetEmployeeWithSchedulesByWeekTypeFunc is not set")
+	}
+	return m.GetEmployeeWithSchedulesByWeekTypeFunc(ctx, employeeID, weekType)
+}
+
+func (m *RepositoryMock) GetEmployees(ctx context.Context) ([]model.Employee, error) {
+	if m.GetEmployeesFunc == nil {
+		panic("RepositoryMock.GetEmployeesFunc is not set")
+	}
+	return m.GetEmployeesFunc(ctx)
+}
+
+func (m *RepositoryMock) GetEmployeesWithCalendar(ctx context.Context, ids []uint, from time.Time, to time.Time) ([]model.Employee, error) {
+	if m.GetEmployeesWithCalendarFunc == nil {
+		panic("RepositoryMock.GetEmployeesWithCalendarFunc is not set")
+	}
+	return m.GetEmployeesWithCalendarFunc(ctx, ids, from, to)
+}
+
+func (m *RepositoryMock) GetEmployeesWithSchedules(ctx context.Context) ([]model.Employee, error) {
+	if m.GetEmployeesWithSchedulesFunc == nil {
+		panic("RepositoryMock.GetEmployeesWithSchedulesFunc is not set")
+	}
+	return m.GetEmployeesWithSchedulesFunc(ctx)
+}
+
+func (m *RepositoryMock) GetSchedule(ctx context.Context, employeeID uint, weekType string) ([]model.Schedule, error) {
+	if m.GetScheduleFunc == nil {
+		panic("RepositoryMock.GetScheduleFunc is not set")
+	}
+	return m.GetScheduleFunc(ctx, employeeID, weekType)
+}
+
+func (m *RepositoryMock) GetScheduleByID(ctx context.Context, id uint) (*model.Schedule, error) {
+	if m.GetScheduleByIDFunc == nil {
+		panic("RepositoryMock.GetScheduleByIDFunc is not set")
+	}
+	return m.GetScheduleByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) HolidayCreate(ctx context.Context, holiday *model.Holiday) error {
+	if m.HolidayCreateFunc == nil {
+		panic("RepositoryMock.HolidayCreateFunc is not set")
+	}
+	return m.HolidayCreateFunc(ctx, holiday)
+}
+
+func (m *RepositoryMock) HolidayDeleteYear(ctx context.Context, year int) (int64, error) {
+	if m.HolidayDeleteYearFunc == nil {
+		panic("RepositoryMock.HolidayDeleteYearFunc is not set")
+	}
+	return m.HolidayDeleteYearFunc(ctx, year)
+}
+
+func (m *RepositoryMock) HolidayFindByDate(ctx context.Context, date time.Time) (*model.Holiday, error) {
+	if m.HolidayFindByDateFunc == nil {
+		panic("RepositoryMock.HolidayFindByDateFunc is not set")
+	}
+	return m.HolidayFindByDateFunc(ctx, date)
+}
+
+func (m *RepositoryMock) HolidayFindByMonthAndYear(ctx context.Context, year int, month time.Month) ([]model.Holiday, error) {
+	if m.HolidayFindByMonthAndYearFunc == nil {
+		panic("RepositoryMock.HolidayFindByMonthAndYearFunc is not set")
+	}
+	return m.HolidayFindByMonthAndYearFunc(ctx, year, month)
+}
+
+func (m *RepositoryMock) HolidayFindByYear(ctx context.Context, year int) ([]model.Holiday, error) {
+	if m.HolidayFindByYearFunc == nil {
+		panic("RepositoryMock.HolidayFindByYearFunc is not set")
+	}
+	return m.HolidayFindByYearFunc(ctx, year)
+}
+
+func (m *RepositoryMock) HolidayListAll(ctx context.Context) ([]model.Holiday, error) {
+	if m.HolidayListAllFunc == nil {
+		panic("RepositoryMock.HolidayListAllFunc is not set")
+	}
+	return m.HolidayListAllFunc(ctx)
+}
+
+func (m *RepositoryMock) HolidayUpdate(ctx context.Context, holiday *model.Holiday) error {
+	if m.HolidayUpdateFunc == nil {
+		panic("RepositoryMock.HolidayUpdateFunc is not set")
+	}
+	return m.HolidayUpdateFunc(ctx, holiday)
+}
+
+func (m *RepositoryMock) HolidayUpsert(ctx context.Context, holidays []model.Holiday) error {
+	if m.HolidayUpsertFunc == nil {
+		panic("RepositoryMock.HolidayUpsertFunc is not set")
+	}
+	return m.HolidayUpsertFunc(ctx, holidays)
+}
+
+func (m *RepositoryMock) IDByUUID(ctx context.Context, resource interface{}, uuid string) (uint, error) {
+	if m.IDByUUIDFunc == nil {
+		panic("RepositoryMock.IDByUUIDFunc is not set")
+	}
+	return m.IDByUUIDFunc(ctx, resource, uuid)
+}
+
+func (m *RepositoryMock) ImportEmployees(ctx context.Context, record *model.EmployeeImport, employees []*model.Employee, upsert bool) (int, error) {
+	if m.ImportEmployeesFunc == nil {
+		panic("RepositoryMock.ImportEmployeesFunc is not set")
+	}
+	return m.ImportEmployeesFunc(ctx, record, employees, upsert)
+}
+
+func (m *RepositoryMock) ImportFindByHash(ctx context.Context, hash string) (*model.EmployeeImport, error) {
+	if m.ImportFindByHashFunc == nil {
+		panic("RepositoryMock.ImportFindByHashFunc is not set")
+	}
+	return m.ImportFindByHashFunc(ctx, hash)
+}
+
+func (m *RepositoryMock) ImportList(ctx context.Context, limit int) ([]model.EmployeeImport, error) {
+	if m.ImportListFunc == nil {
+		panic("RepositoryMock.ImportListFunc is not set")
+	}
+	return m.ImportListFunc(ctx, limit)
+}
+
+func (m *RepositoryMock) LeaveCreate(ctx context.Context, employeeID uint, days []model.EmployeeHoliday) error {
+	if m.LeaveCreateFunc == nil {
+		panic("RepositoryMock.LeaveCreateFunc is not set")
+	}
+	return m.LeaveCreateFunc(ctx, employeeID, days)
+}
+
+func (m *RepositoryMock) LeaveDecide(ctx context.Context, requestID uint, status string, approverID *uint) ([]model.EmployeeHoliday, error) {
+	if m.LeaveDecideFunc == nil {
+		panic("RepositoryMock.LeaveDecideFunc is not set")
+	}
+	return m.LeaveDecideFunc(ctx, requestID, status, approverID)
+}
+
+func (m *RepositoryMock) LeaveDeleteBetween(ctx context.Context, employeeID uint, from time.Time, to time.Time) (int64, error) {
+	if m.LeaveDeleteBetweenFunc == nil {
+		panic("RepositoryMock.LeaveDeleteBetweenFunc is not set")
+	}
+	return m.LeaveDeleteBetweenFunc(ctx, employeeID, from, to)
+}
+
+func (m *RepositoryMock) LeaveFindAllBetween(ctx context.Context, from time.Time, to time.Time, status string) ([]model.EmployeeHoliday, error) {
+	if m.LeaveFindAllBetweenFunc == nil {
+		panic("RepositoryMock.LeaveFindAllBetweenFunc is not set")
+	}
+	return m.LeaveFindAllBetweenFunc(ctx, from, to, status)
+}
+
+func (m *RepositoryMock) LeaveFindBetween(ctx context.Context, employeeID uint, from time.Time, to time.Time, status string) ([]model.EmployeeHoliday, error) {
+	if m.LeaveFindBetweenFunc == nil {
+		panic("RepositoryMock.LeaveFindBetweenFunc is not set")
+	}
+	return m.LeaveFindBetweenFunc(ctx, employeeID, from, to, status)
+}
+
+func (m *RepositoryMock) LeaveFindByID(ctx context.Context, id uint) (*model.EmployeeHoliday, error) {
+	if m.LeaveFindByIDFunc == nil {
+		panic("RepositoryMock.LeaveFindByIDFunc is not set")
+	}
+	return m.LeaveFindByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) LoadEmployees(ctx context.Context, employees []*model.Employee) error {
+	if m.LoadEmployeesFunc == nil {
+		panic("RepositoryMock.LoadEmployeesFunc is not set")
+	}
+	return m.LoadEmployeesFunc(ctx, employees)
+}
+
+func (m *RepositoryMock) MigrationStatus(ctx context.Context) ([]model.MigrationStatus, error) {
+	if m.MigrationStatusFunc == nil {
+		panic("RepositoryMock.MigrationStatusFunc is not set")
+	}
+	return m.MigrationStatusFunc(ctx)
+}
+
+func (m *RepositoryMock) OverrideDelete(ctx context.Context, employeeID uint, date time.Time) error {
+	if m.OverrideDeleteFunc == nil {
+		panic("RepositoryMock.OverrideDeleteFunc is not set")
+	}
+	return m.OverrideDeleteFunc(ctx, employeeID, date)
+}
+
+func (m *RepositoryMock) OverrideFindBetween(ctx context.Context, employeeID uint, from time.Time, to time.Time) ([]model.ScheduleOverride, error) {
+	if m.OverrideFindBetweenFunc == nil {
+		panic("RepositoryMock.OverrideFindBetweenFunc is not set")
+	}
+	return m.OverrideFindBetweenFunc(ctx, employeeID, from, to)
+}
+
+func (m *RepositoryMock) OverrideSave(ctx context.Context, override *model.ScheduleOverride) error {
+	if m.OverrideSaveFunc == nil {
+		panic("RepositoryMock.OverrideSaveFunc is not set")
+	}
+	return m.OverrideSaveFunc(ctx, override)
+}
+
+func (m *RepositoryMock) PairingRuleCreate(ctx context.Context, rule *model.PairingRule) error {
+	if m.PairingRuleCreateFunc == nil {
+		panic("RepositoryMock.PairingRuleCreateFunc is not set")
+	}
+	return m.PairingRuleCreateFunc(ctx, rule)
+}
+
+func (m *RepositoryMock) PairingRuleDelete(ctx context.Context, id uint) error {
+	if m.PairingRuleDeleteFunc == nil {
+		panic("RepositoryMock.PairingRuleDeleteFunc is not set")
+	}
+	return m.PairingRuleDeleteFunc(ctx, id)
+}
+
+func (m *RepositoryMock) PairingRuleList(ctx context.Context) ([]model.PairingRule, error) {
+	if m.PairingRuleListFunc == nil {
+		panic("RepositoryMock.PairingRuleListFunc is not set")
+	}
+	return m.PairingRuleListFunc(ctx)
+}
+
+func (m *RepositoryMock) PlannedHoursByWeekType(ctx context.Context) ([]model.EmployeeWeekTypeHours, error) {
+	if m.PlannedHoursByWeekTypeFunc == nil {
+		panic("RepositoryMock.PlannedHoursByWeekTypeFunc is not set")
+	}
+	return m.PlannedHoursByWeekTypeFunc(ctx)
+}
+
+func (m *RepositoryMock) PrintJobClaim(ctx context.Context, staleBefore time.Time) (*model.PrintJob, error) {
+	if m.PrintJobClaimFunc == nil {
+		panic("RepositoryMock.PrintJobClaimFunc is not set")
+	}
+	return m.PrintJobClaimFunc(ctx, staleBefore)
+}
+
+func (m *RepositoryMock) PrintJobCreate(ctx context.Context, job *model.PrintJob) error {
+	if m.PrintJobCreateFunc == nil {
+		panic("RepositoryMock.PrintJobCreateFunc is not set")
+	}
+	return m.PrintJobCreateFunc(ctx, job)
+}
+
+func (m *RepositoryMock) PrintJobFindByID(ctx context.Context, id uint) (*model.PrintJob, error) {
+	if m.PrintJobFindByIDFunc == nil {
+		panic("RepositoryMock.PrintJobFindByIDFunc is not set")
+	}
+	return m.PrintJobFindByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) PrintJobFindByUUID(ctx context.Context, uuid string) (*model.PrintJob, error) {
+	if m.PrintJobFindByUUIDFunc == nil {
+		panic("RepositoryMock.PrintJobFindByUUIDFunc is not set")
+	}
+	return m.PrintJobFindByUUIDFunc(ctx, uuid)
+}
+
+func (m *RepositoryMock) PrintJobSave(ctx context.Context, job *model.PrintJob) error {
+	if m.PrintJobSaveFunc == nil {
+		panic("RepositoryMock.PrintJobSaveFunc is not set")
+	}
+	return m.PrintJobSaveFunc(ctx, job)
+}
+
+func (m *RepositoryMock) ProvenanceFind(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error) {
+	if m.ProvenanceFindFunc == nil {
+		panic("RepositoryMock.ProvenanceFindFunc is not set")
+	}
+	return m.ProvenanceFindFunc(ctx, filter)
+}
+
+func (m *RepositoryMock) ReplaceSchedules(ctx context.Context, employeeID uint, weekType string, dayName string, schedules []model.Schedule) error {
+	if m.ReplaceSchedulesFunc == nil {
+		panic("RepositoryMock.ReplaceSchedulesFunc is not set")
+	}
+	return m.ReplaceSchedulesFunc(ctx, employeeID, weekType, dayName, schedules)
+}
+
+func (m *RepositoryMock) ReplaceSchedulesFrom(ctx context.Context, version *model.ScheduleVersion, weekType string, dayName string, schedules []model.Schedule) error {
+	if m.ReplaceSchedulesFromFunc == nil {
+		panic("RepositoryMock.ReplaceSchedulesFromFunc is not set")
+	}
+	return m.ReplaceSchedulesFromFunc(ctx, version, weekType, dayName, schedules)
+}
+
+func (m *RepositoryMock) RestoreEmployee(ctx context.Context, id uint) error {
+	if m.RestoreEmployeeFunc == nil {
+		panic("RepositoryMock.RestoreEmployeeFunc is not set")
+	}
+	return m.RestoreEmployeeFunc(ctx, id)
+}
+
+func (m *RepositoryMock) RevenueFindBetween(ctx context.Context, from time.Time, to time.Time) ([]model.DailyRevenue, error) {
+	if m.RevenueFindBetweenFunc == nil {
+		panic("RepositoryMock.RevenueFindBetweenFunc is not set")
+	}
+	return m.RevenueFindBetweenFunc(ctx, from, to)
+}
+
+func (m *RepositoryMock) RevenueUpsert(ctx context.Context, revenues []model.DailyRevenue) error {
+	if m.RevenueUpsertFunc == nil {
+		panic("RepositoryMock.RevenueUpsertFunc is not set")
+	}
+	return m.RevenueUpsertFunc(ctx, revenues)
+}
+
+func (m *RepositoryMock) RoleTemplateCreate(ctx context.Context, template *model.RoleTemplate) error {
+	if m.RoleTemplateCreateFunc == nil {
+		panic("RepositoryMock.RoleTemplateCreateFunc is not set")
+	}
+	return m.RoleTemplateCreateFunc(ctx, template)
+}
+
+func (m *RepositoryMock) RoleTemplateFindByID(ctx context.Context, id uint) (*model.RoleTemplate, error) {
+	if m.RoleTemplateFindByIDFunc == nil {
+		panic("RepositoryMock.RoleTemplateFindByIDFunc is not set")
+	}
+	return m.RoleTemplateFindByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) RoleTemplateFindByName(ctx context.Context, name string) (*model.RoleTemplate, error) {
+	if m.RoleTemplateFindByNameFunc == nil {
+		panic("RepositoryMock.RoleTemplateFindByNameFunc is not set")
+	}
+	return m.RoleTemplateFindByNameFunc(ctx, name)
+}
+
+func (m *RepositoryMock) RoleTemplateList(ctx context.Context) ([]model.RoleTemplate, error) {
+	if m.RoleTemplateListFunc == nil {
+		panic("RepositoryMock.RoleTemplateListFunc is not set")
+	}
+	return m.RoleTemplateListFunc(ctx)
+}
+
+func (m *RepositoryMock) RoleTemplateReplaceSlots(ctx context.Context, id uint, slots []model.RoleTemplateSlot) error {
+	if m.RoleTemplateReplaceSlotsFunc == nil {
+		panic("RepositoryMock.RoleTemplateReplaceSlotsFunc is not set")
+	}
+	return m.RoleTemplateReplaceSlotsFunc(ctx, id, slots)
+}
+
+func (m *RepositoryMock) RollbackImport(ctx context.Context, id uint) (*model.EmployeeImport, error) {
+	if m.RollbackImportFunc == nil {
+		panic("RepositoryMock.RollbackImportFunc is not set")
+	}
+	return m.RollbackImportFunc(ctx, id)
+}
+
+func (m *RepositoryMock) RotationCalendarGet(ctx context.Context) (*model.RotationCalendar, error) {
+	if m.RotationCalendarGetFunc == nil {
+		panic("RepositoryMock.RotationCalendarGetFunc is not set")
+	}
+	return m.RotationCalendarGetFunc(ctx)
+}
+
+func (m *RepositoryMock) RotationCalendarSet(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error) {
+	if m.RotationCalendarSetFunc == nil {
+		panic("RepositoryMock.RotationCalendarSetFunc is not set")
+	}
+	return m.RotationCalendarSetFunc(ctx, anchor)
+}
+
+func (m *RepositoryMock) RotationCreate(ctx context.Context, pattern *model.RotationPattern) error {
+	if m.RotationCreateFunc == nil {
+		panic("RepositoryMock.RotationCreateFunc is not set")
+	}
+	return m.RotationCreateFunc(ctx, pattern)
+}
+
+func (m *RepositoryMock) RotationFindByID(ctx context.Context, id uint) (*model.RotationPattern, error) {
+	if m.RotationFindByIDFunc == nil {
+		panic("RepositoryMock.RotationFindByIDFunc is not set")
+	}
+	return m.RotationFindByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) RotationFindByName(ctx context.Context, name string) (*model.RotationPattern, error) {
+	if m.RotationFindByNameFunc == nil {
+		panic("RepositoryMock.RotationFindByNameFunc is not set")
+	}
+	return m.RotationFindByNameFunc(ctx, name)
+}
+
+func (m *RepositoryMock) RotationList(ctx context.Context) ([]model.RotationPattern, error) {
+	if m.RotationListFunc == nil {
+		panic("RepositoryMock.RotationListFunc is not set")
+	}
+	return m.RotationListFunc(ctx)
+}
+
+func (m *RepositoryMock) SaveScheduleSnapshot(ctx context.Context, employeeID uint, snapshot *model.ScheduleSnapshot) error {
+	if m.SaveScheduleSnapshotFunc == nil {
+		panic("RepositoryMock.SaveScheduleSnapshotFunc is not set")
+	}
+	return m.SaveScheduleSnapshotFunc(ctx, employeeID, snapshot)
+}
+
+func (m *RepositoryMock) ScheduleVersionCreate(ctx context.Context, version *model.ScheduleVersion) error {
+	if m.ScheduleVersionCreateFunc == nil {
+		panic("RepositoryMock.ScheduleVersionCreateFunc is not set")
+	}
+	return m.ScheduleVersionCreateFunc(ctx, version)
+}
+
+func (m *RepositoryMock) ScheduleVersionList(ctx context.Context, employeeID uint) ([]model.ScheduleVersion, error) {
+	if m.ScheduleVersionListFunc == nil {
+		panic("RepositoryMock.ScheduleVersionListFunc is not set")
+	}
+	return m.ScheduleVersionListFunc(ctx, employeeID)
+}
+
+func (m *RepositoryMock) SearchEmployees(ctx context.Context, query string, limit int) ([]model.Employee, error) {
+	if m.SearchEmployeesFunc == nil {
+		panic("RepositoryMock.SearchEmployeesFunc is not set")
+	}
+	return m.SearchEmployeesFunc(ctx, query, limit)
+}
+
+func (m *RepositoryMock) SetEmployeeRoleTemplate(ctx context.Context, employeeID uint, templateID *uint) error {
+	if m.SetEmployeeRoleTemplateFunc == nil {
+		panic("RepositoryMock.SetEmployeeRoleTemplateFunc is not set")
+	}
+	return m.SetEmployeeRoleTemplateFunc(ctx, employeeID, templateID)
+}
+
+func (m *RepositoryMock) SetEmployeeRotation(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error {
+	if m.SetEmployeeRotationFunc == nil {
+		panic("RepositoryMock.SetEmployeeRotationFunc is not set")
+	}
+	return m.SetEmployeeRotationFunc(ctx, employeeID, patternID, anchor)
+}
+
+func (m *RepositoryMock) StaffingRequirementCreate(ctx context.Context, requirement *model.StaffingRequirement) error {
+	if m.StaffingRequirementCreateFunc == nil {
+		panic("RepositoryMock.StaffingRequirementCreateFunc is not set")
+	}
+	return m.StaffingRequirementCreateFunc(ctx, requirement)
+}
+
+func (m *RepositoryMock) StaffingRequirementDelete(ctx context.Context, id uint) error {
+	if m.StaffingRequirementDeleteFunc == nil {
+		panic("RepositoryMock.StaffingRequirementDeleteFunc is not set")
+	}
+	return m.StaffingRequirementDeleteFunc(ctx, id)
+}
+
+func (m *RepositoryMock) StaffingRequirementList(ctx context.Context) ([]model.StaffingRequirement, error) {
+	if m.StaffingRequirementListFunc == nil {
+		panic("RepositoryMock.StaffingRequirementListFunc is not set")
+	}
+	return m.StaffingRequirementListFunc(ctx)
+}
+
+func (m *RepositoryMock) TableRowCounts(ctx context.Context) ([]model.TableRowCount, error) {
+	if m.TableRowCountsFunc == nil {
+		panic("RepositoryMock.TableRowCountsFunc is not set")
+	}
+	return m.TableRowCountsFunc(ctx)
+}
+
+func (m *RepositoryMock) TenantCreate(ctx context.Context, tenant *model.Tenant) error {
+	if m.TenantCreateFunc == nil {
+		panic("RepositoryMock.TenantCreateFunc is not set")
+	}
+	return m.TenantCreateFunc(ctx, tenant)
+}
+
+func (m *RepositoryMock) TenantFindByAPIKeyHash(ctx context.Context, hash string) (*model.Tenant, error) {
+	if m.TenantFindByAPIKeyHashFunc == nil {
+		panic("RepositoryMock.TenantFindByAPIKeyHashFunc is not set")
+	}
+	return m.TenantFindByAPIKeyHashFunc(ctx, hash)
+}
+
+func (m *RepositoryMock) TenantFindBySubdomain(ctx context.Context, subdomain string) (*model.Tenant, error) {
+	if m.TenantFindBySubdomainFunc == nil {
+		panic("RepositoryMock.TenantFindBySubdomainFunc is not set")
+	}
+	return m.TenantFindBySubdomainFunc(ctx, subdomain)
+}
+
+func (m *RepositoryMock) TimeEntryCreate(ctx context.Context, entry *model.TimeEntry) error {
+	if m.TimeEntryCreateFunc == nil {
+		panic("RepositoryMock.TimeEntryCreateFunc is not set")
+	}
+	return m.TimeEntryCreateFunc(ctx, entry)
+}
+
+func (m *RepositoryMock) TimeEntryFindBetween(ctx context.Context, employeeID uint, from time.Time, to time.Time) ([]model.TimeEntry, error) {
+	if m.TimeEntryFindBetweenFunc == nil {
+		panic("RepositoryMock.TimeEntryFindBetweenFunc is not set")
+	}
+	return m.TimeEntryFindBetweenFunc(ctx, employeeID, from, to)
+}
+
+func (m *RepositoryMock) TimeEntryFindByNonce(ctx context.Context, deviceID string, nonce string) (*model.TimeEntry, error) {
+	if m.TimeEntryFindByNonceFunc == nil {
+		panic("RepositoryMock.TimeEntryFindByNonceFunc is not set")
+	}
+	return m.TimeEntryFindByNonceFunc(ctx, deviceID, nonce)
+}
+
+func (m *RepositoryMock) TimeEntryFindNear(ctx context.Context, employeeID uint, kind string, from time.Time, to time.Time) (*model.TimeEntry, error) {
+	if m.TimeEntryFindNearFunc == nil {
+		panic("RepositoryMock.TimeEntryFindNearFunc is not set")
+	}
+	return m.TimeEntryFindNearFunc(ctx, employeeID, kind, from, to)
+}
+
+func (m *RepositoryMock) TimeEntryLastSequence(ctx context.Context, deviceID string) (int64, error) {
+	if m.TimeEntryLastSequenceFunc == nil {
+		panic("RepositoryMock.TimeEntryLastSequenceFunc is not set")
+	}
+	return m.TimeEntryLastSequenceFunc(ctx, deviceID)
+}
+
+func (m *RepositoryMock) TimeEntryListBetween(ctx context.Context, from time.Time, to time.Time) ([]model.TimeEntry, error) {
+	if m.TimeEntryListBetweenFunc == nil {
+		panic("RepositoryMock.TimeEntryListBetweenFunc is not set")
+	}
+	return m.TimeEntryListBetweenFunc(ctx, from, to)
+}
+
+func (m *RepositoryMock) TouchEmployees(ctx context.Context, ids []uint) (int64, error) {
+	if m.TouchEmployeesFunc == nil {
+		panic("RepositoryMock.TouchEmployeesFunc is not set")
+	}
+	return m.TouchEmployeesFunc(ctx, ids)
+}
+
+func (m *RepositoryMock) Transaction(ctx context.Context, fn func(tx Repository) error) error {
+	if m.TransactionFunc == nil {
+		panic("RepositoryMock.TransactionFunc is not set")
+	}
+	return m.TransactionFunc(ctx, fn)
+}
+
+func (m *RepositoryMock) UnavailabilityCreate(ctx context.Context, unavailability *model.Unavailability) error {
+	if m.UnavailabilityCreateFunc == nil {
+		panic("RepositoryMock.UnavailabilityCreateFunc is not set")
+	}
+	return m.UnavailabilityCreateFunc(ctx, unavailability)
+}
+
+func (m *RepositoryMock) UnavailabilityDelete(ctx context.Context, employeeID uint, id uint) error {
+	if m.UnavailabilityDeleteFunc == nil {
+		panic("RepositoryMock.UnavailabilityDeleteFunc is not set")
+	}
+	return m.UnavailabilityDeleteFunc(ctx, employeeID, id)
+}
+
+func (m *RepositoryMock) UnavailabilityList(ctx context.Context) ([]model.Unavailability, error) {
+	if m.UnavailabilityListFunc == nil {
+		panic("RepositoryMock.UnavailabilityListFunc is not set")
+	}
+	return m.UnavailabilityListFunc(ctx)
+}
+
+func (m *RepositoryMock) UnavailabilityListByEmployee(ctx context.Context, employeeID uint) ([]model.Unavailability, error) {
+	if m.UnavailabilityListByEmployeeFunc == nil {
+		panic("RepositoryMock.UnavailabilityListByEmployeeFunc is not set")
+	}
+	return m.UnavailabilityListByEmployeeFunc(ctx, employeeID)
+}
+
+func (m *RepositoryMock) UnavailabilityUpdate(ctx context.Context, unavailability *model.Unavailability) error {
+	if m.UnavailabilityUpdateFunc == nil {
+		panic("RepositoryMock.UnavailabilityUpdateFunc is not set")
+	}
+	return m.UnavailabilityUpdateFunc(ctx, unavailability)
+}
+
+func (m *RepositoryMock) UnscheduledEmployeeIDs(ctx context.Context) ([]uint, error) {
+	if m.UnscheduledEmployeeIDsFunc == nil {
+		panic("RepositoryMock.UnscheduledEmployeeIDsFunc is not set")
+	}
+	return m.UnscheduledEmployeeIDsFunc(ctx)
+}
+
+func (m *RepositoryMock) UpdateEmployee(ctx context.Context, employee model.Employee) error {
+	if m.UpdateEmployeeFunc == nil {
+		panic("RepositoryMock.UpdateEmployeeFunc is not set")
+	}
+	return m.UpdateEmployeeFunc(ctx, employee)
+}
+
+func (m *RepositoryMock) UpdateSchedule(ctx context.Context, schedule model.Schedule) error {
+	if m.UpdateScheduleFunc == nil {
+		panic("RepositoryMock.UpdateScheduleFunc is not set")
+	}
+	return m.UpdateScheduleFunc(ctx, schedule)
+}
+
+func (m *RepositoryMock) UpdateScheduleTask(ctx context.Context, id uint, task string, changedByID *uint) error {
+	if m.UpdateScheduleTaskFunc == nil {
+		panic("RepositoryMock.UpdateScheduleTaskFunc is not set")
+	}
+	return m.UpdateScheduleTaskFunc(ctx, id, task, changedByID)
+}
+
+func (m *RepositoryMock) UserCreate(ctx context.Context, user *model.User) error {
+	if m.UserCreateFunc == nil {
+		panic("RepositoryMock.UserCreateFunc is not set")
+	}
+	return m.UserCreateFunc(ctx, user)
+}
+
+func (m *RepositoryMock) UserFindByUsername(ctx context.Context, username string) (*model.User, error) {
+	if m.UserFindByUsernameFunc == nil {
+		panic("RepositoryMock.UserFindByUsernameFunc is not set")
+	}
+	return m.UserFindByUsernameFunc(ctx, username)
+}
+
+func (m *RepositoryMock) UserUpdatePasswordHash(ctx context.Context, id uint, hash string) error {
+	if m.UserUpdatePasswordHashFunc == nil {
+		panic("RepositoryMock.UserUpdatePasswordHashFunc is not set")
+	}
+	return m.UserUpdatePasswordHashFunc(ctx, id, hash)
+}
+
+func (m *RepositoryMock) ValidationSettingsGet(ctx context.Context) (*model.ValidationSettings, error) {
+	if m.ValidationSettingsGetFunc == nil {
+		panic("RepositoryMock.ValidationSettingsGetFunc is not set")
+	}
+	return m.ValidationSettingsGetFunc(ctx)
+}
+
+func (m *RepositoryMock) ValidationSettingsSet(ctx context.Context, settings *model.ValidationSettings) error {
+	if m.ValidationSettingsSetFunc == nil {
+		panic("RepositoryMock.ValidationSettingsSetFunc is not set")
+	}
+	return m.ValidationSettingsSetFunc(ctx, settings)
+}
+
+func (m *RepositoryMock) WebhookCreate(ctx context.Context, hook *model.Webhook) error {
+	if m.WebhookCreateFunc == nil {
+		panic("RepositoryMock.WebhookCreateFunc is not set")
+	}
+	return m.WebhookCreateFunc(ctx, hook)
+}
+
+func (m *RepositoryMock) WebhookDelete(ctx context.Context, id uint) error {
+	if m.WebhookDeleteFunc == nil {
+		panic("RepositoryMock.WebhookDeleteFunc is not set")
+	}
+	return m.WebhookDeleteFunc(ctx, id)
+}
+
+func (m *RepositoryMock) WebhookDeliveryClaim(ctx context.Context, now time.Time, staleBefore time.Time) (*model.WebhookDelivery, error) {
+	if m.WebhookDeliveryClaimFunc == nil {
+		panic("RepositoryMock.WebhookDeliveryClaimFunc is not set")
+	}
+	return m.WebhookDeliveryClaimFunc(ctx, now, staleBefore)
+}
+
+func (m *RepositoryMock) WebhookDeliveryCreate(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if m.WebhookDeliveryCreateFunc == nil {
+		panic("RepositoryMock.WebhookDeliveryCreateFunc is not set")
+	}
+	return m.WebhookDeliveryCreateFunc(ctx, deliveries)
+}
+
+func (m *RepositoryMock) WebhookDeliveryList(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	if m.WebhookDeliveryListFunc == nil {
+		panic("RepositoryMock.WebhookDeliveryListFunc is not set")
+	}
+	return m.WebhookDeliveryListFunc(ctx, webhookID, limit)
+}
+
+func (m *RepositoryMock) WebhookDeliverySave(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if m.WebhookDeliverySaveFunc == nil {
+		panic("RepositoryMock.WebhookDeliverySaveFunc is not set")
+	}
+	return m.WebhookDeliverySaveFunc(ctx, delivery)
+}
+
+func (m *RepositoryMock) WebhookFindByID(ctx context.Context, id uint) (*model.Webhook, error) {
+	if m.WebhookFindByIDFunc == nil {
+		panic("RepositoryMock.WebhookFindByIDFunc is not set")
+	}
+	return m.WebhookFindByIDFunc(ctx, id)
+}
+
+func (m *RepositoryMock) WebhookList(ctx context.Context) ([]model.Webhook, error) {
+	if m.WebhookListFunc == nil {
+		panic("RepositoryMock.WebhookListFunc is not set")
+	}
+	return m.WebhookListFunc(ctx)
+}
+
+func (m *RepositoryMock) WebhookSetSecret(ctx context.Context, id uint, secret string) error {
+	if m.WebhookSetSecretFunc == nil {
+		panic("RepositoryMock.WebhookSetSecretFunc is not set")
+	}
+	return m.WebhookSetSecretFunc(ctx, id, secret)
+}
+
+func (m *RepositoryMock) WebhookUpdate(ctx context.Context, hook *model.Webhook) error {
+	if m.WebhookUpdateFunc == nil {
+		panic("RepositoryMock.WebhookUpdateFunc is not set")
+	}
+	return m.WebhookUpdateFunc(ctx, hook)
+}
@@ -0,0 +1,40 @@
+// Package repotest provides repositories for the tests of the packages above db/repo, so that they run
+// without a Postgres server.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	repo "github.com/lichensio/api_server/db/repo"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"sync/atomic"
+	"testing"
+)
+
+var databases atomic.Int64
+
+// NewInMemoryRepository returns a repository on a private in-memory sqlite database, migrated like a
+// production database and closed when tb ends. It runs the same queries as against Postgres, so that the
+// tests exercise them too.
+func NewInMemoryRepository(tb testing.TB) repo.Repository {
+	tb.Helper()
+	// The connections of the pool share the database by its name, unique to the repository.
+	dsn := fmt.Sprintf("file:repotest%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		tb.Fatalf("opening the in-memory database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatalf("opening the in-memory database: %v", err)
+	}
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	repository := repo.NewRepositoryWithDB(db)
+	if err := repository.DBCreate(context.Background()); err != nil {
+		tb.Fatalf("migrating the in-memory database: %v", err)
+	}
+	return repository
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on rotation patterns
+
+func orderWeeks(db *gorm.DB) *gorm.DB { return db.Order("position") }
+
+// RotationCreate inserts a rotation pattern together with its weeks
+func (repo *repository) RotationCreate(ctx context.Context, pattern *model.RotationPattern) error {
+	return repo.db.WithContext(ctx).Create(pattern).Error
+}
+
+// RotationList retrieves every rotation pattern with its weeks
+func (repo *repository) RotationList(ctx context.Context) ([]model.RotationPattern, error) {
+	var patterns []model.RotationPattern
+	err := repo.db.WithContext(ctx).Preload("Weeks", orderWeeks).Order("name").Find(&patterns).Error
+	return patterns, err
+}
+
+// RotationFindByID retrieves a rotation pattern with its weeks
+func (repo *repository) RotationFindByID(ctx context.Context, id uint) (*model.RotationPattern, error) {
+	var pattern model.RotationPattern
+	if err := repo.db.WithContext(ctx).Preload("Weeks", orderWeeks).First(&pattern, id).Error; err != nil {
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+// RotationFindByName retrieves a rotation pattern with its weeks by its unique name
+func (repo *repository) RotationFindByName(ctx context.Context, name string) (*model.RotationPattern, error) {
+	var pattern model.RotationPattern
+	if err := repo.db.WithContext(ctx).Preload("Weeks", orderWeeks).Where("name = ?", name).First(&pattern).Error; err != nil {
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+// SetEmployeeRotation makes an employee follow a rotation pattern, or the A/B rotation when patternID is nil,
+// anchored on anchor, or on its start date when anchor is nil
+func (repo *repository) SetEmployeeRotation(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error {
+	result := repo.db.WithContext(ctx).Model(&model.Employee{}).Where("id = ?", employeeID).
+		Updates(map[string]interface{}{"rotation_pattern_id": patternID, "rotation_anchor": anchor})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// seedDefaultRotation creates the A/B rotation pattern the week types stored before rotation patterns
+// existed belong to, unless it already exists
+func (repo *repository) seedDefaultRotation(ctx context.Context) error {
+	_, err := repo.RotationFindByName(ctx, model.DefaultRotationName)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	pattern := model.DefaultRotation
+	pattern.Weeks = append([]model.RotationWeek(nil), model.DefaultRotation.Weeks...)
+	return repo.RotationCreate(ctx, &pattern)
+}
+
+// migrateRotationCalendar creates the table of the rotation calendars
+func migrateRotationCalendar(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.RotationCalendar{})
+}
+
+// RotationCalendarGet retrieves the rotation calendar of the tenant
+func (repo *repository) RotationCalendarGet(ctx context.Context) (*model.RotationCalendar, error) {
+	var calendar model.RotationCalendar
+	if err := repo.db.WithContext(ctx).First(&calendar).Error; err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// RotationCalendarSet anchors the rotation calendar of the tenant on anchor, creating it if needed
+func (repo *repository) RotationCalendarSet(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error) {
+	calendar, err := repo.RotationCalendarGet(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		calendar = &model.RotationCalendar{Anchor: anchor}
+		return calendar, repo.db.WithContext(ctx).Create(calendar).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	calendar.Anchor = anchor
+	return calendar, repo.db.WithContext(ctx).Save(calendar).Error
+}
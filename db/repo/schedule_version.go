@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation on the schedule versions, the patterns of the employees replaced from an effective date
+
+// migrateScheduleVersions creates the table of the schedule versions
+func migrateScheduleVersions(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.ScheduleVersion{})
+}
+
+// orderVersions orders the schedule versions of an employee, oldest first
+func orderVersions(db *gorm.DB) *gorm.DB {
+	return db.Order("effective_until")
+}
+
+// ScheduleVersionList retrieves the schedule versions of an employee, oldest first
+func (repo *repository) ScheduleVersionList(ctx context.Context, employeeID uint) ([]model.ScheduleVersion, error) {
+	var versions []model.ScheduleVersion
+	err := orderVersions(repo.db.WithContext(ctx).Where("employee_id = ?", employeeID)).Find(&versions).Error
+	return versions, err
+}
+
+// ScheduleVersionCreate records version, the pattern of an employee up to its EffectiveUntil
+func (repo *repository) ScheduleVersionCreate(ctx context.Context, version *model.ScheduleVersion) error {
+	return repo.db.WithContext(ctx).Create(version).Error
+}
+
+// ReplaceSchedulesFrom records version, the pattern of an employee up to its EffectiveUntil, and replaces the
+// slots of weekType on dayName, empty for any, with schedules as ReplaceSchedules does, in a single transaction
+func (repo *repository) ReplaceSchedulesFrom(ctx context.Context, version *model.ScheduleVersion, weekType, dayName string, schedules []model.Schedule) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+		return (&repository{db: tx}).ReplaceSchedules(ctx, version.EmployeeID, weekType, dayName, schedules)
+	})
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Employee search
+//
+// The type-ahead pickers look employees up by a part of their name. On PostgreSQL, a trigram index on the
+// names serves both the substring matches and the fuzzy ones, which forgive a typo; SQLite has no trigrams
+// and matches substrings only
+
+// migrateEmployeeSearch creates the trigram index on the names of the employees, on PostgreSQL only
+func migrateEmployeeSearch(ctx context.Context, tx *repository) error {
+	db := tx.db.WithContext(ctx)
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return fmt.Errorf("failed to enable pg_trgm: %w", err)
+	}
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_employees_name_trgm ON employees USING gin (name gin_trgm_ops)").Error; err != nil {
+		return fmt.Errorf("failed to index the names of the employees: %w", err)
+	}
+	return nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, backslash being the escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchEmployees returns at most limit employees whose name matches query, the best matches first: the names
+// starting with query, then those containing it, then on PostgreSQL those similar to it, ties broken by
+// similarity then name. The case is ignored. Archived employees are left out
+func (r *repository) SearchEmployees(ctx context.Context, query string, limit int) ([]model.Employee, error) {
+	db := r.db.WithContext(ctx)
+	escaped := likeEscaper.Replace(query)
+	prefix, substring := escaped+"%", "%"+escaped+"%"
+	rank := `CASE WHEN LOWER(name) LIKE LOWER(?) ESCAPE '\' THEN 0 WHEN LOWER(name) LIKE LOWER(?) ESCAPE '\' THEN 1 ELSE 2 END`
+	if db.Dialector.Name() == "postgres" {
+		db = db.Where(`name ILIKE ? ESCAPE '\' OR name % ?`, substring, query).Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  rank + ", similarity(name, ?) DESC, name, id",
+			Vars: []interface{}{prefix, substring, query},
+		}})
+	} else {
+		db = db.Where(`LOWER(name) LIKE LOWER(?) ESCAPE '\'`, substring).Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  rank + ", name, id",
+			Vars: []interface{}{prefix, substring},
+		}})
+	}
+	var employees []model.Employee
+	err := db.Limit(limit).Find(&employees).Error
+	return employees, err
+}
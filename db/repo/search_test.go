@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSearchEmployees(t *testing.T) {
+	db, cleanup := setupMemoryDB(t)
+	defer cleanup()
+	repo := &repository{db: db}
+	ctx := context.Background()
+	start := time.Date(2024, time.June, 3, 0, 0, 0, 0, time.UTC)
+	var employees []*model.Employee
+	for _, name := range []string{"Jean Delmas", "delphine Roux", "Odile Martin", "Adel Said", "Del_Rio", "Delia Archived"} {
+		employees = append(employees, &model.Employee{Name: name, StartDate: start})
+	}
+	require.NoError(t, repo.LoadEmployees(ctx, employees))
+	require.NoError(t, repo.ArchiveEmployee(ctx, employees[5].ID))
+	names := func(query string, limit int) []string {
+		found, err := repo.SearchEmployees(ctx, query, limit)
+		require.NoError(t, err)
+		names := make([]string, 0, len(found))
+		for _, employee := range found {
+			names = append(names, employee.Name)
+		}
+		return names
+	}
+
+	// The names starting with the query come first, then those containing it, each by name; the case is ignored.
+	require.Equal(t, []string{"Del_Rio", "delphine Roux", "Adel Said", "Jean Delmas"}, names("DEL", 10))
+	require.Equal(t, []string{"Del_Rio", "delphine Roux"}, names("del", 2))
+	// The wildcards of LIKE are searched for as written.
+	require.Equal(t, []string{"Del_Rio"}, names("l_", 10))
+	require.Empty(t, names("%", 10))
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation on staffing requirements
+
+// migrateStaffingRequirements creates the table of the staffing requirements
+func migrateStaffingRequirements(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.StaffingRequirement{})
+}
+
+// StaffingRequirementCreate inserts a staffing requirement
+func (repo *repository) StaffingRequirementCreate(ctx context.Context, requirement *model.StaffingRequirement) error {
+	return repo.db.WithContext(ctx).Create(requirement).Error
+}
+
+// StaffingRequirementList retrieves every staffing requirement
+func (repo *repository) StaffingRequirementList(ctx context.Context) ([]model.StaffingRequirement, error) {
+	var requirements []model.StaffingRequirement
+	err := repo.db.WithContext(ctx).Order("id").Find(&requirements).Error
+	return requirements, err
+}
+
+// StaffingRequirementDelete removes a staffing requirement, returning gorm.ErrRecordNotFound if it does not exist
+func (repo *repository) StaffingRequirementDelete(ctx context.Context, id uint) error {
+	result := repo.db.WithContext(ctx).Delete(&model.StaffingRequirement{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
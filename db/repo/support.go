@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation for the support bundles
+
+// countedModels are the tables whose rows are counted in a support bundle
+var countedModels = []interface{}{&model.Tenant{}, &model.User{}, &model.Employee{}, &model.Schedule{}, &model.ScheduleDelta{}, &model.ScheduleVersion{},
+	&model.EmployeeHoliday{}, &model.ScheduleOverride{}, &model.ScheduleOverrideSlot{}, &model.TimeEntry{}, &model.Unavailability{}, &model.Holiday{},
+	&model.RotationPattern{}, &model.RotationWeek{}, &model.RotationCalendar{}, &model.RoleTemplate{}, &model.RoleTemplateSlot{},
+	&model.PairingRule{}, &model.StaffingRequirement{}, &model.ClosureDay{}, &model.DemandForecast{}, &model.DailyRevenue{}, &model.EmployeeImport{}, &model.EmployeeImportChange{}, &model.Webhook{},
+	&model.WebhookDelivery{}, &model.ValidationSettings{}, &model.CalendarLink{}, &model.PrintJob{}, &model.SchemaMigration{}}
+
+// TableRowCounts counts the rows of every table of every tenant, the soft-deleted ones included. A table
+// missing from the database is reported as such rather than failing the count
+func (repo *repository) TableRowCounts(ctx context.Context) ([]model.TableRowCount, error) {
+	if _, ok := TenantFromContext(ctx); ok {
+		return nil, ErrTenantScoped
+	}
+	db := repo.db.WithContext(ctx)
+	counts := make([]model.TableRowCount, 0, len(countedModels))
+	for _, m := range countedModels {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(m); err != nil {
+			return nil, err
+		}
+		count := model.TableRowCount{Table: statement.Table}
+		if !db.Migrator().HasTable(m) {
+			count.Missing = true
+		} else if err := db.Unscoped().Model(m).Count(&count.Rows).Error; err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
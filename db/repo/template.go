@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on role templates and schedule deltas
+
+// RoleTemplateCreate inserts a role template together with its slots
+func (repo *repository) RoleTemplateCreate(ctx context.Context, template *model.RoleTemplate) error {
+	return repo.db.WithContext(ctx).Create(template).Error
+}
+
+// RoleTemplateList retrieves every role template with its slots
+func (repo *repository) RoleTemplateList(ctx context.Context) ([]model.RoleTemplate, error) {
+	var templates []model.RoleTemplate
+	err := repo.db.WithContext(ctx).Preload("Slots").Order("name").Find(&templates).Error
+	return templates, err
+}
+
+// RoleTemplateFindByID retrieves a role template with its slots
+func (repo *repository) RoleTemplateFindByID(ctx context.Context, id uint) (*model.RoleTemplate, error) {
+	var template model.RoleTemplate
+	if err := repo.db.WithContext(ctx).Preload("Slots").First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// RoleTemplateFindByName retrieves a role template with its slots by its unique name
+func (repo *repository) RoleTemplateFindByName(ctx context.Context, name string) (*model.RoleTemplate, error) {
+	var template model.RoleTemplate
+	if err := repo.db.WithContext(ctx).Preload("Slots").Where("name = ?", name).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// RoleTemplateReplaceSlots atomically replaces all the slots of a role template
+func (repo *repository) RoleTemplateReplaceSlots(ctx context.Context, id uint, slots []model.RoleTemplateSlot) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_template_id = ?", id).Delete(&model.RoleTemplateSlot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.RoleTemplate{}).Where("id = ?", id).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if len(slots) == 0 {
+			return nil
+		}
+		for i := range slots {
+			slots[i].ID = 0
+			slots[i].RoleTemplateID = id
+			slots[i].CreatedAt, slots[i].UpdatedAt = time.Time{}, time.Time{}
+		}
+		return tx.Create(&slots).Error
+	})
+}
+
+// EmployeesByRoleTemplate retrieves the employees inheriting from a role template
+func (repo *repository) EmployeesByRoleTemplate(ctx context.Context, templateID uint) ([]model.Employee, error) {
+	var employees []model.Employee
+	err := repo.db.WithContext(ctx).Preload("Schedules").Preload("RoleTemplate.Slots").Preload("Deltas").
+		Where("role_template_id = ?", templateID).Find(&employees).Error
+	return employees, err
+}
+
+// SetEmployeeRoleTemplate links an employee to a role template, or unlinks it when templateID is nil
+func (repo *repository) SetEmployeeRoleTemplate(ctx context.Context, employeeID uint, templateID *uint) error {
+	result := repo.db.WithContext(ctx).Model(&model.Employee{}).Where("id = ?", employeeID).Update("role_template_id", templateID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DetachRoleTemplate turns the inherited slots of an employee into its own schedules, then drops its
+// deltas and its template link, in one transaction
+func (repo *repository) DetachRoleTemplate(ctx context.Context, employeeID uint, inherited []model.Schedule) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(inherited) > 0 {
+			for i := range inherited {
+				inherited[i].ID = 0
+				inherited[i].EmployeeID = employeeID
+			}
+			if err := tx.Create(&inherited).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("employee_id = ?", employeeID).Delete(&model.ScheduleDelta{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Employee{}).Where("id = ?", employeeID).Update("role_template_id", nil).Error
+	})
+}
+
+// DeltaCreate inserts a schedule delta
+func (repo *repository) DeltaCreate(ctx context.Context, delta *model.ScheduleDelta) error {
+	return repo.db.WithContext(ctx).Create(delta).Error
+}
+
+// DeltaListByEmployee retrieves the schedule deltas of an employee
+func (repo *repository) DeltaListByEmployee(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error) {
+	var deltas []model.ScheduleDelta
+	err := repo.db.WithContext(ctx).Where("employee_id = ?", employeeID).Order("id").Find(&deltas).Error
+	return deltas, err
+}
+
+// DeltaDelete removes a schedule delta of an employee
+func (repo *repository) DeltaDelete(ctx context.Context, employeeID, id uint) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("employee_id = ?", employeeID).Delete(&model.ScheduleDelta{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchEmployee(tx, employeeID)
+	})
+}
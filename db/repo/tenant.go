@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// Tenant isolation
+//
+// In a multi-tenant deployment the request context carries the tenant the request was resolved to, and the
+// callbacks registered by registerTenantScope confine every statement of a tenant-owned model to the rows of
+// that tenant: reads, updates and deletes are filtered on tenant_id, and inserted rows are stamped with it.
+// A context without a tenant, as in a single-tenant deployment, is not filtered at all.
+
+type tenantKey struct{}
+
+// ErrTenantScoped is returned by the operations spanning every tenant when called for a single one
+var ErrTenantScoped = errors.New("operation not allowed within a tenant")
+
+// tenantlessIndexes are the unique indexes of the tables created before tenants existed, replaced by indexes
+// unique within a tenant
+var tenantlessIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{&model.User{}, "idx_users_username"},
+	{&model.EmployeeImport{}, "idx_employee_imports_hash"},
+	{&model.DemandForecast{}, "idx_forecast_department_week"},
+	{&model.DailyRevenue{}, "idx_daily_revenues_date"},
+	{&model.RotationPattern{}, "idx_rotation_patterns_name"},
+	{&model.RoleTemplate{}, "idx_role_templates_name"},
+	{&model.TimeEntry{}, "idx_time_entry_nonce"},
+}
+
+// dropTenantlessIndexes drops the unique indexes that would keep two tenants from using the same name
+func (repo *repository) dropTenantlessIndexes(ctx context.Context) error {
+	migrator := repo.db.WithContext(ctx).Migrator()
+	for _, index := range tenantlessIndexes {
+		if !migrator.HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := migrator.DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenantScopeCallback names the callbacks registered on a gorm.DB, to register them only once.
+const tenantScopeCallback = "tenant:scope"
+
+// WithTenant returns a copy of ctx confining the repository to the rows of the tenant
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ctx is confined to, if any
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(uint)
+	return tenantID, ok
+}
+
+// registerTenantScope registers the tenant isolation callbacks on db, unless they already are
+func registerTenantScope(db *gorm.DB) {
+	if db.Callback().Query().Get(tenantScopeCallback) != nil {
+		return
+	}
+	callbacks := db.Callback()
+	errs := []error{
+		callbacks.Query().Before("gorm:query").Register(tenantScopeCallback, scopeTenant),
+		callbacks.Row().Before("gorm:row").Register(tenantScopeCallback, scopeTenant),
+		callbacks.Update().Before("gorm:update").Register(tenantScopeCallback, stampAndScopeTenant),
+		callbacks.Delete().Before("gorm:delete").Register(tenantScopeCallback, scopeTenant),
+		callbacks.Create().Before("gorm:create").Register(tenantScopeCallback, stampTenant),
+	}
+	if err := errors.Join(errs...); err != nil {
+		log.Errorf("failed to register the tenant scope: %v", err)
+	}
+}
+
+// tenantField returns the tenant of the statement and the TenantID field of its model, if both exist
+func tenantField(db *gorm.DB) (uint, *schema.Field, bool) {
+	if db.Statement.Context == nil || db.Statement.Schema == nil {
+		return 0, nil, false
+	}
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok {
+		return 0, nil, false
+	}
+	field := db.Statement.Schema.LookUpField("TenantID")
+	if field == nil {
+		return 0, nil, false
+	}
+	return tenantID, field, true
+}
+
+// scopeTenant restricts the statement to the rows of the tenant. The existing conditions are grouped first,
+// so that an OR among them cannot reach the rows of another tenant.
+func scopeTenant(db *gorm.DB) {
+	// Raw SQL is written with the tenant in mind, see PlannedHoursByWeekType.
+	if db.Error != nil || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	tenantID, field, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	condition := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID}
+	where := clause.Where{Exprs: []clause.Expression{condition}}
+	if existing, ok := db.Statement.Clauses["WHERE"]; ok {
+		if current, ok := existing.Expression.(clause.Where); ok && len(current.Exprs) > 0 {
+			where.Exprs = []clause.Expression{clause.And(current.Exprs...), condition}
+		}
+		existing.Expression = where
+		db.Statement.Clauses["WHERE"] = existing
+		return
+	}
+	db.Statement.AddClause(where)
+}
+
+// stampTenant sets the tenant of the rows about to be written
+func stampTenant(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	tenantID, field, ok := tenantField(db)
+	if !ok {
+		return
+	}
+	ctx := db.Statement.Context
+	value := reflect.Indirect(db.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == db.Statement.Schema.ModelType && value.CanAddr() {
+			db.AddError(field.Set(ctx, value, tenantID))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			row := reflect.Indirect(value.Index(i))
+			if row.Type() == db.Statement.Schema.ModelType && row.CanAddr() {
+				db.AddError(field.Set(ctx, row, tenantID))
+			}
+		}
+	}
+}
+
+// stampAndScopeTenant keeps a saved row in its tenant and restricts an update to the rows of the tenant
+func stampAndScopeTenant(db *gorm.DB) {
+	stampTenant(db)
+	scopeTenant(db)
+}
+
+// Operation on tenants
+
+// TenantCreate inserts a tenant and seeds its A/B rotation pattern
+func (repo *repository) TenantCreate(ctx context.Context, tenant *model.Tenant) error {
+	if err := repo.db.WithContext(ctx).Create(tenant).Error; err != nil {
+		return err
+	}
+	return repo.seedDefaultRotation(WithTenant(ctx, tenant.ID))
+}
+
+// TenantFindByAPIKeyHash retrieves the tenant whose API key has the given SHA-256 hash
+func (repo *repository) TenantFindByAPIKeyHash(ctx context.Context, hash string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := repo.db.WithContext(ctx).Where("api_key_hash = ?", hash).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// TenantFindBySubdomain retrieves the tenant served on the given subdomain
+func (repo *repository) TenantFindBySubdomain(ctx context.Context, subdomain string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := repo.db.WithContext(ctx).Where("subdomain = ?", subdomain).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"time"
+)
+
+// Operation on time entries
+
+// TimeEntryCreate inserts a time entry
+func (repo *repository) TimeEntryCreate(ctx context.Context, entry *model.TimeEntry) error {
+	return repo.db.WithContext(ctx).Create(entry).Error
+}
+
+// TimeEntryFindByNonce retrieves the time entry recorded by a device for a nonce
+func (repo *repository) TimeEntryFindByNonce(ctx context.Context, deviceID, nonce string) (*model.TimeEntry, error) {
+	var entry model.TimeEntry
+	if err := repo.db.WithContext(ctx).Where("device_id = ? AND nonce = ?", deviceID, nonce).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// TimeEntryLastSequence returns the highest sequence number recorded by a device, 0 if none
+func (repo *repository) TimeEntryLastSequence(ctx context.Context, deviceID string) (int64, error) {
+	var last int64
+	err := repo.db.WithContext(ctx).Model(&model.TimeEntry{}).Where("device_id = ?", deviceID).
+		Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error
+	return last, err
+}
+
+// TimeEntryFindNear retrieves the earliest punch of a kind of an employee from from to to included, returning
+// gorm.ErrRecordNotFound if there is none
+func (repo *repository) TimeEntryFindNear(ctx context.Context, employeeID uint, kind string, from, to time.Time) (*model.TimeEntry, error) {
+	var entry model.TimeEntry
+	err := repo.db.WithContext(ctx).Where("employee_id = ? AND kind = ? AND punched_at BETWEEN ? AND ?", employeeID, kind, from, to).
+		Order("punched_at").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// TimeEntryFindBetween retrieves the punches of an employee from from to to included, ordered by time
+func (repo *repository) TimeEntryFindBetween(ctx context.Context, employeeID uint, from, to time.Time) ([]model.TimeEntry, error) {
+	var entries []model.TimeEntry
+	err := repo.db.WithContext(ctx).Where("employee_id = ? AND punched_at BETWEEN ? AND ?", employeeID, from, to).
+		Order("punched_at").Find(&entries).Error
+	return entries, err
+}
+
+// TimeEntryListBetween retrieves the punches of every employee from from to to included, ordered by employee,
+// then by time
+func (repo *repository) TimeEntryListBetween(ctx context.Context, from, to time.Time) ([]model.TimeEntry, error) {
+	var entries []model.TimeEntry
+	err := repo.db.WithContext(ctx).Where("punched_at BETWEEN ? AND ?", from, to).
+		Order("employee_id, punched_at").Find(&entries).Error
+	return entries, err
+}
+
+// ClockedHours sums, for each of the employees, the hours clocked from each punch in to the punch out that
+// follows it, over the punches of the days from from to to included. The pairing and the sums are done by the
+// database in a single query; employees without a complete pair are left out
+func (repo *repository) ClockedHours(ctx context.Context, employeeIDs []uint, from, to time.Time) (map[uint]float64, error) {
+	db := repo.db.WithContext(ctx)
+	elapsed := secondsBetween(db, "punched_at", "next_punched_at") + " / 3600"
+	tenantID, scoped := TenantFromContext(ctx)
+	var rows []struct {
+		EmployeeID uint
+		Hours      float64
+	}
+	err := db.Raw(`
+		SELECT employee_id, SUM(`+elapsed+`) AS hours
+		FROM (
+			SELECT employee_id, kind, punched_at,
+				LEAD(kind) OVER (PARTITION BY employee_id ORDER BY punched_at) AS next_kind,
+				LEAD(punched_at) OVER (PARTITION BY employee_id ORDER BY punched_at) AS next_punched_at
+			FROM time_entries
+			WHERE employee_id IN ? AND punched_at >= ? AND punched_at < ? AND (NOT ? OR tenant_id = ?)
+		) AS punches
+		WHERE kind = ? AND next_kind = ?
+		GROUP BY employee_id`, employeeIDs, from, to.AddDate(0, 0, 1), scoped, tenantID, model.PunchIn, model.PunchOut).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	hours := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		hours[row.EmployeeID] = row.Hours
+	}
+	return hours, nil
+}
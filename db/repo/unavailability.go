@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on the unavailabilities of the employees
+
+// migrateUnavailabilities creates the table of the unavailabilities
+func migrateUnavailabilities(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.Unavailability{})
+}
+
+// UnavailabilityCreate inserts an unavailability
+func (repo *repository) UnavailabilityCreate(ctx context.Context, unavailability *model.Unavailability) error {
+	return repo.db.WithContext(ctx).Create(unavailability).Error
+}
+
+// UnavailabilityUpdate replaces an unavailability of its employee, returning gorm.ErrRecordNotFound if the
+// employee has no such unavailability
+func (repo *repository) UnavailabilityUpdate(ctx context.Context, unavailability *model.Unavailability) error {
+	unavailability.UpdatedAt = time.Now()
+	result := repo.db.WithContext(ctx).Model(&model.Unavailability{}).Where("id = ? AND employee_id = ?", unavailability.ID, unavailability.EmployeeID).
+		Select("day_name", "valid_from", "valid_to", "start_time", "end_time", "reason", "updated_at").Updates(unavailability)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UnavailabilityList retrieves the unavailabilities of every employee
+func (repo *repository) UnavailabilityList(ctx context.Context) ([]model.Unavailability, error) {
+	var unavailabilities []model.Unavailability
+	err := repo.db.WithContext(ctx).Order("employee_id, id").Find(&unavailabilities).Error
+	return unavailabilities, err
+}
+
+// UnavailabilityListByEmployee retrieves the unavailabilities of an employee
+func (repo *repository) UnavailabilityListByEmployee(ctx context.Context, employeeID uint) ([]model.Unavailability, error) {
+	var unavailabilities []model.Unavailability
+	err := repo.db.WithContext(ctx).Where("employee_id = ?", employeeID).Order("id").Find(&unavailabilities).Error
+	return unavailabilities, err
+}
+
+// UnavailabilityDelete removes an unavailability of an employee, returning gorm.ErrRecordNotFound if it does
+// not exist
+func (repo *repository) UnavailabilityDelete(ctx context.Context, employeeID, id uint) error {
+	result := repo.db.WithContext(ctx).Where("employee_id = ?", employeeID).Delete(&model.Unavailability{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
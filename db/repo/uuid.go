@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// backfillUUIDs gives a UUID to the rows created before resources had one
+func (r *repository) backfillUUIDs(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	for _, resource := range model.WithUUID {
+		var ids []uint
+		if err := db.Unscoped().Model(resource).Where("uuid IS NULL OR uuid = ''").Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to find the %T rows without UUID: %w", resource, err)
+		}
+		for _, id := range ids {
+			if err := db.Unscoped().Model(resource).Where("id = ?", id).UpdateColumn("uuid", model.NewUUID()).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IDByUUID returns the primary key of the row of the resource's table with the given UUID, archived rows
+// included, or gorm.ErrRecordNotFound
+func (r *repository) IDByUUID(ctx context.Context, resource interface{}, uuid string) (uint, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Unscoped().Model(resource).Where("uuid = ?", uuid).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ids[0], nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+)
+
+// Operation on the validation settings
+
+// migrateValidationSettings creates the table of the validation settings
+func migrateValidationSettings(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.ValidationSettings{})
+}
+
+// ValidationSettingsGet retrieves the validation settings of the tenant
+func (repo *repository) ValidationSettingsGet(ctx context.Context) (*model.ValidationSettings, error) {
+	var settings model.ValidationSettings
+	if err := repo.db.WithContext(ctx).First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ValidationSettingsSet replaces the validation settings of the tenant, creating them if needed
+func (repo *repository) ValidationSettingsSet(ctx context.Context, settings *model.ValidationSettings) error {
+	existing, err := repo.ValidationSettingsGet(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		settings.ID = 0
+		return repo.db.WithContext(ctx).Create(settings).Error
+	}
+	if err != nil {
+		return err
+	}
+	settings.ID, settings.TenantID = existing.ID, existing.TenantID
+	return repo.db.WithContext(ctx).Save(settings).Error
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Operation on webhooks
+
+// WebhookCreate inserts a webhook
+func (repo *repository) WebhookCreate(ctx context.Context, hook *model.Webhook) error {
+	return repo.db.WithContext(ctx).Create(hook).Error
+}
+
+// WebhookList retrieves every webhook
+func (repo *repository) WebhookList(ctx context.Context) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := repo.db.WithContext(ctx).Order("id").Find(&hooks).Error
+	return hooks, err
+}
+
+// WebhookFindByID retrieves a webhook
+func (repo *repository) WebhookFindByID(ctx context.Context, id uint) (*model.Webhook, error) {
+	var hook model.Webhook
+	if err := repo.db.WithContext(ctx).First(&hook, id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// WebhookUpdate replaces the name, URL, format, template and events of a webhook, returning
+// gorm.ErrRecordNotFound if it does not exist
+func (repo *repository) WebhookUpdate(ctx context.Context, hook *model.Webhook) error {
+	hook.UpdatedAt = time.Now()
+	result := repo.db.WithContext(ctx).Model(&model.Webhook{}).Where("id = ?", hook.ID).
+		Select("name", "url", "format", "template", "events", "updated_at").Updates(hook)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// WebhookSetSecret replaces the secret signing the deliveries of a webhook, returning gorm.ErrRecordNotFound
+// if it does not exist
+func (repo *repository) WebhookSetSecret(ctx context.Context, id uint, secret string) error {
+	result := repo.db.WithContext(ctx).Model(&model.Webhook{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"secret": secret, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// WebhookDelete removes a webhook with its deliveries, returning gorm.ErrRecordNotFound if it does not exist
+func (repo *repository) WebhookDelete(ctx context.Context, id uint) error {
+	return repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error
+	})
+}
+
+// Operation on webhook deliveries
+
+// migrateWebhookDeliveries adds the events and secret of the webhooks and creates the queue of their deliveries
+func migrateWebhookDeliveries(ctx context.Context, tx *repository) error {
+	return tx.db.WithContext(ctx).AutoMigrate(&model.Webhook{}, &model.WebhookDelivery{})
+}
+
+// WebhookDeliveryCreate queues deliveries
+func (repo *repository) WebhookDeliveryCreate(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	return repo.db.WithContext(ctx).CreateInBatches(deliveries, insertBatchSize).Error
+}
+
+// WebhookDeliveryList retrieves the last deliveries of a webhook, newest first
+func (repo *repository) WebhookDeliveryList(ctx context.Context, webhookID uint, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := repo.db.WithContext(ctx).Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// WebhookDeliveryClaim marks the oldest delivery due at now sending and returns it, or nil if there is none.
+// A delivery still sending since before staleBefore is taken to be abandoned by a worker that stopped, and
+// claimed again. As for print jobs, a delivery is only claimed by one of the workers claiming concurrently
+func (repo *repository) WebhookDeliveryClaim(ctx context.Context, now, staleBefore time.Time) (*model.WebhookDelivery, error) {
+	db := repo.db.WithContext(ctx)
+	claimable := db.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+		model.DeliveryPending, now, model.DeliverySending, staleBefore)
+	for {
+		var delivery model.WebhookDelivery
+		if err := db.Where(claimable).Order("next_attempt_at, id").Limit(1).Find(&delivery).Error; err != nil || delivery.ID == 0 {
+			return nil, err
+		}
+		result := db.Model(&model.WebhookDelivery{}).Where("id = ?", delivery.ID).Where(claimable).
+			Updates(map[string]interface{}{"status": model.DeliverySending, "updated_at": now})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.Status, delivery.UpdatedAt = model.DeliverySending, now
+			return &delivery, nil
+		}
+		// Another worker claimed it first.
+	}
+}
+
+// WebhookDeliverySave updates a delivery
+func (repo *repository) WebhookDeliverySave(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return repo.db.WithContext(ctx).Save(delivery).Error
+}
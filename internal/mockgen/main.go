@@ -0,0 +1,174 @@
+// Command mockgen writes a test double of an interface of the package in the current directory: a struct
+// with a function field per method, <Method>Func, which the method calls. A method whose function is not
+// set panics, so that a test notices the calls it did not expect. It is run by go generate:
+//
+//	//go:generate go run ../../../internal/mockgen -interface EmployeeAPI -out employee_api_mock.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	name := flag.String("interface", "", "the interface to mock")
+	out := flag.String("out", "", "the file to write")
+	flag.Parse()
+	if *name == "" || *out == "" {
+		log.Fatal("usage: mockgen -interface Name -out file.go")
+	}
+	mock, err := generate(".", *name, *out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, mock, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of the mock of the interface name of the package in dir, leaving out the
+// previous mock, out.
+func generate(dir, name, out string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != out
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	for pkgName, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			if iface := findInterface(file, name); iface != nil {
+				return render(fset, pkgName, file, name, iface)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface %s in %s", name, dir)
+}
+
+func findInterface(file *ast.File, name string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				if iface, ok := ts.Type.(*ast.InterfaceType); ok {
+					return iface
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// method is a method of the interface, its parameters named.
+type method struct {
+	name     string
+	params   []string // "name type"
+	args     []string // the parameters as passed on, the variadic one spread
+	results  []string
+	variadic bool
+}
+
+func render(fset *token.FileSet, pkgName string, file *ast.File, name string, iface *ast.InterfaceType) ([]byte, error) {
+	var methods []method
+	used := map[string]bool{}
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s embeds an interface, which is not supported", name)
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if pkg, ok := sel.X.(*ast.Ident); ok {
+					used[pkg.Name] = true
+				}
+			}
+			return true
+		})
+		m := method{name: field.Names[0].Name}
+		for i, param := range fn.Params.List {
+			typ := source(fset, param.Type)
+			names := param.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent("p" + strconv.Itoa(i))}
+			}
+			for _, n := range names {
+				m.params = append(m.params, n.Name+" "+typ)
+				m.args = append(m.args, n.Name)
+			}
+			if _, ok := param.Type.(*ast.Ellipsis); ok {
+				m.args[len(m.args)-1] += "..."
+			}
+		}
+		if fn.Results != nil {
+			for _, result := range fn.Results.List {
+				for range max(1, len(result.Names)) {
+					m.results = append(m.results, source(fset, result.Type))
+				}
+			}
+		}
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+	var buf bytes.Buffer
+	mock := name + "Mock"
+	fmt.Fprintf(&buf, "// Code generated by internal/mockgen from %s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", name, pkgName)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		local := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		if used[local] {
+			fmt.Fprintf(&buf, "\t%s\n", source(fset, spec))
+		}
+	}
+	fmt.Fprintf(&buf, ")\n\n// %s implements %s with the functions of its fields, which panic when not set.\n", mock, name)
+	fmt.Fprintf(&buf, "type %s struct {\n", mock)
+	for _, m := range methods {
+		fmt.Fprintf(&buf, "\t%sFunc func(%s)%s\n", m.name, strings.Join(m.params, ", "), results(m.results))
+	}
+	buf.WriteString("}\n")
+	for _, m := range methods {
+		fmt.Fprintf(&buf, "\nfunc (m *%s) %s(%s)%s {\n", mock, m.name, strings.Join(m.params, ", "), results(m.results))
+		fmt.Fprintf(&buf, "\tif m.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", m.name, mock+"."+m.name+"Func is not set")
+		call := fmt.Sprintf("m.%sFunc(%s)", m.name, strings.Join(m.args, ", "))
+		if len(m.results) > 0 {
+			call = "return " + call
+		}
+		fmt.Fprintf(&buf, "\t%s\n}\n", call)
+	}
+	return format.Source(buf.Bytes())
+}
+
+func results(types []string) string {
+	switch len(types) {
+	case 0:
+		return ""
+	case 1:
+		return " " + types[0]
+	default:
+		return " (" + strings.Join(types, ", ") + ")"
+	}
+}
+
+func source(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The mocks must follow their interface; run go generate on their package when this fails.
+func TestMocksAreUpToDate(t *testing.T) {
+	for _, mock := range []struct{ dir, iface, out string }{
+		{"../../pkg/api/service", "EmployeeAPI", "employee_api_mock.go"},
+		{"../../db/repo", "Repository", "repository_mock.go"},
+	} {
+		want, err := generate(mock.dir, mock.iface, mock.out)
+		require.NoError(t, err)
+		got, err := os.ReadFile(mock.dir + "/" + mock.out)
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), "%s is out of date", mock.out)
+	}
+}
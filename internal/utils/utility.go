@@ -9,40 +9,48 @@ import (
 	"github.com/lichensio/api_server/db/model"
 	"log"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// monthStringToNumber converts month name to its numerical representation.
+// monthStringToNumber converts a month accepted by ParseMonth to its numerical representation, or 0 if
+// the month is invalid.
 func MonthStringToNumber(month string) int {
-	date, err := time.Parse("January", month)
+	_, m, err := ParseMonth(month)
 	if err != nil {
 		log.Printf("Error converting month to number: %v", err)
-		return 1 // default to January on error
+		return 0
 	}
-	return int(date.Month())
+	return int(m)
 }
 
-// weekTypeForDate calculates whether the given date falls on Week A or Week B based on the employee's start date.
-func WeekTypeForDate(startDate, currentDate time.Time) string {
-	_, startWeek := startDate.ISOWeek()
-	_, currentWeek := currentDate.ISOWeek()
-
-	// Calculate the difference in weeks
-	weeksSinceStart := currentWeek - startWeek
-
-	// If the difference is negative, it means the currentDate is in a new year
-	// Adjust weeksSinceStart accordingly by adding the number of weeks in a year
-	// This simple adjustment assumes the dates are within a year of each other
-	// For handling dates spanning multiple years, further adjustments are needed
-	if weeksSinceStart < 0 {
-		weeksSinceStart += 52 // Or 53, depending on the year
+// WeekOfCycle returns the position, from 0 to cycleLength-1, of the week of currentDate in a rotation of
+// cycleLength weeks whose first week is the week (Monday to Sunday) of anchor. It counts the whole days
+// between the Mondays of both weeks, so that it holds across year boundaries, 53-week years and spans of any
+// length. Dates before the anchor are counted backwards, so that the cycle is the same on both sides.
+func WeekOfCycle(anchor, currentDate time.Time, cycleLength int) int {
+	if cycleLength < 1 {
+		return 0
 	}
+	// Both Mondays are UTC midnights, so the difference is a whole number of weeks.
+	weeks := int(MondayOf(currentDate).Sub(MondayOf(anchor)).Hours()/24) / 7
+	position := weeks % cycleLength
+	if position < 0 {
+		position += cycleLength
+	}
+	return position
+}
 
-	// Determine the week type based on the difference
-	if weeksSinceStart%2 == 0 {
-		return "A"
+// WeekTypeForDate returns the name of the week of rotation that currentDate falls in for an employee whose
+// rotation is anchored on anchor, see model.Employee.RotationStart. A nil rotation is the A/B rotation. Both
+// dates are read in their own location: a date in the time zone of a shop is the day it is there, however
+// long the days of a daylight saving time change.
+func WeekTypeForDate(rotation *model.RotationPattern, anchor, currentDate time.Time) string {
+	if rotation == nil || len(rotation.Weeks) == 0 {
+		rotation = &model.DefaultRotation
 	}
-	return "B"
+	return rotation.Weeks[WeekOfCycle(anchor, currentDate, len(rotation.Weeks))].Name
 }
 
 // FormatSQLTime takes a SQL time string (in "15:04:05" format) and formats it to "HH:MM".
@@ -73,7 +81,7 @@ func CountSchedules(employees model.EmployeesInput) map[string]int {
 	return counts
 }
 
-// hashJSON returns a SHA-256 hash of the JSON object represented by jsonString.
+// HashJSON returns a SHA-256 hash of the JSON object represented by jsonString.
 // It normalizes the JSON object to ensure consistent hashing.
 func HashJSON(jsonString string) (string, error) {
 	var object interface{}
@@ -184,4 +192,72 @@ func CalculateHours(start, end string) (float64, error) {
 	return duration.Hours(), nil
 }
 
+// CalculateHoursIn returns the hours elapsed from start to end (HH:MM) on date in loc, an end before the
+// start falling on the next day. They are those of CalculateHours but on the days of a daylight saving time
+// change: from 01:00 to 04:00 lasts 2 hours when the clocks go forward, and 4 when they go back.
+func CalculateHoursIn(loc *time.Location, date time.Time, start, end string) (float64, error) {
+	startClock, err := time.Parse("15:04", start)
+	if err != nil {
+		return 0, err
+	}
+	endClock, err := time.Parse("15:04", end)
+	if err != nil {
+		return 0, err
+	}
+	at := func(clock time.Time, days int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day()+days, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+	from, until := at(startClock, 0), at(endClock, 0)
+	if endClock.Before(startClock) {
+		until = at(endClock, 1)
+	}
+	return until.Sub(from).Hours(), nil
+}
+
+// SlotsOverlap reports whether the half-open time ranges [aStart, aEnd) and [bStart, bEnd) intersect.
+func SlotsOverlap(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// ParseQuarter parses a quarter written as "2024-Q2" and returns the year and the quarter number (1-4).
+func ParseQuarter(value string) (int, int, error) {
+	var year, quarter int
+	if _, err := fmt.Sscanf(value, "%d-Q%d", &year, &quarter); err != nil || quarter < 1 || quarter > 4 {
+		return 0, 0, fmt.Errorf("invalid quarter %q, expected YYYY-Qn", value)
+	}
+	return year, quarter, nil
+}
+
+// MondayOf returns midnight UTC of the Monday of the week containing date.
+func MondayOf(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthFormats describes the month notations accepted by ParseMonth, for error messages.
+const MonthFormats = "a month name (March), a number from 1 to 12 (3) or YYYY-MM (2024-03)"
+
+// ParseMonth parses a month written as a name ("March", case-insensitive), a number ("3" or "03") or a
+// year and month ("2024-03"). The returned year is 0 unless the value includes one.
+func ParseMonth(value string) (int, time.Month, error) {
+	value = strings.TrimSpace(value)
+	invalid := fmt.Errorf("invalid month %q, expected %s", value, MonthFormats)
+
+	if number, err := strconv.Atoi(value); err == nil {
+		if number < 1 || number > 12 {
+			return 0, 0, invalid
+		}
+		return 0, time.Month(number), nil
+	}
+	if date, err := time.Parse("2006-01", value); err == nil {
+		return date.Year(), date.Month(), nil
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.EqualFold(value, m.String()) {
+			return 0, m, nil
+		}
+	}
+	return 0, 0, invalid
+}
+
 // Other utility functions...
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMonth(t *testing.T) {
+	valid := []struct {
+		value string
+		year  int
+		month time.Month
+	}{
+		{"March", 0, time.March},
+		{"march", 0, time.March},
+		{"3", 0, time.March},
+		{"03", 0, time.March},
+		{"12", 0, time.December},
+		{"2024-03", 2024, time.March},
+	}
+	for _, c := range valid {
+		year, month, err := ParseMonth(c.value)
+		require.NoError(t, err, c.value)
+		assert.Equal(t, c.year, year, c.value)
+		assert.Equal(t, c.month, month, c.value)
+	}
+
+	for _, value := range []string{"", "0", "13", "-1", "Mars", "2024-13", "2024-3-1"} {
+		_, _, err := ParseMonth(value)
+		assert.Error(t, err, value)
+	}
+	assert.Equal(t, 0, MonthStringToNumber("Mars"), "Invalid months must not default to January")
+}
+
+func TestWeekTypeForDate(t *testing.T) {
+	start := time.Date(2024, time.February, 24, 0, 0, 0, 0, time.UTC) // a Saturday
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, "A", WeekTypeForDate(nil, start, day(2024, time.February, 19)), "The start week is week A from its Monday")
+	assert.Equal(t, "B", WeekTypeForDate(nil, start, day(2024, time.February, 26)))
+	assert.Equal(t, "A", WeekTypeForDate(nil, start, day(2024, time.March, 4)))
+	assert.Equal(t, "B", WeekTypeForDate(nil, start, day(2024, time.February, 18)), "Weeks before the start alternate too")
+	// 2026 has 53 ISO weeks: the cycle carries over the new year.
+	assert.Equal(t, "A", WeekTypeForDate(nil, day(2026, time.December, 28), day(2027, time.January, 11)))
+	assert.Equal(t, "B", WeekTypeForDate(nil, day(2020, time.December, 28), day(2021, time.January, 4)), "ISO week 53 is followed by week 1")
+	assert.Equal(t, "B", WeekTypeForDate(nil, day(2019, time.January, 7), day(2024, time.January, 8)), "261 weeks apart")
+	for d := day(2020, time.December, 30); d.Before(day(2021, time.January, 4)); d = d.AddDate(0, 0, 1) {
+		assert.Equal(t, "A", WeekTypeForDate(nil, day(2020, time.December, 28), d), "Week 53 does not switch on January 1: %s", d)
+	}
+
+	rotation := &model.RotationPattern{CycleLength: 3, Weeks: []model.RotationWeek{{Name: "early"}, {Name: "late"}, {Name: "off"}}}
+	for i, want := range []string{"early", "late", "off", "early"} {
+		assert.Equal(t, want, WeekTypeForDate(rotation, start, start.AddDate(0, 0, 7*i)), i)
+	}
+	assert.Equal(t, "off", WeekTypeForDate(rotation, start, start.AddDate(0, 0, -7)))
+	assert.Equal(t, 2, WeekOfCycle(start, day(2024, time.March, 4), 4))
+}
+
+func TestWeekTypeForDateAcrossDST(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	start := time.Date(2024, time.March, 25, 0, 0, 0, 0, paris) // the Monday before the clocks go forward
+
+	// The week of the change lasts 167 hours, that of the change back 169: the weeks still switch on Mondays.
+	assert.Equal(t, "A", WeekTypeForDate(nil, start, time.Date(2024, time.March, 31, 23, 30, 0, 0, paris)))
+	assert.Equal(t, "B", WeekTypeForDate(nil, start, time.Date(2024, time.April, 1, 0, 0, 0, 0, paris)))
+	assert.Equal(t, "A", WeekTypeForDate(nil, start, time.Date(2024, time.October, 27, 23, 59, 0, 0, paris)), "31 weeks later")
+	assert.Equal(t, "B", WeekTypeForDate(nil, start, time.Date(2024, time.October, 28, 0, 0, 0, 0, paris)))
+	// Monday 01:00 in Paris is still Sunday in UTC: the date is read where it is taken.
+	monday := time.Date(2024, time.April, 1, 1, 0, 0, 0, paris)
+	assert.Equal(t, "B", WeekTypeForDate(nil, start, monday))
+	assert.Equal(t, "A", WeekTypeForDate(nil, start, monday.UTC()))
+}
+
+func TestCalculateHoursIn(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	for _, c := range []struct {
+		date       time.Time
+		start, end string
+		hours      float64
+	}{
+		{time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC), "01:00", "04:00", 3},
+		{time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), "01:00", "04:00", 2},
+		{time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC), "22:00", "06:00", 7},
+		{time.Date(2024, time.October, 27, 0, 0, 0, 0, time.UTC), "01:00", "04:00", 4},
+		{time.Date(2024, time.October, 26, 0, 0, 0, 0, time.UTC), "22:00", "06:00", 9},
+		{time.Date(2024, time.October, 27, 0, 0, 0, 0, time.UTC), "09:00", "17:30", 8.5},
+	} {
+		hours, err := CalculateHoursIn(paris, c.date, c.start, c.end)
+		require.NoError(t, err)
+		assert.Equal(t, c.hours, hours, "%s %s-%s", c.date.Format("2006-01-02"), c.start, c.end)
+		wallClock, err := CalculateHours(c.start, c.end)
+		require.NoError(t, err)
+		hours, err = CalculateHoursIn(time.UTC, c.date, c.start, c.end)
+		require.NoError(t, err)
+		assert.Equal(t, wallClock, hours, "UTC has no daylight saving time")
+	}
+	_, err = CalculateHoursIn(paris, time.Now(), "25:00", "04:00")
+	assert.Error(t, err)
+}
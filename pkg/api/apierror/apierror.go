@@ -0,0 +1,200 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/middleware"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// Kind classifies an error and determines its HTTP status.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindUnavailable
+	KindTimeout
+	// KindUnprocessable is well-formed input whose fields break their rules, rejected before the service runs.
+	KindUnprocessable
+)
+
+// Error is an error carrying the information needed to build a problem+json response.
+type Error struct {
+	Kind Kind
+	// Code identifies the error for clients; when empty, the default code of Kind is used.
+	Code   Code
+	Detail string
+	// Err is the underlying cause, logged but never shown to clients of internal errors.
+	Err error
+	// Params lists the individual fields rejected by a validation error.
+	Params []InvalidParam
+}
+
+// InvalidParam names one rejected input field and the reason it was rejected.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Code   Code   `json:"code,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Detail
+	case e.Detail == "":
+		return e.Err.Error()
+	default:
+		return e.Detail + ": " + e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithCode sets the code of the error and returns it.
+func (e *Error) WithCode(code Code) *Error {
+	e.Code = code
+	return e
+}
+
+// ErrorCode returns the code of the error, falling back to the default code of its kind.
+func (e *Error) ErrorCode() Code {
+	if e.Code != "" {
+		return e.Code
+	}
+	return e.Kind.defaultCode()
+}
+
+// CodeOf returns the code of err, or CodeInternal if err is not an *Error.
+func CodeOf(err error) Code {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return CodeInternal
+}
+
+// Status returns the HTTP status code associated with the error kind.
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Validation reports input rejected before reaching the database.
+func Validation(detail string) *Error {
+	return &Error{Kind: KindValidation, Detail: detail}
+}
+
+// InvalidParams reports input rejected for several reasons at once, one entry per offending field.
+func InvalidParams(detail string, params []InvalidParam) *Error {
+	return &Error{Kind: KindValidation, Code: CodeImportInvalid, Detail: detail, Params: params}
+}
+
+// Unprocessable reports the fields of well-formed input that break their rules, one entry per field.
+func Unprocessable(detail string, params []InvalidParam) *Error {
+	return &Error{Kind: KindUnprocessable, Detail: detail, Params: params}
+}
+
+// NotFound reports a missing resource.
+func NotFound(detail string) *Error {
+	return &Error{Kind: KindNotFound, Detail: detail}
+}
+
+// Conflict reports a request clashing with the current state of a resource.
+func Conflict(detail string) *Error {
+	return &Error{Kind: KindConflict, Detail: detail}
+}
+
+// Unauthorized reports missing or invalid credentials.
+func Unauthorized(detail string) *Error {
+	return &Error{Kind: KindUnauthorized, Detail: detail}
+}
+
+// Unavailable reports a dependency that cannot be reached; err is the underlying cause.
+func Unavailable(detail string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Detail: detail, Err: err}
+}
+
+// Timeout reports a request that ran out of its time budget; err is the underlying cause.
+func Timeout(detail string, err error) *Error {
+	return &Error{Kind: KindTimeout, Detail: detail, Err: err}
+}
+
+// Internal wraps an unexpected failure.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Err: err}
+}
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Code      Code   `json:"code"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	// InvalidParams is the RFC 7807 "invalid-params" extension member.
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+// Write sends err as an application/problem+json response. Errors that are not an *Error are treated
+// as internal errors, whose details are logged but not returned, except the expired deadlines of the request
+// context, sent as timeouts.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			apiErr = Timeout("the request ran out of time", err)
+		} else {
+			apiErr = Internal(err)
+		}
+	}
+
+	requestID := middleware.GetReqID(r.Context())
+	status := apiErr.Status()
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Code:      apiErr.ErrorCode(),
+		Instance:  r.URL.Path,
+		RequestID: requestID,
+	}
+	if apiErr.Kind == KindInternal {
+		reqlog.From(r.Context()).Errorf("Internal error on %s %s: %v", r.Method, r.URL.Path, err)
+	} else {
+		problem.Detail = apiErr.Detail
+		problem.InvalidParams = apiErr.Params
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("Failed to encode problem response: %v", err)
+	}
+}
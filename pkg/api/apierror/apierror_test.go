@@ -0,0 +1,79 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteProblem(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		detail string
+	}{
+		{Validation("weekType must be either 'A' or 'B'"), http.StatusBadRequest, "weekType must be either 'A' or 'B'"},
+		{fmt.Errorf("loading: %w", NotFound("employee 3 not found")), http.StatusNotFound, "employee 3 not found"},
+		{Conflict("already imported"), http.StatusConflict, "already imported"},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError, ""},
+		{fmt.Errorf("loading: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "the request ran out of time"},
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		Write(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil), c.err)
+
+		assert.Equal(t, c.status, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, c.status, problem.Status)
+		assert.Equal(t, http.StatusText(c.status), problem.Title)
+		assert.Equal(t, c.detail, problem.Detail, "Internal details must not leak to clients")
+		assert.Equal(t, "/prox/api/getEmployees", problem.Instance)
+	}
+}
+
+func TestWriteInvalidParams(t *testing.T) {
+	params := []InvalidParam{{Name: "Alice.A.Thursday[0].start", Reason: "invalid time 25:00"}}
+	rec := httptest.NewRecorder()
+	Write(rec, httptest.NewRequest(http.MethodPost, "/prox/api/loadEmployees", nil), InvalidParams("1 invalid field", params))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, params, problem.InvalidParams)
+}
+
+func TestProblemCodes(t *testing.T) {
+	cases := []struct {
+		err  error
+		code Code
+	}{
+		{NotFound("employee 3 not found").WithCode(CodeEmployeeNotFound), CodeEmployeeNotFound},
+		{NotFound("no such route"), CodeNotFound},
+		{InvalidParams("1 invalid field", nil), CodeImportInvalid},
+		{Unavailable("holiday provider down", errors.New("dial tcp: timeout")).WithCode(CodeHolidayProviderDown), CodeHolidayProviderDown},
+		{errors.New("pq: connection refused"), CodeInternal},
+		{context.DeadlineExceeded, CodeTimeout},
+	}
+	documented := make(map[Code]bool)
+	for _, entry := range Catalog() {
+		documented[entry.Code] = true
+	}
+
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		Write(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil), c.err)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, c.code, problem.Code)
+		assert.True(t, documented[problem.Code], "%s must be documented in the catalog", problem.Code)
+	}
+}
@@ -0,0 +1,174 @@
+package apierror
+
+import (
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should map codes to their own (localized)
+// messages rather than match the English detail, which may change.
+type Code string
+
+const (
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodeInvalidJSON          Code = "INVALID_JSON"
+	CodeImportInvalid        Code = "IMPORT_INVALID"
+	CodeInputInvalid         Code = "INPUT_INVALID"
+	CodeFieldRequired        Code = "FIELD_REQUIRED"
+	CodeWeekTypeInvalid      Code = "WEEK_TYPE_INVALID"
+	CodeDayNameInvalid       Code = "DAY_NAME_INVALID"
+	CodeTimeFormatInvalid    Code = "TIME_FORMAT_INVALID"
+	CodeTimeRangeInvalid     Code = "TIME_RANGE_INVALID"
+	CodeDateInvalid          Code = "DATE_INVALID"
+	CodeMonthInvalid         Code = "MONTH_INVALID"
+	CodeYearInvalid          Code = "YEAR_INVALID"
+	CodeQuarterInvalid       Code = "QUARTER_INVALID"
+	CodeRegionInvalid        Code = "REGION_INVALID"
+	CodeConfirmationInvalid  Code = "CONFIRMATION_INVALID"
+	CodeScheduleRuleViolated Code = "SCHEDULE_RULE_VIOLATED"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeEmployeeNotFound     Code = "EMP_NOT_FOUND"
+	CodeScheduleNotFound     Code = "SCHEDULE_NOT_FOUND"
+	CodeRoleTemplateNotFound Code = "ROLE_TEMPLATE_NOT_FOUND"
+	CodeDeltaNotFound        Code = "DELTA_NOT_FOUND"
+	CodePairingRuleNotFound  Code = "PAIRING_RULE_NOT_FOUND"
+	CodeStaffingNotFound     Code = "STAFFING_REQUIREMENT_NOT_FOUND"
+	CodeLeaveNotFound        Code = "LEAVE_NOT_FOUND"
+	CodeOverrideNotFound     Code = "OVERRIDE_NOT_FOUND"
+	CodeAvailabilityNotFound Code = "AVAILABILITY_NOT_FOUND"
+	CodeClosureNotFound      Code = "CLOSURE_NOT_FOUND"
+	CodeRotationNotFound     Code = "ROTATION_NOT_FOUND"
+	CodeWebhookNotFound      Code = "WEBHOOK_NOT_FOUND"
+	CodeTenantNotFound       Code = "TENANT_NOT_FOUND"
+	CodePrintJobNotFound     Code = "PRINT_JOB_NOT_FOUND"
+	CodeCalendarNotLinked    Code = "CALENDAR_NOT_LINKED"
+	CodeImportNotFound       Code = "IMPORT_NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodeScheduleOverlap      Code = "SCHEDULE_OVERLAP"
+	CodeScheduleVersionOrder Code = "SCHEDULE_VERSION_ORDER"
+	CodeImportSuperseded     Code = "IMPORT_SUPERSEDED"
+	CodeLeaveExists          Code = "LEAVE_EXISTS"
+	CodeLeaveDecided         Code = "LEAVE_DECIDED"
+	CodeRotationExists       Code = "ROTATION_EXISTS"
+	CodeClosureExists        Code = "CLOSURE_EXISTS"
+	CodeNonceReused          Code = "NONCE_REUSED"
+	CodePunchReplayed        Code = "PUNCH_REPLAYED"
+	CodeUnauthorized         Code = "UNAUTHORIZED"
+	CodeInvalidCredentials   Code = "INVALID_CREDENTIALS"
+	CodeInvalidAPIKey        Code = "INVALID_API_KEY"
+	CodeDownloadLinkInvalid  Code = "DOWNLOAD_LINK_INVALID"
+	CodeUnavailable          Code = "SERVICE_UNAVAILABLE"
+	CodeHolidayProviderDown  Code = "HOLIDAY_PROVIDER_DOWN"
+	CodeValidatorUnavailable Code = "VALIDATOR_UNAVAILABLE"
+	CodeCalendarIncomplete   Code = "CALENDAR_INCOMPLETE"
+	CodeCalendarSyncDisabled Code = "CALENDAR_SYNC_DISABLED"
+	CodeGoogleCalendarDown   Code = "GOOGLE_CALENDAR_DOWN"
+	CodeServerBusy           Code = "SERVER_BUSY"
+	CodeTimeout              Code = "TIMEOUT"
+	CodeReadOnly             Code = "READ_ONLY"
+	CodeInternal             Code = "INTERNAL_ERROR"
+)
+
+// CatalogEntry documents one error code.
+type CatalogEntry struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+var catalog = []CatalogEntry{
+	{CodeValidationFailed, http.StatusBadRequest, "The request was rejected by validation; see detail."},
+	{CodeInvalidJSON, http.StatusBadRequest, "The request body is not valid JSON for this endpoint."},
+	{CodeImportInvalid, http.StatusBadRequest, "The employee import was rejected; invalidParams lists every offending field and nothing was saved."},
+	{CodeInputInvalid, http.StatusUnprocessableEntity, "The body is well-formed JSON but breaks the rules of its fields; invalidParams lists every offending field."},
+	{CodeFieldRequired, http.StatusUnprocessableEntity, "A required field of the body is missing or blank."},
+	{CodeWeekTypeInvalid, http.StatusBadRequest, "The week type is not a week of the employee's rotation (A or B unless the employee follows another rotation pattern)."},
+	{CodeDayNameInvalid, http.StatusBadRequest, "The day name is not an English weekday (Monday to Sunday)."},
+	{CodeTimeFormatInvalid, http.StatusBadRequest, "A time is not written as HH:MM."},
+	{CodeTimeRangeInvalid, http.StatusBadRequest, "A slot has zero length, or a time range of a day does not start before it ends."},
+	{CodeDateInvalid, http.StatusBadRequest, "A date or timestamp is malformed."},
+	{CodeMonthInvalid, http.StatusBadRequest, "The month is missing or not a month name, a number from 1 to 12 or YYYY-MM."},
+	{CodeYearInvalid, http.StatusBadRequest, "The year is missing or malformed."},
+	{CodeQuarterInvalid, http.StatusBadRequest, "The quarter is not written as YYYY-Qn with n from 1 to 4."},
+	{CodeRegionInvalid, http.StatusBadRequest, "The holiday region is not metropole, alsace-moselle or an overseas region of the public holiday API."},
+	{CodeConfirmationInvalid, http.StatusBadRequest, "The confirmation token of a destructive operation is wrong or expired; send the request without it for a fresh one."},
+	{CodeScheduleRuleViolated, http.StatusBadRequest, "The schedules break a rule of the validators of the tenant; invalidParams lists every violation by employee and nothing was saved."},
+	{CodeNotFound, http.StatusNotFound, "The requested resource does not exist."},
+	{CodeEmployeeNotFound, http.StatusNotFound, "No employee has the given id."},
+	{CodeScheduleNotFound, http.StatusNotFound, "No schedule slot has the given id."},
+	{CodeRoleTemplateNotFound, http.StatusNotFound, "No role template has the given id or name."},
+	{CodeDeltaNotFound, http.StatusNotFound, "No schedule delta of the employee has the given id."},
+	{CodePairingRuleNotFound, http.StatusNotFound, "No pairing rule has the given id."},
+	{CodeStaffingNotFound, http.StatusNotFound, "No staffing requirement has the given id."},
+	{CodeLeaveNotFound, http.StatusNotFound, "The employee has no leave during the given days."},
+	{CodeOverrideNotFound, http.StatusNotFound, "The employee has no schedule override on the given date."},
+	{CodeAvailabilityNotFound, http.StatusNotFound, "The employee has no unavailability with the given id."},
+	{CodeClosureNotFound, http.StatusNotFound, "No closure day has the given id."},
+	{CodeRotationNotFound, http.StatusNotFound, "No rotation pattern has the given id or name."},
+	{CodeWebhookNotFound, http.StatusNotFound, "No webhook has the given id."},
+	{CodeTenantNotFound, http.StatusNotFound, "No tenant is served on the subdomain the request was sent to."},
+	{CodePrintJobNotFound, http.StatusNotFound, "No print job has the given id, or the job has no artifact to download."},
+	{CodeCalendarNotLinked, http.StatusNotFound, "The employee has no Google Calendar its slots are synced to."},
+	{CodeImportNotFound, http.StatusNotFound, "No employee import exists with the given ID."},
+	{CodeConflict, http.StatusConflict, "The request conflicts with the current state of the resource."},
+	{CodeScheduleOverlap, http.StatusConflict, "The slot overlaps another slot of the same employee on the same day or across midnight, at any location."},
+	{CodeScheduleVersionOrder, http.StatusConflict, "The slots of an employee can only be replaced from a date after the one its current slots are in force from."},
+	{CodeImportSuperseded, http.StatusConflict, "A later import changed employees of the import; roll it back first."},
+	{CodeLeaveExists, http.StatusConflict, "The employee already requested leave, pending or approved, on one of the requested days."},
+	{CodeLeaveDecided, http.StatusConflict, "The leave request was already approved or rejected."},
+	{CodeRotationExists, http.StatusConflict, "A rotation pattern with the same name already exists."},
+	{CodeClosureExists, http.StatusConflict, "The company already closes on the given date."},
+	{CodeNonceReused, http.StatusConflict, "The device already used the nonce for a different punch."},
+	{CodePunchReplayed, http.StatusConflict, "The sequence number is not above the last one of the device: the punch is a stale replay and was not recorded."},
+	{CodeUnauthorized, http.StatusUnauthorized, "The bearer token is missing, invalid or expired."},
+	{CodeInvalidCredentials, http.StatusUnauthorized, "The username or password is wrong."},
+	{CodeInvalidAPIKey, http.StatusUnauthorized, "The X-API-Key header is unknown, or missing on a request not sent to the subdomain of a tenant."},
+	{CodeDownloadLinkInvalid, http.StatusUnauthorized, "The download link was altered or has expired; fetch the print job again for a fresh one."},
+	{CodeUnavailable, http.StatusServiceUnavailable, "A dependency of the API is temporarily unavailable."},
+	{CodeHolidayProviderDown, http.StatusServiceUnavailable, "The public holiday provider could not be reached."},
+	{CodeValidatorUnavailable, http.StatusServiceUnavailable, "A validator of the tenant, such as its external validation service, could not check the schedules; nothing was saved."},
+	{CodeCalendarIncomplete, http.StatusServiceUnavailable, "The employee exists but part of its schedules could not be loaded; retry rather than read it as having no slots."},
+	{CodeCalendarSyncDisabled, http.StatusServiceUnavailable, "The Google Calendar sync is not configured on this deployment."},
+	{CodeGoogleCalendarDown, http.StatusServiceUnavailable, "Google Calendar could not be reached or refused the sync; the link keeps the error and the next change tries again."},
+	{CodeServerBusy, http.StatusServiceUnavailable, "Too many expensive requests (exports, reports) are in progress; retry after the Retry-After delay."},
+	{CodeTimeout, http.StatusGatewayTimeout, "The request did not complete within the time budget of its route; a write may or may not have taken effect."},
+	{CodeReadOnly, http.StatusServiceUnavailable, "This instance is a read-only replica and rejects every write; send it to the primary."},
+	{CodeInternal, http.StatusInternalServerError, "An unexpected error occurred; quote the requestId when reporting it."},
+}
+
+// Catalog returns every error code the API may return.
+func Catalog() []CatalogEntry {
+	return append([]CatalogEntry(nil), catalog...)
+}
+
+// defaultCode is the code of errors created without an explicit one.
+func (k Kind) defaultCode() Code {
+	switch k {
+	case KindValidation:
+		return CodeValidationFailed
+	case KindUnprocessable:
+		return CodeInputInvalid
+	case KindNotFound:
+		return CodeNotFound
+	case KindConflict:
+		return CodeConflict
+	case KindUnauthorized:
+		return CodeUnauthorized
+	case KindUnavailable:
+		return CodeUnavailable
+	case KindTimeout:
+		return CodeTimeout
+	default:
+		return CodeInternal
+	}
+}
+
+// CatalogHandler documents the error codes so client apps can map them to localized messages.
+func CatalogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	if err := json.NewEncoder(w).Encode(Catalog()); err != nil {
+		log.Printf("Failed to encode error catalog: %v", err)
+	}
+}
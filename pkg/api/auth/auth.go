@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCredentials is returned when the username or password does not match a stored user.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+type contextKey struct{}
+
+// Claims are the JWT claims issued at login.
+type Claims struct {
+	UserID   uint   `json:"uid"`
+	Username string `json:"username"`
+	// TenantID is the tenant of the user in a multi-tenant deployment.
+	TenantID uint `json:"tid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Service issues and verifies API tokens for the users stored in the repository.
+type Service struct {
+	repo   repo.Repository
+	secret []byte
+	ttl    time.Duration
+	// hasher hashes new passwords; fallbacks verify the hashes of the other supported algorithms.
+	hasher    Hasher
+	fallbacks []Hasher
+	policy    PasswordPolicy
+	// anonymous are the claims of the requests without a token, see AllowAnonymous.
+	anonymous *Claims
+}
+
+func NewService(repo repo.Repository, secret string, ttl time.Duration) *Service {
+	return &Service{
+		repo:      repo,
+		secret:    []byte(secret),
+		ttl:       ttl,
+		hasher:    DefaultArgon2id,
+		fallbacks: []Hasher{DefaultArgon2id, DefaultBcrypt},
+		policy:    DefaultPasswordPolicy,
+	}
+}
+
+// UsePasswordHasher makes new passwords hashed with hasher. Passwords hashed with argon2id or bcrypt keep
+// verifying and are rehashed with hasher at the next login.
+func (s *Service) UsePasswordHasher(hasher Hasher) {
+	s.hasher = hasher
+}
+
+// SetPasswordPolicy sets the strength required of new passwords.
+func (s *Service) SetPasswordPolicy(policy PasswordPolicy) {
+	s.policy = policy
+}
+
+// CreateUser stores a new user with a hash of the given password, which must meet the password policy.
+func (s *Service) CreateUser(ctx context.Context, username, password string) (*model.User, error) {
+	if err := s.policy.Check(password); err != nil {
+		return nil, apierror.Validation(err.Error())
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{Username: username, PasswordHash: hash}
+	if err := s.repo.UserCreate(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EnsureUser creates the user if no account with that username exists yet.
+func (s *Service) EnsureUser(ctx context.Context, username, password string) error {
+	_, err := s.repo.UserFindByUsername(ctx, username)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	_, err = s.CreateUser(ctx, username, password)
+	return err
+}
+
+// AllowAnonymous lets the requests without a bearer token through as the user with the given username, so
+// that no one has to log in, as in the demo mode. A request with a token is still checked.
+func (s *Service) AllowAnonymous(ctx context.Context, username string) error {
+	user, err := s.repo.UserFindByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to find the anonymous user %s: %w", username, err)
+	}
+	s.anonymous = &Claims{UserID: user.ID, Username: user.Username, TenantID: user.TenantID}
+	return nil
+}
+
+// Login checks the credentials and returns a signed token for the user. A password hashed with another
+// algorithm or weaker parameters than the current ones is rehashed on the way.
+func (s *Service) Login(ctx context.Context, username, password string) (string, error) {
+	user, err := s.repo.UserFindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrInvalidCredentials
+		}
+		return "", err
+	}
+	current := s.hasher.Recognizes(user.PasswordHash)
+	verifier := s.hasher
+	if !current {
+		verifier = nil
+		for _, fallback := range s.fallbacks {
+			if fallback.Recognizes(user.PasswordHash) {
+				verifier = fallback
+				break
+			}
+		}
+	}
+	if verifier == nil {
+		log.Errorf("The password hash of user %s uses an unsupported algorithm", user.Username)
+		return "", ErrInvalidCredentials
+	}
+	if ok, err := verifier.Verify(user.PasswordHash, password); err != nil || !ok {
+		if err != nil {
+			log.Errorf("Failed to verify the password of user %s: %v", user.Username, err)
+		}
+		return "", ErrInvalidCredentials
+	}
+	if !current || s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehash(ctx, user, password)
+	}
+	return s.IssueToken(user)
+}
+
+// rehash stores a hash of password made with the current hasher. The login goes on if it fails.
+func (s *Service) rehash(ctx context.Context, user *model.User, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.repo.UserUpdatePasswordHash(ctx, user.ID, hash)
+	}
+	if err != nil {
+		log.Errorf("Failed to rehash the password of user %s: %v", user.Username, err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
+// IssueToken signs an HS256 token for the user valid for the configured TTL.
+func (s *Service) IssueToken(user *model.User) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		TenantID: user.TenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// ParseToken verifies the signature and expiry of a token and returns its claims.
+func (s *Service) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+// Middleware rejects requests without a valid "Authorization: Bearer <token>" header, or with the token of
+// a user of another tenant than the one the request was resolved to, and stores the token claims in the
+// request context.
+func (s *Service) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if (!ok || tokenString == "") && s.anonymous != nil {
+			claims := *s.anonymous
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, &claims)))
+			return
+		}
+		if !ok || tokenString == "" {
+			apierror.Write(w, r, apierror.Unauthorized("missing bearer token"))
+			return
+		}
+		claims, err := s.ParseToken(tokenString)
+		if err != nil {
+			apierror.Write(w, r, apierror.Unauthorized(err.Error()))
+			return
+		}
+		if tenantID, ok := repo.TenantFromContext(r.Context()); ok && claims.TenantID != tenantID {
+			apierror.Write(w, r, apierror.Unauthorized("the token was not issued for this tenant"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+	})
+}
+
+// ClaimsFromContext returns the claims stored by Middleware, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expiresIn"`
+}
+
+// LoginHandler exchanges a username and password for a signed token.
+func (s *Service) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	token, err := s.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			apierror.Write(w, r, apierror.Unauthorized(err.Error()).WithCode(apierror.CodeInvalidCredentials))
+			return
+		}
+		apierror.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loginResponse{Token: token, ExpiresIn: int(s.ttl.Seconds())})
+}
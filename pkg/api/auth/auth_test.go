@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueAndParseToken(t *testing.T) {
+	svc := NewService(nil, "test-secret", time.Hour)
+
+	token, err := svc.IssueToken(&model.User{ID: 7, Username: "manager"})
+	require.NoError(t, err)
+
+	claims, err := svc.ParseToken(token)
+	require.NoError(t, err, "A freshly issued token should be valid")
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.Equal(t, "manager", claims.Username)
+
+	other := NewService(nil, "other-secret", time.Hour)
+	_, err = other.ParseToken(token)
+	assert.Error(t, err, "A token signed with another secret must be rejected")
+
+	expired := NewService(nil, "test-secret", -time.Minute)
+	expiredToken, err := expired.IssueToken(&model.User{ID: 7, Username: "manager"})
+	require.NoError(t, err)
+	_, err = svc.ParseToken(expiredToken)
+	assert.Error(t, err, "An expired token must be rejected")
+}
+
+func TestMiddleware(t *testing.T) {
+	svc := NewService(nil, "test-secret", time.Hour)
+	handler := svc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		require.True(t, ok, "Claims should be available to protected handlers")
+		w.Write([]byte(claims.Username))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "Requests without a token should be rejected")
+
+	token, err := svc.IssueToken(&model.User{ID: 1, Username: "manager"})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "manager", rec.Body.String())
+
+	svc.anonymous = &Claims{UserID: 2, Username: "demo"}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "Anonymous requests should act as the anonymous user")
+	assert.Equal(t, "demo", rec.Body.String())
+	req = httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "A token should still be checked")
+}
+
+func TestPasswordHashers(t *testing.T) {
+	weak := Argon2id{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16, SaltLen: 8}
+	strong := Argon2id{Time: 2, Memory: 2048, Threads: 1, KeyLen: 16, SaltLen: 8}
+	cheapBcrypt := Bcrypt{Cost: 4}
+
+	hash, err := weak.Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+	assert.True(t, strong.Recognizes(hash))
+	assert.False(t, cheapBcrypt.Recognizes(hash))
+	ok, err := strong.Verify(hash, "correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, ok, "The parameters are read from the hash")
+	ok, err = strong.Verify(hash, "correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, strong.NeedsRehash(hash))
+	assert.False(t, weak.NeedsRehash(hash))
+	_, err = weak.Verify("$argon2id$v=19$m=1024$x$y", "password")
+	assert.Error(t, err)
+
+	hash, err = cheapBcrypt.Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, cheapBcrypt.Recognizes(hash))
+	assert.False(t, weak.Recognizes(hash))
+	ok, err = cheapBcrypt.Verify(hash, "wrong")
+	require.NoError(t, err, "A mismatch is not an error")
+	assert.False(t, ok)
+	assert.True(t, Bcrypt{Cost: 5}.NeedsRehash(hash))
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, MaxLength: 20, CharacterClasses: 3}
+	assert.NoError(t, policy.Check("Opticien2024"))
+	assert.NoError(t, policy.Check("lunettes-été-2024"), "Accented letters count as letters")
+	assert.ErrorContains(t, policy.Check("Opt2024"), "at least 10 characters")
+	assert.ErrorContains(t, policy.Check("Opticien2024Opticien2024"), "at most 20 characters")
+	assert.ErrorContains(t, policy.Check("opticien2024"), "at least 3 of")
+	assert.NoError(t, DefaultPasswordPolicy.Check("manager-password"))
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"unicode"
+)
+
+// Hasher hashes passwords with one algorithm and verifies them against the hashes it produced.
+type Hasher interface {
+	// Hash returns the encoded hash of password, salt and parameters included.
+	Hash(password string) (string, error)
+	// Recognizes reports whether hash was produced by the algorithm of the hasher.
+	Recognizes(hash string) bool
+	// Verify reports whether password matches hash. A mismatch is not an error.
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether hash was produced with weaker parameters than the hasher's.
+	NeedsRehash(hash string) bool
+}
+
+// Argon2id hashes passwords with argon2id, encoded in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>.
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// DefaultArgon2id follows the second recommended option of RFC 9106, for hosts without 2 GiB to spare.
+var DefaultArgon2id = Argon2id{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16}
+
+const argon2idPrefix = "$argon2id$"
+
+func (a Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, a.KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a Argon2id) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (a Argon2id) Verify(hash, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a Argon2id) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Time < a.Time || params.Memory < a.Memory || params.Threads < a.Threads ||
+		uint32(len(key)) < a.KeyLen || uint32(len(salt)) < a.SaltLen
+}
+
+func decodeArgon2id(hash string) (params Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters %q", parts[3])
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+	return params, salt, key, nil
+}
+
+// Bcrypt hashes passwords with bcrypt, the algorithm of the accounts created before argon2id. Passwords are
+// truncated by bcrypt to 72 bytes.
+type Bcrypt struct {
+	Cost int
+}
+
+// DefaultBcrypt uses the default cost of the bcrypt package.
+var DefaultBcrypt = Bcrypt{Cost: bcrypt.DefaultCost}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(hash), err
+}
+
+func (b Bcrypt) Recognizes(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+func (b Bcrypt) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < b.Cost
+}
+
+// PasswordPolicy is the strength required of new passwords.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// CharacterClasses is the number of character classes (lower case, upper case, digits, others) a
+	// password must mix.
+	CharacterClasses int
+}
+
+// DefaultPasswordPolicy asks for passwords long enough to resist offline guessing, without composition
+// rules.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 12, MaxLength: 128, CharacterClasses: 1}
+
+// Check returns an error describing why password does not meet the policy, or nil.
+func (p PasswordPolicy) Check(password string) error {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return fmt.Errorf("the password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("the password must be at most %d characters long", p.MaxLength)
+	}
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, used := range []bool{lower, upper, digit, other} {
+		if used {
+			classes++
+		}
+	}
+	if classes < p.CharacterClasses {
+		return fmt.Errorf("the password must mix at least %d of lower case letters, upper case letters, digits and other characters", p.CharacterClasses)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Package csvimport reads the employees of the /loadEmployees format from a flat CSV file with one slot per
+// row, as exported by spreadsheets and planning tools. Every row is checked on its own, so that the errors
+// can be reported by row for the file to be fixed in one go.
+package csvimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Columns are the columns of the format, named by the header row in any order and case. Every row is a slot
+// of an employee; a row leaving weekType, day, start and end empty only declares the employee.
+var Columns = []string{"name", "startDate", "weekType", "day", "start", "end"}
+
+// OptionalColumns may follow Columns.
+var OptionalColumns = []string{"location", "task"}
+
+var days = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+var weekTypePattern = regexp.MustCompile(`^[A-Za-z0-9]{1,8}$`)
+
+// ErrMalformed is returned by Read when the data is not CSV, such as a quote left open.
+var ErrMalformed = errors.New("malformed CSV")
+
+// Read returns the employees of a CSV file in the order they first appear, with their slots in the order
+// of the rows. The separator is a comma, or a semicolon when the header has semicolons and no comma. The
+// fields breaking the format are returned as invalid, named by their row, counted from 1 with the header,
+// and column, such as "row 3.end"; the rows of an employee must agree on its start date.
+func Read(data []byte) (model.EmployeesInput, []apierror.InvalidParam, error) {
+	// Excel writes a byte order mark before the UTF-8 files it saves.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(header, []byte(";")) && !bytes.Contains(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	titles, err := reader.Read()
+	if err == io.EOF {
+		return nil, []apierror.InvalidParam{{Name: "header", Code: apierror.CodeFieldRequired, Reason: "the header row is required"}}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	columns, invalid := readHeader(titles)
+	if len(invalid) > 0 {
+		return nil, invalid, nil
+	}
+
+	var employees model.EmployeesInput
+	byName := map[string]int{}
+	startRows := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		report := func(column string, code apierror.Code, reason string) {
+			invalid = append(invalid, apierror.InvalidParam{Name: fmt.Sprintf("row %d.%s", line, column), Code: code, Reason: reason})
+		}
+		rejected := len(invalid)
+
+		name, startDate := field("name"), field("startDate")
+		switch {
+		case name == "":
+			report("name", apierror.CodeFieldRequired, "is required")
+		case utf8.RuneCountInString(name) > 255:
+			report("name", apierror.CodeValidationFailed, "must be at most 255 characters long")
+		}
+		if startDate == "" {
+			report("startDate", apierror.CodeFieldRequired, "is required")
+		} else if _, err := time.Parse("2006-01-02", startDate); err != nil {
+			report("startDate", apierror.CodeDateInvalid, fmt.Sprintf("must be a date written YYYY-MM-DD, got: %q", startDate))
+		} else if i, ok := byName[name]; ok && employees[i].StartDate != startDate {
+			report("startDate", apierror.CodeInputInvalid, fmt.Sprintf("%s disagrees with %s on row %d for %s",
+				startDate, employees[i].StartDate, startRows[name], name))
+		}
+		weekType, day, slot, ok := readSlot(field, report)
+		if len(invalid) > rejected || name == "" {
+			continue
+		}
+
+		i, found := byName[name]
+		if !found {
+			i = len(employees)
+			byName[name], startRows[name] = i, line
+			employees = append(employees, model.EmployeeInput{Name: name, StartDate: startDate, Weeks: map[string]model.WeeklyScheduleInput{}})
+		}
+		if !ok {
+			continue
+		}
+		week := employees[i].Weeks[weekType]
+		appendSlot(&week, day, slot)
+		employees[i].Weeks[weekType] = week
+	}
+	if len(employees) == 0 && len(invalid) == 0 {
+		invalid = append(invalid, apierror.InvalidParam{Name: "rows", Code: apierror.CodeFieldRequired, Reason: "the file has no employee"})
+	}
+	return employees, invalid, nil
+}
+
+// readHeader returns the index of each column named by the header row.
+func readHeader(titles []string) (map[string]int, []apierror.InvalidParam) {
+	all := append(append([]string{}, Columns...), OptionalColumns...)
+	known := map[string]string{}
+	for _, name := range all {
+		known[strings.ToLower(name)] = name
+	}
+	columns := map[string]int{}
+	var invalid []apierror.InvalidParam
+	for i, title := range titles {
+		name, ok := known[strings.ToLower(strings.TrimSpace(title))]
+		switch {
+		case !ok:
+			invalid = append(invalid, apierror.InvalidParam{Name: "header", Code: apierror.CodeInputInvalid,
+				Reason: fmt.Sprintf("unknown column %q, expected %s", title, strings.Join(all, ", "))})
+		case hasColumn(columns, name):
+			invalid = append(invalid, apierror.InvalidParam{Name: "header", Code: apierror.CodeInputInvalid, Reason: fmt.Sprintf("column %s appears twice", name)})
+		default:
+			columns[name] = i
+		}
+	}
+	for _, name := range Columns {
+		if !hasColumn(columns, name) {
+			invalid = append(invalid, apierror.InvalidParam{Name: "header", Code: apierror.CodeFieldRequired, Reason: fmt.Sprintf("column %s is required", name)})
+		}
+	}
+	return columns, invalid
+}
+
+func hasColumn(columns map[string]int, name string) bool {
+	_, ok := columns[name]
+	return ok
+}
+
+// readSlot checks the slot of a row, reporting its invalid fields. It returns false when the row has no
+// slot.
+func readSlot(field func(string) string, report func(column string, code apierror.Code, reason string)) (string, string, model.ScheduleInput, bool) {
+	weekType, day, start, end := field("weekType"), field("day"), field("start"), field("end")
+	if weekType == "" && day == "" && start == "" && end == "" {
+		return "", "", model.ScheduleInput{}, false
+	}
+	if weekType == "" {
+		report("weekType", apierror.CodeFieldRequired, "is required")
+	} else if !weekTypePattern.MatchString(weekType) {
+		report("weekType", apierror.CodeWeekTypeInvalid, fmt.Sprintf("must be a week name of 1 to 8 letters or digits, got: %q", weekType))
+	}
+	dayName := ""
+	for _, name := range days {
+		if strings.EqualFold(name, day) {
+			dayName = name
+		}
+	}
+	if day == "" {
+		report("day", apierror.CodeFieldRequired, "is required")
+	} else if dayName == "" {
+		report("day", apierror.CodeDayNameInvalid, fmt.Sprintf("must be one of %s, got: %q", strings.Join(days, ", "), day))
+	}
+	times := make([]time.Time, 2)
+	for i, column := range []string{"start", "end"} {
+		value := field(column)
+		if value == "" {
+			report(column, apierror.CodeFieldRequired, "is required")
+			continue
+		}
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			report(column, apierror.CodeTimeFormatInvalid, fmt.Sprintf("must be a time written HH:MM, got: %q", value))
+			continue
+		}
+		times[i] = t
+	}
+	// A slot ending before it starts is worked overnight.
+	if !times[0].IsZero() && !times[1].IsZero() && times[0].Equal(times[1]) {
+		report("end", apierror.CodeTimeRangeInvalid, fmt.Sprintf("must differ from start %s, got: %s", start, end))
+	}
+	slot := model.ScheduleInput{Start: start, End: end, Location: field("location"), Task: field("task")}
+	if utf8.RuneCountInString(slot.Location) > 100 {
+		report("location", apierror.CodeValidationFailed, "must be at most 100 characters long")
+	}
+	if utf8.RuneCountInString(slot.Task) > 50 {
+		report("task", apierror.CodeValidationFailed, "must be at most 50 characters long")
+	}
+	return weekType, dayName, slot, true
+}
+
+func appendSlot(week *model.WeeklyScheduleInput, day string, slot model.ScheduleInput) {
+	slots := map[string]*[]model.ScheduleInput{
+		"Monday": &week.Monday, "Tuesday": &week.Tuesday, "Wednesday": &week.Wednesday, "Thursday": &week.Thursday,
+		"Friday": &week.Friday, "Saturday": &week.Saturday, "Sunday": &week.Sunday,
+	}[day]
+	*slots = append(*slots, slot)
+}
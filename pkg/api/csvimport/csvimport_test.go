@@ -0,0 +1,60 @@
+package csvimport
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	employees, invalid, err := Read([]byte("\xef\xbb\xbfName;StartDate;WeekType;Day;Start;End;Task\n" +
+		"Ines;2024-04-01;A;monday;9:00;12:00;cash desk\n" +
+		"Paul;2024-04-15;;;;;\n" +
+		"\n" +
+		"Ines;2024-04-01;B;Saturday;09:00;13:00;\n" +
+		"Ines;2024-04-01;A;Monday;14:00;18:00\n" +
+		"Ines;2024-04-01;B;Friday;22:00;06:00\n"))
+	require.NoError(t, err)
+	require.Empty(t, invalid)
+	require.Equal(t, model.EmployeesInput{
+		{Name: "Ines", StartDate: "2024-04-01", Weeks: map[string]model.WeeklyScheduleInput{
+			"A": {Monday: []model.ScheduleInput{{Start: "9:00", End: "12:00", Task: "cash desk"}, {Start: "14:00", End: "18:00"}}},
+			"B": {Friday: []model.ScheduleInput{{Start: "22:00", End: "06:00"}}, Saturday: []model.ScheduleInput{{Start: "09:00", End: "13:00"}}},
+		}},
+		{Name: "Paul", StartDate: "2024-04-15", Weeks: map[string]model.WeeklyScheduleInput{}},
+	}, employees)
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, invalid, err := Read([]byte("name,startDate,weekType,day,start,end\n" +
+		"Ines,2024-04-01,A,Monday,9:00,12:00\n" +
+		",2024-04-01,A,Monday,9:00,12:00\n" +
+		"Ines,2024-04-02,AB-1,Funday,9h,8:00\n" +
+		"Paul,01/04/2024,A,Monday,14:00,14:00\n" +
+		"Zoé,2024-04-01,A,,,\n"))
+	require.NoError(t, err)
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "row 3.name", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "row 4.startDate", Code: apierror.CodeInputInvalid, Reason: "2024-04-02 disagrees with 2024-04-01 on row 2 for Ines"},
+		{Name: "row 4.weekType", Code: apierror.CodeWeekTypeInvalid, Reason: `must be a week name of 1 to 8 letters or digits, got: "AB-1"`},
+		{Name: "row 4.day", Code: apierror.CodeDayNameInvalid, Reason: `must be one of Monday, Tuesday, Wednesday, Thursday, Friday, Saturday, Sunday, got: "Funday"`},
+		{Name: "row 4.start", Code: apierror.CodeTimeFormatInvalid, Reason: `must be a time written HH:MM, got: "9h"`},
+		{Name: "row 5.startDate", Code: apierror.CodeDateInvalid, Reason: `must be a date written YYYY-MM-DD, got: "01/04/2024"`},
+		{Name: "row 5.end", Code: apierror.CodeTimeRangeInvalid, Reason: "must differ from start 14:00, got: 14:00"},
+		{Name: "row 6.day", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "row 6.start", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "row 6.end", Code: apierror.CodeFieldRequired, Reason: "is required"},
+	}, invalid)
+
+	_, invalid, err = Read([]byte("name,startDate,week,day,start,end,start\n"))
+	require.NoError(t, err)
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "header", Code: apierror.CodeInputInvalid, Reason: `unknown column "week", expected name, startDate, weekType, day, start, end, location, task`},
+		{Name: "header", Code: apierror.CodeInputInvalid, Reason: "column start appears twice"},
+		{Name: "header", Code: apierror.CodeFieldRequired, Reason: "column weekType is required"},
+	}, invalid)
+
+	_, _, err = Read([]byte("name,startDate,weekType,day,start,end\n\"Ines,2024-04-01\n"))
+	require.ErrorIs(t, err, ErrMalformed)
+}
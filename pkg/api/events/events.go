@@ -0,0 +1,97 @@
+// Package events carries the changes of the API to the parts of the server that react to them (webhooks,
+// live clients, caches) without the services knowing about them.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Event is something that happened in the API. Data is the resource concerned, in its API JSON form.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+	// TenantID is the tenant the event is about, 0 for an event of the whole deployment.
+	TenantID uint `json:"-"`
+}
+
+// Event types.
+const (
+	// CacheInvalidated is published when the schedule caches are rebuilt on request, with the scope of the
+	// invalidation as data.
+	CacheInvalidated = "cache.invalidated"
+	// PrintCompleted and PrintFailed are published when a print job is over, with the job as data; the job
+	// of PrintCompleted carries a signed download link.
+	PrintCompleted = "print.completed"
+	PrintFailed    = "print.failed"
+	// EmployeeChanged and ScheduleChanged are published when a row of an employee or of a schedule table
+	// (slots, deltas, overrides, leave) was written, by any instance or by hand, with the change as data.
+	EmployeeChanged = "employee.changed"
+	ScheduleChanged = "schedule.changed"
+	// ChangesMissed is published when the change feed reconnected: whatever was derived from the data may
+	// be stale.
+	ChangesMissed = "changes.missed"
+	// EmployeeCreated, ScheduleUpdated and LeaveApproved are published by the instance making the change: when
+	// an employee is created by an import, when a slot, delta or override of a schedule is written and when
+	// a leave request is approved.
+	EmployeeCreated = "employee.created"
+	ScheduleUpdated = "schedule.updated"
+	LeaveApproved   = "leave.approved"
+)
+
+// WebhookTypes are the types of the events webhooks can subscribe to.
+var WebhookTypes = []string{EmployeeCreated, ScheduleUpdated, LeaveApproved}
+
+// New returns an event of type typ about data, with a random ID, occurring now.
+func New(typ string, data interface{}) Event {
+	var id [8]byte
+	rand.Read(id[:])
+	return Event{ID: hex.EncodeToString(id[:]), Type: typ, OccurredAt: time.Now().UTC().Truncate(time.Millisecond), Data: data}
+}
+
+// Bus delivers every published event to every subscriber. Publishing never blocks: a subscriber whose
+// buffer is full misses the event.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving the events published from now on, buffering up to buffer of them,
+// and the function ending the subscription, which closes the channel.
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends event to every subscriber.
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Warnf("Dropped event %s %s for a slow subscriber", event.Type, event.ID)
+		}
+	}
+}
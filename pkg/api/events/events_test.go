@@ -0,0 +1,30 @@
+package events
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	first, unsubscribe := bus.Subscribe(1)
+	second, unsubscribeSecond := bus.Subscribe(1)
+	defer unsubscribeSecond()
+
+	event := New(CacheInvalidated, map[string]string{"scope": "all"})
+	bus.Publish(event)
+	require.Equal(t, event, <-first)
+	require.Equal(t, event, <-second)
+
+	bus.Publish(New(CacheInvalidated, nil))
+	bus.Publish(New(CacheInvalidated, nil))
+	<-second
+	require.Empty(t, second, "The event beyond the buffer is dropped")
+
+	unsubscribe()
+	unsubscribe()
+	<-first
+	_, open := <-first
+	require.False(t, open, "Unsubscribing closes the channel once")
+	bus.Publish(New(CacheInvalidated, nil))
+}
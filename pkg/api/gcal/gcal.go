@@ -0,0 +1,294 @@
+// Package gcal writes the slots of the employees to Google Calendar through the Calendar API v3. The
+// requests are authorized with the OAuth refresh token of a Google account allowed to write the calendars,
+// exchanged for short-lived access tokens as needed.
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoints of the Google APIs, replaced in the tests.
+const (
+	DefaultBaseURL  = "https://www.googleapis.com/calendar/v3"
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// SourceProperty is the private extended property marking the events written by the sync, with the UUID of
+// the employee as value: the events of a calendar without it are never changed.
+const SourceProperty = "lichensioEmployee"
+
+// Credentials are the OAuth client of the deployment and the refresh token of the account writing the
+// calendars.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+// Client calls the Calendar API on behalf of the account of Credentials.
+type Client struct {
+	Credentials Credentials
+	HTTP        *http.Client
+	BaseURL     string
+	TokenURL    string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewClient returns a client of the Google endpoints sending its requests with httpClient.
+func NewClient(credentials Credentials, httpClient *http.Client) *Client {
+	return &Client{Credentials: credentials, HTTP: httpClient, BaseURL: DefaultBaseURL, TokenURL: DefaultTokenURL}
+}
+
+// Event is an event of a calendar as written by the sync: a slot worked.
+type Event struct {
+	ID                 string              `json:"id"`
+	Status             string              `json:"status,omitempty"`
+	Summary            string              `json:"summary"`
+	Location           string              `json:"location,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Start              EventTime           `json:"start"`
+	End                EventTime           `json:"end"`
+	ExtendedProperties *ExtendedProperties `json:"extendedProperties,omitempty"`
+}
+
+// EventTime is the start or end of an event: an RFC 3339 time and the time zone it is shown in.
+type EventTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ExtendedProperties are the properties of an event hidden from the users of the calendar.
+type ExtendedProperties struct {
+	Private map[string]string `json:"private,omitempty"`
+}
+
+// Error is an answer of Google other than a success.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("google calendar answered %d: %s", e.Status, e.Message)
+}
+
+// EventID derives the ID of the event of a slot from what the slot is made of. A slot that changes gets
+// another event, and the event of a slot that does not is left untouched. The hexadecimal digits are among
+// those Google accepts in an event ID.
+func EventID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Result counts what a sync changed in a calendar.
+type Result struct {
+	Created   int `json:"created"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Sync makes the events of source in the calendar between from and to those of events: the events missing
+// are created and the events of source no longer wanted are deleted. The events of events are marked with
+// source, and their IDs are expected to be derived with EventID.
+func (c *Client) Sync(ctx context.Context, calendarID, source string, from, to time.Time, events []Event) (Result, error) {
+	var result Result
+	existing, err := c.ListEvents(ctx, calendarID, source, from, to)
+	if err != nil {
+		return result, err
+	}
+	stale := make(map[string]bool, len(existing))
+	for _, event := range existing {
+		stale[event.ID] = true
+	}
+	for _, event := range events {
+		if stale[event.ID] {
+			delete(stale, event.ID)
+			result.Unchanged++
+			continue
+		}
+		event.ExtendedProperties = &ExtendedProperties{Private: map[string]string{SourceProperty: source}}
+		if err := c.PutEvent(ctx, calendarID, event); err != nil {
+			return result, err
+		}
+		result.Created++
+	}
+	for _, event := range existing {
+		if !stale[event.ID] {
+			continue
+		}
+		if err := c.DeleteEvent(ctx, calendarID, event.ID); err != nil {
+			return result, err
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// ListEvents returns the events of source in the calendar starting between from and to, the deleted ones
+// excluded.
+func (c *Client) ListEvents(ctx context.Context, calendarID, source string, from, to time.Time) ([]Event, error) {
+	query := url.Values{
+		"privateExtendedProperty": {SourceProperty + "=" + source},
+		"timeMin":                 {from.Format(time.RFC3339)},
+		"timeMax":                 {to.Format(time.RFC3339)},
+		"singleEvents":            {"true"},
+		"maxResults":              {"2500"},
+	}
+	var events []Event
+	for {
+		var page struct {
+			Items         []Event `json:"items"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := c.do(ctx, http.MethodGet, eventsPath(calendarID)+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Items...)
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// PutEvent creates event in the calendar. An event deleted earlier keeps its ID, which Google does not
+// reuse: it is restored instead.
+func (c *Client) PutEvent(ctx context.Context, calendarID string, event Event) error {
+	err := c.do(ctx, http.MethodPost, eventsPath(calendarID), event, nil)
+	var answer *Error
+	if !errors.As(err, &answer) || answer.Status != http.StatusConflict {
+		return err
+	}
+	event.Status = "confirmed"
+	return c.do(ctx, http.MethodPut, eventsPath(calendarID)+"/"+url.PathEscape(event.ID), event, nil)
+}
+
+// DeleteEvent deletes an event of the calendar; an event already deleted is not an error.
+func (c *Client) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	err := c.do(ctx, http.MethodDelete, eventsPath(calendarID)+"/"+url.PathEscape(eventID), nil, nil)
+	var answer *Error
+	if errors.As(err, &answer) && (answer.Status == http.StatusNotFound || answer.Status == http.StatusGone) {
+		return nil
+	}
+	return err
+}
+
+// eventsPath is the path of the events of a calendar.
+func eventsPath(calendarID string) string {
+	return "/calendars/" + url.PathEscape(calendarID) + "/events"
+}
+
+// do sends a request to the Calendar API with body as JSON, and decodes the answer into out if not nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return answerError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns an access token valid for at least another minute, exchanging the refresh token for
+// a new one when the last one expires.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.Credentials.ClientID},
+		"client_secret": {c.Credentials.ClientSecret},
+		"refresh_token": {c.Credentials.RefreshToken},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("refreshing the access token: %w", answerError(resp))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("refreshing the access token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("refreshing the access token: no access token in the answer")
+	}
+	c.token = token.AccessToken
+	c.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// answerError reads the error of an answer, as written by the Calendar API or the token endpoint.
+func answerError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var answer struct {
+		Error json.RawMessage `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &answer) == nil && len(answer.Error) > 0 {
+		var detailed struct {
+			Message string `json:"message"`
+		}
+		var code string
+		switch {
+		case json.Unmarshal(answer.Error, &detailed) == nil && detailed.Message != "":
+			message = detailed.Message
+		case json.Unmarshal(answer.Error, &code) == nil:
+			message = code
+		}
+	}
+	return &Error{Status: resp.StatusCode, Message: message}
+}
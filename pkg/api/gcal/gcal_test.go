@@ -0,0 +1,146 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGoogle serves the token endpoint and the events of the Calendar API from memory. The deleted events
+// keep their ID, as Google does.
+type fakeGoogle struct {
+	mu        sync.Mutex
+	events    map[string]Event
+	refreshes int
+}
+
+func (f *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/token" {
+		if r.FormValue("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		f.refreshes++
+		w.Write([]byte(`{"access_token": "access", "expires_in": 3600}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer access" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	const prefix = "/calendars/team@example.com/events"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case r.Method == http.MethodGet:
+		items := []Event{}
+		for _, event := range f.events {
+			if event.Status != "cancelled" && r.URL.Query().Get("privateExtendedProperty") == SourceProperty+"="+event.ExtendedProperties.Private[SourceProperty] {
+				items = append(items, event)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case r.Method == http.MethodPost:
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		if _, ok := f.events[event.ID]; ok {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": {"code": 409, "message": "The requested identifier already exists."}}`))
+			return
+		}
+		f.events[event.ID] = event
+	case r.Method == http.MethodPut:
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		f.events[id] = event
+	case r.Method == http.MethodDelete:
+		event, ok := f.events[id]
+		if !ok || event.Status == "cancelled" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		event.Status = "cancelled"
+		f.events[id] = event
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// live returns the summaries of the events not deleted.
+func (f *fakeGoogle) live() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var summaries []string
+	for _, event := range f.events {
+		if event.Status != "cancelled" {
+			summaries = append(summaries, event.Summary)
+		}
+	}
+	return summaries
+}
+
+func TestSync(t *testing.T) {
+	google := &fakeGoogle{events: map[string]Event{}}
+	server := httptest.NewServer(google)
+	defer server.Close()
+	client := NewClient(Credentials{ClientID: "client", ClientSecret: "secret", RefreshToken: "refresh"}, server.Client())
+	client.BaseURL, client.TokenURL = server.URL, server.URL+"/token"
+
+	from := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	slot := func(day int, task string) Event {
+		start := time.Date(2024, time.April, day, 9, 0, 0, 0, time.UTC)
+		return Event{
+			ID:      EventID("employee", start.Format("2006-01-02"), task),
+			Summary: task,
+			Start:   EventTime{DateTime: start.Format(time.RFC3339)},
+			End:     EventTime{DateTime: start.Add(3 * time.Hour).Format(time.RFC3339)},
+		}
+	}
+	ctx := context.Background()
+	result, err := client.Sync(ctx, "team@example.com", "employee", from, to, []Event{slot(15, "Front desk"), slot(16, "Workshop")})
+	require.NoError(t, err)
+	require.Equal(t, Result{Created: 2}, result)
+	require.ElementsMatch(t, []string{"Front desk", "Workshop"}, google.live())
+
+	// The slot of the 16th changes task: its event is replaced, the other one left alone.
+	result, err = client.Sync(ctx, "team@example.com", "employee", from, to, []Event{slot(15, "Front desk"), slot(16, "Inventory")})
+	require.NoError(t, err)
+	require.Equal(t, Result{Created: 1, Deleted: 1, Unchanged: 1}, result)
+	require.ElementsMatch(t, []string{"Front desk", "Inventory"}, google.live())
+
+	// The events of another employee are not touched.
+	other := slot(15, "Front desk")
+	other.ID = EventID("other", "2024-04-15")
+	_, err = client.Sync(ctx, "team@example.com", "other", from, to, []Event{other})
+	require.NoError(t, err)
+	result, err = client.Sync(ctx, "team@example.com", "employee", from, to, nil)
+	require.NoError(t, err)
+	require.Equal(t, Result{Deleted: 2}, result)
+	require.Equal(t, []string{"Front desk"}, google.live())
+
+	// A slot coming back gets its deleted event back.
+	result, err = client.Sync(ctx, "team@example.com", "employee", from, to, []Event{slot(16, "Workshop")})
+	require.NoError(t, err)
+	require.Equal(t, Result{Created: 1}, result)
+	require.ElementsMatch(t, []string{"Front desk", "Workshop"}, google.live())
+	require.Equal(t, 1, google.refreshes, "the access token is reused until it expires")
+}
+
+func TestSyncRefused(t *testing.T) {
+	google := &fakeGoogle{events: map[string]Event{}}
+	server := httptest.NewServer(google)
+	defer server.Close()
+	client := NewClient(Credentials{RefreshToken: "revoked"}, server.Client())
+	client.BaseURL, client.TokenURL = server.URL, server.URL+"/token"
+
+	_, err := client.Sync(context.Background(), "team@example.com", "employee", time.Now(), time.Now().AddDate(0, 1, 0), nil)
+	require.EqualError(t, err, "refreshing the access token: google calendar answered 400: invalid_grant")
+}
@@ -0,0 +1,470 @@
+// Package graphql serves read-only GraphQL queries over the service layer, so that a client fetches the
+// employees with their slots, leave and hours in one request. It implements the query side of the
+// language: selection sets, arguments, variables, aliases, fragments and the @skip and @include
+// directives. Mutations, subscriptions and introspection are not supported; the schema is published as SDL
+// instead.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	log "github.com/sirupsen/logrus"
+	"reflect"
+	"sort"
+)
+
+// Schema is the types of a GraphQL API, from the root type of its queries.
+type Schema struct {
+	Query *Object
+	// SDL is the schema in the GraphQL schema definition language, for the clients.
+	SDL string
+}
+
+// Object is an object type: its name and its fields.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an object type. Resolve returns its value from the value of the object: a scalar
+// that encodes to JSON, or, when Type is set, a value of Type or a slice of them, nil for null.
+type Field struct {
+	Type *Object
+	// Args are the types of the arguments of the field by name, such as "Int!" or "String".
+	Args    map[string]string
+	Resolve func(ctx context.Context, source interface{}, args Args) (interface{}, error)
+}
+
+// Args are the arguments of a field, coerced to their types: Int as int, Float as float64, String and ID
+// as string and Boolean as bool. The arguments not given are absent.
+type Args map[string]interface{}
+
+// String returns a String or ID argument, empty when absent.
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns an Int argument, 0 when absent.
+func (a Args) Int(name string) int {
+	n, _ := a[name].(int)
+	return n
+}
+
+// Has reports whether an argument was given, null included.
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// Request is a GraphQL request, as sent in the body of a POST.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request: the data of the query, absent when the request was rejected before
+// its execution, and the errors met.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Error is an error of a request. Path is the field that failed, Extensions carries the code of the error
+// as the REST routes do.
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Execute runs the query of req against schema. The fields whose resolver fails are null, with an error
+// each; the data is absent only when the query cannot be run at all.
+func Execute(ctx context.Context, schema *Schema, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		var syntax *SyntaxError
+		errors.As(err, &syntax)
+		return &Response{Errors: []Error{{Message: err.Error(), Locations: []Location{syntax.Location}}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return &Response{Errors: []Error{{Message: fmt.Sprintf("only queries are supported, got a %s", op.kind), Locations: []Location{op.loc}}}}
+	}
+	e := &executor{ctx: ctx, doc: doc}
+	if e.variables, err = coerceVariables(op.variables, req.Variables); err != nil {
+		return &Response{Errors: []Error{{Message: err.Error(), Locations: []Location{op.loc}}}}
+	}
+	data := e.selectionSet(schema.Query, nil, op.selections, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// operation returns the operation of the document named name, which may be empty if it has only one.
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("the document has several operations: operationName must name the one to run")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("the document has no operation named %q", name)
+}
+
+// executor runs an operation, gathering the errors of its fields.
+type executor struct {
+	ctx       context.Context
+	doc       *document
+	variables map[string]interface{}
+	errors    []Error
+}
+
+// fail records an error of the field at path.
+func (e *executor) fail(sel *selection, path []interface{}, message string, extensions map[string]interface{}) {
+	e.errors = append(e.errors, Error{Message: message, Locations: []Location{sel.loc},
+		Path: append([]interface{}(nil), path...), Extensions: extensions})
+}
+
+// resolverFailed records the error of a resolver. The internal errors are logged and not shown, as on the
+// REST routes.
+func (e *executor) resolverFailed(sel *selection, path []interface{}, err error) {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) || apiErr.Kind == apierror.KindInternal {
+		log.Errorf("GraphQL field %v failed: %v", path, err)
+		e.fail(sel, path, "An unexpected error occurred.", map[string]interface{}{"code": apierror.CodeInternal})
+		return
+	}
+	message := apiErr.Detail
+	if message == "" {
+		message = apiErr.Error()
+	}
+	e.fail(sel, path, message, map[string]interface{}{"code": apiErr.ErrorCode()})
+}
+
+// fieldGroup is the fields of a selection set sharing a response key, whose selection sets are merged.
+type fieldGroup struct {
+	key    string
+	fields []*selection
+}
+
+// selectionSet returns the fields of selections on source, of type object, in the order of the query.
+func (e *executor) selectionSet(object *Object, source interface{}, selections []*selection, path []interface{}) *orderedObject {
+	var groups []*fieldGroup
+	e.collect(object, selections, map[string]*fieldGroup{}, &groups, map[string]bool{}, path)
+	result := &orderedObject{values: make(map[string]interface{}, len(groups))}
+	for _, group := range groups {
+		result.set(group.key, e.field(object, source, group, append(path, group.key)))
+	}
+	return result
+}
+
+// collect gathers the fields of selections applying to object, fragments included, by response key.
+func (e *executor) collect(object *Object, selections []*selection, byKey map[string]*fieldGroup, groups *[]*fieldGroup,
+	spread map[string]bool, path []interface{}) {
+	for _, sel := range selections {
+		include, err := e.included(sel)
+		if err != nil {
+			e.fail(sel, path, err.Error(), nil)
+			continue
+		}
+		if !include {
+			continue
+		}
+		switch {
+		case sel.fragment != "":
+			frag := e.doc.fragments[sel.fragment]
+			if frag == nil {
+				e.fail(sel, path, fmt.Sprintf("unknown fragment %q", sel.fragment), nil)
+				continue
+			}
+			if spread[frag.name] || frag.typeCondition != object.Name {
+				continue
+			}
+			spread[frag.name] = true
+			e.collect(object, frag.selections, byKey, groups, spread, path)
+		case sel.inline:
+			if sel.typeCondition == "" || sel.typeCondition == object.Name {
+				e.collect(object, sel.selections, byKey, groups, spread, path)
+			}
+		default:
+			group := byKey[sel.key()]
+			if group == nil {
+				group = &fieldGroup{key: sel.key()}
+				byKey[sel.key()] = group
+				*groups = append(*groups, group)
+			}
+			group.fields = append(group.fields, sel)
+		}
+	}
+}
+
+// included applies the @skip and @include directives of a selection.
+func (e *executor) included(sel *selection) (bool, error) {
+	for _, d := range sel.directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		args, err := e.arguments(map[string]string{"if": "Boolean!"}, d.args)
+		if err != nil {
+			return false, fmt.Errorf("@%s: %v", d.name, err)
+		}
+		if args["if"] == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// field resolves the fields of a group on source and completes their value.
+func (e *executor) field(object *Object, source interface{}, group *fieldGroup, path []interface{}) interface{} {
+	sel := group.fields[0]
+	if sel.name == "__typename" {
+		return object.Name
+	}
+	def := object.Fields[sel.name]
+	if def == nil {
+		e.fail(sel, path, fmt.Sprintf("cannot query field %q on type %s", sel.name, object.Name), nil)
+		return nil
+	}
+	args, err := e.arguments(def.Args, sel.args)
+	if err != nil {
+		e.fail(sel, path, fmt.Sprintf("field %q: %v", sel.name, err), nil)
+		return nil
+	}
+	var selections []*selection
+	for _, field := range group.fields {
+		selections = append(selections, field.selections...)
+	}
+	switch {
+	case def.Type == nil && len(selections) > 0:
+		e.fail(sel, path, fmt.Sprintf("field %q is a scalar and cannot have a selection set", sel.name), nil)
+		return nil
+	case def.Type != nil && len(selections) == 0:
+		e.fail(sel, path, fmt.Sprintf("field %q of type %s needs a selection set", sel.name, def.Type.Name), nil)
+		return nil
+	}
+	value, err := def.Resolve(e.ctx, source, args)
+	if err != nil {
+		e.resolverFailed(sel, path, err)
+		return nil
+	}
+	if def.Type == nil {
+		return value
+	}
+	return e.complete(def.Type, value, selections, path)
+}
+
+// complete selects the fields of an object value, or of every object of a list.
+func (e *executor) complete(object *Object, value interface{}, selections []*selection, path []interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch {
+	case value == nil, v.Kind() == reflect.Ptr && v.IsNil():
+		return nil
+	case v.Kind() == reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = e.complete(object, v.Index(i).Interface(), selections, append(path, i))
+		}
+		return list
+	}
+	return e.selectionSet(object, value, selections, path)
+}
+
+// arguments coerces the arguments of a field or directive to the types of defs.
+func (e *executor) arguments(defs map[string]string, given []argument) (Args, error) {
+	args := make(Args, len(given))
+	for _, arg := range given {
+		typ, ok := defs[arg.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown argument %q", arg.name)
+		}
+		ref, err := parseTypeRef(typ)
+		if err != nil {
+			return nil, err
+		}
+		value, present := e.resolve(arg.value)
+		if !present {
+			continue
+		}
+		if args[arg.name], err = coerce(ref, value); err != nil {
+			return nil, fmt.Errorf("argument %q: %v", arg.name, err)
+		}
+	}
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ref, _ := parseTypeRef(defs[name]); ref != nil && ref.nonNull && args[name] == nil {
+			return nil, fmt.Errorf("argument %q of type %s is required", name, ref)
+		}
+	}
+	return args, nil
+}
+
+// resolve replaces the variables of a value by their value; a variable not provided is absent.
+func (e *executor) resolve(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case variable:
+		resolved, ok := e.variables[string(v)]
+		return resolved, ok
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i], _ = e.resolve(item)
+		}
+		return list, true
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			if resolved, ok := e.resolve(item); ok {
+				object[name] = resolved
+			}
+		}
+		return object, true
+	}
+	return value, true
+}
+
+// coerceVariables checks the variables provided against the definitions of the operation, with the
+// defaults of the variables not provided.
+func coerceVariables(definitions []variableDefinition, provided map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(definitions))
+	for _, definition := range definitions {
+		value, ok := provided[definition.name]
+		if !ok {
+			if definition.hasDefault {
+				variables[definition.name] = definition.defaultVal
+			} else if definition.typ.nonNull {
+				return nil, fmt.Errorf("variable $%s of type %s is required", definition.name, definition.typ)
+			}
+			continue
+		}
+		coerced, err := coerce(definition.typ, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", definition.name, err)
+		}
+		variables[definition.name] = coerced
+	}
+	return variables, nil
+}
+
+// coerce converts a value, from the query or from the JSON variables, to typ.
+func coerce(typ *typeRef, value interface{}) (interface{}, error) {
+	if value == nil {
+		if typ.nonNull {
+			return nil, fmt.Errorf("expected %s, got null", typ)
+		}
+		return nil, nil
+	}
+	if typ.elem != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if list[i], err = coerce(typ.elem, item); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	switch typ.name {
+	case "Int":
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == float64(int(n)) {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch n := value.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "String":
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case "ID":
+		switch id := value.(type) {
+		case string:
+			return id, nil
+		case int:
+			return fmt.Sprint(id), nil
+		case float64:
+			if id == float64(int(id)) {
+				return fmt.Sprint(int(id)), nil
+			}
+		}
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown input type %s", typ.name)
+	}
+	return nil, fmt.Errorf("expected %s, got %v", typ, value)
+}
+
+// parseTypeRef reads a type such as "[String!]".
+func parseTypeRef(typ string) (*typeRef, error) {
+	p := &parser{lexer: lexer{src: typ, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p.typeRef()
+}
+
+// orderedObject is an object of the response, whose fields encode in the order of the query.
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type book struct {
+	Title  string
+	Pages  int
+	Author *author
+}
+
+type author struct {
+	Name string
+}
+
+// library is a schema of books, whose field missing always fails.
+func library() *Schema {
+	authorType := &Object{Name: "Author", Fields: map[string]*Field{
+		"name": {Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(*author).Name, nil
+		}},
+	}}
+	bookType := &Object{Name: "Book", Fields: map[string]*Field{
+		"title": {Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(book).Title, nil
+		}},
+		"pages": {Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(book).Pages, nil
+		}},
+		"author": {Type: authorType, Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(book).Author, nil
+		}},
+	}}
+	books := []book{{Title: "Dune", Pages: 412, Author: &author{Name: "Frank Herbert"}}, {Title: "Beowulf", Pages: 96}}
+	return &Schema{Query: &Object{Name: "Query", Fields: map[string]*Field{
+		"books": {Type: bookType, Args: map[string]string{"minPages": "Int", "titles": "[String!]"},
+			Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				var found []book
+				for _, b := range books {
+					if b.Pages >= args.Int("minPages") {
+						found = append(found, b)
+					}
+				}
+				return found, nil
+			}},
+		"book": {Type: bookType, Args: map[string]string{"title": "String!"},
+			Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				for _, b := range books {
+					if b.Title == args.String("title") {
+						return b, nil
+					}
+				}
+				return nil, apierror.NotFound("no book " + args.String("title")).WithCode(apierror.CodeEmployeeNotFound)
+			}},
+		"missing": {Resolve: func(context.Context, interface{}, Args) (interface{}, error) {
+			return nil, errors.New("connection refused")
+		}},
+	}}}
+}
+
+func run(t *testing.T, query string, variables map[string]interface{}) string {
+	t.Helper()
+	body, err := json.Marshal(Execute(context.Background(), library(), Request{Query: query, Variables: variables}))
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExecute(t *testing.T) {
+	// Aliases, arguments and nested objects, in the order of the query.
+	require.JSONEq(t, `{"data": {"long": [{"title": "Dune", "by": {"name": "Frank Herbert"}}],
+		"all": [{"pages": 412}, {"pages": 96}]}}`,
+		run(t, `{ long: books(minPages: 100) { title by: author { name } } all: books { pages } }`, nil))
+	require.Equal(t, `{"data":{"book":{"pages":96,"title":"Beowulf","author":null}}}`,
+		run(t, `{ book(title: "Beowulf") { pages title author { name } } }`, nil))
+
+	// Variables, with their defaults, fragments and directives.
+	require.JSONEq(t, `{"data": {"books": [{"title": "Dune", "pages": 412, "__typename": "Book"}]}}`,
+		run(t, `query Long($min: Int = 100, $withPages: Boolean!) {
+			books(minPages: $min) { ...Titles ... @include(if: $withPages) { pages } __typename }
+		}
+		fragment Titles on Book { title }`, map[string]interface{}{"withPages": true}))
+	require.JSONEq(t, `{"data": {"books": [{"title": "Dune"}, {"title": "Beowulf"}]}}`,
+		run(t, `query($min: Int) { books(minPages: $min) { title pages @skip(if: true) } }`, map[string]interface{}{"min": 0}))
+}
+
+func TestExecuteErrors(t *testing.T) {
+	// A failing field is null, with its error; the other fields answer.
+	require.JSONEq(t, `{"data": {"book": null, "books": [{"title": "Dune"}]}, "errors": [{"message": "no book Emma",
+		"locations": [{"line": 1, "column": 3}], "path": ["book"], "extensions": {"code": "EMP_NOT_FOUND"}}]}`,
+		run(t, `{ book(title: "Emma") { title } books(minPages: 200) { title } }`, nil))
+	require.JSONEq(t, `{"data": {"missing": null}, "errors": [{"message": "An unexpected error occurred.",
+		"locations": [{"line": 1, "column": 3}], "path": ["missing"], "extensions": {"code": "INTERNAL_ERROR"}}]}`,
+		run(t, `{ missing }`, nil))
+	require.JSONEq(t, `{"data": {"books": [{"isbn": null}]}, "errors": [{"message": "cannot query field \"isbn\" on type Book",
+		"locations": [{"line": 1, "column": 26}], "path": ["books", 0, "isbn"]}]}`,
+		run(t, `{ books(minPages: 200) { isbn } }`, nil))
+
+	// The requests that cannot be run have no data.
+	for query, message := range map[string]string{
+		`{ books { title }`: "syntax error at 1:18: unexpected end of the query",
+		`query($t: String!) { book(title: $t) { title } }`:       "variable $t of type String! is required",
+		`mutation { books { title } }`:                           "only queries are supported, got a mutation",
+		`query A { books { title } } query B { book { title } }`: "the document has several operations: operationName must name the one to run",
+	} {
+		resp := Execute(context.Background(), library(), Request{Query: query})
+		require.Nil(t, resp.Data, query)
+		require.Len(t, resp.Errors, 1, query)
+		require.Contains(t, resp.Errors[0].Message, message, query)
+	}
+}
@@ -0,0 +1,689 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Location is a position in a query, both counted from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// document is a parsed query: its operations and its named fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a query, mutation or subscription of a document.
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []*selection
+	loc        Location
+}
+
+// variableDefinition declares a variable of an operation, with its default value if any.
+type variableDefinition struct {
+	name       string
+	typ        *typeRef
+	defaultVal interface{}
+	hasDefault bool
+}
+
+// fragment is a named fragment, spread in selection sets with ...name.
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []*selection
+	loc           Location
+}
+
+// typeRef is a type as written in a query: a named type or a list, either of them non-null.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// selection is a field of a selection set, or a fragment spread when fragment is set, or an inline fragment
+// when inline is.
+type selection struct {
+	alias         string
+	name          string
+	args          []argument
+	fragment      string
+	inline        bool
+	typeCondition string
+	directives    []directive
+	selections    []*selection
+	loc           Location
+}
+
+// key is the key of a field in the response: its alias, or its name without one.
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type argument struct {
+	name  string
+	value interface{}
+	loc   Location
+}
+
+type directive struct {
+	name string
+	args []argument
+	loc  Location
+}
+
+// The values of a query are parsed as int, float64, string, bool, nil, enumValue, variable, []interface{}
+// and map[string]interface{}.
+type (
+	enumValue string
+	variable  string
+)
+
+// Kinds of the tokens of a query.
+const (
+	tokenEOF = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  int
+	value string
+	loc   Location
+}
+
+// SyntaxError is a query that cannot be parsed.
+type SyntaxError struct {
+	Message  string
+	Location Location
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.Location.Line, e.Location.Column, e.Message)
+}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: utf8.RuneCountInString(l.src[l.lineStart:l.pos]) + 1}
+}
+
+// next returns the next token, skipping the white space, commas and comments.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.pos++
+			l.line, l.lineStart = l.line+1, l.pos
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokenEOF, loc: l.location()}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	loc := l.location()
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunctuator, value: "...", loc: loc}, nil
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(c), loc: loc}, nil
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		start := l.pos
+		for l.pos < len(l.src) && isNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return l.number(loc)
+	case c == '"':
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, &SyntaxError{Message: fmt.Sprintf("unexpected character %q", r), Location: loc}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	start, kind := l.pos, tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() {
+		for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+			l.pos++
+		}
+	}
+	digits()
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		digits()
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		digits()
+	}
+	value := l.src[start:l.pos]
+	if l.pos < len(l.src) && (isNameChar(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return token{}, &SyntaxError{Message: fmt.Sprintf("invalid number %q", value+string(l.src[l.pos])), Location: loc}
+	}
+	return token{kind: kind, value: value, loc: loc}, nil
+}
+
+func (l *lexer) string(loc Location) (token, error) {
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, &SyntaxError{Message: "unterminated string", Location: loc}
+		}
+		value := l.src[l.pos+3 : l.pos+3+end]
+		for _, c := range value {
+			if c == '\n' {
+				l.line++
+			}
+		}
+		l.pos += 3 + end + 3
+		if i := strings.LastIndexByte(l.src[:l.pos], '\n'); i >= 0 {
+			l.lineStart = i + 1
+		}
+		return token{kind: tokenString, value: blockString(value), loc: loc}, nil
+	}
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return token{}, &SyntaxError{Message: "unterminated string", Location: loc}
+		case '"':
+			l.pos++
+			// The escapes of GraphQL strings are those of JSON.
+			var value string
+			if err := json.Unmarshal([]byte(l.src[start:l.pos]), &value); err != nil {
+				return token{}, &SyntaxError{Message: "invalid string " + l.src[start:l.pos], Location: loc}
+			}
+			return token{kind: tokenString, value: value, loc: loc}, nil
+		}
+		l.pos++
+	}
+	return token{}, &SyntaxError{Message: "unterminated string", Location: loc}
+}
+
+// blockString removes the common indentation of the lines of a block string and its blank first and last
+// lines.
+func blockString(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parser reads a document from the tokens of its lexer, one token ahead.
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+// parse reads a query document.
+func parse(query string) (doc *document, err error) {
+	p := &parser{lexer: lexer{src: query, line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunctuator, "{"):
+			loc := p.tok.loc
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections, loc: loc})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokenName, "fragment"):
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if doc.fragments[frag.name] != nil {
+				return nil, &SyntaxError{Message: fmt.Sprintf("fragment %q is defined twice", frag.name), Location: frag.loc}
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &SyntaxError{Message: "the document has no operation", Location: p.tok.loc}
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() (err error) {
+	p.tok, err = p.lexer.next()
+	return err
+}
+
+// peek reports whether the current token is of kind with value.
+func (p *parser) peek(kind int, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return &SyntaxError{Message: "unexpected end of the query", Location: p.tok.loc}
+	}
+	return &SyntaxError{Message: fmt.Sprintf("unexpected %q", p.tok.value), Location: p.tok.loc}
+}
+
+// expect consumes the punctuator value.
+func (p *parser) expect(value string) error {
+	if !p.peek(tokenPunctuator, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// name consumes a name and returns it.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.tok.kind == tokenName {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunctuator, "(") {
+		if op.variables, err = p.variableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var definitions []variableDefinition
+	for !p.peek(tokenPunctuator, ")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		definition := variableDefinition{name: name}
+		if definition.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if p.peek(tokenPunctuator, "=") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if definition.defaultVal, err = p.value(true); err != nil {
+				return nil, err
+			}
+			definition.hasDefault = true
+		}
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, p.advance()
+}
+
+func (p *parser) typeRef() (*typeRef, error) {
+	var t *typeRef
+	if p.peek(tokenPunctuator, "[") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = &typeRef{elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		t = &typeRef{name: name}
+	}
+	if p.peek(tokenPunctuator, "!") {
+		t.nonNull = true
+		return t, p.advance()
+	}
+	return t, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.name == "on" {
+		return nil, &SyntaxError{Message: `a fragment cannot be named "on"`, Location: frag.loc}
+	}
+	if !p.peek(tokenName, "on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []*selection
+	for !p.peek(tokenPunctuator, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, &SyntaxError{Message: "empty selection set", Location: p.tok.loc}
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (*selection, error) {
+	sel := &selection{loc: p.tok.loc}
+	var err error
+	if p.peek(tokenPunctuator, "...") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			sel.fragment = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			sel.directives, err = p.directives()
+			return sel, err
+		}
+		sel.inline = true
+		if p.peek(tokenName, "on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if sel.typeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		sel.selections, err = p.selectionSet()
+		return sel, err
+	}
+	if sel.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, ":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunctuator, "(") {
+		if sel.args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunctuator, "{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) arguments() ([]argument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []argument
+	for !p.peek(tokenPunctuator, ")") {
+		arg := argument{loc: p.tok.loc}
+		var err error
+		if arg.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(false); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, &SyntaxError{Message: "empty argument list", Location: p.tok.loc}
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var directives []directive
+	for p.peek(tokenPunctuator, "@") {
+		d := directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.peek(tokenPunctuator, "(") {
+			if d.args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+// value reads a value; a constant one, as the default of a variable, cannot refer to variables.
+func (p *parser) value(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenInt:
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("integer %s out of range", tok.value), Location: tok.loc}
+		}
+		return n, p.advance()
+	case tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("invalid float %s", tok.value), Location: tok.loc}
+		}
+		return f, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		var value interface{}
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = enumValue(tok.value)
+		}
+		return value, p.advance()
+	}
+	switch {
+	case p.peek(tokenPunctuator, "$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case p.peek(tokenPunctuator, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.peek(tokenPunctuator, "]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case p.peek(tokenPunctuator, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for !p.peek(tokenPunctuator, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,300 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"strconv"
+	"time"
+)
+
+// RosterSDL is the schema of the roster, see RosterSchema. The months are written as the month parameter
+// of the REST routes; the year may be left out when the month includes it (2024-03).
+const RosterSDL = `type Query {
+  # The employees not archived, the active ones unless status is "inactive" or "all".
+  employees(status: String): [Employee!]!
+  # An employee by id or UUID.
+  employee(id: ID!): Employee
+  # The public holidays of a month, in the holiday region of the request.
+  holidays(month: String!, year: Int): [Holiday!]!
+}
+
+type Employee {
+  id: ID!
+  uuid: String!
+  name: String!
+  startDate: String!
+  endDate: String
+  department: String!
+  contractWeeklyHours: Float!
+  email: String!
+  phone: String!
+  # The job title shown on the rosters.
+  role: String!
+  # The color of the employee in calendars, written #RRGGBB, or empty.
+  color: String!
+  # The own slots of the employee, those of its role template excluded.
+  schedules(weekType: String, day: String): [Schedule!]!
+  # The days of leave requested from from to to (YYYY-MM-DD), of any status unless status is set.
+  leave(from: String!, to: String!, status: String): [Leave!]!
+  # The calendar of a month: the slots of each day, overrides applied, with leave and public holidays.
+  monthlySchedule(month: String!, year: Int, location: String): [MonthlySchedule!]!
+  # The hours worked in a month, the days of leave left out.
+  monthlyHours(month: String!, year: Int): Float!
+}
+
+type Schedule {
+  id: ID!
+  uuid: String!
+  weekType: String!
+  dayName: String!
+  startTime: String!
+  endTime: String!
+  location: String!
+  task: String!
+  source: String!
+}
+
+type Leave {
+  id: ID!
+  date: String!
+  description: String!
+  withoutPay: Boolean!
+  status: String!
+}
+
+type MonthlySchedule {
+  date: String!
+  dayName: String!
+  holidayName: String
+  leave: DayLeave
+  overridden: Boolean!
+  unscheduled: Boolean!
+  timeSlots: [TimeSlot!]!
+  # The hours of the slots of the day, 0 on a day of leave.
+  hours: Float!
+}
+
+type DayLeave {
+  id: ID!
+  description: String!
+  withoutPay: Boolean!
+}
+
+type TimeSlot {
+  start: String!
+  end: String!
+  location: String!
+  task: String!
+  # The overnight slots are split at midnight: the part until 00:00 crosses midnight, the part from 00:00 on
+  # the next day is continued.
+  crossesMidnight: Boolean!
+  continued: Boolean!
+}
+
+type Holiday {
+  date: String!
+  name: String!
+  region: String!
+}
+`
+
+// prop is a field without arguments read from a value of type T.
+func prop[T any](get func(T) interface{}) *Field {
+	return &Field{Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+		return get(source.(T)), nil
+	}}
+}
+
+// RosterSchema is the schema of the employees, their slots, leave and monthly calendars and the public
+// holidays, resolved by svc.
+func RosterSchema(svc service.EmployeeAPI) *Schema {
+	timeSlot := &Object{Name: "TimeSlot", Fields: map[string]*Field{
+		"start":           prop(func(s model.TimeSlot) interface{} { return s.Start }),
+		"end":             prop(func(s model.TimeSlot) interface{} { return s.End }),
+		"location":        prop(func(s model.TimeSlot) interface{} { return s.Location }),
+		"task":            prop(func(s model.TimeSlot) interface{} { return s.Task }),
+		"crossesMidnight": prop(func(s model.TimeSlot) interface{} { return s.CrossesMidnight }),
+		"continued":       prop(func(s model.TimeSlot) interface{} { return s.Continued }),
+	}}
+	dayLeave := &Object{Name: "DayLeave", Fields: map[string]*Field{
+		"id":          prop(func(l *model.Leave) interface{} { return strconv.FormatUint(uint64(l.ID), 10) }),
+		"description": prop(func(l *model.Leave) interface{} { return l.Description }),
+		"withoutPay":  prop(func(l *model.Leave) interface{} { return l.WithoutPay }),
+	}}
+	day := &Object{Name: "MonthlySchedule", Fields: map[string]*Field{
+		"date":    prop(func(d model.MonthlySchedule) interface{} { return d.Date }),
+		"dayName": prop(func(d model.MonthlySchedule) interface{} { return d.DayName }),
+		"holidayName": prop(func(d model.MonthlySchedule) interface{} {
+			if d.HolidayName == "" {
+				return nil
+			}
+			return d.HolidayName
+		}),
+		"leave": {Type: dayLeave, Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(model.MonthlySchedule).Leave, nil
+		}},
+		"overridden":  prop(func(d model.MonthlySchedule) interface{} { return d.Overridden }),
+		"unscheduled": prop(func(d model.MonthlySchedule) interface{} { return d.Unscheduled }),
+		"timeSlots": {Type: timeSlot, Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			return source.(model.MonthlySchedule).TimeSlots, nil
+		}},
+		"hours": {Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+			hours, err := svc.CalculateMonthlyHours([]model.MonthlySchedule{source.(model.MonthlySchedule)})
+			return hours.Worked, err
+		}},
+	}}
+	schedule := &Object{Name: "Schedule", Fields: map[string]*Field{
+		"id":        prop(func(s model.Schedule) interface{} { return strconv.FormatUint(uint64(s.ID), 10) }),
+		"uuid":      prop(func(s model.Schedule) interface{} { return s.UUID }),
+		"weekType":  prop(func(s model.Schedule) interface{} { return s.WeekType }),
+		"dayName":   prop(func(s model.Schedule) interface{} { return s.DayName }),
+		"startTime": prop(func(s model.Schedule) interface{} { return s.StartTime.Format("15:04") }),
+		"endTime":   prop(func(s model.Schedule) interface{} { return s.EndTime.Format("15:04") }),
+		"location":  prop(func(s model.Schedule) interface{} { return s.Location }),
+		"task":      prop(func(s model.Schedule) interface{} { return s.Task }),
+		"source":    prop(func(s model.Schedule) interface{} { return s.Source }),
+	}}
+	leave := &Object{Name: "Leave", Fields: map[string]*Field{
+		"id":          prop(func(l model.EmployeeHoliday) interface{} { return strconv.FormatUint(uint64(l.ID), 10) }),
+		"date":        prop(func(l model.EmployeeHoliday) interface{} { return l.HolidayDate.Format("2006-01-02") }),
+		"description": prop(func(l model.EmployeeHoliday) interface{} { return l.Description }),
+		"withoutPay":  prop(func(l model.EmployeeHoliday) interface{} { return l.WithoutPay }),
+		"status":      prop(func(l model.EmployeeHoliday) interface{} { return l.Status }),
+	}}
+	holiday := &Object{Name: "Holiday", Fields: map[string]*Field{
+		"date":   prop(func(h model.Holiday) interface{} { return h.HolidayDate.Format("2006-01-02") }),
+		"name":   prop(func(h model.Holiday) interface{} { return h.HolidayName }),
+		"region": prop(func(h model.Holiday) interface{} { return h.Region }),
+	}}
+	employee := &Object{Name: "Employee", Fields: map[string]*Field{
+		"id":        prop(func(e model.Employee) interface{} { return strconv.FormatUint(uint64(e.ID), 10) }),
+		"uuid":      prop(func(e model.Employee) interface{} { return e.UUID }),
+		"name":      prop(func(e model.Employee) interface{} { return e.Name }),
+		"startDate": prop(func(e model.Employee) interface{} { return e.StartDate.Format("2006-01-02") }),
+		"endDate": prop(func(e model.Employee) interface{} {
+			if e.EndDate == nil {
+				return nil
+			}
+			return e.EndDate.Format("2006-01-02")
+		}),
+		"department":          prop(func(e model.Employee) interface{} { return e.Department }),
+		"contractWeeklyHours": prop(func(e model.Employee) interface{} { return e.ContractWeeklyHours }),
+		"email":               prop(func(e model.Employee) interface{} { return e.Email }),
+		"phone":               prop(func(e model.Employee) interface{} { return e.Phone }),
+		"role":                prop(func(e model.Employee) interface{} { return e.Role }),
+		"color":               prop(func(e model.Employee) interface{} { return e.Color }),
+		"schedules": {Type: schedule, Args: map[string]string{"weekType": "String", "day": "String"},
+			Resolve: func(ctx context.Context, source interface{}, args Args) (interface{}, error) {
+				filter := service.ScheduleFilter{WeekType: args.String("weekType"), DayName: args.String("day")}
+				return svc.ListEmployeeSchedules(ctx, source.(model.Employee).ID, filter)
+			}},
+		"leave": {Type: leave, Args: map[string]string{"from": "String!", "to": "String!", "status": "String"},
+			Resolve: func(ctx context.Context, source interface{}, args Args) (interface{}, error) {
+				from, err := dateArg(args, "from")
+				if err != nil {
+					return nil, err
+				}
+				to, err := dateArg(args, "to")
+				if err != nil {
+					return nil, err
+				}
+				return svc.ListLeave(ctx, source.(model.Employee).ID, from, to, args.String("status"))
+			}},
+		"monthlySchedule": {Type: day, Args: map[string]string{"month": "String!", "year": "Int", "location": "String"},
+			Resolve: func(ctx context.Context, source interface{}, args Args) (interface{}, error) {
+				month, year, err := monthArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				return svc.FetchEmployeeScheduleAtLocation(ctx, source.(model.Employee).ID, month.String(), year, args.String("location"))
+			}},
+		"monthlyHours": {Args: map[string]string{"month": "String!", "year": "Int"},
+			Resolve: func(ctx context.Context, source interface{}, args Args) (interface{}, error) {
+				month, year, err := monthArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				days, err := svc.FetchEmployeeSchedule(ctx, source.(model.Employee).ID, month.String(), year)
+				if err != nil {
+					return nil, err
+				}
+				hours, err := svc.CalculateMonthlyHours(days)
+				return hours.Worked, err
+			}},
+	}}
+	query := &Object{Name: "Query", Fields: map[string]*Field{
+		"employees": {Type: employee, Args: map[string]string{"status": "String"},
+			Resolve: func(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+				return svc.FetchAllEmployees(ctx, args.String("status"))
+			}},
+		"employee": {Type: employee, Args: map[string]string{"id": "ID!"},
+			Resolve: func(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+				id, err := employeeID(ctx, svc, args.String("id"))
+				if err != nil {
+					return nil, err
+				}
+				found, err := svc.GetEmployee(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				return *found, nil
+			}},
+		"holidays": {Type: holiday, Args: map[string]string{"month": "String!", "year": "Int"},
+			Resolve: func(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+				month, year, err := monthArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				return svc.GetHolidaysForMonthYear(ctx, year, month)
+			}},
+	}}
+	return &Schema{Query: query, SDL: RosterSDL}
+}
+
+// employeeID reads the id of an employee, an integer or a UUID.
+func employeeID(ctx context.Context, svc service.EmployeeAPI, value string) (uint, error) {
+	if model.IsUUID(value) {
+		return svc.IDByUUID(ctx, &model.Employee{}, value)
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, apierror.Validation(fmt.Sprintf("invalid employee id %q", value))
+	}
+	return uint(id), nil
+}
+
+// monthArgs reads the month and year arguments as the month parameters of the REST routes: the year may be
+// left out when the month includes it, and must agree with it otherwise.
+func monthArgs(args Args) (time.Month, int, error) {
+	monthYear, month, err := util.ParseMonth(args.String("month"))
+	if err != nil {
+		return 0, 0, apierror.Validation(err.Error()).WithCode(apierror.CodeMonthInvalid)
+	}
+	if !args.Has("year") || args["year"] == nil {
+		if monthYear == 0 {
+			return 0, 0, apierror.Validation("year is required unless the month includes it").WithCode(apierror.CodeYearInvalid)
+		}
+		return month, monthYear, nil
+	}
+	year := args.Int("year")
+	if year < 1 || year > 9999 {
+		return 0, 0, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid)
+	}
+	if monthYear != 0 && monthYear != year {
+		return 0, 0, apierror.Validation(fmt.Sprintf("month %s and year %d disagree", args.String("month"), year)).WithCode(apierror.CodeMonthInvalid)
+	}
+	return month, year, nil
+}
+
+// dateArg reads a date argument written YYYY-MM-DD.
+func dateArg(args Args, name string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", args.String(name))
+	if err != nil {
+		return time.Time{}, apierror.Validation(fmt.Sprintf("%s must be a date written YYYY-MM-DD, got: %q", name, args.String(name))).WithCode(apierror.CodeDateInvalid)
+	}
+	return date, nil
+}
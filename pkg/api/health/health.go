@@ -0,0 +1,162 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Checker verifies that an external dependency is reachable.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// Result is the outcome of the last check of one dependency.
+type Result struct {
+	Name      string    `json:"name"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Duration  string    `json:"duration"`
+}
+
+// Report aggregates the results of every registered checker.
+type Report struct {
+	Healthy bool     `json:"healthy"`
+	Checks  []Result `json:"checks"`
+}
+
+// Aggregator runs checkers concurrently, each bounded by a timeout, and caches their results for a
+// TTL so frequent probes do not hammer the dependencies.
+type Aggregator struct {
+	checkers []Checker
+	timeout  time.Duration
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	results map[string]Result
+}
+
+func NewAggregator(timeout, ttl time.Duration, checkers ...Checker) *Aggregator {
+	return &Aggregator{
+		checkers: checkers,
+		timeout:  timeout,
+		ttl:      ttl,
+		results:  make(map[string]Result),
+	}
+}
+
+// Register adds a checker to the aggregator.
+func (a *Aggregator) Register(checker Checker) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkers = append(a.checkers, checker)
+}
+
+// Check returns a report, re-running only the checkers whose cached result is older than the TTL.
+func (a *Aggregator) Check(ctx context.Context) Report {
+	a.mu.Lock()
+	checkers := append([]Checker(nil), a.checkers...)
+	stale := make([]Checker, 0, len(checkers))
+	for _, checker := range checkers {
+		if result, ok := a.results[checker.Name()]; !ok || time.Since(result.CheckedAt) > a.ttl {
+			stale = append(stale, checker)
+		}
+	}
+	a.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, checker := range stale {
+		wg.Add(1)
+		go func(checker Checker) {
+			defer wg.Done()
+			result := a.run(ctx, checker)
+			a.mu.Lock()
+			a.results[checker.Name()] = result
+			a.mu.Unlock()
+		}(checker)
+	}
+	wg.Wait()
+
+	return a.LastReport()
+}
+
+// LastReport returns the cached results without running any checker.
+func (a *Aggregator) LastReport() Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	report := Report{Healthy: true, Checks: make([]Result, 0, len(a.checkers))}
+	for _, checker := range a.checkers {
+		result, ok := a.results[checker.Name()]
+		if !ok {
+			result = Result{Name: checker.Name(), Error: "not checked yet"}
+		}
+		report.Healthy = report.Healthy && result.Healthy
+		report.Checks = append(report.Checks, result)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	return report
+}
+
+func (a *Aggregator) run(ctx context.Context, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+	result := Result{
+		Name:      checker.Name(),
+		Healthy:   err == nil,
+		CheckedAt: start,
+		Duration:  time.Since(start).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DBChecker pings the database connection pool.
+type DBChecker struct {
+	DB *sql.DB
+}
+
+func (c DBChecker) Name() string { return "database" }
+
+func (c DBChecker) Check(ctx context.Context) error {
+	return c.DB.PingContext(ctx)
+}
+
+// HTTPChecker reports a dependency healthy when a GET on URL answers with a non-5xx status.
+type HTTPChecker struct {
+	CheckName string
+	URL       string
+	Client    *http.Client
+}
+
+func (c HTTPChecker) Name() string { return c.CheckName }
+
+func (c HTTPChecker) Check(ctx context.Context) error {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s answered %s", c.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeChecker struct {
+	name  string
+	delay time.Duration
+	err   error
+	calls int32
+}
+
+func (f *fakeChecker) Name() string { return f.name }
+
+func (f *fakeChecker) Check(ctx context.Context) error {
+	atomic.AddInt32(&f.calls, 1)
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestAggregatorCachesResults(t *testing.T) {
+	ok := &fakeChecker{name: "ok"}
+	down := &fakeChecker{name: "down", err: errors.New("connection refused")}
+	aggregator := NewAggregator(time.Second, time.Minute, ok, down)
+
+	report := aggregator.Check(context.Background())
+	assert.False(t, report.Healthy, "One failing dependency should make the report unhealthy")
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "connection refused", report.Checks[0].Error)
+
+	aggregator.Check(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ok.calls), "Fresh results should be served from the cache")
+}
+
+func TestAggregatorTimesOutSlowCheckers(t *testing.T) {
+	slow := &fakeChecker{name: "slow", delay: time.Second}
+	aggregator := NewAggregator(20*time.Millisecond, time.Minute, slow)
+
+	start := time.Now()
+	report := aggregator.Check(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "The probe should not wait for the slow dependency")
+	assert.False(t, report.Healthy)
+	assert.Contains(t, report.Checks[0].Error, "deadline exceeded")
+}
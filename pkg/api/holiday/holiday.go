@@ -0,0 +1,224 @@
+// Package holiday fetches the public holidays the calendars are built with. A deployment picks one provider:
+// the French government API, Nager.Date for the other countries, or a file for the countries and regions
+// neither covers. The holidays of a year depend on the holiday region, named the way the provider names it.
+package holiday
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Provider fetches the public holidays of a year.
+type Provider interface {
+	// Holidays returns the public holidays of year observed in region, their names by date (YYYY-MM-DD).
+	Holidays(ctx context.Context, region string, year int) (map[string]string, error)
+	// IsRegion reports whether region is a holiday region of the provider.
+	IsRegion(region string) bool
+	// DefaultRegion is the region of the deployments that name none, empty if they must name one.
+	DefaultRegion() string
+}
+
+// Remote is a provider fetching the holidays from a web API, whose availability is checked by the health
+// checks.
+type Remote interface {
+	Provider
+	// URL returns the address the holidays of year in region are fetched from.
+	URL(region string, year int) string
+}
+
+// getJSON decodes into v the response of a GET request to url.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Gouv fetches the holidays of France from calendrier.api.gouv.fr, the regions being those of
+// model.HolidayRegions.
+type Gouv struct {
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// URL returns the address of the holidays of year in region.
+func (Gouv) URL(region string, year int) string {
+	return fmt.Sprintf("https://calendrier.api.gouv.fr/jours-feries/%s/%d.json", region, year)
+}
+
+// Holidays fetches the holidays of year in region.
+func (g Gouv) Holidays(ctx context.Context, region string, year int) (map[string]string, error) {
+	var holidays map[string]string
+	if err := getJSON(ctx, g.Client, g.URL(region, year), &holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// IsRegion reports whether region is one of model.HolidayRegions.
+func (Gouv) IsRegion(region string) bool {
+	return model.IsHolidayRegion(region)
+}
+
+// DefaultRegion is mainland France.
+func (Gouv) DefaultRegion() string {
+	return model.RegionMetropole
+}
+
+// NagerDefaultBaseURL is the address of the Nager.Date API, replaced in the tests.
+const NagerDefaultBaseURL = "https://date.nager.at"
+
+// NagerDate fetches the holidays of a country from the Nager.Date API. The regions are the country itself,
+// by its ISO 3166-1 code such as "DE", for the holidays of the whole country, and its subdivisions, by their
+// ISO 3166-2 code such as "DE-BY", for those holidays plus the ones of the subdivision. Only the public
+// holidays are kept, not the bank or school holidays.
+type NagerDate struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country, upper-case.
+	Country string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+	// BaseURL is the address of the API, NagerDefaultBaseURL when empty.
+	BaseURL string
+}
+
+// nagerHoliday is a holiday as listed by Nager.Date.
+type nagerHoliday struct {
+	Date      string `json:"date"`
+	LocalName string `json:"localName"`
+	Name      string `json:"name"`
+	// Global is set on the holidays of the whole country; the others are observed in Counties only.
+	Global   bool     `json:"global"`
+	Counties []string `json:"counties"`
+	Types    []string `json:"types"`
+}
+
+// URL returns the address of the holidays of the country in year, whatever the region.
+func (n NagerDate) URL(region string, year int) string {
+	baseURL := n.BaseURL
+	if baseURL == "" {
+		baseURL = NagerDefaultBaseURL
+	}
+	return fmt.Sprintf("%s/api/v3/PublicHolidays/%d/%s", strings.TrimSuffix(baseURL, "/"), year, n.Country)
+}
+
+// Holidays fetches the holidays of the country in year and keeps those observed in region, named in the
+// language of the country. Two holidays of the same date are named together.
+func (n NagerDate) Holidays(ctx context.Context, region string, year int) (map[string]string, error) {
+	var listed []nagerHoliday
+	if err := getJSON(ctx, n.Client, n.URL(region, year), &listed); err != nil {
+		return nil, err
+	}
+	holidays := make(map[string]string, len(listed))
+	for _, holiday := range listed {
+		if !holiday.public() || !holiday.Global && !contains(holiday.Counties, region) {
+			continue
+		}
+		name := holiday.LocalName
+		if name == "" {
+			name = holiday.Name
+		}
+		if previous, ok := holidays[holiday.Date]; ok {
+			name = previous + ", " + name
+		}
+		holidays[holiday.Date] = name
+	}
+	return holidays, nil
+}
+
+// public reports whether the holiday is a public holiday; the holidays listed without types are.
+func (h nagerHoliday) public() bool {
+	return len(h.Types) == 0 || contains(h.Types, "Public")
+}
+
+// IsRegion reports whether region is the country or one of its subdivisions.
+func (n NagerDate) IsRegion(region string) bool {
+	return region == n.Country || strings.HasPrefix(region, n.Country+"-") && len(region) > len(n.Country)+1
+}
+
+// DefaultRegion is the whole country.
+func (n NagerDate) DefaultRegion() string {
+	return n.Country
+}
+
+// File serves the holidays read from a JSON file, their names by date (YYYY-MM-DD) by region:
+//
+//	{"CH-ZH": {"2024-01-01": "Neujahr", "2024-01-02": "Berchtoldstag"}, "CH-GE": {"2024-01-01": "Nouvel An"}}
+//
+// The file lists the holidays of every year the calendars are built for.
+type File struct {
+	Regions map[string]map[string]string
+}
+
+// LoadFile reads the holidays of the file at path.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &File{}
+	if err := json.Unmarshal(data, &file.Regions); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Regions) == 0 {
+		return nil, fmt.Errorf("%s lists no region", path)
+	}
+	return file, nil
+}
+
+// Holidays returns the holidays of year in region listed by the file.
+func (f *File) Holidays(ctx context.Context, region string, year int) (map[string]string, error) {
+	prefix := fmt.Sprintf("%04d-", year)
+	holidays := make(map[string]string)
+	for date, name := range f.Regions[region] {
+		if strings.HasPrefix(date, prefix) {
+			holidays[date] = name
+		}
+	}
+	return holidays, nil
+}
+
+// IsRegion reports whether the file lists region.
+func (f *File) IsRegion(region string) bool {
+	_, ok := f.Regions[region]
+	return ok
+}
+
+// DefaultRegion is the region of a file listing a single one, none otherwise.
+func (f *File) DefaultRegion() string {
+	if len(f.Regions) != 1 {
+		return ""
+	}
+	for region := range f.Regions {
+		return region
+	}
+	return ""
+}
+
+// contains reports whether values holds value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
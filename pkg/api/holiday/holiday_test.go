@@ -0,0 +1,67 @@
+package holiday
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNagerDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/PublicHolidays/2024/DE" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[
+			{"date": "2024-01-01", "localName": "Neujahr", "name": "New Year's Day", "global": true, "counties": null, "types": ["Public"]},
+			{"date": "2024-01-06", "localName": "Heilige Drei Könige", "name": "Epiphany", "global": false, "counties": ["DE-BW", "DE-BY", "DE-ST"], "types": ["Public"]},
+			{"date": "2024-03-31", "localName": "Ostersonntag", "name": "Easter Sunday", "global": false, "counties": ["DE-BB"], "types": ["Public"]},
+			{"date": "2024-10-31", "localName": "Reformationstag", "name": "Reformation Day", "global": false, "counties": ["DE-BB"], "types": ["Public"]},
+			{"date": "2024-12-24", "localName": "Heiligabend", "name": "Christmas Eve", "global": true, "types": ["Bank"]}]`))
+	}))
+	defer server.Close()
+	nager := NagerDate{Country: "DE", Client: server.Client(), BaseURL: server.URL}
+
+	holidays, err := nager.Holidays(context.Background(), "DE", 2024)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"2024-01-01": "Neujahr"}, holidays)
+	holidays, err = nager.Holidays(context.Background(), "DE-BY", 2024)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"2024-01-01": "Neujahr", "2024-01-06": "Heilige Drei Könige"}, holidays)
+	_, err = nager.Holidays(context.Background(), "DE", 1900)
+	require.Error(t, err)
+
+	require.True(t, nager.IsRegion("DE"))
+	require.True(t, nager.IsRegion("DE-BY"))
+	require.False(t, nager.IsRegion("DE-"))
+	require.False(t, nager.IsRegion("AT"))
+	require.False(t, nager.IsRegion("metropole"))
+	require.Equal(t, "DE", nager.DefaultRegion())
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"CH-ZH": {"2024-01-01": "Neujahr", "2024-01-02": "Berchtoldstag", "2025-01-01": "Neujahr"},
+		"CH-GE": {"2024-01-01": "Nouvel An"}}`), 0o600))
+	file, err := LoadFile(path)
+	require.NoError(t, err)
+	holidays, err := file.Holidays(context.Background(), "CH-ZH", 2024)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"2024-01-01": "Neujahr", "2024-01-02": "Berchtoldstag"}, holidays)
+	require.True(t, file.IsRegion("CH-GE"))
+	require.False(t, file.IsRegion("CH-BE"))
+	require.Empty(t, file.DefaultRegion())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"CH-GE": {"2024-01-01": "Nouvel An"}}`), 0o600))
+	file, err = LoadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "CH-GE", file.DefaultRegion())
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+	_, err = LoadFile(path)
+	require.Error(t, err)
+}
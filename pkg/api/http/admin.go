@@ -0,0 +1,82 @@
+package http
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"strconv"
+)
+
+// ReadyzHandler reports whether every external dependency is reachable, using cached check results.
+func (s *Service) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.Health.Check(r.Context())
+	status := http.StatusOK
+	if !report.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+// DiagnosticsHandler shows the details of the last dependency checks without triggering new ones.
+func (s *Service) DiagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Health.LastReport())
+}
+
+// ImportProgressHandler reports the employee imports of this instance: the employees processed, schedules
+// written and validation failures since it started, and the progress of the imports running, so that a
+// large migration can be watched by polling it.
+func (s *Service) ImportProgressHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.EmployeeService.ImportProgress(r.Context()))
+}
+
+// CalendarCacheStatsHandler reports the cache of monthly calendars of this instance: its size and its hits,
+// misses, evictions and invalidations since it started.
+func (s *Service) CalendarCacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.EmployeeService.CalendarCacheStats())
+}
+
+// InvalidateCacheHandler rebuilds the schedule caches and bumps the data versions after a fix made directly in
+// the database: for one employee with ?employeeID=, for one month with ?month=&year=, or for everything.
+func (s *Service) InvalidateCacheHandler(w http.ResponseWriter, r *http.Request) {
+	var scope service.CacheScope
+	q := r.URL.Query()
+	if q.Get("employeeID") != "" {
+		employeeID, err := s.resolveID(r, "employeeID", q.Get("employeeID"), &model.Employee{})
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		scope.EmployeeID = employeeID
+	}
+	if q.Get("month") != "" {
+		month, year, err := monthQuery(r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		scope.Month, scope.Year = month, year
+	}
+	result, err := s.EmployeeService.InvalidateCaches(r.Context(), scope)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// PrefetchHolidaysHandler warms the public holidays of the year given as ?year=: they are fetched from the
+// holiday provider and stored, for the holiday region of the request and the regions of the locations.
+func (s *Service) PrefetchHolidaysHandler(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil || year < 1 || year > 9999 {
+		apierror.Write(w, r, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid))
+		return
+	}
+	prefetch, err := s.EmployeeService.PrefetchHolidays(r.Context(), year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, prefetch)
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetSlotAuditHandler lists the stored slots with their provenance, filtered by ?employeeID= (integer or
+// UUID), ?source= (import, manual, template or override), ?changedBy= (a user ID) and ?since= (YYYY-MM-DD or
+// RFC 3339), all optional.
+func (s *Service) GetSlotAuditHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := model.ProvenanceFilter{Source: q.Get("source")}
+	if value := q.Get("employeeID"); value != "" {
+		id, err := s.resolveID(r, "employeeID", value, &model.Employee{})
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		filter.EmployeeID = id
+	}
+	if value := q.Get("changedBy"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid changedBy, expected a user ID"))
+			return
+		}
+		userID := uint(id)
+		filter.ChangedByID = &userID
+	}
+	if value := q.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if since, err = time.Parse("2006-01-02", value); err != nil {
+				apierror.Write(w, r, apierror.Validation("invalid since, expected YYYY-MM-DD or RFC 3339").WithCode(apierror.CodeDateInvalid))
+				return
+			}
+		}
+		filter.Since = since
+	}
+	slots, err := s.EmployeeService.SlotProvenance(r.Context(), filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, slots)
+}
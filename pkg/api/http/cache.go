@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// noStore marks GET responses as not cacheable unless the handler declares them revalidatable with
+// notModified. Responses to authenticated requests must never be stored by shared caches.
+func noStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// notModified sets the caching headers of a response whose content last changed at lastModified, letting
+// browsers and private caches keep it and revalidate it on every use. It answers 304 Not Modified and
+// returns true when the client's copy, given by If-Modified-Since, is still current. A request carrying
+// If-None-Match is left to writeTagged, as the entity tag takes precedence over the date.
+func notModified(w http.ResponseWriter, r *http.Request, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	if r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeTagged writes payload as JSON with a strong ETag, the hash of the payload, or answers 304 Not
+// Modified when the client's copy, given by If-None-Match, has the same tag. The tag follows the data
+// itself, so that it also changes with what Last-Modified does not cover, such as leave and overrides.
+func writeTagged(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	hash, err := util.HashJSON(string(body))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	etag := `"` + hash + `"`
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether the If-None-Match header value lists etag, compared weakly as RFC 9110 asks.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
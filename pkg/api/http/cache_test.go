@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2024, 3, 1, 10, 30, 15, 500, time.UTC)
+
+	rec := httptest.NewRecorder()
+	assert.False(t, notModified(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil), lastModified))
+	assert.Equal(t, "Fri, 01 Mar 2024 10:30:15 GMT", rec.Header().Get("Last-Modified"))
+	assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+
+	req := httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil)
+	req.Header.Set("If-Modified-Since", rec.Header().Get("Last-Modified"))
+	rec = httptest.NewRecorder()
+	assert.True(t, notModified(rec, req, lastModified), "Sub-second precision must not defeat revalidation")
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = httptest.NewRecorder()
+	assert.False(t, notModified(rec, req, lastModified.Add(time.Second)))
+
+	rec = httptest.NewRecorder()
+	assert.False(t, notModified(rec, req, time.Time{}), "Empty collections carry no validator")
+	assert.Empty(t, rec.Header().Get("Last-Modified"))
+}
+
+func TestWriteTagged(t *testing.T) {
+	payload := map[string]interface{}{"totalHours": 35.5, "month": "April"}
+	rec := httptest.NewRecorder()
+	writeTagged(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getMonthlyHours", nil), payload)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"totalHours": 35.5, "month": "April"}`, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{64}"$`, etag)
+	assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+
+	req := httptest.NewRequest(http.MethodGet, "/prox/api/getMonthlyHours", nil)
+	req.Header.Set("If-None-Match", `"stale", W/`+etag)
+	rec = httptest.NewRecorder()
+	writeTagged(rec, req, payload)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	writeTagged(rec, req, map[string]interface{}{"totalHours": 36, "month": "April"})
+	assert.Equal(t, http.StatusOK, rec.Code, "Changed data gets a new tag")
+
+	req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	rec = httptest.NewRecorder()
+	assert.False(t, notModified(rec, req, time.Now()), "If-None-Match takes precedence over If-Modified-Since")
+}
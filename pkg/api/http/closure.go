@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+)
+
+// ListClosureDaysHandler returns the closure days of the company, restricted to ?from=&to= (YYYY-MM-DD) when
+// given.
+func (s *Service) ListClosureDaysHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" {
+		from = "0001-01-01"
+	}
+	if to == "" {
+		to = "9999-12-31"
+	}
+	start, end, err := service.ParseDateRange(from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	closures, err := s.EmployeeService.ListClosureDays(r.Context(), start, end)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, closures)
+}
+
+// CreateClosureDayHandler creates a closure day from the JSON body.
+func (s *Service) CreateClosureDayHandler(w http.ResponseWriter, r *http.Request) {
+	var input model.ClosureDayInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateClosureDay(r.Context(), input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// UpdateClosureDayHandler replaces a closure day with the JSON body.
+func (s *Service) UpdateClosureDayHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.ClosureDay{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var input model.ClosureDayInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.UpdateClosureDay(r.Context(), id, input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteClosureDayHandler removes a closure day.
+func (s *Service) DeleteClosureDayHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.ClosureDay{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteClosureDay(r.Context(), id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
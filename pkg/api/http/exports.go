@@ -0,0 +1,69 @@
+package http
+
+import (
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/payroll"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"strings"
+)
+
+// ExportSchedulesHandler returns the monthly schedules of every employee as a spreadsheet-friendly
+// file, given ?format=csv&month=&year=.
+func (s *Service) ExportSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if format := q.Get("format"); format != "" && format != "csv" {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("unsupported format %q, only csv is available", format)))
+		return
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	rows, err := s.EmployeeService.ExportMonthlySchedules(r.Context(), month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="schedules-%d-%s.csv"`, year, strings.ToLower(month)))
+	if err := service.WriteSchedulesCSV(w, rows); err != nil {
+		reqlog.From(r.Context()).Errorf("Failed to write schedule export: %v", err)
+	}
+}
+
+// ExportPayrollHandler returns the payroll data of every employee for a month, given
+// ?format=dsn|csv&month=&year=.
+func (s *Service) ExportPayrollHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "dsn"
+	}
+	formatter, ok := payroll.Lookup(name)
+	if !ok {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("unsupported format %q, expected one of %s", name, strings.Join(payroll.Names(), ", "))))
+		return
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	period, err := s.EmployeeService.PayrollPeriod(r.Context(), month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", formatter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payroll-%d-%02d.%s"`, year, period.Month, formatter.Extension()))
+	if err := formatter.Format(w, period); err != nil {
+		reqlog.From(r.Context()).Errorf("Failed to write payroll export: %v", err)
+	}
+}
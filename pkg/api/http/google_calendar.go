@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+)
+
+// GetGoogleCalendarHandler returns the Google Calendar the slots of an employee are synced to, with the
+// outcome of its last sync.
+func (s *Service) GetGoogleCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	link, err := s.EmployeeService.GetCalendarLink(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, link)
+}
+
+// LinkGoogleCalendarHandler syncs the slots of an employee to the Google Calendar of the JSON body
+// {calendarId}, and answers the outcome of the first sync.
+func (s *Service) LinkGoogleCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var body struct {
+		CalendarID string `json:"calendarId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	report, err := s.EmployeeService.LinkCalendar(r.Context(), employeeID, body.CalendarID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// SyncGoogleCalendarHandler syncs the Google Calendar of an employee now and answers what changed in it.
+func (s *Service) SyncGoogleCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	report, err := s.EmployeeService.SyncCalendar(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// UnlinkGoogleCalendarHandler stops the sync of the slots of an employee to its Google Calendar.
+func (s *Service) UnlinkGoogleCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.UnlinkCalendar(r.Context(), employeeID); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/graphql"
+	"net/http"
+)
+
+// GraphQLHandler runs a GraphQL query over the employees, their slots, leave and hours, and the public
+// holidays; see graphql.RosterSDL. The query comes as the JSON body {query, operationName, variables} of a
+// POST, or as the query, operationName and variables parameters of a GET. A query that cannot be run is
+// answered 400; the errors of its fields are reported next to the data, with a 200.
+func (s *Service) GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				apierror.Write(w, r, apierror.Validation("variables must be a JSON object: "+err.Error()))
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	if req.Query == "" {
+		apierror.Write(w, r, apierror.Validation("query is required"))
+		return
+	}
+	resp := graphql.Execute(r.Context(), graphql.RosterSchema(s.EmployeeService), req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+// GraphQLSchemaHandler answers the schema of the GraphQL route in the schema definition language.
+func (s *Service) GraphQLSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(graphql.RosterSDL))
+}
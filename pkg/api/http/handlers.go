@@ -0,0 +1,729 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/csvimport"
+	"github.com/lichensio/api_server/pkg/api/health"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/lichensio/api_server/pkg/api/slack"
+	"github.com/lichensio/api_server/pkg/api/support"
+	"github.com/lichensio/api_server/pkg/api/tenant"
+	"github.com/lichensio/api_server/pkg/api/validate"
+	"github.com/lichensio/api_server/pkg/api/xlsx"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service groups the application services exposed over HTTP.
+type Service struct {
+	// EmployeeService is a *service.EmployeeService, or a service.EmployeeAPIMock in the handler tests.
+	EmployeeService service.EmployeeAPI
+	Auth            *auth.Service
+	Health          *health.Aggregator
+	// ReportTimeout bounds report generation; when it expires the report built so far is returned
+	// marked partial. Zero means no limit.
+	ReportTimeout time.Duration
+	// HeavyLimit caps the concurrent exports, reports and all-employee views.
+	HeavyLimit Limit
+	// Timeouts are the time budgets of the CRUD and the expensive routes.
+	Timeouts Timeouts
+	// ReadOnly rejects every route that writes, for replicas pointed at a read-only database.
+	ReadOnly bool
+	// Tenants resolves the tenant of each request in a multi-tenant deployment; nil serves a single company.
+	Tenants *tenant.Resolver
+	// InternalTokens are the service tokens of the sibling services allowed on the internal routes; none
+	// closes them.
+	InternalTokens []string
+	// AdminTokens are the tokens of the operators allowed to migrate and drop the schema; none closes the
+	// schema routes.
+	AdminTokens []string
+	// HolidayRegion is the holiday region of the calendars when neither the request nor its tenant names one;
+	// empty means the default region of Holidays.
+	HolidayRegion string
+	// Holidays is the provider of the public holidays the regions of the requests are checked against; nil
+	// means the French government API.
+	Holidays holiday.Provider
+	// Logs keeps the recent warnings and errors of this instance for the support bundles; nil leaves them out.
+	Logs *support.LogRecorder
+	// Config is the configuration of this instance as shown in the support bundles, its secrets redacted.
+	Config map[string]string
+	// Closing ends the event streams once closed, so that a graceful shutdown does not wait for them.
+	Closing <-chan struct{}
+	// Slack posts the roster of the day to the Slack channel of the deployment; nil when none is configured.
+	Slack *slack.Reminder
+}
+
+// writeJSON encodes payload as the JSON response body with the given status code. A failure is logged with
+// the ID of the request, read back from the response headers.
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.WithField(reqlog.Field, w.Header().Get("X-Request-ID")).Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// idParam reads the path parameter identifying a resource of the type of the model resource, given either as
+// its UUID or as its integer ID. Errors are ready to be written.
+func (s *Service) idParam(r *http.Request, name string, resource interface{}) (uint, error) {
+	return s.resolveID(r, name, chi.URLParam(r, name), resource)
+}
+
+func (s *Service) resolveID(r *http.Request, name, value string, resource interface{}) (uint, error) {
+	if model.IsUUID(value) {
+		return s.EmployeeService.IDByUUID(r.Context(), resource, value)
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, apierror.Validation("invalid " + name)
+	}
+	return uint(id), nil
+}
+
+// scheduleNotModified sets the caching headers of a view of an employee's calendar and reports whether a
+// 304 (or an error) was already written.
+func (s *Service) scheduleNotModified(w http.ResponseWriter, r *http.Request, employeeID uint) bool {
+	lastModified, err := s.EmployeeService.EmployeeScheduleLastModified(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return true
+	}
+	return notModified(w, r, lastModified)
+}
+
+// monthlyQuery reads the employeeID (integer or UUID), month and year query parameters shared by the
+// monthly endpoints. Errors are ready to be written.
+func (s *Service) monthlyQuery(r *http.Request) (uint, string, int, error) {
+	employeeID, err := s.resolveID(r, "employeeID", r.URL.Query().Get("employeeID"), &model.Employee{})
+	if err != nil {
+		return 0, "", 0, err
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	return employeeID, month, year, nil
+}
+
+// monthQuery reads the month and year query parameters. The month may be given in any format accepted by
+// util.ParseMonth; when it includes the year ("2024-03") the year parameter is optional but must agree.
+// The month is returned as its English name. Errors are validation errors ready to be written.
+func monthQuery(r *http.Request) (string, int, error) {
+	q := r.URL.Query()
+	if q.Get("month") == "" {
+		return "", 0, apierror.Validation(fmt.Sprintf("month is required, expected %s", util.MonthFormats)).WithCode(apierror.CodeMonthInvalid)
+	}
+	monthYear, month, err := util.ParseMonth(q.Get("month"))
+	if err != nil {
+		return "", 0, apierror.Validation(err.Error()).WithCode(apierror.CodeMonthInvalid)
+	}
+	if q.Get("year") == "" && monthYear != 0 {
+		return month.String(), monthYear, nil
+	}
+	year, err := strconv.Atoi(q.Get("year"))
+	if err != nil || year < 1 || year > 9999 {
+		return "", 0, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid)
+	}
+	if monthYear != 0 && monthYear != year {
+		return "", 0, apierror.Validation(fmt.Sprintf("month %s and year %d disagree", q.Get("month"), year)).WithCode(apierror.CodeMonthInvalid)
+	}
+	return month.String(), year, nil
+}
+
+// LoadEmployeesHandler imports employees and their A/B weeks. With ?upsert=true, employees matching an
+// existing one by name and start date replace it instead of being duplicated. Replaying a payload that was
+// already imported in the same mode writes nothing and answers 200 instead of 201. With ?dryRun=true, or ?validate=true,
+// nothing is written: the payload goes through every check of the import and the report tells what the
+// import would do, the slots it would write included, and lists the existing employees each record may
+// duplicate. Fields breaking the rules of model.EmployeeInput are answered 422 before anything else is
+// checked.
+func (s *Service) LoadEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	s.loadEmployees(w, r, payload)
+}
+
+// LoadEmployeesXLSXHandler imports employees from an Excel workbook in the roster layout of xlsx.Roster,
+// sent as the body of the request: one sheet per week type, one row per employee and one column per day.
+// The workbook is converted to the /loadEmployees format and loaded like LoadEmployeesHandler, with the same
+// ?upsert= and ?dryRun= modes and answers. The cells that do not follow the layout are answered 422, named
+// by their sheet and cell.
+func (s *Service) LoadEmployeesXLSXHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid workbook").WithCode(apierror.CodeImportInvalid))
+		return
+	}
+	workbook, err := xlsx.Read(data)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid workbook: "+err.Error()).WithCode(apierror.CodeImportInvalid))
+		return
+	}
+	input, invalid := xlsx.Roster(workbook)
+	if len(invalid) > 0 {
+		apierror.Write(w, r, apierror.Unprocessable(fmt.Sprintf("%d invalid cell(s)", len(invalid)), invalid))
+		return
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	s.loadEmployees(w, r, payload)
+}
+
+// LoadEmployeesCSVHandler imports employees from a flat CSV file sent as the body of the request, one slot
+// per row with the columns of csvimport.Columns. Every row is checked before anything is loaded: the fields
+// breaking the format are answered 422, named by their row and column. The file is then converted to the
+// /loadEmployees format and loaded like LoadEmployeesHandler, with the same ?upsert= and ?dryRun= modes and
+// answers.
+func (s *Service) LoadEmployeesCSVHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid CSV file").WithCode(apierror.CodeImportInvalid))
+		return
+	}
+	input, invalid, err := csvimport.Read(data)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid CSV file: "+err.Error()).WithCode(apierror.CodeImportInvalid))
+		return
+	}
+	if len(invalid) > 0 {
+		apierror.Write(w, r, apierror.Unprocessable(fmt.Sprintf("%d invalid field(s)", len(invalid)), invalid))
+		return
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	s.loadEmployees(w, r, payload)
+}
+
+// loadEmployees imports the employees of a JSON payload of the /loadEmployees format, see
+// LoadEmployeesHandler.
+func (s *Service) loadEmployees(w http.ResponseWriter, r *http.Request, payload []byte) {
+	upsert, dryRun, validateOnly := false, false, false
+	for name, flag := range map[string]*bool{"upsert": &upsert, "dryRun": &dryRun, "validate": &validateOnly} {
+		if value := r.URL.Query().Get(name); value != "" {
+			var err error
+			if *flag, err = strconv.ParseBool(value); err != nil {
+				apierror.Write(w, r, apierror.Validation("invalid "+name+" "+value+", expected true or false"))
+				return
+			}
+		}
+	}
+	// Malformed JSON is reported by the import itself.
+	var input model.EmployeesInput
+	if json.Unmarshal(payload, &input) == nil {
+		if err := validate.Check("employees", input); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+	if dryRun || validateOnly {
+		preview, err := s.EmployeeService.PreviewImport(r.Context(), payload, upsert)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, preview)
+		return
+	}
+	result, err := s.EmployeeService.ImportEmployees(r.Context(), payload, upsert)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if result.AlreadyImported {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":     "already imported",
+			"loaded":     result.Import.Employees,
+			"importedAt": result.Import.CreatedAt,
+		})
+		return
+	}
+	status := http.StatusCreated
+	if result.Created == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{
+		"importId": result.Import.ID,
+		"loaded":   result.Import.Employees,
+		"created":  result.Created,
+		"updated":  result.Updated,
+	}
+	if len(result.Warnings) > 0 {
+		response["possibleDuplicates"] = result.Warnings
+	}
+	writeJSON(w, status, response)
+}
+
+// defaultImportsLimit is the number of imports listed when ?limit= is omitted.
+const defaultImportsLimit = 20
+
+// ListImportsHandler returns the last imports of employees, the latest first, with the employees each one
+// created or replaced. ?limit= bounds their number, 20 by default.
+func (s *Service) ListImportsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultImportsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid limit "+value+", expected a positive number"))
+			return
+		}
+	}
+	imports, err := s.EmployeeService.ListImports(r.Context(), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, imports)
+}
+
+// RollbackImportHandler reverses an import of employees: the employees it replaced get their previous
+// attributes and schedules back and the employees it created are archived. It answers the import rolled
+// back, 404 when there is none and 409 while a later import changed one of its employees.
+func (s *Service) RollbackImportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.EmployeeImport{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	record, err := s.EmployeeService.RollbackImport(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+func (s *Service) GetMonthlySchedule2Handler(w http.ResponseWriter, r *http.Request) {
+	employeeID, month, year, err := s.monthlyQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if s.scheduleNotModified(w, r, employeeID) {
+		return
+	}
+	location := r.URL.Query().Get("location")
+	entries, err := s.EmployeeService.FetchEmployeeScheduleAtLocation(r.Context(), employeeID, month, year, location)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeTagged(w, r, entries)
+}
+
+func (s *Service) GetMonthlyHours2Handler(w http.ResponseWriter, r *http.Request) {
+	employeeID, month, year, err := s.monthlyQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if s.scheduleNotModified(w, r, employeeID) {
+		return
+	}
+	entries, err := s.EmployeeService.FetchEmployeeSchedule(r.Context(), employeeID, month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	hours, err := s.EmployeeService.CalculateMonthlyHours(entries)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeTagged(w, r, map[string]interface{}{
+		"employeeID":          employeeID,
+		"month":               month,
+		"year":                year,
+		"totalHours":          hours.Worked,
+		"regularHours":        hours.Regular,
+		"holidayPremiumHours": hours.HolidayPremium,
+		"unpaidHours":         hours.Unpaid,
+		"paidHours":           hours.Paid,
+	})
+}
+
+// GetEmployeesHandler lists the active employees, or the ?status=inactive or ?status=all ones. ?active=true
+// and ?active=false stand for ?status=active and ?status=inactive.
+func (s *Service) GetEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := q.Get("status")
+	if value := q.Get("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil || status != "" {
+			apierror.Write(w, r, apierror.Validation("active must be true or false, and not given with status, got: "+value))
+			return
+		}
+		status = service.EmployeesInactive
+		if active {
+			status = service.EmployeesActive
+		}
+	}
+	lastModified, err := s.EmployeeService.EmployeesLastModified(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	// Deactivated employees become inactive at midnight, in the time zone of the deployment, even when no row
+	// changes.
+	now := time.Now().In(s.EmployeeService.TimeZone(""))
+	if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()); today.After(lastModified) {
+		lastModified = today
+	}
+	if notModified(w, r, lastModified) {
+		return
+	}
+	employees, err := s.EmployeeService.FetchAllEmployees(r.Context(), status)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employees)
+}
+
+// GetWeeksABHandler returns the weekly template of an employee, one week per week of its rotation, the week
+// ?date= (YYYY-MM-DD, default today) falls in marked current.
+func (s *Service) GetWeeksABHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "ID", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	on := time.Now().In(s.EmployeeService.TimeZone(""))
+	if value := q.Get("date"); value != "" {
+		if on, err = time.Parse("2006-01-02", value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid date "+value+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+	}
+	lastModified, err := s.EmployeeService.EmployeeScheduleLastModified(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if q.Get("date") == "" {
+		// The current week changes every Monday even when the schedule does not.
+		if monday := util.MondayOf(on); monday.After(lastModified) {
+			lastModified = monday
+		}
+	}
+	if notModified(w, r, lastModified) {
+		return
+	}
+	weeks, err := s.EmployeeService.FetchEmployeeFormattedABWeek(r.Context(), employeeID, q.Get("location"), on)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeTagged(w, r, weeks)
+}
+
+// defaultSearchLimit is the number of employees searched for when ?limit= is omitted.
+const defaultSearchLimit = 10
+
+// SearchEmployeesHandler returns the employees whose name matches ?q=, the best matches first. ?limit= bounds
+// their number, 10 by default and 50 at most.
+func (s *Service) SearchEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid limit "+value+", expected a positive number"))
+			return
+		}
+	}
+	employees, err := s.EmployeeService.SearchEmployees(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employees)
+}
+
+// GetEmployeeHandler returns an employee, with the related data listed by ?include=, comma-separated among
+// schedules, deltas and leave, embedded.
+func (s *Service) GetEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var include []string
+	for _, name := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			include = append(include, name)
+		}
+	}
+	employee, err := s.EmployeeService.GetEmployeeDetail(r.Context(), id, include)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employee)
+}
+
+// ArchiveEmployeeHandler archives (soft-deletes) an employee.
+func (s *Service) ArchiveEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.ArchiveEmployee(r.Context(), id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetArchivedEmployeesHandler lists the archived employees.
+func (s *Service) GetArchivedEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	employees, err := s.EmployeeService.ListArchivedEmployees(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employees)
+}
+
+// RestoreEmployeeHandler brings an archived employee back.
+func (s *Service) RestoreEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	employee, err := s.EmployeeService.RestoreEmployee(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employee)
+}
+
+// PatchEmployeeHandler changes the profile of an employee from the JSON body, see service.EmployeePatch: the
+// fields left out keep their value. Invalid fields are answered 422.
+func (s *Service) PatchEmployeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var patch service.EmployeePatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	if err := validate.Check("", patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	employee, err := s.EmployeeService.PatchEmployee(r.Context(), id, patch)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employee)
+}
+
+// GetEmployeeChangesHandler is the delta-sync feed: it returns the employees whose calendar changed after
+// ?since= (RFC 3339), or every employee when since is omitted. Archived employees have deletedAt set.
+func (s *Service) GetEmployeeChangesHandler(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid since "+value+", expected RFC 3339 (2024-03-01T10:00:00Z)").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+	}
+	changes, err := s.EmployeeService.FetchEmployeeChanges(r.Context(), since)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, changes)
+}
+
+// GetScheduleHandler returns a schedule slot with its provenance: its source and the user who last changed it.
+func (s *Service) GetScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Schedule{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	schedule, err := s.EmployeeService.GetSchedule(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+// UpdateScheduleHandler replaces a schedule slot with the JSON body of the request, from ?effectiveFrom= if
+// given.
+func (s *Service) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Schedule{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var schedule model.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.UpdateSchedule(r.Context(), id, schedule, effectiveFrom)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+type scheduleTaskInput struct {
+	Task string `json:"task"`
+}
+
+// PatchScheduleTaskHandler tags a schedule slot with a task/station.
+func (s *Service) PatchScheduleTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Schedule{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var input scheduleTaskInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.SetScheduleTask(r.Context(), id, input.Task)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteScheduleHandler removes a schedule slot, from ?effectiveFrom= if given.
+func (s *Service) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Schedule{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteSchedule(r.Context(), id, effectiveFrom); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetConflictsHandler lists the overlapping slots of an employee worked at different locations, and the
+// slots breaking the pairing rules it is bound by during the weeks starting with the week of ?week=
+// (YYYY-MM-DD, default the current week), as GetPairingViolationsHandler does.
+func (s *Service) GetConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	from := time.Now().In(s.EmployeeService.TimeZone(""))
+	if value := r.URL.Query().Get("week"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid week "+value+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+	}
+	conflicts, err := s.EmployeeService.DetectConflicts(r.Context(), employeeID, from)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, conflicts)
+}
+
+// GetScheduleRangeHandler returns the calendar of an employee from ?from= to ?to= (YYYY-MM-DD), which may
+// span months and years, optionally restricted to ?location=.
+func (s *Service) GetScheduleRangeHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("from") == "" || q.Get("to") == "" {
+		apierror.Write(w, r, apierror.Validation("from and to are required, expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	from, to, err := service.ParseDateRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if s.scheduleNotModified(w, r, employeeID) {
+		return
+	}
+	schedule, err := s.EmployeeService.FetchEmployeeScheduleRange(r.Context(), employeeID, from, to, q.Get("location"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeTagged(w, r, schedule)
+}
+
+// GetDashboardHandler returns the counts of the manager dashboard, such as the employees pending a template.
+func (s *Service) GetDashboardHandler(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := s.EmployeeService.Dashboard(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dashboard)
+}
+
+// DeactivateEmployeesHandler deactivates several employees at once from the JSON body {employeeIds, endDate}
+// and returns them.
+func (s *Service) DeactivateEmployeesHandler(w http.ResponseWriter, r *http.Request) {
+	var input model.DeactivationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	employees, err := s.EmployeeService.DeactivateEmployees(r.Context(), input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, employees)
+}
@@ -0,0 +1,307 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve routes one request to a handler of a Service backed by mock.
+func serve(mock *service.EmployeeAPIMock, method, pattern, target, body string, handler func(*Service) http.HandlerFunc) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Method(method, pattern, handler(&Service{EmployeeService: mock}))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestGetEmployeesHandler(t *testing.T) {
+	var status string
+	mock := &service.EmployeeAPIMock{
+		EmployeesLastModifiedFunc: func(context.Context) (time.Time, error) {
+			return time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), nil
+		},
+		TimeZoneFunc: func(string) *time.Location { return time.UTC },
+		FetchAllEmployeesFunc: func(_ context.Context, s string) ([]model.Employee, error) {
+			status = s
+			return []model.Employee{{ID: 7, Name: "Ines"}}, nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/getEmployees", "/getEmployees?status=all", "", func(s *Service) http.HandlerFunc { return s.GetEmployeesHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "all", status)
+	var employees []model.Employee
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &employees))
+	assert.Equal(t, "Ines", employees[0].Name)
+}
+
+func TestGetScheduleHandlerNotFound(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		GetScheduleFunc: func(_ context.Context, id uint) (*model.Schedule, error) {
+			assert.Equal(t, uint(42), id)
+			return nil, apierror.NotFound("schedule 42 not found").WithCode(apierror.CodeScheduleNotFound)
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/schedules/{id}", "/schedules/42", "", func(s *Service) http.HandlerFunc { return s.GetScheduleHandler })
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"SCHEDULE_NOT_FOUND"`)
+
+	// An invalid id never reaches the service.
+	rec = serve(&service.EmployeeAPIMock{}, http.MethodGet, "/schedules/{id}", "/schedules/zero", "", func(s *Service) http.HandlerFunc { return s.GetScheduleHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetScheduleHandlerByUUID(t *testing.T) {
+	uuid := model.NewUUID()
+	mock := &service.EmployeeAPIMock{
+		IDByUUIDFunc: func(_ context.Context, resource interface{}, value string) (uint, error) {
+			assert.IsType(t, &model.Schedule{}, resource)
+			if value != uuid {
+				return 0, apierror.NotFound(value + " not found")
+			}
+			return 42, nil
+		},
+		GetScheduleFunc: func(_ context.Context, id uint) (*model.Schedule, error) {
+			return &model.Schedule{ID: id, UUID: uuid}, nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/schedules/{id}", "/schedules/"+uuid, "", func(s *Service) http.HandlerFunc { return s.GetScheduleHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	var schedule model.Schedule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schedule))
+	assert.Equal(t, uint(42), schedule.ID)
+
+	rec = serve(mock, http.MethodGet, "/schedules/{id}", "/schedules/"+model.NewUUID(), "", func(s *Service) http.HandlerFunc { return s.GetScheduleHandler })
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPatchScheduleTaskHandler(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		SetScheduleTaskFunc: func(_ context.Context, id uint, task string) (*model.Schedule, error) {
+			assert.Equal(t, uint(42), id)
+			assert.Equal(t, "lab", task)
+			return &model.Schedule{ID: id, Task: task}, nil
+		},
+	}
+	rec := serve(mock, http.MethodPatch, "/schedules/{id}", "/schedules/42", `{"task": "lab"}`, func(s *Service) http.HandlerFunc { return s.PatchScheduleTaskHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	var schedule model.Schedule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schedule))
+	assert.Equal(t, "lab", schedule.Task)
+
+	// A body that is not JSON never reaches the service.
+	rec = serve(&service.EmployeeAPIMock{}, http.MethodPatch, "/schedules/{id}", "/schedules/42", `lab`, func(s *Service) http.HandlerFunc { return s.PatchScheduleTaskHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"INVALID_JSON"`)
+}
+
+func TestGetConflictsHandler(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		TimeZoneFunc: func(string) *time.Location { return time.UTC },
+		DetectConflictsFunc: func(_ context.Context, id uint, from time.Time) (*service.EmployeeConflicts, error) {
+			assert.Equal(t, uint(7), id)
+			assert.Equal(t, "2024-06-17", from.Format("2006-01-02"))
+			return &service.EmployeeConflicts{EmployeeID: id, Locations: []service.LocationConflict{},
+				Pairings: []service.PairingViolation{{Kind: model.PairingApart, Date: "2024-06-17", EmployeeID: id}}}, nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/employees/{id}/conflicts", "/employees/7/conflicts?week=2024-06-17", "", func(s *Service) http.HandlerFunc { return s.GetConflictsHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	var conflicts service.EmployeeConflicts
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &conflicts))
+	assert.Len(t, conflicts.Pairings, 1)
+
+	rec = serve(&service.EmployeeAPIMock{TimeZoneFunc: mock.TimeZoneFunc}, http.MethodGet, "/employees/{id}/conflicts", "/employees/7/conflicts?week=June", "", func(s *Service) http.HandlerFunc { return s.GetConflictsHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":"DATE_INVALID"`)
+}
+
+func TestGetRosterHandler(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		TeamRosterFunc: func(_ context.Context, month string, year int, location string) ([]service.RosterEmployee, error) {
+			assert.Equal(t, "April", month)
+			assert.Equal(t, 2024, year)
+			assert.Equal(t, "Gare", location)
+			return []service.RosterEmployee{{EmployeeID: 7, Employee: "Ines", Days: []model.MonthlySchedule{{Date: "2024-04-01", DayName: "Monday"}}}}, nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/roster", "/roster?month=2024-04&location=Gare&groupBy=day", "", func(s *Service) http.HandlerFunc { return s.GetRosterHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	var roster struct {
+		Days []service.RosterDay `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &roster))
+	require.Len(t, roster.Days, 1)
+	assert.Equal(t, "Ines", roster.Days[0].Employees[0].Employee)
+
+	// An unknown grouping never reaches the service.
+	rec = serve(&service.EmployeeAPIMock{}, http.MethodGet, "/roster", "/roster?month=2024-04&groupBy=week", "", func(s *Service) http.HandlerFunc { return s.GetRosterHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPunchHandlerReplay(t *testing.T) {
+	replay := false
+	mock := &service.EmployeeAPIMock{
+		PunchFunc: func(_ context.Context, entry model.TimeEntry) (*model.TimeEntry, bool, error) {
+			assert.Equal(t, "n1", entry.Nonce)
+			entry.ID = 3
+			return &entry, replay, nil
+		},
+	}
+	body := `{"employeeId": 7, "kind": "in", "deviceId": "kiosk-1", "nonce": "n1", "sequence": 1}`
+	rec := serve(mock, http.MethodPost, "/timeclock/punches", "/timeclock/punches", body, func(s *Service) http.HandlerFunc { return s.PunchHandler })
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get("Idempotent-Replayed"))
+
+	replay = true
+	rec = serve(mock, http.MethodPost, "/timeclock/punches", "/timeclock/punches", body, func(s *Service) http.HandlerFunc { return s.PunchHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	var entry model.TimeEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entry))
+	assert.Equal(t, uint(3), entry.ID)
+}
+
+func TestEmployeeSchedulesHandlers(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		ListEmployeeSchedulesFunc: func(_ context.Context, id uint, filter service.ScheduleFilter) ([]model.Schedule, error) {
+			assert.Equal(t, service.ScheduleFilter{WeekType: "A", DayName: "Monday"}, filter)
+			return []model.Schedule{{ID: 3, EmployeeID: id, WeekType: "A", DayName: "Monday"}}, nil
+		},
+		ReplaceEmployeeSchedulesFunc: func(_ context.Context, id uint, filter service.ScheduleFilter, slots []model.Schedule, effectiveFrom time.Time) ([]model.Schedule, error) {
+			assert.Equal(t, service.ScheduleFilter{WeekType: "B"}, filter)
+			assert.Empty(t, slots)
+			assert.Equal(t, "2024-06-17", effectiveFrom.Format("2006-01-02"))
+			return []model.Schedule{}, nil
+		},
+		DeleteEmployeeScheduleFunc: func(_ context.Context, id, scheduleID uint, _ time.Time) error {
+			assert.Equal(t, []uint{7, 3}, []uint{id, scheduleID})
+			return nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/employees/{id}/schedules", "/employees/7/schedules?weekType=A&day=Monday", "", func(s *Service) http.HandlerFunc { return s.ListEmployeeSchedulesHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+	var slots []model.Schedule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &slots))
+	assert.Equal(t, uint(7), slots[0].EmployeeID)
+
+	rec = serve(mock, http.MethodPut, "/employees/{id}/schedules", "/employees/7/schedules?weekType=B&effectiveFrom=2024-06-17", "[]", func(s *Service) http.HandlerFunc { return s.ReplaceEmployeeSchedulesHandler })
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(&service.EmployeeAPIMock{}, http.MethodPut, "/employees/{id}/schedules", "/employees/7/schedules?effectiveFrom=17/06/2024", "[]", func(s *Service) http.HandlerFunc { return s.ReplaceEmployeeSchedulesHandler })
+	assert.Contains(t, rec.Body.String(), `"code":"DATE_INVALID"`)
+
+	rec = serve(mock, http.MethodDelete, "/employees/{id}/schedules/{scheduleID}", "/employees/7/schedules/3", "", func(s *Service) http.HandlerFunc { return s.DeleteEmployeeScheduleHandler })
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = serve(&service.EmployeeAPIMock{}, http.MethodDelete, "/employees/{id}/schedules/{scheduleID}", "/employees/7/schedules/first", "", func(s *Service) http.HandlerFunc { return s.DeleteEmployeeScheduleHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetEmployeeHandler(t *testing.T) {
+	mock := &service.EmployeeAPIMock{
+		GetEmployeeDetailFunc: func(_ context.Context, id uint, include []string) (*service.EmployeeDetail, error) {
+			assert.Equal(t, uint(7), id)
+			assert.Equal(t, []string{"schedules", "leave"}, include)
+			return &service.EmployeeDetail{Employee: &model.Employee{ID: id, Name: "Ines"},
+				Leave: []model.EmployeeHoliday{{EmployeeID: id, Status: model.LeaveApproved}}}, nil
+		},
+	}
+	rec := serve(mock, http.MethodGet, "/employees/{id}", "/employees/7?include=schedules,%20leave,", "", func(s *Service) http.HandlerFunc { return s.GetEmployeeHandler })
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Equal(t, "Ines", detail["name"])
+	assert.Len(t, detail["leave"], 1)
+
+	mock.GetEmployeeDetailFunc = func(context.Context, uint, []string) (*service.EmployeeDetail, error) {
+		return nil, apierror.NotFound("employee 7 not found").WithCode(apierror.CodeEmployeeNotFound)
+	}
+	rec = serve(mock, http.MethodGet, "/employees/{id}", "/employees/7", "", func(s *Service) http.HandlerFunc { return s.GetEmployeeHandler })
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSearchEmployeesHandler(t *testing.T) {
+	var limits []int
+	mock := &service.EmployeeAPIMock{
+		SearchEmployeesFunc: func(_ context.Context, query string, limit int) ([]model.Employee, error) {
+			assert.Equal(t, "del", query)
+			limits = append(limits, limit)
+			return []model.Employee{{ID: 7, Name: "Delphine"}}, nil
+		},
+	}
+	for _, target := range []string{"/employees/search?q=del", "/employees/search?q=del&limit=5"} {
+		rec := serve(mock, http.MethodGet, "/employees/search", target, "", func(s *Service) http.HandlerFunc { return s.SearchEmployeesHandler })
+		require.Equal(t, http.StatusOK, rec.Code, target)
+		var employees []model.Employee
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &employees))
+		assert.Equal(t, "Delphine", employees[0].Name)
+	}
+	assert.Equal(t, []int{10, 5}, limits, "The limit defaults to 10")
+
+	rec := serve(&service.EmployeeAPIMock{}, http.MethodGet, "/employees/search", "/employees/search?q=del&limit=ten", "", func(s *Service) http.HandlerFunc { return s.SearchEmployeesHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLoadEmployeesHandlerValidatesBeforeImporting(t *testing.T) {
+	// The mock has no ImportEmployeesFunc: the import would panic.
+	rec := serve(&service.EmployeeAPIMock{}, http.MethodPost, "/loadEmployees", "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-13-01", "weeks": {"A": {}}}]`, func(s *Service) http.HandlerFunc { return s.LoadEmployeesHandler })
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, "employees[0].startDate", problem.InvalidParams[0].Name)
+}
+
+func TestLoadEmployeesHandlerValidateOnly(t *testing.T) {
+	body := `[{"name": "Ines", "startDate": "2024-06-03", "weeks": {"A": {}}}]`
+	// The mock has no ImportEmployeesFunc: the import would panic.
+	mock := &service.EmployeeAPIMock{
+		PreviewImportFunc: func(_ context.Context, payload []byte, upsert bool) (*service.ImportPreview, error) {
+			assert.JSONEq(t, body, string(payload))
+			assert.True(t, upsert)
+			return &service.ImportPreview{Employees: []service.ImportEntry{{Name: "Ines", Action: service.ImportCreate}}, Created: 1}, nil
+		},
+	}
+	for _, query := range []string{"?validate=true&upsert=true", "?dryRun=1&upsert=true"} {
+		rec := serve(mock, http.MethodPost, "/loadEmployees", "/loadEmployees"+query, body, func(s *Service) http.HandlerFunc { return s.LoadEmployeesHandler })
+		require.Equal(t, http.StatusOK, rec.Code, query)
+		var preview service.ImportPreview
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+		assert.Equal(t, 1, preview.Created)
+	}
+
+	rec := serve(&service.EmployeeAPIMock{}, http.MethodPost, "/loadEmployees", "/loadEmployees?validate=yes", body, func(s *Service) http.HandlerFunc { return s.LoadEmployeesHandler })
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	var logged string
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, requestIDHeader)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { logged = reqlog.ID(r.Context()) })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NotEmpty(t, logged)
+	assert.Equal(t, logged, rec.Header().Get("X-Request-ID"))
+
+	// The ID chosen by the client is kept.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "support-1234")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, "support-1234", rec.Header().Get("X-Request-ID"))
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"strings"
+)
+
+// internalAuth admits the requests of the sibling services, which carry one of tokens as their
+// "Authorization: Bearer <token>" header instead of a user token. Without tokens the internal routes are
+// closed.
+func internalAuth(tokens []string) func(http.Handler) http.Handler {
+	return tokenAuth(tokens, "service token")
+}
+
+// tokenAuth admits the requests carrying one of tokens as their "Authorization: Bearer <token>" header.
+// name names the kind of token in the errors.
+func tokenAuth(tokens []string, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				apierror.Write(w, r, apierror.Unauthorized("missing "+name))
+				return
+			}
+			for _, known := range tokens {
+				if known != "" && subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			apierror.Write(w, r, apierror.Unauthorized("invalid "+name))
+		})
+	}
+}
+
+// bearerToken returns the token of the "Authorization: Bearer <token>" header of r, if any.
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+// PostHourTotalsHandler returns the planned, actual and premium hours of a list of employees over a date
+// range, for the sibling services.
+func (s *Service) PostHourTotalsHandler(w http.ResponseWriter, r *http.Request) {
+	var input service.HourTotalsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	totals, err := s.EmployeeService.HourTotals(r.Context(), input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, totals)
+}
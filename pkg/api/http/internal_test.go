@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalAuth(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tc := range []struct {
+		tokens        []string
+		authorization string
+		status        int
+	}{
+		{[]string{"payroll", "planning"}, "Bearer planning", http.StatusOK},
+		{[]string{"payroll"}, "", http.StatusUnauthorized},
+		{[]string{"payroll"}, "Bearer ", http.StatusUnauthorized},
+		{[]string{"payroll"}, "payroll", http.StatusUnauthorized},
+		{[]string{"payroll"}, "Bearer user-token", http.StatusUnauthorized},
+		{nil, "Bearer payroll", http.StatusUnauthorized},
+		{[]string{""}, "Bearer ", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/prox/api/internal/hourTotals", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		rec := httptest.NewRecorder()
+		internalAuth(tc.tokens)(ok).ServeHTTP(rec, req)
+		assert.Equal(t, tc.status, rec.Code, "%v %q", tc.tokens, tc.authorization)
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+)
+
+// RequestLeaveHandler records personal leave for an employee from the JSON body {from, to, description,
+// withoutPay}.
+func (s *Service) RequestLeaveHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var input model.LeaveInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	days, err := s.EmployeeService.RequestLeave(r.Context(), employeeID, input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, days)
+}
+
+// ListLeaveHandler returns the leave days of an employee, optionally restricted to ?from=&to= (YYYY-MM-DD)
+// and ?status=.
+func (s *Service) ListLeaveHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" {
+		from = "0001-01-01"
+	}
+	if to == "" {
+		to = "9999-12-31"
+	}
+	start, end, err := service.ParseDateRange(from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	days, err := s.EmployeeService.ListLeave(r.Context(), employeeID, start, end, q.Get("status"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, days)
+}
+
+// CancelLeaveHandler removes the leave days of an employee from ?from= to ?to= (YYYY-MM-DD, default from).
+func (s *Service) CancelLeaveHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" {
+		apierror.Write(w, r, apierror.Validation("from is required, expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	if to == "" {
+		to = from
+	}
+	start, end, err := service.ParseDateRange(from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	deleted, err := s.EmployeeService.CancelLeave(r.Context(), employeeID, start, end)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// ApproveLeaveHandler approves the pending leave request a leave day belongs to.
+func (s *Service) ApproveLeaveHandler(w http.ResponseWriter, r *http.Request) {
+	s.decideLeave(w, r, s.EmployeeService.ApproveLeave)
+}
+
+// RejectLeaveHandler rejects the pending leave request a leave day belongs to.
+func (s *Service) RejectLeaveHandler(w http.ResponseWriter, r *http.Request) {
+	s.decideLeave(w, r, s.EmployeeService.RejectLeave)
+}
+
+func (s *Service) decideLeave(w http.ResponseWriter, r *http.Request,
+	decide func(ctx context.Context, id uint, approverID *uint) ([]model.EmployeeHoliday, error)) {
+	id, err := s.idParam(r, "id", &model.EmployeeHoliday{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var approverID *uint
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		approverID = &claims.UserID
+	}
+	days, err := decide(r.Context(), id, approverID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, days)
+}
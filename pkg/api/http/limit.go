@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lichensio/api_server/pkg/api/apierror"
+)
+
+// Limit caps the number of expensive requests (exports, reports, all-employee views) served at once so
+// that a burst of them cannot starve the cheap endpoints of database connections.
+type Limit struct {
+	// Concurrency is the number of requests served at once; zero disables the limit.
+	Concurrency int
+	// Queue is the number of requests allowed to wait for a free slot. Requests beyond it are rejected
+	// with 503 Service Unavailable and a Retry-After header.
+	Queue int
+	// RetryAfter is the delay suggested to rejected clients.
+	RetryAfter time.Duration
+}
+
+// limiter enforces a Limit. A request first takes a place in the queue, then waits for a slot; it gives
+// up when the client goes away.
+type limiter struct {
+	slots      chan struct{}
+	places     chan struct{}
+	retryAfter time.Duration
+}
+
+func newLimiter(limit Limit) *limiter {
+	retryAfter := limit.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	return &limiter{
+		slots:      make(chan struct{}, limit.Concurrency),
+		places:     make(chan struct{}, limit.Concurrency+limit.Queue),
+		retryAfter: retryAfter,
+	}
+}
+
+// limit returns a middleware applying limit to the routes it wraps. All of them share the same slots.
+func limit(limit Limit) func(http.Handler) http.Handler {
+	if limit.Concurrency <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	l := newLimiter(limit)
+	return l.middleware
+}
+
+func (l *limiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case l.places <- struct{}{}:
+		default:
+			l.reject(w, r)
+			return
+		}
+		defer func() { <-l.places }()
+
+		select {
+		case l.slots <- struct{}{}:
+		case <-r.Context().Done():
+			return
+		}
+		defer func() { <-l.slots }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *limiter) reject(w http.ResponseWriter, r *http.Request) {
+	seconds := int((l.retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	apierror.Write(w, r, apierror.Unavailable("too many expensive requests in progress, retry later", nil).WithCode(apierror.CodeServerBusy))
+}
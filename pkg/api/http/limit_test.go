@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitRejectsBeyondQueue(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := limit(Limit{Concurrency: 1, Queue: 0, RetryAfter: 1500 * time.Millisecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/schedule/export", nil))
+		done <- rec.Code
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/schedule/export", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":"SERVER_BUSY"`)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/validate"
+	"io"
+	"net/http"
+	"time"
+)
+
+type lintInput struct {
+	// Draft, in the /loadEmployees format, is analyzed instead of the stored calendars when given.
+	Draft []model.EmployeeInput `json:"draft"`
+	// From (YYYY-MM-DD, default today) is in the first week the date-based rules are checked on.
+	From string `json:"from"`
+}
+
+// LintSchedulesHandler analyzes the weekly templates of the team, or the draft of the JSON body
+// {draft, from}, and returns suggestions each tagged with a rule ID. The body is optional.
+func (s *Service) LintSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	var input lintInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	if err := validate.Check("draft", input.Draft); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	from := time.Now().In(s.EmployeeService.TimeZone(""))
+	if input.From != "" {
+		var err error
+		if from, err = time.Parse("2006-01-02", input.From); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid from "+input.From+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+	}
+	report, err := s.EmployeeService.LintSchedules(r.Context(), input.Draft, from)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
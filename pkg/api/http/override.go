@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+)
+
+// ListScheduleOverridesHandler returns the schedule overrides of an employee, optionally restricted to
+// ?from=&to= (YYYY-MM-DD).
+func (s *Service) ListScheduleOverridesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" {
+		from = "0001-01-01"
+	}
+	if to == "" {
+		to = "9999-12-31"
+	}
+	start, end, err := service.ParseDateRange(from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	overrides, err := s.EmployeeService.ListScheduleOverrides(r.Context(), employeeID, start, end)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+// PutScheduleOverrideHandler sets the override of an employee on {date} from the JSON body {off, reason,
+// slots}.
+func (s *Service) PutScheduleOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var override model.ScheduleOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	saved, err := s.EmployeeService.SetScheduleOverride(r.Context(), employeeID, chi.URLParam(r, "date"), override)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteScheduleOverrideHandler restores the A/B slots of an employee on {date}.
+func (s *Service) DeleteScheduleOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteScheduleOverride(r.Context(), employeeID, chi.URLParam(r, "date")); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+)
+
+// GetOvertimeHandler compares the hours scheduled for an employee with its contract, week by week over the
+// month given by ?month= and ?year=, with the monthly balance.
+func (s *Service) GetOvertimeHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	report, err := s.EmployeeService.EmployeeOvertime(r.Context(), employeeID, month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
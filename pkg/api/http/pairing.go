@@ -0,0 +1,68 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"time"
+)
+
+// ListPairingRulesHandler returns every pairing rule.
+func (s *Service) ListPairingRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.EmployeeService.ListPairingRules(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// CreatePairingRuleHandler creates a pairing rule from the JSON body.
+func (s *Service) CreatePairingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule model.PairingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreatePairingRule(r.Context(), rule)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// DeletePairingRuleHandler removes a pairing rule.
+func (s *Service) DeletePairingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.PairingRule{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeletePairingRule(r.Context(), id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPairingViolationsHandler lists the slots breaking a pairing rule during the weeks covering every
+// combination of rotation weeks (two for A/B), starting with the week of ?week= (YYYY-MM-DD, default the
+// current week).
+func (s *Service) GetPairingViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	from := time.Now().In(s.EmployeeService.TimeZone(""))
+	if value := r.URL.Query().Get("week"); value != "" {
+		var err error
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid week "+value+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+	}
+	violations, err := s.EmployeeService.PairingViolations(r.Context(), from)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, violations)
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// PostPrintHandler queues the generation of an export from the JSON body {kind, format, month, year,
+// callbackUrl} and answers 202 Accepted with the job, to be polled with GetPrintHandler.
+func (s *Service) PostPrintHandler(w http.ResponseWriter, r *http.Request) {
+	var input service.PrintInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	job, err := s.EmployeeService.RequestPrint(r.Context(), input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/prox/api/prints/%d", job.ID))
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetPrintHandler returns a print job with its status, and a signed download link once it is done.
+func (s *Service) GetPrintHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.PrintJob{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	job, err := s.EmployeeService.FindPrintJob(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// DownloadPrintHandler returns the artifact of a print job given the ?expires=&signature= of its signed
+// download link, which stands for the bearer token.
+func (s *Service) DownloadPrintHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	job, artifact, err := s.EmployeeService.OpenPrint(r.Context(), chi.URLParam(r, "uuid"), q.Get("expires"), q.Get("signature"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	defer artifact.Close()
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, job.FileName))
+	w.Header().Set("Content-Length", strconv.FormatInt(job.Size, 10))
+	if _, err := io.Copy(w, artifact); err != nil {
+		reqlog.From(r.Context()).Errorf("Failed to send the artifact of print job %d: %v", job.ID, err)
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lichensio/api_server/pkg/api/apierror"
+)
+
+// readOnlyAllowed lists the routes that do not write although their method is not GET. The GET routes write
+// nothing: the calendars serve the public holidays they fetch without storing them, see
+// service.EmployeeService.UseReadOnly.
+var readOnlyAllowed = map[string]bool{
+	"POST /prox/api/auth/login":          true,
+	"POST /prox/api/internal/hourTotals": true,
+	"POST /prox/api/graphql":             true,
+}
+
+// readOnly rejects every request that could write to the database with 503 Service Unavailable when
+// enabled. It is meant for replicas pointed at a read-only database, which serve calendar reads only.
+func readOnly(enabled bool) func(http.Handler) http.Handler {
+	if !enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method := r.Method
+			if method == http.MethodHead {
+				method = http.MethodGet
+			}
+			allowed, listed := readOnlyAllowed[method+" "+strings.TrimSuffix(r.URL.Path, "/")]
+			if !listed {
+				allowed = method == http.MethodGet || method == http.MethodOptions
+			}
+			if !allowed {
+				apierror.Write(w, r, apierror.Unavailable("this instance is read-only, send writes to the primary", nil).WithCode(apierror.CodeReadOnly))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
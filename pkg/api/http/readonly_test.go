@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := readOnly(true)(ok)
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/prox/api/getMonthlySchedule", http.StatusOK},
+		{http.MethodHead, "/prox/api/getEmployees", http.StatusOK},
+		{http.MethodPost, "/prox/api/auth/login", http.StatusOK},
+		{http.MethodPost, "/prox/api/loadEmployees", http.StatusServiceUnavailable},
+		{http.MethodPut, "/prox/api/schedules/1", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/prox/api/employees/1/", http.StatusServiceUnavailable},
+		{http.MethodPost, "/prox/api/admin/db/migrate", http.StatusServiceUnavailable},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, rec.Code, "%s %s", tc.method, tc.path)
+		if tc.status != http.StatusOK {
+			assert.Contains(t, rec.Body.String(), `"code":"READ_ONLY"`)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	readOnly(false)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/prox/api/loadEmployees", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"fmt"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"net/http"
+)
+
+// holidayRegion sets the holiday region the calendars of the request are built for: the ?region= query
+// parameter when given, one of the regions of holidays, for the customers with stores under several regimes,
+// else the region of the tenant, else defaultRegion. An empty defaultRegion leaves the default region of
+// holidays, the French government API when nil.
+func holidayRegion(defaultRegion string, holidays holiday.Provider) func(http.Handler) http.Handler {
+	if holidays == nil {
+		holidays = holiday.Gouv{}
+	}
+	if defaultRegion == "" {
+		defaultRegion = holidays.DefaultRegion()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if region := r.URL.Query().Get("region"); region != "" {
+				if !holidays.IsRegion(region) {
+					apierror.Write(w, r, apierror.Validation(fmt.Sprintf("unknown holiday region %q", region)).WithCode(apierror.CodeRegionInvalid))
+					return
+				}
+				ctx = repo.WithHolidayRegion(ctx, region)
+			} else if _, ok := repo.HolidayRegionFromContext(ctx); !ok && defaultRegion != "" {
+				ctx = repo.WithHolidayRegion(ctx, defaultRegion)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// reportContext derives the context report generation runs under, bounded by ReportTimeout.
+func (s *Service) reportContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if s.ReportTimeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), s.ReportTimeout)
+}
+
+// writeReport sends a generated report. A report cut short by ReportTimeout is still sent, marked partial;
+// nothing is written when the client went away.
+func writeReport(w http.ResponseWriter, r *http.Request, report interface{}, partial bool, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, report)
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		reqlog.From(r.Context()).Infof("Report generation cancelled by client on %s", r.URL.Path)
+	case errors.Is(err, context.DeadlineExceeded) && partial:
+		reqlog.From(r.Context()).Warnf("Report generation timed out on %s, returning partial result", r.URL.Path)
+		writeJSON(w, http.StatusOK, report)
+	default:
+		apierror.Write(w, r, err)
+	}
+}
+
+// GetCapacityReportHandler returns the weekly capacity plan of the quarter given as ?quarter=YYYY-Qn.
+func (s *Service) GetCapacityReportHandler(w http.ResponseWriter, r *http.Request) {
+	year, quarter, err := util.ParseQuarter(r.URL.Query().Get("quarter"))
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(err.Error()).WithCode(apierror.CodeQuarterInvalid))
+		return
+	}
+	ctx, cancel := s.reportContext(r)
+	defer cancel()
+	report, err := s.EmployeeService.CapacityReport(ctx, year, quarter)
+	writeReport(w, r, report, report != nil && report.Partial, err)
+}
+
+// GetStationCoverageHandler returns the per-station coverage of the month given as ?month=&year=.
+func (s *Service) GetStationCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	ctx, cancel := s.reportContext(r)
+	defer cancel()
+	report, err := s.EmployeeService.StationCoverage(ctx, month, year)
+	writeReport(w, r, report, report != nil && report.Partial, err)
+}
+
+// GetVarianceReportHandler returns the planned against clocked hours of the month given as ?month=&year=.
+func (s *Service) GetVarianceReportHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	ctx, cancel := s.reportContext(r)
+	defer cancel()
+	report, err := s.EmployeeService.VarianceReport(ctx, month, year)
+	writeReport(w, r, report, report != nil && report.Partial, err)
+}
+
+type forecastInput struct {
+	Department  string  `json:"department"`
+	WeekStart   string  `json:"weekStart"`
+	RequiredFTE float64 `json:"requiredFte"`
+}
+
+// PostForecastsHandler stores the demand forecasts used by the capacity report.
+func (s *Service) PostForecastsHandler(w http.ResponseWriter, r *http.Request) {
+	var input []forecastInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	forecasts := make([]model.DemandForecast, 0, len(input))
+	for _, in := range input {
+		weekStart, err := time.Parse("2006-01-02", in.WeekStart)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid weekStart "+in.WeekStart+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+		forecasts = append(forecasts, model.DemandForecast{
+			Department:  in.Department,
+			WeekStart:   weekStart,
+			RequiredFTE: in.RequiredFTE,
+		})
+	}
+	if err := s.EmployeeService.SaveForecasts(r.Context(), forecasts); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"saved": len(forecasts)})
+}
+
+type revenueInput struct {
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+}
+
+// PostRevenuesHandler stores the daily revenues used by the KPI report, given as [{date, amount}].
+func (s *Service) PostRevenuesHandler(w http.ResponseWriter, r *http.Request) {
+	var input []revenueInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	revenues := make([]model.DailyRevenue, 0, len(input))
+	for _, in := range input {
+		date, err := time.Parse("2006-01-02", in.Date)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid date "+in.Date+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+		revenues = append(revenues, model.DailyRevenue{Date: date, Amount: in.Amount})
+	}
+	if err := s.EmployeeService.SaveRevenues(r.Context(), revenues); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"saved": len(revenues)})
+}
+
+// GetKPIReportHandler returns the planned hours against revenue of the month given as ?month=&year=. With
+// ?hourlyCost=, the loaded cost of an hour of work, it also returns labor costs and cost ratios.
+func (s *Service) GetKPIReportHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var hourlyCost *float64
+	if value := r.URL.Query().Get("hourlyCost"); value != "" {
+		cost, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid hourlyCost "+value))
+			return
+		}
+		hourlyCost = &cost
+	}
+	ctx, cancel := s.reportContext(r)
+	defer cancel()
+	report, err := s.EmployeeService.KPIReport(ctx, month, year, hourlyCost)
+	writeReport(w, r, report, false, err)
+}
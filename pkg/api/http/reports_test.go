@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowCapacity is a capacity report completing one week, then waiting for its context to be done.
+func slowCapacity(ctx context.Context, year, quarter int) (*service.CapacityReport, error) {
+	report := &service.CapacityReport{Quarter: "2024-Q2", Weeks: []service.CapacityWeek{{WeekStart: "2024-04-01"}}}
+	<-ctx.Done()
+	report.Partial = true
+	return report, ctx.Err()
+}
+
+func TestCapacityReportTimeoutReturnsPartial(t *testing.T) {
+	svc := &Service{EmployeeService: &service.EmployeeAPIMock{CapacityReportFunc: slowCapacity}, ReportTimeout: 20 * time.Millisecond}
+	r := chi.NewRouter()
+	r.Get("/reports/capacity", svc.GetCapacityReportHandler)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/capacity?quarter=2024-Q2", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report service.CapacityReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.True(t, report.Partial)
+	assert.Len(t, report.Weeks, 1)
+}
+
+func TestCapacityReportClientGoneWritesNothing(t *testing.T) {
+	svc := &Service{EmployeeService: &service.EmployeeAPIMock{CapacityReportFunc: slowCapacity}, ReportTimeout: time.Minute}
+	r := chi.NewRouter()
+	r.Get("/reports/capacity", svc.GetCapacityReportHandler)
+	ctx, hangUp := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, hangUp)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/capacity?quarter=2024-Q2", nil).WithContext(ctx))
+
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Header())
+	assert.Zero(t, rec.Body.Len())
+}
+
+func TestWriteReport(t *testing.T) {
+	report := &service.StationCoverageReport{Month: "June", Year: 2024, Partial: true}
+	timedOut := httptest.NewRequest(http.MethodGet, "/reports/stations", nil)
+	rec := httptest.NewRecorder()
+	writeReport(rec, timedOut, report, true, context.DeadlineExceeded)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"partial":true`)
+
+	// A timeout before anything was built is still an error.
+	rec = httptest.NewRecorder()
+	writeReport(rec, timedOut, nil, false, context.DeadlineExceeded)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	// Cancelled while the client is still there: the service gave up on its own.
+	rec = httptest.NewRecorder()
+	writeReport(rec, timedOut, report, true, context.Canceled)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	writeReport(rec, timedOut.WithContext(ctx), report, true, context.Canceled)
+	assert.Zero(t, rec.Body.Len(), "nothing is written to a client that went away")
+}
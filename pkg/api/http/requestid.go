@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"net/http"
+)
+
+// requestIDHeader returns the ID given to the request by middleware.RequestID in the X-Request-ID header,
+// for the clients to quote it when they report a problem. A client may also choose the ID by sending the
+// header with its request.
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := reqlog.ID(r.Context()); id != "" {
+			w.Header().Set("X-Request-ID", id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
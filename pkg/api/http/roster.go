@@ -0,0 +1,68 @@
+package http
+
+import (
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"time"
+)
+
+// GetRosterHandler returns the monthly calendar of every employee given ?month=&year=, optionally restricted
+// to the slots worked at ?location=. With ?groupBy=day the calendars are regrouped date by date, every date
+// listing the day of each employee, for team calendars.
+func (s *Service) GetRosterHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	groupBy := r.URL.Query().Get("groupBy")
+	if groupBy != "" && groupBy != "employee" && groupBy != "day" {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("groupBy must be employee or day, got: %s", groupBy)))
+		return
+	}
+	roster, err := s.EmployeeService.TeamRoster(r.Context(), month, year, r.URL.Query().Get("location"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if groupBy == "day" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"month": month, "year": year, "days": service.RosterByDay(roster)})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"month": month, "year": year, "employees": roster})
+}
+
+// GetCoverageHandler returns the number of employees scheduled in every 15-minute interval of ?date=
+// (YYYY-MM-DD), optionally counting only the slots worked at ?location=.
+func (s *Service) GetCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	value := r.URL.Query().Get("date")
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", value)).WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	report, err := s.EmployeeService.DailyCoverage(r.Context(), date, r.URL.Query().Get("location"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GetHoursSummaryHandler returns, for every employee, the hours scheduled in the month given as ?month=&year=,
+// the hours worked on public holidays and the hours lost to approved leave.
+func (s *Service) GetHoursSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	summary, err := s.EmployeeService.MonthlyHoursSummary(r.Context(), month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"month": month, "year": year, "employees": summary})
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"time"
+)
+
+// ListRotationPatternsHandler returns every rotation pattern with its weeks.
+func (s *Service) ListRotationPatternsHandler(w http.ResponseWriter, r *http.Request) {
+	patterns, err := s.EmployeeService.ListRotationPatterns(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, patterns)
+}
+
+// CreateRotationPatternHandler creates a rotation pattern from the JSON body {name, weeks: [{name}, ...]},
+// the weeks in cycle order.
+func (s *Service) CreateRotationPatternHandler(w http.ResponseWriter, r *http.Request) {
+	var pattern model.RotationPattern
+	if err := json.NewDecoder(r.Body).Decode(&pattern); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateRotationPattern(r.Context(), pattern)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// AssignRotationHandler makes an employee follow a rotation pattern from the JSON body {rotationPatternId,
+// anchor}; a null rotationPatternId restores the A/B rotation. The week of anchor (YYYY-MM-DD, default the
+// employee's start date) is the first week of the rotation.
+func (s *Service) AssignRotationHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var body struct {
+		RotationPatternID *uint  `json:"rotationPatternId"`
+		Anchor            string `json:"anchor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	var anchor *time.Time
+	if body.Anchor != "" {
+		date, err := time.Parse("2006-01-02", body.Anchor)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid anchor "+body.Anchor+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+		anchor = &date
+	}
+	if err := s.EmployeeService.AssignRotation(r.Context(), employeeID, body.RotationPatternID, anchor); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRotationCalendarHandler returns the rotation calendar of the tenant with the week of rotation ?date=
+// (YYYY-MM-DD, default today) falls in, for the header of the calendars.
+func (s *Service) GetRotationCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	on := time.Now().In(s.EmployeeService.TimeZone(""))
+	if value := r.URL.Query().Get("date"); value != "" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation("invalid date "+value+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+			return
+		}
+		on = date
+	}
+	calendar, err := s.EmployeeService.GetRotationCalendar(r.Context(), on)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, calendar)
+}
+
+// SetRotationCalendarHandler anchors the weeks of rotation of the tenant from the JSON body {anchor}: the
+// week of anchor (YYYY-MM-DD) is the first week of every cycle, for the employees without an anchor of
+// their own.
+func (s *Service) SetRotationCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Anchor string `json:"anchor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	anchor, err := time.Parse("2006-01-02", body.Anchor)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation("invalid anchor "+body.Anchor+", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	calendar, err := s.EmployeeService.SetRotationCalendar(r.Context(), anchor)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, calendar)
+}
@@ -3,29 +3,201 @@ package http
 import (
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/tenant"
+	"net/http"
 )
 
 func NewRouter(svc *Service) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(requestIDHeader)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.StripSlashes)
+	r.Use(noStore)
+	r.Use(readOnly(svc.ReadOnly))
+
+	r.Get("/readyz", svc.ReadyzHandler)
+
+	// Expensive endpoints share a bounded number of slots so they cannot starve the others.
+	heavy := limit(svc.HeavyLimit)
+	// In a multi-tenant deployment, users log in and work within the tenant the request is resolved to.
+	scoped := tenantScope(svc.Tenants)
+	// Public holidays follow the region of the request, its tenant or the deployment.
+	region := holidayRegion(svc.HolidayRegion, svc.Holidays)
+	// Requests are cancelled once out of their time budget: short for CRUD, long for exports and reports.
+	quick, slow := deadline(svc.Timeouts.Default), deadline(svc.Timeouts.Heavy)
 
 	r.Route("/prox/api", func(r chi.Router) {
-		r.Post("/loadEmployees", svc.LoadEmployeesHandler)
-		r.Get("/db/create", svc.DBCreateHandler)
-		r.Delete("/db/delete", svc.DBDeleteHandler)
-		r.Get("/getMonthlySchedule", svc.GetMonthlySchedule2Handler)
-		r.Get("/getEmployees", svc.GetEmployeesHandler)
-		r.Get("/getWeeksAB/{ID}", svc.GetWeeksABHandler)
-		r.Get("/getMonthlyHours", svc.GetMonthlyHours2Handler)
-		// r.Put("/updateEmployees", svc.UpdateEmployees)
-		// r.Put("/updateSchedule", svc.UpdateSchedule)
-		// r.Get("/getSchedule/{employeeID}", svc.GetSchedule)
-		// r.Get("/getEmployees", svc.GetEmployees)
-		// r.Get("/getCalendar/{year}/{month}", svc.GetCalendar)
-		// r.Get("/analytics", svc.GetAnalytics)
+		r.With(quick, scoped).Post("/auth/login", svc.Auth.LoginHandler)
+		r.Get("/errors/catalog", apierror.CatalogHandler)
+		// Download links are signed: the link itself grants access to the artifact until it expires.
+		r.With(slow).Get("/prints/{uuid}/download", svc.DownloadPrintHandler)
+
+		// The sibling services authenticate with a service token rather than as a user.
+		r.With(scoped, internalAuth(svc.InternalTokens), region, heavy, slow).Post("/internal/hourTotals", svc.PostHourTotalsHandler)
+
+		// Schema operations are reserved to the operators of the deployment, who authenticate with an admin
+		// token: no user can drop the tables of every tenant.
+		r.Route("/admin/db", func(r chi.Router) {
+			r.Use(adminAuth(svc.AdminTokens), slow)
+			r.Post("/migrate", svc.MigrateHandler)
+			r.Delete("/", svc.DropSchemaHandler)
+		})
+		// So are the support bundles, whose row counts and logs span every tenant.
+		r.With(adminAuth(svc.AdminTokens), slow).Get("/admin/support-bundle", svc.SupportBundleHandler)
+		// And the views and controls of the instance: its dependency checks, imports and calendar cache. The
+		// imports and the cache invalidation work within the tenant the request is resolved to.
+		r.With(adminAuth(svc.AdminTokens), quick).Get("/admin/diagnostics", svc.DiagnosticsHandler)
+		r.With(adminAuth(svc.AdminTokens), quick).Get("/admin/cache/stats", svc.CalendarCacheStatsHandler)
+		r.With(scoped, adminAuth(svc.AdminTokens), quick).Get("/admin/imports", svc.ImportProgressHandler)
+		r.With(scoped, adminAuth(svc.AdminTokens), quick).Post("/admin/cache/invalidate", svc.InvalidateCacheHandler)
+
+		// Every other route requires a valid bearer token.
+		r.Group(func(r chi.Router) {
+			r.Use(scoped)
+			r.Use(svc.Auth.Middleware)
+			r.Use(region)
+
+			// The event stream stays open as long as the client listens: it has no time budget.
+			r.Get("/events", svc.EventsHandler)
+
+			// Exports, reports and all-employee views share the heavy slots and get the long time budget.
+			r.Group(func(r chi.Router) {
+				r.Use(heavy, slow)
+				r.Get("/schedule/export", svc.ExportSchedulesHandler)
+				r.Post("/schedule/lint", svc.LintSchedulesHandler)
+				r.Get("/payroll/export", svc.ExportPayrollHandler)
+				r.Get("/employees/changes", svc.GetEmployeeChangesHandler)
+				r.Get("/roster", svc.GetRosterHandler)
+				r.Get("/hours", svc.GetHoursSummaryHandler)
+				r.Get("/reports/capacity", svc.GetCapacityReportHandler)
+				r.Get("/reports/stations", svc.GetStationCoverageHandler)
+				r.Get("/reports/kpi", svc.GetKPIReportHandler)
+				r.Get("/reports/variance", svc.GetVarianceReportHandler)
+				r.Get("/pairing-rules/violations", svc.GetPairingViolationsHandler)
+				r.Get("/coverage/gaps", svc.GetCoverageGapsHandler)
+				r.Post("/roster/suggest", svc.SuggestAssignmentsHandler)
+				r.Post("/holidays/prefetch", svc.PrefetchHolidaysHandler)
+				r.Get("/graphql", svc.GraphQLHandler)
+				r.Post("/graphql", svc.GraphQLHandler)
+			})
+
+			// The other routes are cheap and get the short time budget.
+			r.Group(func(r chi.Router) {
+				r.Use(quick)
+				r.Post("/loadEmployees", svc.LoadEmployeesHandler)
+				r.Post("/loadEmployees/xlsx", svc.LoadEmployeesXLSXHandler)
+				r.Post("/loadEmployees/csv", svc.LoadEmployeesCSVHandler)
+				r.Get("/imports", svc.ListImportsHandler)
+				r.Post("/imports/{id}/rollback", svc.RollbackImportHandler)
+				r.Get("/db/migrations/status", svc.MigrationStatusHandler)
+				r.Get("/getMonthlySchedule", svc.GetMonthlySchedule2Handler)
+				r.Get("/getEmployees", svc.GetEmployeesHandler)
+				r.Get("/graphql/schema", svc.GraphQLSchemaHandler)
+				r.Get("/getWeeksAB/{ID}", svc.GetWeeksABHandler)
+				r.Get("/getMonthlyHours", svc.GetMonthlyHours2Handler)
+				r.Get("/schedules/{id}", svc.GetScheduleHandler)
+				r.Put("/schedules/{id}", svc.UpdateScheduleHandler)
+				r.Patch("/schedules/{id}", svc.PatchScheduleTaskHandler)
+				r.Delete("/schedules/{id}", svc.DeleteScheduleHandler)
+				r.Get("/employees/archived", svc.GetArchivedEmployeesHandler)
+				r.Post("/employees/deactivate", svc.DeactivateEmployeesHandler)
+				r.Get("/employees/search", svc.SearchEmployeesHandler)
+				r.Get("/employees/{id}", svc.GetEmployeeHandler)
+				r.Patch("/employees/{id}", svc.PatchEmployeeHandler)
+				r.Delete("/employees/{id}", svc.ArchiveEmployeeHandler)
+				r.Post("/employees/{id}/restore", svc.RestoreEmployeeHandler)
+				r.Get("/employees/{id}/conflicts", svc.GetConflictsHandler)
+				r.Get("/employees/{id}/overtime", svc.GetOvertimeHandler)
+				r.Get("/employees/{id}/violations", svc.GetEmployeeViolationsHandler)
+				r.Get("/employees/{id}/schedule", svc.GetScheduleRangeHandler)
+				r.Get("/employees/{id}/schedules", svc.ListEmployeeSchedulesHandler)
+				r.Put("/employees/{id}/schedules", svc.ReplaceEmployeeSchedulesHandler)
+				r.Delete("/employees/{id}/schedules", svc.DeleteEmployeeSchedulesHandler)
+				r.Post("/employees/{id}/weeks/copy", svc.CopyWeekHandler)
+				r.Post("/employees/{id}/pattern/copyTo/{targetId}", svc.CopyPatternHandler)
+				r.Get("/employees/{id}/schedule-versions", svc.GetScheduleHistoryHandler)
+				r.Get("/employees/{id}/schedules/{scheduleID}", svc.GetEmployeeScheduleHandler)
+				r.Put("/employees/{id}/schedules/{scheduleID}", svc.UpdateEmployeeScheduleHandler)
+				r.Patch("/employees/{id}/schedules/{scheduleID}", svc.PatchEmployeeScheduleHandler)
+				r.Delete("/employees/{id}/schedules/{scheduleID}", svc.DeleteEmployeeScheduleHandler)
+				r.Get("/employees/{id}/google-calendar", svc.GetGoogleCalendarHandler)
+				r.Put("/employees/{id}/google-calendar", svc.LinkGoogleCalendarHandler)
+				r.Delete("/employees/{id}/google-calendar", svc.UnlinkGoogleCalendarHandler)
+				r.Post("/employees/{id}/google-calendar/sync", svc.SyncGoogleCalendarHandler)
+				r.Get("/coverage", svc.GetCoverageHandler)
+				r.Get("/dashboard", svc.GetDashboardHandler)
+				r.Post("/reports/capacity/forecasts", svc.PostForecastsHandler)
+				r.Post("/reports/kpi/revenues", svc.PostRevenuesHandler)
+				r.Get("/audit/slots", svc.GetSlotAuditHandler)
+				r.Get("/role-templates", svc.ListRoleTemplatesHandler)
+				r.Post("/role-templates", svc.CreateRoleTemplateHandler)
+				r.Put("/role-templates/{id}", svc.UpdateRoleTemplateHandler)
+				r.Put("/employees/{id}/role-template", svc.AssignRoleTemplateHandler)
+				r.Get("/rotation", svc.GetRotationCalendarHandler)
+				r.Put("/rotation", svc.SetRotationCalendarHandler)
+				r.Get("/validation", svc.GetValidationHandler)
+				r.Put("/validation", svc.SetValidationHandler)
+				r.Get("/rotation-patterns", svc.ListRotationPatternsHandler)
+				r.Post("/rotation-patterns", svc.CreateRotationPatternHandler)
+				r.Put("/employees/{id}/rotation-pattern", svc.AssignRotationHandler)
+				r.Get("/employees/{id}/deltas", svc.ListScheduleDeltasHandler)
+				r.Post("/employees/{id}/deltas", svc.CreateScheduleDeltaHandler)
+				r.Delete("/employees/{id}/deltas/{deltaID}", svc.DeleteScheduleDeltaHandler)
+				r.Get("/employees/{id}/leave", svc.ListLeaveHandler)
+				r.Post("/employees/{id}/leave", svc.RequestLeaveHandler)
+				r.Delete("/employees/{id}/leave", svc.CancelLeaveHandler)
+				r.Get("/employees/{id}/unavailability", svc.ListUnavailabilitiesHandler)
+				r.Post("/employees/{id}/unavailability", svc.CreateUnavailabilityHandler)
+				r.Get("/employees/{id}/unavailability/conflicts", svc.GetUnavailabilityConflictsHandler)
+				r.Put("/employees/{id}/unavailability/{unavailabilityID}", svc.UpdateUnavailabilityHandler)
+				r.Delete("/employees/{id}/unavailability/{unavailabilityID}", svc.DeleteUnavailabilityHandler)
+				r.Get("/employees/{id}/overrides", svc.ListScheduleOverridesHandler)
+				r.Put("/employees/{id}/overrides/{date}", svc.PutScheduleOverrideHandler)
+				r.Delete("/employees/{id}/overrides/{date}", svc.DeleteScheduleOverrideHandler)
+				r.Patch("/leave/{id}/approve", svc.ApproveLeaveHandler)
+				r.Patch("/leave/{id}/reject", svc.RejectLeaveHandler)
+				r.Get("/pairing-rules", svc.ListPairingRulesHandler)
+				r.Post("/pairing-rules", svc.CreatePairingRuleHandler)
+				r.Delete("/pairing-rules/{id}", svc.DeletePairingRuleHandler)
+				r.Get("/staffing-requirements", svc.ListStaffingRequirementsHandler)
+				r.Post("/staffing-requirements", svc.CreateStaffingRequirementHandler)
+				r.Delete("/staffing-requirements/{id}", svc.DeleteStaffingRequirementHandler)
+				r.Get("/closures", svc.ListClosureDaysHandler)
+				r.Post("/closures", svc.CreateClosureDayHandler)
+				r.Put("/closures/{id}", svc.UpdateClosureDayHandler)
+				r.Delete("/closures/{id}", svc.DeleteClosureDayHandler)
+				r.Post("/roster/suggest/accept", svc.AcceptSuggestionHandler)
+				r.Post("/timeclock/punches", svc.PunchHandler)
+				r.Get("/employees/{id}/punches", svc.ListTimeEntriesHandler)
+				r.Get("/webhooks", svc.ListWebhooksHandler)
+				r.Post("/webhooks", svc.CreateWebhookHandler)
+				r.Put("/webhooks/{id}", svc.UpdateWebhookHandler)
+				r.Delete("/webhooks/{id}", svc.DeleteWebhookHandler)
+				r.Post("/webhooks/{id}/test", svc.TestWebhookHandler)
+				r.Get("/webhooks/{id}/deliveries", svc.ListWebhookDeliveriesHandler)
+				r.Post("/webhooks/{id}/secret", svc.RotateWebhookSecretHandler)
+				r.Post("/notify/slack/today", svc.NotifySlackTodayHandler)
+				r.Post("/prints", svc.PostPrintHandler)
+				r.Get("/prints/{id}", svc.GetPrintHandler)
+				// r.Put("/updateEmployees", svc.UpdateEmployees)
+				// r.Get("/getSchedule/{employeeID}", svc.GetSchedule)
+				// r.Get("/getEmployees", svc.GetEmployees)
+				// r.Get("/getCalendar/{year}/{month}", svc.GetCalendar)
+				// r.Get("/analytics", svc.GetAnalytics)
+			})
+		})
 	})
 
 	return r
 }
+
+// tenantScope confines the requests to their tenant, or lets them through unchanged without a resolver.
+func tenantScope(resolver *tenant.Resolver) func(http.Handler) http.Handler {
+	if resolver == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return resolver.Middleware
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"time"
+)
+
+// scheduleFilter reads the ?weekType= and ?day= filters of the own slots of an employee.
+func scheduleFilter(r *http.Request) service.ScheduleFilter {
+	q := r.URL.Query()
+	return service.ScheduleFilter{WeekType: q.Get("weekType"), DayName: q.Get("day")}
+}
+
+// effectiveFromParam reads the ?effectiveFrom= date (YYYY-MM-DD) a change of the slots of an employee applies
+// from, zero without it: the slots changed stay in force until the day before, kept as a schedule version.
+func effectiveFromParam(r *http.Request) (time.Time, error) {
+	value := r.URL.Query().Get("effectiveFrom")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	effectiveFrom, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, apierror.Validation("invalid effectiveFrom " + value + ", expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid)
+	}
+	return effectiveFrom, nil
+}
+
+// employeeSlotParams reads the employee and the slot identified in the path.
+func (s *Service) employeeSlotParams(r *http.Request) (uint, uint, error) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		return 0, 0, err
+	}
+	scheduleID, err := s.idParam(r, "scheduleID", &model.Schedule{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return employeeID, scheduleID, nil
+}
+
+// ListEmployeeSchedulesHandler lists the own slots of an employee, those of its role template excluded,
+// optionally only those of ?weekType= and ?day=.
+func (s *Service) ListEmployeeSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	slots, err := s.EmployeeService.ListEmployeeSchedules(r.Context(), employeeID, scheduleFilter(r))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeTagged(w, r, slots)
+}
+
+// ReplaceEmployeeSchedulesHandler replaces the own slots of an employee, or only those of ?weekType= and
+// ?day=, with the JSON array of slots of the body; an empty array removes them. With ?effectiveFrom=
+// (YYYY-MM-DD) the slots replaced stay in force until the day before, kept as a schedule version.
+func (s *Service) ReplaceEmployeeSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var slots []model.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&slots); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	written, err := s.EmployeeService.ReplaceEmployeeSchedules(r.Context(), employeeID, scheduleFilter(r), slots, effectiveFrom)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, written)
+}
+
+// CopyWeekHandler replaces the own slots of week ?to= of an employee with copies of those of week ?from=,
+// such as ?from=A&to=B, and answers the slots of week to.
+func (s *Service) CopyWeekHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	written, err := s.EmployeeService.CopyWeek(r.Context(), employeeID, q.Get("from"), q.Get("to"))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, written)
+}
+
+// CopyPatternHandler replaces the own slots of employee {targetId} with copies of the own slots of employee
+// {id}, and answers the slots of the target.
+func (s *Service) CopyPatternHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	targetID, err := s.idParam(r, "targetId", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	written, err := s.EmployeeService.CopyPattern(r.Context(), employeeID, targetID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, written)
+}
+
+// GetScheduleHistoryHandler returns the versions of the recurring pattern of an employee replaced from an
+// effective date, and the date its current slots are in force from.
+func (s *Service) GetScheduleHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	history, err := s.EmployeeService.GetScheduleHistory(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// DeleteEmployeeSchedulesHandler removes the own slots of an employee, or only those of ?weekType= and
+// ?day=, and answers how many were removed.
+func (s *Service) DeleteEmployeeSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	deleted, err := s.EmployeeService.DeleteEmployeeSchedules(r.Context(), employeeID, scheduleFilter(r))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// GetEmployeeScheduleHandler returns a slot of an employee.
+func (s *Service) GetEmployeeScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, scheduleID, err := s.employeeSlotParams(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	slot, err := s.EmployeeService.GetEmployeeSchedule(r.Context(), employeeID, scheduleID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, slot)
+}
+
+// UpdateEmployeeScheduleHandler replaces a slot of an employee with the slot of the JSON body, from
+// ?effectiveFrom= if given.
+func (s *Service) UpdateEmployeeScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, scheduleID, err := s.employeeSlotParams(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var slot model.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&slot); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.UpdateEmployeeSchedule(r.Context(), employeeID, scheduleID, slot, effectiveFrom)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// PatchEmployeeScheduleHandler changes the fields of a slot of an employee present in the JSON body, among
+// weekType, dayName, startTime, endTime, location and task, from ?effectiveFrom= if given.
+func (s *Service) PatchEmployeeScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, scheduleID, err := s.employeeSlotParams(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var patch service.SchedulePatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.PatchEmployeeSchedule(r.Context(), employeeID, scheduleID, patch, effectiveFrom)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteEmployeeScheduleHandler removes a slot of an employee, from ?effectiveFrom= if given.
+func (s *Service) DeleteEmployeeScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, scheduleID, err := s.employeeSlotParams(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	effectiveFrom, err := effectiveFromParam(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteEmployeeSchedule(r.Context(), employeeID, scheduleID, effectiveFrom); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
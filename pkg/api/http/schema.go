@@ -0,0 +1,112 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfirmHeader carries the confirmation token of a destructive schema operation.
+const ConfirmHeader = "X-Confirm-Token"
+
+// confirmTTL is how long a confirmation token stays valid.
+const confirmTTL = 5 * time.Minute
+
+// adminAuth admits the requests of the operators of the deployment, which carry one of tokens as their
+// "Authorization: Bearer <token>" header. A user token is not enough, whatever its tenant. Without tokens
+// the admin routes are closed.
+func adminAuth(tokens []string) func(http.Handler) http.Handler {
+	return tokenAuth(tokens, "admin token")
+}
+
+// logSchemaOp logs a schema operation, naming the admin token by a fingerprint rather than the token.
+func logSchemaOp(r *http.Request, operation, outcome string) {
+	token, _ := bearerToken(r)
+	fingerprint := sha256.Sum256([]byte(token))
+	reqlog.From(r.Context()).WithFields(log.Fields{
+		"operation": operation,
+		"outcome":   outcome,
+		"admin":     hex.EncodeToString(fingerprint[:4]),
+		"remote":    r.RemoteAddr,
+	}).Warn("Schema operation")
+}
+
+// MigrateHandler applies the pending schema migrations, which the primary also does when it starts.
+func (s *Service) MigrateHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.EmployeeService.DBCreate(r.Context()); err != nil {
+		logSchemaOp(r, "migrate", "failed: "+err.Error())
+		apierror.Write(w, r, err)
+		return
+	}
+	logSchemaOp(r, "migrate", "done")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "database migrated"})
+}
+
+// MigrationStatusHandler lists the schema migrations and whether each was applied to the database.
+func (s *Service) MigrationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	report, err := s.EmployeeService.MigrationStatus(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// DropSchemaHandler drops every table, in two steps: without the X-Confirm-Token header it answers
+// 428 Precondition Required with a confirmation token, valid for a few minutes with the same admin token,
+// and the request must be sent again with it.
+func (s *Service) DropSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	confirmation := r.Header.Get(ConfirmHeader)
+	if confirmation == "" {
+		expiry := time.Now().Add(confirmTTL)
+		logSchemaOp(r, "drop", "confirmation requested")
+		writeJSON(w, http.StatusPreconditionRequired, map[string]interface{}{
+			"detail":       "dropping the tables deletes every row of every tenant; send the request again with the confirmation token in the " + ConfirmHeader + " header",
+			"confirmToken": confirmToken(token, "drop", expiry.Unix()),
+			"expiresAt":    expiry.UTC().Truncate(time.Second),
+		})
+		return
+	}
+	if !validConfirmToken(token, "drop", confirmation) {
+		logSchemaOp(r, "drop", "rejected: invalid confirmation")
+		apierror.Write(w, r, apierror.Validation("the confirmation token is invalid or expired").WithCode(apierror.CodeConfirmationInvalid))
+		return
+	}
+	if err := s.EmployeeService.DBDelete(r.Context()); err != nil {
+		logSchemaOp(r, "drop", "failed: "+err.Error())
+		apierror.Write(w, r, err)
+		return
+	}
+	logSchemaOp(r, "drop", "done")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "database deleted"})
+}
+
+// confirmToken returns the token confirming operation until expiry, signed with the admin token so that
+// any instance of the deployment accepts it.
+func confirmToken(adminToken, operation string, expiry int64) string {
+	mac := hmac.New(sha256.New, []byte(adminToken))
+	fmt.Fprintf(mac, "%s:%d", operation, expiry)
+	return fmt.Sprintf("%d.%s", expiry, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// validConfirmToken reports whether token confirms operation and has not expired.
+func validConfirmToken(adminToken, operation, token string) bool {
+	expires, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(confirmToken(adminToken, operation, expiry)))
+}
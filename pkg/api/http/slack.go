@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"time"
+)
+
+// NotifySlackTodayHandler posts the roster of today to the Slack channel of the deployment, as the daily
+// reminder does every morning, and answers the message posted.
+func (s *Service) NotifySlackTodayHandler(w http.ResponseWriter, r *http.Request) {
+	if s.Slack == nil {
+		apierror.Write(w, r, apierror.Unavailable("no Slack webhook is configured", nil))
+		return
+	}
+	message, err := s.Slack.Send(r.Context(), time.Now())
+	if err != nil {
+		apierror.Write(w, r, apierror.Unavailable("the roster could not be posted to Slack", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, message)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+	"time"
+)
+
+// ListStaffingRequirementsHandler returns every staffing requirement.
+func (s *Service) ListStaffingRequirementsHandler(w http.ResponseWriter, r *http.Request) {
+	requirements, err := s.EmployeeService.ListStaffingRequirements(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, requirements)
+}
+
+// CreateStaffingRequirementHandler creates a staffing requirement from the JSON body.
+func (s *Service) CreateStaffingRequirementHandler(w http.ResponseWriter, r *http.Request) {
+	var requirement model.StaffingRequirement
+	if err := json.NewDecoder(r.Body).Decode(&requirement); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateStaffingRequirement(r.Context(), requirement)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// DeleteStaffingRequirementHandler removes a staffing requirement.
+func (s *Service) DeleteStaffingRequirementHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.StaffingRequirement{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteStaffingRequirement(r.Context(), id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCoverageGapsHandler lists the time ranges of the month given as ?month=&year= during which fewer
+// employees are scheduled than a staffing requirement asks for.
+func (s *Service) GetCoverageGapsHandler(w http.ResponseWriter, r *http.Request) {
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	gaps, err := s.EmployeeService.CoverageGaps(r.Context(), month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"month": month, "year": year, "gaps": gaps})
+}
+
+// SuggestAssignmentsHandler proposes the slots filling the coverage gaps of the days from "from" to "to"
+// (YYYY-MM-DD) given in the JSON body, each with the slots of its employee that day before and after.
+func (s *Service) SuggestAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	var period struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&period); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	from, err := time.Parse("2006-01-02", period.From)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("invalid from %q, expected YYYY-MM-DD", period.From)).WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	to, err := time.Parse("2006-01-02", period.To)
+	if err != nil {
+		apierror.Write(w, r, apierror.Validation(fmt.Sprintf("invalid to %q, expected YYYY-MM-DD", period.To)).WithCode(apierror.CodeDateInvalid))
+		return
+	}
+	suggestions, err := s.EmployeeService.SuggestAssignments(r.Context(), from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, suggestions)
+}
+
+// AcceptSuggestionHandler accepts one of the suggestions of SuggestAssignmentsHandler, sent back as the JSON
+// body, and returns the schedule override it sets.
+func (s *Service) AcceptSuggestionHandler(w http.ResponseWriter, r *http.Request) {
+	var suggestion service.RosterSuggestion
+	if err := json.NewDecoder(r.Body).Decode(&suggestion); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	override, err := s.EmployeeService.AcceptSuggestion(r.Context(), suggestion)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, override)
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"net/http"
+	"time"
+)
+
+// streamHeartbeat is how often an idle event stream sends a comment, so that the proxies in between do
+// not close it.
+const streamHeartbeat = 25 * time.Second
+
+// streamedTypes are the events of the event stream: the changes of the employees and of their schedules,
+// leave and overrides included. ChangesMissed tells the clients to reload what they show.
+var streamedTypes = map[string]bool{
+	events.EmployeeChanged: true,
+	events.ScheduleChanged: true,
+	events.ChangesMissed:   true,
+	events.EmployeeCreated: true,
+	events.ScheduleUpdated: true,
+	events.LeaveApproved:   true,
+}
+
+// EventsHandler streams the changes of the roster as Server-Sent Events, until the client goes away or the
+// server shuts down. Each event is named after its type and carries the event as its JSON data. The writes
+// of the other instances and those made by hand are only streamed with the database change feed; a client
+// that reconnects should reload, as the changes made in between are not replayed.
+func (s *Service) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierror.Write(w, r, apierror.Unavailable("the connection cannot stream events", nil))
+		return
+	}
+	tenantID, scoped := repo.TenantFromContext(r.Context())
+	stream, unsubscribe := s.EmployeeService.Events().Subscribe(64)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.Closing:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event := <-stream:
+			if !streamedTypes[event.Type] || scoped && event.TenantID != tenantID && event.TenantID != 0 {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				reqlog.From(r.Context()).Errorf("Failed to encode event %s %s for the event stream: %v", event.Type, event.ID, err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsHandlerStreamsTheChangesOfTheTenant(t *testing.T) {
+	closing := make(chan struct{})
+	svc := &Service{EmployeeService: service.NewEmployeeService(nil), Closing: closing}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		svc.EventsHandler(w, r.WithContext(repo.WithTenant(r.Context(), 1)))
+	}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// The stream starts once subscribed to the bus.
+	lines := bufio.NewScanner(resp.Body)
+	require.True(t, lines.Scan())
+	assert.Equal(t, "retry: 5000", lines.Text())
+
+	bus := svc.EmployeeService.Events()
+	other := events.New(events.ScheduleUpdated, nil)
+	other.TenantID = 2
+	own := events.New(events.ScheduleUpdated, nil)
+	own.TenantID = 1
+	bus.Publish(other)
+	bus.Publish(events.New(events.PrintCompleted, nil))
+	bus.Publish(own)
+	bus.Publish(events.New(events.ChangesMissed, nil))
+	var ids []string
+	for len(ids) < 2 && lines.Scan() {
+		if id, ok := strings.CutPrefix(lines.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	assert.Len(t, ids, 2)
+	assert.Equal(t, own.ID, ids[0], "the events of other tenants and the other types are not streamed")
+
+	close(closing)
+	for lines.Scan() {
+	}
+	assert.NoError(t, lines.Err(), "the stream ends when the server shuts down")
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/support"
+	"net/http"
+)
+
+// SupportBundleHandler downloads a support bundle of this instance, a zip archive to attach to a problem
+// report: its build and configuration, the last dependency checks, the schema version, the row counts of
+// every table and the recent warnings and errors, without the secrets nor the content of any row.
+func (s *Service) SupportBundleHandler(w http.ResponseWriter, r *http.Request) {
+	bundle := support.NewBundle()
+	bundle.Add("manifest", map[string]interface{}{
+		"generatedAt": bundle.GeneratedAt,
+		"build":       support.ReadBuild(),
+		"readOnly":    s.ReadOnly,
+		"multiTenant": s.Tenants != nil,
+	})
+	bundle.Add("config", s.Config)
+	if s.Health != nil {
+		bundle.Add("diagnostics", s.Health.LastReport())
+	}
+	if migrations, err := s.EmployeeService.MigrationStatus(r.Context()); err != nil {
+		bundle.Add("migrations", err)
+	} else {
+		bundle.Add("migrations", migrations)
+	}
+	if counts, err := s.EmployeeService.TableRowCounts(r.Context()); err != nil {
+		bundle.Add("row_counts", err)
+	} else {
+		bundle.Add("row_counts", counts)
+	}
+	if s.Logs != nil {
+		bundle.Add("logs", s.Logs.Entries())
+	}
+	var archive bytes.Buffer
+	if err := bundle.WriteZip(&archive); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="support-bundle-%s.zip"`, bundle.GeneratedAt.Format("20060102-150405")))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive.Bytes())
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+)
+
+// ListRoleTemplatesHandler returns every role template with its slots.
+func (s *Service) ListRoleTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.EmployeeService.ListRoleTemplates(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if notModified(w, r, service.RoleTemplatesLastModified(templates)) {
+		return
+	}
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// CreateRoleTemplateHandler creates a role template from the JSON body.
+func (s *Service) CreateRoleTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	var template model.RoleTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateRoleTemplate(r.Context(), template)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// UpdateRoleTemplateHandler replaces the slots of a role template. Inheriting employees follow the change
+// unless ?cascade=false is given.
+func (s *Service) UpdateRoleTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.RoleTemplate{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var body struct {
+		Slots []model.RoleTemplateSlot `json:"slots"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	cascade := r.URL.Query().Get("cascade") != "false"
+	updated, err := s.EmployeeService.UpdateRoleTemplate(r.Context(), id, body.Slots, cascade)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// AssignRoleTemplateHandler links an employee to a role template; a null roleTemplateId unlinks it.
+func (s *Service) AssignRoleTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var body struct {
+		RoleTemplateID *uint `json:"roleTemplateId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	if err := s.EmployeeService.AssignRoleTemplate(r.Context(), employeeID, body.RoleTemplateID); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListScheduleDeltasHandler returns the deviations of an employee from its role template.
+func (s *Service) ListScheduleDeltasHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	deltas, err := s.EmployeeService.ListScheduleDeltas(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deltas)
+}
+
+// CreateScheduleDeltaHandler records a deviation of an employee from its role template.
+func (s *Service) CreateScheduleDeltaHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var delta model.ScheduleDelta
+	if err := json.NewDecoder(r.Body).Decode(&delta); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.AddScheduleDelta(r.Context(), employeeID, delta)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// DeleteScheduleDeltaHandler removes a deviation of an employee from its role template.
+func (s *Service) DeleteScheduleDeltaHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	deltaID, err := s.idParam(r, "deltaID", &model.ScheduleDelta{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteScheduleDelta(r.Context(), employeeID, deltaID); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"net/http"
+)
+
+// PunchHandler records a punch of the time clock from the JSON body {employeeId, kind, deviceId, nonce,
+// sequence, punchedAt}. A new punch answers 201. A replay of a recorded punch answers 200 with the punch
+// recorded first and the Idempotent-Replayed header, so that kiosks can retry safely.
+func (s *Service) PunchHandler(w http.ResponseWriter, r *http.Request) {
+	var entry model.TimeEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	recorded, replayed, err := s.EmployeeService.Punch(r.Context(), entry)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+		writeJSON(w, http.StatusOK, recorded)
+		return
+	}
+	writeJSON(w, http.StatusCreated, recorded)
+}
+
+// ListTimeEntriesHandler returns the punches of an employee on the days from ?from= to ?to= (YYYY-MM-DD),
+// both optional.
+func (s *Service) ListTimeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" {
+		from = "0001-01-01"
+	}
+	if to == "" {
+		to = "9999-12-31"
+	}
+	start, end, err := service.ParseDateRange(from, to)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	entries, err := s.EmployeeService.ListTimeEntries(r.Context(), employeeID, start, end)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+	"time"
+)
+
+// Timeouts are the time budgets of the routes. When a budget runs out the context of the request is
+// cancelled, which makes the pending database queries and holiday API calls return, and the client gets
+// 504 Gateway Timeout. Zero leaves the routes unbounded.
+type Timeouts struct {
+	// Default bounds the CRUD routes.
+	Default time.Duration
+	// Heavy bounds the exports, reports and other expensive routes.
+	Heavy time.Duration
+}
+
+// deadline returns a middleware bounding the routes it wraps to budget. A handler that gives up once the
+// budget is spent without answering is answered 504 for it.
+func deadline(budget time.Duration) func(http.Handler) http.Handler {
+	if budget <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+			tw := &trackingWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				apierror.Write(w, r, apierror.Timeout(fmt.Sprintf("the request did not complete within %s", budget), ctx.Err()))
+			}
+		})
+	}
+}
+
+// trackingWriter records whether a response was started.
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineAnswersGatewayTimeout(t *testing.T) {
+	// A handler stuck on a call that honours its context, and one reporting the error itself.
+	stuck := deadline(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	reporting := deadline(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		apierror.Write(w, r, r.Context().Err())
+	}))
+	for _, handler := range []http.Handler{stuck, reporting} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":"TIMEOUT"`)
+	}
+
+	fast := deadline(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	fast.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/prox/api/getEmployees", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+)
+
+// ListUnavailabilitiesHandler returns the times an employee declared it cannot work.
+func (s *Service) ListUnavailabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	unavailabilities, err := s.EmployeeService.ListUnavailabilities(r.Context(), employeeID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, unavailabilities)
+}
+
+// CreateUnavailabilityHandler records an unavailability of an employee from the JSON body, see
+// model.UnavailabilityInput.
+func (s *Service) CreateUnavailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var input model.UnavailabilityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateUnavailability(r.Context(), employeeID, input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// UpdateUnavailabilityHandler replaces an unavailability of an employee with the JSON body.
+func (s *Service) UpdateUnavailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	id, err := s.idParam(r, "unavailabilityID", &model.Unavailability{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var input model.UnavailabilityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.UpdateUnavailability(r.Context(), employeeID, id, input)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteUnavailabilityHandler removes an unavailability of an employee.
+func (s *Service) DeleteUnavailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	id, err := s.idParam(r, "unavailabilityID", &model.Unavailability{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteUnavailability(r.Context(), employeeID, id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUnavailabilityConflictsHandler lists the slots an employee works during the month given as
+// ?month=&year= while it declared it cannot work.
+func (s *Service) GetUnavailabilityConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	conflicts, err := s.EmployeeService.UnavailabilityConflicts(r.Context(), employeeID, month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, conflicts)
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+)
+
+// GetValidationHandler returns the validators checking the schedules written for the tenant, along with
+// the validators compiled into the server.
+func (s *Service) GetValidationHandler(w http.ResponseWriter, r *http.Request) {
+	config, err := s.EmployeeService.GetValidationConfig(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, config)
+}
+
+// SetValidationHandler replaces the validators of the tenant from the JSON body {validators, url}: the
+// names of compiled-in validators, and the URL of an external validator or "" for none.
+func (s *Service) SetValidationHandler(w http.ResponseWriter, r *http.Request) {
+	var settings model.ValidationSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	config, err := s.EmployeeService.SetValidationConfig(r.Context(), settings)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, config)
+}
+
+// GetEmployeeViolationsHandler checks the calendar of an employee over ?month= and ?year= against the rules
+// on consecutive days of the validators of the tenant, such as the minimum daily rest, and answers the rules
+// checked and the violations found.
+func (s *Service) GetEmployeeViolationsHandler(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := s.idParam(r, "id", &model.Employee{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	month, year, err := monthQuery(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	report, err := s.EmployeeService.EmployeeViolations(r.Context(), employeeID, month, year)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/http"
+)
+
+// ListWebhooksHandler returns every webhook, without their secrets.
+func (s *Service) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.EmployeeService.ListWebhooks(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hooks)
+}
+
+// CreateWebhookHandler creates a webhook from the JSON body {name, url, format, template, events} and
+// returns it with the secret signing its deliveries, shown this once.
+func (s *Service) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var hook model.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	created, err := s.EmployeeService.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// UpdateWebhookHandler replaces a webhook with the JSON body, as for CreateWebhookHandler. The secret is kept.
+func (s *Service) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Webhook{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	var hook model.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		apierror.Write(w, r, apierror.Validation("Invalid JSON payload: "+err.Error()).WithCode(apierror.CodeInvalidJSON))
+		return
+	}
+	updated, err := s.EmployeeService.UpdateWebhook(r.Context(), id, hook)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteWebhookHandler removes a webhook.
+func (s *Service) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Webhook{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if err := s.EmployeeService.DeleteWebhook(r.Context(), id); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestWebhookHandler renders a sample event in the format of the webhook, sends it and returns the payload
+// with the answer of the consumer.
+func (s *Service) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Webhook{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	result, err := s.EmployeeService.TestWebhook(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ListWebhookDeliveriesHandler returns the last deliveries of a webhook, newest first, with the outcome of
+// their last attempt.
+func (s *Service) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Webhook{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	deliveries, err := s.EmployeeService.ListWebhookDeliveries(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// RotateWebhookSecretHandler replaces the secret signing the deliveries of a webhook and returns the webhook
+// with its new secret, shown this once. The deliveries still queued are signed with the new secret.
+func (s *Service) RotateWebhookSecretHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.idParam(r, "id", &model.Webhook{})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	hook, err := s.EmployeeService.RotateWebhookSecret(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, hook)
+}
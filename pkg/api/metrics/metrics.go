@@ -0,0 +1,60 @@
+// Package metrics holds the counters and gauges of the server, safe for use by any number of goroutines
+// at once and cheap enough to be updated for every record of a large import.
+package metrics
+
+import (
+	"sync/atomic"
+)
+
+// Counter is a count that only goes up, such as the employees imported since the server started. Its zero
+// value is ready to use.
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the counter.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds n to the counter; negative values are ignored, a counter never goes down.
+func (c *Counter) Add(n int64) {
+	if n > 0 {
+		c.value.Add(n)
+	}
+}
+
+// Value returns the current count.
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that goes up and down, such as the imports running. Its zero value is ready to use.
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Set replaces the value of the gauge.
+func (g *Gauge) Set(n int64) {
+	g.value.Store(n)
+}
+
+// Add adds n, possibly negative, to the gauge.
+func (g *Gauge) Add(n int64) {
+	g.value.Add(n)
+}
+
+// Inc adds one to the gauge.
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec subtracts one from the gauge.
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
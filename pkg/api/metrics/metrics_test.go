@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentUpdates(t *testing.T) {
+	var counter Counter
+	var gauge Gauge
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gauge.Inc()
+			for j := 0; j < 1000; j++ {
+				counter.Inc()
+			}
+			counter.Add(-5)
+			gauge.Dec()
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 50000, counter.Value())
+	require.Zero(t, gauge.Value())
+
+	gauge.Set(7)
+	gauge.Add(-2)
+	require.EqualValues(t, 5, gauge.Value())
+}
@@ -0,0 +1,112 @@
+package payroll
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("csv", CSV{})
+}
+
+// CSV writes one summary row per employee, for spreadsheets and payroll software without a DSN import. The
+// columns and separator can be mapped to what the payroll software imports.
+type CSV struct {
+	// Columns are the columns of the file in order; nil means DefaultCSVColumns.
+	Columns []Column
+	// Comma is the field separator; zero means a comma.
+	Comma rune
+}
+
+// Column is a column of a CSV export: a header and the employee field it holds, one of CSVFields.
+type Column struct {
+	Header string
+	Field  string
+}
+
+// csvFields formats each employee field a column can hold.
+var csvFields = map[string]func(e *Employee) string{
+	"employee_id":           func(e *Employee) string { return strconv.FormatUint(uint64(e.ID), 10) },
+	"employee":              func(e *Employee) string { return e.Name },
+	"start_date":            func(e *Employee) string { return e.StartDate.Format("2006-01-02") },
+	"contract_weekly_hours": func(e *Employee) string { return strconv.FormatFloat(e.ContractWeeklyHours, 'f', 2, 64) },
+	"worked_hours":          func(e *Employee) string { return strconv.FormatFloat(e.WorkedHours, 'f', 2, 64) },
+	"holiday_hours":         func(e *Employee) string { return strconv.FormatFloat(e.HolidayHours, 'f', 2, 64) },
+	"unpaid_absence_days":   func(e *Employee) string { return strconv.Itoa(e.UnpaidAbsenceDays()) },
+	"hourly_rate":           func(e *Employee) string { return strconv.FormatFloat(e.HourlyRate, 'f', 2, 64) },
+	"gross_pay":             func(e *Employee) string { return strconv.FormatFloat(e.GrossPay(), 'f', 2, 64) },
+}
+
+// DefaultCSVColumns are the columns of the CSV export unless mapped otherwise, each headed by its field name.
+var DefaultCSVColumns = []Column{
+	{"employee_id", "employee_id"},
+	{"employee", "employee"},
+	{"start_date", "start_date"},
+	{"contract_weekly_hours", "contract_weekly_hours"},
+	{"worked_hours", "worked_hours"},
+	{"holiday_hours", "holiday_hours"},
+	{"unpaid_absence_days", "unpaid_absence_days"},
+}
+
+// CSVFields returns the fields a CSV column can hold, in alphabetical order.
+func CSVFields() []string {
+	fields := make([]string, 0, len(csvFields))
+	for field := range csvFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ParseCSVColumns reads a column mapping written as comma-separated header=field pairs, for instance
+// "Matricule=employee_id,Nom=employee,Heures=worked_hours". A pair without "=" uses the field as header.
+func ParseCSVColumns(mapping string) ([]Column, error) {
+	var columns []Column
+	for _, pair := range strings.Split(mapping, ",") {
+		header, field, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			field = header
+		}
+		header, field = strings.TrimSpace(header), strings.TrimSpace(field)
+		if _, ok := csvFields[field]; !ok {
+			return nil, fmt.Errorf("unknown payroll CSV field %q, expected one of %s", field, strings.Join(CSVFields(), ", "))
+		}
+		if header == "" {
+			return nil, fmt.Errorf("empty header for payroll CSV field %q", field)
+		}
+		columns = append(columns, Column{Header: header, Field: field})
+	}
+	return columns, nil
+}
+
+func (CSV) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (CSV) Extension() string { return "csv" }
+
+func (c CSV) Format(w io.Writer, period *Period) error {
+	columns := c.Columns
+	if columns == nil {
+		columns = DefaultCSVColumns
+	}
+	writer := csv.NewWriter(w)
+	if c.Comma != 0 {
+		writer.Comma = c.Comma
+	}
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.Header
+	}
+	writer.Write(row)
+	for i := range period.Employees {
+		for j, column := range columns {
+			row[j] = csvFields[column.Field](&period.Employees[i])
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,106 @@
+package payroll
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("dsn", DSN{})
+}
+
+// DSN writes the blocks of the French déclaration sociale nominative that our accountant pre-fills from the
+// planning, one rubric per line as S21.G00.<block>.<rubric>,'<value>':
+//
+//   - S21.G00.30 (individu): family name and employee number;
+//   - S21.G00.40 (contrat): contract start date, number and working time in hours per month, against the
+//     company reference of 35 hours per week;
+//   - S21.G00.51 (rémunération): hours worked during the period, type 002, then the hours planned on
+//     public holidays, type 010, when there are any;
+//   - S21.G00.65 (autre suspension du contrat): one block per unpaid absence, reason 501 (congé divers
+//     non rémunéré). Paid absences do not suspend the contract and are not declared.
+//
+// Amounts are left to the payroll software.
+type DSN struct{}
+
+// dsnReferenceWeeklyHours is the company reference working time (quotité de travail de référence).
+const dsnReferenceWeeklyHours = 35
+
+func (DSN) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (DSN) Extension() string { return "dsn" }
+
+func (DSN) Format(w io.Writer, period *Period) error {
+	out := bufio.NewWriter(w)
+	rubric := func(block, rubric, value string) {
+		fmt.Fprintf(out, "S21.G00.%s.%s,'%s'\n", block, rubric, value)
+	}
+	start, end := dsnDate(period.Start()), dsnDate(period.End())
+
+	for _, employee := range period.Employees {
+		contract := dsnContractNumber(employee.ID)
+		rubric("30", "002", dsnText(employee.Name))
+		rubric("30", "019", strconv.FormatUint(uint64(employee.ID), 10))
+
+		rubric("40", "001", dsnDate(employee.StartDate))
+		rubric("40", "009", contract)
+		rubric("40", "011", "10") // unit: hours
+		rubric("40", "012", dsnHours(monthlyHours(dsnReferenceWeeklyHours)))
+		rubric("40", "013", dsnHours(monthlyHours(employee.ContractWeeklyHours)))
+
+		rubric("51", "001", start)
+		rubric("51", "002", end)
+		rubric("51", "010", contract)
+		rubric("51", "011", "002")
+		rubric("51", "012", dsnHours(employee.WorkedHours))
+		if employee.HolidayHours > 0 {
+			rubric("51", "001", start)
+			rubric("51", "002", end)
+			rubric("51", "010", contract)
+			rubric("51", "011", "010")
+			rubric("51", "012", dsnHours(employee.HolidayHours))
+		}
+
+		for _, absence := range employee.Absences {
+			if absence.Paid {
+				continue
+			}
+			rubric("65", "001", "501")
+			rubric("65", "002", dsnDate(absence.Start))
+			rubric("65", "003", dsnDate(absence.End))
+		}
+	}
+	return out.Flush()
+}
+
+// monthlyHours converts weekly hours to the monthly equivalent used by the DSN (52 weeks over 12 months).
+func monthlyHours(weekly float64) float64 {
+	return weekly * 52 / 12
+}
+
+func dsnDate(t time.Time) string {
+	return t.Format("02012006")
+}
+
+func dsnHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', 2, 64)
+}
+
+func dsnContractNumber(employeeID uint) string {
+	return fmt.Sprintf("E%05d", employeeID)
+}
+
+// dsnText upper-cases a value and strips the characters the DSN does not accept in names.
+func dsnText(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	return strings.Map(func(r rune) rune {
+		if r == '\'' || r == ',' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,112 @@
+// Package payroll turns the planned hours of a month into the files handed to payroll and to the accountant.
+package payroll
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Period is the payroll data of every employee for one calendar month.
+type Period struct {
+	Year      int
+	Month     time.Month
+	Employees []Employee
+}
+
+// Start returns the first day of the period.
+func (p *Period) Start() time.Time {
+	return time.Date(p.Year, p.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End returns the last day of the period.
+func (p *Period) End() time.Time {
+	return p.Start().AddDate(0, 1, -1)
+}
+
+// Employee is the payroll data of one employee for a period.
+type Employee struct {
+	ID                  uint
+	Name                string
+	StartDate           time.Time
+	ContractWeeklyHours float64
+	// HourlyRate is the gross pay of one hour, in euros.
+	HourlyRate float64
+	// WorkedHours is the total of the hours planned during the period, HolidayHours included.
+	WorkedHours float64
+	// HolidayHours is the part of WorkedHours planned on public holidays.
+	HolidayHours float64
+	Absences     []Absence
+}
+
+// GrossPay returns the pay of the hours worked during the period at the hourly rate, rounded to the cent.
+func (e *Employee) GrossPay() float64 {
+	return math.Round(e.WorkedHours*e.HourlyRate*100) / 100
+}
+
+// UnpaidAbsenceDays returns the number of days of the unpaid absences of the employee.
+func (e *Employee) UnpaidAbsenceDays() int {
+	days := 0
+	for _, absence := range e.Absences {
+		if !absence.Paid {
+			days += int(absence.End.Sub(absence.Start).Hours()/24) + 1
+		}
+	}
+	return days
+}
+
+// Absence is a period during which the employee's contract is suspended.
+type Absence struct {
+	Start time.Time
+	End   time.Time
+	// Reason is a free-text description of the absence.
+	Reason string
+	// Paid tells whether the employee keeps being paid during the absence.
+	Paid bool
+}
+
+// Formatter writes a period in one payroll file format.
+type Formatter interface {
+	// ContentType is the MIME type of the output.
+	ContentType() string
+	// Extension is the file name extension of the output, without the dot.
+	Extension() string
+	Format(w io.Writer, period *Period) error
+}
+
+var formatters = map[string]Formatter{}
+
+// Register makes a formatter available under name. It panics if the name is already taken.
+func Register(name string, formatter Formatter) {
+	if _, ok := formatters[name]; ok {
+		panic(fmt.Sprintf("payroll: formatter %q registered twice", name))
+	}
+	formatters[name] = formatter
+}
+
+// Replace swaps the formatter registered under name for a reconfigured one. It panics if no formatter is
+// registered under that name.
+func Replace(name string, formatter Formatter) {
+	if _, ok := formatters[name]; !ok {
+		panic(fmt.Sprintf("payroll: no formatter %q to replace", name))
+	}
+	formatters[name] = formatter
+}
+
+// Lookup returns the formatter registered under name.
+func Lookup(name string) (Formatter, bool) {
+	formatter, ok := formatters[name]
+	return formatter, ok
+}
+
+// Names returns the names of the registered formatters in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
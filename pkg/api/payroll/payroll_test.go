@@ -0,0 +1,85 @@
+package payroll
+
+import (
+	"bytes"
+	"flag"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the fixtures with the current outputs")
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// april is a month with a public holiday worked by one employee and an unpaid and a paid absence.
+var april = &Period{
+	Year:  2024,
+	Month: time.April,
+	Employees: []Employee{
+		{
+			ID:                  1,
+			Name:                "Henny O'Neil",
+			StartDate:           date(2021, time.September, 1),
+			ContractWeeklyHours: 35,
+			HourlyRate:          12.25,
+			WorkedHours:         154,
+			HolidayHours:        7,
+		},
+		{
+			ID:                  2,
+			Name:                "paul, martin",
+			StartDate:           date(2023, time.February, 15),
+			ContractWeeklyHours: 24,
+			WorkedHours:         86.5,
+			Absences: []Absence{
+				{Start: date(2024, time.April, 8), End: date(2024, time.April, 10), Reason: "congé sans solde"},
+				{Start: date(2024, time.April, 22), End: date(2024, time.April, 26), Reason: "congés payés", Paid: true},
+			},
+		},
+	},
+}
+
+func TestFormatters(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			formatter, _ := Lookup(name)
+			var out bytes.Buffer
+			require.NoError(t, formatter.Format(&out, april))
+
+			path := filepath.Join("testdata", "april."+formatter.Extension())
+			if *update {
+				require.NoError(t, os.WriteFile(path, out.Bytes(), 0o644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "missing fixture, run the test with -update to create it")
+			require.Equal(t, string(want), out.String(), "output differs from %s", path)
+		})
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	columns, err := ParseCSVColumns("Matricule=employee_id, Nom=employee,Taux=hourly_rate,Brut=gross_pay,unpaid_absence_days")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, CSV{Columns: columns, Comma: ';'}.Format(&out, april))
+	require.Equal(t, "Matricule;Nom;Taux;Brut;unpaid_absence_days\n"+
+		"1;Henny O'Neil;12.25;1886.50;0\n"+
+		"2;paul, martin;0.00;0.00;3\n", out.String())
+
+	_, err = ParseCSVColumns("Matricule=employee_id,IBAN=iban")
+	require.ErrorContains(t, err, `unknown payroll CSV field "iban"`)
+	_, err = ParseCSVColumns("=employee")
+	require.Error(t, err)
+}
+
+func TestPeriodBounds(t *testing.T) {
+	february := &Period{Year: 2024, Month: time.February}
+	require.Equal(t, date(2024, time.February, 1), february.Start())
+	require.Equal(t, date(2024, time.February, 29), february.End())
+}
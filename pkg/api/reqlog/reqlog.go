@@ -0,0 +1,27 @@
+// Package reqlog ties the log lines written while serving a request to the ID of the request, which the
+// clients receive in the X-Request-ID header: support finds every line of the request a client reports.
+package reqlog
+
+import (
+	"context"
+	"github.com/go-chi/chi/middleware"
+	log "github.com/sirupsen/logrus"
+)
+
+// Field is the logrus field carrying the ID of the request.
+const Field = "request_id"
+
+// ID returns the ID of the request ctx belongs to, given by middleware.RequestID; empty outside of a request.
+func ID(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
+
+// From returns the logger of the request ctx belongs to, whose lines carry its ID. Outside of a request it
+// is the standard logger.
+func From(ctx context.Context) *log.Entry {
+	entry := log.NewEntry(log.StandardLogger())
+	if id := ID(ctx); id != "" {
+		entry = entry.WithField(Field, id)
+	}
+	return entry
+}
@@ -0,0 +1,26 @@
+package reqlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrom(t *testing.T) {
+	hook := test.NewLocal(log.StandardLogger())
+	defer log.StandardLogger().ReplaceHooks(make(log.LevelHooks))
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	From(ctx).Warn("Inside a request")
+	From(context.Background()).Warn("Outside of a request")
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, log.Fields{Field: "host/abc-000001"}, entries[0].Data)
+	assert.Empty(t, entries[1].Data)
+}
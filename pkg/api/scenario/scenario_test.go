@@ -0,0 +1,1903 @@
+// Package scenario drives the HTTP API through the day-to-day flow of an optician shop, from the import
+// of the team to the monthly export handed to payroll. The expected outputs are golden files under
+// testdata; run "go test ./pkg/api/scenario -update" to rewrite them after an intended change and review
+// the diff.
+package scenario
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/gcal"
+	lhttp "github.com/lichensio/api_server/pkg/api/http"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/lichensio/api_server/pkg/api/slack"
+	"github.com/lichensio/api_server/pkg/api/storage"
+	"github.com/lichensio/api_server/pkg/api/validation"
+	"github.com/lichensio/api_server/pkg/api/webhook"
+	"github.com/lichensio/api_server/pkg/api/xlsx"
+	"github.com/lichensio/api_server/pkg/api/xlsx/xlsxtest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current outputs")
+
+// api is a running instance of the API backed by a fresh in-memory database.
+type api struct {
+	t       *testing.T
+	repo    repo.Repository
+	svc     *service.EmployeeService
+	handler http.Handler
+	token   string
+}
+
+func newAPI(t *testing.T) *api {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	repository := repo.NewRepositoryWithDB(db)
+	require.NoError(t, repository.DBCreate(context.Background()))
+	authService := auth.NewService(repository, "scenario-secret", time.Hour)
+	require.NoError(t, authService.EnsureUser(context.Background(), "manager", "manager-password"))
+
+	employeeService := service.NewEmployeeService(repository)
+	store, err := storage.NewDir(t.TempDir())
+	require.NoError(t, err)
+	printsCtx, stopPrints := context.WithCancel(context.Background())
+	t.Cleanup(stopPrints)
+	employeeService.StartPrintQueue(printsCtx, service.PrintQueue{Store: store, Secret: []byte("print-secret")})
+	return &api{
+		t:    t,
+		repo: repository,
+		svc:  employeeService,
+		handler: lhttp.NewRouter(&lhttp.Service{
+			EmployeeService: employeeService,
+			Auth:            authService,
+			InternalTokens:  []string{"payroll-service-token"},
+			AdminTokens:     []string{"ops-token"},
+		}),
+	}
+}
+
+// do sends a request, authenticated once login has succeeded, and returns the response.
+func (a *api) do(method, path, body string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, "/prox/api"+path, strings.NewReader(body))
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	rec := httptest.NewRecorder()
+	a.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect sends a request and fails the test unless it is answered with status.
+func (a *api) expect(status int, method, path, body string) []byte {
+	a.t.Helper()
+	rec := a.do(method, path, body)
+	require.Equal(a.t, status, rec.Code, "%s %s answered %s", method, path, rec.Body.String())
+	return rec.Body.Bytes()
+}
+
+func (a *api) login(username, password string) {
+	a.t.Helper()
+	body := a.expect(http.StatusOK, http.MethodPost, "/auth/login",
+		fmt.Sprintf(`{"username": %q, "password": %q}`, username, password))
+	var response struct {
+		Token string `json:"token"`
+	}
+	require.NoError(a.t, json.Unmarshal(body, &response))
+	a.token = response.Token
+}
+
+// golden compares got with testdata/<name>, or rewrites the file when -update is set. JSON outputs are
+// indented first so that golden diffs stay readable.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	if strings.HasSuffix(name, ".json") {
+		var indented bytes.Buffer
+		require.NoError(t, json.Indent(&indented, got, "", "  "))
+		got = indented.Bytes()
+	}
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file, run the test with -update to create it")
+	require.Equal(t, string(want), string(got), "output differs from %s", path)
+}
+
+func TestOpticianMonth(t *testing.T) {
+	a := newAPI(t)
+
+	// The manager signs in; nothing but the login works without a token.
+	a.expect(http.StatusUnauthorized, http.MethodGet, "/getEmployees", "")
+	a.expect(http.StatusUnauthorized, http.MethodPost, "/auth/login", `{"username": "manager", "password": "wrong"}`)
+	a.login("manager", "manager-password")
+
+	// An account migrated with its bcrypt hash signs in too, and its hash is upgraded to argon2id.
+	legacy, err := auth.DefaultBcrypt.Hash("legacy-password")
+	require.NoError(t, err)
+	require.NoError(t, a.repo.UserCreate(context.Background(), &model.User{Username: "assistant", PasswordHash: legacy}))
+	a.login("assistant", "legacy-password")
+	assistant, err := a.repo.UserFindByUsername(context.Background(), "assistant")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(assistant.PasswordHash, "$argon2id$"), assistant.PasswordHash)
+	a.login("assistant", "legacy-password")
+	a.login("manager", "manager-password")
+
+	// The schema is at the version of the server; migrating again finds nothing to apply.
+	a.expect(http.StatusUnauthorized, http.MethodPost, "/admin/db/migrate", "")
+	ops := &api{t: t, handler: a.handler, token: "ops-token"}
+	ops.expect(http.StatusOK, http.MethodPost, "/admin/db/migrate", "")
+	var migrations service.MigrationReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/db/migrations/status", ""), &migrations))
+	require.Equal(t, "0001_baseline", migrations.Migrations[0].ID)
+	require.Equal(t, migrations.Migrations[len(migrations.Migrations)-1].ID, migrations.Current)
+	require.Zero(t, migrations.Pending)
+	require.True(t, migrations.Migrations[0].Applied)
+
+	// The team is imported from the JSON the shop keeps its A/B weeks in.
+	employees, err := os.ReadFile(filepath.Join("testdata", "employees.json"))
+	require.NoError(t, err)
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", string(employees))
+
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 2)
+	ids := map[string]uint{}
+	for _, employee := range team {
+		ids[employee.Name] = employee.ID
+	}
+
+	// Sending the same file again, even reformatted, does not duplicate the team.
+	replay := a.expect(http.StatusOK, http.MethodPost, "/loadEmployees", strings.Join(strings.Fields(string(employees)), " "))
+	require.Contains(t, string(replay), `"status":"already imported"`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 2)
+
+	// A bad import is rejected as a whole and leaves the team untouched.
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees",
+		`[{"name": "Late", "startDate": "2024-03-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "13:00"}, {"start": "12:00", "end": "17:00"}]}}}]`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 2)
+
+	// So is an import with malformed fields, before the import runs: each field is named by its path.
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusUnprocessableEntity, http.MethodPost, "/loadEmployees",
+		`[{"name": " ", "startDate": "2024-03-01", "weeks": {"A": {"Monday": [{"start": "9h", "end": "13:00"}]}}}]`), &problem))
+	require.Equal(t, apierror.CodeInputInvalid, problem.Code)
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "employees[0].name", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "employees[0].weeks.A.Monday[0].start", Code: apierror.CodeTimeFormatInvalid, Reason: `must be a time written HH:MM, got: "9h"`},
+	}, problem.InvalidParams)
+
+	// The import counters follow every import, the replay aside; none is running any more.
+	var progress service.ImportProgress
+	a.expect(http.StatusUnauthorized, http.MethodGet, "/admin/imports", "")
+	require.NoError(t, json.Unmarshal(ops.expect(http.StatusOK, http.MethodGet, "/admin/imports", ""), &progress))
+	require.Equal(t, service.ImportProgress{EmployeesProcessed: 3, SchedulesWritten: 28, ValidationFailures: 1, Imports: []service.ActiveImport{}}, progress)
+
+	// Public holidays of April 2024 are known, so the provider is never called.
+	require.NoError(t, a.repo.HolidayCreate(context.Background(),
+		&model.Holiday{HolidayDate: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), HolidayName: "Lundi de Pâques"}))
+
+	// Henny's April calendar and hours.
+	henny := ids["Henny Honore"]
+	golden(t, "henny-april-schedule.json",
+		a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", henny), ""))
+	golden(t, "henny-april-hours.json",
+		a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlyHours?employeeID=%d&month=April&year=2024", henny), ""))
+	golden(t, "henny-weeks.json",
+		a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d?date=2024-04-01", henny), ""))
+
+	// Clients may address every resource by its UUID instead of its sequential ID.
+	var hennyUUID string
+	for _, employee := range team {
+		if employee.ID == henny {
+			hennyUUID = employee.UUID
+		}
+	}
+	require.True(t, model.IsUUID(hennyUUID))
+	require.Equal(t, a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", henny), ""),
+		a.expect(http.StatusOK, http.MethodGet, "/getWeeksAB/"+hennyUUID, ""))
+	a.expect(http.StatusOK, http.MethodGet, "/getMonthlyHours?month=2024-04&employeeID="+hennyUUID, "")
+	a.expect(http.StatusNotFound, http.MethodGet, "/getWeeksAB/"+model.NewUUID(), "")
+
+	// Her calendar over the new year: 2026 ends with an ISO week 53 and the A/B weeks carry on into 2027.
+	require.NoError(t, a.repo.HolidayCreate(context.Background(),
+		&model.Holiday{HolidayDate: time.Date(2026, time.December, 25, 0, 0, 0, 0, time.UTC), HolidayName: "Noël"}))
+	require.NoError(t, a.repo.HolidayCreate(context.Background(),
+		&model.Holiday{HolidayDate: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC), HolidayName: "Jour de l'an"}))
+	var newYear service.ScheduleRange
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet,
+		fmt.Sprintf("/employees/%d/schedule?from=2026-12-30&to=2027-01-05", henny), ""), &newYear))
+	require.Len(t, newYear.Days, 7)
+	require.Equal(t, "2027-01-01", newYear.Days[2].Date)
+	require.Equal(t, "Jour de l'an", newYear.Days[2].HolidayName)
+	require.Equal(t, []int{2026, 53}, []int{newYear.Days[4].ISOYear, newYear.Days[4].ISOWeek}, "Sunday January 3 closes week 53")
+	require.Equal(t, []int{2027, 1}, []int{newYear.Days[5].ISOYear, newYear.Days[5].ISOWeek})
+	require.Equal(t, newYear.Days[0].WeekType, newYear.Days[4].WeekType)
+	require.NotEqual(t, newYear.Days[4].WeekType, newYear.Days[5].WeekType, "The week after week 53 switches")
+	var january []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2027-01", henny), ""), &january))
+	require.Equal(t, january[4].TimeSlots, newYear.Days[6].TimeSlots, "The range agrees with the monthly calendar")
+	a.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/employees/%d/schedule?from=2026-01-01&to=2027-01-05", henny), "")
+	a.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/employees/%d/schedule?from=2027-01-05&to=2026-12-30", henny), "")
+
+	// The shop in Strasbourg observes the holidays of Alsace-Moselle, Saint-Étienne included.
+	alsace := repo.WithHolidayRegion(context.Background(), "alsace-moselle")
+	require.NoError(t, a.repo.HolidayCreate(alsace,
+		&model.Holiday{HolidayDate: time.Date(2026, time.December, 25, 0, 0, 0, 0, time.UTC), HolidayName: "Noël"}))
+	require.NoError(t, a.repo.HolidayCreate(alsace,
+		&model.Holiday{HolidayDate: time.Date(2026, time.December, 26, 0, 0, 0, 0, time.UTC), HolidayName: "Saint-Étienne"}))
+	var christmas service.ScheduleRange
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet,
+		fmt.Sprintf("/employees/%d/schedule?from=2026-12-25&to=2026-12-26&region=alsace-moselle", henny), ""), &christmas))
+	require.Equal(t, []string{"Noël", "Saint-Étienne"}, []string{christmas.Days[0].HolidayName, christmas.Days[1].HolidayName})
+	christmas.Days = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet,
+		fmt.Sprintf("/employees/%d/schedule?from=2026-12-25&to=2026-12-26", henny), ""), &christmas))
+	require.Equal(t, []string{"Noël", ""}, []string{christmas.Days[0].HolidayName, christmas.Days[1].HolidayName})
+	body := a.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/employees/%d/schedule?from=2026-12-25&to=2026-12-26&region=bavaria", henny), "")
+	require.Contains(t, string(body), "REGION_INVALID")
+
+	// Delphine asks for two unpaid days off. Once approved they stay in her calendar but no longer count as
+	// worked.
+	delphine := ids["Delphine"]
+	leave := `{"from": "2024-04-09", "to": "2024-04-10", "description": "congé sans solde", "withoutPay": true}`
+	var requested []model.EmployeeHoliday
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/employees/%d/leave", delphine), leave), &requested))
+	require.Len(t, requested, 2)
+	require.Equal(t, model.LeavePending, requested[0].Status)
+	a.expect(http.StatusConflict, http.MethodPost, fmt.Sprintf("/employees/%d/leave", delphine), leave)
+	var april []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", delphine), ""), &april))
+	require.Nil(t, april[8].Leave, "Pending leave is not in the calendar")
+
+	var approved []model.EmployeeHoliday
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPatch, fmt.Sprintf("/leave/%d/approve", requested[1].ID), ""), &approved))
+	require.Len(t, approved, 2, "The whole request is approved")
+	require.Equal(t, model.LeaveApproved, approved[0].Status)
+	require.NotNil(t, approved[0].ApproverID)
+	a.expect(http.StatusConflict, http.MethodPatch, fmt.Sprintf("/leave/%d/reject", requested[0].ID), "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", delphine), ""), &april))
+	require.NotNil(t, april[8].Leave)
+	require.True(t, april[8].Leave.WithoutPay)
+	require.Nil(t, april[10].Leave)
+
+	// Payroll checks the hours of the month before the export: Henny works Easter Monday, Delphine's leave is
+	// not worked.
+	golden(t, "april-hours.json", a.expect(http.StatusOK, http.MethodGet, "/hours?month=2024-04", ""))
+
+	// The month is exported for payroll.
+	golden(t, "april-export.csv",
+		a.expect(http.StatusOK, http.MethodGet, "/schedule/export?format=csv&month=2024-04", ""))
+	golden(t, "april-payroll.dsn",
+		a.expect(http.StatusOK, http.MethodGet, "/payroll/export?format=dsn&month=2024-04", ""))
+
+	// The accountant's tool asks for the same export in the background and is called back once it is ready;
+	// the signed link downloads it without a token.
+	callbacks := make(chan events.Event, 1)
+	accounting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event events.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		callbacks <- event
+	}))
+	defer accounting.Close()
+	a.expect(http.StatusBadRequest, http.MethodPost, "/prints", `{"kind": "schedules", "format": "pdf", "month": "2024-04"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/prints", `{"kind": "payslips", "month": "2024-04"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/prints", `{"kind": "schedules", "month": "2024-04", "callbackUrl": "accounting"}`)
+	var job model.PrintJob
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusAccepted, http.MethodPost, "/prints",
+		fmt.Sprintf(`{"kind": "schedules", "month": "2024-04", "callbackUrl": %q}`, accounting.URL)), &job))
+	require.Equal(t, model.PrintQueued, job.Status)
+	select {
+	case event := <-callbacks:
+		require.Equal(t, events.PrintCompleted, event.Type)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the print job was not completed")
+	}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/prints/%d", job.ID), ""), &job))
+	require.Equal(t, model.PrintDone, job.Status)
+	require.Equal(t, "schedules-2024-april.csv", job.FileName)
+	server := httptest.NewServer(a.handler)
+	defer server.Close()
+	download, err := http.Get(server.URL + job.DownloadURL)
+	require.NoError(t, err)
+	artifact, err := io.ReadAll(download.Body)
+	download.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, download.StatusCode, string(artifact))
+	golden(t, "april-export.csv", artifact)
+	download, err = http.Get(server.URL + strings.Replace(job.DownloadURL, "expires=", "expires=9", 1))
+	require.NoError(t, err)
+	download.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, download.StatusCode)
+
+	// Delphine covers a Saturday morning at the station shop, and the shop closes on the next Friday.
+	a.expect(http.StatusBadRequest, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-20", delphine),
+		`{"slots": [{"startTime": "09:00", "endTime": "12:00"}, {"startTime": "11:00", "endTime": "13:00"}]}`)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-20", delphine),
+		`{"reason": "covers Henny", "slots": [{"startTime": "09:00", "endTime": "13:00"}]}`)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-20", delphine),
+		`{"reason": "covers Henny", "slots": [{"startTime": "09:00", "endTime": "12:00", "location": "Gare"}]}`)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-26", delphine), `{"off": true, "reason": "inventory"}`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", delphine), ""), &april))
+	require.True(t, april[19].Overridden)
+	require.Equal(t, []model.TimeSlot{{Start: "09:00", End: "12:00", Location: "Gare"}}, april[19].TimeSlots)
+	require.True(t, april[25].Overridden)
+	require.Empty(t, april[25].TimeSlots)
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/employees/%d/overrides/2024-04-26", delphine), "")
+	a.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/employees/%d/overrides/2024-04-26", delphine), "")
+	var overrides []model.ScheduleOverride
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/overrides", delphine), ""), &overrides))
+	require.Len(t, overrides, 1)
+
+	// The team calendar shows every employee's month at once, leave and overrides included.
+	var roster struct {
+		Employees []service.RosterEmployee `json:"employees"`
+		Days      []service.RosterDay      `json:"days"`
+	}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/roster?month=2024-04", ""), &roster))
+	require.Len(t, roster.Employees, len(team))
+	for _, employee := range roster.Employees {
+		april = nil
+		require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet,
+			fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", employee.EmployeeID), ""), &april))
+		require.Equal(t, april, employee.Days, employee.Employee)
+	}
+	a.expect(http.StatusBadRequest, http.MethodGet, "/roster?month=2024-04&groupBy=week", "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/roster?month=2024-04&groupBy=day&location=Gare", ""), &roster))
+	require.Len(t, roster.Days, 30)
+	require.Equal(t, "Lundi de Pâques", roster.Days[0].HolidayName)
+	for _, shift := range roster.Days[19].Employees {
+		if shift.EmployeeID == delphine {
+			require.Equal(t, []model.TimeSlot{{Start: "09:00", End: "12:00", Location: "Gare"}}, shift.TimeSlots)
+		} else {
+			require.Empty(t, shift.TimeSlots, "Nobody else works at the station shop")
+		}
+	}
+
+	// On that Saturday nobody is scheduled over lunch, between Delphine's morning and Henny's afternoon.
+	var coverage service.CoverageReport
+	a.expect(http.StatusBadRequest, http.MethodGet, "/coverage?date=20/04/2024", "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/coverage?date=2024-04-20", ""), &coverage))
+	require.Len(t, coverage.Intervals, 96)
+	require.Equal(t, service.CoverageInterval{Start: "09:00", End: "09:15", Count: 1}, coverage.Intervals[36])
+	require.Equal(t, service.CoverageInterval{Start: "12:00", End: "12:15", Count: 0}, coverage.Intervals[48])
+	require.Equal(t, service.CoverageInterval{Start: "19:45", End: "20:00", Count: 1}, coverage.Intervals[79])
+	require.Equal(t, service.CoverageInterval{Start: "23:45", End: "24:00", Count: 0}, coverage.Intervals[95])
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/coverage?date=2024-04-20&location=Gare", ""), &coverage))
+	require.Equal(t, 0, coverage.Intervals[79].Count)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/coverage?date=2024-04-09", ""), &coverage))
+	for _, interval := range coverage.Intervals {
+		require.LessOrEqual(t, interval.Count, 1, "Delphine is on leave")
+	}
+
+	// The kiosk punches Henny in. Its retries, and her second tap with a fresh nonce, record nothing more.
+	punch := func(nonce string, sequence int, at string) string {
+		return fmt.Sprintf(`{"employeeId": %d, "kind": "in", "deviceId": "kiosk-1", "nonce": %q, "sequence": %d, "punchedAt": %q}`, henny, nonce, sequence, at)
+	}
+	var punched, replayed model.TimeEntry
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/timeclock/punches", punch("n1", 1, "2024-04-02T08:58:00Z")), &punched))
+	res := a.do(http.MethodPost, "/timeclock/punches", punch("n1", 1, "2024-04-02T08:58:00Z"))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "true", res.Header().Get("Idempotent-Replayed"))
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &replayed))
+	require.Equal(t, punched.ID, replayed.ID)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/timeclock/punches", punch("n2", 2, "2024-04-02T08:59:00Z")), &replayed))
+	require.Equal(t, punched.ID, replayed.ID)
+	a.expect(http.StatusConflict, http.MethodPost, "/timeclock/punches",
+		fmt.Sprintf(`{"employeeId": %d, "kind": "out", "deviceId": "kiosk-1", "nonce": "n1", "sequence": 3}`, henny))
+	a.expect(http.StatusConflict, http.MethodPost, "/timeclock/punches", punch("n3", 1, "2024-04-03T08:58:00Z"))
+	a.expect(http.StatusBadRequest, http.MethodPost, "/timeclock/punches", punch("", 4, "2024-04-03T08:58:00Z"))
+	a.expect(http.StatusCreated, http.MethodPost, "/timeclock/punches", punch("n4", 4, "2024-04-03T08:58:00Z"))
+	var punches []model.TimeEntry
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/punches?from=2024-04-02&to=2024-04-02", henny), ""), &punches))
+	require.Len(t, punches, 1)
+
+	// The payroll service totals the first week of April with its own token: Henny clocked out after 8h30 on
+	// Tuesday, and her Easter Monday is paid at a premium.
+	a.expect(http.StatusCreated, http.MethodPost, "/timeclock/punches",
+		fmt.Sprintf(`{"employeeId": %d, "kind": "out", "deviceId": "kiosk-1", "nonce": "n5", "sequence": 5, "punchedAt": "2024-04-02T17:28:00Z"}`, henny))
+	hourTotals := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/prox/api/internal/hourTotals", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		a.handler.ServeHTTP(rec, req)
+		return rec
+	}
+	totalsQuery := fmt.Sprintf(`{"employeeIds": [%d, 9999, %d], "from": "2024-04-01", "to": "2024-04-07"}`, henny, delphine)
+	require.Equal(t, http.StatusUnauthorized, hourTotals("", totalsQuery).Code)
+	require.Equal(t, http.StatusUnauthorized, hourTotals(a.token, totalsQuery).Code)
+	require.Equal(t, http.StatusBadRequest, hourTotals("payroll-service-token", `{"employeeIds": [], "from": "2024-04-01", "to": "2024-04-07"}`).Code)
+	res = hourTotals("payroll-service-token", totalsQuery)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var totals []service.HourTotals
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &totals))
+	require.Len(t, totals, 2)
+	require.Equal(t, service.HourTotals{EmployeeID: henny, PlannedHours: 36.75, ActualHours: 8.5, PremiumHours: 7}, totals[0])
+	require.Equal(t, service.HourTotals{EmployeeID: delphine, PlannedHours: 37.5}, totals[1])
+
+	// The shop imports its April takings and compares them with the hours planned.
+	a.expect(http.StatusBadRequest, http.MethodPost, "/reports/kpi/revenues", `[{"date": "2024-04-19", "amount": -10}]`)
+	require.JSONEq(t, `{"saved": 2}`, string(a.expect(http.StatusOK, http.MethodPost, "/reports/kpi/revenues",
+		`[{"date": "2024-04-19", "amount": 1500}, {"date": "2024-04-20", "amount": 900}]`)))
+	a.expect(http.StatusOK, http.MethodPost, "/reports/kpi/revenues", `[{"date": "2024-04-20", "amount": 2400}]`)
+	golden(t, "april-kpi.json",
+		a.expect(http.StatusOK, http.MethodGet, "/reports/kpi?month=2024-04&hourlyCost=25", ""))
+
+	// Before changing the weeks, the manager asks for suggestions on the current ones, then on a draft.
+	var lint service.LintReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/schedule/lint", `{"from": "2024-04-01"}`), &lint))
+	require.Equal(t, "2024-04-14", lint.To)
+	require.Empty(t, lint.Findings)
+	golden(t, "draft-lint.json", a.expect(http.StatusOK, http.MethodPost, "/schedule/lint", `{"from": "2024-04-01", "draft": [
+		{"name": "Solo", "startDate": "2024-01-01", "weeks": {
+			"A": {"Monday": [{"start": "9:00", "end": "10:30"}], "Tuesday": [{"start": "12:00", "end": "20:00"}],
+				"Friday": [{"start": "12:00", "end": "20:00"}], "Saturday": [{"start": "9:00", "end": "19:00"}]},
+			"B": {"Tuesday": [{"start": "12:00", "end": "20:00"}], "Friday": [{"start": "12:00", "end": "20:00"}],
+				"Saturday": [{"start": "9:00", "end": "19:00"}]}}},
+		{"name": "Early", "startDate": "2024-01-01", "weeks": {
+			"A": {"Tuesday": [{"start": "8:00", "end": "12:00"}], "Wednesday": [{"start": "8:00", "end": "12:00"}],
+				"Thursday": [{"start": "8:00", "end": "12:00"}]},
+			"B": {"Tuesday": [{"start": "8:00", "end": "12:00"}]}}}]}`))
+	a.expect(http.StatusBadRequest, http.MethodPost, "/schedule/lint", `{"draft": [{"name": "Solo", "startDate": "2024-01-01", "weeks": {"C": {}}}]}`)
+
+	// Henny moves to the workshop on Mondays of week A; the shop re-sends her entry in upsert mode and a
+	// newcomer along with it.
+	upsert := `[
+		{"name": "Henny Honore", "startDate": "2024-02-24", "department": "workshop",
+		 "weeks": {"A": {"Monday": [{"start": "9:00", "end": "17:00", "task": "lab"}]}}},
+		{"name": "Nadia", "startDate": "2024-04-15", "contractWeeklyHours": 6, "hourlyRate": 11.88, "weeks": {"B": {"Saturday": [{"start": "9:00", "end": "13:00"}]}}}
+	]`
+	require.JSONEq(t, `{"importId": 2, "loaded": 2, "created": 1, "updated": 1}`,
+		string(a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees?upsert=true", upsert)))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 3)
+	var weeks []service.WeekSchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", henny), ""), &weeks))
+	require.Equal(t, []service.TimeSlot{{Start: "09:00", End: "17:00", Task: "lab"}}, weeks[0].Days[0].TimeSlots)
+	require.Empty(t, weeks[1].Days[0].TimeSlots, "The upsert replaces both weeks")
+
+	// The payroll export spells her "Henny Honoré" and has her start two days later: a dry run flags her as a
+	// possible duplicate, and the record linked to her replaces her instead of creating another employee.
+	accented := `[{"name": "Henny Honoré", "startDate": "2024-02-26", "department": "workshop",
+		"weeks": {"A": {"Monday": [{"start": "9:00", "end": "17:00", "task": "lab"}]}}%s}]`
+	var preview service.ImportPreview
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees?dryRun=true&upsert=true", fmt.Sprintf(accented, "")), &preview))
+	require.Len(t, preview.Employees, 1)
+	require.Equal(t, service.ImportCreate, preview.Employees[0].Action)
+	require.Len(t, preview.Employees[0].Candidates, 1)
+	require.Equal(t, service.NameMatch{EmployeeID: henny, UUID: team[1].UUID, Name: "Henny Honore", StartDate: "2024-02-24", Similarity: 1},
+		preview.Employees[0].Candidates[0])
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees?dryRun=true", fmt.Sprintf(accented, `, "linkTo": 9999`))
+	linked := fmt.Sprintf(accented, fmt.Sprintf(`, "linkTo": %d`, henny))
+	preview = service.ImportPreview{}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees?dryRun=true", linked), &preview))
+	require.Equal(t, []service.ImportEntry{{Name: "Henny Honoré", Action: service.ImportLink, EmployeeID: henny, Slots: 1}}, preview.Employees)
+	require.JSONEq(t, `{"importId": 3, "loaded": 1, "created": 0, "updated": 1}`, string(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees", linked)))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 3)
+	require.Equal(t, "Henny Honore", team[1].Name)
+
+	// Nadia is contracted for six hours a week but only scheduled on Saturday mornings of week B: she is
+	// short every week since she started mid-April.
+	nadia := team[len(team)-1].ID
+	require.Equal(t, 11.88, team[len(team)-1].HourlyRate)
+	golden(t, "nadia-april-overtime.json",
+		a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/overtime?month=2024-04", nadia), ""))
+	a.expect(http.StatusBadRequest, http.MethodGet, fmt.Sprintf("/employees/%d/overtime", nadia), "")
+
+	// The workshop moves to a three-week rotation: early, late, then a week off.
+	a.expect(http.StatusBadRequest, http.MethodPost, "/rotation-patterns", `{"name": "workshop", "weeks": [{"name": "early"}, {"name": "early"}]}`)
+	var rotation model.RotationPattern
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/rotation-patterns",
+		`{"name": "workshop", "weeks": [{"name": "early"}, {"name": "late"}, {"name": "off"}]}`), &rotation))
+	require.Equal(t, 3, rotation.CycleLength)
+	a.expect(http.StatusConflict, http.MethodPost, "/rotation-patterns", `{"name": "workshop", "weeks": [{"name": "W1"}]}`)
+	var rotations []model.RotationPattern
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/rotation-patterns", ""), &rotations))
+	require.Len(t, rotations, 2, "The A/B rotation is seeded next to the new one")
+	a.expect(http.StatusConflict, http.MethodPut, fmt.Sprintf("/employees/%d/rotation-pattern", henny),
+		fmt.Sprintf(`{"rotationPatternId": %d}`, rotation.ID))
+
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees",
+		`[{"name": "Yann", "startDate": "2024-04-01", "rotation": "workshop", "weeks": {"A": {"Monday": [{"start": "8:00", "end": "12:00"}]}}}]`)
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Yann", "startDate": "2024-04-01", "rotation": "workshop", "weeks": {
+		"early": {"Monday": [{"start": "7:00", "end": "15:00"}]},
+		"late": {"Monday": [{"start": "12:00", "end": "20:00"}]}}}]`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	yann := team[len(team)-1].ID
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", yann), ""), &weeks))
+	require.Equal(t, []string{"early", "late", "off"}, []string{weeks[0].WeekType, weeks[1].WeekType, weeks[2].WeekType})
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", yann), ""), &april))
+	mondays := make([][]model.TimeSlot, 0, 5)
+	for _, day := range april {
+		if day.DayName == "Monday" {
+			mondays = append(mondays, day.TimeSlots)
+		}
+	}
+	require.Equal(t, [][]model.TimeSlot{
+		{{Start: "07:00", End: "15:00"}}, {{Start: "12:00", End: "20:00"}}, nil, {{Start: "07:00", End: "15:00"}}, {{Start: "12:00", End: "20:00"}},
+	}, mondays)
+
+	// His cycle is re-anchored so that his early week starts on April 8.
+	a.expect(http.StatusNoContent, http.MethodPut, fmt.Sprintf("/employees/%d/rotation-pattern", yann),
+		fmt.Sprintf(`{"rotationPatternId": %d, "anchor": "2024-04-10"}`, rotation.ID))
+	weeks = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d?date=2024-04-01", yann), ""), &weeks))
+	require.Equal(t, []bool{false, false, true}, []bool{weeks[0].Current, weeks[1].Current, weeks[2].Current})
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", yann), ""), &april))
+	require.Nil(t, april[0].TimeSlots, "April 1 falls in the week off")
+	require.Equal(t, []model.TimeSlot{{Start: "07:00", End: "15:00"}}, april[7].TimeSlots)
+	withSchedules, err := a.repo.GetEmployeeWithSchedules(context.Background(), yann)
+	require.NoError(t, err)
+	slot := fmt.Sprintf("/schedules/%d", withSchedules.Schedules[0].ID)
+	a.expect(http.StatusBadRequest, http.MethodPut, slot,
+		fmt.Sprintf(`{"employeeId": %d, "weekType": "A", "dayName": "Tuesday", "startTime": "09:00", "endTime": "12:00"}`, yann))
+	a.expect(http.StatusOK, http.MethodPut, slot,
+		fmt.Sprintf(`{"employeeId": %d, "weekType": "off", "dayName": "Saturday", "startTime": "09:00", "endTime": "12:00"}`, yann))
+
+	// Who changed his Saturday: the edited slot is now a manual edit of the manager, the other still imported.
+	var edited model.Schedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, slot, ""), &edited))
+	require.Equal(t, model.SourceManual, edited.Source)
+	require.NotNil(t, edited.ChangedByID)
+	var audit []model.SlotProvenance
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/audit/slots?employeeID=%d", yann), ""), &audit))
+	require.Len(t, audit, 2)
+	require.Equal(t, []string{"Saturday", model.SourceManual, "manager"}, []string{audit[0].DayName, audit[0].Source, audit[0].ChangedBy})
+	require.Equal(t, model.SourceImport, audit[1].Source)
+	audit = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/audit/slots?employeeID=%d&source=override", delphine), ""), &audit))
+	require.Len(t, audit, 1)
+	require.Equal(t, []string{model.SlotKindOverride, "2024-04-20", "manager"}, []string{audit[0].Kind, audit[0].Date, audit[0].ChangedBy})
+	a.expect(http.StatusBadRequest, http.MethodGet, "/audit/slots?source=swap", "")
+
+	// The store chat is notified through a webhook whose payload is tested before any real event.
+	var received []string
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+	defer chat.Close()
+	a.expect(http.StatusBadRequest, http.MethodPost, "/webhooks", `{"url": "ftp://chat.example", "format": "slack"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/webhooks", `{"url": "https://chat.example", "format": "teams"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/webhooks", `{"url": "https://chat.example", "format": "template", "template": "{\"text\": {{.type}}}"}`)
+	var hook model.Webhook
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/webhooks",
+		fmt.Sprintf(`{"name": "chat", "url": %q, "format": "slack"}`, chat.URL)), &hook))
+	var test service.WebhookTest
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/webhooks/%d/test", hook.ID), ""), &test))
+	require.True(t, test.Delivered)
+	require.Equal(t, []string{test.Payload}, received)
+	require.Contains(t, test.Payload, `"text":"*webhook.test*`)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/webhooks/%s", hook.UUID), fmt.Sprintf(
+		`{"name": "chat", "url": %q, "format": "template", "template": "{\"text\": {{json .data.message}}}"}`, chat.URL))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/webhooks/%d/test", hook.ID), ""), &test))
+	require.JSONEq(t, `{"text": "This is a test delivery."}`, received[1])
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/webhooks/%d", hook.ID), "")
+	a.expect(http.StatusNotFound, http.MethodPost, fmt.Sprintf("/webhooks/%d/test", hook.ID), "")
+
+	// Support fixed Yann's slots directly in the database: invalidating his caches bumps his version and
+	// tells the subscribers.
+	invalidations, unsubscribe := a.svc.Events().Subscribe(1)
+	defer unsubscribe()
+	var before model.Employee
+	require.NoError(t, a.repo.GetEmployeeByID(context.Background(), yann, &before))
+	a.expect(http.StatusUnauthorized, http.MethodPost, "/admin/cache/invalidate", "")
+	ops.expect(http.StatusBadRequest, http.MethodPost, "/admin/cache/invalidate?month=April", "")
+	ops.expect(http.StatusBadRequest, http.MethodPost, fmt.Sprintf("/admin/cache/invalidate?employeeID=%d&month=2024-04", yann), "")
+	var invalidated service.CacheInvalidation
+	require.NoError(t, json.Unmarshal(ops.expect(http.StatusOK, http.MethodPost,
+		fmt.Sprintf("/admin/cache/invalidate?employeeID=%d", yann), ""), &invalidated))
+	require.Equal(t, service.CacheInvalidation{Scope: service.CacheScope{EmployeeID: yann}, Employees: 1}, invalidated)
+	var after model.Employee
+	require.NoError(t, a.repo.GetEmployeeByID(context.Background(), yann, &after))
+	require.True(t, after.UpdatedAt.After(before.UpdatedAt))
+	event := <-invalidations
+	require.Equal(t, events.CacheInvalidated, event.Type)
+	require.Equal(t, service.CacheScope{EmployeeID: yann}, event.Data)
+	require.NoError(t, json.Unmarshal(ops.expect(http.StatusOK, http.MethodPost, "/admin/cache/invalidate", ""), &invalidated))
+	require.Equal(t, int64(4), invalidated.Employees)
+
+	// A new optician is hired before the shop sends her weeks: she is flagged and counted as unscheduled, and
+	// her calendar says why it is empty.
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Zoé", "startDate": "2024-04-22", "weeks": {}}]`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	zoe := team[len(team)-1]
+	require.Equal(t, "Zoé", zoe.Name)
+	require.True(t, zoe.Unscheduled)
+	require.False(t, team[0].Unscheduled)
+	require.JSONEq(t, `{"employees": 5, "unscheduled": 1}`, string(a.expect(http.StatusOK, http.MethodGet, "/dashboard", "")))
+	april = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", zoe.ID), ""), &april))
+	require.True(t, april[0].Unscheduled)
+	require.Empty(t, april[0].TimeSlots)
+	april = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", yann), ""), &april))
+	require.False(t, april[0].Unscheduled)
+
+	// The season ends for Nadia and Zoé on April 24: both are deactivated together or not at all.
+	a.expect(http.StatusNotFound, http.MethodPost, "/employees/deactivate", fmt.Sprintf(`{"employeeIds": [%d, 999], "endDate": "2024-04-24"}`, nadia))
+	a.expect(http.StatusBadRequest, http.MethodPost, "/employees/deactivate", fmt.Sprintf(`{"employeeIds": [%d, %d], "endDate": "2024-04-20"}`, nadia, zoe.ID))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?status=inactive", ""), &team))
+	require.Empty(t, team, "Failed deactivations change nothing")
+	var deactivated []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/employees/deactivate",
+		fmt.Sprintf(`{"employeeIds": [%d, %d, %d], "endDate": "2024-04-24"}`, nadia, zoe.ID, nadia)), &deactivated))
+	require.Len(t, deactivated, 2)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 3)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?status=inactive", ""), &team))
+	require.Len(t, team, 2)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?status=all", ""), &team))
+	require.Len(t, team, 5)
+	a.expect(http.StatusBadRequest, http.MethodGet, "/getEmployees?status=gone", "")
+
+	// Her Saturday of week B after the end date is no longer planned, and her contract is prorated to the
+	// three days she worked in her last week.
+	april = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", nadia), ""), &april))
+	require.Empty(t, april[26].TimeSlots)
+	var overtime service.OvertimeReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/overtime?month=2024-04", nadia), ""), &overtime))
+	require.Equal(t, service.OvertimeWeek{Year: 2024, Week: 17, Start: "2024-04-22", End: "2024-04-28", ContractHours: 2.57, Balance: -2.57}, overtime.Weeks[3])
+
+	// The shop settles that week A starts on April 8 for everyone: Henny, whose cycle counted from her start
+	// in February, had it in week B; Yann keeps his own anchor.
+	require.JSONEq(t, `{"anchor": "", "date": "2024-04-08", "isoYear": 2024, "isoWeek": 15, "weekType": ""}`,
+		string(a.expect(http.StatusOK, http.MethodGet, "/rotation?date=2024-04-08", "")))
+	weeks = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d?date=2024-04-08", henny), ""), &weeks))
+	require.Equal(t, []bool{false, true}, []bool{weeks[0].Current, weeks[1].Current})
+	a.expect(http.StatusBadRequest, http.MethodPut, "/rotation", `{"anchor": "April 8"}`)
+	var calendar model.RotationCalendar
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPut, "/rotation", `{"anchor": "2024-04-10"}`), &calendar))
+	require.Equal(t, "2024-04-08", calendar.Anchor.Format("2006-01-02"), "The anchor is the Monday of its week")
+	require.JSONEq(t, `{"anchor": "2024-04-08", "date": "2024-04-15", "isoYear": 2024, "isoWeek": 16, "weekType": "B"}`,
+		string(a.expect(http.StatusOK, http.MethodGet, "/rotation?date=2024-04-15", "")))
+	weeks = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d?date=2024-04-08", henny), ""), &weeks))
+	require.Equal(t, []bool{true, false}, []bool{weeks[0].Current, weeks[1].Current})
+	weeks = nil
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d?date=2024-04-01", yann), ""), &weeks))
+	require.Equal(t, []bool{false, false, true}, []bool{weeks[0].Current, weeks[1].Current, weeks[2].Current})
+}
+
+func TestCalendarIncomplete(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	conflicts := fmt.Sprintf("/employees/%d/conflicts", team[0].ID)
+	a.expect(http.StatusOK, http.MethodGet, conflicts, "")
+
+	// The deltas can no longer be read: the calendar is reported incomplete rather than without deltas.
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("ALTER TABLE schedule_delta RENAME TO schedule_delta_lost").Error)
+	var problem struct {
+		Code   string `json:"code"`
+		Detail string `json:"detail"`
+	}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusServiceUnavailable, http.MethodGet, conflicts, ""), &problem))
+	require.Equal(t, "CALENDAR_INCOMPLETE", problem.Code)
+	require.Equal(t, fmt.Sprintf("the deltas of employee %d could not be loaded", team[0].ID), problem.Detail)
+	a.expect(http.StatusNotFound, http.MethodGet, "/employees/9999/conflicts", "")
+}
+
+func TestCalendarCache(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	april := fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", team[0].ID)
+	a.expect(http.StatusUnauthorized, http.MethodGet, "/admin/cache/stats", "")
+	ops := &api{t: t, handler: a.handler, token: "ops-token"}
+	stats := func() service.CalendarCacheStats {
+		var stats service.CalendarCacheStats
+		require.NoError(t, json.Unmarshal(ops.expect(http.StatusOK, http.MethodGet, "/admin/cache/stats", ""), &stats))
+		return stats
+	}
+
+	// The second read of April is served from memory.
+	var first, second []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, april, ""), &first))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, april, ""), &second))
+	require.Equal(t, first, second)
+	got := stats()
+	require.Equal(t, []int64{1, 1}, []int64{got.Hits, got.Misses})
+	require.Equal(t, 1, got.Entries)
+
+	// An override is not part of the version of the schedules: setting it drops the cached calendar.
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-01", team[0].ID), `{"off": true, "reason": "inventory"}`)
+	require.Equal(t, int64(1), stats().Invalidations)
+	var overridden []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, april, ""), &overridden))
+	require.True(t, overridden[0].Overridden)
+	require.Empty(t, overridden[0].TimeSlots)
+	require.Equal(t, int64(2), stats().Misses)
+}
+
+func TestSupportBundle(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)
+
+	// A user cannot download the bundle, whose row counts span every tenant, nor read the dependency checks;
+	// an operator can.
+	a.expect(http.StatusUnauthorized, http.MethodGet, "/admin/support-bundle", "")
+	a.expect(http.StatusUnauthorized, http.MethodGet, "/admin/diagnostics", "")
+	ops := &api{t: t, handler: a.handler, token: "ops-token"}
+	rec := ops.do(http.MethodGet, "/admin/support-bundle", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), `attachment; filename="support-bundle-`)
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(reader)
+		require.NoError(t, err)
+		reader.Close()
+	}
+	require.Contains(t, files, "manifest.json")
+	require.Contains(t, files, "config.json")
+
+	// The bundle holds the schema version and the number of rows of each table, not their content.
+	var migrations service.MigrationReport
+	require.NoError(t, json.Unmarshal(files["migrations.json"], &migrations))
+	require.Zero(t, migrations.Pending)
+	var counts []model.TableRowCount
+	require.NoError(t, json.Unmarshal(files["row_counts.json"], &counts))
+	rows := map[string]int64{}
+	for _, count := range counts {
+		require.False(t, count.Missing, count.Table)
+		rows[count.Table] = count.Rows
+	}
+	require.Equal(t, int64(1), rows["employees"])
+	require.Equal(t, int64(1), rows["schedules"])
+	require.Equal(t, int64(1), rows["users"])
+	for name, content := range files {
+		require.NotContains(t, string(content), "Ines", name)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	a.svc.StartWebhookQueue(ctx, service.WebhookQueue{PollInterval: 10 * time.Millisecond})
+
+	// The payroll service accepts every delivery; the chat bot is down.
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	payroll := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received, bodies = append(received, r), append(bodies, body)
+	}))
+	defer payroll.Close()
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer chat.Close()
+
+	a.expect(http.StatusBadRequest, http.MethodPost, "/webhooks", fmt.Sprintf(`{"url": %q, "events": ["employee.deleted"]}`, payroll.URL))
+	var hook, bot model.Webhook
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/webhooks",
+		fmt.Sprintf(`{"name": "payroll", "url": %q, "events": ["leave.approved", "employee.created", "leave.approved"]}`, payroll.URL)), &hook))
+	require.Equal(t, []string{"employee.created", "leave.approved"}, hook.Events)
+	require.True(t, strings.HasPrefix(hook.Secret, "whsec_"), "the secret is shown once the webhook is created")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/webhooks",
+		fmt.Sprintf(`{"name": "chat", "url": %q, "format": "slack", "events": ["leave.approved"]}`, chat.URL)), &bot))
+	var hooks []model.Webhook
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/webhooks", ""), &hooks))
+	require.Len(t, hooks, 2)
+	require.Empty(t, hooks[0].Secret, "the secret is not listed")
+
+	// Importing an employee and approving its leave notify the payroll service, in order, signed.
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	var requested []model.EmployeeHoliday
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/employees/%d/leave", team[0].ID),
+		`{"from": "2024-04-08", "to": "2024-04-08", "description": "congé"}`), &requested))
+	a.expect(http.StatusOK, http.MethodPatch, fmt.Sprintf("/leave/%d/approve", requested[0].ID), "")
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	var types []string
+	for i, r := range received {
+		types = append(types, r.Header.Get(webhook.EventHeader))
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhook.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, webhook.Sign(hook.Secret, timestamp, bodies[i]), r.Header.Get(webhook.SignatureHeader))
+	}
+	require.Equal(t, []string{"employee.created", "leave.approved"}, types)
+	require.Contains(t, string(bodies[0]), `"name":"Ines"`)
+
+	// The delivery the chat bot rejected waits for its next attempt.
+	var deliveries []model.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries = nil
+		require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/webhooks/%d/deliveries", bot.ID), ""), &deliveries))
+		return len(deliveries) == 1 && deliveries[0].Attempts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, model.DeliveryPending, deliveries[0].Status)
+	require.Equal(t, http.StatusInternalServerError, deliveries[0].LastStatus)
+	require.True(t, deliveries[0].NextAttemptAt.After(time.Now().Add(20*time.Second)))
+
+	// A leaked secret is rotated.
+	var rotated model.Webhook
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/webhooks/%d/secret", hook.ID), ""), &rotated))
+	require.NotEqual(t, hook.Secret, rotated.Secret)
+	require.True(t, strings.HasPrefix(rotated.Secret, "whsec_"))
+}
+
+func TestValidationHooks(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+
+	// The store policy, checked by an external service, keeps the forklift away from Sundays.
+	var mu sync.Mutex
+	down := false
+	var stages []string
+	policy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		var change struct {
+			Stage     string           `json:"stage"`
+			Employees []model.Employee `json:"employees"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&change))
+		stages = append(stages, change.Stage)
+		violations := []map[string]string{}
+		for _, employee := range change.Employees {
+			for _, slot := range employee.Schedules {
+				if slot.Task == "forklift" && slot.DayName == "Sunday" {
+					violations = append(violations, map[string]string{"rule": "store-policy", "employee": employee.Name, "message": "no forklift on Sundays"})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"violations": violations})
+	}))
+	defer policy.Close()
+
+	var config service.ValidationConfig
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/validation", ""), &config))
+	require.True(t, config.Default)
+	require.Empty(t, config.Validators)
+	require.Contains(t, config.Available, "max-daily-hours")
+	a.expect(http.StatusBadRequest, http.MethodPut, "/validation", `{"validators": ["max-yearly-hours"]}`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPut, "/validation",
+		fmt.Sprintf(`{"validators": ["max-daily-hours", "max-daily-hours"], "url": %q}`, policy.URL)), &config))
+	require.False(t, config.Default)
+	require.Equal(t, []string{"max-daily-hours"}, config.Validators)
+
+	// An 11-hour Monday is rejected with the whole import.
+	body := a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "8:00", "end": "13:00"}, {"start": "13:30", "end": "19:30"}]}, "B": {}}}]`)
+	require.Contains(t, string(body), "SCHEDULE_RULE_VIOLATED")
+	require.Contains(t, string(body), "max-daily-hours")
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Empty(t, team)
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "8:00", "end": "13:00"}], "Sunday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 1)
+	ines, err := a.repo.GetEmployeeWithSchedules(context.Background(), team[0].ID)
+	require.NoError(t, err)
+	monday, sunday := fmt.Sprintf("/schedules/%d", ines.Schedules[0].ID), fmt.Sprintf("/schedules/%d", ines.Schedules[1].ID)
+
+	// Stretching Monday over 10 hours is rejected, as is the forklift on Sunday.
+	a.expect(http.StatusBadRequest, http.MethodPut, monday,
+		fmt.Sprintf(`{"employeeId": %d, "weekType": "A", "dayName": "Monday", "startTime": "08:00", "endTime": "18:30"}`, ines.ID))
+	a.expect(http.StatusOK, http.MethodPut, monday,
+		fmt.Sprintf(`{"employeeId": %d, "weekType": "A", "dayName": "Monday", "startTime": "08:00", "endTime": "17:00"}`, ines.ID))
+	body = a.expect(http.StatusBadRequest, http.MethodPatch, sunday, `{"task": "forklift"}`)
+	require.Contains(t, string(body), "no forklift on Sundays")
+	a.expect(http.StatusOK, http.MethodPatch, sunday, `{"task": "till"}`)
+	mu.Lock()
+	require.Equal(t, []string{"import", "import", "update", "update", "patch", "patch"}, stages)
+	down = true
+	mu.Unlock()
+
+	// Nothing is written while the schedules cannot be checked.
+	body = a.expect(http.StatusServiceUnavailable, http.MethodPatch, sunday, `{"task": "optics"}`)
+	require.Contains(t, string(body), "VALIDATOR_UNAVAILABLE")
+	var slot model.Schedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, sunday, ""), &slot))
+	require.Equal(t, "till", slot.Task)
+}
+
+func TestLabourRules(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusOK, http.MethodPut, "/validation",
+		`{"validators": ["max-daily-hours", "min-daily-rest", "max-consecutive-days", "max-sundays"]}`)
+
+	// Closing on Saturday night and opening on Sunday morning leaves 9 hours of rest.
+	body := a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Saturday": [{"start": "14:00", "end": "22:00"}], "Sunday": [{"start": "7:00", "end": "12:00"}]}, "B": {}}}]`)
+	require.Contains(t, string(body), "min-daily-rest: the rest between Saturday of week A and Sunday of week A lasts 9h")
+	// Week A closes on Sunday and week B opens on Monday: the weeks follow each other.
+	body = a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Sunday": [{"start": "14:00", "end": "23:00"}]}, "B": {"Monday": [{"start": "6:00", "end": "12:00"}]}}}]`)
+	require.Contains(t, string(body), "the rest between Sunday of week A and Monday of week B lasts 7h")
+
+	morning := `[{"start": "9:00", "end": "12:00"}]`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Monday": `+morning+`, "Tuesday": `+morning+`, "Wednesday": `+morning+`, "Thursday": `+morning+`, "Friday": `+morning+`, "Saturday": `+morning+`},
+		"B": {"Monday": `+morning+`, "Tuesday": `+morning+`, "Wednesday": `+morning+`, "Thursday": `+morning+`, "Friday": `+morning+`}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	violations := fmt.Sprintf("/employees/%d/violations", team[0].ID)
+	var report service.ViolationReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, violations+"?month=2024-04", ""), &report))
+	require.Equal(t, "2024-04", report.Month)
+	require.Equal(t, []string{"max-daily-hours", "min-daily-rest", "max-consecutive-days", "max-sundays"}, report.Rules)
+	require.Empty(t, report.Violations)
+
+	// Working the first Sunday of April makes 12 days in a row; the month is checked with its overrides.
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-07", team[0].ID),
+		`{"reason": "inventory", "slots": [{"startTime": "09:00", "endTime": "12:00"}]}`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, violations+"?month=April&year=2024", ""), &report))
+	require.Equal(t, []validation.Violation{{Rule: "max-consecutive-days", EmployeeID: team[0].ID, Employee: "Ines",
+		Message: "12 days are worked in a row from 2024-04-01 to 2024-04-12, over the 6 allowed"}}, report.Violations)
+	a.expect(http.StatusBadRequest, http.MethodGet, violations, "")
+	a.expect(http.StatusNotFound, http.MethodGet, "/employees/999/violations?month=2024-04", "")
+}
+
+func TestCoverageGaps(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	week := `{"Monday": [{"start": "9:00", "end": "12:00", "location": "Gare"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+week+`, "B": `+week+`}},
+		{"name": "Paul", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "10:00", "end": "16:00"}]}, "B": {"Monday": [{"start": "10:00", "end": "16:00"}]}}}]`)
+
+	a.expect(http.StatusBadRequest, http.MethodPost, "/staffing-requirements", `{"dayName": "Monday", "startTime": "08:00", "endTime": "12:00", "headcount": 0}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/staffing-requirements", `{"dayName": "Funday", "startTime": "08:00", "endTime": "12:00", "headcount": 2}`)
+	var everywhere, gare model.StaffingRequirement
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/staffing-requirements",
+		`{"dayName": "Monday", "startTime": "08:00", "endTime": "12:00", "headcount": 2}`), &everywhere))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/staffing-requirements",
+		`{"location": "Gare", "dayName": "Monday", "startTime": "09:30", "endTime": "11:00", "headcount": 1}`), &gare))
+	var requirements []model.StaffingRequirement
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/staffing-requirements", ""), &requirements))
+	require.Len(t, requirements, 2)
+
+	// Ines opens on her own from 9:00 and nobody is there before; the station needs her from 9:30.
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/2024-04-15", team[0].ID),
+		`{"reason": "doctor", "slots": [{"startTime": "10:00", "endTime": "12:00", "location": "Gare"}]}`)
+	var report struct {
+		Gaps []service.CoverageGap `json:"gaps"`
+	}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/coverage/gaps?month=2024-04", ""), &report))
+	require.Len(t, report.Gaps, 10)
+	require.Equal(t, []service.CoverageGap{
+		{Date: "2024-04-08", DayName: "Monday", RequirementID: everywhere.ID, Start: "08:00", End: "09:00", Required: 2, Scheduled: 0},
+		{Date: "2024-04-08", DayName: "Monday", RequirementID: everywhere.ID, Start: "09:00", End: "10:00", Required: 2, Scheduled: 1},
+		{Date: "2024-04-15", DayName: "Monday", RequirementID: everywhere.ID, Start: "08:00", End: "10:00", Required: 2, Scheduled: 0},
+		{Date: "2024-04-15", DayName: "Monday", RequirementID: gare.ID, Location: "Gare", Start: "09:30", End: "10:00", Required: 1, Scheduled: 0},
+	}, report.Gaps[2:6])
+
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/staffing-requirements/%d", everywhere.ID), "")
+	a.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/staffing-requirements/%d", everywhere.ID), "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/coverage/gaps?month=April&year=2024", ""), &report))
+	require.Len(t, report.Gaps, 1)
+	require.Equal(t, "2024-04-15", report.Gaps[0].Date)
+	a.expect(http.StatusBadRequest, http.MethodGet, "/coverage/gaps", "")
+}
+
+func TestRosterSuggestions(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusOK, http.MethodPut, "/validation", `{"validators": ["min-daily-rest"]}`)
+	ines := `{"Monday": [{"start": "9:00", "end": "12:00"}], "Sunday": [{"start": "14:00", "end": "22:00"}]}`
+	paul := `{"Tuesday": [{"start": "9:00", "end": "17:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Ines", "startDate": "2024-04-01", "contractWeeklyHours": 12, "weeks": {"A": `+ines+`, "B": `+ines+`}},
+		{"name": "Paul", "startDate": "2024-04-01", "contractWeeklyHours": 35, "weeks": {"A": `+paul+`, "B": `+paul+`}},
+		{"name": "Zoé", "startDate": "2024-04-01", "weeks": {"A": {}, "B": {}}}]`)
+	a.expect(http.StatusCreated, http.MethodPost, "/staffing-requirements", `{"dayName": "Monday", "startTime": "08:00", "endTime": "12:00", "headcount": 2}`)
+
+	a.expect(http.StatusBadRequest, http.MethodPost, "/roster/suggest", `{"from": "2024-04-08", "to": "2024-04-01"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/roster/suggest", `{"from": "2024-04-01", "to": "2024-06-01"}`)
+	// Paul has the most contract hours left. Ines could open at 8:00 but would rest 10 hours after closing on
+	// Sunday; Zoé has no contract hours and comes last.
+	var suggestions service.RosterSuggestions
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/roster/suggest", `{"from": "2024-04-08", "to": "2024-04-08"}`), &suggestions))
+	require.Empty(t, suggestions.Unfilled)
+	require.Len(t, suggestions.Suggestions, 2)
+	require.Equal(t, "Paul", suggestions.Suggestions[0].Employee)
+	require.Equal(t, model.TimeSlot{Start: "08:00", End: "12:00"}, suggestions.Suggestions[0].Slot)
+	zoe := suggestions.Suggestions[1]
+	require.Equal(t, "Zoé", zoe.Employee)
+	require.Equal(t, "2024-04-08", zoe.Date)
+	require.Equal(t, []model.TimeSlot{}, zoe.Before)
+	require.Equal(t, []model.TimeSlot{{Start: "08:00", End: "09:00"}}, zoe.After)
+
+	body, err := json.Marshal(zoe)
+	require.NoError(t, err)
+	var override model.ScheduleOverride
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/roster/suggest/accept", string(body)), &override))
+	require.Len(t, override.Slots, 1)
+	require.Equal(t, "08:00", override.Slots[0].StartTime.Format("15:04"))
+	// Zoé works 8:00-9:00 now: the suggestion was made for a day without slots.
+	a.expect(http.StatusConflict, http.MethodPost, "/roster/suggest/accept", string(body))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/roster/suggest", `{"from": "2024-04-08", "to": "2024-04-08"}`), &suggestions))
+	require.Len(t, suggestions.Suggestions, 1)
+	require.Equal(t, "Paul", suggestions.Suggestions[0].Employee)
+}
+
+func TestUnavailability(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	week := `{"Monday": [{"start": "9:00", "end": "12:00"}], "Tuesday": [{"start": "18:00", "end": "21:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+week+`, "B": `+week+`}},
+		{"name": "Paul", "startDate": "2024-04-01", "contractWeeklyHours": 35, "weeks": {"A": {}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := fmt.Sprintf("/employees/%d/unavailability", team[0].ID)
+
+	a.expect(http.StatusBadRequest, http.MethodPost, ines, `{"dayName": "Funday"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, ines, `{"reason": "no day"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, ines, `{"dayName": "Tuesday", "start": "18:00"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, ines, `{"from": "2024-04-09", "to": "2024-04-08"}`)
+	a.expect(http.StatusNotFound, http.MethodPost, "/employees/999/unavailability", `{"dayName": "Tuesday"}`)
+	var evenings, wedding model.Unavailability
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, ines,
+		`{"dayName": "Tuesday", "start": "17:00", "end": "23:00", "reason": "evening classes"}`), &evenings))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, ines, `{"from": "2024-04-08", "reason": "wedding"}`), &wedding))
+	require.Equal(t, "2024-04-08", wedding.ValidTo.Format("2006-01-02"))
+	var unavailabilities []model.Unavailability
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, ines, ""), &unavailabilities))
+	require.Len(t, unavailabilities, 2)
+
+	// Every Tuesday evening of April collides with the classes, and the Monday morning with the wedding.
+	var conflicts []service.UnavailabilityConflict
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, ines+"/conflicts?month=2024-04", ""), &conflicts))
+	require.Len(t, conflicts, 6)
+	require.Equal(t, service.UnavailabilityConflict{Date: "2024-04-02", DayName: "Tuesday", Slot: model.TimeSlot{Start: "18:00", End: "21:00"},
+		UnavailabilityID: evenings.ID, Reason: "evening classes"}, conflicts[0])
+	require.Equal(t, "2024-04-08", conflicts[1].Date)
+	require.Equal(t, wedding.ID, conflicts[1].UnavailabilityID)
+
+	// The classes start on the 20th, and the wedding is called off.
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("%s/%d", ines, evenings.ID),
+		`{"dayName": "Tuesday", "from": "2024-04-20", "start": "17:00", "end": "23:00", "reason": "evening classes"}`)
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("%s/%d", ines, wedding.ID), "")
+	a.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("%s/%d", ines, wedding.ID), "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, ines+"/conflicts?month=2024-04", ""), &conflicts))
+	require.Len(t, conflicts, 2)
+	require.Equal(t, "2024-04-23", conflicts[0].Date)
+
+	// Paul has contract hours left but cannot work on Wednesday evenings: Ines is suggested.
+	a.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/employees/%d/unavailability", team[1].ID), `{"dayName": "Wednesday", "start": "17:00", "end": "22:00"}`)
+	a.expect(http.StatusCreated, http.MethodPost, "/staffing-requirements", `{"dayName": "Wednesday", "startTime": "18:00", "endTime": "20:00", "headcount": 1}`)
+	var suggestions service.RosterSuggestions
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/roster/suggest", `{"from": "2024-04-10", "to": "2024-04-10"}`), &suggestions))
+	require.Len(t, suggestions.Suggestions, 1)
+	require.Equal(t, "Ines", suggestions.Suggestions[0].Employee)
+}
+
+func TestVarianceReport(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	week := `{"Tuesday": [{"start": "9:00", "end": "12:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+week+`, "B": `+week+`}},
+		{"name": "Paul", "startDate": "2024-04-01", "weeks": {"A": {}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0].ID
+	if team[0].Name != "Ines" {
+		ines = team[1].ID
+	}
+
+	// Ines works her five Tuesdays of April: she forgets to punch out on the 9th, to punch in on the 16th, and
+	// does not punch at all on the 23rd.
+	for i, punch := range []struct{ kind, at string }{
+		{"in", "2024-04-02T09:00:00Z"}, {"out", "2024-04-02T12:30:00Z"},
+		{"in", "2024-04-09T09:00:00Z"},
+		{"out", "2024-04-16T12:00:00Z"},
+		{"in", "2024-04-30T09:00:00Z"}, {"out", "2024-04-30T12:00:00Z"},
+	} {
+		a.expect(http.StatusCreated, http.MethodPost, "/timeclock/punches", fmt.Sprintf(
+			`{"employeeId": %d, "kind": %q, "deviceId": "kiosk-1", "nonce": "n%d", "punchedAt": %q}`, ines, punch.kind, i, punch.at))
+	}
+	var report service.VarianceReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/reports/variance?month=April&year=2024", ""), &report))
+	require.Len(t, report.Employees, 2)
+	for _, variance := range report.Employees {
+		if variance.EmployeeID != ines {
+			require.Equal(t, service.HoursVariance{EmployeeID: variance.EmployeeID, Employee: "Paul", MissingPunches: []service.MissingPunch{}}, variance)
+			continue
+		}
+		require.Equal(t, 15.0, variance.PlannedHours)
+		require.Equal(t, 6.5, variance.ClockedHours)
+		require.Equal(t, -8.5, variance.DeltaHours)
+		require.Equal(t, []service.MissingPunch{
+			{Date: "2024-04-09", DayName: "Tuesday", PlannedHours: 3, Issue: service.PunchMissingOut},
+			{Date: "2024-04-16", DayName: "Tuesday", PlannedHours: 3, Issue: service.PunchMissingIn},
+			{Date: "2024-04-23", DayName: "Tuesday", PlannedHours: 3, Issue: service.PunchesNone},
+		}, variance.MissingPunches)
+	}
+	a.expect(http.StatusBadRequest, http.MethodGet, "/reports/variance?month=Avril&year=2024", "")
+}
+
+func TestClosureDays(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	week := `{"Monday": [{"start": "9:00", "end": "12:00"}], "Friday": [{"start": "14:00", "end": "18:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+week+`, "B": `+week+`}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	calendar := fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", team[0].ID)
+
+	a.expect(http.StatusBadRequest, http.MethodPost, "/closures", `{"date": "2024-04-31", "name": "inventory"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/closures", `{"date": "2024-04-12", "name": " "}`)
+	// The shop closes for the inventory on Friday the 12th, and Ines still comes on Monday the 22nd, the day
+	// of the company party, which only marks the day.
+	var inventory, party model.ClosureDay
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/closures",
+		`{"date": "2024-04-12", "name": "inventory", "clearSlots": true}`), &inventory))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusCreated, http.MethodPost, "/closures", `{"date": "2024-04-22", "name": "company party"}`), &party))
+	a.expect(http.StatusConflict, http.MethodPost, "/closures", `{"date": "2024-04-12", "name": "audit"}`)
+	var closures []model.ClosureDay
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/closures?from=2024-04-01&to=2024-04-15", ""), &closures))
+	require.Len(t, closures, 1)
+	require.Equal(t, "inventory", closures[0].Name)
+
+	var april []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, calendar, ""), &april))
+	require.Equal(t, "inventory", april[11].HolidayName)
+	require.Equal(t, model.HolidayCompany, april[11].HolidaySource)
+	require.True(t, april[11].Closed)
+	require.Empty(t, april[11].TimeSlots)
+	require.Equal(t, "company party", april[21].HolidayName)
+	require.False(t, april[21].Closed)
+	require.Equal(t, []model.TimeSlot{{Start: "09:00", End: "12:00"}}, april[21].TimeSlots)
+	require.Len(t, april[4].TimeSlots, 1)
+
+	// The inventory moves to the 5th, and the party is called off.
+	a.expect(http.StatusConflict, http.MethodPut, fmt.Sprintf("/closures/%d", inventory.ID), `{"date": "2024-04-22", "name": "inventory"}`)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/closures/%d", inventory.ID), `{"date": "2024-04-05", "name": "inventory", "clearSlots": true}`)
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/closures/%d", party.ID), "")
+	a.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/closures/%d", party.ID), "")
+	a.expect(http.StatusNotFound, http.MethodPut, fmt.Sprintf("/closures/%d", party.ID), `{"date": "2024-04-23", "name": "party"}`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, calendar, ""), &april))
+	require.True(t, april[4].Closed)
+	require.Empty(t, april[4].TimeSlots)
+	require.Len(t, april[11].TimeSlots, 1)
+	require.Empty(t, april[21].HolidayName)
+}
+
+func TestEventStream(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines, err := a.repo.GetEmployeeWithSchedules(context.Background(), team[0].ID)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(a.handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/prox/api/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/prox/api/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+	next := func() (string, string) {
+		var name, data string
+		for lines.Scan() && lines.Text() != "" {
+			field, value, _ := strings.Cut(lines.Text(), ": ")
+			switch field {
+			case "event":
+				name = value
+			case "data":
+				data = value
+			}
+		}
+		return name, data
+	}
+	next()
+
+	// A slot edited, a cache rebuilt and a row changed by another instance: the cache is not a change of
+	// the roster.
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/schedules/%d", ines.Schedules[0].ID),
+		fmt.Sprintf(`{"employeeId": %d, "weekType": "A", "dayName": "Monday", "startTime": "09:00", "endTime": "13:00"}`, ines.ID))
+	a.svc.Events().Publish(events.New(events.CacheInvalidated, nil))
+	a.svc.PublishDataChange(model.DataChange{Table: "employee_holidays", Op: "insert", ID: 7, EmployeeID: ines.ID})
+	name, data := next()
+	require.Equal(t, events.ScheduleUpdated, name)
+	var event struct {
+		Type string                 `json:"type"`
+		Data service.ScheduleUpdate `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	require.Equal(t, service.ScheduleUpdate{EmployeeID: ines.ID, Change: service.ChangeSlot, Action: service.ActionWritten, ID: ines.Schedules[0].ID},
+		service.ScheduleUpdate{EmployeeID: event.Data.EmployeeID, Change: event.Data.Change, Action: event.Data.Action, ID: event.Data.ID})
+	name, data = next()
+	require.Equal(t, events.ScheduleChanged, name)
+	require.Contains(t, data, `"table":"employee_holidays"`)
+}
+
+func TestEmployeeSchedules(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Monday": [{"start": "14:00", "end": "18:00"}, {"start": "9:00", "end": "12:00"}], "Tuesday": [{"start": "9:00", "end": "12:00"}]},
+		"B": {"Monday": [{"start": "10:00", "end": "16:00"}]}}}, {"name": "Paul", "startDate": "2024-04-01", "weeks": {"A": {}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines, paul := team[0].ID, team[1].ID
+	slots := fmt.Sprintf("/employees/%d/schedules", ines)
+	times := func(body []byte) []string {
+		var list []model.Schedule
+		require.NoError(t, json.Unmarshal(body, &list))
+		var out []string
+		for _, slot := range list {
+			out = append(out, fmt.Sprintf("%s %s %s-%s", slot.WeekType, slot.DayName, slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04")))
+		}
+		return out
+	}
+
+	// The slots of a week or a day, in order.
+	require.Equal(t, []string{"A Monday 09:00-12:00", "A Monday 14:00-18:00", "A Tuesday 09:00-12:00", "B Monday 10:00-16:00"},
+		times(a.expect(http.StatusOK, http.MethodGet, slots, "")))
+	require.Equal(t, []string{"A Monday 09:00-12:00", "A Monday 14:00-18:00"}, times(a.expect(http.StatusOK, http.MethodGet, slots+"?weekType=A&day=Monday", "")))
+	a.expect(http.StatusBadRequest, http.MethodGet, slots+"?day=Funday", "")
+	a.expect(http.StatusNotFound, http.MethodGet, "/employees/999/schedules", "")
+
+	// Monday of week A is replaced whole, the slots of the body checked against each other.
+	a.expect(http.StatusConflict, http.MethodPut, slots+"?weekType=A&day=Monday",
+		`[{"startTime": "08:00", "endTime": "12:00"}, {"startTime": "11:00", "endTime": "13:00"}]`)
+	a.expect(http.StatusBadRequest, http.MethodPut, slots+"?weekType=A&day=Monday", `[{"dayName": "Friday", "startTime": "08:00", "endTime": "12:00"}]`)
+	require.Equal(t, []string{"A Monday 08:00-12:00", "A Monday 13:00-17:00"}, times(a.expect(http.StatusOK, http.MethodPut, slots+"?weekType=A&day=Monday",
+		`[{"startTime": "13:00", "endTime": "17:00", "task": "lab"}, {"startTime": "08:00", "endTime": "12:00"}]`)))
+	require.Equal(t, []string{"A Monday 08:00-12:00", "A Monday 13:00-17:00", "A Tuesday 09:00-12:00", "B Monday 10:00-16:00"},
+		times(a.expect(http.StatusOK, http.MethodGet, slots, "")))
+	var monday []model.Schedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, slots+"?weekType=A&day=Monday", ""), &monday))
+	require.Equal(t, model.SourceManual, monday[0].Source)
+
+	// One slot is moved to Wednesday, only through its own employee.
+	slot := fmt.Sprintf("%s/%d", slots, monday[1].ID)
+	a.expect(http.StatusNotFound, http.MethodPatch, fmt.Sprintf("/employees/%d/schedules/%d", paul, monday[1].ID), `{"dayName": "Wednesday"}`)
+	a.expect(http.StatusBadRequest, http.MethodPatch, slot, `{"endTime": "13:00"}`)
+	var moved model.Schedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPatch, slot, `{"dayName": "Wednesday"}`), &moved))
+	require.Equal(t, []string{"Wednesday", "lab"}, []string{moved.DayName, moved.Task})
+	require.Equal(t, []string{"A Wednesday 13:00-17:00"}, times(a.expect(http.StatusOK, http.MethodGet, slots+"?day=Wednesday", "")))
+
+	// Week B is cleared, then the slot moved.
+	require.JSONEq(t, `{"deleted": 1}`, string(a.expect(http.StatusOK, http.MethodDelete, slots+"?weekType=B", "")))
+	a.expect(http.StatusNoContent, http.MethodDelete, slot, "")
+	a.expect(http.StatusNotFound, http.MethodGet, slot, "")
+	require.Equal(t, []string{"A Monday 08:00-12:00", "A Tuesday 09:00-12:00"}, times(a.expect(http.StatusOK, http.MethodGet, slots, "")))
+}
+
+func TestCopySchedules(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Monday": [{"start": "9:00", "end": "12:00", "task": "lab"}], "Tuesday": [{"start": "9:00", "end": "12:00"}]},
+		"B": {"Monday": [{"start": "10:00", "end": "16:00"}]}}}, {"name": "Paul", "startDate": "2024-04-01", "weeks": {"A": {}, "B": {}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines, paul := team[0].ID, team[1].ID
+	times := func(body []byte) []string {
+		var list []model.Schedule
+		require.NoError(t, json.Unmarshal(body, &list))
+		var out []string
+		for _, slot := range list {
+			out = append(out, fmt.Sprintf("%s %s %s-%s %s", slot.WeekType, slot.DayName, slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04"), slot.Task))
+		}
+		return out
+	}
+
+	// Week B of Ines becomes a copy of week A.
+	copyWeek := fmt.Sprintf("/employees/%d/weeks/copy", ines)
+	require.Equal(t, []string{"B Monday 09:00-12:00 lab", "B Tuesday 09:00-12:00 "},
+		times(a.expect(http.StatusOK, http.MethodPost, copyWeek+"?from=A&to=B", "")))
+	require.Equal(t, []string{"A Monday 09:00-12:00 lab", "A Tuesday 09:00-12:00 ", "B Monday 09:00-12:00 lab", "B Tuesday 09:00-12:00 "},
+		times(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/schedules", ines), "")))
+	a.expect(http.StatusBadRequest, http.MethodPost, copyWeek+"?from=A&to=A", "")
+	a.expect(http.StatusBadRequest, http.MethodPost, copyWeek+"?from=C&to=A", "")
+	a.expect(http.StatusBadRequest, http.MethodPost, copyWeek+"?from=A&to=C", "")
+	a.expect(http.StatusNotFound, http.MethodPost, "/employees/999/weeks/copy?from=A&to=B", "")
+
+	// Paul gets the whole pattern of Ines, his own slots replaced.
+	copyTo := fmt.Sprintf("/employees/%d/pattern/copyTo/%d", ines, paul)
+	require.Len(t, times(a.expect(http.StatusOK, http.MethodPost, copyTo, "")), 4)
+	var april []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", paul), ""), &april))
+	require.Len(t, april[0].TimeSlots, 1)
+	a.expect(http.StatusBadRequest, http.MethodPost, fmt.Sprintf("/employees/%d/pattern/copyTo/%d", ines, ines), "")
+	a.expect(http.StatusNotFound, http.MethodPost, fmt.Sprintf("/employees/%d/pattern/copyTo/999", ines), "")
+}
+
+func TestScheduleVersions(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {
+		"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {"Monday": [{"start": "10:00", "end": "16:00"}]}}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0].ID
+	slots := fmt.Sprintf("/employees/%d/schedules", ines)
+	mondays := func() map[string]string {
+		var days []model.MonthlySchedule
+		require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", ines), ""), &days))
+		out := make(map[string]string)
+		for _, day := range days {
+			if day.DayName == "Monday" {
+				var times []string
+				for _, slot := range day.TimeSlots {
+					times = append(times, slot.Start+"-"+slot.End)
+				}
+				out[day.Date] = strings.Join(times, " ")
+			}
+		}
+		return out
+	}
+
+	// Monday of week A changes from the 15th on: the 1st keeps the slot replaced.
+	a.expect(http.StatusOK, http.MethodPut, slots+"?weekType=A&day=Monday&effectiveFrom=2024-04-15", `[{"startTime": "13:00", "endTime": "17:00"}]`)
+	require.Equal(t, map[string]string{"2024-04-01": "09:00-12:00", "2024-04-08": "10:00-16:00", "2024-04-15": "13:00-17:00",
+		"2024-04-22": "10:00-16:00", "2024-04-29": "13:00-17:00"}, mondays())
+
+	// A version cannot start before the slots in force, and week B is cleared from the 22nd on.
+	a.expect(http.StatusConflict, http.MethodPut, slots+"?weekType=B&effectiveFrom=2024-04-10", `[]`)
+	a.expect(http.StatusBadRequest, http.MethodPut, slots+"?weekType=B&effectiveFrom=22/04/2024", `[]`)
+	a.expect(http.StatusOK, http.MethodPut, slots+"?weekType=B&effectiveFrom=2024-04-22", `[]`)
+	require.Equal(t, map[string]string{"2024-04-01": "09:00-12:00", "2024-04-08": "10:00-16:00", "2024-04-15": "13:00-17:00",
+		"2024-04-22": "", "2024-04-29": "13:00-17:00"}, mondays())
+
+	var history service.ScheduleHistory
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/schedule-versions", ines), ""), &history))
+	require.Equal(t, "2024-04-22", history.CurrentFrom.Format("2006-01-02"))
+	require.Len(t, history.Versions, 2)
+	require.Equal(t, []string{"2024-04-01", "2024-04-15"}, []string{history.Versions[0].EffectiveFrom.Format("2006-01-02"),
+		history.Versions[0].EffectiveUntil.Format("2006-01-02")})
+	require.Len(t, history.Versions[1].Pattern.Schedules, 2, "the pattern replaced on the 22nd has both Mondays")
+	a.expect(http.StatusNotFound, http.MethodGet, "/employees/999/schedule-versions", "")
+
+	// A single slot changes from an effective date too, and is removed from another.
+	var weekA []model.Schedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, slots+"?weekType=A", ""), &weekA))
+	slot := fmt.Sprintf("%s/%d", slots, weekA[0].ID)
+	a.expect(http.StatusConflict, http.MethodPut, slot+"?effectiveFrom=2024-04-15", `{"weekType": "A", "dayName": "Monday", "startTime": "14:00", "endTime": "18:00"}`)
+	a.expect(http.StatusOK, http.MethodPut, slot+"?effectiveFrom=2024-04-29", `{"weekType": "A", "dayName": "Monday", "startTime": "14:00", "endTime": "18:00"}`)
+	require.Equal(t, map[string]string{"2024-04-01": "09:00-12:00", "2024-04-08": "10:00-16:00", "2024-04-15": "13:00-17:00",
+		"2024-04-22": "", "2024-04-29": "14:00-18:00"}, mondays())
+	a.expect(http.StatusBadRequest, http.MethodDelete, fmt.Sprintf("/schedules/%d?effectiveFrom=May", weekA[0].ID), "")
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/schedules/%d?effectiveFrom=2024-05-06", weekA[0].ID), "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/schedule-versions", ines), ""), &history))
+	require.Equal(t, "2024-05-06", history.CurrentFrom.Format("2006-01-02"))
+	require.Len(t, history.Versions, 4)
+	require.Equal(t, "14:00", history.Versions[3].Pattern.Schedules[0].StartTime.Format("15:04"), "the slot removed on May 6")
+	require.Equal(t, "14:00-18:00", mondays()["2024-04-29"], "the slot is removed from May 6 only")
+}
+
+func TestImportRollback(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	require.JSONEq(t, `{"importId": 1, "loaded": 1, "created": 1, "updated": 0}`, string(a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "department": "shop", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}}]`)))
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0].ID
+	slots := func() []model.Schedule {
+		var list []model.Schedule
+		require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/employees/%d/schedules", ines), ""), &list))
+		return list
+	}
+	before := slots()
+
+	// A bad upload moves Ines to the workshop with other slots and brings a newcomer.
+	upload := `[
+		{"name": "Ines", "startDate": "2024-04-01", "department": "workshop", "weeks": {"A": {"Tuesday": [{"start": "13:00", "end": "17:00"}]}}},
+		{"name": "Paul", "startDate": "2024-04-01", "weeks": {"B": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`
+	// Validating it first writes nothing and tells what it would change; overlapping slots are caught.
+	var preview service.ImportPreview
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees?validate=true&upsert=true", upload), &preview))
+	require.Equal(t, []int{1, 1, 2}, []int{preview.Created, preview.Updated, preview.Slots})
+	require.Equal(t, service.ImportUpdate, preview.Employees[0].Action)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees?validate=true", `[{"name": "Zoé", "startDate": "2024-04-01",
+		"weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}, {"start": "11:00", "end": "13:00"}]}}}]`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees?validate=yes", upload)
+	require.JSONEq(t, `{"importId": 2, "loaded": 2, "created": 1, "updated": 1}`,
+		string(a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees?upsert=true", upload)))
+	var imports []model.EmployeeImport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/imports", ""), &imports))
+	require.Len(t, imports, 2)
+	require.Equal(t, []string{model.ImportReplaced, model.ImportCreated}, []string{imports[0].Changes[0].Action, imports[0].Changes[1].Action})
+
+	// The first import cannot be rolled back before the one replacing Ines after it.
+	a.expect(http.StatusConflict, http.MethodPost, "/imports/1/rollback", "")
+	a.expect(http.StatusNotFound, http.MethodPost, "/imports/999/rollback", "")
+	a.expect(http.StatusOK, http.MethodPost, "/imports/2/rollback", "")
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Len(t, team, 1, "Paul is archived")
+	require.Equal(t, "shop", team[0].Department)
+	require.Equal(t, before, slots(), "the slots come back as they were")
+	var archived []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/employees/archived", ""), &archived))
+	require.Equal(t, "Paul", archived[0].Name)
+	a.expect(http.StatusNotFound, http.MethodPost, "/imports/2/rollback", "")
+
+	// Once forgotten, the upload can be loaded again.
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees?upsert=true", upload)
+}
+
+func TestImportWorkbook(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	roster := func(monday string) string {
+		return string(xlsxtest.Workbook(
+			xlsx.Sheet{Name: "A", Rows: [][]string{
+				{"Name", "Start date", "Department", "Monday", "Tuesday"},
+				{"Ines", "45383", "shop", monday, "14:00-18:00"},
+				{"Paul", "2024-04-01", "workshop", "", "9:00-12:00 lab"},
+			}},
+			xlsx.Sheet{Name: "B", Rows: [][]string{
+				{"Name", "Saturday"},
+				{"Ines", "9:00-13:00"},
+			}},
+		))
+	}
+
+	// A dry run reports the import without writing it.
+	var preview service.ImportPreview
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees/xlsx?dryRun=true", roster("9:00-12:00 cash desk")), &preview))
+	require.Len(t, preview.Employees, 2)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Empty(t, team)
+
+	require.JSONEq(t, `{"importId": 1, "loaded": 2, "created": 2, "updated": 0}`,
+		string(a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees/xlsx", roster("9:00-12:00 cash desk"))))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Equal(t, []string{"Ines", "Paul"}, []string{team[0].Name, team[1].Name})
+	require.Equal(t, "2024-04-01", team[0].StartDate.Format("2006-01-02"))
+	var weeks []service.WeekSchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", team[0].ID), ""), &weeks))
+	require.Equal(t, []service.TimeSlot{{Start: "09:00", End: "12:00", Task: "cash desk"}}, weeks[0].Days[0].TimeSlots)
+	require.Equal(t, []service.TimeSlot{{Start: "09:00", End: "13:00"}}, weeks[1].Days[5].TimeSlots)
+
+	// The same workbook is not imported twice, and the cells off the layout are named.
+	a.expect(http.StatusOK, http.MethodPost, "/loadEmployees/xlsx", roster("9:00-12:00 cash desk"))
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusUnprocessableEntity, http.MethodPost, "/loadEmployees/xlsx", roster("9h-12h")), &problem))
+	require.Equal(t, "A!D2", problem.InvalidParams[0].Name)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees/xlsx", "Name,Monday\nInes,9:00-12:00")
+}
+
+func TestImportCSV(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	file := "name,startDate,weekType,day,start,end,task\n" +
+		"Ines,2024-04-01,A,Monday,9:00,12:00,cash desk\n" +
+		"Ines,2024-04-01,B,Monday,10:00,16:00,\n" +
+		"Paul,2024-04-01,A,Tuesday,9:00,12:00,lab\n"
+
+	// Every invalid row is reported, and a dry run writes nothing.
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusUnprocessableEntity, http.MethodPost, "/loadEmployees/csv",
+		file+"Zoé,2024-04-01,A,Mon,9:00,12:00,\nLéa,,A,Monday,12:00,12:00,\n"), &problem))
+	var names []string
+	for _, param := range problem.InvalidParams {
+		names = append(names, param.Name)
+	}
+	require.Equal(t, []string{"row 5.day", "row 6.startDate", "row 6.end"}, names)
+	var preview service.ImportPreview
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, "/loadEmployees/csv?dryRun=true", file), &preview))
+	require.Len(t, preview.Employees, 2)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	require.Empty(t, team)
+
+	require.JSONEq(t, `{"importId": 1, "loaded": 2, "created": 2, "updated": 0}`,
+		string(a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees/csv", file)))
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	var weeks []service.WeekSchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", team[0].ID), ""), &weeks))
+	require.Equal(t, []service.TimeSlot{{Start: "09:00", End: "12:00", Task: "cash desk"}}, weeks[0].Days[0].TimeSlots)
+	require.Equal(t, []service.TimeSlot{{Start: "10:00", End: "16:00"}}, weeks[1].Days[0].TimeSlots)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees/csv", file+"\"Léa,2024-04-01\n")
+}
+
+func TestEmployeeProfile(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	monday := `{"Monday": [{"start": "9:00", "end": "12:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-03-01", "department": "shop", "hourlyRate": 12.5, "email": "ines@example.com",
+		"phone": "+33 6 12 34 56 78", "role": "Optician", "color": "#1E90FF", "weeks": {"A": `+monday+`}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0]
+	require.Equal(t, []string{"ines@example.com", "+33 6 12 34 56 78", "Optician", "#1E90FF"},
+		[]string{ines.Email, ines.Phone, ines.Role, ines.Color})
+	require.Equal(t, 12.5, ines.HourlyRate)
+
+	// A patch changes the fields it gives and keeps the others.
+	var patched model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPatch, fmt.Sprintf("/employees/%d", ines.ID),
+		`{"phone": "04 78 00 00 00", "color": "#00aa00", "hourlyRate": 13}`), &patched))
+	require.Equal(t, []string{"ines@example.com", "04 78 00 00 00", "Optician", "#00aa00", "shop"},
+		[]string{patched.Email, patched.Phone, patched.Role, patched.Color, patched.Department})
+	require.Equal(t, 13.0, patched.HourlyRate)
+	var weeks []service.WeekSchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", ines.ID), ""), &weeks))
+	require.Equal(t, []service.TimeSlot{{Start: "09:00", End: "12:00"}}, weeks[0].Days[0].TimeSlots)
+
+	var problem apierror.Problem
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusUnprocessableEntity, http.MethodPatch, fmt.Sprintf("/employees/%d", ines.ID),
+		`{"email": "ines@", "phone": "12", "color": "blue", "hourlyRate": -1}`), &problem))
+	var names []string
+	for _, param := range problem.InvalidParams {
+		names = append(names, param.Name)
+	}
+	require.Equal(t, []string{"hourlyRate", "email", "phone", "color"}, names)
+	a.expect(http.StatusNotFound, http.MethodPatch, "/employees/999", `{"role": "Manager"}`)
+	a.expect(http.StatusUnprocessableEntity, http.MethodPost, "/loadEmployees",
+		`[{"name": "Paul", "startDate": "2024-03-01", "color": "red", "weeks": {"A": `+monday+`}}]`)
+}
+
+func TestEmployeeDetail(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	monday := `{"Monday": [{"start": "9:00", "end": "12:00"}, {"start": "14:00", "end": "18:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+monday+`}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := fmt.Sprintf("/employees/%d", team[0].ID)
+	a.expect(http.StatusCreated, http.MethodPost, ines+"/leave", `{"from": "2024-04-08", "to": "2024-04-09", "description": "congé"}`)
+
+	// Nothing is embedded unless asked for.
+	var detail service.EmployeeDetail
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, ines, ""), &detail))
+	require.Equal(t, "Ines", detail.Name)
+	require.Empty(t, detail.Schedules)
+	require.Empty(t, detail.Leave)
+	detail = service.EmployeeDetail{}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, ines+"?include=schedules,leave", ""), &detail))
+	require.Len(t, detail.Schedules, 2)
+	require.Equal(t, "14:00", detail.Schedules[1].StartTime.Format("15:04"))
+	require.Len(t, detail.Leave, 2)
+	require.Equal(t, model.LeavePending, detail.Leave[0].Status)
+	require.Empty(t, detail.Deltas)
+
+	a.expect(http.StatusBadRequest, http.MethodGet, ines+"?include=schedules,payslips", "")
+	a.expect(http.StatusNotFound, http.MethodGet, "/employees/999?include=schedules", "")
+	a.expect(http.StatusNoContent, http.MethodDelete, ines, "")
+	a.expect(http.StatusNotFound, http.MethodGet, ines, "")
+}
+
+func TestEmployeeSearch(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Cordelia", "startDate": "2024-04-01"}, {"name": "Bernard", "startDate": "2024-04-01"},
+		{"name": "Delphine", "startDate": "2024-04-01"}, {"name": "Adele", "startDate": "2024-04-01"}]`)
+	search := func(query string) []string {
+		var found []model.Employee
+		require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/employees/search?"+query, ""), &found))
+		names := []string{}
+		for _, employee := range found {
+			names = append(names, employee.Name)
+		}
+		return names
+	}
+
+	// The names starting with the query come first, then those containing it.
+	require.Equal(t, []string{"Delphine", "Adele", "Cordelia"}, search("q=del"))
+	require.Equal(t, []string{"Delphine", "Adele", "Cordelia"}, search("q=DEL"))
+	require.Equal(t, []string{"Delphine"}, search("q=del&limit=1"))
+	require.Equal(t, []string{"Bernard"}, search("q=+nar+"))
+	require.Empty(t, search("q=d%25"))
+	require.Empty(t, search("q=_"))
+
+	a.expect(http.StatusBadRequest, http.MethodGet, "/employees/search", "")
+	a.expect(http.StatusBadRequest, http.MethodGet, "/employees/search?q=del&limit=0", "")
+	a.expect(http.StatusBadRequest, http.MethodGet, "/employees/search?q=del&limit=51", "")
+}
+
+func TestEmployeeEndDate(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	monday := `{"Monday": [{"start": "9:00", "end": "12:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-03-01", "endDate": "2024-04-10", "weeks": {"A": `+monday+`, "B": `+monday+`}},
+		{"name": "Jules", "startDate": "2024-03-01", "weeks": {"A": `+monday+`, "B": `+monday+`}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?status=all", ""), &team))
+	ines, jules := team[0], team[1]
+	require.Equal(t, "2024-04-10", ines.EndDate.Format("2006-01-02"))
+
+	// Ines works the Mondays of April up to her end date only.
+	var april []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", ines.ID), ""), &april))
+	require.Len(t, april[7].TimeSlots, 1)
+	require.Empty(t, april[14].TimeSlots)
+
+	var listed []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?active=true", ""), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, jules.ID, listed[0].ID)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?active=false", ""), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, ines.ID, listed[0].ID)
+	a.expect(http.StatusBadRequest, http.MethodGet, "/getEmployees?active=maybe", "")
+	a.expect(http.StatusBadRequest, http.MethodGet, "/getEmployees?active=true&status=all", "")
+
+	// Importing Ines again without an end date keeps hers; a patch reactivates her.
+	a.expect(http.StatusOK, http.MethodPost, "/loadEmployees?upsert=true",
+		`[{"name": "Ines", "startDate": "2024-03-01", "weeks": {"A": `+monday+`, "B": `+monday+`}}]`)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?active=false", ""), &listed))
+	require.Len(t, listed, 1)
+	var patched model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPatch, fmt.Sprintf("/employees/%d", ines.ID), `{"endDate": ""}`), &patched))
+	require.Nil(t, patched.EndDate)
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees?active=true", ""), &listed))
+	require.Len(t, listed, 2)
+
+	a.expect(http.StatusUnprocessableEntity, http.MethodPatch, fmt.Sprintf("/employees/%d", ines.ID), `{"endDate": "2024-02-01"}`)
+	a.expect(http.StatusBadRequest, http.MethodPost, "/loadEmployees",
+		`[{"name": "Paul", "startDate": "2024-03-01", "endDate": "2024-02-01", "weeks": {"A": `+monday+`}}]`)
+}
+
+func TestSlackReminder(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[
+		{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {}}},
+		{"name": "Paul", "startDate": "2024-04-01", "weeks": {"A": {}, "B": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`)
+	a.expect(http.StatusServiceUnavailable, http.MethodPost, "/notify/slack/today", "")
+
+	var posted []string
+	channel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted = append(posted, string(body))
+	}))
+	defer channel.Close()
+	reminder := &slack.Reminder{Service: a.svc, URL: channel.URL, Client: channel.Client()}
+	message, err := reminder.Send(context.Background(), time.Date(2024, time.April, 15, 7, 30, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Equal(t, "*Who works today, Monday 15 April 2024*\n• *Ines* 09:00–12:00", message.Text)
+	require.Len(t, posted, 1)
+	require.JSONEq(t, `{"text": "*Who works today, Monday 15 April 2024*\n• *Ines* 09:00–12:00"}`, posted[0])
+}
+
+func TestGoogleCalendarSync(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	week := `{"Monday": [{"start": "9:00", "end": "12:00"}], "Tuesday": [{"start": "9:00", "end": "12:00"}],
+		"Wednesday": [{"start": "9:00", "end": "12:00"}], "Thursday": [{"start": "9:00", "end": "12:00"}],
+		"Friday": [{"start": "9:00", "end": "12:00"}], "Saturday": [{"start": "9:00", "end": "12:00"}],
+		"Sunday": [{"start": "9:00", "end": "12:00"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-04-01", "weeks": {"A": `+week+`, "B": `+week+`}}]`)
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0].ID
+	a.expect(http.StatusServiceUnavailable, http.MethodPut, fmt.Sprintf("/employees/%d/google-calendar", ines), `{"calendarId": "ines@example.com"}`)
+
+	// Google keeps the events of the calendar, the deleted ones included.
+	var mu sync.Mutex
+	stored := map[string]gcal.Event{}
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := strings.TrimPrefix(r.URL.Path, "/calendars/ines@example.com/events/")
+		switch {
+		case r.URL.Path == "/token":
+			w.Write([]byte(`{"access_token": "access", "expires_in": 3600}`))
+		case r.Method == http.MethodGet:
+			items := []gcal.Event{}
+			for _, event := range stored {
+				if event.Status != "cancelled" {
+					items = append(items, event)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		case r.Method == http.MethodPost, r.Method == http.MethodPut:
+			var event gcal.Event
+			json.NewDecoder(r.Body).Decode(&event)
+			stored[event.ID] = event
+		case r.Method == http.MethodDelete:
+			event := stored[id]
+			event.Status = "cancelled"
+			stored[id] = event
+		}
+	}))
+	defer google.Close()
+	starts := func() map[string]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		live := map[string]bool{}
+		for _, event := range stored {
+			if event.Status != "cancelled" {
+				live[event.Start.DateTime] = true
+			}
+		}
+		return live
+	}
+	client := gcal.NewClient(gcal.Credentials{RefreshToken: "refresh"}, google.Client())
+	client.BaseURL, client.TokenURL = google.URL, google.URL+"/token"
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	a.svc.StartCalendarSync(ctx, service.CalendarSync{Client: client, TimeZone: time.UTC, Months: 1, Delay: 10 * time.Millisecond})
+
+	// Linking the calendar writes a slot a day for the current month.
+	a.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/employees/%d/google-calendar", ines), "")
+	now := time.Now().UTC()
+	days := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var report service.CalendarSyncReport
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/google-calendar", ines),
+		`{"calendarId": "ines@example.com"}`), &report))
+	require.Equal(t, &gcal.Result{Created: days}, report.Result)
+	require.Empty(t, report.LastError)
+	require.NotNil(t, report.SyncedAt)
+	require.Len(t, starts(), days)
+
+	// An override moves the slot of the 15th to the afternoon, and the calendar follows.
+	date := time.Date(now.Year(), now.Month(), 15, 0, 0, 0, 0, time.UTC)
+	a.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/employees/%d/overrides/%s", ines, date.Format("2006-01-02")),
+		`{"reason": "covers Paul", "slots": [{"startTime": "14:00", "endTime": "18:00"}]}`)
+	require.Eventually(t, func() bool {
+		live := starts()
+		return live[date.Add(14*time.Hour).Format(time.RFC3339)] && !live[date.Add(9*time.Hour).Format(time.RFC3339)] && len(live) == days
+	}, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/employees/%d/google-calendar/sync", ines), ""), &report))
+	require.Equal(t, &gcal.Result{Unchanged: days}, report.Result)
+
+	// Unlinking removes the events of the month.
+	a.expect(http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/employees/%d/google-calendar", ines), "")
+	require.Empty(t, starts())
+	a.expect(http.StatusNotFound, http.MethodPost, fmt.Sprintf("/employees/%d/google-calendar/sync", ines), "")
+}
+
+func TestGraphQL(t *testing.T) {
+	a := newAPI(t)
+	a.login("manager", "manager-password")
+	monday := `{"Monday": [{"start": "9:00", "end": "12:00", "location": "Shop"}]}`
+	a.expect(http.StatusCreated, http.MethodPost, "/loadEmployees",
+		`[{"name": "Ines", "startDate": "2024-03-01", "weeks": {"A": `+monday+`, "B": `+monday+`}},
+		{"name": "Jules", "startDate": "2024-03-01", "weeks": {"A": `+monday+`}}]`)
+	require.NoError(t, a.repo.HolidayCreate(context.Background(),
+		&model.Holiday{HolidayDate: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), HolidayName: "Lundi de Pâques"}))
+	var team []model.Employee
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &team))
+	ines := team[0]
+	require.Equal(t, "Ines", ines.Name)
+
+	post := func(status int, query string, variables map[string]interface{}) []byte {
+		body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+		require.NoError(t, err)
+		return a.expect(status, http.MethodPost, "/graphql", string(body))
+	}
+
+	// The whole team with their slots and hours in one request.
+	body := post(http.StatusOK, `query Team($month: String!) {
+		employees { name schedules(weekType: "A") { dayName startTime endTime location } april: monthlyHours(month: $month) }
+		holidays(month: $month) { date name }
+	}`, map[string]interface{}{"month": "2024-04"})
+	require.JSONEq(t, `{"data": {
+		"employees": [
+			{"name": "Ines", "schedules": [{"dayName": "Monday", "startTime": "09:00", "endTime": "12:00", "location": "Shop"}], "april": 15},
+			{"name": "Jules", "schedules": [{"dayName": "Monday", "startTime": "09:00", "endTime": "12:00", "location": "Shop"}], "april": 6}
+		],
+		"holidays": [{"date": "2024-04-01", "name": "Lundi de Pâques"}]
+	}}`, string(body))
+
+	// One employee by UUID, with the days of a month, as a GET.
+	query := url.Values{"query": {`query($id: ID!) { employee(id: $id) { id ...Days } }
+		fragment Days on Employee { monthlySchedule(month: "April", year: 2024) { date holidayName hours } }`},
+		"variables": {fmt.Sprintf(`{"id": %q}`, ines.UUID)}}
+	var days struct {
+		Data struct {
+			Employee struct {
+				ID              string
+				MonthlySchedule []struct {
+					Date        string
+					HolidayName *string
+					Hours       float64
+				}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(a.expect(http.StatusOK, http.MethodGet, "/graphql?"+query.Encode(), ""), &days))
+	require.Equal(t, fmt.Sprint(ines.ID), days.Data.Employee.ID)
+	require.Len(t, days.Data.Employee.MonthlySchedule, 30)
+	first, second := days.Data.Employee.MonthlySchedule[0], days.Data.Employee.MonthlySchedule[1]
+	require.Equal(t, "2024-04-01", first.Date)
+	require.Equal(t, "Lundi de Pâques", *first.HolidayName)
+	require.Equal(t, 3.0, first.Hours)
+	require.Nil(t, second.HolidayName)
+	require.Zero(t, second.Hours)
+
+	// A field that fails is null with its error, the rest of the data is answered.
+	body = post(http.StatusOK, `{ missing: employee(id: "999") { name } employees(status: "all") { name } }`, nil)
+	require.JSONEq(t, `{"data": {"missing": null, "employees": [{"name": "Ines"}, {"name": "Jules"}]}, "errors": [{"message": "employee 999 not found",
+		"locations": [{"line": 1, "column": 3}], "path": ["missing"], "extensions": {"code": "EMP_NOT_FOUND"}}]}`, string(body))
+
+	// A query that cannot be run is answered 400.
+	post(http.StatusBadRequest, `mutation { employees { name } }`, nil)
+	post(http.StatusBadRequest, `{ employees { name }`, nil)
+	a.expect(http.StatusBadRequest, http.MethodGet, "/graphql", "")
+	require.Contains(t, string(a.expect(http.StatusOK, http.MethodGet, "/graphql/schema", "")), "type Employee {")
+}
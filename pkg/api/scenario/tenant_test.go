@@ -0,0 +1,171 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	lhttp "github.com/lichensio/api_server/pkg/api/http"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/lichensio/api_server/pkg/api/tenant"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// company is a client of a multi-tenant deployment, identified by its API key or by the subdomain it uses.
+type company struct {
+	t       *testing.T
+	handler http.Handler
+	apiKey  string
+	host    string
+	token   string
+	header  http.Header
+}
+
+func (c *company) expect(status int, method, path, body string) []byte {
+	c.t.Helper()
+	req := httptest.NewRequest(method, "/prox/api"+path, strings.NewReader(body))
+	if c.apiKey != "" {
+		req.Header.Set(tenant.APIKeyHeader, c.apiKey)
+	}
+	if c.host != "" {
+		req.Host = c.host
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for name, values := range c.header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	require.Equal(c.t, status, rec.Code, "%s %s answered %s", method, path, rec.Body.String())
+	return rec.Body.Bytes()
+}
+
+func (c *company) login(username, password string) {
+	c.t.Helper()
+	var response struct {
+		Token string `json:"token"`
+	}
+	require.NoError(c.t, json.Unmarshal(c.expect(http.StatusOK, http.MethodPost, "/auth/login",
+		fmt.Sprintf(`{"username": %q, "password": %q}`, username, password)), &response))
+	c.token = response.Token
+}
+
+// TestTenantIsolation runs two opticians on one deployment: each only ever sees its own team.
+func TestTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:tenants?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	ctx := context.Background()
+	repository := repo.NewRepositoryWithDB(db)
+	require.NoError(t, repository.DBCreate(ctx))
+	authService := auth.NewService(repository, "scenario-secret", time.Hour)
+	handler := lhttp.NewRouter(&lhttp.Service{
+		EmployeeService: service.NewEmployeeService(repository),
+		Auth:            authService,
+		Tenants:         tenant.NewResolver(repository, "planning.test"),
+		AdminTokens:     []string{"ops-token"},
+	})
+
+	// Both companies name their first account "manager".
+	acmeTenant, acmeKey, err := tenant.Create(ctx, repository, holiday.Gouv{}, "Acme Optique", "acme", "")
+	require.NoError(t, err)
+	require.NoError(t, authService.EnsureUser(repo.WithTenant(ctx, acmeTenant.ID), "manager", "acme-password"))
+	betaTenant, _, err := tenant.Create(ctx, repository, holiday.Gouv{}, "Beta Vision", "beta", "alsace-moselle")
+	require.NoError(t, err)
+	require.NoError(t, authService.EnsureUser(repo.WithTenant(ctx, betaTenant.ID), "manager", "beta-password"))
+	_, _, err = tenant.Create(ctx, repository, holiday.Gouv{}, "Gamma", "Not a subdomain", "")
+	require.Error(t, err)
+
+	acme := &company{t: t, handler: handler, apiKey: acmeKey}
+	beta := &company{t: t, handler: handler, host: "beta.planning.test:8070"}
+	acme.expect(http.StatusUnauthorized, http.MethodPost, "/auth/login", `{"username": "manager", "password": "beta-password"}`)
+	acme.login("manager", "acme-password")
+	beta.login("manager", "beta-password")
+
+	// The same payload imported by both companies is a new import for each.
+	team := `[{"name": "Alice", "startDate": "2024-04-01", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "17:00"}]}}}]`
+	acme.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", team)
+	beta.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", team)
+	beta.expect(http.StatusCreated, http.MethodPost, "/loadEmployees", `[{"name": "Bruno", "startDate": "2024-04-01"}]`)
+
+	var acmeTeam, betaTeam []model.Employee
+	require.NoError(t, json.Unmarshal(acme.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &acmeTeam))
+	require.NoError(t, json.Unmarshal(beta.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &betaTeam))
+	require.Len(t, acmeTeam, 1)
+	require.Len(t, betaTeam, 2)
+	alice := acmeTeam[0].ID
+	require.NotEqual(t, alice, betaTeam[0].ID)
+
+	// The employees of the other company are unknown, by ID as well as by UUID.
+	beta.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/getWeeksAB/%d", alice), "")
+	beta.expect(http.StatusNotFound, http.MethodGet, "/getWeeksAB/"+acmeTeam[0].UUID, "")
+	beta.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/employees/%d", alice), "")
+	beta.expect(http.StatusNotFound, http.MethodPost, "/employees/deactivate",
+		fmt.Sprintf(`{"employeeIds": [%d], "endDate": "2024-04-30"}`, alice))
+	require.NoError(t, json.Unmarshal(acme.expect(http.StatusOK, http.MethodGet, "/getEmployees", ""), &acmeTeam))
+	require.Len(t, acmeTeam, 1)
+	require.Nil(t, acmeTeam[0].EndDate)
+
+	// Each company has its own A/B rotation.
+	var rotations []model.RotationPattern
+	require.NoError(t, json.Unmarshal(acme.expect(http.StatusOK, http.MethodGet, "/rotation-patterns", ""), &rotations))
+	require.Len(t, rotations, 1)
+	acme.expect(http.StatusCreated, http.MethodPost, "/rotation-patterns", `{"name": "workshop", "weeks": [{"name": "early"}, {"name": "late"}]}`)
+	beta.expect(http.StatusCreated, http.MethodPost, "/rotation-patterns", `{"name": "workshop", "weeks": [{"name": "early"}, {"name": "late"}]}`)
+
+	// Beta's stores are in Alsace: its calendars carry the holidays of Alsace-Moselle.
+	require.NoError(t, repository.HolidayCreate(repo.WithHolidayRegion(ctx, "alsace-moselle"),
+		&model.Holiday{HolidayDate: time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), HolidayName: "Lundi de Pâques"}))
+	var april []model.MonthlySchedule
+	require.NoError(t, json.Unmarshal(beta.expect(http.StatusOK, http.MethodGet,
+		fmt.Sprintf("/getMonthlySchedule?employeeID=%d&month=2024-04", betaTeam[0].ID), ""), &april))
+	require.Equal(t, "Lundi de Pâques", april[0].HolidayName)
+
+	// A token only opens the company it was issued for.
+	stolen := &company{t: t, handler: handler, host: "beta.planning.test", token: acme.token}
+	stolen.expect(http.StatusUnauthorized, http.MethodGet, "/getEmployees", "")
+
+	unknown := &company{t: t, handler: handler, apiKey: "not-a-key", token: acme.token}
+	body := unknown.expect(http.StatusUnauthorized, http.MethodGet, "/getEmployees", "")
+	require.Contains(t, string(body), "INVALID_API_KEY")
+	elsewhere := &company{t: t, handler: handler, host: "gamma.planning.test", token: acme.token}
+	body = elsewhere.expect(http.StatusNotFound, http.MethodGet, "/getEmployees", "")
+	require.Contains(t, string(body), "TENANT_NOT_FOUND")
+	anonymous := &company{t: t, handler: handler, host: "planning.test", token: acme.token}
+	anonymous.expect(http.StatusUnauthorized, http.MethodGet, "/getEmployees", "")
+
+	// The tables are shared by every company, none of them can drop them. The operators of the deployment
+	// can, confirming it with the token the first request returns.
+	acme.expect(http.StatusUnauthorized, http.MethodDelete, "/admin/db", "")
+	acme.expect(http.StatusUnauthorized, http.MethodPost, "/admin/db/migrate", "")
+	ops := &company{t: t, handler: handler, token: "ops-token"}
+	ops.expect(http.StatusOK, http.MethodPost, "/admin/db/migrate", "")
+	var confirmation struct {
+		ConfirmToken string `json:"confirmToken"`
+	}
+	require.NoError(t, json.Unmarshal(ops.expect(http.StatusPreconditionRequired, http.MethodDelete, "/admin/db", ""), &confirmation))
+	ops.header = http.Header{lhttp.ConfirmHeader: {confirmation.ConfirmToken + "0"}}
+	require.Contains(t, string(ops.expect(http.StatusBadRequest, http.MethodDelete, "/admin/db", "")), "CONFIRMATION_INVALID")
+	impostor := &company{t: t, handler: handler, token: "not-ops", header: http.Header{lhttp.ConfirmHeader: {confirmation.ConfirmToken}}}
+	impostor.expect(http.StatusUnauthorized, http.MethodDelete, "/admin/db", "")
+	ops.header = http.Header{lhttp.ConfirmHeader: {confirmation.ConfirmToken}}
+	ops.expect(http.StatusOK, http.MethodDelete, "/admin/db", "")
+	require.False(t, db.Migrator().HasTable(&model.Employee{}))
+}
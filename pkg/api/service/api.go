@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/payroll"
+	"io"
+	"time"
+)
+
+//go:generate go run ../../../internal/mockgen -interface EmployeeAPI -out employee_api_mock.go
+
+// EmployeeAPI is the part of EmployeeService the HTTP handlers and the GraphQL schema call, so that they can
+// be tested against EmployeeAPIMock without a database. Run go generate after changing it.
+type EmployeeAPI interface {
+	AddScheduleDelta(ctx context.Context, employeeID uint, delta model.ScheduleDelta) (*model.ScheduleDelta, error)
+	AcceptSuggestion(ctx context.Context, suggestion RosterSuggestion) (*model.ScheduleOverride, error)
+	ApproveLeave(ctx context.Context, id uint, approverID *uint) ([]model.EmployeeHoliday, error)
+	ArchiveEmployee(ctx context.Context, id uint) error
+	AssignRoleTemplate(ctx context.Context, employeeID uint, templateID *uint) error
+	AssignRotation(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error
+	CalculateMonthlyHours(entries []model.MonthlySchedule) (MonthlyHours, error)
+	CalendarCacheStats() CalendarCacheStats
+	CancelLeave(ctx context.Context, employeeID uint, from, to time.Time) (int64, error)
+	CapacityReport(ctx context.Context, year, quarter int) (*CapacityReport, error)
+	CopyPattern(ctx context.Context, employeeID, targetID uint) ([]model.Schedule, error)
+	CopyWeek(ctx context.Context, employeeID uint, from, to string) ([]model.Schedule, error)
+	CoverageGaps(ctx context.Context, month string, year int) ([]CoverageGap, error)
+	CreateClosureDay(ctx context.Context, input model.ClosureDayInput) (*model.ClosureDay, error)
+	CreatePairingRule(ctx context.Context, rule model.PairingRule) (*model.PairingRule, error)
+	CreateRoleTemplate(ctx context.Context, template model.RoleTemplate) (*model.RoleTemplate, error)
+	CreateRotationPattern(ctx context.Context, pattern model.RotationPattern) (*model.RotationPattern, error)
+	CreateStaffingRequirement(ctx context.Context, requirement model.StaffingRequirement) (*model.StaffingRequirement, error)
+	CreateUnavailability(ctx context.Context, employeeID uint, input model.UnavailabilityInput) (*model.Unavailability, error)
+	CreateWebhook(ctx context.Context, hook model.Webhook) (*model.Webhook, error)
+	DBCreate(ctx context.Context) error
+	DBDelete(ctx context.Context) error
+	DailyCoverage(ctx context.Context, date time.Time, location string) (*CoverageReport, error)
+	Dashboard(ctx context.Context) (*Dashboard, error)
+	DeactivateEmployees(ctx context.Context, input model.DeactivationInput) ([]model.Employee, error)
+	DeleteClosureDay(ctx context.Context, id uint) error
+	DeleteEmployeeSchedule(ctx context.Context, employeeID, id uint, effectiveFrom time.Time) error
+	DeleteEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter) (int64, error)
+	DeletePairingRule(ctx context.Context, id uint) error
+	DeleteSchedule(ctx context.Context, id uint, effectiveFrom time.Time) error
+	DeleteScheduleDelta(ctx context.Context, employeeID, id uint) error
+	DeleteScheduleOverride(ctx context.Context, employeeID uint, date string) error
+	DeleteStaffingRequirement(ctx context.Context, id uint) error
+	DeleteUnavailability(ctx context.Context, employeeID, id uint) error
+	DeleteWebhook(ctx context.Context, id uint) error
+	DetectLocationConflicts(ctx context.Context, employeeID uint) ([]LocationConflict, error)
+	DetectConflicts(ctx context.Context, employeeID uint, from time.Time) (*EmployeeConflicts, error)
+	EmployeeOvertime(ctx context.Context, employeeID uint, month string, year int) (*OvertimeReport, error)
+	EmployeeScheduleLastModified(ctx context.Context, employeeID uint) (time.Time, error)
+	EmployeeViolations(ctx context.Context, employeeID uint, month string, year int) (*ViolationReport, error)
+	EmployeesLastModified(ctx context.Context) (time.Time, error)
+	Events() *events.Bus
+	ExportMonthlySchedules(ctx context.Context, month string, year int) ([]ScheduleExportRow, error)
+	FetchAllEmployees(ctx context.Context, status string) ([]model.Employee, error)
+	FetchEmployeeChanges(ctx context.Context, since time.Time) (*EmployeeChanges, error)
+	FetchEmployeeFormattedABWeek(ctx context.Context, employeeID uint, location string, on time.Time) ([]WeekSchedule, error)
+	FetchEmployeeSchedule(ctx context.Context, employeeID uint, month string, year int) ([]model.MonthlySchedule, error)
+	FetchEmployeeScheduleAtLocation(ctx context.Context, employeeID uint, month string, year int, location string) ([]model.MonthlySchedule, error)
+	FetchEmployeeScheduleRange(ctx context.Context, employeeID uint, from, to time.Time, location string) (*ScheduleRange, error)
+	FindPrintJob(ctx context.Context, id uint) (*model.PrintJob, error)
+	GetCalendarLink(ctx context.Context, employeeID uint) (*model.CalendarLink, error)
+	GetEmployee(ctx context.Context, id uint) (*model.Employee, error)
+	GetEmployeeDetail(ctx context.Context, id uint, include []string) (*EmployeeDetail, error)
+	GetEmployeeSchedule(ctx context.Context, employeeID, id uint) (*model.Schedule, error)
+	GetHolidaysForMonthYear(ctx context.Context, year int, month time.Month) ([]model.Holiday, error)
+	GetRotationCalendar(ctx context.Context, date time.Time) (*RotationCalendar, error)
+	GetSchedule(ctx context.Context, id uint) (*model.Schedule, error)
+	GetScheduleHistory(ctx context.Context, employeeID uint) (*ScheduleHistory, error)
+	GetValidationConfig(ctx context.Context) (*ValidationConfig, error)
+	HourTotals(ctx context.Context, input HourTotalsInput) ([]HourTotals, error)
+	IDByUUID(ctx context.Context, resource interface{}, uuid string) (uint, error)
+	ImportEmployees(ctx context.Context, payload []byte, upsert bool) (*ImportResult, error)
+	ImportProgress(ctx context.Context) ImportProgress
+	InvalidateCaches(ctx context.Context, scope CacheScope) (*CacheInvalidation, error)
+	KPIReport(ctx context.Context, month string, year int, hourlyCost *float64) (*KPIReport, error)
+	LinkCalendar(ctx context.Context, employeeID uint, calendarID string) (*CalendarSyncReport, error)
+	LintSchedules(ctx context.Context, draft []model.EmployeeInput, from time.Time) (*LintReport, error)
+	ListArchivedEmployees(ctx context.Context) ([]model.Employee, error)
+	ListClosureDays(ctx context.Context, from, to time.Time) ([]model.ClosureDay, error)
+	ListEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter) ([]model.Schedule, error)
+	ListImports(ctx context.Context, limit int) ([]model.EmployeeImport, error)
+	ListLeave(ctx context.Context, employeeID uint, from, to time.Time, status string) ([]model.EmployeeHoliday, error)
+	ListPairingRules(ctx context.Context) ([]model.PairingRule, error)
+	ListRoleTemplates(ctx context.Context) ([]model.RoleTemplate, error)
+	ListRotationPatterns(ctx context.Context) ([]model.RotationPattern, error)
+	ListScheduleDeltas(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error)
+	ListScheduleOverrides(ctx context.Context, employeeID uint, from, to time.Time) ([]model.ScheduleOverride, error)
+	ListStaffingRequirements(ctx context.Context) ([]model.StaffingRequirement, error)
+	ListTimeEntries(ctx context.Context, employeeID uint, from, to time.Time) ([]model.TimeEntry, error)
+	ListUnavailabilities(ctx context.Context, employeeID uint) ([]model.Unavailability, error)
+	ListWebhookDeliveries(ctx context.Context, id uint) ([]model.WebhookDelivery, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	MigrationStatus(ctx context.Context) (*MigrationReport, error)
+	MonthlyHoursSummary(ctx context.Context, month string, year int) ([]EmployeeHours, error)
+	OpenPrint(ctx context.Context, uuid, expires, signature string) (*model.PrintJob, io.ReadCloser, error)
+	PairingViolations(ctx context.Context, from time.Time) ([]PairingViolation, error)
+	PatchEmployee(ctx context.Context, id uint, patch EmployeePatch) (*model.Employee, error)
+	PatchEmployeeSchedule(ctx context.Context, employeeID, id uint, patch SchedulePatch, effectiveFrom time.Time) (*model.Schedule, error)
+	PayrollPeriod(ctx context.Context, month string, year int) (*payroll.Period, error)
+	PrefetchHolidays(ctx context.Context, year int) (*HolidayPrefetch, error)
+	PreviewImport(ctx context.Context, payload []byte, upsert bool) (*ImportPreview, error)
+	Punch(ctx context.Context, entry model.TimeEntry) (recorded *model.TimeEntry, replayed bool, err error)
+	RejectLeave(ctx context.Context, id uint, approverID *uint) ([]model.EmployeeHoliday, error)
+	ReplaceEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter, slots []model.Schedule, effectiveFrom time.Time) ([]model.Schedule, error)
+	RequestLeave(ctx context.Context, employeeID uint, input model.LeaveInput) ([]model.EmployeeHoliday, error)
+	RequestPrint(ctx context.Context, input PrintInput) (*model.PrintJob, error)
+	RestoreEmployee(ctx context.Context, id uint) (*model.Employee, error)
+	RollbackImport(ctx context.Context, id uint) (*model.EmployeeImport, error)
+	RotateWebhookSecret(ctx context.Context, id uint) (*model.Webhook, error)
+	SaveForecasts(ctx context.Context, forecasts []model.DemandForecast) error
+	SaveRevenues(ctx context.Context, revenues []model.DailyRevenue) error
+	SearchEmployees(ctx context.Context, query string, limit int) ([]model.Employee, error)
+	SetRotationCalendar(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error)
+	SetScheduleOverride(ctx context.Context, employeeID uint, date string, override model.ScheduleOverride) (*model.ScheduleOverride, error)
+	SetScheduleTask(ctx context.Context, id uint, task string) (*model.Schedule, error)
+	SetValidationConfig(ctx context.Context, settings model.ValidationSettings) (*ValidationConfig, error)
+	SlotProvenance(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error)
+	StationCoverage(ctx context.Context, month string, year int) (*StationCoverageReport, error)
+	SuggestAssignments(ctx context.Context, from, to time.Time) (*RosterSuggestions, error)
+	SyncCalendar(ctx context.Context, employeeID uint) (*CalendarSyncReport, error)
+	TableRowCounts(ctx context.Context) ([]model.TableRowCount, error)
+	TeamRoster(ctx context.Context, month string, year int, location string) ([]RosterEmployee, error)
+	TestWebhook(ctx context.Context, id uint) (*WebhookTest, error)
+	TimeZone(location string) *time.Location
+	UnavailabilityConflicts(ctx context.Context, employeeID uint, month string, year int) ([]UnavailabilityConflict, error)
+	UnlinkCalendar(ctx context.Context, employeeID uint) error
+	UpdateClosureDay(ctx context.Context, id uint, input model.ClosureDayInput) (*model.ClosureDay, error)
+	UpdateEmployeeSchedule(ctx context.Context, employeeID, id uint, slot model.Schedule, effectiveFrom time.Time) (*model.Schedule, error)
+	UpdateRoleTemplate(ctx context.Context, id uint, slots []model.RoleTemplateSlot, cascade bool) (*model.RoleTemplate, error)
+	UpdateSchedule(ctx context.Context, id uint, schedule model.Schedule, effectiveFrom time.Time) (*model.Schedule, error)
+	UpdateUnavailability(ctx context.Context, employeeID, id uint, input model.UnavailabilityInput) (*model.Unavailability, error)
+	UpdateWebhook(ctx context.Context, id uint, hook model.Webhook) (*model.Webhook, error)
+	VarianceReport(ctx context.Context, month string, year int) (*VarianceReport, error)
+}
+
+var _ EmployeeAPI = (*EmployeeService)(nil)
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"gorm.io/gorm"
+)
+
+// CacheScope selects what InvalidateCaches drops: one employee, one month, or everything when both are empty.
+type CacheScope struct {
+	EmployeeID uint   `json:"employeeId,omitempty"`
+	Month      string `json:"month,omitempty"`
+	Year       int    `json:"year,omitempty"`
+}
+
+// CacheInvalidation reports what InvalidateCaches did.
+type CacheInvalidation struct {
+	Scope CacheScope `json:"scope"`
+	// Employees is the number of employees whose schedule version was bumped.
+	Employees int64 `json:"employees"`
+	// HolidaysCleared is the number of cached public holidays removed, fetched again on the next read.
+	HolidaysCleared int64 `json:"holidaysCleared"`
+}
+
+// InvalidateCaches is for when support fixed data directly in the database: it rebuilds the schedule
+// snapshots in scope, bumps the updated_at of the employees concerned so that their Last-Modified changes
+// and clients drop their copies, and publishes a CacheInvalidated event. A month scope also clears the
+// public holidays cached for the year of that month, which are fetched and stored a year at a time. Replicas
+// read the rebuilt snapshots and bumped versions through database replication; the event lets in-process
+// subscribers drop anything they derived from them.
+func (s *EmployeeService) InvalidateCaches(ctx context.Context, scope CacheScope) (*CacheInvalidation, error) {
+	if scope.EmployeeID != 0 && scope.Month != "" {
+		return nil, apierror.Validation("invalidate either an employee or a month, not both")
+	}
+	result := &CacheInvalidation{Scope: scope}
+
+	switch {
+	case scope.EmployeeID != 0:
+		var employee model.Employee
+		if err := s.repo.GetEmployeeByID(ctx, scope.EmployeeID, &employee); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", scope.EmployeeID)).WithCode(apierror.CodeEmployeeNotFound)
+			}
+			return nil, err
+		}
+		touched, err := s.repo.TouchEmployees(ctx, []uint{scope.EmployeeID})
+		if err != nil {
+			return nil, err
+		}
+		s.syncSnapshots(ctx, scope.EmployeeID)
+		result.Employees = touched
+
+	case scope.Month != "":
+		month := util.MonthStringToNumber(scope.Month)
+		if month == 0 {
+			return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", scope.Month)).WithCode(apierror.CodeMonthInvalid)
+		}
+		if scope.Year < 1 || scope.Year > 9999 {
+			return nil, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid)
+		}
+		cleared, err := s.repo.HolidayDeleteYear(ctx, scope.Year)
+		if err != nil {
+			return nil, err
+		}
+		result.HolidaysCleared = cleared
+		fallthrough
+
+	default:
+		if err := s.RebuildScheduleSnapshots(ctx); err != nil {
+			return nil, err
+		}
+		touched, err := s.repo.TouchEmployees(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Employees = touched
+	}
+
+	if scope.EmployeeID != 0 {
+		s.calendars.invalidate(scope.EmployeeID)
+	} else {
+		s.calendars.invalidate()
+	}
+	s.events.Publish(events.New(events.CacheInvalidated, scope))
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/metrics"
+	"sync"
+	"time"
+)
+
+// calendarCacheTTL is how long a computed monthly calendar is served from memory. The key carries the last
+// modification of the schedules, so that a slot written by any instance is seen at once; the TTL bounds the
+// staleness of the rest, such as public holidays left out while their provider was down.
+const calendarCacheTTL = 5 * time.Minute
+
+// calendarCacheSize bounds the number of monthly calendars kept in memory.
+const calendarCacheSize = 10_000
+
+// calendarKey identifies a monthly calendar as computed for a version of the schedules of an employee.
+type calendarKey struct {
+	tenantID     uint
+	employeeID   uint
+	year         int
+	month        time.Month
+	region       string
+	location     string
+	lastModified int64
+}
+
+type calendarEntry struct {
+	days    []model.MonthlySchedule
+	expires time.Time
+}
+
+// calendarCache keeps the monthly calendars computed by FetchEmployeeScheduleAtLocation. The leave and
+// overrides of an employee are not part of the last modification of its schedules: writing them invalidates
+// its calendars instead.
+type calendarCache struct {
+	mu      sync.Mutex
+	entries map[calendarKey]calendarEntry
+
+	hits          metrics.Counter
+	misses        metrics.Counter
+	evictions     metrics.Counter
+	invalidations metrics.Counter
+}
+
+// CalendarCacheStats reports the monthly calendar cache of this instance since it started.
+type CalendarCacheStats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"maxEntries"`
+	TTLSeconds int   `json:"ttlSeconds"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	// Evictions are the calendars dropped to make room, Invalidations those dropped by a write.
+	Evictions     int64 `json:"evictions"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// newCalendarKey returns the key of the calendar of an employee for a month, in the tenant and holiday
+// region of ctx.
+func newCalendarKey(ctx context.Context, employeeID uint, year int, month time.Month, location string, lastModified time.Time) calendarKey {
+	tenantID, _ := repo.TenantFromContext(ctx)
+	region, _ := repo.HolidayRegionFromContext(ctx)
+	return calendarKey{tenantID: tenantID, employeeID: employeeID, year: year, month: month, region: region,
+		location: location, lastModified: lastModified.UnixNano()}
+}
+
+// get returns a copy of the calendar cached under key, unless it expired.
+func (c *calendarCache) get(key calendarKey) ([]model.MonthlySchedule, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		c.misses.Inc()
+		return nil, false
+	}
+	c.hits.Inc()
+	return append([]model.MonthlySchedule(nil), entry.days...), true
+}
+
+// put caches days under key. When the cache is full, the expired calendars are dropped, then any calendar.
+func (c *calendarCache) put(key calendarKey, days []model.MonthlySchedule) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[calendarKey]calendarEntry)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= calendarCacheSize {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < calendarCacheSize {
+				break
+			}
+			delete(c.entries, k)
+			c.evictions.Inc()
+		}
+	}
+	c.entries[key] = calendarEntry{days: append([]model.MonthlySchedule(nil), days...), expires: now.Add(calendarCacheTTL)}
+}
+
+// invalidate drops the calendars of the given employees, or every calendar without employees.
+func (c *calendarCache) invalidate(employeeIDs ...uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(employeeIDs) == 0 {
+		c.invalidations.Add(int64(len(c.entries)))
+		c.entries = nil
+		return
+	}
+	ids := make(map[uint]bool, len(employeeIDs))
+	for _, id := range employeeIDs {
+		ids[id] = true
+	}
+	for key := range c.entries {
+		if ids[key.employeeID] {
+			delete(c.entries, key)
+			c.invalidations.Inc()
+		}
+	}
+}
+
+// CalendarCacheStats returns the counters of the monthly calendar cache of this instance. Each instance has
+// its own cache: behind a load balancer, ask each of them.
+func (s *EmployeeService) CalendarCacheStats() CalendarCacheStats {
+	c := s.calendars
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+	return CalendarCacheStats{
+		Entries:       entries,
+		MaxEntries:    calendarCacheSize,
+		TTLSeconds:    int(calendarCacheTTL / time.Second),
+		Hits:          c.hits.Value(),
+		Misses:        c.misses.Value(),
+		Evictions:     c.evictions.Value(),
+		Invalidations: c.invalidations.Value(),
+	}
+}
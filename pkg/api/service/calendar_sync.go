@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/gcal"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CalendarSync configures the sync of the slots of the employees to their Google Calendar, see
+// StartCalendarSync.
+type CalendarSync struct {
+	Client *gcal.Client
+	// TimeZone is the time zone of the slots, in which their events are shown; the local time of the server
+	// when not set.
+	TimeZone *time.Location
+	// Months is the number of months synced, the current one first, 2 when not set.
+	Months int
+	// Delay is how long the sync waits for more changes after a change of the schedules, 5 seconds when not
+	// set, so that an import or a series of edits is written once.
+	Delay time.Duration
+	// Resync is how often every calendar is synced again, which brings in the month entering the months
+	// synced and repairs the events edited by hand, 24 hours when not set.
+	Resync time.Duration
+}
+
+// CalendarSyncReport is a calendar link with what its last sync changed, if it succeeded.
+type CalendarSyncReport struct {
+	model.CalendarLink
+	Result *gcal.Result `json:"result,omitempty"`
+}
+
+// calendarTarget is the employee whose calendar is to be synced, and the tenant of the employee; an
+// employee of 0 stands for every employee of the tenant, and a tenant of 0 for every tenant.
+type calendarTarget struct {
+	tenantID   uint
+	employeeID uint
+}
+
+// StartCalendarSync syncs the calendar of an employee whenever the slots, overrides or leave of the employee
+// change, by any instance, and every calendar every Resync, until ctx is done. Without it calendars cannot
+// be linked.
+func (s *EmployeeService) StartCalendarSync(ctx context.Context, sync CalendarSync) {
+	if sync.TimeZone == nil {
+		sync.TimeZone = time.Local
+	}
+	if sync.Months < 1 {
+		sync.Months = 2
+	}
+	if sync.Delay <= 0 {
+		sync.Delay = 5 * time.Second
+	}
+	if sync.Resync <= 0 {
+		sync.Resync = 24 * time.Hour
+	}
+	s.calendarSync = &sync
+	stream, unsubscribe := s.events.Subscribe(256)
+	go func() {
+		defer unsubscribe()
+		s.calendarSyncWorker(ctx, stream)
+	}()
+}
+
+// calendarSyncWorker gathers the employees changed until Delay after the first change, then syncs their
+// calendars.
+func (s *EmployeeService) calendarSyncWorker(ctx context.Context, stream <-chan events.Event) {
+	resync := time.NewTicker(s.calendarSync.Resync)
+	defer resync.Stop()
+	pending := make(map[calendarTarget]bool)
+	var due <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-stream:
+			targets := calendarTargets(event)
+			for _, target := range targets {
+				pending[target] = true
+			}
+			if len(targets) > 0 && due == nil {
+				due = time.After(s.calendarSync.Delay)
+			}
+		case <-due:
+			s.syncCalendars(ctx, pending)
+			pending, due = make(map[calendarTarget]bool), nil
+		case <-resync.C:
+			s.syncCalendars(ctx, map[calendarTarget]bool{{}: true})
+		}
+	}
+}
+
+// calendarTargets returns the employees whose slots may have changed with event.
+func calendarTargets(event events.Event) []calendarTarget {
+	switch data := event.Data.(type) {
+	case ScheduleUpdate:
+		return []calendarTarget{{tenantID: event.TenantID, employeeID: data.EmployeeID}}
+	case []model.EmployeeHoliday:
+		targets := make([]calendarTarget, len(data))
+		for i, day := range data {
+			targets[i] = calendarTarget{tenantID: event.TenantID, employeeID: day.EmployeeID}
+		}
+		return targets
+	case model.DataChange:
+		// A change of a role template, or of any table not tied to one employee, may change any of them.
+		return []calendarTarget{{tenantID: event.TenantID, employeeID: data.EmployeeID}}
+	}
+	if event.Type == events.ChangesMissed {
+		return []calendarTarget{{tenantID: event.TenantID}}
+	}
+	return nil
+}
+
+// syncCalendars syncs the linked calendars of the targets, each once. A sync that fails is logged and kept
+// on its link, and tried again with the next change or resync.
+func (s *EmployeeService) syncCalendars(ctx context.Context, targets map[calendarTarget]bool) {
+	synced := make(map[uint]bool)
+	for target := range targets {
+		scoped := ctx
+		if target.tenantID != 0 {
+			scoped = repo.WithTenant(ctx, target.tenantID)
+		}
+		var links []model.CalendarLink
+		if target.employeeID == 0 {
+			var err error
+			if links, err = s.repo.CalendarLinkList(scoped); err != nil {
+				reqlog.From(ctx).Errorf("Failed to list the calendar links to sync: %v", err)
+				continue
+			}
+		} else if link, err := s.repo.CalendarLinkGet(scoped, target.employeeID); err == nil {
+			links = append(links, *link)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			reqlog.From(ctx).Errorf("Failed to find the calendar link of employee %d: %v", target.employeeID, err)
+		}
+		for i := range links {
+			if synced[links[i].ID] || ctx.Err() != nil {
+				continue
+			}
+			synced[links[i].ID] = true
+			if _, err := s.syncCalendar(ctx, &links[i]); err != nil {
+				reqlog.From(ctx).Warnf("Failed to sync the Google Calendar of employee %d: %v", links[i].EmployeeID, err)
+			}
+		}
+	}
+}
+
+// syncCalendar writes the slots of the months synced to the calendar of link, and records the outcome on
+// the link.
+func (s *EmployeeService) syncCalendar(ctx context.Context, link *model.CalendarLink) (*gcal.Result, error) {
+	if link.TenantID != 0 {
+		ctx = repo.WithTenant(ctx, link.TenantID)
+	}
+	var employee model.Employee
+	err := s.repo.GetEmployeeByID(ctx, link.EmployeeID, &employee)
+	var result gcal.Result
+	if err == nil {
+		from, to := s.calendarWindow()
+		var slots []gcal.Event
+		if slots, err = s.calendarEvents(ctx, &employee, from, to); err == nil {
+			result, err = s.calendarSync.Client.Sync(ctx, link.CalendarID, employee.UUID, from, to, slots)
+		}
+	}
+	now := time.Now()
+	if err != nil {
+		link.LastError = err.Error()
+	} else {
+		link.SyncedAt, link.LastError = &now, ""
+	}
+	if err := s.repo.CalendarLinkSave(ctx, link); err != nil {
+		reqlog.From(ctx).Errorf("Failed to record the sync of the Google Calendar of employee %d: %v", link.EmployeeID, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// calendarWindow returns the start of the current month and the start of the month after the months synced.
+func (s *EmployeeService) calendarWindow() (time.Time, time.Time) {
+	now := time.Now().In(s.calendarSync.TimeZone)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, s.calendarSync.TimeZone)
+	return from, from.AddDate(0, s.calendarSync.Months, 0)
+}
+
+// calendarEvents returns the events of the slots worked by employee from the month of from until to, from
+// its monthly calendars: overrides included, days of leave left out.
+func (s *EmployeeService) calendarEvents(ctx context.Context, employee *model.Employee, from, to time.Time) ([]gcal.Event, error) {
+	zone := s.calendarSync.TimeZone
+	zoneName := zone.String()
+	if zone == time.Local {
+		// "Local" is no name Google knows: the offsets of the times place the events.
+		zoneName = ""
+	}
+	var slots []gcal.Event
+	for month := from; month.Before(to); month = month.AddDate(0, 1, 0) {
+		days, err := s.FetchEmployeeScheduleAtLocation(ctx, employee.ID, month.Month().String(), month.Year(), "")
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			if day.Leave != nil {
+				continue
+			}
+			date, err := time.ParseInLocation("2006-01-02", day.Date, zone)
+			if err != nil {
+				return nil, err
+			}
+			for _, slot := range day.TimeSlots {
+				start, err := clockOn(date, slot.Start)
+				if err != nil {
+					return nil, err
+				}
+				end, err := clockOn(date, slot.End)
+				if err != nil {
+					return nil, err
+				}
+				if !end.After(start) {
+					end = end.AddDate(0, 0, 1)
+				}
+				summary := slot.Task
+				if summary == "" {
+					summary = "Work"
+				}
+				slots = append(slots, gcal.Event{
+					ID:          gcal.EventID(employee.UUID, day.Date, slot.Start, slot.End, slot.Location, slot.Task),
+					Summary:     summary,
+					Location:    slot.Location,
+					Description: "Synced from the schedules; changes made in the calendar are overwritten.",
+					Start:       gcal.EventTime{DateTime: start.Format(time.RFC3339), TimeZone: zoneName},
+					End:         gcal.EventTime{DateTime: end.Format(time.RFC3339), TimeZone: zoneName},
+				})
+			}
+		}
+	}
+	return slots, nil
+}
+
+// clockOn returns the time HH:MM of date, in the location of date.
+func clockOn(date time.Time, clock string) (time.Time, error) {
+	at, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), at.Hour(), at.Minute(), 0, 0, date.Location()), nil
+}
+
+// GetCalendarLink returns the Google Calendar the slots of an employee are synced to.
+func (s *EmployeeService) GetCalendarLink(ctx context.Context, employeeID uint) (*model.CalendarLink, error) {
+	link, err := s.repo.CalendarLinkGet(ctx, employeeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apierror.NotFound(fmt.Sprintf("employee %d has no linked Google Calendar", employeeID)).WithCode(apierror.CodeCalendarNotLinked)
+	}
+	return link, err
+}
+
+// LinkCalendar syncs the slots of an employee to the Google Calendar calendarID from now on, in place of
+// the calendar it had, and syncs it at once. The calendar stays linked when that first sync fails: the
+// report carries the error and the next change tries again.
+func (s *EmployeeService) LinkCalendar(ctx context.Context, employeeID uint, calendarID string) (*CalendarSyncReport, error) {
+	if s.calendarSync == nil {
+		return nil, calendarSyncDisabled()
+	}
+	if calendarID == "" || len(calendarID) > 1024 {
+		return nil, apierror.Validation("calendarId must be the ID of a Google Calendar, at most 1024 characters")
+	}
+	var employee model.Employee
+	if err := s.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	if previous, err := s.repo.CalendarLinkGet(ctx, employeeID); err == nil && previous.CalendarID != calendarID {
+		s.clearCalendar(ctx, previous)
+	}
+	link := &model.CalendarLink{EmployeeID: employeeID, CalendarID: calendarID}
+	if err := s.repo.CalendarLinkSave(ctx, link); err != nil {
+		return nil, err
+	}
+	result, _ := s.syncCalendar(ctx, link)
+	return &CalendarSyncReport{CalendarLink: *link, Result: result}, nil
+}
+
+// SyncCalendar syncs the Google Calendar of an employee now, without waiting for a change.
+func (s *EmployeeService) SyncCalendar(ctx context.Context, employeeID uint) (*CalendarSyncReport, error) {
+	if s.calendarSync == nil {
+		return nil, calendarSyncDisabled()
+	}
+	link, err := s.GetCalendarLink(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.syncCalendar(ctx, link)
+	if err != nil {
+		return nil, apierror.Unavailable(fmt.Sprintf("the Google Calendar of employee %d could not be synced", employeeID), err).
+			WithCode(apierror.CodeGoogleCalendarDown)
+	}
+	return &CalendarSyncReport{CalendarLink: *link, Result: result}, nil
+}
+
+// UnlinkCalendar stops the sync of the slots of an employee to its Google Calendar, and removes the events
+// of the months synced from the calendar; the events of the past months are left as they are.
+func (s *EmployeeService) UnlinkCalendar(ctx context.Context, employeeID uint) error {
+	link, err := s.GetCalendarLink(ctx, employeeID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CalendarLinkDelete(ctx, employeeID); err != nil {
+		return err
+	}
+	if s.calendarSync != nil {
+		s.clearCalendar(ctx, link)
+	}
+	return nil
+}
+
+// clearCalendar removes the events of the months synced from the calendar of a link that is replaced or
+// deleted. The link being already changed, a failure is logged.
+func (s *EmployeeService) clearCalendar(ctx context.Context, link *model.CalendarLink) {
+	var employee model.Employee
+	err := s.repo.GetEmployeeByID(ctx, link.EmployeeID, &employee)
+	if err == nil {
+		from, to := s.calendarWindow()
+		_, err = s.calendarSync.Client.Sync(ctx, link.CalendarID, employee.UUID, from, to, nil)
+	}
+	if err != nil {
+		reqlog.From(ctx).Warnf("Failed to remove the events of employee %d from Google Calendar %s: %v", link.EmployeeID, link.CalendarID, err)
+	}
+}
+
+// calendarSyncDisabled is the error of the calendar operations on a deployment without the sync.
+func calendarSyncDisabled() error {
+	return apierror.Unavailable("the Google Calendar sync is not configured on this instance", nil).
+		WithCode(apierror.CodeCalendarSyncDisabled)
+}
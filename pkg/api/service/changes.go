@@ -0,0 +1,28 @@
+package service
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/events"
+)
+
+// PublishDataChange publishes a change notified by the database on the event bus, so that the subscribers
+// of every instance learn about the writes of the other instances and of scripts, see repo.ListenChanges.
+// The monthly calendars cached for the employee changed are dropped first, all of them after a resync.
+func (s *EmployeeService) PublishDataChange(change model.DataChange) {
+	if change.Op == model.ChangeResync || change.EmployeeID == 0 {
+		s.calendars.invalidate()
+	} else {
+		s.calendars.invalidate(change.EmployeeID)
+	}
+	var event events.Event
+	switch {
+	case change.Op == model.ChangeResync:
+		event = events.New(events.ChangesMissed, nil)
+	case change.Table == "employees":
+		event = events.New(events.EmployeeChanged, change)
+	default:
+		event = events.New(events.ScheduleChanged, change)
+	}
+	event.TenantID = change.TenantID
+	s.events.Publish(event)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CreateClosureDay records a day the company closes. As the calendars of every employee mark it, every
+// employee is touched so that clients drop their copies.
+func (s *EmployeeService) CreateClosureDay(ctx context.Context, input model.ClosureDayInput) (*model.ClosureDay, error) {
+	closure, err := closureDayFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.ClosureDayCreate(ctx, closure); err != nil {
+			return err
+		}
+		_, err := tx.TouchEmployees(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return nil, closureDayError(err, closure)
+	}
+	return closure, nil
+}
+
+// UpdateClosureDay replaces a closure day, touching every employee as CreateClosureDay does.
+func (s *EmployeeService) UpdateClosureDay(ctx context.Context, id uint, input model.ClosureDayInput) (*model.ClosureDay, error) {
+	closure, err := closureDayFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	closure.ID = id
+	err = s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.ClosureDayUpdate(ctx, closure); err != nil {
+			return err
+		}
+		_, err := tx.TouchEmployees(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return nil, closureDayError(err, closure)
+	}
+	return closure, nil
+}
+
+// ListClosureDays returns the closure days from from to to included, ordered by date.
+func (s *EmployeeService) ListClosureDays(ctx context.Context, from, to time.Time) ([]model.ClosureDay, error) {
+	return s.repo.ClosureDayFindBetween(ctx, from, to)
+}
+
+// DeleteClosureDay removes a closure day, touching every employee as CreateClosureDay does.
+func (s *EmployeeService) DeleteClosureDay(ctx context.Context, id uint) error {
+	err := s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.ClosureDayDelete(ctx, id); err != nil {
+			return err
+		}
+		_, err := tx.TouchEmployees(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return closureDayError(err, &model.ClosureDay{ID: id})
+	}
+	return nil
+}
+
+// closureDayError turns the errors of the repository on closure into API errors.
+func closureDayError(err error, closure *model.ClosureDay) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return apierror.NotFound(fmt.Sprintf("closure day %d not found", closure.ID)).WithCode(apierror.CodeClosureNotFound)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return apierror.Conflict(fmt.Sprintf("the company already closes on %s", closure.Date.Format("2006-01-02"))).WithCode(apierror.CodeClosureExists)
+	}
+	return err
+}
+
+// closureDayFromInput checks a closure day as declared, see model.ClosureDayInput.
+func closureDayFromInput(input model.ClosureDayInput) (*model.ClosureDay, error) {
+	date, err := time.Parse("2006-01-02", input.Date)
+	if err != nil {
+		return nil, apierror.Validation(fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", input.Date)).WithCode(apierror.CodeDateInvalid)
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, apierror.Validation("name is required").WithCode(apierror.CodeFieldRequired)
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return nil, apierror.Validation("name must be at most 100 characters long")
+	}
+	return &model.ClosureDay{Date: date, Name: name, ClearSlots: input.ClearSlots}, nil
+}
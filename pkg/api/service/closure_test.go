@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClosureDays(t *testing.T) {
+	svc, ids := newStationService(t)
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {"2024-06-10": "Lundi de Pentecôte"}}})
+	ctx := context.Background()
+	alice := ids["Alice"]
+	june := func() []model.MonthlySchedule {
+		days, err := svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+		require.NoError(t, err)
+		return days
+	}
+	require.Equal(t, model.HolidayPublic, june()[9].HolidaySource)
+
+	// The inventory of the 17th keeps the slots; the seminar of the 10th replaces Whit Monday and clears them.
+	inventory, err := svc.CreateClosureDay(ctx, model.ClosureDayInput{Date: "2024-06-17", Name: " Inventory "})
+	require.NoError(t, err)
+	require.Equal(t, "Inventory", inventory.Name)
+	seminar, err := svc.CreateClosureDay(ctx, model.ClosureDayInput{Date: "2024-06-10", Name: "Seminar", ClearSlots: true})
+	require.NoError(t, err)
+	_, err = svc.CreateClosureDay(ctx, model.ClosureDayInput{Date: "2024-06-10", Name: "Again"})
+	require.Equal(t, apierror.CodeClosureExists, apierror.CodeOf(err))
+	days := june()
+	require.Equal(t, "Seminar", days[9].HolidayName)
+	require.Equal(t, model.HolidayCompany, days[9].HolidaySource)
+	require.True(t, days[9].Closed)
+	require.Empty(t, days[9].TimeSlots)
+	require.Equal(t, "Inventory", days[16].HolidayName)
+	require.False(t, days[16].Closed)
+	require.Len(t, days[16].TimeSlots, 2)
+	require.False(t, days[16].PublicHoliday(), "A closure day is no public holiday")
+
+	// An override still opens a day clearing its slots.
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	_, err = svc.SetScheduleOverride(ctx, alice, "2024-06-10", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{StartTime: at(9), EndTime: at(11)}}})
+	require.NoError(t, err)
+	require.Equal(t, []model.TimeSlot{{Start: "09:00", End: "11:00"}}, june()[9].TimeSlots)
+
+	// The calendars cached before a change are dropped.
+	_, err = svc.UpdateClosureDay(ctx, inventory.ID, model.ClosureDayInput{Date: "2024-06-17", Name: "Inventory", ClearSlots: true})
+	require.NoError(t, err)
+	require.Empty(t, june()[16].TimeSlots)
+	_, err = svc.UpdateClosureDay(ctx, inventory.ID, model.ClosureDayInput{Date: "2024-06-10", Name: "Inventory"})
+	require.Equal(t, apierror.CodeClosureExists, apierror.CodeOf(err))
+	closures, err := svc.ListClosureDays(ctx, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, closures, 2)
+	require.Equal(t, seminar.ID, closures[0].ID)
+
+	require.NoError(t, svc.DeleteClosureDay(ctx, seminar.ID))
+	require.Equal(t, "Lundi de Pentecôte", june()[9].HolidayName)
+	require.Equal(t, apierror.CodeClosureNotFound, apierror.CodeOf(svc.DeleteClosureDay(ctx, seminar.ID)))
+	_, err = svc.UpdateClosureDay(ctx, seminar.ID, model.ClosureDayInput{Date: "2024-06-10", Name: "Seminar"})
+	require.Equal(t, apierror.CodeClosureNotFound, apierror.CodeOf(err))
+}
+
+func TestCreateClosureDayRejects(t *testing.T) {
+	svc, _ := newStationService(t)
+	for _, test := range []struct {
+		name  string
+		input model.ClosureDayInput
+		code  apierror.Code
+	}{
+		{"date", model.ClosureDayInput{Date: "10/06/2024", Name: "Seminar"}, apierror.CodeDateInvalid},
+		{"no name", model.ClosureDayInput{Date: "2024-06-10", Name: "  "}, apierror.CodeFieldRequired},
+		{"long name", model.ClosureDayInput{Date: "2024-06-10", Name: strings.Repeat("x", 101)}, apierror.CodeValidationFailed},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := svc.CreateClosureDay(context.Background(), test.input)
+			require.Equal(t, test.code, apierror.CodeOf(err))
+		})
+	}
+}
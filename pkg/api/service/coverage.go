@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"time"
+)
+
+// coverageInterval is the length of the intervals of the daily coverage report.
+const coverageInterval = 15 * time.Minute
+
+// CoverageInterval is the number of employees scheduled during one interval of a day. An employee counts
+// when one of its slots overlaps the interval, even partly.
+type CoverageInterval struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Count int    `json:"count"`
+}
+
+// CoverageReport is the staffing of a date, interval by interval from 00:00 to 24:00.
+type CoverageReport struct {
+	Date        string             `json:"date"`
+	HolidayName string             `json:"holidayName,omitempty"`
+	Intervals   []CoverageInterval `json:"intervals"`
+}
+
+// DailyCoverage counts, for every 15-minute interval of date, the employees scheduled, overrides applied.
+// Employees on approved leave and employees not hired yet are not counted. An empty location counts the
+// slots of every location.
+func (s *EmployeeService) DailyCoverage(ctx context.Context, date time.Time, location string) (*CoverageReport, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	employees, rotations, err := s.teamCalendars(ctx, day, day)
+	if err != nil {
+		return nil, err
+	}
+	holidays := s.holidayNames(ctx, day.Year(), day.Month())
+	counts, err := intervalCounts(employees, rotations, day, location)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CoverageReport{Date: day.Format("2006-01-02"), HolidayName: holidays[day.Format("2006-01-02")].Name, Intervals: make([]CoverageInterval, len(counts))}
+	for n, count := range counts {
+		start := day.Add(time.Duration(n) * coverageInterval)
+		end := start.Add(coverageInterval).Format("15:04")
+		if n == len(counts)-1 {
+			end = "24:00"
+		}
+		report.Intervals[n] = CoverageInterval{Start: start.Format("15:04"), End: end, Count: count}
+	}
+	return report, nil
+}
+
+// intervalCounts counts the employees scheduled at location during every 15-minute interval of day, as
+// DailyCoverage reports them. The employees hold their resolved calendars, see teamCalendars.
+func intervalCounts(employees []model.Employee, rotations rotations, day time.Time, location string) ([]int, error) {
+	counts := make([]int, 24*time.Hour/coverageInterval)
+	for i := range employees {
+		employee := &employees[i]
+		if employee.StartDate.Format("2006-01-02") > day.Format("2006-01-02") {
+			continue // not hired yet
+		}
+		entry := monthlyCalendar(employee, rotations.of(employee.RotationPatternID), day, day, nil, employee.LeaveDays, employee.Overrides, location)[0]
+		if entry.Leave != nil {
+			continue
+		}
+		if err := addCoverage(counts, entry.TimeSlots); err != nil {
+			return nil, fmt.Errorf("%w for employee ID %d", err, employee.ID)
+		}
+	}
+	return counts, nil
+}
+
+// addCoverage counts an employee working slots in every interval of counts the slots overlap. An employee
+// working two slots overlapping the same interval counts once.
+func addCoverage(counts []int, slots []model.TimeSlot) error {
+	working := make([]bool, len(counts))
+	for _, slot := range slots {
+		start, end, err := slot.Clock()
+		if err != nil {
+			return fmt.Errorf("invalid slot %s-%s: %w", slot.Start, slot.End, err)
+		}
+		sinceMidnight := time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+		untilEnd := sinceMidnight + end.Sub(start)
+		first := int(sinceMidnight / coverageInterval)
+		last := int((untilEnd + coverageInterval - 1) / coverageInterval)
+		for n := first; n < last && n < len(working); n++ {
+			working[n] = true
+		}
+	}
+	for n, present := range working {
+		if present {
+			counts[n]++
+		}
+	}
+	return nil
+}
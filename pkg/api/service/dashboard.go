@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+)
+
+// Dashboard counts what the manager should look at first.
+type Dashboard struct {
+	// Employees is the number of active employees.
+	Employees int `json:"employees"`
+	// Unscheduled is the number of employees pending a template, with nothing planned until their schedules
+	// are imported or a role template is assigned.
+	Unscheduled int `json:"unscheduled"`
+}
+
+// Dashboard returns the counts of the manager dashboard.
+func (s *EmployeeService) Dashboard(ctx context.Context) (*Dashboard, error) {
+	employees, err := s.FetchAllEmployees(ctx, EmployeesActive)
+	if err != nil {
+		return nil, err
+	}
+	dashboard := &Dashboard{Employees: len(employees)}
+	for _, employee := range employees {
+		if employee.Unscheduled {
+			dashboard.Unscheduled++
+		}
+	}
+	return dashboard, nil
+}
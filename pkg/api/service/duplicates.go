@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// nameMatchThreshold is the similarity from which an imported name is reported as a possible duplicate of
+// an existing employee: one typo in a 12-letter name is 0.92, accents and case are ignored.
+const nameMatchThreshold = 0.85
+
+// Actions of the records of an import.
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+	ImportLink   = "link"
+)
+
+// NameMatch is an existing employee whose name is close to the name of an imported record.
+type NameMatch struct {
+	EmployeeID uint   `json:"employeeId"`
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	StartDate  string `json:"startDate"`
+	// Similarity goes from 0 to 1, for names equal but for accents, case and word order.
+	Similarity float64 `json:"similarity"`
+}
+
+// ImportEntry tells what an import does with one of its records: create an employee, update the employee
+// it matches by name and start date (with upsert) or replace the employee it is linked to. Candidates are
+// the existing employees with a close name the record may duplicate; set linkTo on the record to one of
+// them to import it as that employee. Slots are the schedule slots the record writes, in place of the own
+// slots of the employee it updates or replaces.
+type ImportEntry struct {
+	Name       string      `json:"name"`
+	Action     string      `json:"action"`
+	EmployeeID uint        `json:"employeeId,omitempty"`
+	Slots      int         `json:"slots"`
+	Candidates []NameMatch `json:"candidates,omitempty"`
+}
+
+// ImportPreview is the outcome of an import without writing anything.
+type ImportPreview struct {
+	// AlreadyImported is set when the payload was imported before: importing it would write nothing.
+	AlreadyImported bool          `json:"alreadyImported"`
+	Employees       []ImportEntry `json:"employees"`
+	// Created and Updated count the employees the import would insert and replace, linked ones included,
+	// and Slots the schedule slots it would write.
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Slots   int `json:"slots"`
+}
+
+// PreviewImport validates an import payload like ImportEmployees, overlaps, references to role templates,
+// rotations and linked employees and schedule rules included, and reports what importing it would do, with
+// the possible duplicates of existing employees, without writing anything.
+func (s *EmployeeService) PreviewImport(ctx context.Context, payload []byte, upsert bool) (*ImportPreview, error) {
+	hash, err := importHash(payload, upsert)
+	if err != nil {
+		return nil, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON)
+	}
+	if _, err := s.repo.ImportFindByHash(ctx, hash); err == nil {
+		return &ImportPreview{AlreadyImported: true, Employees: []ImportEntry{}}, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var input model.EmployeesInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, apierror.Validation("Invalid JSON payload: " + err.Error()).WithCode(apierror.CodeInvalidJSON)
+	}
+	employees, err := s.employeesFromInput(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.planImport(ctx, input, employees, upsert)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkImportRules(ctx, employees); err != nil {
+		return nil, err
+	}
+	preview := &ImportPreview{Employees: entries}
+	for _, entry := range entries {
+		if entry.Action == ImportCreate {
+			preview.Created++
+		} else {
+			preview.Updated++
+		}
+		preview.Slots += entry.Slots
+	}
+	return preview, nil
+}
+
+// planImport tells what importing the validated employees of input does, see ImportEntry, and sets the ID
+// of the employees linked to an existing one.
+func (s *EmployeeService) planImport(ctx context.Context, input []model.EmployeeInput, employees []*model.Employee, upsert bool) ([]ImportEntry, error) {
+	existing, err := s.repo.GetEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ImportEntry, len(employees))
+	for i, employee := range employees {
+		entry := ImportEntry{Name: employee.Name, Action: ImportCreate, Slots: len(employee.Schedules)}
+		if link := input[i].LinkTo; link != 0 {
+			employee.ID = link
+			entry.Action, entry.EmployeeID = ImportLink, link
+			entries[i] = entry
+			continue
+		}
+		for j := range existing {
+			other := &existing[j]
+			if upsert && other.Name == employee.Name && other.StartDate.Equal(employee.StartDate) {
+				entry.Action, entry.EmployeeID, entry.Candidates = ImportUpdate, other.ID, nil
+				break
+			}
+			if similarity := nameSimilarity(employee.Name, other.Name); similarity >= nameMatchThreshold {
+				entry.Candidates = append(entry.Candidates, NameMatch{
+					EmployeeID: other.ID, UUID: other.UUID, Name: other.Name,
+					StartDate: other.StartDate.Format("2006-01-02"), Similarity: math.Round(similarity*100) / 100,
+				})
+			}
+		}
+		sort.SliceStable(entry.Candidates, func(a, b int) bool { return entry.Candidates[a].Similarity > entry.Candidates[b].Similarity })
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+// foldName lowercases a name, strips its accents and keeps single spaces between its words, hyphens
+// included, so that "Henny  Honoré" and "henny honore" fold the same.
+func foldName(name string) []string {
+	var folded strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r == '-' || unicode.IsSpace(r):
+			folded.WriteByte(' ')
+		default:
+			folded.WriteRune(r)
+		}
+	}
+	return strings.Fields(folded.String())
+}
+
+// nameSimilarity compares two names once folded, as written and with their words sorted so that "Honoré
+// Henny" matches "Henny Honore": 1 minus their edit distance over the length of the longest.
+func nameSimilarity(a, b string) float64 {
+	wordsA, wordsB := foldName(a), foldName(b)
+	asWritten := similarity(strings.Join(wordsA, " "), strings.Join(wordsB, " "))
+	sort.Strings(wordsA)
+	sort.Strings(wordsB)
+	return max(asWritten, similarity(strings.Join(wordsA, " "), strings.Join(wordsB, " ")))
+}
+
+// similarity is 1 minus the Levenshtein distance of a and b over the length of the longest, in runes.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of rune insertions, deletions and substitutions turning a into b.
+func levenshtein(a, b []rune) int {
+	previous, current := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
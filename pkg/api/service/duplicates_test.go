@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	for _, test := range []struct {
+		a, b       string
+		similarity float64
+	}{
+		{"Henny Honoré", "henny  honore", 1},
+		{"Honoré Henny", "Henny Honore", 1},
+		{"Jean-Marc Roux", "Jean Marc Roux", 1},
+		{"Henny Honore", "Henny Honnore", 12.0 / 13},
+		{"Ines", "Paul", 0},
+		{"", "", 0},
+	} {
+		require.InDelta(t, test.similarity, nameSimilarity(test.a, test.b), 1e-9, "%s / %s", test.a, test.b)
+	}
+	require.GreaterOrEqual(t, nameSimilarity("Delphine Martin", "Delphine Martn"), nameMatchThreshold, "One typo is a match")
+	require.Less(t, nameSimilarity("Delphine Martin", "Delphine Morel"), nameMatchThreshold)
+}
+
+func TestPreviewImportFlagsDuplicates(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	record := `[{"name": "alice", "startDate": "2024-06-05", "weeks": {"A": {"Tuesday": [{"start": "9:00", "end": "12:00"}]}}%s}]`
+
+	preview, err := svc.PreviewImport(ctx, []byte(fmt.Sprintf(record, "")), true)
+	require.NoError(t, err)
+	require.Equal(t, 1, preview.Created)
+	entry := preview.Employees[0]
+	require.Equal(t, ImportCreate, entry.Action)
+	require.Equal(t, []NameMatch{{EmployeeID: ids["Alice"], UUID: entry.Candidates[0].UUID, Name: "Alice", StartDate: "2024-06-03", Similarity: 1}}, entry.Candidates)
+	result, err := svc.ImportEmployees(ctx, []byte(fmt.Sprintf(record, "")), false)
+	require.NoError(t, err)
+	require.Len(t, result.Warnings, 1, "The duplicate is created with a warning")
+	_, err = svc.RollbackImport(ctx, result.Import.ID)
+	require.NoError(t, err)
+
+	// Linked to Alice, the record replaces her; an unknown employee, or one linked twice, is rejected.
+	linked := fmt.Sprintf(record, fmt.Sprintf(`, "linkTo": %d`, ids["Alice"]))
+	preview, err = svc.PreviewImport(ctx, []byte(linked), false)
+	require.NoError(t, err)
+	require.Equal(t, []ImportEntry{{Name: "alice", Action: ImportLink, EmployeeID: ids["Alice"], Slots: 1}}, preview.Employees)
+	result, err = svc.ImportEmployees(ctx, []byte(linked), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Updated)
+	require.Empty(t, result.Warnings)
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 2)
+
+	_, err = svc.PreviewImport(ctx, []byte(fmt.Sprintf(record, `, "linkTo": 999`)), false)
+	require.Equal(t, apierror.CodeImportInvalid, apierror.CodeOf(err))
+	twice := fmt.Sprintf(`[{"name": "A", "startDate": "2024-06-05", "linkTo": %[1]d, "weeks": {}}, {"name": "B", "startDate": "2024-06-05", "linkTo": %[1]d, "weeks": {}}]`, ids["Bob"])
+	_, err = svc.PreviewImport(ctx, []byte(twice), false)
+	require.Equal(t, apierror.CodeImportInvalid, apierror.CodeOf(err))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var scheduleExportHeader = []string{"employee_id", "employee", "date", "day", "holiday", "start", "end", "hours", "slots"}
+
+// ScheduleExportRow is the planned work of one employee on one day of a monthly export.
+type ScheduleExportRow struct {
+	EmployeeID  uint
+	Employee    string
+	Date        string
+	DayName     string
+	HolidayName string
+	// PublicHoliday is set when HolidayName is a public holiday rather than a closure day of the company.
+	PublicHoliday bool
+	// Start and End are the start of the first slot and the end of the last slot of the day, empty on
+	// days off.
+	Start string
+	End   string
+	Hours float64
+	// Slots details every slot of the day as "HH:MM-HH:MM", followed by its task and location if set.
+	Slots string
+}
+
+// ExportMonthlySchedules returns one row per employee per day of the month, built from the team roster.
+func (svc *EmployeeService) ExportMonthlySchedules(ctx context.Context, month string, year int) ([]ScheduleExportRow, error) {
+	roster, err := svc.TeamRoster(ctx, month, year, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ScheduleExportRow, 0, len(roster)*31)
+	for _, employee := range roster {
+		for _, entry := range employee.Days {
+			hours, err := svc.CalculateMonthlyHours([]model.MonthlySchedule{entry})
+			if err != nil {
+				return nil, err
+			}
+			row := ScheduleExportRow{
+				EmployeeID:    employee.EmployeeID,
+				Employee:      employee.Employee,
+				Date:          entry.Date,
+				DayName:       entry.DayName,
+				HolidayName:   entry.HolidayName,
+				PublicHoliday: entry.PublicHoliday(),
+				Hours:         hours.Worked,
+				Slots:         formatExportSlots(entry.TimeSlots),
+			}
+			if len(entry.TimeSlots) > 0 {
+				row.Start = entry.TimeSlots[0].Start
+				row.End = entry.TimeSlots[len(entry.TimeSlots)-1].End
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// WriteSchedulesCSV writes the rows of a monthly schedule export as CSV, with a header line.
+func WriteSchedulesCSV(w io.Writer, rows []ScheduleExportRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write(scheduleExportHeader)
+	for _, row := range rows {
+		writer.Write([]string{
+			strconv.FormatUint(uint64(row.EmployeeID), 10),
+			row.Employee,
+			row.Date,
+			row.DayName,
+			row.HolidayName,
+			row.Start,
+			row.End,
+			strconv.FormatFloat(row.Hours, 'f', 2, 64),
+			row.Slots,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatExportSlots(slots []model.TimeSlot) string {
+	parts := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		part := fmt.Sprintf("%s-%s", slot.Start, slot.End)
+		var tags []string
+		if slot.Task != "" {
+			tags = append(tags, slot.Task)
+		}
+		if slot.Location != "" {
+			tags = append(tags, "@"+slot.Location)
+		}
+		if len(tags) > 0 {
+			part += " (" + strings.Join(tags, " ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// HolidayPay tells how the public holidays are paid.
+type HolidayPay struct {
+	// WorkedMultiplier is the rate of the hours worked on a public holiday: 1 pays them as the other hours, 2
+	// pays them double.
+	WorkedMultiplier float64
+	// PaidIfNotWorked are the dates (MM-DD) of the public holidays that are days off paid as if worked, such as
+	// 05-01 in France. Their recurring slots are paid without being worked; the slots of an override are
+	// worked, at the premium rate.
+	PaidIfNotWorked []string
+}
+
+// UseHolidayPay sets how the public holidays are paid, at the rate of the other hours and never off by
+// default.
+func (s *EmployeeService) UseHolidayPay(pay HolidayPay) error {
+	if pay.WorkedMultiplier < 1 {
+		return fmt.Errorf("the multiplier of the hours worked on public holidays must be at least 1, got %g", pay.WorkedMultiplier)
+	}
+	for _, date := range pay.PaidIfNotWorked {
+		if _, err := time.Parse("01-02", date); err != nil {
+			return fmt.Errorf("invalid public holiday %q, expected MM-DD", date)
+		}
+	}
+	s.holidayPay = pay
+	return nil
+}
+
+// paidIfNotWorked reports whether date (YYYY-MM-DD) is one of HolidayPay.PaidIfNotWorked.
+func (p HolidayPay) paidIfNotWorked(date string) bool {
+	if len(date) != len("2006-01-02") {
+		return false
+	}
+	for _, holiday := range p.PaidIfNotWorked {
+		if date[5:] == holiday {
+			return true
+		}
+	}
+	return false
+}
+
+// MonthlyHours splits the hours of a calendar by the way they are paid, see CalculateMonthlyHours.
+type MonthlyHours struct {
+	// Worked are the hours scheduled and worked, the days of leave and the public holidays off left out.
+	Worked float64 `json:"workedHours"`
+	// Regular are the hours paid at the base rate: those worked on other days than the public holidays, and
+	// those of the public holidays paid though not worked.
+	Regular float64 `json:"regularHours"`
+	// HolidayPremium are the hours worked on public holidays, paid at the rate of HolidayPay.WorkedMultiplier.
+	HolidayPremium float64 `json:"holidayPremiumHours"`
+	// Unpaid are the hours scheduled on days of leave without pay.
+	Unpaid float64 `json:"unpaidHours"`
+	// Paid are the hours paid at the base rate: Regular, plus HolidayPremium at the premium rate.
+	Paid float64 `json:"paidHours"`
+}
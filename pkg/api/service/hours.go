@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+)
+
+// EmployeeHours sums the scheduled hours of an employee over a month.
+type EmployeeHours struct {
+	EmployeeID uint   `json:"employeeId"`
+	Employee   string `json:"employee"`
+	// TotalHours are the hours scheduled and worked, the days of leave and the public holidays off left out, as
+	// in the monthly hours of the employee.
+	TotalHours float64 `json:"totalHours"`
+	// HolidayHours are the part of TotalHours worked on public holidays.
+	HolidayHours float64 `json:"holidayHours"`
+	// PaidHours are the hours paid at the base rate, see MonthlyHours.Paid.
+	PaidHours float64 `json:"paidHours"`
+	// LeaveHours are the hours scheduled on days of approved leave, not worked.
+	LeaveHours float64 `json:"leaveHours"`
+	// UnpaidHours are the part of LeaveHours on days of leave without pay.
+	UnpaidHours float64 `json:"unpaidHours"`
+}
+
+// MonthlyHoursSummary sums, for every employee, the hours of its monthly calendar, split into hours worked,
+// hours worked on public holidays and hours lost to approved leave, along with the hours paid as
+// CalculateMonthlyHours counts them.
+func (s *EmployeeService) MonthlyHoursSummary(ctx context.Context, month string, year int) ([]EmployeeHours, error) {
+	roster, err := s.TeamRoster(ctx, month, year, "")
+	if err != nil {
+		return nil, err
+	}
+	summary := make([]EmployeeHours, 0, len(roster))
+	for _, employee := range roster {
+		hours := EmployeeHours{EmployeeID: employee.EmployeeID, Employee: employee.Employee}
+		for _, entry := range employee.Days {
+			var day float64
+			for _, slot := range entry.TimeSlots {
+				slotHours, err := s.slotHours(entry.Date, slot)
+				if err != nil {
+					return nil, err
+				}
+				day += slotHours
+			}
+			paid, err := s.CalculateMonthlyHours([]model.MonthlySchedule{entry})
+			if err != nil {
+				return nil, err
+			}
+			if entry.Leave != nil {
+				hours.LeaveHours += day
+			}
+			hours.TotalHours += paid.Worked
+			hours.HolidayHours += paid.HolidayPremium
+			hours.PaidHours += paid.Paid
+			hours.UnpaidHours += paid.Unpaid
+		}
+		summary = append(summary, hours)
+	}
+	return summary, nil
+}
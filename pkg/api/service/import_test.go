@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImportEmployeesReplayIsIgnored(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	result, err := svc.ImportEmployees(ctx, []byte(`[{"name": "Ines", "startDate": "2024-01-08", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`), false)
+	require.NoError(t, err)
+	require.False(t, result.AlreadyImported)
+	first := result.Import.ID
+
+	// The same payload reformatted, its keys in another order, is the same import.
+	result, err = svc.ImportEmployees(ctx, []byte(`[ { "weeks": {"A": {"Monday": [{"end": "12:00", "start": "9:00"}]}},
+		"startDate": "2024-01-08", "name": "Ines" } ]`), false)
+	require.NoError(t, err)
+	require.True(t, result.AlreadyImported)
+	require.Equal(t, first, result.Import.ID)
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+
+	_, err = svc.ImportEmployees(ctx, []byte(`[{"name": "Ines"`), false)
+	require.Equal(t, apierror.CodeInvalidJSON, apierror.CodeOf(err))
+}
+
+func TestImportEmployeesReplayWithUpsert(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	payload := []byte(`[{"name": "Ines", "startDate": "2024-01-08", "weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`)
+
+	result, err := svc.ImportEmployees(ctx, payload, false)
+	require.NoError(t, err)
+	require.False(t, result.AlreadyImported)
+	require.Equal(t, 1, result.Created)
+	result, err = svc.ImportEmployees(ctx, payload, false)
+	require.NoError(t, err)
+	require.True(t, result.AlreadyImported)
+
+	// The same payload with upsert is another import, replacing the employee it inserted.
+	preview, err := svc.PreviewImport(ctx, payload, true)
+	require.NoError(t, err)
+	require.False(t, preview.AlreadyImported)
+	require.Equal(t, 1, preview.Updated)
+	result, err = svc.ImportEmployees(ctx, payload, true)
+	require.NoError(t, err)
+	require.False(t, result.AlreadyImported)
+	require.Equal(t, 0, result.Created)
+	require.Equal(t, 1, result.Updated)
+	result, err = svc.ImportEmployees(ctx, payload, true)
+	require.NoError(t, err)
+	require.True(t, result.AlreadyImported)
+
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+}
+
+func TestImportEmployeesUpsert(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	_, err := svc.ImportEmployees(ctx, []byte(`[{"name": "Ines", "startDate": "2024-01-08", "department": "shop",
+		"weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}, "B": {"Friday": [{"start": "13:00", "end": "17:00"}]}}}]`), false)
+	require.NoError(t, err)
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	ines := employees[0].ID
+
+	// Ines matches by name and start date: she is replaced, own slots of both weeks included. Paul is new.
+	result, err := svc.ImportEmployees(ctx, []byte(`[
+		{"name": "Ines", "startDate": "2024-01-08", "department": "workshop", "weeks": {"A": {"Tuesday": [{"start": "14:00", "end": "18:00"}]}}},
+		{"name": "Paul", "startDate": "2024-01-08", "weeks": {"B": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`), true)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Created)
+	require.Equal(t, 1, result.Updated)
+	employee, err := svc.repo.GetEmployeeWithSchedules(ctx, ines)
+	require.NoError(t, err)
+	require.Equal(t, "workshop", employee.Department)
+	require.Len(t, employee.Schedules, 1)
+	require.Equal(t, "A", employee.Schedules[0].WeekType)
+	require.Equal(t, "Tuesday", employee.Schedules[0].DayName)
+
+	// Another start date is another employee, and so is every record without upsert.
+	_, err = svc.ImportEmployees(ctx, []byte(`[{"name": "Ines", "startDate": "2024-02-05", "weeks": {}}]`), true)
+	require.NoError(t, err)
+	result, err = svc.ImportEmployees(ctx, []byte(`[{"name": "Ines", "startDate": "2024-01-08", "weeks": {}}]`), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Created)
+	employees, err = svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 4)
+}
+
+func TestRollbackImport(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	first, err := svc.ImportEmployees(ctx, []byte(`[{"name": "Ines", "startDate": "2024-01-08", "department": "shop",
+		"weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`), false)
+	require.NoError(t, err)
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	ines := employees[0].ID
+	before, err := svc.ListEmployeeSchedules(ctx, ines, ScheduleFilter{})
+	require.NoError(t, err)
+
+	upload := []byte(`[
+		{"name": "Ines", "startDate": "2024-01-08", "department": "workshop", "weeks": {"A": {"Tuesday": [{"start": "13:00", "end": "17:00"}]}}},
+		{"name": "Paul", "startDate": "2024-01-08", "weeks": {}}]`)
+	second, err := svc.ImportEmployees(ctx, upload, true)
+	require.NoError(t, err)
+	imports, err := svc.ListImports(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, imports, 2)
+	require.Equal(t, second.Import.ID, imports[0].ID, "The latest import comes first")
+
+	// The first import cannot be rolled back while the second changed Ines after it.
+	_, err = svc.RollbackImport(ctx, first.Import.ID)
+	require.Equal(t, apierror.CodeImportSuperseded, apierror.CodeOf(err))
+	record, err := svc.RollbackImport(ctx, second.Import.ID)
+	require.NoError(t, err)
+	require.Len(t, record.Changes, 2)
+
+	// Ines gets back her department and slots; Paul, created by the import, is archived.
+	employees, err = svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	require.Equal(t, "shop", employees[0].Department)
+	after, err := svc.ListEmployeeSchedules(ctx, ines, ScheduleFilter{})
+	require.NoError(t, err)
+	require.Len(t, after, len(before))
+	require.Equal(t, "Monday", after[0].DayName)
+	archived, err := svc.ListArchivedEmployees(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "Paul", archived[0].Name)
+
+	// The import is forgotten: it cannot be rolled back twice, and the same upload can be loaded again.
+	_, err = svc.RollbackImport(ctx, second.Import.ID)
+	require.Equal(t, apierror.CodeImportNotFound, apierror.CodeOf(err))
+	result, err := svc.ImportEmployees(ctx, upload, true)
+	require.NoError(t, err)
+	require.False(t, result.AlreadyImported)
+	_, err = svc.ListImports(ctx, 0)
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+}
+
+func TestPreviewImportWritesNothing(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	upload := []byte(`[
+		{"name": "Alice", "startDate": "2024-06-03", "weeks": {"A": {"Tuesday": [{"start": "13:00", "end": "17:00"}]}, "B": {"Friday": [{"start": "9:00", "end": "12:00"}]}}},
+		{"name": "Paul", "startDate": "2024-06-03", "weeks": {"B": {"Monday": [{"start": "9:00", "end": "12:00"}]}}}]`)
+
+	preview, err := svc.PreviewImport(ctx, upload, true)
+	require.NoError(t, err)
+	require.False(t, preview.AlreadyImported)
+	require.Equal(t, []int{1, 1, 3}, []int{preview.Created, preview.Updated, preview.Slots})
+	require.Equal(t, ImportEntry{Name: "Alice", Action: ImportUpdate, EmployeeID: ids["Alice"], Slots: 2}, preview.Employees[0])
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	require.Len(t, employees, 2)
+	slots, err := svc.ListEmployeeSchedules(ctx, ids["Alice"], ScheduleFilter{})
+	require.NoError(t, err)
+	require.Len(t, slots, 4, "Alice keeps her slots")
+	imports, err := svc.ListImports(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, imports)
+
+	// The checks of the import run: overlapping slots are rejected.
+	_, err = svc.PreviewImport(ctx, []byte(`[{"name": "Zoé", "startDate": "2024-06-03",
+		"weeks": {"A": {"Monday": [{"start": "9:00", "end": "12:00"}, {"start": "11:00", "end": "13:00"}]}}}]`), false)
+	require.Equal(t, apierror.CodeImportInvalid, apierror.CodeOf(err))
+
+	_, err = svc.ImportEmployees(ctx, upload, true)
+	require.NoError(t, err)
+	preview, err = svc.PreviewImport(ctx, upload, true)
+	require.NoError(t, err)
+	require.True(t, preview.AlreadyImported)
+	require.Empty(t, preview.Employees)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/metrics"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phases of a running import.
+const (
+	importValidating = "validating"
+	importWriting    = "writing"
+)
+
+// importMetrics counts the work of the employee imports of this instance since it started, and follows
+// the imports running.
+type importMetrics struct {
+	employeesProcessed metrics.Counter
+	schedulesWritten   metrics.Counter
+	validationFailures metrics.Counter
+	activeImports      metrics.Gauge
+
+	mu     sync.Mutex
+	nextID uint64
+	runs   map[uint64]*importRun
+}
+
+// importRun follows one running import. Its methods may be called on a nil run, which records nothing.
+type importRun struct {
+	owner     *importMetrics
+	id        uint64
+	tenantID  uint
+	employees int
+	startedAt time.Time
+	processed metrics.Counter
+	rejected  metrics.Counter
+	// phase is guarded by owner.mu.
+	phase string
+}
+
+// ImportProgress reports the imports of this instance: the totals since it started and the imports running.
+type ImportProgress struct {
+	// EmployeesProcessed are the employees validated, whether they were then written or not.
+	EmployeesProcessed int64 `json:"employeesProcessed"`
+	// SchedulesWritten are the schedule slots saved by the imports that succeeded.
+	SchedulesWritten int64 `json:"schedulesWritten"`
+	// ValidationFailures are the employees rejected by the validation, each rejecting its whole import.
+	ValidationFailures int64 `json:"validationFailures"`
+	ActiveImports      int64 `json:"activeImports"`
+	// Imports are the running imports of the tenant of the request, the oldest first.
+	Imports []ActiveImport `json:"imports"`
+}
+
+// ActiveImport is the progress of a running import.
+type ActiveImport struct {
+	ID        uint64    `json:"id"`
+	Phase     string    `json:"phase"`
+	Employees int       `json:"employees"`
+	Processed int64     `json:"processed"`
+	Rejected  int64     `json:"rejected"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// start records the start of an import of the given number of employees, in the tenant of ctx.
+func (m *importMetrics) start(ctx context.Context, employees int) *importRun {
+	tenantID, _ := repo.TenantFromContext(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	run := &importRun{owner: m, id: m.nextID, tenantID: tenantID, employees: employees, startedAt: time.Now(), phase: importValidating}
+	if m.runs == nil {
+		m.runs = make(map[uint64]*importRun)
+	}
+	m.runs[run.id] = run
+	m.activeImports.Inc()
+	return run
+}
+
+// finish records the end of the import, successful or not.
+func (r *importRun) finish() {
+	if r == nil {
+		return
+	}
+	r.owner.mu.Lock()
+	defer r.owner.mu.Unlock()
+	delete(r.owner.runs, r.id)
+	r.owner.activeImports.Dec()
+}
+
+// employeeValidated records the validation of one more employee.
+func (r *importRun) employeeValidated(valid bool) {
+	if r == nil {
+		return
+	}
+	r.processed.Inc()
+	r.owner.employeesProcessed.Inc()
+	if !valid {
+		r.rejected.Inc()
+		r.owner.validationFailures.Inc()
+	}
+}
+
+// writing records that the import passed validation and is being written.
+func (r *importRun) writing() {
+	if r == nil {
+		return
+	}
+	r.owner.mu.Lock()
+	defer r.owner.mu.Unlock()
+	r.phase = importWriting
+}
+
+// written records the schedules saved by the import.
+func (r *importRun) written(schedules int) {
+	if r == nil {
+		return
+	}
+	r.owner.schedulesWritten.Add(int64(schedules))
+}
+
+// ImportProgress returns the import counters of this instance and the imports of the tenant of ctx running
+// on it. Each instance counts its own imports: behind a load balancer, ask each of them.
+func (s *EmployeeService) ImportProgress(ctx context.Context) ImportProgress {
+	m := s.imports
+	progress := ImportProgress{
+		EmployeesProcessed: m.employeesProcessed.Value(),
+		SchedulesWritten:   m.schedulesWritten.Value(),
+		ValidationFailures: m.validationFailures.Value(),
+		ActiveImports:      m.activeImports.Value(),
+		Imports:            []ActiveImport{},
+	}
+	tenantID, _ := repo.TenantFromContext(ctx)
+	m.mu.Lock()
+	for _, run := range m.runs {
+		if run.tenantID != tenantID {
+			continue
+		}
+		progress.Imports = append(progress.Imports, ActiveImport{
+			ID: run.id, Phase: run.phase, Employees: run.employees,
+			Processed: run.processed.Value(), Rejected: run.rejected.Value(), StartedAt: run.startedAt,
+		})
+	}
+	m.mu.Unlock()
+	sort.Slice(progress.Imports, func(i, j int) bool { return progress.Imports[i].ID < progress.Imports[j].ID })
+	return progress
+}
+
+// countSchedules returns the number of schedule slots of the employees.
+func countSchedules(employees []*model.Employee) int {
+	n := 0
+	for _, employee := range employees {
+		n += len(employee.Schedules)
+	}
+	return n
+}
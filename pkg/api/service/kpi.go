@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// KPIFigures relates planned labor hours to revenue over a day or a month. Ratios are omitted when there is
+// no revenue to relate to, and costs when no hourly cost was given.
+type KPIFigures struct {
+	Hours   float64 `json:"hours"`
+	Revenue float64 `json:"revenue"`
+	// HoursPer1000 is the number of hours planned per €1000 of revenue.
+	HoursPer1000 *float64 `json:"hoursPer1000,omitempty"`
+	LaborCost    *float64 `json:"laborCost,omitempty"`
+	// CostRatio is the labor cost divided by the revenue.
+	CostRatio *float64 `json:"costRatio,omitempty"`
+}
+
+// KPIDay is the productivity of the store on one date.
+type KPIDay struct {
+	Date string `json:"date"`
+	KPIFigures
+}
+
+// KPIReport is the productivity of the store over a month, day by day and in total.
+type KPIReport struct {
+	Month string `json:"month"`
+	Year  int    `json:"year"`
+	// HourlyCost is the loaded cost of an hour of work the costs are computed with, if given.
+	HourlyCost *float64   `json:"hourlyCost,omitempty"`
+	Total      KPIFigures `json:"total"`
+	Days       []KPIDay   `json:"days"`
+}
+
+// SaveRevenues stores daily revenues, replacing the figures already imported for the same dates.
+func (s *EmployeeService) SaveRevenues(ctx context.Context, revenues []model.DailyRevenue) error {
+	for i := range revenues {
+		if revenues[i].Amount < 0 {
+			return apierror.Validation(fmt.Sprintf("amount must not be negative for %s", revenues[i].Date.Format("2006-01-02")))
+		}
+		revenues[i].ID = 0
+	}
+	return s.repo.RevenueUpsert(ctx, revenues)
+}
+
+// KPIReport relates the hours planned for every employee in the month, leave and overrides applied, to the
+// imported revenue. hourlyCost, when not nil, is the loaded cost of an hour of work used for the labor cost
+// and cost ratio.
+func (s *EmployeeService) KPIReport(ctx context.Context, month string, year int, hourlyCost *float64) (*KPIReport, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	if hourlyCost != nil && *hourlyCost < 0 {
+		return nil, apierror.Validation("hourlyCost must not be negative")
+	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+
+	rows, err := s.ExportMonthlySchedules(ctx, month, year)
+	if err != nil {
+		return nil, err
+	}
+	hours := make(map[string]float64)
+	for _, row := range rows {
+		hours[row.Date] += row.Hours
+	}
+	revenues, err := s.repo.RevenueFindBetween(ctx, firstDayOfMonth, lastDayOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	revenue := make(map[string]float64, len(revenues))
+	for _, r := range revenues {
+		revenue[r.Date.Format("2006-01-02")] = r.Amount
+	}
+
+	report := &KPIReport{Month: month, Year: year, HourlyCost: hourlyCost, Days: make([]KPIDay, 0, lastDayOfMonth.Day())}
+	var totalHours, totalRevenue float64
+	for d := firstDayOfMonth; !d.After(lastDayOfMonth); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		report.Days = append(report.Days, KPIDay{Date: date, KPIFigures: kpiFigures(hours[date], revenue[date], hourlyCost)})
+		totalHours += hours[date]
+		totalRevenue += revenue[date]
+	}
+	report.Total = kpiFigures(totalHours, totalRevenue, hourlyCost)
+	return report, nil
+}
+
+func kpiFigures(hours, revenue float64, hourlyCost *float64) KPIFigures {
+	figures := KPIFigures{Hours: hours, Revenue: revenue}
+	if hourlyCost != nil {
+		cost := hours * *hourlyCost
+		figures.LaborCost = &cost
+	}
+	if revenue > 0 {
+		perThousand := hours / revenue * 1000
+		figures.HoursPer1000 = &perThousand
+		if figures.LaborCost != nil {
+			ratio := *figures.LaborCost / revenue
+			figures.CostRatio = &ratio
+		}
+	}
+	return figures
+}
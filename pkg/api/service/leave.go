@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/payroll"
+	"gorm.io/gorm"
+	"time"
+)
+
+// maxLeaveDays bounds a single leave request, which is stored one row per day.
+const maxLeaveDays = 366
+
+// RequestLeave records a pending request for a day of personal leave for each day from input.From to
+// input.To. No day is recorded if one of them is already requested and not rejected.
+func (s *EmployeeService) RequestLeave(ctx context.Context, employeeID uint, input model.LeaveInput) ([]model.EmployeeHoliday, error) {
+	if input.From == "" {
+		return nil, apierror.Validation("from is required, expected YYYY-MM-DD").WithCode(apierror.CodeDateInvalid)
+	}
+	if input.To == "" {
+		input.To = input.From
+	}
+	from, to, err := ParseDateRange(input.From, input.To)
+	if err != nil {
+		return nil, err
+	}
+	if to.Sub(from) >= maxLeaveDays*24*time.Hour {
+		return nil, apierror.Validation(fmt.Sprintf("leave cannot span more than %d days", maxLeaveDays))
+	}
+
+	var employee model.Employee
+	if err := s.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+
+	var days []model.EmployeeHoliday
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, model.EmployeeHoliday{HolidayDate: d, Description: input.Description, WithoutPay: input.WithoutPay, Status: model.LeavePending})
+	}
+	if err := s.repo.LeaveCreate(ctx, employeeID, days); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, apierror.Conflict(fmt.Sprintf("employee %d already requested leave between %s and %s", employeeID, input.From, input.To)).WithCode(apierror.CodeLeaveExists)
+		}
+		return nil, err
+	}
+	return days, nil
+}
+
+// ListLeave returns the leave days of an employee from from to to included with the given status, or with
+// any status if it is empty.
+func (s *EmployeeService) ListLeave(ctx context.Context, employeeID uint, from, to time.Time, status string) ([]model.EmployeeHoliday, error) {
+	switch status {
+	case "", model.LeavePending, model.LeaveApproved, model.LeaveRejected:
+	default:
+		return nil, apierror.Validation(fmt.Sprintf("status must be one of '%s', '%s' or '%s', got: %s",
+			model.LeavePending, model.LeaveApproved, model.LeaveRejected, status))
+	}
+	return s.repo.LeaveFindBetween(ctx, employeeID, from, to, status)
+}
+
+// ApproveLeave approves the pending leave request the leave day id belongs to, on behalf of approverID.
+func (s *EmployeeService) ApproveLeave(ctx context.Context, id uint, approverID *uint) ([]model.EmployeeHoliday, error) {
+	days, err := s.decideLeave(ctx, id, model.LeaveApproved, approverID)
+	if err != nil {
+		return nil, err
+	}
+	s.emit(ctx, events.LeaveApproved, days)
+	return days, nil
+}
+
+// RejectLeave rejects the pending leave request the leave day id belongs to, on behalf of approverID. The
+// rejected days can be requested again.
+func (s *EmployeeService) RejectLeave(ctx context.Context, id uint, approverID *uint) ([]model.EmployeeHoliday, error) {
+	return s.decideLeave(ctx, id, model.LeaveRejected, approverID)
+}
+
+func (s *EmployeeService) decideLeave(ctx context.Context, id uint, status string, approverID *uint) ([]model.EmployeeHoliday, error) {
+	day, err := s.repo.LeaveFindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("leave %d not found", id)).WithCode(apierror.CodeLeaveNotFound)
+		}
+		return nil, err
+	}
+	requestID := day.RequestID
+	if requestID == 0 {
+		requestID = day.ID
+	}
+	days, err := s.repo.LeaveDecide(ctx, requestID, status, approverID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.Conflict(fmt.Sprintf("leave %d is already %s", id, day.Status)).WithCode(apierror.CodeLeaveDecided)
+		}
+		return nil, err
+	}
+	s.calendars.invalidate(day.EmployeeID)
+	return days, nil
+}
+
+// CancelLeave removes the leave days of an employee from from to to included and returns how many there were.
+func (s *EmployeeService) CancelLeave(ctx context.Context, employeeID uint, from, to time.Time) (int64, error) {
+	deleted, err := s.repo.LeaveDeleteBetween(ctx, employeeID, from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, apierror.NotFound(fmt.Sprintf("employee %d has no leave between %s and %s",
+				employeeID, from.Format("2006-01-02"), to.Format("2006-01-02"))).WithCode(apierror.CodeLeaveNotFound)
+		}
+		return 0, err
+	}
+	s.calendars.invalidate(employeeID)
+	return deleted, nil
+}
+
+// ParseDateRange parses two YYYY-MM-DD dates and checks that from is not after to.
+func ParseDateRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", from)).WithCode(apierror.CodeDateInvalid)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", to)).WithCode(apierror.CodeDateInvalid)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, apierror.Validation(fmt.Sprintf("%s is before %s", to, from)).WithCode(apierror.CodeDateInvalid)
+	}
+	return start, end, nil
+}
+
+// leaveAbsences merges the consecutive leave days of an employee, ordered by date, into payroll absences.
+// Days are merged only when they share their description and pay.
+func leaveAbsences(days []model.EmployeeHoliday) []payroll.Absence {
+	var absences []payroll.Absence
+	for _, day := range days {
+		date := day.HolidayDate.UTC()
+		if n := len(absences); n > 0 {
+			last := &absences[n-1]
+			if last.End.AddDate(0, 0, 1).Equal(date) && last.Reason == day.Description && last.Paid == !day.WithoutPay {
+				last.End = date
+				continue
+			}
+		}
+		absences = append(absences, payroll.Absence{Start: date, End: date, Reason: day.Description, Paid: !day.WithoutPay})
+	}
+	return absences
+}
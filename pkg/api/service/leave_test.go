@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLeaveApproval(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, approver := ids["Alice"], uint(1)
+	leave := model.LeaveInput{From: "2024-06-10", To: "2024-06-11", Description: "congé"}
+
+	requested, err := svc.RequestLeave(ctx, alice, leave)
+	require.NoError(t, err)
+	require.Len(t, requested, 2)
+	require.Equal(t, model.LeavePending, requested[0].Status)
+	_, err = svc.RequestLeave(ctx, alice, model.LeaveInput{From: "2024-06-11"})
+	require.Equal(t, apierror.CodeLeaveExists, apierror.CodeOf(err))
+	june, err := svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+	require.NoError(t, err)
+	require.Nil(t, june[9].Leave, "Pending leave is not in the calendar")
+
+	// Deciding on one day decides on the whole request; rejected days can be requested again.
+	rejected, err := svc.RejectLeave(ctx, requested[1].ID, &approver)
+	require.NoError(t, err)
+	require.Len(t, rejected, 2)
+	require.Equal(t, model.LeaveRejected, rejected[0].Status)
+	_, err = svc.ApproveLeave(ctx, requested[0].ID, &approver)
+	require.Equal(t, apierror.CodeLeaveDecided, apierror.CodeOf(err))
+	requested, err = svc.RequestLeave(ctx, alice, leave)
+	require.NoError(t, err)
+
+	approved, err := svc.ApproveLeave(ctx, requested[0].ID, &approver)
+	require.NoError(t, err)
+	require.Len(t, approved, 2)
+	require.Equal(t, model.LeaveApproved, approved[1].Status)
+	require.Equal(t, approver, *approved[1].ApproverID)
+	june, err = svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+	require.NoError(t, err)
+	require.NotNil(t, june[9].Leave, "The calendar cached before the approval is dropped")
+
+	from, to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	pending, err := svc.ListLeave(ctx, alice, from, to, model.LeavePending)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	_, err = svc.ListLeave(ctx, alice, from, to, "granted")
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+	_, err = svc.ApproveLeave(ctx, 999, &approver)
+	require.Equal(t, apierror.CodeLeaveNotFound, apierror.CodeOf(err))
+}
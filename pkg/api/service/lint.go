@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"sort"
+	"time"
+)
+
+// Lint rule IDs, stable so that clients can group, hide or translate suggestions.
+const (
+	// LintWeekBalance flags employees whose rotation weeks carry very different hours.
+	LintWeekBalance = "week-balance"
+	// LintShortSlot flags slots shorter than minSlotHours.
+	LintShortSlot = "short-slot"
+	// LintSingleSaturday flags Saturdays worked by a single employee.
+	LintSingleSaturday = "single-person-saturday"
+	// LintUnevenClosing flags employees closing the store much more often than the others.
+	LintUnevenClosing = "uneven-closing"
+)
+
+const (
+	// minSlotHours is the length under which a slot is hardly worth the commute.
+	minSlotHours = 2
+	// maxWeekImbalance is the share of the busiest week the lightest week of a rotation may lack.
+	maxWeekImbalance = 0.25
+	// maxClosingShare is how much more than the fair share of closings an employee may close.
+	maxClosingShare = 1.5
+)
+
+// LintFinding is one suggestion about a weekly template. EmployeeID is 0 for the employees of a draft.
+type LintFinding struct {
+	Rule       string    `json:"rule"`
+	Severity   string    `json:"severity"`
+	Message    string    `json:"message"`
+	Suggestion string    `json:"suggestion"`
+	EmployeeID uint      `json:"employeeId,omitempty"`
+	Employee   string    `json:"employee,omitempty"`
+	WeekType   string    `json:"weekType,omitempty"`
+	DayName    string    `json:"dayName,omitempty"`
+	Date       string    `json:"date,omitempty"`
+	Slot       *TimeSlot `json:"slot,omitempty"`
+}
+
+// LintReport lists the findings about the templates of the team from From to To included.
+type LintReport struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Findings []LintFinding `json:"findings"`
+}
+
+// LintSchedules analyzes the weekly templates of the team and suggests improvements. With a draft, in the
+// employee import format, the draft is analyzed instead of the stored calendars; nothing is saved. The rules
+// on dates (Saturdays, closing duty) are checked on the weeks starting with the week of from that cover
+// every combination of rotation weeks.
+func (s *EmployeeService) LintSchedules(ctx context.Context, draft []model.EmployeeInput, from time.Time) (*LintReport, error) {
+	var employees []model.Employee
+	if draft != nil {
+		drafted, err := s.employeesFromInput(ctx, draft, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, employee := range drafted {
+			if employee.RoleTemplateID != nil {
+				if employee.RoleTemplate, err = s.repo.RoleTemplateFindByID(ctx, *employee.RoleTemplateID); err != nil {
+					return nil, err
+				}
+			}
+			employees = append(employees, *employee)
+		}
+	} else {
+		var err error
+		if employees, err = s.repo.GetEmployeesWithSchedules(ctx); err != nil {
+			return nil, err
+		}
+	}
+	rotations, err := s.loadRotations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range employees {
+		employees[i].Schedules = resolveSchedules(&employees[i])
+	}
+
+	monday := util.MondayOf(from)
+	last := monday.AddDate(0, 0, 7*rotationWeeks(employees, rotations)-1)
+
+	findings := make([]LintFinding, 0)
+	for i := range employees {
+		findings = append(findings, lintEmployee(&employees[i], rotations.of(employees[i].RotationPatternID))...)
+	}
+	findings = append(findings, lintDays(employees, rotations, monday, last)...)
+	return &LintReport{From: monday.Format("2006-01-02"), To: last.Format("2006-01-02"), Findings: findings}, nil
+}
+
+// lintEmployee applies the rules on the template of one employee: week balance and short slots.
+func lintEmployee(employee *model.Employee, rotation *model.RotationPattern) []LintFinding {
+	var findings []LintFinding
+	hours := make(map[string]float64, len(rotation.Weeks))
+	for _, slot := range employee.Schedules {
+		length := slot.End().Sub(slot.StartTime.Time).Hours()
+		hours[slot.WeekType] += length
+		if length < minSlotHours {
+			findings = append(findings, LintFinding{
+				Rule:       LintShortSlot,
+				Severity:   "warning",
+				Message:    fmt.Sprintf("%s works a %s slot on %s of week %s", employee.Name, formatHours(length), slot.DayName, slot.WeekType),
+				Suggestion: fmt.Sprintf("Merge the slot with another one of the day or extend it to at least %dh.", minSlotHours),
+				EmployeeID: employee.ID,
+				Employee:   employee.Name,
+				WeekType:   slot.WeekType,
+				DayName:    slot.DayName,
+				Slot:       &TimeSlot{Start: slot.StartTime.Format("15:04"), End: slot.EndTime.Format("15:04"), Location: slot.Location, Task: slot.Task},
+			})
+		}
+	}
+
+	if len(rotation.Weeks) < 2 {
+		return findings
+	}
+	busiest, lightest := rotation.Weeks[0].Name, rotation.Weeks[0].Name
+	for _, week := range rotation.Weeks[1:] {
+		if hours[week.Name] > hours[busiest] {
+			busiest = week.Name
+		}
+		if hours[week.Name] < hours[lightest] {
+			lightest = week.Name
+		}
+	}
+	if hours[busiest]-hours[lightest] > maxWeekImbalance*hours[busiest] {
+		findings = append(findings, LintFinding{
+			Rule:     LintWeekBalance,
+			Severity: "info",
+			Message: fmt.Sprintf("%s works %s in week %s but %s in week %s", employee.Name,
+				formatHours(hours[busiest]), busiest, formatHours(hours[lightest]), lightest),
+			Suggestion: fmt.Sprintf("Move about %s from week %s to week %s.", formatHours((hours[busiest]-hours[lightest])/2), busiest, lightest),
+			EmployeeID: employee.ID,
+			Employee:   employee.Name,
+			WeekType:   busiest,
+		})
+	}
+	return findings
+}
+
+// slotsOnDate returns the resolved slots an employee following rotation works on date, ordered by start time:
+// none after its end date.
+func slotsOnDate(employee *model.Employee, rotation *model.RotationPattern, date time.Time) []model.Schedule {
+	if employee.EndedBefore(date) {
+		return nil
+	}
+	weekType := util.WeekTypeForDate(rotation, employee.RotationStart(rotation), date)
+	var day []model.Schedule
+	for _, slot := range employee.Schedules {
+		if slot.WeekType == weekType && slot.DayName == date.Weekday().String() {
+			day = append(day, slot)
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool { return day[i].StartTime.Before(day[j].StartTime.Time) })
+	return day
+}
+
+// lintDays applies the rules on the dates from first to last: single-person Saturdays and closing duty.
+func lintDays(employees []model.Employee, rotations rotations, first, last time.Time) []LintFinding {
+	var findings []LintFinding
+	closings := make(map[int]int)
+	worked := make(map[int]bool)
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		var working []int
+		var closers []int
+		var closing time.Time
+		for i := range employees {
+			if d.Format("2006-01-02") < employees[i].StartDate.Format("2006-01-02") {
+				continue // not hired yet
+			}
+			day := slotsOnDate(&employees[i], rotations.of(employees[i].RotationPatternID), d)
+			if len(day) == 0 {
+				continue
+			}
+			working = append(working, i)
+			worked[i] = true
+			end := day[0].End()
+			for _, slot := range day[1:] {
+				if slot.End().After(end) {
+					end = slot.End()
+				}
+			}
+			switch {
+			case len(closers) == 0 || end.After(closing):
+				closers, closing = []int{i}, end
+			case end.Equal(closing):
+				closers = append(closers, i)
+			}
+		}
+		for _, i := range closers {
+			closings[i]++
+		}
+
+		if d.Weekday() == time.Saturday && len(working) == 1 {
+			employee := &employees[working[0]]
+			rotation := rotations.of(employee.RotationPatternID)
+			findings = append(findings, LintFinding{
+				Rule:       LintSingleSaturday,
+				Severity:   "warning",
+				Message:    fmt.Sprintf("%s is alone in the store on Saturday %s", employee.Name, d.Format("2006-01-02")),
+				Suggestion: "Schedule a second employee on that Saturday, at least around the busiest hours.",
+				EmployeeID: employee.ID,
+				Employee:   employee.Name,
+				WeekType:   util.WeekTypeForDate(rotation, employee.RotationStart(rotation), d),
+				DayName:    time.Saturday.String(),
+				Date:       d.Format("2006-01-02"),
+			})
+		}
+	}
+
+	if len(worked) < 2 {
+		return findings
+	}
+	total := 0
+	for _, count := range closings {
+		total += count
+	}
+	fairShare := float64(total) / float64(len(worked))
+	indexes := make([]int, 0, len(worked))
+	for i := range worked {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	least := indexes[0]
+	for _, i := range indexes[1:] {
+		if closings[i] < closings[least] {
+			least = i
+		}
+	}
+	for _, i := range indexes {
+		count := float64(closings[i])
+		if count <= maxClosingShare*fairShare || count-fairShare < 2 {
+			continue
+		}
+		employee := &employees[i]
+		findings = append(findings, LintFinding{
+			Rule:     LintUnevenClosing,
+			Severity: "info",
+			Message: fmt.Sprintf("%s closes the store %d times from %s to %s, against a fair share of %.1f", employee.Name,
+				closings[i], first.Format("2006-01-02"), last.Format("2006-01-02"), fairShare),
+			Suggestion: fmt.Sprintf("Swap some closing shifts with %s, who closes %d times.", employees[least].Name, closings[least]),
+			EmployeeID: employee.ID,
+			Employee:   employee.Name,
+		})
+	}
+	return findings
+}
+
+func formatHours(hours float64) string {
+	if hours == float64(int(hours)) {
+		return fmt.Sprintf("%dh", int(hours))
+	}
+	return fmt.Sprintf("%dh%02d", int(hours), int((hours-float64(int(hours)))*60+0.5))
+}
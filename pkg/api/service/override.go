@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// SetScheduleOverride replaces the A/B slots of an employee on date (YYYY-MM-DD) by the slots of override,
+// or by none if it is off. An existing override of that date is replaced.
+func (s *EmployeeService) SetScheduleOverride(ctx context.Context, employeeID uint, date string, override model.ScheduleOverride) (*model.ScheduleOverride, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", date)).WithCode(apierror.CodeDateInvalid)
+	}
+	if override.Off && len(override.Slots) > 0 {
+		return nil, apierror.Validation("an override cannot be off and have slots")
+	}
+	if !override.Off && len(override.Slots) == 0 {
+		return nil, apierror.Validation("an override needs slots unless it is off")
+	}
+	sort.SliceStable(override.Slots, func(i, j int) bool { return override.Slots[i].StartTime.Before(override.Slots[j].StartTime.Time) })
+	for i, slot := range override.Slots {
+		if err := validateShift(slot.StartTime, slot.EndTime); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			previous := override.Slots[i-1]
+			if util.SlotsOverlap(previous.StartTime.Time, previous.End(), slot.StartTime.Time, slot.End()) {
+				return nil, apierror.Validation(fmt.Sprintf("slot %s-%s overlaps slot %s-%s",
+					slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04"),
+					previous.StartTime.Format("15:04"), previous.EndTime.Format("15:04"))).WithCode(apierror.CodeScheduleOverlap)
+			}
+		}
+	}
+
+	var employee model.Employee
+	if err := s.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+
+	override.ID, override.UUID = 0, ""
+	override.EmployeeID = employeeID
+	override.Date = day
+	override.Source, override.ChangedByID = model.SourceOverride, changedBy(ctx)
+	override.CreatedAt, override.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.OverrideSave(ctx, &override); err != nil {
+		return nil, err
+	}
+	s.calendars.invalidate(employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeOverride, Action: ActionWritten, ID: override.ID, Date: date, Resource: override})
+	return &override, nil
+}
+
+// ListScheduleOverrides returns the overrides of an employee from from to to included.
+func (s *EmployeeService) ListScheduleOverrides(ctx context.Context, employeeID uint, from, to time.Time) ([]model.ScheduleOverride, error) {
+	return s.repo.OverrideFindBetween(ctx, employeeID, from, to)
+}
+
+// DeleteScheduleOverride restores the A/B slots of an employee on date (YYYY-MM-DD).
+func (s *EmployeeService) DeleteScheduleOverride(ctx context.Context, employeeID uint, date string) error {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", date)).WithCode(apierror.CodeDateInvalid)
+	}
+	if err := s.repo.OverrideDelete(ctx, employeeID, day); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("employee %d has no override on %s", employeeID, date)).WithCode(apierror.CodeOverrideNotFound)
+		}
+		return err
+	}
+	s.calendars.invalidate(employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeOverride, Action: ActionDeleted, Date: date})
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestScheduleOverrides(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice := ids["Alice"]
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	june := func() []model.MonthlySchedule {
+		days, err := svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+		require.NoError(t, err)
+		return days
+	}
+	require.Len(t, june()[9].TimeSlots, 2, "Alice works Mondays from 9:00 to 17:00")
+
+	// Monday the 10th moves to the afternoon, Tuesday the 11th is worked and Monday the 17th is off.
+	_, err := svc.SetScheduleOverride(ctx, alice, "2024-06-10", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{StartTime: at(14), EndTime: at(18), Task: "lab"}}})
+	require.NoError(t, err)
+	_, err = svc.SetScheduleOverride(ctx, alice, "2024-06-11", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{StartTime: at(9), EndTime: at(12)}}})
+	require.NoError(t, err)
+	_, err = svc.SetScheduleOverride(ctx, alice, "2024-06-17", model.ScheduleOverride{Off: true, Reason: "training"})
+	require.NoError(t, err)
+	days := june()
+	require.True(t, days[9].Overridden)
+	require.Equal(t, []model.TimeSlot{{Start: "14:00", End: "18:00", Task: "lab"}}, days[9].TimeSlots)
+	require.Equal(t, []model.TimeSlot{{Start: "09:00", End: "12:00"}}, days[10].TimeSlots)
+	require.True(t, days[16].Overridden)
+	require.Empty(t, days[16].TimeSlots)
+	require.False(t, days[23].Overridden, "The other Mondays keep their A/B slots")
+	require.Len(t, days[23].TimeSlots, 2)
+
+	// Setting an override of the same date again replaces it; deleting it brings the A/B slots back.
+	_, err = svc.SetScheduleOverride(ctx, alice, "2024-06-10", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{StartTime: at(15), EndTime: at(19)}}})
+	require.NoError(t, err)
+	overrides, err := svc.ListScheduleOverrides(ctx, alice, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, overrides, 2)
+	require.Equal(t, "15:00", overrides[0].Slots[0].StartTime.Format("15:04"))
+	require.NoError(t, svc.DeleteScheduleOverride(ctx, alice, "2024-06-10"))
+	days = june()
+	require.False(t, days[9].Overridden)
+	require.Len(t, days[9].TimeSlots, 2)
+	require.Equal(t, apierror.CodeOverrideNotFound, apierror.CodeOf(svc.DeleteScheduleOverride(ctx, alice, "2024-06-10")))
+}
+
+func TestSetScheduleOverrideRejects(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	morning := []model.ScheduleOverrideSlot{{StartTime: at(9), EndTime: at(12)}}
+
+	for _, test := range []struct {
+		name     string
+		employee uint
+		date     string
+		override model.ScheduleOverride
+		code     apierror.Code
+	}{
+		{"bad date", ids["Alice"], "10/06/2024", model.ScheduleOverride{Slots: morning}, apierror.CodeDateInvalid},
+		{"off with slots", ids["Alice"], "2024-06-10", model.ScheduleOverride{Off: true, Slots: morning}, apierror.CodeValidationFailed},
+		{"no slots", ids["Alice"], "2024-06-10", model.ScheduleOverride{}, apierror.CodeValidationFailed},
+		{"overlap", ids["Alice"], "2024-06-10", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{
+			{StartTime: at(11), EndTime: at(14)}, {StartTime: at(9), EndTime: at(12)}}}, apierror.CodeScheduleOverlap},
+		{"unknown employee", 999, "2024-06-10", model.ScheduleOverride{Slots: morning}, apierror.CodeEmployeeNotFound},
+	} {
+		_, err := svc.SetScheduleOverride(ctx, test.employee, test.date, test.override)
+		require.Equal(t, test.code, apierror.CodeOf(err), test.name)
+	}
+	overrides, err := svc.ListScheduleOverrides(ctx, ids["Alice"], time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, overrides)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"math"
+	"time"
+)
+
+// OvertimeWeek compares the hours scheduled in one ISO week with the contract of the employee.
+type OvertimeWeek struct {
+	Year int `json:"year"`
+	Week int `json:"week"`
+	// Start and End are the Monday and the Sunday of the week.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// ScheduledHours are the hours scheduled and worked, the days of leave left out.
+	ScheduledHours float64 `json:"scheduledHours"`
+	// LeaveHours are the hours scheduled on days of approved leave, which count towards the contract.
+	LeaveHours float64 `json:"leaveHours"`
+	// ContractHours are the contracted weekly hours, prorated to the days employed when the employee started
+	// or was deactivated during the week.
+	ContractHours float64 `json:"contractHours"`
+	// Balance is ScheduledHours plus LeaveHours minus ContractHours: overtime when positive, undertime when
+	// negative.
+	Balance float64 `json:"balance"`
+}
+
+// OvertimeReport is the overtime balance of an employee over a month.
+type OvertimeReport struct {
+	EmployeeID          uint           `json:"employeeId"`
+	Employee            string         `json:"employee"`
+	Month               string         `json:"month"`
+	Year                int            `json:"year"`
+	ContractWeeklyHours float64        `json:"contractWeeklyHours"`
+	Weeks               []OvertimeWeek `json:"weeks"`
+	// OvertimeHours and UndertimeHours sum the positive and the negative weekly balances, UndertimeHours as
+	// a positive number.
+	OvertimeHours  float64 `json:"overtimeHours"`
+	UndertimeHours float64 `json:"undertimeHours"`
+	// Balance is the monthly balance, OvertimeHours minus UndertimeHours.
+	Balance float64 `json:"balance"`
+}
+
+// EmployeeOvertime compares, week by week, the hours scheduled for an employee with its contracted weekly
+// hours. A month covers the ISO weeks whose Thursday falls in it, so that every week is counted in exactly
+// one month even when it straddles two.
+func (s *EmployeeService) EmployeeOvertime(ctx context.Context, employeeID uint, month string, year int) (*OvertimeReport, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	first := isoWeekStart(firstDayOfMonth)
+	if first.AddDate(0, 0, 3).Before(firstDayOfMonth) {
+		first = first.AddDate(0, 0, 7)
+	}
+	last := isoWeekStart(firstDayOfMonth.AddDate(0, 1, -1))
+	if last.AddDate(0, 0, 3).Month() != time.Month(monthNum) {
+		last = last.AddDate(0, 0, -7)
+	}
+	last = last.AddDate(0, 0, 6)
+
+	employee, entries, err := s.calendarBetween(ctx, employeeID, first, last, "")
+	if err != nil {
+		return nil, err
+	}
+	report := &OvertimeReport{
+		EmployeeID:          employee.ID,
+		Employee:            employee.Name,
+		Month:               month,
+		Year:                year,
+		ContractWeeklyHours: employee.ContractWeeklyHours,
+		Weeks:               make([]OvertimeWeek, 0, len(entries)/7),
+	}
+	startDate := employee.StartDate.Format("2006-01-02")
+	endDate := "9999-12-31"
+	if employee.EndDate != nil {
+		endDate = employee.EndDate.Format("2006-01-02")
+	}
+	for i := 0; i+7 <= len(entries); i += 7 {
+		monday, err := time.Parse("2006-01-02", entries[i].Date)
+		if err != nil {
+			return nil, err
+		}
+		week := OvertimeWeek{Start: entries[i].Date, End: entries[i+6].Date}
+		week.Year, week.Week = monday.ISOWeek()
+		employed := 0
+		for _, entry := range entries[i : i+7] {
+			if entry.Date < startDate || entry.Date > endDate {
+				continue
+			}
+			employed++
+			hours, err := s.CalculateMonthlyHours([]model.MonthlySchedule{{Date: entry.Date, TimeSlots: entry.TimeSlots}})
+			if err != nil {
+				return nil, err
+			}
+			if entry.Leave != nil {
+				week.LeaveHours += hours.Worked
+			} else {
+				week.ScheduledHours += hours.Worked
+			}
+		}
+		week.ContractHours = roundHours(employee.ContractWeeklyHours * float64(employed) / 7)
+		week.Balance = roundHours(week.ScheduledHours + week.LeaveHours - week.ContractHours)
+		if week.Balance > 0 {
+			report.OvertimeHours += week.Balance
+		} else {
+			report.UndertimeHours -= week.Balance
+		}
+		report.Weeks = append(report.Weeks, week)
+	}
+	report.OvertimeHours = roundHours(report.OvertimeHours)
+	report.UndertimeHours = roundHours(report.UndertimeHours)
+	report.Balance = roundHours(report.OvertimeHours - report.UndertimeHours)
+	return report, nil
+}
+
+// isoWeekStart returns the Monday of the ISO week of date.
+func isoWeekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
+
+// roundHours rounds hours to the hundredth, hiding the float noise of prorated contracts.
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// CreatePairingRule validates and stores a pairing rule between two existing employees.
+func (s *EmployeeService) CreatePairingRule(ctx context.Context, rule model.PairingRule) (*model.PairingRule, error) {
+	switch rule.Kind {
+	case model.PairingTogether, model.PairingApart, model.PairingNotClosingTogether:
+	default:
+		return nil, apierror.Validation(fmt.Sprintf("kind must be one of '%s', '%s' or '%s', got: %s",
+			model.PairingTogether, model.PairingApart, model.PairingNotClosingTogether, rule.Kind))
+	}
+	if rule.EmployeeID == rule.OtherEmployeeID {
+		return nil, apierror.Validation("a pairing rule needs two different employees")
+	}
+	for _, id := range []uint{rule.EmployeeID, rule.OtherEmployeeID} {
+		var employee model.Employee
+		if err := s.repo.GetEmployeeByID(ctx, id, &employee); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, apierror.Validation(fmt.Sprintf("employee %d does not exist", id)).WithCode(apierror.CodeEmployeeNotFound)
+			}
+			return nil, err
+		}
+	}
+
+	rule.ID, rule.UUID = 0, ""
+	rule.CreatedAt, rule.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.PairingRuleCreate(ctx, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListPairingRules returns every pairing rule.
+func (s *EmployeeService) ListPairingRules(ctx context.Context) ([]model.PairingRule, error) {
+	return s.repo.PairingRuleList(ctx)
+}
+
+// DeletePairingRule removes a pairing rule.
+func (s *EmployeeService) DeletePairingRule(ctx context.Context, id uint) error {
+	if err := s.repo.PairingRuleDelete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("pairing rule %d not found", id)).WithCode(apierror.CodePairingRuleNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// PairingViolation is a slot of EmployeeID that breaks a pairing rule on Date.
+type PairingViolation struct {
+	RuleID          uint     `json:"ruleId"`
+	Kind            string   `json:"kind"`
+	Date            string   `json:"date"`
+	EmployeeID      uint     `json:"employeeId"`
+	OtherEmployeeID uint     `json:"otherEmployeeId"`
+	Slot            TimeSlot `json:"slot"`
+	Detail          string   `json:"detail"`
+}
+
+// PairingViolations checks every pairing rule against the calendars of the weeks starting on the Monday of
+// the week of from that cover every combination of the employees' rotation weeks: two weeks when everyone
+// follows the A/B rotation, see rotationWeeks otherwise.
+func (s *EmployeeService) PairingViolations(ctx context.Context, from time.Time) ([]PairingViolation, error) {
+	rules, err := s.repo.PairingRuleList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.checkPairingRules(ctx, rules, from)
+}
+
+// checkPairingRules checks rules as PairingViolations does. The days are those of the calendars of the
+// employees: nothing is worked before their start date, after their end date, on their approved leave and on
+// the closure days clearing the slots, and the overrides replace the slots of their date.
+func (s *EmployeeService) checkPairingRules(ctx context.Context, rules []model.PairingRule, from time.Time) ([]PairingViolation, error) {
+	violations := make([]PairingViolation, 0)
+	if len(rules) == 0 {
+		return violations, nil
+	}
+	monday := util.MondayOf(from)
+	// The overrides and leave days of the longest span that may be checked are loaded: the span depends on
+	// the rotations of the employees.
+	employees, rotations, err := s.teamCalendars(ctx, monday, monday.AddDate(0, 0, 7*maxRotationWeeks-1))
+	if err != nil {
+		return nil, err
+	}
+	last := monday.AddDate(0, 0, 7*rotationWeeks(employees, rotations)-1)
+	holidays := s.holidaysBetween(ctx, monday.AddDate(0, 0, -1), last)
+	byID := make(map[uint]*model.Employee, len(employees))
+	days := make(map[uint][][]model.Schedule, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+		if days[employees[i].ID], err = workedDays(&employees[i], rotations.of(employees[i].RotationPatternID), monday, last, holidays); err != nil {
+			return nil, err
+		}
+	}
+
+	for n, d := 0, monday; !d.After(last); n, d = n+1, d.AddDate(0, 0, 1) {
+		var closing time.Time
+		open := false
+		for _, employee := range employees {
+			for _, slot := range days[employee.ID][n] {
+				if !open || slot.End().After(closing) {
+					closing, open = slot.End(), true
+				}
+			}
+		}
+
+		for _, rule := range rules {
+			employee, other := byID[rule.EmployeeID], byID[rule.OtherEmployeeID]
+			if employee == nil || other == nil {
+				continue
+			}
+			violation := func(slot model.Schedule, detail string) {
+				violations = append(violations, PairingViolation{
+					RuleID:          rule.ID,
+					Kind:            rule.Kind,
+					Date:            d.Format("2006-01-02"),
+					EmployeeID:      rule.EmployeeID,
+					OtherEmployeeID: rule.OtherEmployeeID,
+					Slot:            TimeSlot{Start: slot.StartTime.Format("15:04"), End: slot.EndTime.Format("15:04"), Location: slot.Location, Task: slot.Task},
+					Detail:          detail,
+				})
+			}
+			mine, theirs := days[rule.EmployeeID][n], days[rule.OtherEmployeeID][n]
+			switch rule.Kind {
+			case model.PairingTogether:
+				for _, slot := range mine {
+					if !coveredBy(slot, theirs) {
+						violation(slot, fmt.Sprintf("%s works without %s", employee.Name, other.Name))
+					}
+				}
+			case model.PairingApart:
+				for _, slot := range mine {
+					for _, theirSlot := range theirs {
+						if util.SlotsOverlap(slot.StartTime.Time, slot.End(), theirSlot.StartTime.Time, theirSlot.End()) {
+							violation(slot, fmt.Sprintf("%s and %s work at the same time", employee.Name, other.Name))
+							break
+						}
+					}
+				}
+			case model.PairingNotClosingTogether:
+				if len(mine) > 0 && len(theirs) > 0 &&
+					mine[len(mine)-1].End().Equal(closing) && theirs[len(theirs)-1].End().Equal(closing) {
+					violation(mine[len(mine)-1], fmt.Sprintf("%s and %s both close at %s", employee.Name, other.Name, closing.Format("15:04")))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// workedDays returns the slots an employee following rotation works on every date from first to last, as its
+// calendar lists them, each day ordered by start time. The days before its start date and its days of leave
+// are empty.
+func workedDays(employee *model.Employee, rotation *model.RotationPattern, first, last time.Time, holidays map[string]dayHoliday) ([][]model.Schedule, error) {
+	entries := monthlyCalendar(employee, rotation, first, last, holidays, employee.LeaveDays, employee.Overrides, "")
+	days := make([][]model.Schedule, len(entries))
+	for n, entry := range entries {
+		if entry.Leave != nil || employee.StartDate.Format("2006-01-02") > entry.Date {
+			continue
+		}
+		day, err := clockSlots(entry.TimeSlots)
+		if err != nil {
+			return nil, fmt.Errorf("%w of employee ID %d", err, employee.ID)
+		}
+		days[n] = day
+	}
+	return days, nil
+}
+
+// clockSlots returns the slots of a day of a calendar as schedule slots on the clock of the day, ordered by
+// start time, so that the slots ending at midnight or later end after those ending earlier.
+func clockSlots(slots []model.TimeSlot) ([]model.Schedule, error) {
+	day := make([]model.Schedule, 0, len(slots))
+	for _, slot := range slots {
+		start, end, err := slot.Clock()
+		if err != nil {
+			return nil, fmt.Errorf("slot %s-%s: invalid time", slot.Start, slot.End)
+		}
+		day = append(day, model.Schedule{StartTime: model.CustomTime{Time: start}, EndTime: model.CustomTime{Time: end},
+			Location: slot.Location, Task: slot.Task})
+	}
+	sort.SliceStable(day, func(i, j int) bool { return day[i].StartTime.Before(day[j].StartTime.Time) })
+	return day, nil
+}
+
+// closesAt reports whether one of slots ends at closing.
+func closesAt(slots []model.Schedule, closing time.Time) bool {
+	for _, slot := range slots {
+		if slot.End().Equal(closing) {
+			return true
+		}
+	}
+	return false
+}
+
+// coveredBy reports whether slot lies entirely within the union of others, which are ordered by start time.
+func coveredBy(slot model.Schedule, others []model.Schedule) bool {
+	reached := slot.StartTime.Time
+	for _, other := range others {
+		if !reached.Before(slot.End()) {
+			break
+		}
+		if other.StartTime.After(reached) {
+			break
+		}
+		if other.End().After(reached) {
+			reached = other.End()
+		}
+	}
+	return !reached.Before(slot.End())
+}
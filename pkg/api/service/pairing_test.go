@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/db/repo/repotest"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// newPairingService returns a service on a fresh in-memory database where Bob, a trainee, must work with
+// Alice, his mentor, and Carol must not work at the same time as Bob. They all work on Mondays from June 2024,
+// Carol from the 17th only.
+func newPairingService(tb testing.TB) (*EmployeeService, map[string]uint) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(tb))
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {}}})
+	ctx := context.Background()
+	weeks := func(start, end string) map[string]model.WeeklyScheduleInput {
+		week := model.WeeklyScheduleInput{Monday: []model.ScheduleInput{{Start: start, End: end}}}
+		return map[string]model.WeeklyScheduleInput{"A": week, "B": week}
+	}
+	require.NoError(tb, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Alice", StartDate: "2024-06-03", Weeks: weeks("9:00", "17:00")},
+		{Name: "Bob", StartDate: "2024-06-03", Weeks: weeks("9:00", "12:00")},
+		{Name: "Carol", StartDate: "2024-06-17", Weeks: weeks("10:00", "11:00")},
+	}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(tb, err)
+	ids := make(map[string]uint)
+	for _, employee := range employees {
+		ids[employee.Name] = employee.ID
+	}
+	for _, rule := range []model.PairingRule{
+		{Kind: model.PairingTogether, EmployeeID: ids["Bob"], OtherEmployeeID: ids["Alice"]},
+		{Kind: model.PairingApart, EmployeeID: ids["Carol"], OtherEmployeeID: ids["Bob"]},
+	} {
+		_, err := svc.CreatePairingRule(ctx, rule)
+		require.NoError(tb, err)
+	}
+	return svc, ids
+}
+
+func TestPairingViolationsFollowTheCalendars(t *testing.T) {
+	svc, ids := newPairingService(t)
+	ctx := context.Background()
+	june := func(day int) time.Time { return time.Date(2024, time.June, day, 0, 0, 0, 0, time.UTC) }
+
+	violations, err := svc.PairingViolations(ctx, june(5))
+	require.NoError(t, err)
+	require.Empty(t, violations, "Carol is not hired before the 17th")
+
+	// Alice only comes in the afternoon of the 3rd, and is on leave on the 10th.
+	_, err = svc.SetScheduleOverride(ctx, ids["Alice"], "2024-06-03", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{
+		StartTime: model.CustomTime{Time: time.Date(0, 1, 1, 13, 0, 0, 0, time.UTC)}, EndTime: model.CustomTime{Time: time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC)}}}})
+	require.NoError(t, err)
+	leave, err := svc.RequestLeave(ctx, ids["Alice"], model.LeaveInput{From: "2024-06-10"})
+	require.NoError(t, err)
+	_, err = svc.ApproveLeave(ctx, leave[0].ID, nil)
+	require.NoError(t, err)
+	violations, err = svc.PairingViolations(ctx, june(5))
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	for i, date := range []string{"2024-06-03", "2024-06-10"} {
+		require.Equal(t, date, violations[i].Date)
+		require.Equal(t, model.PairingTogether, violations[i].Kind)
+		require.Equal(t, ids["Bob"], violations[i].EmployeeID)
+		require.Equal(t, TimeSlot{Start: "09:00", End: "12:00"}, violations[i].Slot)
+	}
+
+	// Nobody works on the closure day of the 24th.
+	_, err = svc.CreateClosureDay(ctx, model.ClosureDayInput{Date: "2024-06-24", Name: "Inventory", ClearSlots: true})
+	require.NoError(t, err)
+	violations, err = svc.PairingViolations(ctx, june(17))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	require.Equal(t, "2024-06-17", violations[0].Date)
+	require.Equal(t, model.PairingApart, violations[0].Kind)
+}
+
+func TestDetectConflictsReportsPairings(t *testing.T) {
+	svc, ids := newPairingService(t)
+	ctx := context.Background()
+	monday := time.Date(2024, time.June, 17, 0, 0, 0, 0, time.UTC)
+
+	conflicts, err := svc.DetectConflicts(ctx, ids["Carol"], monday)
+	require.NoError(t, err)
+	require.Empty(t, conflicts.Locations)
+	require.Len(t, conflicts.Pairings, 2, "Carol works with Bob on the 17th and the 24th")
+	require.Equal(t, ids["Carol"], conflicts.Pairings[0].EmployeeID)
+
+	// Alice is bound by the rule of Bob, who keeps it.
+	conflicts, err = svc.DetectConflicts(ctx, ids["Alice"], monday)
+	require.NoError(t, err)
+	require.Empty(t, conflicts.Pairings)
+
+	_, err = svc.DetectConflicts(ctx, 999, monday)
+	require.Equal(t, apierror.CodeEmployeeNotFound, apierror.CodeOf(err))
+}
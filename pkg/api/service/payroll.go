@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/payroll"
+)
+
+// PayrollPeriod sums the monthly calendar of every employee into the data handed to the payroll formatters.
+func (svc *EmployeeService) PayrollPeriod(ctx context.Context, month string, year int) (*payroll.Period, error) {
+	_, monthNumber, err := util.ParseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := svc.repo.GetEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := svc.ExportMonthlySchedules(ctx, month, year)
+	if err != nil {
+		return nil, err
+	}
+
+	period := &payroll.Period{Year: year, Month: monthNumber, Employees: make([]payroll.Employee, 0, len(employees))}
+	index := make(map[uint]int, len(employees))
+	for _, employee := range employees {
+		if employee.EndedBefore(period.Start()) {
+			continue
+		}
+		index[employee.ID] = len(period.Employees)
+		period.Employees = append(period.Employees, payroll.Employee{
+			ID:                  employee.ID,
+			Name:                employee.Name,
+			StartDate:           employee.StartDate,
+			ContractWeeklyHours: employee.ContractWeeklyHours,
+			HourlyRate:          employee.HourlyRate,
+		})
+	}
+	leaveDays, err := svc.repo.LeaveFindAllBetween(ctx, period.Start(), period.End(), model.LeaveApproved)
+	if err != nil {
+		return nil, err
+	}
+	byEmployee := make(map[uint][]model.EmployeeHoliday)
+	for _, day := range leaveDays {
+		byEmployee[day.EmployeeID] = append(byEmployee[day.EmployeeID], day)
+	}
+	for id, days := range byEmployee {
+		if i, ok := index[id]; ok {
+			period.Employees[i].Absences = leaveAbsences(days)
+		}
+	}
+
+	for _, row := range rows {
+		i, ok := index[row.EmployeeID]
+		if !ok {
+			continue
+		}
+		period.Employees[i].WorkedHours += row.Hours
+		if row.PublicHoliday {
+			period.Employees[i].HolidayHours += row.Hours
+		}
+	}
+	return period, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/payroll"
+	"github.com/lichensio/api_server/pkg/api/storage"
+	"github.com/lichensio/api_server/pkg/api/webhook"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// printJobTimeout bounds the rendering of a print job. A job running for longer is taken to be abandoned by
+// a worker that stopped and is claimed again.
+const printJobTimeout = 10 * time.Minute
+
+// printDownloadPath is the route downloading the artifact of a print job, given its UUID.
+const printDownloadPath = "/prox/api/prints/%s/download"
+
+// PrintQueue configures the workers rendering print jobs, see StartPrintQueue.
+type PrintQueue struct {
+	// Store keeps the rendered artifacts.
+	Store storage.Store
+	// Workers is the number of jobs rendered at once by this instance, 1 when not set.
+	Workers int
+	// Secret signs the download links.
+	Secret []byte
+	// BaseURL prefixes the download links, such as https://planning.example.com; without it the links are
+	// relative to the host of the API, which webhook consumers cannot follow.
+	BaseURL string
+	// LinkTTL is how long a download link stays valid, 15 minutes when not set.
+	LinkTTL time.Duration
+	// PollInterval is how often idle workers look for the jobs queued by other instances, 5 seconds when
+	// not set. The jobs requested from this instance wake a worker at once.
+	PollInterval time.Duration
+}
+
+// PrintInput requests the generation of an export: the monthly schedules (format csv) or the payroll of a
+// month (format dsn or csv, see payroll.Names). Month is as accepted by util.ParseMonth; Year may be left
+// out when Month is YYYY-MM. CallbackURL optionally receives the outcome.
+type PrintInput struct {
+	Kind        string `json:"kind"`
+	Format      string `json:"format"`
+	Month       string `json:"month"`
+	Year        int    `json:"year"`
+	CallbackURL string `json:"callbackUrl"`
+}
+
+// StartPrintQueue starts the workers rendering the print jobs, until ctx is done. Without it print jobs
+// cannot be requested.
+func (s *EmployeeService) StartPrintQueue(ctx context.Context, queue PrintQueue) {
+	if queue.Workers < 1 {
+		queue.Workers = 1
+	}
+	if queue.LinkTTL <= 0 {
+		queue.LinkTTL = 15 * time.Minute
+	}
+	if queue.PollInterval <= 0 {
+		queue.PollInterval = 5 * time.Second
+	}
+	queue.BaseURL = strings.TrimSuffix(queue.BaseURL, "/")
+	s.prints = &queue
+	s.printWake = make(chan struct{}, queue.Workers)
+	for i := 0; i < queue.Workers; i++ {
+		go s.printWorker(ctx)
+	}
+}
+
+// RequestPrint queues the generation of an export and returns the queued job, which a worker renders.
+func (s *EmployeeService) RequestPrint(ctx context.Context, input PrintInput) (*model.PrintJob, error) {
+	if s.prints == nil {
+		return nil, apierror.Unavailable("the print queue is not running on this instance", nil)
+	}
+	job := model.PrintJob{Kind: input.Kind, Format: strings.ToLower(input.Format), Status: model.PrintQueued, RequestedByID: changedBy(ctx)}
+	switch job.Kind {
+	case model.PrintSchedules:
+		if job.Format == "" {
+			job.Format = "csv"
+		}
+		if job.Format != "csv" {
+			return nil, apierror.Validation(fmt.Sprintf("unsupported format %q, only csv is available", input.Format))
+		}
+	case model.PrintPayroll:
+		if job.Format == "" {
+			job.Format = "dsn"
+		}
+		if _, ok := payroll.Lookup(job.Format); !ok {
+			return nil, apierror.Validation(fmt.Sprintf("unsupported format %q, expected one of %s", input.Format, strings.Join(payroll.Names(), ", ")))
+		}
+	default:
+		return nil, apierror.Validation(fmt.Sprintf("kind must be %s or %s, got: %q", model.PrintSchedules, model.PrintPayroll, input.Kind))
+	}
+	monthYear, month, err := util.ParseMonth(input.Month)
+	if err != nil {
+		return nil, apierror.Validation(err.Error()).WithCode(apierror.CodeMonthInvalid)
+	}
+	job.Month, job.Year = month.String(), input.Year
+	if job.Year == 0 {
+		job.Year = monthYear
+	}
+	if job.Year < 1 || job.Year > 9999 {
+		return nil, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid)
+	}
+	if monthYear != 0 && monthYear != job.Year {
+		return nil, apierror.Validation(fmt.Sprintf("month %s and year %d disagree", input.Month, job.Year)).WithCode(apierror.CodeMonthInvalid)
+	}
+	if input.CallbackURL != "" && !isHTTPURL(input.CallbackURL) {
+		return nil, apierror.Validation(fmt.Sprintf("callbackUrl must be an absolute http or https URL, got: %q", input.CallbackURL))
+	}
+	job.CallbackURL = input.CallbackURL
+	job.Region, _ = repo.HolidayRegionFromContext(ctx)
+
+	if err := s.repo.PrintJobCreate(ctx, &job); err != nil {
+		return nil, err
+	}
+	select {
+	case s.printWake <- struct{}{}:
+	default:
+		// Every worker is already awake.
+	}
+	return &job, nil
+}
+
+// FindPrintJob returns a print job, with a fresh download link once it is done.
+func (s *EmployeeService) FindPrintJob(ctx context.Context, id uint) (*model.PrintJob, error) {
+	job, err := s.repo.PrintJobFindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("print job %d not found", id)).WithCode(apierror.CodePrintJobNotFound)
+		}
+		return nil, err
+	}
+	s.linkPrint(job)
+	return job, nil
+}
+
+// OpenPrint checks a download link and returns the job it was signed for with its artifact, which the caller
+// must close. The link is the only credential: it is valid until it expires, for anyone holding it.
+func (s *EmployeeService) OpenPrint(ctx context.Context, uuid, expires, signature string) (*model.PrintJob, io.ReadCloser, error) {
+	invalid := apierror.Unauthorized("the download link is invalid or expired").WithCode(apierror.CodeDownloadLinkInvalid)
+	if s.prints == nil {
+		return nil, nil, invalid
+	}
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiry || !hmac.Equal([]byte(signature), []byte(s.prints.sign(uuid, expiry))) {
+		return nil, nil, invalid
+	}
+	job, err := s.repo.PrintJobFindByUUID(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.NotFound(fmt.Sprintf("print job %s not found", uuid)).WithCode(apierror.CodePrintJobNotFound)
+		}
+		return nil, nil, err
+	}
+	if job.Status != model.PrintDone {
+		return nil, nil, apierror.NotFound(fmt.Sprintf("print job %s has no artifact", uuid)).WithCode(apierror.CodePrintJobNotFound)
+	}
+	artifact, err := s.prints.Store.Open(ctx, job.ArtifactKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, apierror.NotFound(fmt.Sprintf("the artifact of print job %s is gone", uuid)).WithCode(apierror.CodePrintJobNotFound)
+		}
+		return nil, nil, err
+	}
+	return job, artifact, nil
+}
+
+// sign returns the signature of the download link of the job with the given UUID, expiring at expiry.
+func (q *PrintQueue) sign(uuid string, expiry int64) string {
+	mac := hmac.New(sha256.New, q.Secret)
+	fmt.Fprintf(mac, "%s:%d", uuid, expiry)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// linkPrint sets the download link of a done job.
+func (s *EmployeeService) linkPrint(job *model.PrintJob) {
+	if s.prints == nil || job.Status != model.PrintDone {
+		return
+	}
+	expiry := time.Now().Add(s.prints.LinkTTL).Unix()
+	job.DownloadURL = fmt.Sprintf("%s"+printDownloadPath+"?expires=%d&signature=%s", s.prints.BaseURL, job.UUID, expiry, s.prints.sign(job.UUID, expiry))
+}
+
+// printWorker renders the queued jobs one after the other, then waits to be woken or polls for new ones.
+func (s *EmployeeService) printWorker(ctx context.Context) {
+	for {
+		job, err := s.repo.PrintJobClaim(ctx, time.Now().Add(-printJobTimeout))
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Failed to claim a print job: %v", err)
+		}
+		if job != nil {
+			s.runPrint(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.printWake:
+		case <-time.After(s.prints.PollInterval):
+		}
+	}
+}
+
+// runPrint renders a claimed job within its tenant and holiday region, stores the artifact and reports the
+// outcome.
+func (s *EmployeeService) runPrint(ctx context.Context, job *model.PrintJob) {
+	if job.TenantID != 0 {
+		ctx = repo.WithTenant(ctx, job.TenantID)
+	}
+	if job.Region != "" {
+		ctx = repo.WithHolidayRegion(ctx, job.Region)
+	}
+	renderCtx, cancel := context.WithTimeout(ctx, printJobTimeout)
+	defer cancel()
+
+	var artifact bytes.Buffer
+	err := s.renderPrint(renderCtx, job, &artifact)
+	if err == nil {
+		job.ArtifactKey = fmt.Sprintf("prints/%s/%s", job.UUID, job.FileName)
+		job.Size, err = s.prints.Store.Put(renderCtx, job.ArtifactKey, &artifact)
+	}
+	now := time.Now()
+	job.CompletedAt = &now
+	eventType := events.PrintCompleted
+	if err != nil {
+		log.Errorf("Print job %d failed: %v", job.ID, err)
+		job.Status, job.Error, job.ArtifactKey = model.PrintFailed, err.Error(), ""
+		eventType = events.PrintFailed
+	} else {
+		job.Status = model.PrintDone
+	}
+	if err := s.repo.PrintJobSave(ctx, job); err != nil {
+		log.Errorf("Failed to save print job %d: %v", job.ID, err)
+		return
+	}
+
+	s.linkPrint(job)
+	event := events.New(eventType, job)
+	s.events.Publish(event)
+	if job.CallbackURL == "" {
+		return
+	}
+	payload, err := renderWebhook(&model.Webhook{Format: "json"}, event)
+	if err != nil {
+		log.Errorf("Failed to render the callback of print job %d: %v", job.ID, err)
+		return
+	}
+	if delivery := webhook.Deliver(ctx, s.webhookClient, job.CallbackURL, payload); !delivery.Delivered() {
+		log.Warnf("The callback of print job %d was not delivered: %s", job.ID, delivery.Error)
+	}
+}
+
+// renderPrint writes the export of a job to w, setting its file name and content type.
+func (s *EmployeeService) renderPrint(ctx context.Context, job *model.PrintJob, w io.Writer) error {
+	switch job.Kind {
+	case model.PrintSchedules:
+		rows, err := s.ExportMonthlySchedules(ctx, job.Month, job.Year)
+		if err != nil {
+			return err
+		}
+		job.FileName = fmt.Sprintf("schedules-%d-%s.csv", job.Year, strings.ToLower(job.Month))
+		job.ContentType = "text/csv; charset=utf-8"
+		return WriteSchedulesCSV(w, rows)
+	case model.PrintPayroll:
+		formatter, ok := payroll.Lookup(job.Format)
+		if !ok {
+			return fmt.Errorf("unknown payroll format %s", job.Format)
+		}
+		period, err := s.PayrollPeriod(ctx, job.Month, job.Year)
+		if err != nil {
+			return err
+		}
+		job.FileName = fmt.Sprintf("payroll-%d-%02d.%s", job.Year, period.Month, formatter.Extension())
+		job.ContentType = formatter.ContentType()
+		return formatter.Format(w, period)
+	}
+	return fmt.Errorf("unknown print kind %s", job.Kind)
+}
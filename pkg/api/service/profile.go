@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// EmployeePatch is a partial change of the profile of an employee: the fields left nil keep their value. Its
+// validate tags are checked by the handlers, as for model.EmployeeInput.
+type EmployeePatch struct {
+	// EndDate (YYYY-MM-DD) ends the contract of the employee on that day, see model.Employee.EndDate; an empty
+	// string reactivates the employee.
+	EndDate             *string  `json:"endDate" validate:"date"`
+	Department          *string  `json:"department" validate:"max=100"`
+	ContractWeeklyHours *float64 `json:"contractWeeklyHours" validate:"min=0,max=168"`
+	HourlyRate          *float64 `json:"hourlyRate" validate:"min=0"`
+	Email               *string  `json:"email" validate:"email"`
+	Phone               *string  `json:"phone" validate:"phone"`
+	Role                *string  `json:"role" validate:"max=100"`
+	Color               *string  `json:"color" validate:"color"`
+}
+
+// PatchEmployee changes the profile of an employee, leaving its name, start date and slots as they are. An end
+// date before the start date is answered 422.
+func (s *EmployeeService) PatchEmployee(ctx context.Context, id uint, patch EmployeePatch) (*model.Employee, error) {
+	employee, err := s.GetEmployee(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Department != nil {
+		employee.Department = *patch.Department
+	}
+	if patch.Email != nil {
+		employee.Email = *patch.Email
+	}
+	if patch.Phone != nil {
+		employee.Phone = *patch.Phone
+	}
+	if patch.Role != nil {
+		employee.Role = *patch.Role
+	}
+	if patch.Color != nil {
+		employee.Color = *patch.Color
+	}
+	if patch.ContractWeeklyHours != nil {
+		employee.ContractWeeklyHours = *patch.ContractWeeklyHours
+	}
+	if patch.HourlyRate != nil {
+		employee.HourlyRate = *patch.HourlyRate
+	}
+	if patch.EndDate != nil {
+		employee.EndDate = nil
+		if *patch.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", *patch.EndDate)
+			if err != nil {
+				return nil, apierror.Validation(fmt.Sprintf("invalid endDate %q, expected YYYY-MM-DD", *patch.EndDate)).WithCode(apierror.CodeDateInvalid)
+			}
+			if endDate.Before(employee.StartDate) {
+				return nil, apierror.Unprocessable("1 invalid field(s)", []apierror.InvalidParam{{Name: "endDate", Code: apierror.CodeDateInvalid,
+					Reason: fmt.Sprintf("cannot be before the start date %s", employee.StartDate.Format("2006-01-02"))}})
+			}
+			employee.EndDate = &endDate
+		}
+	}
+	if err := s.repo.UpdateEmployee(ctx, *employee); err != nil {
+		return nil, err
+	}
+	return s.GetEmployee(ctx, id)
+}
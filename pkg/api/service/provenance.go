@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/auth"
+	"strings"
+)
+
+// changedBy returns the user the request of ctx is authenticated as, recorded as the author of the slots it
+// writes; nil outside of an authenticated request.
+func changedBy(ctx context.Context) *uint {
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		userID := claims.UserID
+		return &userID
+	}
+	return nil
+}
+
+// SlotProvenance lists the stored slots matching filter with how they came to exist and who last changed
+// them, the most recent change first, to answer "who changed my Saturday".
+func (s *EmployeeService) SlotProvenance(ctx context.Context, filter model.ProvenanceFilter) ([]model.SlotProvenance, error) {
+	if filter.Source != "" {
+		known := false
+		for _, source := range model.Sources {
+			known = known || source == filter.Source
+		}
+		if !known {
+			return nil, apierror.Validation(fmt.Sprintf("unknown source %q, expected one of %s", filter.Source, strings.Join(model.Sources, ", ")))
+		}
+	}
+	return s.repo.ProvenanceFind(ctx, filter)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"sort"
+	"time"
+)
+
+// FullTimeWeeklyHours is the number of weekly hours counted as one full-time equivalent.
+const FullTimeWeeklyHours = 35.0
+
+// DepartmentCapacity compares, for one department and one week, the planned staffing with the
+// contracted staffing and the forecast demand, all expressed in full-time equivalents.
+type DepartmentCapacity struct {
+	Department    string  `json:"department"`
+	PlannedFTE    float64 `json:"plannedFte"`
+	ContractedFTE float64 `json:"contractedFte"`
+	ForecastFTE   float64 `json:"forecastFte"`
+	// GapFTE is PlannedFTE minus ForecastFTE; a negative gap means understaffing.
+	GapFTE float64 `json:"gapFte"`
+}
+
+// CapacityWeek groups the department capacities of the week starting on WeekStart.
+type CapacityWeek struct {
+	WeekStart   string               `json:"weekStart"`
+	Departments []DepartmentCapacity `json:"departments"`
+}
+
+// CapacityReport is the weekly capacity plan of a quarter.
+type CapacityReport struct {
+	Quarter             string         `json:"quarter"`
+	FullTimeWeeklyHours float64        `json:"fullTimeWeeklyHours"`
+	Weeks               []CapacityWeek `json:"weeks"`
+	// Partial is set when generation was cut short; Weeks then stops at the last complete week.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// CapacityReport computes planned FTE per department against contracted FTE and forecast demand for
+// every week overlapping the given quarter. Hours are aggregated by the database; only the A/B week
+// resolution happens here. If ctx is done midway, the complete weeks are returned, marked partial, along
+// with ctx.Err().
+func (s *EmployeeService) CapacityReport(ctx context.Context, year, quarter int) (*CapacityReport, error) {
+	if quarter < 1 || quarter > 4 {
+		return nil, apierror.Validation(fmt.Sprintf("quarter must be between 1 and 4, got: %d", quarter)).WithCode(apierror.CodeQuarterInvalid)
+	}
+	quarterStart := time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, time.UTC)
+	quarterEnd := quarterStart.AddDate(0, 3, -1)
+	firstWeek := util.MondayOf(quarterStart)
+
+	planned, err := s.repo.PlannedHoursByWeekType(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rotations, err := s.loadRotations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contracted, err := s.repo.ContractedHoursByDepartment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forecasts, err := s.repo.ForecastFindBetween(ctx, firstWeek, quarterEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	departmentSet := make(map[string]bool)
+	for department := range contracted {
+		departmentSet[department] = true
+	}
+	for _, row := range planned {
+		departmentSet[row.Department] = true
+	}
+	forecastByWeek := make(map[string]map[string]float64)
+	for _, forecast := range forecasts {
+		week := forecast.WeekStart.Format("2006-01-02")
+		if forecastByWeek[week] == nil {
+			forecastByWeek[week] = make(map[string]float64)
+		}
+		forecastByWeek[week][forecast.Department] += forecast.RequiredFTE
+		departmentSet[forecast.Department] = true
+	}
+	departments := make([]string, 0, len(departmentSet))
+	for department := range departmentSet {
+		departments = append(departments, department)
+	}
+	sort.Strings(departments)
+
+	report := &CapacityReport{
+		Quarter:             fmt.Sprintf("%d-Q%d", year, quarter),
+		FullTimeWeeklyHours: FullTimeWeeklyHours,
+		Weeks:               make([]CapacityWeek, 0, 14),
+	}
+	for week := firstWeek; !week.After(quarterEnd); week = week.AddDate(0, 0, 7) {
+		if err := ctx.Err(); err != nil {
+			report.Partial = true
+			return report, err
+		}
+		weekKey := week.Format("2006-01-02")
+		plannedHours := make(map[string]float64)
+		for _, row := range planned {
+			if row.StartDate.After(week.AddDate(0, 0, 6)) {
+				continue // not hired yet
+			}
+			if row.EndDate != nil && row.EndDate.Before(week) {
+				continue // left before the week
+			}
+			rotation := rotations.of(row.RotationPatternID)
+			if util.WeekTypeForDate(rotation, row.RotationStart(rotation), week) == row.WeekType {
+				plannedHours[row.Department] += row.Hours
+			}
+		}
+
+		capacityWeek := CapacityWeek{WeekStart: weekKey, Departments: make([]DepartmentCapacity, 0, len(departments))}
+		for _, department := range departments {
+			plannedFTE := plannedHours[department] / FullTimeWeeklyHours
+			forecastFTE := forecastByWeek[weekKey][department]
+			capacityWeek.Departments = append(capacityWeek.Departments, DepartmentCapacity{
+				Department:    department,
+				PlannedFTE:    plannedFTE,
+				ContractedFTE: contracted[department] / FullTimeWeeklyHours,
+				ForecastFTE:   forecastFTE,
+				GapFTE:        plannedFTE - forecastFTE,
+			})
+		}
+		report.Weeks = append(report.Weeks, capacityWeek)
+	}
+	return report, nil
+}
+
+// SaveForecasts stores demand forecasts, normalising each week start to its Monday.
+func (s *EmployeeService) SaveForecasts(ctx context.Context, forecasts []model.DemandForecast) error {
+	for i := range forecasts {
+		if forecasts[i].RequiredFTE < 0 {
+			return apierror.Validation(fmt.Sprintf("requiredFte must not be negative for %s", forecasts[i].Department))
+		}
+		forecasts[i].ID = 0
+		forecasts[i].WeekStart = util.MondayOf(forecasts[i].WeekStart)
+	}
+	return s.repo.ForecastUpsert(ctx, forecasts)
+}
+
+// StationDay is the coverage of a station on one date.
+type StationDay struct {
+	Date      string  `json:"date"`
+	Headcount int     `json:"headcount"`
+	Hours     float64 `json:"hours"`
+}
+
+// StationCoverage aggregates the slots tagged with one task over a month. Slots without a task are
+// grouped under an empty task.
+type StationCoverage struct {
+	Task       string       `json:"task"`
+	TotalHours float64      `json:"totalHours"`
+	Employees  int          `json:"employees"`
+	Days       []StationDay `json:"days"`
+}
+
+// StationCoverageReport is the per-station coverage of a month.
+type StationCoverageReport struct {
+	Month    string            `json:"month"`
+	Year     int               `json:"year"`
+	Stations []StationCoverage `json:"stations"`
+	// Partial is set when generation was cut short; only the first Employees employees are then counted.
+	Partial   bool `json:"partial,omitempty"`
+	Employees int  `json:"employees"`
+}
+
+// StationCoverage computes, for every task/station, the hours and headcount planned on each day of the month
+// across all employees, including the slots inherited from role templates. If ctx is done midway, the
+// coverage of the employees processed so far is returned, marked partial, along with ctx.Err().
+func (s *EmployeeService) StationCoverage(ctx context.Context, month string, year int) (*StationCoverageReport, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	employees, err := s.repo.GetEmployeesWithSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rotations, err := s.loadRotations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type dayKey struct{ task, date string }
+	hours := make(map[dayKey]float64)
+	staff := make(map[dayKey]map[uint]bool)
+	stationStaff := make(map[string]map[uint]bool)
+
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	processed := 0
+	var cancelled error
+	for i := range employees {
+		if cancelled = ctx.Err(); cancelled != nil {
+			break
+		}
+		processed++
+		employee := &employees[i]
+		schedules := resolveSchedules(employee)
+		for d := firstDayOfMonth; !d.After(lastDayOfMonth); d = d.AddDate(0, 0, 1) {
+			if d.Format("2006-01-02") < employee.StartDate.Format("2006-01-02") || employee.EndedBefore(d) {
+				continue // not hired yet or deactivated
+			}
+			rotation := rotations.of(employee.RotationPatternID)
+			weekType := util.WeekTypeForDate(rotation, employee.RotationStart(rotation), d)
+			for _, sched := range schedules {
+				if sched.WeekType != weekType || sched.DayName != d.Weekday().String() {
+					continue
+				}
+				key := dayKey{task: sched.Task, date: d.Format("2006-01-02")}
+				hours[key] += sched.End().Sub(sched.StartTime.Time).Hours()
+				if staff[key] == nil {
+					staff[key] = make(map[uint]bool)
+				}
+				staff[key][employee.ID] = true
+				if stationStaff[sched.Task] == nil {
+					stationStaff[sched.Task] = make(map[uint]bool)
+				}
+				stationStaff[sched.Task][employee.ID] = true
+			}
+		}
+	}
+
+	tasks := make([]string, 0, len(stationStaff))
+	for task := range stationStaff {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	report := &StationCoverageReport{
+		Month:     month,
+		Year:      year,
+		Stations:  make([]StationCoverage, 0, len(tasks)),
+		Partial:   cancelled != nil,
+		Employees: processed,
+	}
+	for _, task := range tasks {
+		station := StationCoverage{Task: task, Employees: len(stationStaff[task]), Days: []StationDay{}}
+		for d := firstDayOfMonth; !d.After(lastDayOfMonth); d = d.AddDate(0, 0, 1) {
+			key := dayKey{task: task, date: d.Format("2006-01-02")}
+			if len(staff[key]) == 0 {
+				continue
+			}
+			station.Days = append(station.Days, StationDay{Date: key.date, Headcount: len(staff[key]), Hours: hours[key]})
+			station.TotalHours += hours[key]
+		}
+		report.Stations = append(report.Stations, station)
+	}
+	return report, cancelled
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/db/repo/repotest"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// newStationService returns a service on a fresh in-memory database holding two employees working on
+// Mondays: Alice at the till then in the lab from June 3 2024, Bob at the till from June 10.
+func newStationService(tb testing.TB) (*EmployeeService, map[string]uint) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(tb))
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {}}})
+	ctx := context.Background()
+	alice := model.WeeklyScheduleInput{Monday: []model.ScheduleInput{{Start: "9:00", End: "12:00", Task: "till"}, {Start: "13:00", End: "17:00", Task: "lab"}}}
+	bob := model.WeeklyScheduleInput{Monday: []model.ScheduleInput{{Start: "9:00", End: "13:00", Task: "till"}}}
+	require.NoError(tb, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Alice", StartDate: "2024-06-03", Weeks: map[string]model.WeeklyScheduleInput{"A": alice, "B": alice}},
+		{Name: "Bob", StartDate: "2024-06-10", Weeks: map[string]model.WeeklyScheduleInput{"A": bob, "B": bob}},
+	}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(tb, err)
+	ids := make(map[string]uint)
+	for _, employee := range employees {
+		ids[employee.Name] = employee.ID
+	}
+	return svc, ids
+}
+
+func TestStationCoverage(t *testing.T) {
+	svc, _ := newStationService(t)
+
+	report, err := svc.StationCoverage(context.Background(), "June", 2024)
+	require.NoError(t, err)
+	require.False(t, report.Partial)
+	require.Equal(t, 2, report.Employees)
+	require.Len(t, report.Stations, 2)
+	lab, till := report.Stations[0], report.Stations[1]
+	require.Equal(t, StationCoverage{Task: "lab", TotalHours: 16, Employees: 1, Days: []StationDay{
+		{Date: "2024-06-03", Headcount: 1, Hours: 4}, {Date: "2024-06-10", Headcount: 1, Hours: 4},
+		{Date: "2024-06-17", Headcount: 1, Hours: 4}, {Date: "2024-06-24", Headcount: 1, Hours: 4}}}, lab)
+	require.Equal(t, "till", till.Task)
+	require.Equal(t, 2, till.Employees)
+	require.Equal(t, 24.0, till.TotalHours)
+	require.Equal(t, StationDay{Date: "2024-06-03", Headcount: 1, Hours: 3}, till.Days[0], "Bob starts on the 10th")
+	require.Equal(t, StationDay{Date: "2024-06-10", Headcount: 2, Hours: 7}, till.Days[1])
+
+	_, err = svc.StationCoverage(context.Background(), "Juin", 2024)
+	require.Equal(t, apierror.CodeMonthInvalid, apierror.CodeOf(err))
+}
+
+// cancelAfterLoad is a repository cancelling the request once the report has read all it needs, as a client
+// hanging up or the report timeout would while the employees are processed.
+type cancelAfterLoad struct {
+	repo.Repository
+	cancel context.CancelFunc
+}
+
+func (c cancelAfterLoad) RotationCalendarGet(ctx context.Context) (*model.RotationCalendar, error) {
+	defer c.cancel()
+	return c.Repository.RotationCalendarGet(ctx)
+}
+
+func TestStationCoverageCancelled(t *testing.T) {
+	svc, _ := newStationService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.repo = cancelAfterLoad{svc.repo, cancel}
+
+	report, err := svc.StationCoverage(ctx, "June", 2024)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, report.Partial)
+	require.Zero(t, report.Employees)
+	require.Empty(t, report.Stations)
+}
+
+// cancelAfterForecasts is a repository cancelling the request once the capacity report has read the
+// forecasts, its last query before the weeks are computed.
+type cancelAfterForecasts struct {
+	repo.Repository
+	cancel context.CancelFunc
+}
+
+func (c cancelAfterForecasts) ForecastFindBetween(ctx context.Context, from, to time.Time) ([]model.DemandForecast, error) {
+	defer c.cancel()
+	return c.Repository.ForecastFindBetween(ctx, from, to)
+}
+
+func TestCapacityReportCancelled(t *testing.T) {
+	svc, _ := newStationService(t)
+	report, err := svc.CapacityReport(context.Background(), 2024, 2)
+	require.NoError(t, err)
+	require.False(t, report.Partial)
+	require.Len(t, report.Weeks, 13)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.repo = cancelAfterForecasts{svc.repo, cancel}
+	report, err = svc.CapacityReport(ctx, 2024, 2)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, report.Partial)
+	require.Empty(t, report.Weeks)
+}
+
+func TestCapacityReportCountsOvernightSlots(t *testing.T) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(t))
+	ctx := context.Background()
+	night := model.WeeklyScheduleInput{Monday: []model.ScheduleInput{{Start: "22:00", End: "06:00"}}}
+	require.NoError(t, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Night Guard", StartDate: "2024-01-01", Department: "security", Weeks: map[string]model.WeeklyScheduleInput{"A": night, "B": night}},
+	}))
+
+	report, err := svc.CapacityReport(ctx, 2024, 2)
+	require.NoError(t, err)
+	require.Equal(t, "security", report.Weeks[0].Departments[0].Department)
+	require.InDelta(t, 8/FullTimeWeeklyHours, report.Weeks[0].Departments[0].PlannedFTE, 1e-9, "The slot lasts until 6:00 the next day")
+}
+
+func TestSetScheduleTask(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	slots, err := svc.repo.GetSchedule(ctx, ids["Bob"], "A")
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+
+	updated, err := svc.SetScheduleTask(ctx, slots[0].ID, "lab")
+	require.NoError(t, err)
+	require.Equal(t, "lab", updated.Task)
+	stored, err := svc.repo.GetScheduleByID(ctx, slots[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, "lab", stored.Task)
+	updated, err = svc.SetScheduleTask(ctx, slots[0].ID, "")
+	require.NoError(t, err)
+	require.Empty(t, updated.Task, "An empty task clears it")
+
+	_, err = svc.SetScheduleTask(ctx, slots[0].ID, string(bytes.Repeat([]byte("x"), 51)))
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+	_, err = svc.SetScheduleTask(ctx, 999, "lab")
+	require.Equal(t, apierror.CodeScheduleNotFound, apierror.CodeOf(err))
+}
+
+func TestExportMonthlySchedulesRendersTasks(t *testing.T) {
+	svc, ids := newStationService(t)
+
+	rows, err := svc.ExportMonthlySchedules(context.Background(), "June", 2024)
+	require.NoError(t, err)
+	var monday ScheduleExportRow
+	for _, row := range rows {
+		if row.EmployeeID == ids["Alice"] && row.Date == "2024-06-03" {
+			monday = row
+		}
+	}
+	require.Equal(t, "09:00-12:00 (till); 13:00-17:00 (lab)", monday.Slots)
+	require.Equal(t, "09:00", monday.Start)
+	require.Equal(t, "17:00", monday.End)
+	require.Equal(t, 7.0, monday.Hours)
+
+	var csv bytes.Buffer
+	require.NoError(t, WriteSchedulesCSV(&csv, []ScheduleExportRow{monday}))
+	require.Contains(t, csv.String(), ",7.00,09:00-12:00 (till); 13:00-17:00 (lab)\n")
+}
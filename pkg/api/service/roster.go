@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// RosterEmployee is the monthly calendar of one employee of the team roster.
+type RosterEmployee struct {
+	EmployeeID uint                    `json:"employeeId"`
+	Employee   string                  `json:"employee"`
+	Days       []model.MonthlySchedule `json:"days"`
+}
+
+// RosterShift is the day of one employee in a roster grouped by day.
+type RosterShift struct {
+	EmployeeID uint             `json:"employeeId"`
+	Employee   string           `json:"employee"`
+	Leave      *model.Leave     `json:"leave,omitempty"`
+	Overridden bool             `json:"overridden,omitempty"`
+	TimeSlots  []model.TimeSlot `json:"timeSlots"`
+}
+
+// RosterDay is one date of a roster grouped by day, with the day of every employee.
+type RosterDay struct {
+	Date        string `json:"date"`
+	DayName     string `json:"dayName"`
+	HolidayName string `json:"holidayName,omitempty"`
+	// HolidaySource tells whether HolidayName is a public holiday or a closure day of the company.
+	HolidaySource string        `json:"holidaySource,omitempty"`
+	Employees     []RosterShift `json:"employees"`
+}
+
+// TeamRoster builds the monthly calendar of every employee, as FetchEmployeeScheduleAtLocation does for one,
+// from a single query loading the employees with their slots, overrides and leave of the month.
+func (s *EmployeeService) TeamRoster(ctx context.Context, month string, year int, location string) ([]RosterEmployee, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	holidays := s.holidaysBetween(s.atLocation(ctx, location), firstDayOfMonth.AddDate(0, 0, -1), lastDayOfMonth)
+
+	employees, rotations, err := s.teamCalendars(ctx, firstDayOfMonth, lastDayOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	roster := make([]RosterEmployee, 0, len(employees))
+	for i := range employees {
+		employee := &employees[i]
+		roster = append(roster, RosterEmployee{
+			EmployeeID: employee.ID,
+			Employee:   employee.Name,
+			Days: monthlyCalendar(employee, rotations.of(employee.RotationPatternID), firstDayOfMonth, lastDayOfMonth,
+				holidays, employee.LeaveDays, employee.Overrides, location),
+		})
+	}
+	return roster, nil
+}
+
+// DayRoster builds the day of every employee on date, as TeamRoster does for a month: who works when and
+// where, and who is on leave.
+func (s *EmployeeService) DayRoster(ctx context.Context, date time.Time) (*RosterDay, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	holidays := s.holidaysBetween(ctx, day.AddDate(0, 0, -1), day)
+	employees, rotations, err := s.teamCalendars(ctx, day, day)
+	if err != nil {
+		return nil, err
+	}
+	roster := make([]RosterEmployee, 0, len(employees))
+	for i := range employees {
+		employee := &employees[i]
+		roster = append(roster, RosterEmployee{
+			EmployeeID: employee.ID,
+			Employee:   employee.Name,
+			Days: monthlyCalendar(employee, rotations.of(employee.RotationPatternID), day, day,
+				holidays, employee.LeaveDays, employee.Overrides, ""),
+		})
+	}
+	if days := RosterByDay(roster); len(days) > 0 {
+		return &days[0], nil
+	}
+	holiday := holidays[day.Format("2006-01-02")]
+	return &RosterDay{Date: day.Format("2006-01-02"), DayName: day.Weekday().String(), HolidayName: holiday.Name, HolidaySource: holiday.Source,
+		Employees: []RosterShift{}}, nil
+}
+
+// teamCalendars loads every employee not deactivated before first with its resolved slots in Schedules, from
+// its snapshot when snapshot reads are enabled, and its overrides and approved leave days from the day before
+// first, for the overnight slots continuing on first, to last included, together with the rotation patterns.
+func (s *EmployeeService) teamCalendars(ctx context.Context, first, last time.Time) ([]model.Employee, rotations, error) {
+	employees, rotations, err := s.calendarsOf(ctx, nil, first, last)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Employees deactivated before the period are left out.
+	active := employees[:0]
+	for _, employee := range employees {
+		if !employee.EndedBefore(first) {
+			active = append(active, employee)
+		}
+	}
+	return active, rotations, nil
+}
+
+// calendarsOf loads the employees of ids, or every employee when ids is nil, like teamCalendars but including
+// the employees deactivated before first.
+func (s *EmployeeService) calendarsOf(ctx context.Context, ids []uint, first, last time.Time) ([]model.Employee, rotations, error) {
+	employees, err := s.repo.GetEmployeesWithCalendar(ctx, ids, first.AddDate(0, 0, -1), last)
+	if err != nil {
+		return nil, nil, err
+	}
+	rotations, err := s.loadRotations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range employees {
+		if s.snapshotReads && employees[i].ScheduleSnapshot != nil {
+			employees[i].Schedules = employees[i].ScheduleSnapshot.Schedules
+		} else {
+			employees[i].Schedules = resolveSchedules(&employees[i])
+		}
+	}
+	return employees, rotations, nil
+}
+
+// RosterByDay regroups a roster date by date, the employees of each date in the order of the roster.
+func RosterByDay(roster []RosterEmployee) []RosterDay {
+	var days []RosterDay
+	for _, employee := range roster {
+		for i, entry := range employee.Days {
+			if i == len(days) {
+				days = append(days, RosterDay{Date: entry.Date, DayName: entry.DayName, HolidayName: entry.HolidayName, HolidaySource: entry.HolidaySource, Employees: make([]RosterShift, 0, len(roster))})
+			}
+			days[i].Employees = append(days[i].Employees, RosterShift{
+				EmployeeID: employee.EmployeeID,
+				Employee:   employee.Employee,
+				Leave:      entry.Leave,
+				Overridden: entry.Overridden,
+				TimeSlots:  entry.TimeSlots,
+			})
+		}
+	}
+	if days == nil {
+		days = make([]RosterDay, 0)
+	}
+	return days
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTeamRoster(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	_, err := svc.RequestLeave(ctx, ids["Bob"], model.LeaveInput{From: "2024-06-17"})
+	require.NoError(t, err)
+	leave, err := svc.ListLeave(ctx, ids["Bob"], time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), "")
+	require.NoError(t, err)
+	_, err = svc.ApproveLeave(ctx, leave[0].ID, nil)
+	require.NoError(t, err)
+
+	// The roster holds the calendar of every employee, as read one by one.
+	roster, err := svc.TeamRoster(ctx, "June", 2024, "")
+	require.NoError(t, err)
+	require.Len(t, roster, 2)
+	for _, employee := range roster {
+		days, err := svc.FetchEmployeeSchedule(ctx, employee.EmployeeID, "June", 2024)
+		require.NoError(t, err)
+		require.Equal(t, days, employee.Days, employee.Employee)
+	}
+
+	days := RosterByDay(roster)
+	require.Len(t, days, 30)
+	require.Equal(t, "2024-06-17", days[16].Date)
+	require.Len(t, days[16].Employees, 2)
+	for _, shift := range days[16].Employees {
+		if shift.EmployeeID == ids["Bob"] {
+			require.NotNil(t, shift.Leave)
+		} else {
+			require.Equal(t, "Alice", shift.Employee)
+			require.Len(t, shift.TimeSlots, 2)
+		}
+	}
+	require.Empty(t, RosterByDay(nil))
+
+	day, err := svc.DayRoster(ctx, time.Date(2024, 6, 17, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, days[16], *day)
+
+	_, err = svc.TeamRoster(ctx, "Juin", 2024, "")
+	require.Equal(t, apierror.CodeMonthInvalid, apierror.CodeOf(err))
+}
+
+func TestTeamRosterReadsTheDayBefore(t *testing.T) {
+	svc, _ := newStationService(t)
+	ctx := context.Background()
+	night := model.WeeklyScheduleInput{Friday: []model.ScheduleInput{{Start: "22:00", End: "06:00"}}}
+	require.NoError(t, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Night Guard", StartDate: "2024-05-06", Weeks: map[string]model.WeeklyScheduleInput{"A": night, "B": night}},
+	}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	var guard uint
+	for _, employee := range employees {
+		if employee.Name == "Night Guard" {
+			guard = employee.ID
+		}
+	}
+	// The night of Friday May 31 is a day of leave, so it does not continue on June 1.
+	_, err = svc.RequestLeave(ctx, guard, model.LeaveInput{From: "2024-05-31"})
+	require.NoError(t, err)
+	leave, err := svc.ListLeave(ctx, guard, time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), "")
+	require.NoError(t, err)
+	_, err = svc.ApproveLeave(ctx, leave[0].ID, nil)
+	require.NoError(t, err)
+
+	june, err := svc.FetchEmployeeSchedule(ctx, guard, "June", 2024)
+	require.NoError(t, err)
+	require.Empty(t, june[0].TimeSlots)
+	roster, err := svc.TeamRoster(ctx, "June", 2024, "")
+	require.NoError(t, err)
+	require.Len(t, roster, 3)
+	require.Equal(t, june, roster[2].Days)
+	day, err := svc.DayRoster(ctx, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Empty(t, day.Employees[2].TimeSlots)
+
+	totals, err := svc.HourTotals(ctx, HourTotalsInput{EmployeeIDs: []uint{guard}, From: "2024-06-01", To: "2024-06-07"})
+	require.NoError(t, err)
+	require.Equal(t, []HourTotals{{EmployeeID: guard, PlannedHours: 2}}, totals, "Only the night of June 7 counts, until midnight")
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// weekNamePattern is the shape of a week type: a short name such as "A", "B", "early" or "W3".
+var weekNamePattern = regexp.MustCompile(`^[A-Za-z0-9]{1,8}$`)
+
+// maxCycleLength bounds the number of weeks of a rotation pattern.
+const maxCycleLength = 12
+
+// maxRotationWeeks bounds the number of weeks checked by the analyses spanning whole rotations when rotations
+// of unrelated lengths are mixed.
+const maxRotationWeeks = 12
+
+// rotationWeeks returns the number of weeks after which the rotations of all employees start over together,
+// the least common multiple of their cycle lengths, at most maxRotationWeeks.
+func rotationWeeks(employees []model.Employee, rotations rotations) int {
+	weeks := 1
+	for i := range employees {
+		length := len(rotations.of(employees[i].RotationPatternID).Weeks)
+		x, y := weeks, length
+		for y != 0 {
+			x, y = y, x%y
+		}
+		weeks = weeks / x * length
+		if weeks > maxRotationWeeks {
+			return maxRotationWeeks
+		}
+	}
+	return weeks
+}
+
+// rotations maps the ID of every rotation pattern to the pattern, for the reports that resolve the week
+// type of many employees. The patterns carry the anchor of the rotation calendar, and the A/B rotation
+// followed by the employees without a pattern is kept under ID 0.
+type rotations map[uint]*model.RotationPattern
+
+// of returns the pattern with the given ID, or the A/B rotation for nil.
+func (r rotations) of(id *uint) *model.RotationPattern {
+	if id == nil || r[*id] == nil {
+		if r[0] != nil {
+			return r[0]
+		}
+		return &model.DefaultRotation
+	}
+	return r[*id]
+}
+
+// loadRotations returns every rotation pattern by ID, anchored on the rotation calendar.
+func (s *EmployeeService) loadRotations(ctx context.Context) (rotations, error) {
+	patterns, err := s.repo.RotationList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	anchor, err := s.rotationAnchor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(rotations, len(patterns)+1)
+	for i := range patterns {
+		patterns[i].Anchor = anchor
+		byID[patterns[i].ID] = &patterns[i]
+	}
+	byID[0] = anchoredDefault(anchor)
+	return byID, nil
+}
+
+// rotationOf returns the rotation pattern an employee follows, anchored on the rotation calendar.
+func (s *EmployeeService) rotationOf(ctx context.Context, employee *model.Employee) (*model.RotationPattern, error) {
+	anchor, err := s.rotationAnchor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if employee.RotationPatternID == nil {
+		return anchoredDefault(anchor), nil
+	}
+	pattern, err := s.repo.RotationFindByID(ctx, *employee.RotationPatternID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rotation pattern %d of employee ID %d: %w", *employee.RotationPatternID, employee.ID, err)
+	}
+	pattern.Anchor = anchor
+	return pattern, nil
+}
+
+// rotationAnchor returns the anchor of the rotation calendar of the tenant, nil when it has none.
+func (s *EmployeeService) rotationAnchor(ctx context.Context) (*time.Time, error) {
+	calendar, err := s.repo.RotationCalendarGet(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the rotation calendar: %w", err)
+	}
+	return &calendar.Anchor, nil
+}
+
+// anchoredDefault returns the A/B rotation anchored on anchor, leaving model.DefaultRotation untouched.
+func anchoredDefault(anchor *time.Time) *model.RotationPattern {
+	if anchor == nil {
+		return &model.DefaultRotation
+	}
+	pattern := model.DefaultRotation
+	pattern.Anchor = anchor
+	return &pattern
+}
+
+// checkWeekType checks that weekType is one of the weeks of rotation.
+func checkWeekType(rotation *model.RotationPattern, weekType string) error {
+	if !rotation.HasWeek(weekType) {
+		return apierror.Validation(fmt.Sprintf("weekType must be one of %s, got: %s",
+			strings.Join(rotation.WeekNames(), ", "), weekType)).WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	return nil
+}
+
+// CreateRotationPattern validates and stores a new rotation pattern. The weeks are given in cycle order;
+// their positions are assigned from it and CycleLength, if given, must match their number.
+func (s *EmployeeService) CreateRotationPattern(ctx context.Context, pattern model.RotationPattern) (*model.RotationPattern, error) {
+	if pattern.Name == "" {
+		return nil, apierror.Validation("rotation pattern name is required")
+	}
+	if len(pattern.Weeks) < 1 || len(pattern.Weeks) > maxCycleLength {
+		return nil, apierror.Validation(fmt.Sprintf("a rotation pattern has from 1 to %d weeks, got: %d", maxCycleLength, len(pattern.Weeks)))
+	}
+	if pattern.CycleLength != 0 && pattern.CycleLength != len(pattern.Weeks) {
+		return nil, apierror.Validation(fmt.Sprintf("cycleLength %d does not match the %d weeks", pattern.CycleLength, len(pattern.Weeks)))
+	}
+	seen := make(map[string]bool, len(pattern.Weeks))
+	for i := range pattern.Weeks {
+		name := pattern.Weeks[i].Name
+		if !weekNamePattern.MatchString(name) {
+			return nil, apierror.Validation(fmt.Sprintf("week name must be 1 to 8 letters or digits, got: %q", name)).WithCode(apierror.CodeWeekTypeInvalid)
+		}
+		if seen[name] {
+			return nil, apierror.Validation(fmt.Sprintf("week %s appears twice in the rotation", name)).WithCode(apierror.CodeWeekTypeInvalid)
+		}
+		seen[name] = true
+		pattern.Weeks[i] = model.RotationWeek{Position: i, Name: name}
+	}
+	if _, err := s.repo.RotationFindByName(ctx, pattern.Name); err == nil {
+		return nil, apierror.Conflict(fmt.Sprintf("rotation pattern %q already exists", pattern.Name)).WithCode(apierror.CodeRotationExists)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	pattern.ID, pattern.UUID = 0, ""
+	pattern.CycleLength = len(pattern.Weeks)
+	pattern.CreatedAt, pattern.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.RotationCreate(ctx, &pattern); err != nil {
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+// ListRotationPatterns returns every rotation pattern with its weeks.
+func (s *EmployeeService) ListRotationPatterns(ctx context.Context) ([]model.RotationPattern, error) {
+	return s.repo.RotationList(ctx)
+}
+
+// AssignRotation makes an employee follow a rotation pattern, or the A/B rotation when patternID is nil,
+// whose first week is the week of anchor, or of its start date when anchor is nil. Every week type of the
+// employee's own slots and deltas must be a week of the new rotation.
+func (s *EmployeeService) AssignRotation(ctx context.Context, employeeID uint, patternID *uint, anchor *time.Time) error {
+	rotation := &model.DefaultRotation
+	if patternID != nil {
+		pattern, err := s.repo.RotationFindByID(ctx, *patternID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apierror.Validation(fmt.Sprintf("rotation pattern %d does not exist", *patternID)).WithCode(apierror.CodeRotationNotFound)
+			}
+			return err
+		}
+		rotation = pattern
+	}
+
+	employee, err := s.employeeWithSchedules(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return err
+	}
+	weekTypes := make([]string, 0, len(employee.Schedules)+len(employee.Deltas))
+	for _, slot := range employee.Schedules {
+		weekTypes = append(weekTypes, slot.WeekType)
+	}
+	for _, delta := range employee.Deltas {
+		weekTypes = append(weekTypes, delta.WeekType)
+	}
+	for _, weekType := range weekTypes {
+		if !rotation.HasWeek(weekType) {
+			return apierror.Conflict(fmt.Sprintf("employee %d has slots in week %s, which rotation %s does not have",
+				employeeID, weekType, rotation.Name))
+		}
+	}
+
+	if err := s.repo.SetEmployeeRotation(ctx, employeeID, patternID, anchor); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	return nil
+}
+
+// RotationCalendar tells which week of rotation a date falls in, for the header of the calendars.
+type RotationCalendar struct {
+	// Anchor is the Monday of a first week of the cycles, "week A starts on", empty when the tenant has no
+	// rotation calendar and the cycle of each employee starts on the week of its start date.
+	Anchor  string `json:"anchor"`
+	Date    string `json:"date"`
+	ISOYear int    `json:"isoYear"`
+	ISOWeek int    `json:"isoWeek"`
+	// WeekType is the week of the A/B rotation the date falls in, empty without an anchor.
+	WeekType string `json:"weekType"`
+}
+
+// GetRotationCalendar returns the rotation calendar of the tenant and the week of rotation of date.
+func (s *EmployeeService) GetRotationCalendar(ctx context.Context, date time.Time) (*RotationCalendar, error) {
+	anchor, err := s.rotationAnchor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &RotationCalendar{Date: date.Format("2006-01-02")}
+	result.ISOYear, result.ISOWeek = date.ISOWeek()
+	if anchor != nil {
+		result.Anchor = anchor.Format("2006-01-02")
+		result.WeekType = util.WeekTypeForDate(anchoredDefault(anchor), *anchor, date)
+	}
+	return result, nil
+}
+
+// SetRotationCalendar anchors the weeks of rotation of the tenant on the week of anchor, which becomes the
+// first week of every cycle for the employees without an anchor of their own. As the week types of their
+// calendars may change, every employee is touched so that clients drop their copies.
+func (s *EmployeeService) SetRotationCalendar(ctx context.Context, anchor time.Time) (*model.RotationCalendar, error) {
+	var calendar *model.RotationCalendar
+	err := s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		var err error
+		if calendar, err = tx.RotationCalendarSet(ctx, util.MondayOf(anchor)); err != nil {
+			return err
+		}
+		_, err = tx.TouchEmployees(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// maxScheduleRangeDays bounds the span of FetchEmployeeScheduleRange.
+const maxScheduleRangeDays = 366
+
+// RangeDay is a day of a schedule range: the day of the monthly calendar with the week of rotation and the
+// ISO week it falls in.
+type RangeDay struct {
+	model.MonthlySchedule
+	WeekType string `json:"weekType"`
+	// ISOYear and ISOWeek identify the ISO week of the day; the ISO year differs from the calendar year in
+	// the first and last days of some years, and some years have a week 53.
+	ISOYear int `json:"isoYear"`
+	ISOWeek int `json:"isoWeek"`
+}
+
+// ScheduleRange is the calendar of an employee over any span of days, within or across months and years.
+type ScheduleRange struct {
+	EmployeeID uint       `json:"employeeId"`
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	Days       []RangeDay `json:"days"`
+}
+
+// FetchEmployeeScheduleRange builds the calendar of an employee from from to to included, restricted to
+// location unless it is empty. The span may cross months and years: the week of rotation is counted from
+// the rotation start whatever the year, so that week types follow each other across December and January,
+// including after a week 53, and the public holidays of every month of the span are marked.
+func (s *EmployeeService) FetchEmployeeScheduleRange(ctx context.Context, employeeID uint, from, to time.Time, location string) (*ScheduleRange, error) {
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxScheduleRangeDays {
+		return nil, apierror.Validation(fmt.Sprintf("the range spans %d days, at most %d are allowed", days, maxScheduleRangeDays)).WithCode(apierror.CodeDateInvalid)
+	}
+	employee, entries, err := s.calendarBetween(ctx, employeeID, from, to, location)
+	if err != nil {
+		return nil, err
+	}
+	rotation, err := s.rotationOf(ctx, employee)
+	if err != nil {
+		return nil, err
+	}
+	result := &ScheduleRange{
+		EmployeeID: employee.ID,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Days:       make([]RangeDay, 0, len(entries)),
+	}
+	for i, entry := range entries {
+		day := from.AddDate(0, 0, i)
+		isoYear, isoWeek := day.ISOWeek()
+		result.Days = append(result.Days, RangeDay{
+			MonthlySchedule: entry,
+			WeekType:        util.WeekTypeForDate(rotation, employee.RotationStart(rotation), day),
+			ISOYear:         isoYear,
+			ISOWeek:         isoWeek,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// ScheduleHistory is the history of the recurring pattern of an employee: the versions replaced from an
+// effective date, oldest first, and the date its current slots are in force from.
+type ScheduleHistory struct {
+	CurrentFrom time.Time               `json:"currentFrom"`
+	Versions    []model.ScheduleVersion `json:"versions"`
+}
+
+// GetScheduleHistory returns the history of the recurring pattern of an employee.
+func (s *EmployeeService) GetScheduleHistory(ctx context.Context, employeeID uint) (*ScheduleHistory, error) {
+	employee, err := s.employeeSchedules(ctx, employeeID, ScheduleFilter{})
+	if err != nil {
+		return nil, err
+	}
+	versions := employee.ScheduleVersions
+	if versions == nil {
+		versions = []model.ScheduleVersion{}
+	}
+	return &ScheduleHistory{CurrentFrom: employee.SchedulesFrom(), Versions: versions}, nil
+}
+
+// newScheduleVersion returns the version keeping the pattern of an employee, loaded with its slots, role
+// template, deltas and versions, when it is replaced from effectiveFrom. The version starts where the current
+// slots are in force from, which effectiveFrom must come after.
+func newScheduleVersion(ctx context.Context, employee *model.Employee, effectiveFrom time.Time) (*model.ScheduleVersion, error) {
+	from := employee.SchedulesFrom()
+	until := time.Date(effectiveFrom.Year(), effectiveFrom.Month(), effectiveFrom.Day(), 0, 0, 0, 0, time.UTC)
+	if until.Format("2006-01-02") <= from.Format("2006-01-02") {
+		return nil, apierror.Conflict(fmt.Sprintf("effectiveFrom must be after %s, the date the current slots of employee %d are in force from",
+			from.Format("2006-01-02"), employee.ID)).WithCode(apierror.CodeScheduleVersionOrder)
+	}
+	return &model.ScheduleVersion{
+		EmployeeID:     employee.ID,
+		EffectiveFrom:  from,
+		EffectiveUntil: until,
+		Pattern:        snapshotOf(employee),
+		ChangedByID:    changedBy(ctx),
+	}, nil
+}
+
+// versionFrom returns the version keeping the pattern of employee employeeID when one of its slots changes
+// from effectiveFrom, see newScheduleVersion, or nil without effectiveFrom: the change then applies to every
+// date.
+func (s *EmployeeService) versionFrom(ctx context.Context, employeeID uint, effectiveFrom time.Time) (*model.ScheduleVersion, error) {
+	if effectiveFrom.IsZero() {
+		return nil, nil
+	}
+	employee, err := s.employeeSchedules(ctx, employeeID, ScheduleFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return newScheduleVersion(ctx, employee, effectiveFrom)
+}
+
+// writeFrom runs write, recording version first unless it is nil, in a single transaction.
+func (s *EmployeeService) writeFrom(ctx context.Context, version *model.ScheduleVersion, write func(tx repo.Repository) error) error {
+	if version == nil {
+		return write(s.repo)
+	}
+	return s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.ScheduleVersionCreate(ctx, version); err != nil {
+			return err
+		}
+		return write(tx)
+	})
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/validation"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// ScheduleFilter selects the own slots of an employee by week type and day; an empty field selects any.
+type ScheduleFilter struct {
+	WeekType string
+	DayName  string
+}
+
+// matches reports whether slot is selected by the filter.
+func (f ScheduleFilter) matches(slot model.Schedule) bool {
+	return (f.WeekType == "" || slot.WeekType == f.WeekType) && (f.DayName == "" || slot.DayName == f.DayName)
+}
+
+// validate rejects a filter on a malformed week type or an unknown day.
+func (f ScheduleFilter) validate() error {
+	if f.WeekType != "" && !weekNamePattern.MatchString(f.WeekType) {
+		return apierror.Validation(fmt.Sprintf("weekType must be a week name of 1 to 8 letters or digits, got: %q", f.WeekType)).WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	if f.DayName != "" && findDayIndex(f.DayName, daysOrder) == -1 {
+		return apierror.Validation(fmt.Sprintf("invalid day: %s", f.DayName)).WithCode(apierror.CodeDayNameInvalid)
+	}
+	return nil
+}
+
+// SchedulePatch is a partial change of a slot: the fields left nil keep their value.
+type SchedulePatch struct {
+	WeekType  *string           `json:"weekType"`
+	DayName   *string           `json:"dayName"`
+	StartTime *model.CustomTime `json:"startTime"`
+	EndTime   *model.CustomTime `json:"endTime"`
+	Location  *string           `json:"location"`
+	Task      *string           `json:"task"`
+}
+
+// employeeSchedules returns the employee with the own slots selected by filter, ordered by week, day and
+// start time. The slots inherited from a role template are not part of them.
+func (s *EmployeeService) employeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter) (*model.Employee, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	var employee *model.Employee
+	var err error
+	if filter.WeekType != "" {
+		employee, err = s.repo.GetEmployeeWithSchedulesByWeekType(ctx, employeeID, filter.WeekType)
+	} else {
+		employee, err = s.repo.GetEmployeeWithSchedules(ctx, employeeID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	selected := make([]model.Schedule, 0, len(employee.Schedules))
+	for _, slot := range employee.Schedules {
+		if filter.matches(slot) {
+			selected = append(selected, slot)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if a.WeekType != b.WeekType {
+			return a.WeekType < b.WeekType
+		}
+		if a.DayName != b.DayName {
+			return findDayIndex(a.DayName, daysOrder) < findDayIndex(b.DayName, daysOrder)
+		}
+		return a.StartTime.Before(b.StartTime.Time)
+	})
+	employee.Schedules = selected
+	return employee, nil
+}
+
+// ListEmployeeSchedules returns the own slots of an employee selected by filter, ordered by week, day and
+// start time.
+func (s *EmployeeService) ListEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter) ([]model.Schedule, error) {
+	employee, err := s.employeeSchedules(ctx, employeeID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return employee.Schedules, nil
+}
+
+// ReplaceEmployeeSchedules replaces the own slots of an employee selected by filter, all of them without a
+// filter, with slots, which become manual edits of the user of ctx. A slot without week type or day takes
+// those of the filter, and must match it otherwise. The slots are validated as a whole, against each other
+// and the slots kept, before anything is written. With an effectiveFrom date the change only applies from
+// that date: the pattern replaced is kept as a schedule version in force until the day before.
+func (s *EmployeeService) ReplaceEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter, slots []model.Schedule, effectiveFrom time.Time) ([]model.Schedule, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	employee, err := s.employeeSchedules(ctx, employeeID, ScheduleFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var version *model.ScheduleVersion
+	if !effectiveFrom.IsZero() {
+		if version, err = newScheduleVersion(ctx, employee, effectiveFrom); err != nil {
+			return nil, err
+		}
+	}
+	rotation, err := s.rotationOf(ctx, employee)
+	if err != nil {
+		return nil, err
+	}
+	var kept []model.Schedule
+	for _, slot := range employee.Schedules {
+		if !filter.matches(slot) {
+			kept = append(kept, slot)
+		}
+	}
+	written := make([]model.Schedule, 0, len(slots))
+	for i, slot := range slots {
+		if slot.WeekType == "" {
+			slot.WeekType = filter.WeekType
+		}
+		if slot.DayName == "" {
+			slot.DayName = filter.DayName
+		}
+		if !filter.matches(slot) {
+			return nil, apierror.Validation(fmt.Sprintf("slot %d is on %s of week %s, outside the slots replaced", i, slot.DayName, slot.WeekType))
+		}
+		if err := validateSlot(slot.WeekType, slot.DayName, slot.StartTime, slot.EndTime); err != nil {
+			return nil, err
+		}
+		if err := checkWeekType(rotation, slot.WeekType); err != nil {
+			return nil, err
+		}
+		if len(slot.Task) > 50 {
+			return nil, apierror.Validation("task must be at most 50 characters")
+		}
+		candidate := model.Schedule{WeekType: slot.WeekType, DayName: slot.DayName, Location: slot.Location, StartTime: slot.StartTime, EndTime: slot.EndTime}
+		others := append(append([]model.Schedule(nil), kept...), written...)
+		if j, ok := overlappingSlot(candidate, others, rotation.WeekNames()); ok {
+			return nil, apierror.Conflict(fmt.Sprintf("slot %d: %s", i, overlapReason(candidate, others[j]))).WithCode(apierror.CodeScheduleOverlap)
+		}
+		written = append(written, model.Schedule{
+			EmployeeID: employeeID, WeekType: slot.WeekType, DayName: slot.DayName, StartTime: slot.StartTime, EndTime: slot.EndTime,
+			Location: slot.Location, Task: slot.Task, Source: model.SourceManual, ChangedByID: changedBy(ctx),
+		})
+	}
+	employee.Schedules = append(kept, written...)
+	if err := s.checkEmployeeRules(ctx, validation.StageReplace, *employee); err != nil {
+		return nil, err
+	}
+	if version != nil {
+		err = s.repo.ReplaceSchedulesFrom(ctx, version, filter.WeekType, filter.DayName, written)
+	} else {
+		err = s.repo.ReplaceSchedules(ctx, employeeID, filter.WeekType, filter.DayName, written)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeSlot, Action: ActionWritten, Resource: written})
+	return s.ListEmployeeSchedules(ctx, employeeID, filter)
+}
+
+// CopyWeek replaces the own slots of week to of an employee with copies of those of week from, as
+// ReplaceEmployeeSchedules does, and returns the slots of week to. A week without slot cannot be copied.
+func (s *EmployeeService) CopyWeek(ctx context.Context, employeeID uint, from, to string) ([]model.Schedule, error) {
+	if from == "" || to == "" {
+		return nil, apierror.Validation("from and to must name the week copied and the week replaced").WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	if from == to {
+		return nil, apierror.Validation(fmt.Sprintf("week %s cannot be copied onto itself", from)).WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	slots, err := s.ListEmployeeSchedules(ctx, employeeID, ScheduleFilter{WeekType: from})
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("week %s of employee %d has no slot to copy", from, employeeID)).WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	for i := range slots {
+		slots[i].WeekType = to
+	}
+	return s.ReplaceEmployeeSchedules(ctx, employeeID, ScheduleFilter{WeekType: to}, slots, time.Time{})
+}
+
+// CopyPattern replaces the own slots of employee targetID with copies of the own slots of employee
+// employeeID, as ReplaceEmployeeSchedules does, and returns the slots of the target. The slots inherited from
+// a role template are not copied; the target must follow a rotation with the weeks of the slots.
+func (s *EmployeeService) CopyPattern(ctx context.Context, employeeID, targetID uint) ([]model.Schedule, error) {
+	if employeeID == targetID {
+		return nil, apierror.Validation(fmt.Sprintf("the pattern of employee %d cannot be copied onto itself", employeeID))
+	}
+	slots, err := s.ListEmployeeSchedules(ctx, employeeID, ScheduleFilter{})
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("employee %d has no slot to copy", employeeID))
+	}
+	return s.ReplaceEmployeeSchedules(ctx, targetID, ScheduleFilter{}, slots, time.Time{})
+}
+
+// DeleteEmployeeSchedules removes the own slots of an employee selected by filter, all of them without a
+// filter, and returns how many were removed.
+func (s *EmployeeService) DeleteEmployeeSchedules(ctx context.Context, employeeID uint, filter ScheduleFilter) (int64, error) {
+	if _, err := s.employeeSchedules(ctx, employeeID, filter); err != nil {
+		return 0, err
+	}
+	deleted, err := s.repo.DeleteSchedules(ctx, employeeID, filter.WeekType, filter.DayName)
+	if err != nil || deleted == 0 {
+		return deleted, err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeSlot, Action: ActionDeleted})
+	return deleted, nil
+}
+
+// GetEmployeeSchedule returns the slot identified by id, provided it is a slot of the employee.
+func (s *EmployeeService) GetEmployeeSchedule(ctx context.Context, employeeID, id uint) (*model.Schedule, error) {
+	slot, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if slot.EmployeeID != employeeID {
+		return nil, apierror.NotFound(fmt.Sprintf("employee %d has no schedule %d", employeeID, id)).WithCode(apierror.CodeScheduleNotFound)
+	}
+	return slot, nil
+}
+
+// UpdateEmployeeSchedule replaces the slot identified by id of the employee, as UpdateSchedule does; the
+// slot stays a slot of the employee.
+func (s *EmployeeService) UpdateEmployeeSchedule(ctx context.Context, employeeID, id uint, slot model.Schedule, effectiveFrom time.Time) (*model.Schedule, error) {
+	if _, err := s.GetEmployeeSchedule(ctx, employeeID, id); err != nil {
+		return nil, err
+	}
+	slot.EmployeeID = employeeID
+	return s.UpdateSchedule(ctx, id, slot, effectiveFrom)
+}
+
+// PatchEmployeeSchedule changes the fields of the slot identified by id of the employee set in patch, and
+// validates and writes the slot that results as UpdateSchedule does.
+func (s *EmployeeService) PatchEmployeeSchedule(ctx context.Context, employeeID, id uint, patch SchedulePatch, effectiveFrom time.Time) (*model.Schedule, error) {
+	slot, err := s.GetEmployeeSchedule(ctx, employeeID, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.WeekType != nil {
+		slot.WeekType = *patch.WeekType
+	}
+	if patch.DayName != nil {
+		slot.DayName = *patch.DayName
+	}
+	if patch.StartTime != nil {
+		slot.StartTime = *patch.StartTime
+	}
+	if patch.EndTime != nil {
+		slot.EndTime = *patch.EndTime
+	}
+	if patch.Location != nil {
+		slot.Location = *patch.Location
+	}
+	if patch.Task != nil {
+		if len(*patch.Task) > 50 {
+			return nil, apierror.Validation("task must be at most 50 characters")
+		}
+		slot.Task = *patch.Task
+	}
+	return s.UpdateSchedule(ctx, id, *slot, effectiveFrom)
+}
+
+// DeleteEmployeeSchedule removes the slot identified by id of the employee, as DeleteSchedule does.
+func (s *EmployeeService) DeleteEmployeeSchedule(ctx context.Context, employeeID, id uint, effectiveFrom time.Time) error {
+	if _, err := s.GetEmployeeSchedule(ctx, employeeID, id); err != nil {
+		return err
+	}
+	return s.DeleteSchedule(ctx, id, effectiveFrom)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEmployeeSchedules(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, bob := ids["Alice"], ids["Bob"]
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+
+	slots, err := svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{})
+	require.NoError(t, err)
+	require.Len(t, slots, 4)
+	require.Equal(t, []string{"A", "A", "B", "B"}, []string{slots[0].WeekType, slots[1].WeekType, slots[2].WeekType, slots[3].WeekType})
+	require.True(t, slots[0].StartTime.Before(slots[1].StartTime.Time), "The slots of a day come in order")
+	_, err = svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{DayName: "Lundi"})
+	require.Equal(t, apierror.CodeDayNameInvalid, apierror.CodeOf(err))
+	_, err = svc.ListEmployeeSchedules(ctx, 999, ScheduleFilter{})
+	require.Equal(t, apierror.CodeEmployeeNotFound, apierror.CodeOf(err))
+
+	// Monday of week A is replaced whole; the slots take the week and day of the filter.
+	monday := ScheduleFilter{WeekType: "A", DayName: "Monday"}
+	written, err := svc.ReplaceEmployeeSchedules(ctx, alice, monday, []model.Schedule{{StartTime: at(10), EndTime: at(16), Task: "lab"}}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	require.Equal(t, model.SourceManual, written[0].Source)
+	_, err = svc.ReplaceEmployeeSchedules(ctx, alice, monday, []model.Schedule{{DayName: "Tuesday", StartTime: at(10), EndTime: at(16)}}, time.Time{})
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err), "A slot outside the filter is rejected")
+	slots, err = svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{})
+	require.NoError(t, err)
+	require.Len(t, slots, 3, "The slots of week B are kept")
+
+	// A slot is only reachable through its own employee.
+	slot := written[0]
+	_, err = svc.GetEmployeeSchedule(ctx, bob, slot.ID)
+	require.Equal(t, apierror.CodeScheduleNotFound, apierror.CodeOf(err))
+	require.Equal(t, apierror.CodeScheduleNotFound, apierror.CodeOf(svc.DeleteEmployeeSchedule(ctx, bob, slot.ID, time.Time{})))
+	location := "Gare"
+	patched, err := svc.PatchEmployeeSchedule(ctx, alice, slot.ID, SchedulePatch{Location: &location}, time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "Gare", patched.Location)
+	require.Equal(t, "lab", patched.Task, "The fields not patched are kept")
+	require.Equal(t, "10:00", patched.StartTime.Format("15:04"))
+	_, err = svc.UpdateEmployeeSchedule(ctx, bob, slot.ID, model.Schedule{WeekType: "A", DayName: "Monday", StartTime: at(9), EndTime: at(12)}, time.Time{})
+	require.Equal(t, apierror.CodeScheduleNotFound, apierror.CodeOf(err))
+	require.NoError(t, svc.DeleteEmployeeSchedule(ctx, alice, slot.ID, time.Time{}))
+
+	deleted, err := svc.DeleteEmployeeSchedules(ctx, alice, ScheduleFilter{WeekType: "B"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, deleted)
+	slots, err = svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{})
+	require.NoError(t, err)
+	require.Empty(t, slots)
+}
+
+func TestCopyWeekAndPattern(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, bob := ids["Alice"], ids["Bob"]
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	_, err := svc.ReplaceEmployeeSchedules(ctx, alice, ScheduleFilter{WeekType: "A", DayName: "Friday"},
+		[]model.Schedule{{StartTime: at(14), EndTime: at(18), Task: "till"}}, time.Time{})
+	require.NoError(t, err)
+
+	// Week B of Alice becomes a copy of her week A, Friday included.
+	copied, err := svc.CopyWeek(ctx, alice, "A", "B")
+	require.NoError(t, err)
+	require.Len(t, copied, 3)
+	require.Equal(t, "B", copied[2].WeekType)
+	require.Equal(t, "Friday", copied[2].DayName)
+	require.Equal(t, "till", copied[2].Task)
+	weekA, err := svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{WeekType: "A"})
+	require.NoError(t, err)
+	require.Len(t, weekA, 3, "Week A is left as it was")
+
+	// Bob gets the whole pattern of Alice, his own slots replaced.
+	pattern, err := svc.CopyPattern(ctx, alice, bob)
+	require.NoError(t, err)
+	require.Len(t, pattern, 6)
+	for _, slot := range pattern {
+		require.Equal(t, bob, slot.EmployeeID)
+	}
+
+	_, err = svc.CopyWeek(ctx, alice, "A", "A")
+	require.Equal(t, apierror.CodeWeekTypeInvalid, apierror.CodeOf(err))
+	_, err = svc.CopyWeek(ctx, alice, "C", "A")
+	require.Equal(t, apierror.CodeWeekTypeInvalid, apierror.CodeOf(err), "A week without slot cannot be copied")
+	_, err = svc.CopyPattern(ctx, alice, alice)
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+	_, err = svc.CopyPattern(ctx, alice, 999)
+	require.Equal(t, apierror.CodeEmployeeNotFound, apierror.CodeOf(err))
+}
+
+func TestUpdateScheduleFromEffectiveDate(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, bob := ids["Alice"], ids["Bob"]
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	slots, err := svc.ListEmployeeSchedules(ctx, alice, ScheduleFilter{WeekType: "A"})
+	require.NoError(t, err)
+	morning := slots[0]
+
+	// The morning of Monday A starts at 8:00 from June 17: the pattern of June 3 is kept until the 16th.
+	morning.StartTime = at(8)
+	updated, err := svc.UpdateSchedule(ctx, morning.ID, morning, time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, morning.ID, updated.ID)
+	june, err := svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+	require.NoError(t, err)
+	require.Equal(t, "09:00", june[2].TimeSlots[0].Start)
+	require.Equal(t, "08:00", june[16].TimeSlots[0].Start)
+
+	// The slot is removed from July 1, and cannot move to Bob from a date.
+	_, err = svc.UpdateSchedule(ctx, morning.ID, model.Schedule{EmployeeID: bob, WeekType: "A", DayName: "Friday", StartTime: at(8), EndTime: at(12)},
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+	err = svc.DeleteSchedule(ctx, morning.ID, time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, apierror.CodeScheduleVersionOrder, apierror.CodeOf(err), "The slots are in force from June 17")
+	require.NoError(t, svc.DeleteSchedule(ctx, morning.ID, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+	history, err := svc.GetScheduleHistory(ctx, alice)
+	require.NoError(t, err)
+	require.Len(t, history.Versions, 2)
+	require.Equal(t, "2024-07-01", history.CurrentFrom.Format("2006-01-02"))
+	june, err = svc.FetchEmployeeSchedule(ctx, alice, "June", 2024)
+	require.NoError(t, err)
+	require.Len(t, june[23].TimeSlots, 2, "The slot is worked until June 30")
+}
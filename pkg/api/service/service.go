@@ -1,59 +1,422 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/lichensio/api_server/db/model"
 	repo "github.com/lichensio/api_server/db/repo"
 	util "github.com/lichensio/api_server/internal/utils"
-	log "github.com/sirupsen/logrus"
-	"io/ioutil"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/validation"
+	"gorm.io/gorm"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type EmployeeService struct {
 	repo repo.Repository
+	// snapshotReads makes calendar reads use the schedule snapshots, see UseScheduleSnapshots.
+	snapshotReads bool
+	// webhookClient sends the webhook deliveries; webhookWake wakes an idle worker of the webhook queue, see
+	// StartWebhookQueue.
+	webhookClient *http.Client
+	webhookWake   chan struct{}
+	// events carries the changes published by the service, see Events.
+	events *events.Bus
+	// prints configures the print queue once started, see StartPrintQueue; printWake wakes an idle worker.
+	prints    *PrintQueue
+	printWake chan struct{}
+	// imports counts the work of the employee imports, see ImportProgress.
+	imports *importMetrics
+	// calendars keeps the computed monthly calendars, see FetchEmployeeScheduleAtLocation.
+	calendars *calendarCache
+	// validation are the validators of the tenants without settings of their own, see UseValidators;
+	// validatorClient calls the external validators.
+	validation      model.ValidationSettings
+	validatorClient *http.Client
+	// calendarSync configures the Google Calendar sync once started, see StartCalendarSync.
+	calendarSync *CalendarSync
+	// holidays fetches the public holidays missing from the database, see UseHolidayProvider; locationRegions
+	// are the holiday regions of the locations observing the holidays of their own region, see
+	// UseLocationHolidayRegions.
+	holidays        holiday.Provider
+	locationRegions map[string]string
+	// readOnly makes the calendar reads write nothing, see UseReadOnly.
+	readOnly bool
+	// holidayPay tells how the public holidays are paid, see UseHolidayPay.
+	holidayPay HolidayPay
+	// timeZone is the time zone the slots are worked in, and locationZones those of the locations in another
+	// zone, see UseTimeZones.
+	timeZone      *time.Location
+	locationZones map[string]*time.Location
 }
 
 func NewEmployeeService(repo repo.Repository) *EmployeeService {
 	return &EmployeeService{
-		repo: repo,
+		repo:            repo,
+		webhookClient:   &http.Client{Timeout: webhookTimeout},
+		events:          events.NewBus(),
+		imports:         &importMetrics{},
+		calendars:       &calendarCache{},
+		validatorClient: &http.Client{Timeout: validatorTimeout},
+		holidays:        holiday.Gouv{},
+		holidayPay:      HolidayPay{WorkedMultiplier: 1},
+		timeZone:        time.UTC,
 	}
 }
 
-// LoadEmployeesFromInput assumes input is already a Go struct
-// LoadEmployeesFromInput modified to use the helper function.
-func (s *EmployeeService) LoadEmployeesFromInput(input []model.EmployeeInput) error {
-	for _, empInput := range input {
-		startDate, err := time.Parse("2006-01-02", empInput.StartDate)
-		if err != nil {
-			return err // Consider logging or handling the error as needed
+// UseHolidayProvider sets the provider the public holidays are fetched from, the French government API by
+// default. The holidays are stored by region, so a region is fetched once whatever the provider.
+func (s *EmployeeService) UseHolidayProvider(provider holiday.Provider) {
+	s.holidays = provider
+}
+
+// UseReadOnly makes the calendar reads write nothing, for read-only replicas whose database rejects every
+// write: the public holidays missing from the database are fetched from the holiday provider on every read
+// and served without being stored.
+func (s *EmployeeService) UseReadOnly() {
+	s.readOnly = true
+}
+
+// UseLocationHolidayRegions sets the holiday regions of the locations, such as a store in Strasbourg observing
+// the holidays of Alsace-Moselle in a company of mainland France. The calendars restricted to one of these
+// locations are built with the holidays of its region, whatever the region of the request or its tenant.
+func (s *EmployeeService) UseLocationHolidayRegions(regions map[string]string) error {
+	for location, region := range regions {
+		if !s.holidays.IsRegion(region) {
+			return fmt.Errorf("unknown holiday region %q of location %q", region, location)
+		}
+	}
+	s.locationRegions = regions
+	return nil
+}
+
+// atLocation returns ctx confined to the holiday region of location when it has one of its own, see
+// UseLocationHolidayRegions, ctx otherwise.
+func (s *EmployeeService) atLocation(ctx context.Context, location string) context.Context {
+	if region, ok := s.locationRegions[location]; ok && location != "" {
+		return repo.WithHolidayRegion(ctx, region)
+	}
+	return ctx
+}
+
+// Events returns the bus on which the service publishes its events, such as cache invalidations.
+func (s *EmployeeService) Events() *events.Bus {
+	return s.events
+}
+
+// LoadEmployeesFromInput validates the whole import before writing anything: every rejected field is
+// reported at once, keyed by employee, week and day, and nothing is saved unless the input is valid.
+// The employees, their schedules and their schedule snapshots are then written in a single transaction:
+// the import is saved whole or not at all.
+func (s *EmployeeService) LoadEmployeesFromInput(ctx context.Context, input []model.EmployeeInput) error {
+	run := s.imports.start(ctx, len(input))
+	defer run.finish()
+	employees, err := s.employeesFromInput(ctx, input, run)
+	if err != nil {
+		return err
+	}
+	if err := s.checkImportRules(ctx, employees); err != nil {
+		return err
+	}
+	run.writing()
+	err = s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if err := tx.LoadEmployees(ctx, employees); err != nil {
+			return err
+		}
+		return saveSnapshots(ctx, tx, employeeIDs(employees)...)
+	})
+	if err != nil {
+		return err
+	}
+	run.written(countSchedules(employees))
+	s.emitEmployeesCreated(ctx, employees)
+	return nil
+}
+
+// ImportResult describes an employee import.
+type ImportResult struct {
+	Import *model.EmployeeImport
+	// AlreadyImported is set when the payload had been imported before and nothing was written.
+	AlreadyImported bool
+	// Created and Updated count the employees inserted and the existing employees replaced, linked ones
+	// included.
+	Created int
+	Updated int
+	// Warnings are the employees created although their name is close to the name of an existing employee,
+	// with the match candidates.
+	Warnings []ImportEntry
+}
+
+// importHash returns the idempotency key of an import: the hash of the normalized JSON of its payload, hashed
+// again with the mode for an upsert. A payload imported once can then be imported again with upsert.
+func importHash(payload []byte, upsert bool) (string, error) {
+	hash, err := util.HashJSON(string(payload))
+	if err != nil || !upsert {
+		return hash, err
+	}
+	sum := sha256.Sum256([]byte(hash + " upsert"))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ImportEmployees loads the employees of a JSON import payload like LoadEmployeesFromInput, unless the same
+// payload was already imported in the same mode, see importHash. With upsert, employees
+// matching an existing employee by name and start date replace it, own schedules included, instead of
+// being inserted again. Records linked to an existing employee replace it in any case, see
+// model.EmployeeInput.LinkTo. The import is written in a single transaction, as in LoadEmployeesFromInput.
+func (s *EmployeeService) ImportEmployees(ctx context.Context, payload []byte, upsert bool) (*ImportResult, error) {
+	hash, err := importHash(payload, upsert)
+	if err != nil {
+		return nil, apierror.Validation("Invalid JSON payload").WithCode(apierror.CodeInvalidJSON)
+	}
+	if record, err := s.repo.ImportFindByHash(ctx, hash); err == nil {
+		return &ImportResult{Import: record, AlreadyImported: true}, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var input model.EmployeesInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, apierror.Validation("Invalid JSON payload: " + err.Error()).WithCode(apierror.CodeInvalidJSON)
+	}
+	run := s.imports.start(ctx, len(input))
+	defer run.finish()
+	employees, err := s.employeesFromInput(ctx, input, run)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.planImport(ctx, input, employees, upsert)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkImportRules(ctx, employees); err != nil {
+		return nil, err
+	}
+	var warnings []ImportEntry
+	for _, entry := range plan {
+		if entry.Action == ImportCreate && len(entry.Candidates) > 0 {
+			warnings = append(warnings, entry)
+		}
+	}
+	run.writing()
+	record := &model.EmployeeImport{Hash: hash, Employees: len(employees)}
+	var updated int
+	err = s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		var err error
+		if updated, err = tx.ImportEmployees(ctx, record, employees, upsert); err != nil {
+			return err
+		}
+		return saveSnapshots(ctx, tx, employeeIDs(employees)...)
+	})
+	if err != nil {
+		// A concurrent replay of the payload won the race on the unique hash.
+		if existing, findErr := s.repo.ImportFindByHash(ctx, hash); findErr == nil {
+			return &ImportResult{Import: existing, AlreadyImported: true}, nil
+		}
+		return nil, err
+	}
+	run.written(countSchedules(employees))
+	created := make([]*model.Employee, 0, len(employees))
+	for i, entry := range plan {
+		if entry.Action == ImportCreate {
+			created = append(created, employees[i])
+		}
+	}
+	s.emitEmployeesCreated(ctx, created)
+	return &ImportResult{Import: record, Created: len(employees) - updated, Updated: updated, Warnings: warnings}, nil
+}
+
+// ListImports returns the last imports of employees with the employees each one created or replaced, the
+// latest first.
+func (s *EmployeeService) ListImports(ctx context.Context, limit int) ([]model.EmployeeImport, error) {
+	if limit <= 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid limit %d, expected a positive number", limit))
+	}
+	return s.repo.ImportList(ctx, limit)
+}
+
+// RollbackImport reverses an import of employees in a single transaction: the employees it replaced get
+// back their attributes and own schedules, and the employees it created are archived. The import is
+// forgotten, so that the same payload can be loaded again. An import can only be rolled back while no later
+// import changed one of its employees.
+func (s *EmployeeService) RollbackImport(ctx context.Context, id uint) (*model.EmployeeImport, error) {
+	var record *model.EmployeeImport
+	err := s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		var err error
+		if record, err = tx.RollbackImport(ctx, id); err != nil {
+			return err
+		}
+		var replaced []uint
+		for _, change := range record.Changes {
+			if change.Action == model.ImportReplaced {
+				replaced = append(replaced, change.EmployeeID)
+			}
+		}
+		return saveSnapshots(ctx, tx, replaced...)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, apierror.NotFound(fmt.Sprintf("import %d not found", id)).WithCode(apierror.CodeImportNotFound)
+	case errors.Is(err, repo.ErrImportSuperseded):
+		return nil, apierror.Conflict(fmt.Sprintf("import %d cannot be rolled back: %v", id, err)).WithCode(apierror.CodeImportSuperseded)
+	case err != nil:
+		return nil, err
+	}
+	reqlog.From(ctx).Infof("Rolled back import %d of %d employee(s)", id, len(record.Changes))
+	return record, nil
+}
+
+func employeeIDs(employees []*model.Employee) []uint {
+	ids := make([]uint, len(employees))
+	for i, employee := range employees {
+		ids[i] = employee.ID
+	}
+	return ids
+}
+
+// employeesFromInput converts and validates an import, see LoadEmployeesFromInput, recording the progress
+// of the validation on run.
+func (s *EmployeeService) employeesFromInput(ctx context.Context, input []model.EmployeeInput, run *importRun) ([]*model.Employee, error) {
+	var invalid []apierror.InvalidParam
+	employees := make([]*model.Employee, 0, len(input))
+	linkedBy := map[uint]string{}
+	for i, empInput := range input {
+		rejected := len(invalid)
+		key := empInput.Name
+		if key == "" {
+			key = fmt.Sprintf("employees[%d]", i)
 		}
 
-		// Load the employee, assuming LoadEmployees returns the ID of the loaded employee
 		employee := &model.Employee{
-			Name:      empInput.Name,
-			StartDate: startDate,
+			Name:                empInput.Name,
+			Department:          empInput.Department,
+			ContractWeeklyHours: empInput.ContractWeeklyHours,
+			HourlyRate:          empInput.HourlyRate,
+			Email:               empInput.Email,
+			Phone:               empInput.Phone,
+			Role:                empInput.Role,
+			Color:               empInput.Color,
+		}
+		if empInput.HourlyRate < 0 {
+			invalid = append(invalid, apierror.InvalidParam{Name: key + ".hourlyRate", Code: apierror.CodeValidationFailed, Reason: "the hourly rate cannot be negative"})
 		}
-		err = s.repo.LoadEmployees([]*model.Employee{employee})
+		startDate, err := time.Parse("2006-01-02", empInput.StartDate)
 		if err != nil {
-			return err // Consider logging or handling the error as needed
+			invalid = append(invalid, apierror.InvalidParam{Name: key + ".startDate", Code: apierror.CodeDateInvalid, Reason: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", empInput.StartDate)})
 		}
-		// fmt.Printf("Loaded employee ID: %d\n", employee.ID)
-
-		// Assuming we now have employee.ID available
-		// Iterate over each week's schedule and load schedules
-		for weekType, weeklySchedule := range empInput.Weeks {
-			err = s.loadWeeklySchedules(employee.ID, weekType, weeklySchedule)
+		employee.StartDate = startDate
+		if empInput.EndDate != "" {
+			endDate, err := time.Parse("2006-01-02", empInput.EndDate)
+			switch {
+			case err != nil:
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".endDate", Code: apierror.CodeDateInvalid, Reason: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", empInput.EndDate)})
+			case endDate.Before(startDate):
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".endDate", Code: apierror.CodeDateInvalid, Reason: fmt.Sprintf("the end date cannot be before the start date %s", empInput.StartDate)})
+			}
+			employee.EndDate = &endDate
+		}
+		if empInput.RotationAnchor != "" {
+			anchor, err := time.Parse("2006-01-02", empInput.RotationAnchor)
 			if err != nil {
-				return err // Consider logging or handling the error as needed
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".rotationAnchor", Code: apierror.CodeDateInvalid, Reason: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", empInput.RotationAnchor)})
+			}
+			employee.RotationAnchor = &anchor
+		}
+
+		if empInput.LinkTo != 0 {
+			var linked model.Employee
+			err := s.repo.GetEmployeeByID(ctx, empInput.LinkTo, &linked)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".linkTo", Code: apierror.CodeEmployeeNotFound, Reason: fmt.Sprintf("no employee has id %d", empInput.LinkTo)})
+			case err != nil:
+				return nil, err
+			case linkedBy[empInput.LinkTo] != "":
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".linkTo", Code: apierror.CodeValidationFailed, Reason: fmt.Sprintf("employee %d is already linked to %s", empInput.LinkTo, linkedBy[empInput.LinkTo])})
+			default:
+				linkedBy[empInput.LinkTo] = key
 			}
 		}
+
+		// rotation stays nil when unknown, the week types then cannot be checked.
+		rotation := &model.DefaultRotation
+		if empInput.Rotation != "" {
+			rotation = nil
+			pattern, err := s.repo.RotationFindByName(ctx, empInput.Rotation)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".rotation", Code: apierror.CodeRotationNotFound, Reason: fmt.Sprintf("unknown rotation pattern %q", empInput.Rotation)})
+			case err != nil:
+				return nil, err
+			default:
+				employee.RotationPatternID = &pattern.ID
+				rotation = pattern
+			}
+		}
+
+		if empInput.RoleTemplate != "" {
+			template, err := s.repo.RoleTemplateFindByName(ctx, empInput.RoleTemplate)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".roleTemplate", Code: apierror.CodeRoleTemplateNotFound, Reason: fmt.Sprintf("unknown role template %q", empInput.RoleTemplate)})
+			case err != nil:
+				return nil, err
+			default:
+				employee.RoleTemplateID = &template.ID
+			}
+		}
+
+		weekTypes := make([]string, 0, len(empInput.Weeks))
+		for weekType := range empInput.Weeks {
+			weekTypes = append(weekTypes, weekType)
+		}
+		sort.Strings(weekTypes)
+		weeks := weekTypes
+		if rotation != nil {
+			weeks = rotation.WeekNames()
+		}
+		var parsed []model.Schedule
+		for _, weekType := range weekTypes {
+			if rotation != nil {
+				if err := checkWeekType(rotation, weekType); err != nil {
+					invalid = append(invalid, apierror.InvalidParam{Name: key + ".weeks." + weekType, Code: apierror.CodeWeekTypeInvalid, Reason: err.Error()})
+					continue
+				}
+			}
+			schedules, errs := parseWeeklySchedules(key, weekType, empInput.Weeks[weekType], weeks, parsed)
+			for i := range schedules {
+				schedules[i].ChangedByID = changedBy(ctx)
+			}
+			parsed = append(parsed, schedules...)
+			employee.Schedules = append(employee.Schedules, schedules...)
+			invalid = append(invalid, errs...)
+		}
+		employees = append(employees, employee)
+		run.employeeValidated(len(invalid) == rejected)
 	}
-	return nil
+
+	if len(invalid) > 0 {
+		return nil, apierror.InvalidParams(fmt.Sprintf("%d invalid field(s), nothing was imported", len(invalid)), invalid)
+	}
+	return employees, nil
 }
-func (s *EmployeeService) loadWeeklySchedules(employeeID uint, weekType string, weeklySchedule model.WeeklyScheduleInput) error {
+
+// parseWeeklySchedules converts the slots of one week of an employee, returning the slots that parsed
+// and a report entry, keyed "<employee>.<week>.<day>[<slot>]", for every slot that did not. Zero-length
+// slots and slots overlapping an earlier slot of the same day or of the days around, at any location, are
+// rejected, earlier being the slots of the weeks parsed before and weeks the cycle of weeks, see
+// overlappingSlot.
+func parseWeeklySchedules(employeeKey, weekType string, weeklySchedule model.WeeklyScheduleInput, weeks []string, earlier []model.Schedule) ([]model.Schedule, []apierror.InvalidParam) {
 	days := map[string][]model.ScheduleInput{
 		"Monday":    weeklySchedule.Monday,
 		"Tuesday":   weeklySchedule.Tuesday,
@@ -64,123 +427,705 @@ func (s *EmployeeService) loadWeeklySchedules(employeeID uint, weekType string,
 		"Sunday":    weeklySchedule.Sunday,
 	}
 
-	for dayName, schedules := range days {
-		for _, schedule := range schedules {
+	var schedules []model.Schedule
+	var keys []string // keys[i] is the input key of schedules[i]
+	var invalid []apierror.InvalidParam
+	for _, dayName := range daysOrder {
+		for i, schedule := range days[dayName] {
+			key := fmt.Sprintf("%s.%s.%s[%d]", employeeKey, weekType, dayName, i)
 			startTime, err := time.Parse("15:04", schedule.Start)
 			if err != nil {
-				return err // Consider logging or handling the error as needed
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".start", Code: apierror.CodeTimeFormatInvalid, Reason: fmt.Sprintf("invalid time %q, expected HH:MM", schedule.Start)})
+				continue
 			}
 			endTime, err := time.Parse("15:04", schedule.End)
 			if err != nil {
-				return err // Consider logging or handling the error as needed
+				invalid = append(invalid, apierror.InvalidParam{Name: key + ".end", Code: apierror.CodeTimeFormatInvalid, Reason: fmt.Sprintf("invalid time %q, expected HH:MM", schedule.End)})
+				continue
 			}
 
-			err = s.repo.UpdateSchedule(model.Schedule{
-				EmployeeID: employeeID,
-				WeekType:   weekType,
-				DayName:    dayName,
-				StartTime:  model.CustomTime{Time: startTime},
-				EndTime:    model.CustomTime{Time: endTime},
-			})
-			if err != nil {
-				return err // Consider logging or handling the error as needed
+			slot := model.Schedule{
+				WeekType:  weekType,
+				DayName:   dayName,
+				StartTime: model.CustomTime{Time: startTime},
+				EndTime:   model.CustomTime{Time: endTime},
+				Location:  schedule.Location,
+				Task:      schedule.Task,
+				Source:    model.SourceImport,
+			}
+			if err := validateSlot(slot.WeekType, slot.DayName, slot.StartTime, slot.EndTime); err != nil {
+				invalid = append(invalid, apierror.InvalidParam{Name: key, Code: apierror.CodeOf(err), Reason: err.Error()})
+				continue
+			}
+			if j, ok := overlappingSlot(slot, earlier, weeks); ok {
+				invalid = append(invalid, apierror.InvalidParam{Name: key, Code: apierror.CodeScheduleOverlap, Reason: overlapReason(slot, earlier[j])})
+				continue
 			}
+			if j, ok := overlappingSlot(slot, schedules, weeks); ok {
+				other := schedules[j]
+				reason := fmt.Sprintf("%s-%s overlaps %s %s-%s", slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04"),
+					keys[j], other.StartTime.Format("15:04"), other.EndTime.Format("15:04"))
+				if other.Location != slot.Location {
+					reason += " at another location"
+				}
+				invalid = append(invalid, apierror.InvalidParam{Name: key, Code: apierror.CodeScheduleOverlap, Reason: reason})
+				continue
+			}
+			schedules = append(schedules, slot)
+			keys = append(keys, key)
 		}
 	}
+	return schedules, invalid
+}
 
-	return nil
+// overlappingSlot returns the index of the first of others whose time range intersects slot, slot itself
+// excepted: the others on the same week type and day, and those on the day before or after, which an
+// overnight slot runs into. The days follow each other in the cycle of weeks, Sunday being followed by Monday
+// of the next week, see nextDay. The location is ignored: an employee cannot be in two places at once, and
+// every write of a slot applies this rule.
+func overlappingSlot(slot model.Schedule, others []model.Schedule, weeks []string) (int, bool) {
+	start, end := slot.StartTime.Time, slot.End()
+	day := 24 * time.Hour
+	for i, other := range others {
+		if other.ID != 0 && other.ID == slot.ID {
+			continue
+		}
+		otherStart, otherEnd := other.StartTime.Time, other.End()
+		switch {
+		case other.WeekType == slot.WeekType && other.DayName == slot.DayName:
+		case isNextDay(slot, other, weeks):
+			otherStart, otherEnd = otherStart.Add(day), otherEnd.Add(day)
+		case isNextDay(other, slot, weeks):
+			otherStart, otherEnd = otherStart.Add(-day), otherEnd.Add(-day)
+		default:
+			continue
+		}
+		if util.SlotsOverlap(start, end, otherStart, otherEnd) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// nextDay returns the week type and day following dayName of week in the cycle of weeks: Sunday is followed
+// by Monday of the next week, the last week by the first. A week out of weeks repeats.
+func nextDay(week, dayName string, weeks []string) (string, string) {
+	i := findDayIndex(dayName, daysOrder)
+	if i < len(daysOrder)-1 {
+		return week, daysOrder[i+1]
+	}
+	return nextWeek(week, weeks), daysOrder[0]
+}
+
+// nextWeek returns the week following week in the cycle of weeks, the last being followed by the first. A
+// week out of weeks repeats.
+func nextWeek(week string, weeks []string) string {
+	for i, name := range weeks {
+		if name == week {
+			return weeks[(i+1)%len(weeks)]
+		}
+	}
+	return week
+}
+
+// previousWeek returns the week week follows in the cycle of weeks, see nextWeek.
+func previousWeek(week string, weeks []string) string {
+	for i, name := range weeks {
+		if name == week {
+			return weeks[(i+len(weeks)-1)%len(weeks)]
+		}
+	}
+	return week
+}
+
+// isNextDay reports whether the day of next follows the day of slot, see nextDay.
+func isNextDay(slot, next model.Schedule, weeks []string) bool {
+	week, dayName := nextDay(slot.WeekType, slot.DayName, weeks)
+	return next.WeekType == week && next.DayName == dayName
+}
+
+// overlapReason describes the overlap of slot with other.
+func overlapReason(slot, other model.Schedule) string {
+	reason := fmt.Sprintf("%s-%s overlaps the %s-%s slot of %s of week %s", slot.StartTime.Format("15:04"), slot.EndTime.Format("15:04"),
+		other.StartTime.Format("15:04"), other.EndTime.Format("15:04"), other.DayName, other.WeekType)
+	if other.Location != slot.Location {
+		reason += " at another location"
+	}
+	return reason
 }
-func (s *EmployeeService) FetchEmployeeSchedule(employeeID uint, month string, year int) ([]model.MonthlySchedule, error) {
+
+// FetchEmployeeSchedule builds the monthly calendar of an employee across all locations.
+func (s *EmployeeService) FetchEmployeeSchedule(ctx context.Context, employeeID uint, month string, year int) ([]model.MonthlySchedule, error) {
+	return s.FetchEmployeeScheduleAtLocation(ctx, employeeID, month, year, "")
+}
+
+// FetchEmployeeScheduleAtLocation builds the monthly calendar of an employee restricted to the slots
+// worked at location, with the holidays of its region. An empty location includes every slot. The calendar
+// is cached for the version of the employee's schedules, see calendarCache.
+func (s *EmployeeService) FetchEmployeeScheduleAtLocation(ctx context.Context, employeeID uint, month string, year int, location string) ([]model.MonthlySchedule, error) {
 	monthNum := util.MonthStringToNumber(month)
 
 	if monthNum == 0 {
-		return nil, fmt.Errorf("invalid month: %s", month)
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
 	}
 
-	// Fetch holidays for the month and year
-	holidays, err := s.GetHolidaysForMonthYear(year, time.Month(monthNum))
+	ctx = s.atLocation(ctx, location)
+	lastModified, err := s.EmployeeScheduleLastModified(ctx, employeeID)
 	if err != nil {
-		// Decide how to handle errors: log, return an error, or proceed without holidays
-		log.Printf("Could not fetch holidays for %d-%02d: %v", year, monthNum, err)
-		// Optional: return nil, err
+		return nil, err
+	}
+	key := newCalendarKey(ctx, employeeID, year, time.Month(monthNum), location, lastModified)
+	if entries, ok := s.calendars.get(key); ok {
+		return entries, nil
 	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	_, entries, err := s.calendarBetween(ctx, employeeID, firstDayOfMonth, firstDayOfMonth.AddDate(0, 1, -1), location)
+	if err != nil {
+		return nil, err
+	}
+	s.calendars.put(key, entries)
+	return entries, nil
+}
 
-	// Convert holidays into a map for easy lookup
-	holidayMap := make(map[string]string)
-	for _, holiday := range holidays {
-		holidayMap[holiday.HolidayDate.Format("2006-01-02")] = holiday.HolidayName
+// calendarBetween builds the calendar of an employee from first to last included, as
+// FetchEmployeeScheduleAtLocation does for a month, and returns it with the employee.
+func (s *EmployeeService) calendarBetween(ctx context.Context, employeeID uint, first, last time.Time, location string) (*model.Employee, []model.MonthlySchedule, error) {
+	employee, err := s.employeeCalendar(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to get start date for employee ID %d: %w", employeeID, err)
+	}
+	rotation, err := s.rotationOf(ctx, employee)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	employee, err := s.repo.GetEmployeeWithSchedules(employeeID)
+	// The day before first is read for the overnight slots continuing on first.
+	leaveDays, err := s.repo.LeaveFindBetween(ctx, employeeID, first.AddDate(0, 0, -1), last, model.LeaveApproved)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get start date for employee ID %d: %v", employeeID, err)
+		return nil, nil, fmt.Errorf("failed to get the leave of employee ID %d: %w", employeeID, err)
 	}
+	overrides, err := s.repo.OverrideFindBetween(ctx, employeeID, first.AddDate(0, 0, -1), last)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the schedule overrides of employee ID %d: %w", employeeID, err)
+	}
+	holidays := s.holidaysBetween(s.atLocation(ctx, location), first.AddDate(0, 0, -1), last)
+	return employee, monthlyCalendar(employee, rotation, first, last, holidays, leaveDays, overrides, location), nil
+}
 
-	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
-	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+// dayHoliday is a day off marked in the calendars: a public holiday, or a closure day of the company.
+type dayHoliday struct {
+	Name string
+	// Source is model.HolidayPublic or model.HolidayCompany.
+	Source string
+	// Closed clears the recurring slots of the day, see model.ClosureDay.
+	Closed bool
+}
+
+// holidaysBetween returns the public holidays and closure days of every month from first to last by date,
+// as holidayNames does for one month.
+func (s *EmployeeService) holidaysBetween(ctx context.Context, first, last time.Time) map[string]dayHoliday {
+	holidays := make(map[string]dayHoliday)
+	for month := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(last); month = month.AddDate(0, 1, 0) {
+		for date, holiday := range s.holidayNames(ctx, month.Year(), month.Month()) {
+			holidays[date] = holiday
+		}
+	}
+	return holidays
+}
 
+// holidayNames returns the public holidays of a month by date (YYYY-MM-DD), along with the closure days of
+// the company, which replace the public holidays of their date. Days that cannot be fetched are logged and
+// left out.
+func (s *EmployeeService) holidayNames(ctx context.Context, year int, month time.Month) map[string]dayHoliday {
+	holidays, err := s.GetHolidaysForMonthYear(ctx, year, month)
+	if err != nil {
+		reqlog.From(ctx).Printf("Could not fetch holidays for %d-%02d: %v", year, month, err)
+	}
+	names := make(map[string]dayHoliday, len(holidays))
+	for _, holiday := range holidays {
+		names[holiday.HolidayDate.Format("2006-01-02")] = dayHoliday{Name: holiday.HolidayName, Source: model.HolidayPublic}
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	closures, err := s.repo.ClosureDayFindBetween(ctx, first, first.AddDate(0, 1, -1))
+	if err != nil {
+		reqlog.From(ctx).Printf("Could not fetch the closure days of %d-%02d: %v", year, month, err)
+	}
+	for _, closure := range closures {
+		names[closure.Date.Format("2006-01-02")] = dayHoliday{Name: closure.Name, Source: model.HolidayCompany, Closed: closure.ClearSlots}
+	}
+	return names
+}
+
+// monthlyCalendar builds the calendar of an employee, whose resolved slots are in Schedules, from first to
+// last included: the slots in force on each date of its rotation week, replaced by the override of the date if any,
+// restricted to location unless it is empty, with the approved leave days, public holidays and closure days
+// marked. The closure days clearing their slots keep only the slots of their override.
+//
+// The overnight slots are split at midnight, see model.TimeSlot; the part after midnight of the slots of the
+// day before first opens the calendar, read from the leave days, holidays and overrides when they cover that
+// day. The slots of a day of leave are not worked, so they do not continue on the next day.
+func monthlyCalendar(employee *model.Employee, rotation *model.RotationPattern, first, last time.Time, holidays map[string]dayHoliday,
+	leaveDays []model.EmployeeHoliday, overrides []model.ScheduleOverride, location string) []model.MonthlySchedule {
+	leaveMap := make(map[string]*model.Leave, len(leaveDays))
+	for _, day := range leaveDays {
+		leaveMap[day.HolidayDate.Format("2006-01-02")] = &model.Leave{ID: day.ID, Description: day.Description, WithoutPay: day.WithoutPay}
+	}
+	overrideMap := make(map[string]model.ScheduleOverride, len(overrides))
+	for _, override := range overrides {
+		overrideMap[override.Date.Format("2006-01-02")] = override
+	}
+
+	pending := employee.PendingTemplate()
 	entries := make([]model.MonthlySchedule, 0)
-	for d := firstDayOfMonth; !d.After(lastDayOfMonth); d = d.AddDate(0, 0, 1) {
+	var continued []model.TimeSlot // the parts after midnight of the overnight slots of the day before d
+	for d := first.AddDate(0, 0, -1); !d.After(last); d = d.AddDate(0, 0, 1) {
 		dateStr := d.Format("2006-01-02")
-		weekType := util.WeekTypeForDate(employee.StartDate, d)
-		var timeSlots []model.TimeSlot
-		for _, sched := range employee.Schedules {
+		holiday := holidays[dateStr]
+		timeSlots := continued
+		continued = nil
+		if employee.EndedBefore(d) {
+			// Nothing is planned after the end date of a deactivated employee.
+			if !d.Before(first) {
+				entries = append(entries, model.MonthlySchedule{Date: dateStr, DayName: d.Weekday().String(), HolidayName: holiday.Name,
+					HolidaySource: holiday.Source, Closed: holiday.Closed, TimeSlots: timeSlots})
+			}
+			continue
+		}
+		weekType := util.WeekTypeForDate(rotation, employee.RotationStart(rotation), d)
+		addSlot := func(start, end model.CustomTime, location, task string) {
+			if !model.CrossesMidnight(start, end) {
+				timeSlots = append(timeSlots, model.TimeSlot{Start: start.Format("15:04"), End: end.Format("15:04"), Location: location, Task: task})
+				return
+			}
+			timeSlots = append(timeSlots, model.TimeSlot{Start: start.Format("15:04"), End: "00:00", Location: location, Task: task,
+				CrossesMidnight: true})
+			if leaveMap[dateStr] == nil && end.Format("15:04") != "00:00" {
+				continued = append(continued, model.TimeSlot{Start: "00:00", End: end.Format("15:04"), Location: location, Task: task,
+					Continued: true})
+			}
+		}
+		// An override replaces the recurring slots of its date, which are those of the version in force.
+		schedules := employee.SchedulesOn(d)
+		override, overridden := overrideMap[dateStr]
+		if overridden || holiday.Closed {
+			schedules = nil
+		}
+		for _, slot := range override.Slots {
+			if location != "" && slot.Location != location {
+				continue
+			}
+			addSlot(slot.StartTime, slot.EndTime, slot.Location, slot.Task)
+		}
+		for _, sched := range schedules {
+			if location != "" && sched.Location != location {
+				continue
+			}
 			if sched.WeekType == weekType && sched.DayName == d.Weekday().String() {
-				formattedStartTime := sched.StartTime.Format("15:04")
-				formattedEndTime := sched.EndTime.Format("15:04")
-
-				timeSlots = append(timeSlots, model.TimeSlot{
-					Start: formattedStartTime,
-					End:   formattedEndTime,
-				})
+				addSlot(sched.StartTime, sched.EndTime, sched.Location, sched.Task)
 			}
 		}
-
-		holidayName := ""
-		if name, ok := holidayMap[dateStr]; ok {
-			holidayName = name
+		if d.Before(first) {
+			continue
 		}
 
 		entries = append(entries, model.MonthlySchedule{
-			Date:        dateStr,
-			DayName:     d.Weekday().String(),
-			HolidayName: holidayName,
-			TimeSlots:   timeSlots,
+			Date:          dateStr,
+			DayName:       d.Weekday().String(),
+			HolidayName:   holiday.Name,
+			HolidaySource: holiday.Source,
+			Closed:        holiday.Closed,
+			Leave:         leaveMap[dateStr],
+			Overridden:    overridden,
+			Unscheduled:   pending,
+			TimeSlots:     timeSlots,
 		})
 	}
 
-	return entries, nil
+	return entries
 }
 
-func (s *EmployeeService) CalculateMonthlyHours(entries []model.MonthlySchedule) (float64, error) {
-	var totalHours float64
+// CalculateMonthlyHours sums the hours of the slots of a monthly calendar, in the time zone of their location,
+// and splits them by the way they are paid, see HolidayPay. The days of paid leave are left out, paid as
+// absences. A public holiday paid if not worked is paid but not worked, unless an override sets its slots; if
+// it falls on a day of leave, it is paid all the same.
+func (s *EmployeeService) CalculateMonthlyHours(entries []model.MonthlySchedule) (MonthlyHours, error) {
+	var total MonthlyHours
 	for _, entry := range entries {
+		var hours float64
 		for _, slot := range entry.TimeSlots {
-			hours, err := util.CalculateHours(slot.Start, slot.End)
+			slotHours, err := s.slotHours(entry.Date, slot)
 			if err != nil {
-				return 0, err // Handle the error appropriately
+				return MonthlyHours{}, err // Handle the error appropriately
+			}
+			hours += slotHours
+		}
+		offPaid := entry.PublicHoliday() && s.holidayPay.paidIfNotWorked(entry.Date)
+		switch {
+		case entry.Leave != nil && offPaid:
+			total.Regular += hours
+		case entry.Leave != nil:
+			if entry.Leave.WithoutPay {
+				total.Unpaid += hours
 			}
-			totalHours += hours
+		case offPaid && !entry.Overridden:
+			total.Regular += hours
+		case entry.PublicHoliday():
+			total.Worked += hours
+			total.HolidayPremium += hours
+		default:
+			total.Worked += hours
+			total.Regular += hours
+		}
+	}
+	total.Paid = total.Regular + total.HolidayPremium*s.holidayPay.WorkedMultiplier
+	return total, nil
+}
+
+// DBCreate applies the pending schema migrations.
+func (s *EmployeeService) DBCreate(ctx context.Context) error {
+	return s.repo.DBCreate(ctx)
+}
+
+// MigrationReport is the version of the schema: the migrations known to the server, oldest first.
+type MigrationReport struct {
+	// Current is the last migration applied, empty before the first.
+	Current    string                  `json:"current"`
+	Pending    int                     `json:"pending"`
+	Migrations []model.MigrationStatus `json:"migrations"`
+}
+
+// MigrationStatus reports which schema migrations were applied to the database.
+func (s *EmployeeService) MigrationStatus(ctx context.Context) (*MigrationReport, error) {
+	migrations, err := s.repo.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &MigrationReport{Migrations: migrations}
+	for _, migration := range migrations {
+		if migration.Applied {
+			report.Current = migration.ID
+		} else {
+			report.Pending++
 		}
 	}
-	return totalHours, nil
+	return report, nil
 }
 
-func (s *EmployeeService) DBCreate() error {
-	return s.repo.DBCreate()
+// TableRowCounts counts the rows of every table of every tenant, for the support bundles.
+func (s *EmployeeService) TableRowCounts(ctx context.Context) ([]model.TableRowCount, error) {
+	counts, err := s.repo.TableRowCounts(ctx)
+	if errors.Is(err, repo.ErrTenantScoped) {
+		return nil, apierror.Conflict("the row counts span every tenant and cannot be read by one")
+	}
+	return counts, err
 }
 
-func (svc *EmployeeService) DBDelete() error {
-	return svc.repo.DBDelete()
+func (svc *EmployeeService) DBDelete(ctx context.Context) error {
+	if err := svc.repo.DBDelete(ctx); err != nil {
+		if errors.Is(err, repo.ErrTenantScoped) {
+			return apierror.Conflict("the database is shared by every tenant and cannot be deleted by one")
+		}
+		return err
+	}
+	return nil
 }
 
-func (svc *EmployeeService) FetchAllEmployees() ([]model.Employee, error) {
-	return svc.repo.GetEmployees()
+// GetEmployee returns an employee that is not archived, without its slots.
+func (svc *EmployeeService) GetEmployee(ctx context.Context, id uint) (*model.Employee, error) {
+	var employee model.Employee
+	if err := svc.repo.GetEmployeeByID(ctx, id, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", id)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	return &employee, nil
 }
 
+// employeeIncludes are the related data GetEmployeeDetail embeds on request, by the relation of model.Employee
+// they are loaded from.
+var employeeIncludes = map[string]string{"schedules": "Schedules", "deltas": "Deltas", "leave": "LeaveDays"}
+
+// EmployeeDetail is an employee with the related data asked to GetEmployeeDetail: its own slots in Schedules,
+// its deltas in Deltas, and its leave days in Leave. The data not asked for, or empty, is left out.
+type EmployeeDetail struct {
+	*model.Employee
+	// Leave are the leave days of the employee, of every status.
+	Leave []model.EmployeeHoliday `json:"leave,omitempty"`
+}
+
+// GetEmployeeDetail returns an employee that is not archived with the related data named by include, among
+// "schedules", "deltas" and "leave", flagged unscheduled if it is pending a template.
+func (svc *EmployeeService) GetEmployeeDetail(ctx context.Context, id uint, include []string) (*EmployeeDetail, error) {
+	// The own slots are always loaded, to tell whether the employee is pending a template, but only returned
+	// when asked for.
+	relations := []string{"Schedules"}
+	withSchedules := false
+	for _, name := range include {
+		relation, ok := employeeIncludes[name]
+		if !ok {
+			return nil, apierror.Validation(fmt.Sprintf("include must list schedules, deltas or leave, got: %q", name))
+		}
+		if relation == "Schedules" {
+			withSchedules = true
+			continue
+		}
+		relations = append(relations, relation)
+	}
+	employee, err := svc.repo.GetEmployeeIncluding(ctx, id, relations...)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", id)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	employee.Unscheduled = employee.PendingTemplate()
+	if !withSchedules {
+		employee.Schedules = nil
+	}
+	return &EmployeeDetail{Employee: employee, Leave: employee.LeaveDays}, nil
+}
+
+// maxSearchResults bounds the number of employees SearchEmployees returns.
+const maxSearchResults = 50
+
+// SearchEmployees returns at most limit employees that are not archived whose name matches query, the best
+// matches first, for the type-ahead pickers: the names starting with query, then those containing it, then
+// those close to it despite a typo. The case is ignored. SQLite deployments only match the names containing
+// query.
+func (svc *EmployeeService) SearchEmployees(ctx context.Context, query string, limit int) ([]model.Employee, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, apierror.Validation("q is required").WithCode(apierror.CodeFieldRequired)
+	}
+	if limit <= 0 || limit > maxSearchResults {
+		return nil, apierror.Validation(fmt.Sprintf("limit must be between 1 and %d, got: %d", maxSearchResults, limit))
+	}
+	return svc.repo.SearchEmployees(ctx, query, limit)
+}
+
+// Filters of FetchAllEmployees: employees are inactive once the end date they were deactivated with is past.
+const (
+	EmployeesActive   = "active"
+	EmployeesInactive = "inactive"
+	EmployeesAll      = "all"
+)
+
+// FetchAllEmployees lists the employees that are not archived, restricted to the active or inactive ones by
+// status (default active), flagging those pending a template as unscheduled.
+func (svc *EmployeeService) FetchAllEmployees(ctx context.Context, status string) ([]model.Employee, error) {
+	switch status {
+	case "":
+		status = EmployeesActive
+	case EmployeesActive, EmployeesInactive, EmployeesAll:
+	default:
+		return nil, apierror.Validation(fmt.Sprintf("status must be one of %s, %s or %s, got: %s",
+			EmployeesActive, EmployeesInactive, EmployeesAll, status))
+	}
+	all, err := svc.repo.GetEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := svc.today("")
+	employees := all[:0]
+	for _, employee := range all {
+		if status == EmployeesAll || employee.EndedBefore(today) == (status == EmployeesInactive) {
+			employees = append(employees, employee)
+		}
+	}
+	unscheduled, err := svc.repo.UnscheduledEmployeeIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[uint]bool, len(unscheduled))
+	for _, id := range unscheduled {
+		pending[id] = true
+	}
+	for i := range employees {
+		employees[i].Unscheduled = pending[employees[i].ID]
+	}
+	return employees, nil
+}
+
+// DeactivateEmployees ends the contracts of several employees on the same day, at season end for instance:
+// their slots stop generating after the end date, the reports of the final month only count the days worked,
+// and once the date is past they move from the active to the inactive employees. Either every employee is
+// deactivated or none is.
+func (svc *EmployeeService) DeactivateEmployees(ctx context.Context, input model.DeactivationInput) ([]model.Employee, error) {
+	if len(input.EmployeeIDs) == 0 {
+		return nil, apierror.Validation("employeeIds must list at least one employee")
+	}
+	endDate, err := time.Parse("2006-01-02", input.EndDate)
+	if err != nil {
+		return nil, apierror.Validation(fmt.Sprintf("invalid endDate %q, expected YYYY-MM-DD", input.EndDate)).WithCode(apierror.CodeDateInvalid)
+	}
+	employees, err := svc.repo.GetEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*model.Employee, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+	}
+	ids := make([]uint, 0, len(input.EmployeeIDs))
+	seen := make(map[uint]bool, len(input.EmployeeIDs))
+	var missing []string
+	var invalid []apierror.InvalidParam
+	for i, id := range input.EmployeeIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		employee, ok := byID[id]
+		if !ok {
+			missing = append(missing, strconv.FormatUint(uint64(id), 10))
+			continue
+		}
+		if endDate.Before(employee.StartDate) {
+			invalid = append(invalid, apierror.InvalidParam{Name: fmt.Sprintf("employeeIds[%d]", i), Code: apierror.CodeDateInvalid,
+				Reason: fmt.Sprintf("%s starts on %s, after the end date", employee.Name, employee.StartDate.Format("2006-01-02"))})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apierror.NotFound(fmt.Sprintf("employees %s not found", strings.Join(missing, ", "))).WithCode(apierror.CodeEmployeeNotFound)
+	}
+	if len(invalid) > 0 {
+		return nil, apierror.InvalidParams(fmt.Sprintf("%d employees cannot end on %s", len(invalid), input.EndDate), invalid).WithCode(apierror.CodeValidationFailed)
+	}
+	if err := svc.repo.DeactivateEmployees(ctx, ids, endDate); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.Conflict("an employee was archived during the deactivation, nothing was changed").WithCode(apierror.CodeConflict)
+		}
+		return nil, err
+	}
+	deactivated := make([]model.Employee, 0, len(ids))
+	for _, id := range ids {
+		employee := *byID[id]
+		employee.EndDate = &endDate
+		deactivated = append(deactivated, employee)
+	}
+	return deactivated, nil
+}
+
+// ArchiveEmployee soft-deletes an employee: it disappears from listings, calendars, exports and reports but
+// keeps its schedules and can be restored.
+func (svc *EmployeeService) ArchiveEmployee(ctx context.Context, id uint) error {
+	if err := svc.repo.ArchiveEmployee(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("employee %d not found", id)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListArchivedEmployees returns the archived employees, most recently archived first.
+func (svc *EmployeeService) ListArchivedEmployees(ctx context.Context) ([]model.Employee, error) {
+	return svc.repo.ArchivedEmployees(ctx)
+}
+
+// RestoreEmployee brings an archived employee back with its schedules.
+func (svc *EmployeeService) RestoreEmployee(ctx context.Context, id uint) (*model.Employee, error) {
+	if err := svc.repo.RestoreEmployee(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("archived employee %d not found", id)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	var employee model.Employee
+	if err := svc.repo.GetEmployeeByID(ctx, id, &employee); err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+// EmployeesLastModified returns when the employee list last changed, or the zero time if it is empty.
+func (svc *EmployeeService) EmployeesLastModified(ctx context.Context) (time.Time, error) {
+	return svc.repo.EmployeesLastModified(ctx)
+}
+
+// EmployeeScheduleLastModified returns when the calendar of an employee last changed: the latest
+// modification of the employee, its slots, its deltas and the role template it inherits from.
+func (svc *EmployeeService) EmployeeScheduleLastModified(ctx context.Context, employeeID uint) (time.Time, error) {
+	if svc.snapshotReads {
+		var employee model.Employee
+		if err := svc.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return time.Time{}, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+			}
+			return time.Time{}, err
+		}
+		if employee.ScheduleSnapshot != nil {
+			return laterOf(employee.UpdatedAt, employee.ScheduleSnapshot.LastModified), nil
+		}
+	}
+
+	employee, err := svc.employeeWithSchedules(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return time.Time{}, err
+	}
+	return scheduleLastModified(employee), nil
+}
+
+// scheduleLastModified is EmployeeScheduleLastModified for an employee loaded with its schedules, role
+// template and deltas.
+func scheduleLastModified(employee *model.Employee) time.Time {
+	latest := employee.UpdatedAt
+	for _, schedule := range employee.Schedules {
+		latest = laterOf(latest, schedule.UpdatedAt)
+	}
+	for _, delta := range employee.Deltas {
+		latest = laterOf(latest, delta.UpdatedAt)
+	}
+	if employee.RoleTemplate != nil {
+		latest = laterOf(latest, RoleTemplatesLastModified([]model.RoleTemplate{*employee.RoleTemplate}))
+	}
+	return latest
+}
+
+// EmployeeChanges is a page of the delta-sync feed: the employees whose calendar changed since Since. Clients
+// pass ServerTime as the since of their next sync.
+type EmployeeChanges struct {
+	Since      time.Time        `json:"since"`
+	ServerTime time.Time        `json:"serverTime"`
+	Employees  []model.Employee `json:"employees"`
+}
+
+// FetchEmployeeChanges returns the employees changed after since, with their own slots and deltas. A zero since
+// returns every employee.
+func (svc *EmployeeService) FetchEmployeeChanges(ctx context.Context, since time.Time) (*EmployeeChanges, error) {
+	// Taken before querying so a change committed during the query is returned again by the next sync
+	// rather than missed.
+	serverTime := time.Now().UTC()
+	employees, err := svc.repo.EmployeesChangedSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return &EmployeeChanges{Since: since, ServerTime: serverTime, Employees: employees}, nil
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// daysOrder is the fixed order of the days of the week used in weekly views.
+var daysOrder = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
 type WeekSchedule struct {
-	WeekType string          `json:"weekType"`
-	Days     []DailySchedule `json:"days"`
+	WeekType string `json:"weekType"`
+	// Current marks the week of the rotation the date asked for falls in.
+	Current bool            `json:"current,omitempty"`
+	Days    []DailySchedule `json:"days"`
 }
 
 type DailySchedule struct {
@@ -189,36 +1134,56 @@ type DailySchedule struct {
 }
 
 type TimeSlot struct {
-	Start string `json:"start"`
-	End   string `json:"end"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Location string `json:"location,omitempty"`
+	Task     string `json:"task,omitempty"`
 }
 
-func (svc *EmployeeService) FetchEmployeeFormattedABWeek(employeeID uint) ([]WeekSchedule, error) {
-	weekSchedules := []WeekSchedule{
-		{WeekType: "A", Days: make([]DailySchedule, 7)},
-		{WeekType: "B", Days: make([]DailySchedule, 7)},
+// FetchEmployeeFormattedABWeek returns the weekly template of an employee, one week per week of its rotation
+// (A and B unless it follows another rotation pattern) in cycle order. A non-empty location restricts the
+// template to the slots worked at that location. Unless on is zero, the week on falls in is marked current.
+func (svc *EmployeeService) FetchEmployeeFormattedABWeek(ctx context.Context, employeeID uint, location string, on time.Time) ([]WeekSchedule, error) {
+	employee, err := svc.employeeCalendar(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	rotation, err := svc.rotationOf(ctx, employee)
+	if err != nil {
+		return nil, err
 	}
+	schedules := employee.Schedules
 
-	// Define a fixed order and empty structure for the days of the week
-	daysOrder := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
-	for i, day := range daysOrder {
-		weekSchedules[0].Days[i] = DailySchedule{DayName: day, TimeSlots: []TimeSlot{}}
-		weekSchedules[1].Days[i] = DailySchedule{DayName: day, TimeSlots: []TimeSlot{}}
+	// Define a fixed order and empty structure for the days of every week
+	current := ""
+	if !on.IsZero() {
+		current = util.WeekTypeForDate(rotation, employee.RotationStart(rotation), on)
+	}
+	weekSchedules := make([]WeekSchedule, len(rotation.Weeks))
+	for w, week := range rotation.Weeks {
+		weekSchedules[w] = WeekSchedule{WeekType: week.Name, Current: week.Name == current, Days: make([]DailySchedule, 7)}
+		for i, day := range daysOrder {
+			weekSchedules[w].Days[i] = DailySchedule{DayName: day, TimeSlots: []TimeSlot{}}
+		}
 	}
 
 	// Populate time slots for each week type
 	for weekIndex, weekSchedule := range weekSchedules {
-		schedules, err := svc.repo.GetSchedule(employeeID, weekSchedule.WeekType)
-		if err != nil {
-			return nil, err
-		}
-
 		for _, schedule := range schedules {
+			if schedule.WeekType != weekSchedule.WeekType {
+				continue
+			}
+			if location != "" && schedule.Location != location {
+				continue
+			}
 			dayIndex := findDayIndex(schedule.DayName, daysOrder)
 			if dayIndex != -1 {
 				startFormatted := schedule.StartTime.Format("15:04")
 				endFormatted := schedule.EndTime.Format("15:04")
-				weekSchedules[weekIndex].Days[dayIndex].TimeSlots = append(weekSchedules[weekIndex].Days[dayIndex].TimeSlots, TimeSlot{Start: startFormatted, End: endFormatted})
+				weekSchedules[weekIndex].Days[dayIndex].TimeSlots = append(weekSchedules[weekIndex].Days[dayIndex].TimeSlots, TimeSlot{Start: startFormatted, End: endFormatted, Location: schedule.Location, Task: schedule.Task})
 			}
 		}
 	}
@@ -226,69 +1191,378 @@ func (svc *EmployeeService) FetchEmployeeFormattedABWeek(employeeID uint) ([]Wee
 	return weekSchedules, nil
 }
 
-func findDayIndex(dayName string, daysOrder []string) int {
-	for i, day := range daysOrder {
-		if day == dayName {
-			return i
+// GetSchedule returns the schedule slot identified by id, with its provenance.
+func (svc *EmployeeService) GetSchedule(ctx context.Context, id uint) (*model.Schedule, error) {
+	schedule, err := svc.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("schedule %d not found", id)).WithCode(apierror.CodeScheduleNotFound)
 		}
+		return nil, err
 	}
-	return -1
+	return schedule, nil
 }
 
-// GetHolidaysForMonthYear tries to get holidays from the DB, fetches from the API if not found, and stores them
-func (hs *EmployeeService) GetHolidaysForMonthYear(year int, month time.Month) ([]model.Holiday, error) {
-	holidays, err := hs.repo.HolidayFindByMonthAndYear(year, month)
+// UpdateSchedule replaces the schedule slot identified by id after validating the new values. The slot
+// becomes a manual edit of the user of ctx. With an effectiveFrom date the change only applies from that
+// date, as with ReplaceEmployeeSchedules, and the slot stays a slot of its employee.
+func (svc *EmployeeService) UpdateSchedule(ctx context.Context, id uint, schedule model.Schedule, effectiveFrom time.Time) (*model.Schedule, error) {
+	existing, err := svc.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("schedule %d not found", id)).WithCode(apierror.CodeScheduleNotFound)
+		}
+		return nil, err
+	}
+	rotation, err := svc.validateSchedule(ctx, schedule)
 	if err != nil {
 		return nil, err
 	}
+	if !effectiveFrom.IsZero() && schedule.EmployeeID != existing.EmployeeID {
+		return nil, apierror.Validation(fmt.Sprintf("slot %d of employee %d cannot move to employee %d from an effective date",
+			id, existing.EmployeeID, schedule.EmployeeID))
+	}
 
-	// If holidays are not found in the database for the given month/year, fetch from API
-	if len(holidays) == 0 {
-		allHolidays, err := FetchHolidaysFromAPI(year)
+	schedule.ID = id
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.Source, schedule.ChangedByID = model.SourceManual, changedBy(ctx)
+	// The slot may run into the slots of the days around it, up to those of the weeks around its week.
+	weeks := rotation.WeekNames()
+	var others []model.Schedule
+	seen := make(map[string]bool)
+	for _, weekType := range []string{previousWeek(schedule.WeekType, weeks), schedule.WeekType, nextWeek(schedule.WeekType, weeks)} {
+		if seen[weekType] {
+			continue
+		}
+		seen[weekType] = true
+		slots, err := svc.repo.GetSchedule(ctx, schedule.EmployeeID, weekType)
 		if err != nil {
 			return nil, err
 		}
+		others = append(others, slots...)
+	}
+	if i, ok := overlappingSlot(schedule, others, weeks); ok {
+		return nil, apierror.Conflict(fmt.Sprintf("slot %d: %s", others[i].ID, overlapReason(schedule, others[i]))).WithCode(apierror.CodeScheduleOverlap)
+	}
+	if err := svc.checkSlotRules(ctx, validation.StageUpdate, schedule); err != nil {
+		return nil, err
+	}
+	version, err := svc.versionFrom(ctx, existing.EmployeeID, effectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	err = svc.writeFrom(ctx, version, func(tx repo.Repository) error {
+		return tx.UpdateSchedule(ctx, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	svc.syncSnapshots(ctx, existing.EmployeeID)
+	if schedule.EmployeeID != existing.EmployeeID {
+		svc.syncSnapshots(ctx, schedule.EmployeeID)
+	}
+	updated, err := svc.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	svc.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: updated.EmployeeID, Change: ChangeSlot, Action: ActionWritten, ID: id, Resource: updated})
+	return updated, nil
+}
 
-		for dateStr, name := range allHolidays {
-			date, err := time.Parse("2006-01-02", dateStr)
-			if err != nil {
-				continue // skip if the date format is incorrect
-			}
+// SetScheduleTask assigns the slot identified by id to a task/station; an empty task clears it.
+func (svc *EmployeeService) SetScheduleTask(ctx context.Context, id uint, task string) (*model.Schedule, error) {
+	if len(task) > 50 {
+		return nil, apierror.Validation("task must be at most 50 characters")
+	}
+	existing, err := svc.repo.GetScheduleByID(ctx, id)
+	if err == nil {
+		existing.Task = task
+		if err := svc.checkSlotRules(ctx, validation.StagePatch, *existing); err != nil {
+			return nil, err
+		}
+		err = svc.repo.UpdateScheduleTask(ctx, id, task, changedBy(ctx))
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("schedule %d not found", id)).WithCode(apierror.CodeScheduleNotFound)
+		}
+		return nil, err
+	}
+	updated, err := svc.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	svc.syncSnapshots(ctx, updated.EmployeeID)
+	svc.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: updated.EmployeeID, Change: ChangeSlot, Action: ActionWritten, ID: id, Resource: updated})
+	return updated, nil
+}
 
-			// If the month matches the requested month, add to the database
-			if date.Year() == year && date.Month() == month {
-				holiday := model.Holiday{HolidayDate: date, HolidayName: name}
-				err := hs.repo.HolidayCreate(&holiday)
-				if err != nil {
-					return nil, err
-				}
-				holidays = append(holidays, holiday)
+// DeleteSchedule removes the schedule slot identified by id. With an effectiveFrom date the slot is only
+// removed from that date, as with ReplaceEmployeeSchedules.
+func (svc *EmployeeService) DeleteSchedule(ctx context.Context, id uint, effectiveFrom time.Time) error {
+	schedule, err := svc.repo.GetScheduleByID(ctx, id)
+	var version *model.ScheduleVersion
+	if err == nil {
+		version, err = svc.versionFrom(ctx, schedule.EmployeeID, effectiveFrom)
+	}
+	if err == nil {
+		err = svc.writeFrom(ctx, version, func(tx repo.Repository) error {
+			return tx.DeleteSchedule(ctx, id)
+		})
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("schedule %d not found", id)).WithCode(apierror.CodeScheduleNotFound)
+		}
+		return err
+	}
+	svc.syncSnapshots(ctx, schedule.EmployeeID)
+	svc.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: schedule.EmployeeID, Change: ChangeSlot, Action: ActionDeleted, ID: id})
+	return nil
+}
+
+// validateSchedule checks the week type, day name and time range of a slot, that its employee exists and
+// that the week type is a week of the employee's rotation, which it returns.
+func (svc *EmployeeService) validateSchedule(ctx context.Context, schedule model.Schedule) (*model.RotationPattern, error) {
+	if err := validateSlot(schedule.WeekType, schedule.DayName, schedule.StartTime, schedule.EndTime); err != nil {
+		return nil, err
+	}
+
+	var employee model.Employee
+	if err := svc.repo.GetEmployeeByID(ctx, schedule.EmployeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.Validation(fmt.Sprintf("employee %d does not exist", schedule.EmployeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	rotation, err := svc.rotationOf(ctx, &employee)
+	if err != nil {
+		return nil, err
+	}
+	return rotation, checkWeekType(rotation, schedule.WeekType)
+}
+
+// validateSlot checks the week type, day name and time range shared by schedules, template slots and deltas.
+// The week type is only checked to be a week name: which weeks exist depends on the employee's rotation.
+func validateSlot(weekType, dayName string, start, end model.CustomTime) error {
+	if !weekNamePattern.MatchString(weekType) {
+		return apierror.Validation(fmt.Sprintf("weekType must be a week name of 1 to 8 letters or digits, got: %q", weekType)).WithCode(apierror.CodeWeekTypeInvalid)
+	}
+	if findDayIndex(dayName, daysOrder) == -1 {
+		return apierror.Validation(fmt.Sprintf("invalid dayName: %s", dayName)).WithCode(apierror.CodeDayNameInvalid)
+	}
+	return validateShift(start, end)
+}
+
+// validateShift checks that a slot worked has a length. It may end before it starts: it is then worked
+// overnight, see model.Schedule.CrossesMidnight.
+func validateShift(start, end model.CustomTime) error {
+	if start.Equal(end.Time) {
+		return apierror.Validation(fmt.Sprintf("slot %s-%s has zero length",
+			start.Format("15:04"), end.Format("15:04"))).WithCode(apierror.CodeTimeRangeInvalid)
+	}
+	return nil
+}
+
+// validateTimeRange checks that a time range of a day starts before it ends.
+func validateTimeRange(start, end model.CustomTime) error {
+	if err := validateShift(start, end); err != nil {
+		return err
+	}
+	if !start.Before(end.Time) {
+		return apierror.Validation(fmt.Sprintf("startTime %s must be before endTime %s",
+			start.Format("15:04"), end.Format("15:04"))).WithCode(apierror.CodeTimeRangeInvalid)
+	}
+	return nil
+}
+
+// LocationConflict describes two overlapping slots of the same employee at different locations.
+type LocationConflict struct {
+	WeekType string   `json:"weekType"`
+	DayName  string   `json:"dayName"`
+	First    TimeSlot `json:"first"`
+	Second   TimeSlot `json:"second"`
+}
+
+// DetectLocationConflicts lists the slots of an employee that overlap in time while being worked at
+// different locations, which cannot both be honoured.
+func (svc *EmployeeService) DetectLocationConflicts(ctx context.Context, employeeID uint) ([]LocationConflict, error) {
+	employee, err := svc.employeeWithSchedules(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+
+	conflicts := make([]LocationConflict, 0)
+	schedules := resolveSchedules(employee)
+	for i := range schedules {
+		for j := i + 1; j < len(schedules); j++ {
+			a, b := schedules[i], schedules[j]
+			if a.WeekType != b.WeekType || a.DayName != b.DayName || a.Location == b.Location {
+				continue
+			}
+			if util.SlotsOverlap(a.StartTime.Time, a.End(), b.StartTime.Time, b.End()) {
+				conflicts = append(conflicts, LocationConflict{
+					WeekType: a.WeekType,
+					DayName:  a.DayName,
+					First:    TimeSlot{Start: a.StartTime.Format("15:04"), End: a.EndTime.Format("15:04"), Location: a.Location, Task: a.Task},
+					Second:   TimeSlot{Start: b.StartTime.Format("15:04"), End: b.EndTime.Format("15:04"), Location: b.Location, Task: b.Task},
+				})
 			}
 		}
 	}
+	return conflicts, nil
+}
 
-	return holidays, nil
+// EmployeeConflicts are the conflicts of the calendar of an employee: its slots at different locations
+// overlapping in time, and the slots breaking a pairing rule it is bound by.
+type EmployeeConflicts struct {
+	EmployeeID uint               `json:"employeeId"`
+	Locations  []LocationConflict `json:"locations"`
+	Pairings   []PairingViolation `json:"pairings"`
 }
 
-// FetchHolidaysFromAPI fetches holidays for a given year from the API
-func FetchHolidaysFromAPI(year int) (map[string]string, error) {
-	url := fmt.Sprintf("https://calendrier.api.gouv.fr/jours-feries/metropole/%d.json", year)
-	resp, err := http.Get(url)
+// DetectConflicts lists the location conflicts of an employee, see DetectLocationConflicts, and the
+// violations of the pairing rules naming it during the weeks checked by PairingViolations from from.
+func (svc *EmployeeService) DetectConflicts(ctx context.Context, employeeID uint, from time.Time) (*EmployeeConflicts, error) {
+	locations, err := svc.DetectLocationConflicts(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := svc.repo.PairingRuleList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var bound []model.PairingRule
+	for _, rule := range rules {
+		if rule.EmployeeID == employeeID || rule.OtherEmployeeID == employeeID {
+			bound = append(bound, rule)
+		}
+	}
+	pairings, err := svc.checkPairingRules(ctx, bound, from)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return &EmployeeConflicts{EmployeeID: employeeID, Locations: locations, Pairings: pairings}, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+func findDayIndex(dayName string, daysOrder []string) int {
+	for i, day := range daysOrder {
+		if day == dayName {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetHolidaysForMonthYear tries to get holidays from the DB, fetches from the holiday provider if not found, and
+// stores them, unless the service is read-only, see UseReadOnly. The holidays are fetched and stored a year at
+// a time: a month without holidays of a year already stored is not fetched again.
+func (hs *EmployeeService) GetHolidaysForMonthYear(ctx context.Context, year int, month time.Month) ([]model.Holiday, error) {
+	region, ok := repo.HolidayRegionFromContext(ctx)
+	if !ok {
+		region = hs.holidays.DefaultRegion()
+		ctx = repo.WithHolidayRegion(ctx, region)
+	}
+	yearHolidays, err := hs.repo.HolidayFindByYear(ctx, year)
 	if err != nil {
 		return nil, err
 	}
 
-	var holidays map[string]string
-	err = json.Unmarshal(body, &holidays)
+	// If holidays are not found in the database for the given year, fetch from API
+	if len(yearHolidays) == 0 {
+		fetch := hs.storeHolidayYear
+		if hs.readOnly {
+			fetch = hs.fetchHolidayYear
+		}
+		if yearHolidays, err = fetch(ctx, region, year); err != nil {
+			return nil, err
+		}
+	}
+
+	var holidays []model.Holiday
+	for _, holiday := range yearHolidays {
+		if holiday.HolidayDate.Month() == month {
+			holidays = append(holidays, holiday)
+		}
+	}
+	return holidays, nil
+}
+
+// HolidayPrefetch reports the public holidays PrefetchHolidays stored.
+type HolidayPrefetch struct {
+	Year int `json:"year"`
+	// Regions are the numbers of holidays stored by holiday region.
+	Regions map[string]int `json:"regions"`
+}
+
+// PrefetchHolidays fetches the public holidays of a year from the holiday provider and stores them, replacing
+// those already stored, for the holiday region of ctx and the regions of the locations. The calendars built
+// from the holidays stored before are dropped.
+func (hs *EmployeeService) PrefetchHolidays(ctx context.Context, year int) (*HolidayPrefetch, error) {
+	if year < 1 || year > 9999 {
+		return nil, apierror.Validation("invalid year").WithCode(apierror.CodeYearInvalid)
+	}
+	region, ok := repo.HolidayRegionFromContext(ctx)
+	if !ok {
+		region = hs.holidays.DefaultRegion()
+	}
+	prefetch := &HolidayPrefetch{Year: year, Regions: map[string]int{}}
+	if region != "" {
+		prefetch.Regions[region] = 0
+	}
+	for _, locationRegion := range hs.locationRegions {
+		prefetch.Regions[locationRegion] = 0
+	}
+	for region := range prefetch.Regions {
+		holidays, err := hs.storeHolidayYear(repo.WithHolidayRegion(ctx, region), region, year)
+		if err != nil {
+			return nil, err
+		}
+		prefetch.Regions[region] = len(holidays)
+	}
+	hs.calendars.invalidate()
+	return prefetch, nil
+}
+
+// storeHolidayYear fetches the public holidays of a year in region from the holiday provider and stores them
+// in a single batch, ordered by date.
+func (hs *EmployeeService) storeHolidayYear(ctx context.Context, region string, year int) ([]model.Holiday, error) {
+	holidays, err := hs.fetchHolidayYear(ctx, region, year)
 	if err != nil {
 		return nil, err
 	}
+	if err := hs.repo.HolidayUpsert(ctx, holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
 
+// fetchHolidayYear fetches the public holidays of a year in region from the holiday provider, ordered by date.
+func (hs *EmployeeService) fetchHolidayYear(ctx context.Context, region string, year int) ([]model.Holiday, error) {
+	fetched, err := hs.holidays.Holidays(ctx, region, year)
+	if err != nil {
+		return nil, apierror.Unavailable("the public holiday provider could not be reached", err).WithCode(apierror.CodeHolidayProviderDown)
+	}
+	holidays := make([]model.Holiday, 0, len(fetched))
+	for dateStr, name := range fetched {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil || date.Year() != year {
+			continue // skip if the date format is incorrect
+		}
+		holidays = append(holidays, model.Holiday{Region: region, HolidayDate: date, HolidayName: name})
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].HolidayDate.Before(holidays[j].HolidayDate) })
 	return holidays, nil
 }
+
+// IDByUUID returns the primary key of the resource, a model such as &model.Employee{}, with the given UUID.
+func (svc *EmployeeService) IDByUUID(ctx context.Context, resource interface{}, uuid string) (uint, error) {
+	id, err := svc.repo.IDByUUID(ctx, resource, uuid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, apierror.NotFound(fmt.Sprintf("%s not found", uuid))
+	}
+	return id, err
+}
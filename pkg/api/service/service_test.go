@@ -1,67 +1,26 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/joho/godotenv"
 	"github.com/lichensio/api_server/db/model"
 	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/db/repo/repotest"
 	"github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
-	"os"
 	"testing"
+	"time"
 )
 
-// setupTestDB initializes the test database, applies migrations, and returns a gorm.DB instance.
-func setupTestDB(t *testing.T) (*gorm.DB, func()) {
-	err := godotenv.Load(".env") // Adjust the path to your .env file
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
-	}
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_SSLMODE"),
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	require.NoError(t, err)
-
-	// Apply migrations
-	err = db.AutoMigrate(&model.Employee{}, &model.Schedule{})
-	require.NoError(t, err)
-
-	// Cleanup function to be called after tests
-	cleanup := func() {
-		if err := db.Migrator().DropTable(&model.Schedule{}); err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				log.Printf("Warning: Failed to clean up schedules table: %v", err)
-			}
-		}
-		if err := db.Migrator().DropTable(&model.Employee{}); err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				log.Printf("Warning: Failed to clean up employees table: %v", err)
-			}
-		}
-	}
-
-	return db, cleanup
-}
-
-// setupTestService initializes EmployeeService with a test database for use in tests.
+// setupTestService initializes EmployeeService with an in-memory database for use in tests.
 func setupTestService(t *testing.T) (*EmployeeService, func()) {
-	db, cleanup := setupTestDB(t)
-	repository := repo.NewRepositoryWithDB(db) // Assumes NewRepository can accept *gorm.DB directly
-	employeeService := NewEmployeeService(repository)
-	return employeeService, cleanup
+	return NewEmployeeService(repotest.NewInMemoryRepository(t)), func() {}
 }
 
 // Define your JSON input here as a raw string for testing or load it from a file
@@ -125,7 +84,7 @@ func TestLoadEmployeesFromInput(t *testing.T) {
 	var employees []model.EmployeeInput
 	var appEmployees []model.Employee
 
-	employeeService.repo.CleanupDatabase() // Assuming this properly cleans the test database
+	employeeService.repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Unmarshal the JSON into the EmployeesInput slice
 	if err := json.Unmarshal([]byte(jsonInput), &employees); err != nil {
 		log.Fatalf("Error unmarshalling JSON: %v", err)
@@ -140,11 +99,11 @@ func TestLoadEmployeesFromInput(t *testing.T) {
 	// fmt.Println(employees)
 	// Load employees and their schedules from the JSON input
 
-	err := employeeService.LoadEmployeesFromInput(employees)
+	err := employeeService.LoadEmployeesFromInput(context.Background(), employees)
 	require.NoError(t, err, "Failed to load employees and schedules from input")
 
 	// Fetch all employees to verify the outcomes
-	appEmployees, err = employeeService.repo.GetEmployees()
+	appEmployees, err = employeeService.repo.GetEmployees(context.Background())
 	require.NoError(t, err, "Failed to retrieve employees")
 
 	// Check if the correct number of employees are loaded
@@ -153,8 +112,8 @@ func TestLoadEmployeesFromInput(t *testing.T) {
 	// Further verification could involve checking the schedules for each employee.
 	// This could include verifying the total number of schedules, specific schedule details, etc.
 	for _, employee := range appEmployees {
-		schedulesA, errA := employeeService.repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "A")
-		schedulesB, errB := employeeService.repo.GetEmployeeWithSchedulesByWeekType(employee.ID, "B")
+		schedulesA, errA := employeeService.repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "A")
+		schedulesB, errB := employeeService.repo.GetEmployeeWithSchedulesByWeekType(context.Background(), employee.ID, "B")
 		require.NoError(t, errA, "Failed to retrieve schedules A for employee")
 		require.NoError(t, errB, "Failed to retrieve schedules B for employee")
 		// Add assertions about the schedules here, such as checking the number of schedules matches expectations
@@ -204,19 +163,19 @@ func TestFetchEmployeeSchedule(t *testing.T) {
 
 	var employees []model.EmployeeInput
 
-	employeeService.repo.CleanupDatabase() // Assuming this properly cleans the test database
+	employeeService.repo.CleanupDatabase(context.Background()) // Assuming this properly cleans the test database
 	// Unmarshal the JSON into the EmployeesInput slice
 	if err := json.Unmarshal([]byte(jsonInput), &employees); err != nil {
 		log.Fatalf("Error unmarshalling JSON: %v", err)
 	}
-	err0 := employeeService.LoadEmployeesFromInput(employees)
+	err0 := employeeService.LoadEmployeesFromInput(context.Background(), employees)
 	require.NoError(t, err0, "Failed to load employees and schedules from input")
-	employeeDB, err1 := employeeService.repo.GetEmployees()
+	employeeDB, err1 := employeeService.repo.GetEmployees(context.Background())
 	require.NoError(t, err1, "Failed to load employees list")
 	id, err2 := util.GetEmployeeIDByName(employeeDB, "Henny Honore")
 	// fmt.Println(id)
 	require.NoError(t, err2, "Failed to load employees list")
-	monthlySchedule, err3 := employeeService.FetchEmployeeSchedule(id, "March", 2024)
+	monthlySchedule, err3 := employeeService.FetchEmployeeSchedule(context.Background(), id, "March", 2024)
 	require.NoError(t, err3, "Failed to fetch the Monthly calendar")
 	areEqual, diff := util.CompareMonthlySchedules(schedulesResult, monthlySchedule)
 	if !areEqual {
@@ -224,3 +183,491 @@ func TestFetchEmployeeSchedule(t *testing.T) {
 		fmt.Println(diff)
 	}
 }
+
+func TestLocationHolidayRegions(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	employeeService.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{
+		model.RegionMetropole: {"2024-04-01": "Lundi de Pâques"},
+		"alsace-moselle":      {"2024-03-29": "Vendredi saint", "2024-04-01": "Lundi de Pâques"},
+	}})
+	require.Error(t, employeeService.UseLocationHolidayRegions(map[string]string{"Strasbourg": "bavaria"}))
+	require.NoError(t, employeeService.UseLocationHolidayRegions(map[string]string{"Strasbourg": "alsace-moselle"}))
+
+	week := model.WeeklyScheduleInput{Friday: []model.ScheduleInput{{Start: "9:00", End: "12:00", Location: "Strasbourg"}, {Start: "14:00", End: "18:00", Location: "Nancy"}}}
+	require.NoError(t, employeeService.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Ines", StartDate: "2024-01-01", Weeks: map[string]model.WeeklyScheduleInput{"A": week, "B": week}}}))
+	employees, err := employeeService.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+
+	// Good Friday is a public holiday in Strasbourg only.
+	for location, holidayName := range map[string]string{"Strasbourg": "Vendredi saint", "Nancy": "", "": ""} {
+		calendar, err := employeeService.FetchEmployeeScheduleAtLocation(ctx, employees[0].ID, "March", 2024, location)
+		require.NoError(t, err)
+		require.Equal(t, "2024-03-29", calendar[28].Date)
+		require.Equal(t, holidayName, calendar[28].HolidayName, location)
+	}
+}
+
+// countingProvider counts the years fetched from the holiday provider it wraps.
+type countingProvider struct {
+	holiday.Provider
+	fetches int
+}
+
+func (p *countingProvider) Holidays(ctx context.Context, region string, year int) (map[string]string, error) {
+	p.fetches++
+	return p.Provider.Holidays(ctx, region, year)
+}
+
+func TestHolidaysStoredByYear(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := repo.WithHolidayRegion(context.Background(), model.RegionMetropole)
+	file := &holiday.File{Regions: map[string]map[string]string{
+		model.RegionMetropole: {"2024-01-01": "Jour de l'an", "2024-04-01": "Lundi de Pâques", "2024-05-01": "Fête du Travail"},
+		"alsace-moselle":      {"2024-03-29": "Vendredi saint"},
+	}}
+	provider := &countingProvider{Provider: file}
+	employeeService.UseHolidayProvider(provider)
+	require.NoError(t, employeeService.UseLocationHolidayRegions(map[string]string{"Strasbourg": "alsace-moselle"}))
+
+	// The whole year is stored on the first read: the next months, with holidays or without, are not fetched.
+	for month, names := range map[time.Month]int{time.April: 1, time.February: 0, time.May: 1} {
+		holidays, err := employeeService.GetHolidaysForMonthYear(ctx, 2024, month)
+		require.NoError(t, err)
+		require.Len(t, holidays, names, month)
+	}
+	require.Equal(t, 1, provider.fetches)
+
+	// Prefetching fetches the year again, for the region of the request and that of Strasbourg, and renames the
+	// holidays stored.
+	file.Regions[model.RegionMetropole]["2024-04-01"] = "Lundi de Pâques (Easter Monday)"
+	prefetch, err := employeeService.PrefetchHolidays(ctx, 2024)
+	require.NoError(t, err)
+	require.Equal(t, &HolidayPrefetch{Year: 2024, Regions: map[string]int{model.RegionMetropole: 3, "alsace-moselle": 1}}, prefetch)
+	require.Equal(t, 3, provider.fetches)
+	holidays, err := employeeService.GetHolidaysForMonthYear(ctx, 2024, time.April)
+	require.NoError(t, err)
+	require.Equal(t, "Lundi de Pâques (Easter Monday)", holidays[0].HolidayName)
+	holidays, err = employeeService.GetHolidaysForMonthYear(repo.WithHolidayRegion(ctx, "alsace-moselle"), 2024, time.March)
+	require.NoError(t, err)
+	require.Len(t, holidays, 1)
+	require.Equal(t, 3, provider.fetches)
+
+	_, err = employeeService.PrefetchHolidays(ctx, 0)
+	require.Equal(t, apierror.CodeYearInvalid, apierror.CodeOf(err))
+}
+
+// readOnlyDatabase is a repository rejecting the writes of holidays, as the database of a read-only replica does.
+type readOnlyDatabase struct {
+	repo.Repository
+}
+
+func (readOnlyDatabase) HolidayUpsert(context.Context, []model.Holiday) error {
+	return errors.New("cannot execute INSERT in a read-only transaction")
+}
+
+func TestHolidaysUnstoredReadOnly(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	provider := &countingProvider{Provider: &holiday.File{Regions: map[string]map[string]string{"FR": {"2024-06-10": "Lundi de Pentecôte"}}}}
+	svc.UseHolidayProvider(provider)
+	svc.repo = readOnlyDatabase{svc.repo}
+	svc.UseReadOnly()
+
+	// The holidays are fetched on every read and served, never stored.
+	for _, month := range []time.Month{time.June, time.July} {
+		_, err := svc.GetHolidaysForMonthYear(ctx, 2024, month)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, provider.fetches)
+	june, err := svc.FetchEmployeeSchedule(ctx, ids["Alice"], "June", 2024)
+	require.NoError(t, err)
+	require.Equal(t, "Lundi de Pentecôte", june[9].HolidayName)
+	stored, err := svc.repo.HolidayFindByYear(repo.WithHolidayRegion(ctx, "FR"), 2024)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+}
+
+func TestCalculateMonthlyHoursHolidayPay(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	require.Error(t, employeeService.UseHolidayPay(HolidayPay{WorkedMultiplier: 0.5}))
+	require.Error(t, employeeService.UseHolidayPay(HolidayPay{WorkedMultiplier: 2, PaidIfNotWorked: []string{"05-32"}}))
+	require.NoError(t, employeeService.UseHolidayPay(HolidayPay{WorkedMultiplier: 2, PaidIfNotWorked: []string{"05-01"}}))
+
+	morning := []model.TimeSlot{{Start: "09:00", End: "12:00"}}
+	hours, err := employeeService.CalculateMonthlyHours([]model.MonthlySchedule{
+		{Date: "2024-04-30", TimeSlots: morning},
+		// May Day is off and paid, Ascension Day is worked at the premium rate.
+		{Date: "2024-05-01", HolidayName: "1er mai", HolidaySource: model.HolidayPublic, TimeSlots: morning},
+		{Date: "2024-05-09", HolidayName: "Ascension", HolidaySource: model.HolidayPublic, TimeSlots: morning},
+		// A closure day of the company is no public holiday.
+		{Date: "2024-05-10", HolidayName: "inventory", HolidaySource: model.HolidayCompany, TimeSlots: morning},
+		{Date: "2024-05-13", Leave: &model.Leave{WithoutPay: true}, TimeSlots: morning},
+		{Date: "2024-05-14", Leave: &model.Leave{}, TimeSlots: morning},
+	})
+	require.NoError(t, err)
+	require.Equal(t, MonthlyHours{Worked: 9, Regular: 9, HolidayPremium: 3, Unpaid: 3, Paid: 15}, hours)
+
+	// Working May Day takes an override.
+	hours, err = employeeService.CalculateMonthlyHours([]model.MonthlySchedule{
+		{Date: "2024-05-01", HolidayName: "1er mai", HolidaySource: model.HolidayPublic, Overridden: true, TimeSlots: morning}})
+	require.NoError(t, err)
+	require.Equal(t, MonthlyHours{Worked: 3, HolidayPremium: 3, Paid: 6}, hours)
+}
+
+func TestTimeZones(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	employeeService.UseTimeZones(nil, map[string]*time.Location{"Paris": paris})
+	require.Equal(t, time.UTC, employeeService.TimeZone("Lyon"))
+	require.Equal(t, paris, employeeService.TimeZone("Paris"))
+
+	// The night slots at Paris lose an hour to the spring change and gain one at the autumn change.
+	night := []model.TimeSlot{{Start: "01:00", End: "04:00", Location: "Paris"}}
+	for date, want := range map[string]float64{"2024-03-30": 3, "2024-03-31": 2, "2024-10-27": 4} {
+		hours, err := employeeService.CalculateMonthlyHours([]model.MonthlySchedule{{Date: date, TimeSlots: night}})
+		require.NoError(t, err)
+		require.Equal(t, want, hours.Worked, date)
+	}
+	hours, err := employeeService.CalculateMonthlyHours([]model.MonthlySchedule{
+		{Date: "2024-03-31", TimeSlots: []model.TimeSlot{{Start: "01:00", End: "04:00", Location: "Lyon"}}}})
+	require.NoError(t, err)
+	require.Equal(t, 3.0, hours.Worked, "Lyon has no zone of its own and the deployment is in UTC")
+
+	// A shift started at 23:30 UTC is clocked on the next day in Paris.
+	punchIn := time.Date(2024, time.June, 3, 23, 30, 0, 0, time.UTC)
+	punches := []model.TimeEntry{{Kind: model.PunchIn, PunchedAt: punchIn}, {Kind: model.PunchOut, PunchedAt: punchIn.Add(4 * time.Hour)}}
+	first, last := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC)
+	clocked, _ := pairPunches(punches, first, last, paris)
+	require.Equal(t, map[string]float64{"2024-06-04": 4}, clocked)
+	clocked, _ = pairPunches(punches, first, last, time.UTC)
+	require.Equal(t, map[string]float64{"2024-06-03": 4}, clocked)
+}
+
+func TestOvernightSlots(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	ctx := context.Background()
+	employeeService.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {"2024-06-08": "Fête du village"}}})
+
+	overlapping := model.WeeklyScheduleInput{Friday: []model.ScheduleInput{{Start: "22:00", End: "06:00"}, {Start: "23:00", End: "01:00"}}}
+	require.Error(t, employeeService.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Ines", StartDate: "2024-05-06", Weeks: map[string]model.WeeklyScheduleInput{"A": overlapping}}}))
+	week := model.WeeklyScheduleInput{Friday: []model.ScheduleInput{{Start: "22:00", End: "06:00", Task: "bakery"}}}
+	require.NoError(t, employeeService.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Ines", StartDate: "2024-05-06", Weeks: map[string]model.WeeklyScheduleInput{"A": week, "B": week}}}))
+	employees, err := employeeService.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	id, err := util.GetEmployeeIDByName(employees, "Ines")
+	require.NoError(t, err)
+	stored, err := employeeService.repo.GetSchedule(ctx, id, "A")
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.True(t, stored[0].CrossesMidnight)
+
+	// The night of Friday May 31 ends on the first day of June.
+	calendar, err := employeeService.FetchEmployeeSchedule(ctx, id, "June", 2024)
+	require.NoError(t, err)
+	require.Equal(t, []model.TimeSlot{{Start: "00:00", End: "06:00", Task: "bakery", Continued: true}}, calendar[0].TimeSlots)
+	require.Equal(t, []model.TimeSlot{{Start: "22:00", End: "00:00", Task: "bakery", CrossesMidnight: true}}, calendar[6].TimeSlots)
+	require.Equal(t, []model.TimeSlot{{Start: "00:00", End: "06:00", Task: "bakery", Continued: true}}, calendar[7].TimeSlots)
+	require.Empty(t, calendar[29].TimeSlots, "The night of Friday June 28 ends on Saturday")
+
+	// The hours after midnight count on the Saturday, a public holiday on June 8.
+	hours, err := employeeService.CalculateMonthlyHours(calendar)
+	require.NoError(t, err)
+	require.Equal(t, MonthlyHours{Worked: 38, Regular: 32, HolidayPremium: 6, Paid: 38}, hours)
+}
+
+func TestDetectLocationConflicts(t *testing.T) {
+	employeeService, cleanup := setupTestService(t)
+	defer cleanup()
+	employeeService.repo.CleanupDatabase(context.Background())
+
+	input := []model.EmployeeInput{{
+		Name:      "Shared Employee",
+		StartDate: "2024-01-08",
+		Weeks: map[string]model.WeeklyScheduleInput{
+			"A": {
+				Monday:  []model.ScheduleInput{{Start: "9:00", End: "13:00", Location: "Centre"}},
+				Tuesday: []model.ScheduleInput{{Start: "9:00", End: "12:00", Location: "Centre"}, {Start: "13:00", End: "17:00", Location: "Gare"}},
+			},
+		},
+	}}
+	require.NoError(t, employeeService.LoadEmployeesFromInput(context.Background(), input))
+
+	employees, err := employeeService.repo.GetEmployees(context.Background())
+	require.NoError(t, err)
+	id, err := util.GetEmployeeIDByName(employees, "Shared Employee")
+	require.NoError(t, err)
+	noon, err := time.Parse("15:04", "12:00")
+	require.NoError(t, err)
+	afternoon := model.Schedule{EmployeeID: id, WeekType: "A", DayName: "Monday",
+		StartTime: model.CustomTime{Time: noon}, EndTime: model.CustomTime{Time: noon.Add(5 * time.Hour)}, Location: "Gare"}
+
+	// No write of a slot lets the employee be in two places at once.
+	tuesday, err := employeeService.ListEmployeeSchedules(context.Background(), id, ScheduleFilter{WeekType: "A", DayName: "Tuesday"})
+	require.NoError(t, err)
+	require.Len(t, tuesday, 2)
+	_, err = employeeService.UpdateSchedule(context.Background(), tuesday[1].ID, afternoon, time.Time{})
+	require.Equal(t, apierror.CodeScheduleOverlap, apierror.CodeOf(err))
+	monday, err := employeeService.ListEmployeeSchedules(context.Background(), id, ScheduleFilter{WeekType: "A", DayName: "Monday"})
+	require.NoError(t, err)
+	_, err = employeeService.ReplaceEmployeeSchedules(context.Background(), id, ScheduleFilter{WeekType: "A", DayName: "Monday"},
+		[]model.Schedule{monday[0], afternoon}, time.Time{})
+	require.Equal(t, apierror.CodeScheduleOverlap, apierror.CodeOf(err))
+
+	// A role template can still give the employee a slot elsewhere while it works at Centre.
+	template, err := employeeService.CreateRoleTemplate(context.Background(), model.RoleTemplate{Name: "Gare afternoons", Slots: []model.RoleTemplateSlot{
+		{WeekType: "A", DayName: "Monday", StartTime: afternoon.StartTime, EndTime: afternoon.EndTime, Location: "Gare"}}})
+	require.NoError(t, err)
+	require.NoError(t, employeeService.AssignRoleTemplate(context.Background(), id, &template.ID))
+	_, err = employeeService.AddScheduleDelta(context.Background(), id, model.ScheduleDelta{Action: model.DeltaAdd, WeekType: "A", DayName: "Monday",
+		StartTime: monday[0].StartTime, EndTime: afternoon.StartTime, Location: "Gare"})
+	require.Equal(t, apierror.CodeScheduleOverlap, apierror.CodeOf(err))
+
+	conflicts, err := employeeService.DetectLocationConflicts(context.Background(), id)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1, "Only the overlapping Monday slots should conflict")
+	require.Equal(t, "Monday", conflicts[0].DayName)
+
+	centre, err := employeeService.FetchEmployeeScheduleAtLocation(context.Background(), id, "January", 2024, "Centre")
+	require.NoError(t, err)
+	all, err := employeeService.FetchEmployeeSchedule(context.Background(), id, "January", 2024)
+	require.NoError(t, err)
+	// On Monday the 8th the employee works at both locations: the calendar of Centre only lists its slot.
+	require.Len(t, all[7].TimeSlots, 2)
+	require.Len(t, centre[7].TimeSlots, 1)
+	require.Equal(t, "Centre", centre[7].TimeSlots[0].Location)
+	centreHours, err := employeeService.CalculateMonthlyHours(centre)
+	require.NoError(t, err)
+	allHours, err := employeeService.CalculateMonthlyHours(all)
+	require.NoError(t, err)
+	require.Less(t, centreHours.Worked, allHours.Worked, "Hours across all locations should exceed a single location's")
+}
+
+func TestResolveSchedulesAppliesTemplateAndDeltas(t *testing.T) {
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	employee := &model.Employee{
+		ID:        1,
+		Schedules: []model.Schedule{{WeekType: "A", DayName: "Monday", StartTime: at(9), EndTime: at(12)}},
+		RoleTemplate: &model.RoleTemplate{Slots: []model.RoleTemplateSlot{
+			{WeekType: "A", DayName: "Saturday", StartTime: at(9), EndTime: at(12)},
+			{WeekType: "A", DayName: "Saturday", StartTime: at(13), EndTime: at(18)},
+		}},
+		Deltas: []model.ScheduleDelta{
+			{Action: model.DeltaRemove, WeekType: "A", DayName: "Saturday", StartTime: at(13), EndTime: at(18)},
+			{Action: model.DeltaAdd, WeekType: "A", DayName: "Sunday", StartTime: at(10), EndTime: at(12)},
+		},
+	}
+
+	resolved := resolveSchedules(employee)
+	require.Len(t, resolved, 3, "Own slot, remaining inherited slot and added slot expected")
+	days := make([]string, 0, len(resolved))
+	for _, schedule := range resolved {
+		days = append(days, schedule.DayName+" "+schedule.StartTime.Format("15:04"))
+	}
+	require.ElementsMatch(t, []string{"Monday 09:00", "Saturday 09:00", "Sunday 10:00"}, days)
+}
+
+func TestParseWeeklySchedulesReportsEveryInvalidSlot(t *testing.T) {
+	week := model.WeeklyScheduleInput{
+		Monday:   []model.ScheduleInput{{Start: "9:00", End: "12:00"}},
+		Thursday: []model.ScheduleInput{{Start: "25:00", End: "12:00"}, {Start: "14:00", End: "14:00"}},
+	}
+	schedules, invalid := parseWeeklySchedules("Alice", "A", week, nil, nil)
+	require.Len(t, schedules, 1, "Only the valid Monday slot should parse")
+	require.Len(t, invalid, 2, "Both bad Thursday slots should be reported, not only the first")
+	require.Equal(t, "Alice.A.Thursday[0].start", invalid[0].Name)
+	require.Equal(t, "Alice.A.Thursday[1]", invalid[1].Name)
+}
+
+func TestParseWeeklySchedulesRejectsOverlaps(t *testing.T) {
+	week := model.WeeklyScheduleInput{
+		Monday: []model.ScheduleInput{{Start: "9:00", End: "13:00"}, {Start: "12:00", End: "17:00", Location: "Gare"}},
+		Friday: []model.ScheduleInput{{Start: "9:00", End: "12:00"}, {Start: "12:00", End: "12:00"}, {Start: "12:00", End: "17:00"}},
+	}
+	schedules, invalid := parseWeeklySchedules("Alice", "B", week, nil, nil)
+	require.Len(t, schedules, 3, "Adjacent slots do not overlap")
+	require.Len(t, invalid, 2)
+	require.Equal(t, "Alice.B.Monday[1]", invalid[0].Name)
+	require.Equal(t, apierror.CodeScheduleOverlap, invalid[0].Code)
+	require.Equal(t, "12:00-17:00 overlaps Alice.B.Monday[0] 09:00-13:00 at another location", invalid[0].Reason)
+	require.Equal(t, "Alice.B.Friday[1]", invalid[1].Name)
+	require.Equal(t, apierror.CodeTimeRangeInvalid, invalid[1].Code, "Zero-length slots are rejected")
+}
+
+func TestParseWeeklySchedulesRejectsOvernightOverlaps(t *testing.T) {
+	weeks := []string{"A", "B"}
+	a, invalid := parseWeeklySchedules("Alice", "A", model.WeeklyScheduleInput{
+		Monday:  []model.ScheduleInput{{Start: "07:00", End: "09:00"}, {Start: "22:00", End: "06:00"}},
+		Tuesday: []model.ScheduleInput{{Start: "04:00", End: "08:00"}, {Start: "06:00", End: "10:00"}},
+		Sunday:  []model.ScheduleInput{{Start: "22:00", End: "06:00"}},
+	}, weeks, nil)
+	require.Len(t, a, 4, "A slot may start when the night before ends")
+	require.Len(t, invalid, 1)
+	require.Equal(t, "Alice.A.Tuesday[0]", invalid[0].Name)
+	require.Equal(t, apierror.CodeScheduleOverlap, invalid[0].Code)
+
+	// Sunday of week A is followed by Monday of week B, and Sunday of week B by Monday of week A.
+	b, invalid := parseWeeklySchedules("Alice", "B", model.WeeklyScheduleInput{
+		Monday: []model.ScheduleInput{{Start: "05:00", End: "09:00"}},
+		Sunday: []model.ScheduleInput{{Start: "23:00", End: "08:00"}},
+	}, weeks, a)
+	require.Empty(t, b)
+	require.Len(t, invalid, 2)
+	require.Equal(t, "Alice.B.Monday[0]", invalid[0].Name)
+	require.Equal(t, "05:00-09:00 overlaps the 22:00-06:00 slot of Sunday of week A", invalid[0].Reason)
+	require.Equal(t, "Alice.B.Sunday[0]", invalid[1].Name)
+	require.Equal(t, "23:00-08:00 overlaps the 07:00-09:00 slot of Monday of week A", invalid[1].Reason)
+}
+
+func TestUpdateScheduleRejectsOvernightOverlaps(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	slots, err := svc.repo.GetSchedule(ctx, ids["Bob"], "A")
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+
+	// Moving Bob's Monday of week A to a night from Sunday running until 10:00 overlaps his Monday of week B.
+	night := model.Schedule{EmployeeID: ids["Bob"], WeekType: "A", DayName: "Sunday", StartTime: at(22), EndTime: at(10)}
+	_, err = svc.UpdateSchedule(ctx, slots[0].ID, night, time.Time{})
+	require.Equal(t, apierror.CodeScheduleOverlap, apierror.CodeOf(err))
+	night.EndTime = at(9)
+	updated, err := svc.UpdateSchedule(ctx, slots[0].ID, night, time.Time{})
+	require.NoError(t, err)
+	require.True(t, updated.CrossesMidnight)
+}
+
+func TestFormatExportSlots(t *testing.T) {
+	slots := []model.TimeSlot{{Start: "09:00", End: "12:00", Task: "till"}, {Start: "13:00", End: "17:30", Location: "Gare"}}
+	require.Equal(t, "09:00-12:00 (till); 13:00-17:30 (@Gare)", formatExportSlots(slots))
+	require.Equal(t, "", formatExportSlots(nil))
+}
+
+func TestCoveredBy(t *testing.T) {
+	slot := func(start, end string) model.Schedule {
+		s, _ := time.Parse("15:04", start)
+		e, _ := time.Parse("15:04", end)
+		return model.Schedule{StartTime: model.CustomTime{Time: s}, EndTime: model.CustomTime{Time: e}}
+	}
+	mentor := []model.Schedule{slot("09:00", "12:00"), slot("12:00", "15:00"), slot("16:00", "19:00")}
+	require.True(t, coveredBy(slot("10:00", "14:00"), mentor), "Adjacent mentor slots cover the gap-free range")
+	require.False(t, coveredBy(slot("14:00", "17:00"), mentor), "The mentor is away from 15:00 to 16:00")
+	require.False(t, coveredBy(slot("08:00", "10:00"), mentor))
+	require.False(t, coveredBy(slot("18:00", "20:00"), mentor))
+	require.False(t, coveredBy(slot("10:00", "11:00"), nil))
+}
+
+func TestLeaveAbsences(t *testing.T) {
+	day := func(d int, description string, withoutPay bool) model.EmployeeHoliday {
+		return model.EmployeeHoliday{HolidayDate: time.Date(2024, time.April, d, 0, 0, 0, 0, time.UTC), Description: description, WithoutPay: withoutPay}
+	}
+	absences := leaveAbsences([]model.EmployeeHoliday{
+		day(8, "sans solde", true), day(9, "sans solde", true),
+		day(10, "congés payés", false),
+		day(12, "sans solde", true),
+	})
+	require.Len(t, absences, 3)
+	require.Equal(t, 8, absences[0].Start.Day())
+	require.Equal(t, 9, absences[0].End.Day())
+	require.False(t, absences[0].Paid)
+	require.True(t, absences[1].Paid)
+	require.Equal(t, 12, absences[2].Start.Day())
+}
+
+func TestGetEmployeeNotFound(t *testing.T) {
+	svc := NewEmployeeService(&repo.RepositoryMock{
+		GetEmployeeByIDFunc: func(_ context.Context, id uint, _ *model.Employee) error {
+			if id == 7 {
+				return gorm.ErrRecordNotFound
+			}
+			return errors.New("connection refused")
+		},
+	})
+	_, err := svc.GetEmployee(context.Background(), 7)
+	require.Equal(t, apierror.CodeEmployeeNotFound, apierror.CodeOf(err))
+	_, err = svc.GetEmployee(context.Background(), 8)
+	require.EqualError(t, err, "connection refused")
+}
+
+func TestGetEmployeeDetail(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice := ids["Alice"]
+	_, err := svc.RequestLeave(ctx, alice, model.LeaveInput{From: "2024-06-10"})
+	require.NoError(t, err)
+
+	detail, err := svc.GetEmployeeDetail(ctx, alice, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", detail.Name)
+	require.Empty(t, detail.Schedules, "Nothing is embedded unless asked")
+	require.Empty(t, detail.Leave)
+	require.False(t, detail.Unscheduled, "Alice has slots of her own, even if they are not embedded")
+
+	detail, err = svc.GetEmployeeDetail(ctx, alice, []string{"schedules", "leave"})
+	require.NoError(t, err)
+	require.Len(t, detail.Schedules, 4)
+	require.Len(t, detail.Leave, 1)
+	require.Equal(t, model.LeavePending, detail.Leave[0].Status, "Leave of every status is embedded")
+	body, err := json.Marshal(detail)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"leave":[{`)
+	require.Contains(t, string(body), `"name":"Alice"`)
+
+	// An employee hired before its weeks are imported is pending a template.
+	require.NoError(t, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{{Name: "Zoé", StartDate: "2024-06-17"}}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	detail, err = svc.GetEmployeeDetail(ctx, employees[len(employees)-1].ID, []string{"schedules"})
+	require.NoError(t, err)
+	require.Equal(t, "Zoé", detail.Name)
+	require.True(t, detail.Unscheduled)
+	require.Empty(t, detail.Schedules)
+
+	_, err = svc.GetEmployeeDetail(ctx, alice, []string{"overrides"})
+	require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err))
+	require.NoError(t, svc.ArchiveEmployee(ctx, ids["Bob"]))
+	_, err = svc.GetEmployeeDetail(ctx, ids["Bob"], nil)
+	require.Equal(t, apierror.CodeEmployeeNotFound, apierror.CodeOf(err), "Archived employees are left out")
+}
+
+func TestSearchEmployees(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	found, err := svc.SearchEmployees(ctx, " ali ", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, ids["Alice"], found[0].ID)
+
+	_, err = svc.SearchEmployees(ctx, "  ", 10)
+	require.Equal(t, apierror.CodeFieldRequired, apierror.CodeOf(err))
+	for _, limit := range []int{0, maxSearchResults + 1} {
+		_, err = svc.SearchEmployees(ctx, "ali", limit)
+		require.Equal(t, apierror.CodeValidationFailed, apierror.CodeOf(err), limit)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	svc := NewEmployeeService(&repo.RepositoryMock{
+		MigrationStatusFunc: func(context.Context) ([]model.MigrationStatus, error) {
+			return []model.MigrationStatus{{ID: "0001_baseline", Applied: true}, {ID: "0002_change_notify", Applied: true}, {ID: "0003_rotation_calendar"}}, nil
+		},
+	})
+	report, err := svc.MigrationStatus(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "0002_change_notify", report.Current)
+	require.Equal(t, 1, report.Pending)
+	require.Len(t, report.Migrations, 3)
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+)
+
+// UseScheduleSnapshots makes calendar reads (A/B weeks, monthly schedules and exports) load the schedule
+// snapshot stored with each employee instead of its slot, template and delta rows. Every snapshot is rebuilt
+// first so that rows written while the option was off are taken into account.
+func (s *EmployeeService) UseScheduleSnapshots(ctx context.Context) error {
+	if err := s.RebuildScheduleSnapshots(ctx); err != nil {
+		return err
+	}
+	s.snapshotReads = true
+	return nil
+}
+
+// UseStoredScheduleSnapshots makes calendar reads load the stored schedule snapshots without rebuilding them,
+// for read-only replicas whose primary keeps the snapshots up to date.
+func (s *EmployeeService) UseStoredScheduleSnapshots() {
+	s.snapshotReads = true
+}
+
+// RebuildScheduleSnapshots recomputes the schedule snapshot of every employee.
+func (s *EmployeeService) RebuildScheduleSnapshots(ctx context.Context) error {
+	employees, err := s.repo.GetEmployeesWithSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range employees {
+		if err := s.repo.SaveScheduleSnapshot(ctx, employees[i].ID, snapshotOf(&employees[i])); err != nil {
+			return fmt.Errorf("failed to save the schedule snapshot of employee %d: %w", employees[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// saveSnapshots recomputes the schedule snapshots of the given employees through r, within the transaction
+// changing their calendar: a failure rolls the change back with it.
+func saveSnapshots(ctx context.Context, r repo.Repository, employeeIDs ...uint) error {
+	for _, id := range employeeIDs {
+		employee, err := r.GetEmployeeWithSchedules(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := r.SaveScheduleSnapshot(ctx, id, snapshotOf(employee)); err != nil {
+			return fmt.Errorf("failed to save the schedule snapshot of employee %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// syncSnapshots recomputes the schedule snapshots of the given employees after their calendar changed. The
+// change is already committed, so a failure is logged and the snapshot cleared: reads then fall back to the
+// rows until the next successful sync.
+func (s *EmployeeService) syncSnapshots(ctx context.Context, employeeIDs ...uint) {
+	if len(employeeIDs) > 0 {
+		s.calendars.invalidate(employeeIDs...)
+	}
+	for _, id := range employeeIDs {
+		employee, err := s.repo.GetEmployeeWithSchedules(ctx, id)
+		if err == nil {
+			err = s.repo.SaveScheduleSnapshot(ctx, id, snapshotOf(employee))
+		}
+		if err != nil {
+			reqlog.From(ctx).Errorf("Failed to sync the schedule snapshot of employee %d: %v", id, err)
+			if err := s.repo.SaveScheduleSnapshot(context.WithoutCancel(ctx), id, nil); err != nil {
+				reqlog.From(ctx).Errorf("Failed to clear the stale schedule snapshot of employee %d: %v", id, err)
+			}
+		}
+	}
+}
+
+// snapshotOf builds the snapshot of an employee loaded with its schedules, role template and deltas.
+func snapshotOf(employee *model.Employee) *model.ScheduleSnapshot {
+	schedules := resolveSchedules(employee)
+	for i := range schedules {
+		schedules[i].EmployeeID = employee.ID
+	}
+	return &model.ScheduleSnapshot{LastModified: scheduleLastModified(employee), Schedules: schedules}
+}
+
+// employeeCalendar returns an employee with its resolved slots in Schedules, read from its snapshot when
+// snapshot reads are enabled and the snapshot exists, or else from the rows, and its schedule versions.
+func (s *EmployeeService) employeeCalendar(ctx context.Context, employeeID uint) (*model.Employee, error) {
+	if s.snapshotReads {
+		var employee model.Employee
+		if err := s.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+			return nil, err
+		}
+		if employee.ScheduleSnapshot != nil {
+			employee.Schedules = employee.ScheduleSnapshot.Schedules
+			versions, err := s.repo.ScheduleVersionList(ctx, employeeID)
+			if err != nil {
+				return nil, err
+			}
+			employee.ScheduleVersions = versions
+			return &employee, nil
+		}
+	}
+	employee, err := s.employeeWithSchedules(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	employee.Schedules = resolveSchedules(employee)
+	return employee, nil
+}
+
+// employeeWithSchedules loads an employee with its slots, role template and deltas. A relation that failed
+// to load is a 503 CALENDAR_INCOMPLETE rather than an internal error: the employee exists, and answering
+// with the relation empty would show days off that are not.
+func (s *EmployeeService) employeeWithSchedules(ctx context.Context, employeeID uint) (*model.Employee, error) {
+	employee, err := s.repo.GetEmployeeWithSchedules(ctx, employeeID)
+	var partial *repo.PreloadError
+	if errors.As(err, &partial) && !errors.Is(err, context.DeadlineExceeded) {
+		return nil, apierror.Unavailable(fmt.Sprintf("the %s of employee %d could not be loaded", partial.Relation, employeeID), err).
+			WithCode(apierror.CodeCalendarIncomplete)
+	}
+	return employee, err
+}
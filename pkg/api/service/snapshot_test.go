@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/db/repo/repotest"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+)
+
+// newSnapshotService returns a service on a fresh in-memory database holding one employee who
+// inherits a role template, has a delta and a full A/B week of own slots.
+func newSnapshotService(tb testing.TB) (*EmployeeService, uint) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(tb))
+	ctx := context.Background()
+
+	at := func(clock string) model.CustomTime {
+		t, err := time.Parse("15:04", clock)
+		require.NoError(tb, err)
+		return model.CustomTime{Time: t}
+	}
+	template, err := svc.CreateRoleTemplate(ctx, model.RoleTemplate{Name: "weekend seller", Slots: []model.RoleTemplateSlot{
+		{WeekType: "A", DayName: "Sunday", StartTime: at("10:00"), EndTime: at("13:00")},
+		{WeekType: "B", DayName: "Sunday", StartTime: at("10:00"), EndTime: at("13:00")},
+	}})
+	require.NoError(tb, err)
+
+	week := model.WeeklyScheduleInput{}
+	days := []*[]model.ScheduleInput{&week.Monday, &week.Tuesday, &week.Wednesday, &week.Thursday, &week.Friday, &week.Saturday}
+	for _, day := range days {
+		*day = []model.ScheduleInput{{Start: "9:00", End: "12:00"}, {Start: "13:00", End: "15:00", Task: "lab"}, {Start: "15:00", End: "18:00"}}
+	}
+	require.NoError(tb, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{{
+		Name: "Delphine", StartDate: "2024-01-08", RoleTemplate: template.Name,
+		Weeks: map[string]model.WeeklyScheduleInput{"A": week, "B": week},
+	}}))
+	employees, err := svc.FetchAllEmployees(ctx, EmployeesActive)
+	require.NoError(tb, err)
+	_, err = svc.AddScheduleDelta(ctx, employees[0].ID, model.ScheduleDelta{
+		Action: model.DeltaRemove, WeekType: "B", DayName: "Sunday", StartTime: at("10:00"), EndTime: at("13:00")})
+	require.NoError(tb, err)
+	return svc, employees[0].ID
+}
+
+func TestScheduleSnapshotMatchesRows(t *testing.T) {
+	svc, id := newSnapshotService(t)
+	ctx := context.Background()
+
+	fromRows, err := svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{})
+	require.NoError(t, err)
+	rowsModified, err := svc.EmployeeScheduleLastModified(ctx, id)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.UseScheduleSnapshots(ctx))
+	fromSnapshot, err := svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, fromRows, fromSnapshot)
+	snapshotModified, err := svc.EmployeeScheduleLastModified(ctx, id)
+	require.NoError(t, err)
+	require.True(t, rowsModified.Equal(snapshotModified))
+
+	// Writes keep the snapshot in sync.
+	weeks, err := svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, weeks[0].Days[0].TimeSlots, 3)
+	schedules, err := svc.repo.GetSchedule(ctx, id, "A")
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteSchedule(ctx, schedules[0].ID, time.Time{}))
+	weeks, err = svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, weeks[0].Days[0].TimeSlots, 2)
+
+	svc.snapshotReads = false
+	fromRows, err = svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, fromRows, weeks)
+}
+
+// BenchmarkWeeksAB compares reading the A/B weeks of an employee from its slot rows and from its snapshot.
+func BenchmarkWeeksAB(b *testing.B) {
+	for _, snapshots := range []bool{false, true} {
+		name := "rows"
+		if snapshots {
+			name = "snapshot"
+		}
+		b.Run(name, func(b *testing.B) {
+			svc, id := newSnapshotService(b)
+			ctx := context.Background()
+			if snapshots {
+				require.NoError(b, svc.UseScheduleSnapshots(ctx))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.EmployeeScheduleLastModified(ctx, id); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := svc.FetchEmployeeFormattedABWeek(ctx, id, "", time.Time{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEmployeeWithSchedulesReportsIncompleteCalendars(t *testing.T) {
+	var failure error
+	svc := NewEmployeeService(&repo.RepositoryMock{
+		GetEmployeeWithSchedulesFunc: func(_ context.Context, id uint) (*model.Employee, error) {
+			return nil, failure
+		},
+	})
+	ctx := context.Background()
+
+	failure = &repo.PreloadError{EmployeeID: 7, Relation: "deltas", Err: errors.New("connection reset")}
+	_, err := svc.employeeWithSchedules(ctx, 7)
+	require.Equal(t, apierror.CodeCalendarIncomplete, apierror.CodeOf(err))
+	require.ErrorContains(t, err, "the deltas of employee 7 could not be loaded")
+
+	// A request running out of time is a timeout, not an incomplete calendar.
+	failure = &repo.PreloadError{EmployeeID: 7, Relation: "schedules", Err: context.DeadlineExceeded}
+	_, err = svc.employeeWithSchedules(ctx, 7)
+	require.NotEqual(t, apierror.CodeCalendarIncomplete, apierror.CodeOf(err))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
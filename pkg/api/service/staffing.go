@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"time"
+	"unicode/utf8"
+)
+
+// CreateStaffingRequirement validates and stores a staffing requirement.
+func (s *EmployeeService) CreateStaffingRequirement(ctx context.Context, requirement model.StaffingRequirement) (*model.StaffingRequirement, error) {
+	if findDayIndex(requirement.DayName, daysOrder) == -1 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid dayName: %s", requirement.DayName)).WithCode(apierror.CodeDayNameInvalid)
+	}
+	if err := validateTimeRange(requirement.StartTime, requirement.EndTime); err != nil {
+		return nil, err
+	}
+	if requirement.Headcount < 1 {
+		return nil, apierror.Validation(fmt.Sprintf("headcount must be at least 1, got: %d", requirement.Headcount))
+	}
+	if utf8.RuneCountInString(requirement.Location) > 100 {
+		return nil, apierror.Validation("location must be at most 100 characters long")
+	}
+
+	requirement.ID, requirement.UUID = 0, ""
+	requirement.CreatedAt, requirement.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.StaffingRequirementCreate(ctx, &requirement); err != nil {
+		return nil, err
+	}
+	return &requirement, nil
+}
+
+// ListStaffingRequirements returns every staffing requirement.
+func (s *EmployeeService) ListStaffingRequirements(ctx context.Context) ([]model.StaffingRequirement, error) {
+	return s.repo.StaffingRequirementList(ctx)
+}
+
+// DeleteStaffingRequirement removes a staffing requirement.
+func (s *EmployeeService) DeleteStaffingRequirement(ctx context.Context, id uint) error {
+	if err := s.repo.StaffingRequirementDelete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("staffing requirement %d not found", id)).WithCode(apierror.CodeStaffingNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// CoverageGap is a time range of a date during which fewer employees are scheduled than a staffing
+// requirement asks for.
+type CoverageGap struct {
+	Date          string `json:"date"`
+	DayName       string `json:"dayName"`
+	HolidayName   string `json:"holidayName,omitempty"`
+	RequirementID uint   `json:"requirementId"`
+	Location      string `json:"location"`
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	Required      int    `json:"required"`
+	Scheduled     int    `json:"scheduled"`
+}
+
+// CoverageGaps checks the staffing requirements against the calendars of the month, overrides applied and
+// employees on approved leave left out, as DailyCoverage counts them. The 15-minute intervals of a
+// requirement that fall short are merged into one gap while the number of employees scheduled stays the
+// same. Gaps are ordered by date, then in the order of the requirements.
+func (s *EmployeeService) CoverageGaps(ctx context.Context, month string, year int) ([]CoverageGap, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	gaps := make([]CoverageGap, 0)
+	requirements, err := s.repo.StaffingRequirementList(ctx)
+	if err != nil || len(requirements) == 0 {
+		return gaps, err
+	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	employees, rotations, err := s.teamCalendars(ctx, firstDayOfMonth, lastDayOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	holidays := s.holidayNames(ctx, year, time.Month(monthNum))
+
+	for day := firstDayOfMonth; !day.After(lastDayOfMonth); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		date := day.Format("2006-01-02")
+		byLocation := map[string][]int{}
+		for _, requirement := range requirements {
+			if requirement.DayName != day.Weekday().String() {
+				continue
+			}
+			counts, ok := byLocation[requirement.Location]
+			if !ok {
+				if counts, err = intervalCounts(employees, rotations, day, requirement.Location); err != nil {
+					return nil, err
+				}
+				byLocation[requirement.Location] = counts
+			}
+
+			gaps = append(gaps, shortfalls(day, holidays[date].Name, requirement, counts)...)
+		}
+	}
+	return gaps, nil
+}
+
+// shortfalls returns the gaps of requirement on day given the employees scheduled in every interval of the
+// day, merging the consecutive intervals short of the same number of employees.
+func shortfalls(day time.Time, holidayName string, requirement model.StaffingRequirement, counts []int) []CoverageGap {
+	var gaps []CoverageGap
+	start, end := clockOf(requirement.StartTime), clockOf(requirement.EndTime)
+	for n := int(start / coverageInterval); time.Duration(n)*coverageInterval < end; n++ {
+		from := max(time.Duration(n)*coverageInterval, start)
+		until := min(time.Duration(n+1)*coverageInterval, end)
+		if counts[n] >= requirement.Headcount {
+			continue
+		}
+		if last := len(gaps) - 1; last >= 0 && gaps[last].End == formatClock(from) && gaps[last].Scheduled == counts[n] {
+			gaps[last].End = formatClock(until)
+			continue
+		}
+		gaps = append(gaps, CoverageGap{
+			Date:          day.Format("2006-01-02"),
+			DayName:       day.Weekday().String(),
+			HolidayName:   holidayName,
+			RequirementID: requirement.ID,
+			Location:      requirement.Location,
+			Start:         formatClock(from),
+			End:           formatClock(until),
+			Required:      requirement.Headcount,
+			Scheduled:     counts[n],
+		})
+	}
+	return gaps
+}
+
+// clockOf returns the time of day of t.
+func clockOf(t model.CustomTime) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// formatClock writes a time of day as HH:MM, 24:00 for the end of the day.
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
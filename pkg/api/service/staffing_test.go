@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCoverageGaps(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	gaps, err := svc.CoverageGaps(ctx, "June", 2024)
+	require.NoError(t, err)
+	require.Empty(t, gaps, "Nothing is required yet")
+
+	requirement, err := svc.CreateStaffingRequirement(ctx, model.StaffingRequirement{DayName: "Monday", StartTime: at(9), EndTime: at(13), Headcount: 2})
+	require.NoError(t, err)
+	leave, err := svc.RequestLeave(ctx, ids["Bob"], model.LeaveInput{From: "2024-06-17"})
+	require.NoError(t, err)
+	_, err = svc.ApproveLeave(ctx, leave[0].ID, nil)
+	require.NoError(t, err)
+
+	// Alice works until noon and Bob, from the 10th, until 13:00; he is on leave on the 17th.
+	gaps, err = svc.CoverageGaps(ctx, "June", 2024)
+	require.NoError(t, err)
+	gap := func(date, start, end string, scheduled int) CoverageGap {
+		return CoverageGap{Date: date, DayName: "Monday", RequirementID: requirement.ID, Start: start, End: end, Required: 2, Scheduled: scheduled}
+	}
+	require.Equal(t, []CoverageGap{
+		gap("2024-06-03", "09:00", "12:00", 1), gap("2024-06-03", "12:00", "13:00", 0),
+		gap("2024-06-10", "12:00", "13:00", 1),
+		gap("2024-06-17", "09:00", "12:00", 1), gap("2024-06-17", "12:00", "13:00", 0),
+		gap("2024-06-24", "12:00", "13:00", 1),
+	}, gaps)
+
+	require.NoError(t, svc.DeleteStaffingRequirement(ctx, requirement.ID))
+	require.Equal(t, apierror.CodeStaffingNotFound, apierror.CodeOf(svc.DeleteStaffingRequirement(ctx, requirement.ID)))
+	_, err = svc.CoverageGaps(ctx, "Juin", 2024)
+	require.Equal(t, apierror.CodeMonthInvalid, apierror.CodeOf(err))
+}
+
+func TestCreateStaffingRequirementRejects(t *testing.T) {
+	svc, _ := newStationService(t)
+	ctx := context.Background()
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	for _, test := range []struct {
+		name        string
+		requirement model.StaffingRequirement
+		code        apierror.Code
+	}{
+		{"day", model.StaffingRequirement{DayName: "Lundi", StartTime: at(9), EndTime: at(13), Headcount: 1}, apierror.CodeDayNameInvalid},
+		{"range", model.StaffingRequirement{DayName: "Monday", StartTime: at(13), EndTime: at(9), Headcount: 1}, apierror.CodeTimeRangeInvalid},
+		{"headcount", model.StaffingRequirement{DayName: "Monday", StartTime: at(9), EndTime: at(13)}, apierror.CodeValidationFailed},
+	} {
+		_, err := svc.CreateStaffingRequirement(ctx, test.requirement)
+		require.Equal(t, test.code, apierror.CodeOf(err), test.name)
+	}
+	requirements, err := svc.ListStaffingRequirements(ctx)
+	require.NoError(t, err)
+	require.Empty(t, requirements)
+}
@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/validation"
+	"sort"
+	"time"
+)
+
+// maxSuggestionDays bounds the period whose coverage gaps SuggestAssignments fills.
+const maxSuggestionDays = 31
+
+// RosterSuggestion proposes that an employee works Slot on Date to fill a coverage gap of the staffing
+// requirement RequirementID. Before are the slots the employee works that day, After those it works once the
+// suggestion is accepted, which sets them as the schedule override of the date.
+type RosterSuggestion struct {
+	EmployeeID    uint             `json:"employeeId"`
+	Employee      string           `json:"employee"`
+	Date          string           `json:"date"`
+	RequirementID uint             `json:"requirementId"`
+	Slot          model.TimeSlot   `json:"slot"`
+	Before        []model.TimeSlot `json:"before"`
+	After         []model.TimeSlot `json:"after"`
+}
+
+// RosterSuggestions are the assignments proposed to fill the coverage gaps from From to To, and the gaps
+// left once they are accepted.
+type RosterSuggestions struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	Suggestions []RosterSuggestion `json:"suggestions"`
+	Unfilled    []CoverageGap      `json:"unfilled"`
+}
+
+// SuggestAssignments proposes slots filling the coverage gaps of the days from from to to, see CoverageGaps.
+// Every run of intervals of a requirement short of employees is offered in turn to the employees free for
+// the whole run: hired and not gone, not on leave nor unavailable, and working no slot overlapping it. The
+// employee must keep within its contract weekly hours, break no pairing rule it keeps that day, and break no
+// more rules of the validators of the tenant checking consecutive days, such as the daily rest, than it
+// already does. The employees with the most contract hours left that week come first, those without contract
+// hours last. Each suggestion is counted as accepted by the following ones; the runs nobody can fill are left
+// as they are.
+func (s *EmployeeService) SuggestAssignments(ctx context.Context, from, to time.Time) (*RosterSuggestions, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, apierror.Validation(fmt.Sprintf("to %s is before from %s", to.Format("2006-01-02"), from.Format("2006-01-02"))).WithCode(apierror.CodeDateInvalid)
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxSuggestionDays {
+		return nil, apierror.Validation(fmt.Sprintf("the period spans %d days, at most %d are allowed", days, maxSuggestionDays)).WithCode(apierror.CodeDateInvalid)
+	}
+	result := &RosterSuggestions{From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), Suggestions: []RosterSuggestion{}, Unfilled: []CoverageGap{}}
+	requirements, err := s.repo.StaffingRequirementList(ctx)
+	if err != nil || len(requirements) == 0 {
+		return result, err
+	}
+	validators, err := s.validators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rules []validation.DayRule
+	for _, v := range validators {
+		if rule, ok := v.validator.(validation.DayRule); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	// The weeks of the period are loaded for the weekly hours, and a week more on each side for the rules
+	// checking the days around those of the period.
+	first := util.MondayOf(from.AddDate(0, 0, -7))
+	last := util.MondayOf(to.AddDate(0, 0, 7)).AddDate(0, 0, 6)
+	employees, rotations, err := s.teamCalendars(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	plan := newRosterPlan(employees, rotations, first, last)
+	if plan.pairings, err = s.repo.PairingRuleList(ctx); err != nil {
+		return nil, err
+	}
+	unavailabilities, err := s.repo.UnavailabilityList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, unavailability := range unavailabilities {
+		plan.unavailable[unavailability.EmployeeID] = append(plan.unavailable[unavailability.EmployeeID], unavailability)
+	}
+	holidays := s.holidayNames(ctx, from.Year(), from.Month())
+	if to.Month() != from.Month() {
+		for date, holiday := range s.holidayNames(ctx, to.Year(), to.Month()) {
+			holidays[date] = holiday
+		}
+	}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, requirement := range requirements {
+			if requirement.DayName != day.Weekday().String() {
+				continue
+			}
+			start, end := clockOf(requirement.StartTime), clockOf(requirement.EndTime)
+			unfillable := make([]bool, 24*time.Hour/coverageInterval)
+			for {
+				counts, err := plan.counts(day, requirement.Location)
+				if err != nil {
+					return nil, err
+				}
+				// The first run of intervals short of employees that was not found unfillable yet.
+				runFrom, runUntil := -1, -1
+				for n := int(start / coverageInterval); time.Duration(n)*coverageInterval < end; n++ {
+					short := counts[n] < requirement.Headcount && !unfillable[n]
+					if short && runFrom < 0 {
+						runFrom = n
+					}
+					if !short && runFrom >= 0 {
+						break
+					}
+					if short {
+						runUntil = n + 1
+					}
+				}
+				if runFrom < 0 {
+					break
+				}
+				slot := model.TimeSlot{
+					Start:    formatClock(max(time.Duration(runFrom)*coverageInterval, start)),
+					End:      formatClock(min(time.Duration(runUntil)*coverageInterval, end)),
+					Location: requirement.Location,
+				}
+				i, err := plan.pick(day, slot, rules)
+				if err != nil {
+					return nil, err
+				}
+				if i < 0 {
+					for n := runFrom; n < runUntil; n++ {
+						unfillable[n] = true
+					}
+					continue
+				}
+				before := plan.slotsOn(i, day)
+				plan.add(i, day, slot)
+				result.Suggestions = append(result.Suggestions, RosterSuggestion{
+					EmployeeID:    employees[i].ID,
+					Employee:      employees[i].Name,
+					Date:          day.Format("2006-01-02"),
+					RequirementID: requirement.ID,
+					Slot:          slot,
+					Before:        before,
+					After:         plan.slotsOn(i, day),
+				})
+			}
+			counts, err := plan.counts(day, requirement.Location)
+			if err != nil {
+				return nil, err
+			}
+			result.Unfilled = append(result.Unfilled, shortfalls(day, holidays[day.Format("2006-01-02")].Name, requirement, counts)...)
+		}
+	}
+	return result, nil
+}
+
+// AcceptSuggestion sets the slots of a suggestion of SuggestAssignments as the schedule override of its
+// date. The suggestion is refused when the employee no longer works the slots it was made for that day.
+func (s *EmployeeService) AcceptSuggestion(ctx context.Context, suggestion RosterSuggestion) (*model.ScheduleOverride, error) {
+	date, err := time.Parse("2006-01-02", suggestion.Date)
+	if err != nil {
+		return nil, apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", suggestion.Date)).WithCode(apierror.CodeDateInvalid)
+	}
+	_, entries, err := s.calendarBetween(ctx, suggestion.EmployeeID, date, date.AddDate(0, 0, 1), "")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 2 || entries[0].Leave != nil {
+		return nil, apierror.Conflict(fmt.Sprintf("employee %d is not available on %s any more", suggestion.EmployeeID, suggestion.Date))
+	}
+	if !sameSlots(entries[0].TimeSlots, suggestion.Before) {
+		return nil, apierror.Conflict(fmt.Sprintf("the slots of employee %d on %s changed since the suggestion", suggestion.EmployeeID, suggestion.Date))
+	}
+
+	override := model.ScheduleOverride{Reason: fmt.Sprintf("fills staffing requirement %d", suggestion.RequirementID)}
+	// The calendars split the overnight slots at midnight: the parts continued from the day before are not
+	// slots of the date, and the slots crossing midnight end with their part on the next day.
+	next := entries[1].TimeSlots
+	for _, slot := range append(append([]model.TimeSlot{}, suggestion.Before...), suggestion.Slot) {
+		if slot.Continued {
+			continue
+		}
+		if slot.CrossesMidnight {
+			for i, rest := range next {
+				if rest.Continued && rest.Location == slot.Location && rest.Task == slot.Task {
+					slot.End, next = rest.End, append(next[:i:i], next[i+1:]...)
+					break
+				}
+			}
+		}
+		start, errStart := time.Parse("15:04", slot.Start)
+		end, errEnd := time.Parse("15:04", slot.End)
+		if errStart != nil || errEnd != nil {
+			return nil, apierror.Validation(fmt.Sprintf("invalid slot %s-%s, expected HH:MM-HH:MM", slot.Start, slot.End)).WithCode(apierror.CodeTimeFormatInvalid)
+		}
+		override.Slots = append(override.Slots, model.ScheduleOverrideSlot{StartTime: model.CustomTime{Time: start}, EndTime: model.CustomTime{Time: end},
+			Location: slot.Location, Task: slot.Task})
+	}
+	return s.SetScheduleOverride(ctx, suggestion.EmployeeID, suggestion.Date, override)
+}
+
+// sameSlots reports whether a and b are the same slots, in any order.
+func sameSlots(a, b []model.TimeSlot) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	left := make(map[model.TimeSlot]int, len(a))
+	for _, slot := range a {
+		left[slot]++
+	}
+	for _, slot := range b {
+		if left[slot] == 0 {
+			return false
+		}
+		left[slot]--
+	}
+	return true
+}
+
+// rosterPlan is the calendar of the team from first to last, updated with the slots suggested.
+type rosterPlan struct {
+	first     time.Time
+	employees []model.Employee
+	// days[i][n] are the slots employee i works on the nth day since first; off[i][n] is set on the days
+	// the employee cannot work: before it is hired, after it is gone and on leave.
+	days [][][]model.TimeSlot
+	off  [][]bool
+	// unavailable are the unavailabilities of the employees by ID.
+	unavailable map[uint][]model.Unavailability
+	// pairings are the pairing rules, and positions the index in employees of every employee by ID.
+	pairings  []model.PairingRule
+	positions map[uint]int
+}
+
+func newRosterPlan(employees []model.Employee, rotations rotations, first, last time.Time) *rosterPlan {
+	plan := &rosterPlan{first: first, employees: employees, days: make([][][]model.TimeSlot, len(employees)), off: make([][]bool, len(employees)),
+		unavailable: map[uint][]model.Unavailability{}, positions: make(map[uint]int, len(employees))}
+	for i := range employees {
+		employee := &employees[i]
+		plan.positions[employee.ID] = i
+		entries := monthlyCalendar(employee, rotations.of(employee.RotationPatternID), first, last, nil, employee.LeaveDays, employee.Overrides, "")
+		plan.days[i], plan.off[i] = make([][]model.TimeSlot, len(entries)), make([]bool, len(entries))
+		for n, entry := range entries {
+			date := first.AddDate(0, 0, n)
+			plan.off[i][n] = entry.Leave != nil || employee.StartDate.Format("2006-01-02") > entry.Date || employee.EndedBefore(date)
+			if !plan.off[i][n] {
+				plan.days[i][n] = entry.TimeSlots
+			}
+		}
+	}
+	return plan
+}
+
+// index returns the day of day in the plan, or -1 outside of it.
+func (p *rosterPlan) index(day time.Time) int {
+	n := int(day.Sub(p.first).Hours() / 24)
+	if n < 0 || len(p.days) == 0 || n >= len(p.days[0]) {
+		return -1
+	}
+	return n
+}
+
+func (p *rosterPlan) slotsOn(i int, day time.Time) []model.TimeSlot {
+	slots := append([]model.TimeSlot{}, p.days[i][p.index(day)]...)
+	sort.SliceStable(slots, func(a, b int) bool { return slots[a].Start < slots[b].Start })
+	return slots
+}
+
+func (p *rosterPlan) add(i int, day time.Time, slot model.TimeSlot) {
+	n := p.index(day)
+	p.days[i][n] = append(p.days[i][n], slot)
+}
+
+// counts counts the employees working at location during every interval of day, as intervalCounts does.
+func (p *rosterPlan) counts(day time.Time, location string) ([]int, error) {
+	counts := make([]int, 24*time.Hour/coverageInterval)
+	n := p.index(day)
+	for i, employee := range p.employees {
+		var slots []model.TimeSlot
+		for _, slot := range p.days[i][n] {
+			if location == "" || slot.Location == location {
+				slots = append(slots, slot)
+			}
+		}
+		if err := addCoverage(counts, slots); err != nil {
+			return nil, fmt.Errorf("%w for employee ID %d", err, employee.ID)
+		}
+	}
+	return counts, nil
+}
+
+// weekHours returns the hours employee i works during the week of day.
+func (p *rosterPlan) weekHours(i int, day time.Time) (float64, error) {
+	monday := util.MondayOf(day)
+	hours := 0.0
+	for d := monday; d.Before(monday.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		n := p.index(d)
+		if n < 0 {
+			continue
+		}
+		for _, slot := range p.days[i][n] {
+			length, err := slotLength(slot)
+			if err != nil {
+				return 0, err
+			}
+			hours += length.Hours()
+		}
+	}
+	return hours, nil
+}
+
+// breaksPairing reports whether employee i working slot on day breaks a pairing rule it keeps otherwise:
+// working slot without the employee it must work with, at the same time as one it must not work with, or
+// closing with one it must not close with.
+func (p *rosterPlan) breaksPairing(i int, day time.Time, slot model.TimeSlot) (bool, error) {
+	n := p.index(day)
+	extra, err := clockSlots([]model.TimeSlot{slot})
+	if err != nil {
+		return false, err
+	}
+	// The slots are on the clock of year 0, before the zero time.Time: the latest end is searched from slot.
+	closing := extra[0].StartTime.Time
+	for j := range p.employees {
+		slots, err := clockSlots(p.days[j][n])
+		if err != nil {
+			return false, fmt.Errorf("%w of employee ID %d", err, p.employees[j].ID)
+		}
+		for _, other := range slots {
+			if other.End().After(closing) {
+				closing = other.End()
+			}
+		}
+	}
+	mine, err := clockSlots(p.days[i][n])
+	if err != nil {
+		return false, fmt.Errorf("%w of employee ID %d", err, p.employees[i].ID)
+	}
+
+	id := p.employees[i].ID
+	for _, rule := range p.pairings {
+		otherID := rule.OtherEmployeeID
+		if rule.OtherEmployeeID == id {
+			otherID = rule.EmployeeID
+		} else if rule.EmployeeID != id {
+			continue
+		}
+		j, ok := p.positions[otherID]
+		if !ok {
+			continue
+		}
+		theirs, err := clockSlots(p.days[j][n])
+		if err != nil {
+			return false, fmt.Errorf("%w of employee ID %d", err, otherID)
+		}
+		switch rule.Kind {
+		case model.PairingTogether:
+			if rule.EmployeeID == id && !coveredBy(extra[0], theirs) {
+				return true, nil
+			}
+		case model.PairingApart:
+			for _, their := range theirs {
+				if util.SlotsOverlap(extra[0].StartTime.Time, extra[0].End(), their.StartTime.Time, their.End()) {
+					return true, nil
+				}
+			}
+		case model.PairingNotClosingTogether:
+			after := closing
+			if extra[0].End().After(after) {
+				after = extra[0].End()
+			}
+			before := len(mine) > 0 && closesAt(mine, closing) && closesAt(theirs, closing)
+			if !before && closesAt(append(mine, extra[0]), after) && closesAt(theirs, after) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// breaches counts the breaches of rules on the days around day, six on each side, worked by employee i with
+// extra on day if not nil. The overnight slots are checked whole, see wholeSlots.
+func (p *rosterPlan) breaches(i int, day time.Time, rules []validation.DayRule, extra *model.TimeSlot) (int, error) {
+	var dates []time.Time
+	var slots [][]model.TimeSlot
+	// The day after the last is read for the rest of the slots crossing midnight on the last.
+	for d := day.AddDate(0, 0, -6); !d.After(day.AddDate(0, 0, 7)); d = d.AddDate(0, 0, 1) {
+		n := p.index(d)
+		if n < 0 {
+			continue
+		}
+		daySlots := p.days[i][n]
+		if extra != nil && d.Equal(day) {
+			daySlots = append(append([]model.TimeSlot{}, daySlots...), *extra)
+		}
+		dates, slots = append(dates, d), append(slots, daySlots)
+	}
+	slots = wholeSlots(slots)
+	var days []validation.Day
+	for n, d := range dates {
+		if d.After(day.AddDate(0, 0, 6)) {
+			break
+		}
+		checked, err := validationDay(d, slots[n])
+		if err != nil {
+			return 0, err
+		}
+		days = append(days, checked)
+	}
+	count := 0
+	for _, rule := range rules {
+		count += len(rule.CheckDays(days, false))
+	}
+	return count, nil
+}
+
+// pick returns the employee to work slot on day, or -1 when no employee is free for it.
+func (p *rosterPlan) pick(day time.Time, slot model.TimeSlot, rules []validation.DayRule) (int, error) {
+	length, err := slotLength(slot)
+	if err != nil {
+		return -1, err
+	}
+	start, end, _ := slot.Clock()
+	n := p.index(day)
+
+	type candidate struct {
+		i       int
+		limited bool
+		left    float64
+	}
+	var candidates []candidate
+	for i, employee := range p.employees {
+		if p.off[i][n] {
+			continue
+		}
+		busy := false
+		for _, other := range p.days[i][n] {
+			otherStart, otherEnd, err := other.Clock()
+			if err != nil {
+				return -1, fmt.Errorf("slot %s-%s of employee ID %d: invalid time", other.Start, other.End, employee.ID)
+			}
+			busy = busy || util.SlotsOverlap(start, end, otherStart, otherEnd)
+		}
+		for _, unavailability := range p.unavailable[employee.ID] {
+			unavailable, err := unavailableDuring(unavailability, day, slot)
+			if err != nil {
+				return -1, err
+			}
+			busy = busy || unavailable
+		}
+		if busy {
+			continue
+		}
+		broken, err := p.breaksPairing(i, day, slot)
+		if err != nil {
+			return -1, err
+		}
+		if broken {
+			continue
+		}
+		worked, err := p.weekHours(i, day)
+		if err != nil {
+			return -1, err
+		}
+		left := employee.ContractWeeklyHours - worked
+		if employee.ContractWeeklyHours > 0 && left < length.Hours() {
+			continue
+		}
+		candidates = append(candidates, candidate{i: i, limited: employee.ContractWeeklyHours > 0, left: left})
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].limited != candidates[b].limited {
+			return candidates[a].limited
+		}
+		return candidates[a].limited && candidates[a].left > candidates[b].left
+	})
+
+	for _, c := range candidates {
+		before, err := p.breaches(c.i, day, rules, nil)
+		if err != nil {
+			return -1, err
+		}
+		after, err := p.breaches(c.i, day, rules, &slot)
+		if err != nil {
+			return -1, err
+		}
+		if after <= before {
+			return c.i, nil
+		}
+	}
+	return -1, nil
+}
+
+// slotLength returns the time worked during slot.
+func slotLength(slot model.TimeSlot) (time.Duration, error) {
+	start, end, err := slot.Clock()
+	if err != nil {
+		return 0, fmt.Errorf("slot %s-%s: invalid time", slot.Start, slot.End)
+	}
+	return end.Sub(start), nil
+}
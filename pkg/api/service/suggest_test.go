@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/db/repo/repotest"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSuggestAssignmentsKeepsPairingRules(t *testing.T) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(t))
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {}}})
+	ctx := context.Background()
+	tuesday := []model.ScheduleInput{{Start: "9:00", End: "10:00"}}
+	employee := func(name string, monday ...model.ScheduleInput) model.EmployeeInput {
+		week := model.WeeklyScheduleInput{Monday: monday, Tuesday: tuesday}
+		return model.EmployeeInput{Name: name, StartDate: "2024-06-03", Weeks: map[string]model.WeeklyScheduleInput{"A": week, "B": week}}
+	}
+	// Centre needs someone from 13:00 to 15:00 on Mondays. Ann, Ben, Cleo and Eve are free; Mia works the
+	// morning at Centre, Xavier and Dora the afternoon at Gare.
+	require.NoError(t, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		employee("Ann"), employee("Ben"), employee("Cleo"), employee("Eve"),
+		employee("Mia", model.ScheduleInput{Start: "9:00", End: "12:00", Location: "Centre"}),
+		employee("Xavier", model.ScheduleInput{Start: "13:00", End: "15:00", Location: "Gare"}),
+		employee("Dora", model.ScheduleInput{Start: "9:00", End: "15:00", Location: "Gare"}),
+	}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	ids := make(map[string]uint)
+	for _, e := range employees {
+		ids[e.Name] = e.ID
+	}
+	clock := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	_, err = svc.CreateStaffingRequirement(ctx, model.StaffingRequirement{Location: "Centre", DayName: "Monday", StartTime: clock(13), EndTime: clock(15), Headcount: 1})
+	require.NoError(t, err)
+	monday := time.Date(2024, time.June, 17, 0, 0, 0, 0, time.UTC)
+
+	suggested := func() string {
+		suggestions, err := svc.SuggestAssignments(ctx, monday, monday)
+		require.NoError(t, err)
+		require.Len(t, suggestions.Suggestions, 1)
+		require.Equal(t, model.TimeSlot{Start: "13:00", End: "15:00", Location: "Centre"}, suggestions.Suggestions[0].Slot)
+		return suggestions.Suggestions[0].Employee
+	}
+	require.Equal(t, "Ann", suggested())
+
+	// Ann must not work with Xavier, Ben may only work with Mia, and Cleo must not close with Dora.
+	for _, rule := range []model.PairingRule{
+		{Kind: model.PairingApart, EmployeeID: ids["Ann"], OtherEmployeeID: ids["Xavier"]},
+		{Kind: model.PairingTogether, EmployeeID: ids["Ben"], OtherEmployeeID: ids["Mia"]},
+		{Kind: model.PairingNotClosingTogether, EmployeeID: ids["Dora"], OtherEmployeeID: ids["Cleo"]},
+	} {
+		_, err := svc.CreatePairingRule(ctx, rule)
+		require.NoError(t, err)
+	}
+	require.Equal(t, "Eve", suggested())
+}
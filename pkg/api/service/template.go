@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// resolveSchedules returns the effective slots of an employee: its own schedules plus the slots inherited
+// from its role template, minus the inherited slots removed by a delta, plus the slots added by a delta.
+// The result is ordered by start time.
+func resolveSchedules(employee *model.Employee) []model.Schedule {
+	resolved := append([]model.Schedule(nil), employee.Schedules...)
+	resolved = append(resolved, inheritedSchedules(employee)...)
+	sort.SliceStable(resolved, func(i, j int) bool {
+		return resolved[i].StartTime.Before(resolved[j].StartTime.Time)
+	})
+	return resolved
+}
+
+// inheritedSchedules returns the slots an employee gets from its role template once its deltas are applied.
+func inheritedSchedules(employee *model.Employee) []model.Schedule {
+	if employee.RoleTemplate == nil {
+		return nil
+	}
+
+	removed := make(map[string]int)
+	var added []model.Schedule
+	for _, delta := range employee.Deltas {
+		switch delta.Action {
+		case model.DeltaRemove:
+			removed[slotKey(delta.WeekType, delta.DayName, delta.StartTime, delta.EndTime, delta.Location)]++
+		case model.DeltaAdd:
+			added = append(added, model.Schedule{
+				EmployeeID:      employee.ID,
+				WeekType:        delta.WeekType,
+				DayName:         delta.DayName,
+				StartTime:       delta.StartTime,
+				EndTime:         delta.EndTime,
+				Location:        delta.Location,
+				Task:            delta.Task,
+				CrossesMidnight: model.CrossesMidnight(delta.StartTime, delta.EndTime),
+				// The slot is the employee's own, added by whoever recorded the delta.
+				Source:      model.SourceManual,
+				ChangedByID: delta.ChangedByID,
+			})
+		}
+	}
+
+	inherited := make([]model.Schedule, 0, len(employee.RoleTemplate.Slots)+len(added))
+	for _, slot := range employee.RoleTemplate.Slots {
+		key := slotKey(slot.WeekType, slot.DayName, slot.StartTime, slot.EndTime, slot.Location)
+		if removed[key] > 0 {
+			removed[key]--
+			continue
+		}
+		inherited = append(inherited, model.Schedule{
+			EmployeeID:      employee.ID,
+			WeekType:        slot.WeekType,
+			DayName:         slot.DayName,
+			StartTime:       slot.StartTime,
+			EndTime:         slot.EndTime,
+			Location:        slot.Location,
+			Task:            slot.Task,
+			CrossesMidnight: model.CrossesMidnight(slot.StartTime, slot.EndTime),
+			Source:          model.SourceTemplate,
+		})
+	}
+	return append(inherited, added...)
+}
+
+// slotKey identifies a recurring slot independently of the table it is stored in.
+func slotKey(weekType, dayName string, start, end model.CustomTime, location string) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s", weekType, dayName, start.Format("15:04"), end.Format("15:04"), location)
+}
+
+// CreateRoleTemplate validates and stores a new role template with its slots.
+func (s *EmployeeService) CreateRoleTemplate(ctx context.Context, template model.RoleTemplate) (*model.RoleTemplate, error) {
+	if template.Name == "" {
+		return nil, apierror.Validation("role template name is required")
+	}
+	if err := validateTemplateSlots(template.Slots); err != nil {
+		return nil, err
+	}
+	template.ID, template.UUID = 0, ""
+	template.CreatedAt, template.UpdatedAt = time.Time{}, time.Time{}
+	for i := range template.Slots {
+		template.Slots[i].ID = 0
+		template.Slots[i].CreatedAt, template.Slots[i].UpdatedAt = time.Time{}, time.Time{}
+	}
+	if err := s.repo.RoleTemplateCreate(ctx, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListRoleTemplates returns every role template with its slots.
+func (s *EmployeeService) ListRoleTemplates(ctx context.Context) ([]model.RoleTemplate, error) {
+	return s.repo.RoleTemplateList(ctx)
+}
+
+// UpdateRoleTemplate replaces the slots of a role template. With cascade, every inheriting employee
+// follows the new slots. Without cascade, inheriting employees are first detached: the slots they
+// currently inherit are copied into their own schedules so their calendar does not change. The detaches and
+// the new slots are written in a single transaction.
+func (s *EmployeeService) UpdateRoleTemplate(ctx context.Context, id uint, slots []model.RoleTemplateSlot, cascade bool) (*model.RoleTemplate, error) {
+	if _, err := s.repo.RoleTemplateFindByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("role template %d not found", id)).WithCode(apierror.CodeRoleTemplateNotFound)
+		}
+		return nil, err
+	}
+	if err := validateTemplateSlots(slots); err != nil {
+		return nil, err
+	}
+
+	inheritors, err := s.repo.EmployeesByRoleTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.Transaction(ctx, func(tx repo.Repository) error {
+		if !cascade {
+			for i := range inheritors {
+				if err := tx.DetachRoleTemplate(ctx, inheritors[i].ID, inheritedSchedules(&inheritors[i])); err != nil {
+					return fmt.Errorf("failed to detach employee %d from role template %d: %w", inheritors[i].ID, id, err)
+				}
+			}
+		}
+		return tx.RoleTemplateReplaceSlots(ctx, id, slots)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range inheritors {
+		s.syncSnapshots(ctx, inheritors[i].ID)
+	}
+	return s.repo.RoleTemplateFindByID(ctx, id)
+}
+
+// RoleTemplatesLastModified returns the latest modification of the given templates and their slots.
+func RoleTemplatesLastModified(templates []model.RoleTemplate) time.Time {
+	var latest time.Time
+	for _, template := range templates {
+		latest = laterOf(latest, template.UpdatedAt)
+		for _, slot := range template.Slots {
+			latest = laterOf(latest, slot.UpdatedAt)
+		}
+	}
+	return latest
+}
+
+// AssignRoleTemplate makes an employee inherit from a role template, or stop inheriting when templateID is nil.
+func (s *EmployeeService) AssignRoleTemplate(ctx context.Context, employeeID uint, templateID *uint) error {
+	if templateID != nil {
+		if _, err := s.repo.RoleTemplateFindByID(ctx, *templateID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apierror.Validation(fmt.Sprintf("role template %d does not exist", *templateID)).WithCode(apierror.CodeRoleTemplateNotFound)
+			}
+			return err
+		}
+	}
+	if err := s.repo.SetEmployeeRoleTemplate(ctx, employeeID, templateID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	return nil
+}
+
+// AddScheduleDelta records a deviation of an employee from its role template. A "remove" delta must
+// match one of the slots the employee currently inherits; an "add" delta must not overlap its other slots.
+func (s *EmployeeService) AddScheduleDelta(ctx context.Context, employeeID uint, delta model.ScheduleDelta) (*model.ScheduleDelta, error) {
+	if delta.Action != model.DeltaAdd && delta.Action != model.DeltaRemove {
+		return nil, apierror.Validation(fmt.Sprintf("action must be either '%s' or '%s', got: %s", model.DeltaAdd, model.DeltaRemove, delta.Action))
+	}
+	if err := validateSlot(delta.WeekType, delta.DayName, delta.StartTime, delta.EndTime); err != nil {
+		return nil, err
+	}
+
+	employee, err := s.employeeWithSchedules(ctx, employeeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	if employee.RoleTemplate == nil {
+		return nil, apierror.Validation(fmt.Sprintf("employee %d does not inherit from a role template", employeeID))
+	}
+	rotation, err := s.rotationOf(ctx, employee)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWeekType(rotation, delta.WeekType); err != nil {
+		return nil, err
+	}
+	if delta.Action == model.DeltaRemove {
+		key := slotKey(delta.WeekType, delta.DayName, delta.StartTime, delta.EndTime, delta.Location)
+		found := false
+		for _, slot := range inheritedSchedules(employee) {
+			if slotKey(slot.WeekType, slot.DayName, slot.StartTime, slot.EndTime, slot.Location) == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, apierror.Validation("the removed slot is not inherited from the role template")
+		}
+	}
+	if delta.Action == model.DeltaAdd {
+		slot := model.Schedule{WeekType: delta.WeekType, DayName: delta.DayName, StartTime: delta.StartTime, EndTime: delta.EndTime, Location: delta.Location}
+		others := resolveSchedules(employee)
+		if i, ok := overlappingSlot(slot, others, rotation.WeekNames()); ok {
+			return nil, apierror.Conflict(overlapReason(slot, others[i])).WithCode(apierror.CodeScheduleOverlap)
+		}
+	}
+
+	delta.ID, delta.UUID = 0, ""
+	delta.EmployeeID = employeeID
+	delta.Source, delta.ChangedByID = model.SourceManual, changedBy(ctx)
+	delta.CreatedAt, delta.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.DeltaCreate(ctx, &delta); err != nil {
+		return nil, err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeDelta, Action: ActionWritten, ID: delta.ID, Resource: delta})
+	return &delta, nil
+}
+
+// ListScheduleDeltas returns the deviations of an employee from its role template.
+func (s *EmployeeService) ListScheduleDeltas(ctx context.Context, employeeID uint) ([]model.ScheduleDelta, error) {
+	return s.repo.DeltaListByEmployee(ctx, employeeID)
+}
+
+// DeleteScheduleDelta removes a deviation, restoring the inherited behaviour.
+func (s *EmployeeService) DeleteScheduleDelta(ctx context.Context, employeeID, id uint) error {
+	if err := s.repo.DeltaDelete(ctx, employeeID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("schedule delta %d not found", id)).WithCode(apierror.CodeDeltaNotFound)
+		}
+		return err
+	}
+	s.syncSnapshots(ctx, employeeID)
+	s.emit(ctx, events.ScheduleUpdated, ScheduleUpdate{EmployeeID: employeeID, Change: ChangeDelta, Action: ActionDeleted, ID: id})
+	return nil
+}
+
+func validateTemplateSlots(slots []model.RoleTemplateSlot) error {
+	for _, slot := range slots {
+		if err := validateSlot(slot.WeekType, slot.DayName, slot.StartTime, slot.EndTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
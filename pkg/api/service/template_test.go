@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// failingSlots is a repository that cannot replace the slots of a role template, inside a transaction or not.
+type failingSlots struct {
+	repo.Repository
+}
+
+func (f failingSlots) RoleTemplateReplaceSlots(ctx context.Context, id uint, slots []model.RoleTemplateSlot) error {
+	return errors.New("disk full")
+}
+
+func (f failingSlots) Transaction(ctx context.Context, fn func(tx repo.Repository) error) error {
+	return f.Repository.Transaction(ctx, func(tx repo.Repository) error { return fn(failingSlots{tx}) })
+}
+
+func TestUpdateRoleTemplateDetachesAtomically(t *testing.T) {
+	svc, id := newSnapshotService(t)
+	ctx := context.Background()
+	templates, err := svc.ListRoleTemplates(ctx)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	before, err := svc.repo.GetEmployeeWithSchedules(ctx, id)
+	require.NoError(t, err)
+	slots := []model.RoleTemplateSlot{{WeekType: "A", DayName: "Saturday", StartTime: templates[0].Slots[0].StartTime, EndTime: templates[0].Slots[0].EndTime}}
+
+	// The slots cannot be replaced: the employee must not be left detached from the unchanged template.
+	stored := svc.repo
+	svc.repo = failingSlots{stored}
+	_, err = svc.UpdateRoleTemplate(ctx, templates[0].ID, slots, false)
+	require.ErrorContains(t, err, "disk full")
+	svc.repo = stored
+	after, err := svc.repo.GetEmployeeWithSchedules(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, after.RoleTemplateID)
+	require.Len(t, after.Schedules, len(before.Schedules))
+
+	updated, err := svc.UpdateRoleTemplate(ctx, templates[0].ID, slots, false)
+	require.NoError(t, err)
+	require.Len(t, updated.Slots, 1)
+	after, err = svc.repo.GetEmployeeWithSchedules(ctx, id)
+	require.NoError(t, err)
+	require.Nil(t, after.RoleTemplateID)
+	require.Len(t, after.Schedules, len(before.Schedules)+1, "The Sunday slot still inherited, the other being removed by a delta, becomes an own slot")
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	// punchDedupeWindow is the time within which a second punch of the same kind by the same employee is
+	// taken for a retry of the first one, whatever its nonce: kiosks on flaky networks retry requests they
+	// did not get an answer for, and employees tap twice.
+	punchDedupeWindow = 2 * time.Minute
+	// maxPunchSkew bounds how far in the future the clock of a kiosk may place a punch.
+	maxPunchSkew = 5 * time.Minute
+)
+
+// Punch records a punch of the time clock. A request is identified by the nonce its device gives it: a
+// request already recorded, or a punch of the same kind by the same employee within punchDedupeWindow, is a
+// replay and the punch recorded first is returned with replayed set, nothing being written. A new nonce with
+// a sequence number not above the last one of the device is rejected as a stale replay; devices that do not
+// number their requests send no sequence. PunchedAt defaults to now.
+func (s *EmployeeService) Punch(ctx context.Context, entry model.TimeEntry) (recorded *model.TimeEntry, replayed bool, err error) {
+	if entry.Kind != model.PunchIn && entry.Kind != model.PunchOut {
+		return nil, false, apierror.Validation(fmt.Sprintf("kind must be '%s' or '%s', got: %s", model.PunchIn, model.PunchOut, entry.Kind))
+	}
+	if entry.DeviceID == "" || len(entry.DeviceID) > 100 {
+		return nil, false, apierror.Validation("deviceId is required, up to 100 characters")
+	}
+	if entry.Nonce == "" || len(entry.Nonce) > 100 {
+		return nil, false, apierror.Validation("nonce is required, up to 100 characters")
+	}
+	if entry.Sequence < 0 {
+		return nil, false, apierror.Validation("sequence must not be negative")
+	}
+	now := time.Now().UTC()
+	if entry.PunchedAt.IsZero() {
+		entry.PunchedAt = now
+	} else if entry.PunchedAt.After(now.Add(maxPunchSkew)) {
+		return nil, false, apierror.Validation(fmt.Sprintf("punchedAt %s is in the future", entry.PunchedAt.Format(time.RFC3339))).WithCode(apierror.CodeDateInvalid)
+	}
+	entry.PunchedAt = entry.PunchedAt.UTC().Truncate(time.Second)
+
+	if previous, err := s.replayOf(ctx, &entry); previous != nil || err != nil {
+		return previous, previous != nil, err
+	}
+	var employee model.Employee
+	if err := s.repo.GetEmployeeByID(ctx, entry.EmployeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, apierror.NotFound(fmt.Sprintf("employee %d not found", entry.EmployeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, false, err
+	}
+	if entry.Sequence > 0 {
+		last, err := s.repo.TimeEntryLastSequence(ctx, entry.DeviceID)
+		if err != nil {
+			return nil, false, err
+		}
+		if entry.Sequence <= last {
+			return nil, false, apierror.Conflict(fmt.Sprintf("sequence %d of device %s is not above its last sequence %d",
+				entry.Sequence, entry.DeviceID, last)).WithCode(apierror.CodePunchReplayed)
+		}
+	}
+
+	entry.ID, entry.UUID, entry.CreatedAt = 0, "", time.Time{}
+	if err := s.repo.TimeEntryCreate(ctx, &entry); err != nil {
+		// A concurrent retry of the same request may have been recorded in between.
+		if previous, _ := s.replayOf(ctx, &entry); previous != nil {
+			return previous, true, nil
+		}
+		return nil, false, err
+	}
+	return &entry, false, nil
+}
+
+// replayOf returns the punch entry repeats, or nil if it is a new punch.
+func (s *EmployeeService) replayOf(ctx context.Context, entry *model.TimeEntry) (*model.TimeEntry, error) {
+	previous, err := s.repo.TimeEntryFindByNonce(ctx, entry.DeviceID, entry.Nonce)
+	switch {
+	case err == nil:
+		if previous.EmployeeID != entry.EmployeeID || previous.Kind != entry.Kind {
+			return nil, apierror.Conflict(fmt.Sprintf("nonce %s of device %s was used for another punch", entry.Nonce, entry.DeviceID)).WithCode(apierror.CodeNonceReused)
+		}
+		return previous, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	previous, err = s.repo.TimeEntryFindNear(ctx, entry.EmployeeID, entry.Kind,
+		entry.PunchedAt.Add(-punchDedupeWindow), entry.PunchedAt.Add(punchDedupeWindow))
+	switch {
+	case err == nil:
+		return previous, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// ListTimeEntries returns the punches of an employee on the days from from to to included.
+func (s *EmployeeService) ListTimeEntries(ctx context.Context, employeeID uint, from, to time.Time) ([]model.TimeEntry, error) {
+	return s.repo.TimeEntryFindBetween(ctx, employeeID, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPunchReplays(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice := ids["Alice"]
+	at := time.Date(2024, 6, 3, 8, 58, 0, 0, time.UTC)
+	punch := func(kind, nonce string, sequence int64, punchedAt time.Time) model.TimeEntry {
+		return model.TimeEntry{EmployeeID: alice, Kind: kind, DeviceID: "kiosk-1", Nonce: nonce, Sequence: sequence, PunchedAt: punchedAt}
+	}
+
+	recorded, replayed, err := svc.Punch(ctx, punch(model.PunchIn, "n1", 1, at))
+	require.NoError(t, err)
+	require.False(t, replayed)
+
+	// A retry of the request, and a second tap within the window, return the punch recorded first.
+	again, replayed, err := svc.Punch(ctx, punch(model.PunchIn, "n1", 1, at))
+	require.NoError(t, err)
+	require.True(t, replayed)
+	require.Equal(t, recorded.ID, again.ID)
+	again, replayed, err = svc.Punch(ctx, punch(model.PunchIn, "n2", 2, at.Add(time.Minute)))
+	require.NoError(t, err)
+	require.True(t, replayed)
+	require.Equal(t, recorded.ID, again.ID)
+
+	// A nonce reused for another punch, and a sequence not above the last one, are rejected.
+	_, _, err = svc.Punch(ctx, punch(model.PunchOut, "n1", 3, at.Add(8*time.Hour)))
+	require.Equal(t, apierror.CodeNonceReused, apierror.CodeOf(err))
+	_, _, err = svc.Punch(ctx, punch(model.PunchOut, "n3", 1, at.Add(8*time.Hour)))
+	require.Equal(t, apierror.CodePunchReplayed, apierror.CodeOf(err))
+	_, replayed, err = svc.Punch(ctx, punch(model.PunchOut, "n4", 4, at.Add(8*time.Hour)))
+	require.NoError(t, err)
+	require.False(t, replayed)
+	_, replayed, err = svc.Punch(ctx, punch(model.PunchIn, "n5", 0, at.Add(24*time.Hour)))
+	require.NoError(t, err)
+	require.False(t, replayed, "Devices may not number their requests")
+
+	punches, err := svc.ListTimeEntries(ctx, alice, at, at)
+	require.NoError(t, err)
+	require.Len(t, punches, 2)
+}
+
+func TestPunchRejects(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	for _, test := range []struct {
+		name  string
+		entry model.TimeEntry
+		code  apierror.Code
+	}{
+		{"kind", model.TimeEntry{EmployeeID: ids["Alice"], Kind: "lunch", DeviceID: "kiosk-1", Nonce: "n1"}, apierror.CodeValidationFailed},
+		{"device", model.TimeEntry{EmployeeID: ids["Alice"], Kind: model.PunchIn, Nonce: "n1"}, apierror.CodeValidationFailed},
+		{"nonce", model.TimeEntry{EmployeeID: ids["Alice"], Kind: model.PunchIn, DeviceID: "kiosk-1"}, apierror.CodeValidationFailed},
+		{"sequence", model.TimeEntry{EmployeeID: ids["Alice"], Kind: model.PunchIn, DeviceID: "kiosk-1", Nonce: "n1", Sequence: -1}, apierror.CodeValidationFailed},
+		{"future", model.TimeEntry{EmployeeID: ids["Alice"], Kind: model.PunchIn, DeviceID: "kiosk-1", Nonce: "n1", PunchedAt: time.Now().Add(time.Hour)}, apierror.CodeDateInvalid},
+		{"employee", model.TimeEntry{EmployeeID: 999, Kind: model.PunchIn, DeviceID: "kiosk-1", Nonce: "n1"}, apierror.CodeEmployeeNotFound},
+	} {
+		_, _, err := svc.Punch(ctx, test.entry)
+		require.Equal(t, test.code, apierror.CodeOf(err), test.name)
+	}
+}
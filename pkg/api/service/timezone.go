@@ -0,0 +1,46 @@
+package service
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"time"
+)
+
+// UseTimeZones sets the time zone the slots are worked in, UTC by default, and those of the locations in
+// another zone, such as a shop in Paris in a company whose other locations are in Martinique. The hours of
+// the slots are counted in their zone, so that they follow its daylight saving time changes, and today is
+// the date in the zone.
+func (s *EmployeeService) UseTimeZones(zone *time.Location, locations map[string]*time.Location) {
+	if zone == nil {
+		zone = time.UTC
+	}
+	s.timeZone, s.locationZones = zone, locations
+}
+
+// TimeZone returns the time zone of location, that of the deployment if it has none of its own.
+func (s *EmployeeService) TimeZone(location string) *time.Location {
+	if zone, ok := s.locationZones[location]; ok && location != "" {
+		return zone
+	}
+	if s.timeZone == nil {
+		return time.UTC
+	}
+	return s.timeZone
+}
+
+// today returns the date of today in the time zone of location, see UseTimeZones, as midnight UTC like the
+// other dates of the calendars.
+func (s *EmployeeService) today(location string) time.Time {
+	now := time.Now().In(s.TimeZone(location))
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// slotHours returns the hours of slot on date (YYYY-MM-DD) in the time zone of its location. The slots of an
+// undated day are counted by the wall clock.
+func (s *EmployeeService) slotHours(date string, slot model.TimeSlot) (float64, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return util.CalculateHours(slot.Start, slot.End)
+	}
+	return util.CalculateHoursIn(s.TimeZone(slot.Location), day, slot.Start, slot.End)
+}
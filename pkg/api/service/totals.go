@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// maxHourTotalsEmployees bounds the employees of a single HourTotals call.
+const maxHourTotalsEmployees = 1000
+
+// HourTotalsInput asks for the hour totals of employees over the days from From to To included (YYYY-MM-DD).
+type HourTotalsInput struct {
+	EmployeeIDs []uint `json:"employeeIds"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+// HourTotals are the hours of one employee over a period.
+type HourTotals struct {
+	EmployeeID uint `json:"employeeId"`
+	// PlannedHours are the hours scheduled, the days of approved leave left out.
+	PlannedHours float64 `json:"plannedHours"`
+	// ActualHours are the hours clocked on the time clock, from each punch in to the next punch out.
+	ActualHours float64 `json:"actualHours"`
+	// PremiumHours are the part of PlannedHours falling on Sundays and public holidays, paid at a premium.
+	PremiumHours float64 `json:"premiumHours"`
+}
+
+// HourTotals returns the planned, actual and premium hours of each employee of the input, in the order of
+// the input, for the sibling services that only need totals. The calendars of all the employees are built
+// from one query and the clocked hours are summed by the database. Unknown employees are left out, and so
+// are the days before the start date of an employee.
+func (s *EmployeeService) HourTotals(ctx context.Context, input HourTotalsInput) ([]HourTotals, error) {
+	if len(input.EmployeeIDs) == 0 {
+		return nil, apierror.Validation("employeeIds is required")
+	}
+	if len(input.EmployeeIDs) > maxHourTotalsEmployees {
+		return nil, apierror.Validation(fmt.Sprintf("at most %d employees can be totalled at once", maxHourTotalsEmployees))
+	}
+	first, last, err := ParseDateRange(input.From, input.To)
+	if err != nil {
+		return nil, err
+	}
+	if days := int(last.Sub(first).Hours()/24) + 1; days > maxScheduleRangeDays {
+		return nil, apierror.Validation(fmt.Sprintf("the range spans %d days, at most %d are allowed", days, maxScheduleRangeDays)).WithCode(apierror.CodeDateInvalid)
+	}
+
+	employees, rotations, err := s.calendarsOf(ctx, input.EmployeeIDs, first, last)
+	if err != nil {
+		return nil, err
+	}
+	clocked, err := s.repo.ClockedHours(ctx, input.EmployeeIDs, first, last)
+	if err != nil {
+		return nil, err
+	}
+	holidays := s.holidaysBetween(ctx, first.AddDate(0, 0, -1), last)
+
+	byID := make(map[uint]HourTotals, len(employees))
+	for i := range employees {
+		employee := &employees[i]
+		totals := HourTotals{EmployeeID: employee.ID, ActualHours: roundHours(clocked[employee.ID])}
+		calendar := monthlyCalendar(employee, rotations.of(employee.RotationPatternID), first, last, holidays, employee.LeaveDays, employee.Overrides, "")
+		for _, entry := range calendar {
+			if employee.StartDate.Format("2006-01-02") > entry.Date {
+				continue
+			}
+			hours, err := s.CalculateMonthlyHours([]model.MonthlySchedule{entry})
+			if err != nil {
+				return nil, err
+			}
+			totals.PlannedHours += hours.Worked
+			if entry.PublicHoliday() || entry.DayName == time.Sunday.String() {
+				totals.PremiumHours += hours.Worked
+			}
+		}
+		totals.PlannedHours = roundHours(totals.PlannedHours)
+		totals.PremiumHours = roundHours(totals.PremiumHours)
+		byID[employee.ID] = totals
+	}
+	result := make([]HourTotals, 0, len(byID))
+	for _, id := range input.EmployeeIDs {
+		if totals, ok := byID[id]; ok {
+			result = append(result, totals)
+			delete(byID, id)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestHourTotals(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, bob := ids["Alice"], ids["Bob"]
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {"2024-06-10": "Lundi de Pentecôte"}}})
+	for _, punch := range []model.TimeEntry{
+		{EmployeeID: alice, Kind: model.PunchIn, DeviceID: "kiosk-1", Nonce: "n1", PunchedAt: time.Date(2024, 6, 3, 8, 58, 0, 0, time.UTC)},
+		{EmployeeID: alice, Kind: model.PunchOut, DeviceID: "kiosk-1", Nonce: "n2", PunchedAt: time.Date(2024, 6, 3, 12, 28, 0, 0, time.UTC)},
+	} {
+		_, _, err := svc.Punch(ctx, punch)
+		require.NoError(t, err)
+	}
+
+	// The totals come in the order asked for, the unknown employees left out; Bob starts on the holiday.
+	totals, err := svc.HourTotals(ctx, HourTotalsInput{EmployeeIDs: []uint{bob, 999, alice}, From: "2024-06-03", To: "2024-06-16"})
+	require.NoError(t, err)
+	require.Equal(t, []HourTotals{
+		{EmployeeID: bob, PlannedHours: 4, PremiumHours: 4},
+		{EmployeeID: alice, PlannedHours: 14, ActualHours: 3.5, PremiumHours: 7},
+	}, totals)
+
+	for _, input := range []HourTotalsInput{
+		{From: "2024-06-03", To: "2024-06-16"},
+		{EmployeeIDs: make([]uint, maxHourTotalsEmployees+1), From: "2024-06-03", To: "2024-06-16"},
+		{EmployeeIDs: []uint{alice}, From: "2024-06-16", To: "2024-06-03"},
+		{EmployeeIDs: []uint{alice}, From: "2020-01-01", To: "2024-06-03"},
+	} {
+		_, err := svc.HourTotals(ctx, input)
+		var apiErr *apierror.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, apierror.KindValidation, apiErr.Kind, "%+v", input)
+	}
+}
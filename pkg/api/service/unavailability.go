@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"gorm.io/gorm"
+	"time"
+	"unicode/utf8"
+)
+
+// CreateUnavailability records a time an employee declared it cannot work.
+func (s *EmployeeService) CreateUnavailability(ctx context.Context, employeeID uint, input model.UnavailabilityInput) (*model.Unavailability, error) {
+	unavailability, err := unavailabilityFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	var employee model.Employee
+	if err := s.repo.GetEmployeeByID(ctx, employeeID, &employee); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("employee %d not found", employeeID)).WithCode(apierror.CodeEmployeeNotFound)
+		}
+		return nil, err
+	}
+	unavailability.EmployeeID = employeeID
+	if err := s.repo.UnavailabilityCreate(ctx, unavailability); err != nil {
+		return nil, err
+	}
+	return unavailability, nil
+}
+
+// UpdateUnavailability replaces an unavailability of an employee.
+func (s *EmployeeService) UpdateUnavailability(ctx context.Context, employeeID, id uint, input model.UnavailabilityInput) (*model.Unavailability, error) {
+	unavailability, err := unavailabilityFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	unavailability.ID, unavailability.EmployeeID = id, employeeID
+	if err := s.repo.UnavailabilityUpdate(ctx, unavailability); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("unavailability %d of employee %d not found", id, employeeID)).WithCode(apierror.CodeAvailabilityNotFound)
+		}
+		return nil, err
+	}
+	unavailabilities, err := s.repo.UnavailabilityListByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range unavailabilities {
+		if unavailabilities[i].ID == id {
+			return &unavailabilities[i], nil
+		}
+	}
+	return unavailability, nil
+}
+
+// ListUnavailabilities returns the unavailabilities of an employee.
+func (s *EmployeeService) ListUnavailabilities(ctx context.Context, employeeID uint) ([]model.Unavailability, error) {
+	return s.repo.UnavailabilityListByEmployee(ctx, employeeID)
+}
+
+// DeleteUnavailability removes an unavailability of an employee.
+func (s *EmployeeService) DeleteUnavailability(ctx context.Context, employeeID, id uint) error {
+	if err := s.repo.UnavailabilityDelete(ctx, employeeID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("unavailability %d of employee %d not found", id, employeeID)).WithCode(apierror.CodeAvailabilityNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// UnavailabilityConflict is a slot an employee works on Date while it declared it cannot work.
+type UnavailabilityConflict struct {
+	Date             string         `json:"date"`
+	DayName          string         `json:"dayName"`
+	Slot             model.TimeSlot `json:"slot"`
+	UnavailabilityID uint           `json:"unavailabilityId"`
+	Reason           string         `json:"reason,omitempty"`
+}
+
+// UnavailabilityConflicts lists the slots of the monthly calendar of an employee colliding with one of its
+// unavailabilities. The days of leave are not worked and have no conflict.
+func (s *EmployeeService) UnavailabilityConflicts(ctx context.Context, employeeID uint, month string, year int) ([]UnavailabilityConflict, error) {
+	entries, err := s.FetchEmployeeSchedule(ctx, employeeID, month, year)
+	if err != nil {
+		return nil, err
+	}
+	unavailabilities, err := s.repo.UnavailabilityListByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := make([]UnavailabilityConflict, 0)
+	for _, entry := range entries {
+		date, err := time.Parse("2006-01-02", entry.Date)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Leave != nil {
+			continue
+		}
+		for _, slot := range entry.TimeSlots {
+			for _, unavailability := range unavailabilities {
+				collides, err := unavailableDuring(unavailability, date, slot)
+				if err != nil {
+					return nil, err
+				}
+				if collides {
+					conflicts = append(conflicts, UnavailabilityConflict{Date: entry.Date, DayName: entry.DayName, Slot: slot,
+						UnavailabilityID: unavailability.ID, Reason: unavailability.Reason})
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// unavailableDuring reports whether unavailability collides with slot worked on date.
+func unavailableDuring(unavailability model.Unavailability, date time.Time, slot model.TimeSlot) (bool, error) {
+	day := date.Format("2006-01-02")
+	if unavailability.ValidFrom != nil && day < unavailability.ValidFrom.Format("2006-01-02") ||
+		unavailability.ValidTo != nil && day > unavailability.ValidTo.Format("2006-01-02") ||
+		unavailability.DayName != "" && unavailability.DayName != date.Weekday().String() {
+		return false, nil
+	}
+	if unavailability.StartTime == nil || unavailability.EndTime == nil {
+		return true, nil
+	}
+	start, end, err := slot.Clock()
+	if err != nil {
+		return false, fmt.Errorf("slot %s-%s of %s: invalid time", slot.Start, slot.End, day)
+	}
+	from := time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	until := from + end.Sub(start)
+	return from < clockOf(*unavailability.EndTime) && clockOf(*unavailability.StartTime) < until, nil
+}
+
+// unavailabilityFromInput checks an unavailability as declared, see model.UnavailabilityInput.
+func unavailabilityFromInput(input model.UnavailabilityInput) (*model.Unavailability, error) {
+	unavailability := &model.Unavailability{DayName: input.DayName, Reason: input.Reason}
+	if input.DayName != "" && findDayIndex(input.DayName, daysOrder) == -1 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid dayName: %s", input.DayName)).WithCode(apierror.CodeDayNameInvalid)
+	}
+	if input.DayName == "" {
+		if input.From == "" {
+			return nil, apierror.Validation("from is required unless dayName is given, expected YYYY-MM-DD").WithCode(apierror.CodeFieldRequired)
+		}
+		if input.To == "" {
+			input.To = input.From
+		}
+	}
+	switch {
+	case input.From != "" && input.To != "":
+		from, to, err := ParseDateRange(input.From, input.To)
+		if err != nil {
+			return nil, err
+		}
+		unavailability.ValidFrom, unavailability.ValidTo = &from, &to
+	case input.From != "" || input.To != "":
+		date, err := time.Parse("2006-01-02", input.From+input.To)
+		if err != nil {
+			return nil, apierror.Validation(fmt.Sprintf("invalid date %s, expected YYYY-MM-DD", input.From+input.To)).WithCode(apierror.CodeDateInvalid)
+		}
+		if input.From != "" {
+			unavailability.ValidFrom = &date
+		} else {
+			unavailability.ValidTo = &date
+		}
+	}
+
+	if (input.Start == "") != (input.End == "") {
+		return nil, apierror.Validation("start and end must be given together, or neither for the whole day").WithCode(apierror.CodeFieldRequired)
+	}
+	if input.Start != "" {
+		start, errStart := time.Parse("15:04", input.Start)
+		end, errEnd := time.Parse("15:04", input.End)
+		if errStart != nil || errEnd != nil {
+			return nil, apierror.Validation(fmt.Sprintf("invalid time range %s-%s, expected HH:MM", input.Start, input.End)).WithCode(apierror.CodeTimeFormatInvalid)
+		}
+		unavailability.StartTime, unavailability.EndTime = &model.CustomTime{Time: start}, &model.CustomTime{Time: end}
+		if err := validateTimeRange(*unavailability.StartTime, *unavailability.EndTime); err != nil {
+			return nil, err
+		}
+	}
+	if utf8.RuneCountInString(input.Reason) > 255 {
+		return nil, apierror.Validation("reason must be at most 255 characters long")
+	}
+	return unavailability, nil
+}
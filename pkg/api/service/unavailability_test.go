@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUnavailabilityConflicts(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice, approver := ids["Alice"], uint(1)
+	conflicts := func() []UnavailabilityConflict {
+		conflicts, err := svc.UnavailabilityConflicts(ctx, alice, "June", 2024)
+		require.NoError(t, err)
+		return conflicts
+	}
+
+	// Alice cannot work on Monday mornings from the 10th, nor at all on the 3rd.
+	mornings, err := svc.CreateUnavailability(ctx, alice, model.UnavailabilityInput{DayName: "Monday", From: "2024-06-10", Start: "08:00", End: "10:00"})
+	require.NoError(t, err)
+	require.Nil(t, mornings.ValidTo)
+	dentist, err := svc.CreateUnavailability(ctx, alice, model.UnavailabilityInput{From: "2024-06-03", Reason: "dentist"})
+	require.NoError(t, err)
+	require.Equal(t, "2024-06-03", dentist.ValidTo.Format("2006-01-02"), "To defaults to From")
+	listed, err := svc.ListUnavailabilities(ctx, alice)
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+
+	found := conflicts()
+	require.Len(t, found, 5)
+	require.Equal(t, UnavailabilityConflict{Date: "2024-06-03", DayName: "Monday", Slot: found[0].Slot, UnavailabilityID: dentist.ID, Reason: "dentist"}, found[0])
+	require.Equal(t, "2024-06-03", found[1].Date, "The whole day collides with both slots")
+	for _, conflict := range found[2:] {
+		require.Equal(t, mornings.ID, conflict.UnavailabilityID)
+		require.Equal(t, "09:00", conflict.Slot.Start)
+	}
+
+	// Approved leave is not worked: the unavailability on the 10th no longer collides with anything.
+	requested, err := svc.RequestLeave(ctx, alice, model.LeaveInput{From: "2024-06-10"})
+	require.NoError(t, err)
+	_, err = svc.ApproveLeave(ctx, requested[0].ID, &approver)
+	require.NoError(t, err)
+	require.Len(t, conflicts(), 4)
+
+	// Moved to the lunch break, the mornings touch the slots without colliding.
+	updated, err := svc.UpdateUnavailability(ctx, alice, mornings.ID, model.UnavailabilityInput{DayName: "Monday", Start: "12:00", End: "13:00", Reason: "lunch"})
+	require.NoError(t, err)
+	require.Equal(t, "lunch", updated.Reason)
+	require.Nil(t, updated.ValidFrom)
+	require.Len(t, conflicts(), 2)
+
+	_, err = svc.UpdateUnavailability(ctx, ids["Bob"], mornings.ID, model.UnavailabilityInput{DayName: "Monday"})
+	require.Equal(t, apierror.CodeAvailabilityNotFound, apierror.CodeOf(err), "The unavailability belongs to Alice")
+	require.NoError(t, svc.DeleteUnavailability(ctx, alice, dentist.ID))
+	require.Empty(t, conflicts())
+	err = svc.DeleteUnavailability(ctx, alice, dentist.ID)
+	require.Equal(t, apierror.CodeAvailabilityNotFound, apierror.CodeOf(err))
+}
+
+func TestCreateUnavailabilityRejects(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	for _, test := range []struct {
+		name     string
+		employee uint
+		input    model.UnavailabilityInput
+		code     apierror.Code
+	}{
+		{"day name", ids["Alice"], model.UnavailabilityInput{DayName: "Lundi"}, apierror.CodeDayNameInvalid},
+		{"no day nor date", ids["Alice"], model.UnavailabilityInput{Start: "09:00", End: "10:00"}, apierror.CodeFieldRequired},
+		{"date", ids["Alice"], model.UnavailabilityInput{DayName: "Monday", To: "2024-13-01"}, apierror.CodeDateInvalid},
+		{"start without end", ids["Alice"], model.UnavailabilityInput{From: "2024-06-03", Start: "09:00"}, apierror.CodeFieldRequired},
+		{"time", ids["Alice"], model.UnavailabilityInput{From: "2024-06-03", Start: "9h", End: "10h"}, apierror.CodeTimeFormatInvalid},
+		{"range", ids["Alice"], model.UnavailabilityInput{From: "2024-06-03", Start: "10:00", End: "09:00"}, apierror.CodeTimeRangeInvalid},
+		{"employee", 999, model.UnavailabilityInput{From: "2024-06-03"}, apierror.CodeEmployeeNotFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := svc.CreateUnavailability(ctx, test.employee, test.input)
+			require.Equal(t, test.code, apierror.CodeOf(err))
+		})
+	}
+	listed, err := svc.ListUnavailabilities(ctx, ids["Alice"])
+	require.NoError(t, err)
+	require.Empty(t, listed)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/validation"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validatorTimeout bounds the answer of an external validator, which holds the write it checks.
+const validatorTimeout = 5 * time.Second
+
+// ValidationConfig is what checks the schedules written for the tenant, see model.ValidationSettings.
+type ValidationConfig struct {
+	model.ValidationSettings
+	// Default is set when the tenant has no settings of its own and gets those of the deployment.
+	Default bool `json:"default"`
+	// Available are the names of the validators compiled into the server.
+	Available []string `json:"available"`
+}
+
+// namedValidator is a validator as named in the errors it causes.
+type namedValidator struct {
+	name      string
+	validator validation.Validator
+}
+
+// UseValidators sets the validators of the tenants without validation settings of their own: the names
+// of compiled-in validators and the URL of an external one, empty for none.
+func (s *EmployeeService) UseValidators(names []string, url string) error {
+	settings := model.ValidationSettings{Validators: names, URL: url}
+	if err := checkValidationSettings(&settings); err != nil {
+		return err
+	}
+	s.validation = settings
+	return nil
+}
+
+// GetValidationConfig returns the validators of the tenant.
+func (s *EmployeeService) GetValidationConfig(ctx context.Context) (*ValidationConfig, error) {
+	config := &ValidationConfig{Available: validation.Names()}
+	settings, err := s.repo.ValidationSettingsGet(ctx)
+	switch {
+	case err == nil:
+		config.ValidationSettings = *settings
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		config.ValidationSettings, config.Default = s.validation, true
+	default:
+		return nil, err
+	}
+	if config.Validators == nil {
+		config.Validators = []string{}
+	}
+	return config, nil
+}
+
+// SetValidationConfig replaces the validators of the tenant. The writes already made are not checked again.
+func (s *EmployeeService) SetValidationConfig(ctx context.Context, settings model.ValidationSettings) (*ValidationConfig, error) {
+	if err := checkValidationSettings(&settings); err != nil {
+		return nil, err
+	}
+	if err := s.repo.ValidationSettingsSet(ctx, &settings); err != nil {
+		return nil, err
+	}
+	return s.GetValidationConfig(ctx)
+}
+
+// checkValidationSettings rejects the settings naming unknown validators and drops the duplicate names.
+func checkValidationSettings(settings *model.ValidationSettings) error {
+	names := make([]string, 0, len(settings.Validators))
+	seen := make(map[string]bool, len(settings.Validators))
+	for _, name := range settings.Validators {
+		if _, ok := validation.Lookup(name); !ok {
+			return apierror.Validation(fmt.Sprintf("validators must be among %s, got: %q", strings.Join(validation.Names(), ", "), name))
+		}
+		if !seen[name] {
+			names, seen[name] = append(names, name), true
+		}
+	}
+	settings.Validators = names
+	if settings.URL != "" && !isHTTPURL(settings.URL) {
+		return apierror.Validation(fmt.Sprintf("url must be an absolute http or https URL, got: %q", settings.URL))
+	}
+	return nil
+}
+
+// validators returns the validators of the tenant of ctx, the external one last.
+func (s *EmployeeService) validators(ctx context.Context) ([]namedValidator, error) {
+	settings := s.validation
+	if stored, err := s.repo.ValidationSettingsGet(ctx); err == nil {
+		settings = *stored
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	validators := make([]namedValidator, 0, len(settings.Validators)+1)
+	for _, name := range settings.Validators {
+		validator, ok := validation.Lookup(name)
+		if !ok {
+			return nil, apierror.Unavailable(fmt.Sprintf("the validator %q is not compiled into this server", name), nil).
+				WithCode(apierror.CodeValidatorUnavailable)
+		}
+		validators = append(validators, namedValidator{name: name, validator: validator})
+	}
+	if settings.URL != "" {
+		validators = append(validators, namedValidator{name: "external", validator: validation.Remote{URL: settings.URL, Client: s.validatorClient}})
+	}
+	return validators, nil
+}
+
+// checkImportRules checks the employees of an import against the validators of the tenant.
+func (s *EmployeeService) checkImportRules(ctx context.Context, employees []*model.Employee) error {
+	validators, err := s.validators(ctx)
+	if err != nil || len(validators) == 0 {
+		return err
+	}
+	change := &validation.Change{Stage: validation.StageImport, Employees: make([]model.Employee, len(employees))}
+	for i, employee := range employees {
+		change.Employees[i] = *employee
+	}
+	return s.checkRules(ctx, validators, change)
+}
+
+// checkSlotRules checks the weekly schedule of the employee of slot, once slot is written, against the
+// validators of the tenant.
+func (s *EmployeeService) checkSlotRules(ctx context.Context, stage string, slot model.Schedule) error {
+	validators, err := s.validators(ctx)
+	if err != nil || len(validators) == 0 {
+		return err
+	}
+	employee, err := s.repo.GetEmployeeWithSchedules(ctx, slot.EmployeeID)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range employee.Schedules {
+		if employee.Schedules[i].ID == slot.ID {
+			employee.Schedules[i], replaced = slot, true
+		}
+	}
+	if !replaced {
+		employee.Schedules = append(employee.Schedules, slot)
+	}
+	employee.RoleTemplate = nil
+	return s.checkRules(ctx, validators, &validation.Change{Stage: stage, Employees: []model.Employee{*employee}})
+}
+
+// checkEmployeeRules checks employee, with its own slots as they would be once written, against the
+// validators of the tenant.
+func (s *EmployeeService) checkEmployeeRules(ctx context.Context, stage string, employee model.Employee) error {
+	validators, err := s.validators(ctx)
+	if err != nil || len(validators) == 0 {
+		return err
+	}
+	employee.RoleTemplate = nil
+	return s.checkRules(ctx, validators, &validation.Change{Stage: stage, Employees: []model.Employee{employee}})
+}
+
+// checkRules runs every validator on change, given the weeks of the rotation of its employees, and reports
+// all the violations at once. A validator that fails rejects the change, which could not be checked.
+func (s *EmployeeService) checkRules(ctx context.Context, validators []namedValidator, change *validation.Change) error {
+	rotations, err := s.loadRotations(ctx)
+	if err != nil {
+		return err
+	}
+	change.Weeks = make([][]string, len(change.Employees))
+	for i := range change.Employees {
+		change.Weeks[i] = rotations.of(change.Employees[i].RotationPatternID).WeekNames()
+	}
+	ctx, cancel := context.WithTimeout(ctx, validatorTimeout)
+	defer cancel()
+	var params []apierror.InvalidParam
+	for _, v := range validators {
+		violations, err := v.validator.Validate(ctx, change)
+		if err != nil {
+			return apierror.Unavailable(fmt.Sprintf("the %s validator could not check the schedules", v.name), err).
+				WithCode(apierror.CodeValidatorUnavailable)
+		}
+		for _, violation := range violations {
+			params = append(params, apierror.InvalidParam{Name: violation.Employee, Reason: violation.Rule + ": " + violation.Message})
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return apierror.InvalidParams(fmt.Sprintf("the schedules break %d rule(s) of the tenant", len(params)), params).
+		WithCode(apierror.CodeScheduleRuleViolated)
+}
+
+// ViolationReport is the rules of the tenant broken by the calendar of an employee over a month.
+type ViolationReport struct {
+	EmployeeID uint   `json:"employeeId"`
+	Month      string `json:"month"`
+	// Rules are the names of the rules checked: the validators of the tenant that check consecutive days,
+	// see validation.DayRule.
+	Rules      []string               `json:"rules"`
+	Violations []validation.Violation `json:"violations"`
+}
+
+// EmployeeViolations checks the calendar of an employee over a month against the validators of the tenant,
+// as the writes of its slots are: the days of the month follow each other, with their overrides and without
+// the slots of leave days, and the overnight slots are checked whole on the day they start. The days before
+// and after the month are not checked with it.
+func (s *EmployeeService) EmployeeViolations(ctx context.Context, employeeID uint, month string, year int) (*ViolationReport, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	first := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	// The day after the month is read for the rest of the slots crossing midnight on its last day.
+	employee, entries, err := s.calendarBetween(ctx, employeeID, first, first.AddDate(0, 1, 0), "")
+	if err != nil {
+		return nil, err
+	}
+	validators, err := s.validators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([][]model.TimeSlot, len(entries))
+	for i, entry := range entries {
+		slots[i] = entry.TimeSlots
+	}
+	slots = wholeSlots(slots)
+	days := make([]validation.Day, len(entries)-1)
+	for i := range days {
+		date, err := time.Parse("2006-01-02", entries[i].Date)
+		if err != nil {
+			return nil, err
+		}
+		if entries[i].Leave != nil {
+			slots[i] = nil
+		}
+		if days[i], err = validationDay(date, slots[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ViolationReport{EmployeeID: employeeID, Month: first.Format("2006-01"), Rules: []string{}, Violations: []validation.Violation{}}
+	for _, v := range validators {
+		rule, ok := v.validator.(validation.DayRule)
+		if !ok {
+			continue
+		}
+		report.Rules = append(report.Rules, v.name)
+		for _, message := range rule.CheckDays(days, false) {
+			report.Violations = append(report.Violations, validation.Violation{Rule: v.name, EmployeeID: employeeID, Employee: employee.Name, Message: message})
+		}
+	}
+	return report, nil
+}
+
+// wholeSlots rebuilds the overnight slots of consecutive days of a calendar, which splits them at midnight,
+// see model.TimeSlot: the part of a slot crossing midnight is joined with the part it continues with on the
+// next day, and the slot stays on the day it starts. The parts continued from the day before the first are
+// left out, and a slot crossing midnight on the last day keeps ending at midnight.
+func wholeSlots(days [][]model.TimeSlot) [][]model.TimeSlot {
+	whole := make([][]model.TimeSlot, len(days))
+	joined := make(map[[2]int]bool)
+	for i, slots := range days {
+		for _, slot := range slots {
+			if slot.Continued {
+				continue
+			}
+			if slot.CrossesMidnight && i+1 < len(days) {
+				for j, rest := range days[i+1] {
+					if rest.Continued && !joined[[2]int{i + 1, j}] && rest.Location == slot.Location && rest.Task == slot.Task {
+						slot.End, joined[[2]int{i + 1, j}] = rest.End, true
+						break
+					}
+				}
+			}
+			whole[i] = append(whole[i], slot)
+		}
+	}
+	return whole
+}
+
+// validationDay returns date as the day the rules of the validators check, with the slots worked that day.
+func validationDay(date time.Time, slots []model.TimeSlot) (validation.Day, error) {
+	day := validation.Day{Name: date.Format("2006-01-02"), Weekday: date.Weekday()}
+	for _, slot := range slots {
+		start, end, err := slot.Clock()
+		if err != nil {
+			return day, fmt.Errorf("slot %s-%s of %s: invalid time", slot.Start, slot.End, day.Name)
+		}
+		day.Slots = append(day.Slots, model.Schedule{DayName: date.Weekday().String(),
+			StartTime: model.CustomTime{Time: start}, EndTime: model.CustomTime{Time: end}})
+	}
+	sort.SliceStable(day.Slots, func(i, j int) bool { return day.Slots[i].StartTime.Before(day.Slots[j].StartTime.Time) })
+	return day, nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/db/repo/repotest"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEmployeeViolationsChecksOvernightSlotsWhole(t *testing.T) {
+	svc := NewEmployeeService(repotest.NewInMemoryRepository(t))
+	svc.UseHolidayProvider(&holiday.File{Regions: map[string]map[string]string{"FR": {}}})
+	ctx := context.Background()
+	night := []model.ScheduleInput{{Start: "22:00", End: "06:00"}}
+	week := model.WeeklyScheduleInput{Monday: night, Tuesday: night, Wednesday: night, Thursday: night, Friday: night, Saturday: night}
+	require.NoError(t, svc.LoadEmployeesFromInput(ctx, []model.EmployeeInput{
+		{Name: "Night Guard", StartDate: "2024-04-01", Weeks: map[string]model.WeeklyScheduleInput{"A": week, "B": week}},
+	}))
+	employees, err := svc.repo.GetEmployees(ctx)
+	require.NoError(t, err)
+	id := employees[0].ID
+	require.NoError(t, svc.UseValidators([]string{"min-daily-rest", "max-consecutive-days"}, ""))
+
+	// Six nights from Monday to Saturday: 16 hours of rest every day, and Sunday off.
+	report, err := svc.EmployeeViolations(ctx, id, "April", 2024)
+	require.NoError(t, err)
+	require.Equal(t, "2024-04", report.Month)
+	require.Equal(t, []string{"min-daily-rest", "max-consecutive-days"}, report.Rules)
+	require.Empty(t, report.Violations)
+
+	// Working Sunday the 7th from 16:00 leaves 10 hours of rest after Saturday night, and 7 days in a row.
+	at := func(hour int) model.CustomTime {
+		return model.CustomTime{Time: time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	_, err = svc.SetScheduleOverride(ctx, id, "2024-04-07", model.ScheduleOverride{Slots: []model.ScheduleOverrideSlot{{StartTime: at(16), EndTime: at(20)}}})
+	require.NoError(t, err)
+	report, err = svc.EmployeeViolations(ctx, id, "April", 2024)
+	require.NoError(t, err)
+	require.Len(t, report.Violations, 2)
+	require.Equal(t, "min-daily-rest", report.Violations[0].Rule)
+	require.Equal(t, "max-consecutive-days", report.Violations[1].Rule)
+
+	_, err = svc.EmployeeViolations(ctx, id, "Avril", 2024)
+	require.Equal(t, apierror.CodeMonthInvalid, apierror.CodeOf(err))
+}
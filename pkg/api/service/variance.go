@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	util "github.com/lichensio/api_server/internal/utils"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"time"
+)
+
+// maxShiftLength bounds the time between a punch in and the punch out closing it: an out further away is taken
+// for the punch out of a later shift, the punch in of the day being forgotten, and the two are left unpaired.
+const maxShiftLength = 16 * time.Hour
+
+// Issues of a day with missing punches.
+const (
+	// PunchesNone flags a day worked according to the calendar without any punch.
+	PunchesNone = "noPunch"
+	// PunchMissingOut flags a punch in not followed by a punch out.
+	PunchMissingOut = "missingOut"
+	// PunchMissingIn flags a punch out not preceded by a punch in.
+	PunchMissingIn = "missingIn"
+)
+
+// MissingPunch is a day of an employee whose punches do not account for the hours worked.
+type MissingPunch struct {
+	Date         string  `json:"date"`
+	DayName      string  `json:"dayName"`
+	PlannedHours float64 `json:"plannedHours"`
+	Issue        string  `json:"issue"`
+}
+
+// HoursVariance compares the hours planned for an employee over a month with the hours it clocked.
+type HoursVariance struct {
+	EmployeeID uint   `json:"employeeId"`
+	Employee   string `json:"employee"`
+	// PlannedHours are the hours scheduled, the days of approved leave left out.
+	PlannedHours float64 `json:"plannedHours"`
+	// ClockedHours are the hours from each punch in to the punch out that follows it.
+	ClockedHours float64 `json:"clockedHours"`
+	// DeltaHours is ClockedHours less PlannedHours: negative when the employee clocked less than planned.
+	DeltaHours     float64        `json:"deltaHours"`
+	MissingPunches []MissingPunch `json:"missingPunches"`
+}
+
+// VarianceReport reconciles the hours planned with the hours clocked of every employee over a month.
+type VarianceReport struct {
+	Month     string          `json:"month"`
+	Year      int             `json:"year"`
+	Employees []HoursVariance `json:"employees"`
+	// Partial is set when generation was cut short; only the employees listed were reconciled.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// VarianceReport reconciles, for every employee active during the month, the hours of its calendar,
+// overrides applied and leave left out, with the hours it clocked. Punches are paired as HourTotals pairs
+// them, at most maxShiftLength apart, the hours of a pair counting on the day of its punch in, in the time zone
+// of the deployment; the day before and the day after the month are read so that a shift crossing midnight at
+// either end pairs up. The days before the start date of an employee are left out. The days planned up to
+// today without any punch are flagged, and so are the days with a punch left unpaired. If ctx is done midway,
+// the employees reconciled so far are returned, marked partial, along with ctx.Err().
+func (s *EmployeeService) VarianceReport(ctx context.Context, month string, year int) (*VarianceReport, error) {
+	monthNum := util.MonthStringToNumber(month)
+	if monthNum == 0 {
+		return nil, apierror.Validation(fmt.Sprintf("invalid month: %s", month)).WithCode(apierror.CodeMonthInvalid)
+	}
+	firstDayOfMonth := time.Date(year, time.Month(monthNum), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := firstDayOfMonth.AddDate(0, 1, -1)
+	employees, rotations, err := s.teamCalendars(ctx, firstDayOfMonth, lastDayOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.TimeEntryListBetween(ctx, firstDayOfMonth.AddDate(0, 0, -1), lastDayOfMonth.AddDate(0, 0, 2).Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	punches := make(map[uint][]model.TimeEntry)
+	for _, entry := range entries {
+		punches[entry.EmployeeID] = append(punches[entry.EmployeeID], entry)
+	}
+	holidays := s.holidaysBetween(ctx, firstDayOfMonth.AddDate(0, 0, -1), lastDayOfMonth)
+	today := s.today("").Format("2006-01-02")
+
+	report := &VarianceReport{Month: month, Year: year, Employees: make([]HoursVariance, 0, len(employees))}
+	var cancelled error
+	for i := range employees {
+		if cancelled = ctx.Err(); cancelled != nil {
+			break
+		}
+		employee := &employees[i]
+		clocked, issues := pairPunches(punches[employee.ID], firstDayOfMonth, lastDayOfMonth, s.TimeZone(""))
+		variance := HoursVariance{EmployeeID: employee.ID, Employee: employee.Name, MissingPunches: []MissingPunch{}}
+		calendar := monthlyCalendar(employee, rotations.of(employee.RotationPatternID), firstDayOfMonth, lastDayOfMonth, holidays, employee.LeaveDays, employee.Overrides, "")
+		for _, entry := range calendar {
+			if employee.StartDate.Format("2006-01-02") > entry.Date {
+				continue
+			}
+			hours, err := s.CalculateMonthlyHours([]model.MonthlySchedule{entry})
+			if err != nil {
+				return nil, err
+			}
+			planned := hours.Worked
+			variance.PlannedHours += planned
+			day, punched := clocked[entry.Date]
+			variance.ClockedHours += day
+			flag := func(issue string) {
+				variance.MissingPunches = append(variance.MissingPunches,
+					MissingPunch{Date: entry.Date, DayName: entry.DayName, PlannedHours: roundHours(planned), Issue: issue})
+			}
+			if planned > 0 && !punched && len(issues[entry.Date]) == 0 && entry.Date <= today {
+				flag(PunchesNone)
+			}
+			for _, issue := range issues[entry.Date] {
+				flag(issue)
+			}
+		}
+		variance.PlannedHours = roundHours(variance.PlannedHours)
+		variance.ClockedHours = roundHours(variance.ClockedHours)
+		variance.DeltaHours = roundHours(variance.ClockedHours - variance.PlannedHours)
+		report.Employees = append(report.Employees, variance)
+	}
+	report.Partial = cancelled != nil
+	return report, cancelled
+}
+
+// pairPunches pairs each punch in with the punch out following it, ordered punches of an employee, and returns
+// the hours clocked by date of the punch in, in zone, along with the issues of the punches left unpaired, for
+// the dates from first to last included.
+func pairPunches(punches []model.TimeEntry, first, last time.Time, zone *time.Location) (map[string]float64, map[string][]string) {
+	clocked := make(map[string]float64)
+	issues := make(map[string][]string)
+	inMonth := func(date string) bool {
+		return date >= first.Format("2006-01-02") && date <= last.Format("2006-01-02")
+	}
+	for i := 0; i < len(punches); i++ {
+		punch := punches[i]
+		date := punch.PunchedAt.In(zone).Format("2006-01-02")
+		switch {
+		case punch.Kind == model.PunchIn && i+1 < len(punches) && punches[i+1].Kind == model.PunchOut &&
+			punches[i+1].PunchedAt.Sub(punch.PunchedAt) <= maxShiftLength:
+			if inMonth(date) {
+				clocked[date] += punches[i+1].PunchedAt.Sub(punch.PunchedAt).Hours()
+			}
+			i++
+		case !inMonth(date):
+		case punch.Kind == model.PunchIn:
+			issues[date] = append(issues[date], PunchMissingOut)
+		default:
+			issues[date] = append(issues[date], PunchMissingIn)
+		}
+	}
+	return clocked, issues
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestVarianceReport(t *testing.T) {
+	svc, ids := newStationService(t)
+	ctx := context.Background()
+	alice := ids["Alice"]
+	sequence := int64(0)
+	punch := func(kind string, punchedAt time.Time) {
+		sequence++
+		_, _, err := svc.Punch(ctx, model.TimeEntry{EmployeeID: alice, Kind: kind, DeviceID: "kiosk-1",
+			Nonce: punchedAt.Format(time.RFC3339), Sequence: sequence, PunchedAt: punchedAt})
+		require.NoError(t, err)
+	}
+	june := func(day, hour int) time.Time { return time.Date(2024, time.June, day, hour, 0, 0, 0, time.UTC) }
+	// Alice works the 3rd, forgets to punch out on the 10th, does not punch on the 17th and forgets to punch
+	// in on the 24th. The night of the 30th ends in July.
+	punch(model.PunchIn, june(3, 9))
+	punch(model.PunchOut, june(3, 17))
+	punch(model.PunchIn, june(10, 9))
+	punch(model.PunchOut, june(24, 17))
+	punch(model.PunchIn, june(30, 22))
+	punch(model.PunchOut, june(31, 2))
+
+	report, err := svc.VarianceReport(ctx, "June", 2024)
+	require.NoError(t, err)
+	require.False(t, report.Partial)
+	variances := make(map[string]HoursVariance)
+	for _, variance := range report.Employees {
+		variances[variance.Employee] = variance
+	}
+	require.Len(t, variances, 2)
+	require.Equal(t, HoursVariance{EmployeeID: alice, Employee: "Alice", PlannedHours: 28, ClockedHours: 12, DeltaHours: -16,
+		MissingPunches: []MissingPunch{
+			{Date: "2024-06-10", DayName: "Monday", PlannedHours: 7, Issue: PunchMissingOut},
+			{Date: "2024-06-17", DayName: "Monday", PlannedHours: 7, Issue: PunchesNone},
+			{Date: "2024-06-24", DayName: "Monday", PlannedHours: 7, Issue: PunchMissingIn},
+		}}, variances["Alice"])
+
+	// Bob starts on the 10th: the 3rd is neither planned nor flagged.
+	bob := variances["Bob"]
+	require.Equal(t, 12.0, bob.PlannedHours)
+	require.Equal(t, -12.0, bob.DeltaHours)
+	require.Len(t, bob.MissingPunches, 3)
+	require.Equal(t, "2024-06-10", bob.MissingPunches[0].Date)
+
+	_, err = svc.VarianceReport(ctx, "Juin", 2024)
+	require.Equal(t, apierror.CodeMonthInvalid, apierror.CodeOf(err))
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/webhook"
+	"gorm.io/gorm"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds a webhook delivery, connection and answer included.
+const webhookTimeout = 10 * time.Second
+
+// CreateWebhook validates and stores a new webhook, returned with the secret signing its deliveries.
+func (s *EmployeeService) CreateWebhook(ctx context.Context, hook model.Webhook) (*model.Webhook, error) {
+	if err := validateWebhook(&hook); err != nil {
+		return nil, err
+	}
+	hook.ID, hook.UUID = 0, ""
+	hook.Secret = newWebhookSecret()
+	hook.CreatedAt, hook.UpdatedAt = time.Time{}, time.Time{}
+	if err := s.repo.WebhookCreate(ctx, &hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// ListWebhooks returns every webhook, without their secrets.
+func (s *EmployeeService) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	hooks, err := s.repo.WebhookList(ctx)
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, err
+}
+
+// UpdateWebhook replaces the name, URL, format, template and events of a webhook, keeping its secret.
+func (s *EmployeeService) UpdateWebhook(ctx context.Context, id uint, hook model.Webhook) (*model.Webhook, error) {
+	if err := validateWebhook(&hook); err != nil {
+		return nil, err
+	}
+	hook.ID = id
+	if err := s.repo.WebhookUpdate(ctx, &hook); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("webhook %d not found", id)).WithCode(apierror.CodeWebhookNotFound)
+		}
+		return nil, err
+	}
+	updated, err := s.findWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated.Secret = ""
+	return updated, nil
+}
+
+// DeleteWebhook removes a webhook.
+func (s *EmployeeService) DeleteWebhook(ctx context.Context, id uint) error {
+	if err := s.repo.WebhookDelete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apierror.NotFound(fmt.Sprintf("webhook %d not found", id)).WithCode(apierror.CodeWebhookNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// WebhookTest is the outcome of a test delivery: the payload rendered for the sample event and the answer
+// of the consumer.
+type WebhookTest struct {
+	Delivered bool   `json:"delivered"`
+	Status    int    `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Payload   string `json:"payload"`
+}
+
+// TestWebhook renders the sample event in the format of a webhook and sends it. A consumer that cannot be
+// reached or rejects the payload is reported in the result, not as an error.
+func (s *EmployeeService) TestWebhook(ctx context.Context, id uint) (*WebhookTest, error) {
+	hook, err := s.findWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := renderWebhook(hook, webhook.SampleEvent(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	delivery := webhook.DeliverSigned(ctx, s.webhookClient, hook.URL, payload, webhook.Signing{Secret: hook.Secret, Event: "webhook.test", DeliveryID: "test"})
+	return &WebhookTest{Delivered: delivery.Delivered(), Status: delivery.Status, Error: delivery.Error, Payload: string(payload)}, nil
+}
+
+func (s *EmployeeService) findWebhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	hook, err := s.repo.WebhookFindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("webhook %d not found", id)).WithCode(apierror.CodeWebhookNotFound)
+		}
+		return nil, err
+	}
+	return hook, nil
+}
+
+// validateWebhook checks the URL, events and format of a webhook, parsing its template if it has one. The
+// format defaults to json; the template is dropped for the presets.
+func validateWebhook(hook *model.Webhook) error {
+	if !isHTTPURL(hook.URL) {
+		return apierror.Validation(fmt.Sprintf("url must be an absolute http or https URL, got: %q", hook.URL))
+	}
+	sort.Strings(hook.Events)
+	subscribed := make([]string, 0, len(hook.Events))
+	for _, eventType := range hook.Events {
+		known := false
+		for _, webhookType := range events.WebhookTypes {
+			known = known || eventType == webhookType
+		}
+		if !known {
+			return apierror.Validation(fmt.Sprintf("events must be among %s, got: %s", strings.Join(events.WebhookTypes, ", "), eventType))
+		}
+		if len(subscribed) == 0 || subscribed[len(subscribed)-1] != eventType {
+			subscribed = append(subscribed, eventType)
+		}
+	}
+	hook.Events = subscribed
+	if hook.Format == "" {
+		hook.Format = "json"
+	}
+	if hook.Format == model.WebhookTemplateFormat {
+		if strings.TrimSpace(hook.Template) == "" {
+			return apierror.Validation("a template is required with the template format")
+		}
+		if _, err := webhook.ParseTemplate(hook.Template); err != nil {
+			return apierror.Validation("invalid template: " + err.Error())
+		}
+		return nil
+	}
+	if _, ok := webhook.Lookup(hook.Format); !ok {
+		return apierror.Validation(fmt.Sprintf("format must be one of %s or %s, got: %s",
+			strings.Join(webhook.Names(), ", "), model.WebhookTemplateFormat, hook.Format))
+	}
+	hook.Template = ""
+	return nil
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL.
+func isHTTPURL(raw string) bool {
+	target, err := url.Parse(raw)
+	return err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != ""
+}
+
+// renderWebhook returns the payload of event in the format of hook.
+func renderWebhook(hook *model.Webhook, event events.Event) ([]byte, error) {
+	if hook.Format == model.WebhookTemplateFormat {
+		tmpl, err := webhook.ParseTemplate(hook.Template)
+		if err != nil {
+			return nil, err
+		}
+		return tmpl.Render(event)
+	}
+	preset, ok := webhook.Lookup(hook.Format)
+	if !ok {
+		return nil, fmt.Errorf("webhook %d has unknown format %s", hook.ID, hook.Format)
+	}
+	return preset.Render(event)
+}
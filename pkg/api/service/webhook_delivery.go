@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"github.com/lichensio/api_server/pkg/api/reqlog"
+	"github.com/lichensio/api_server/pkg/api/webhook"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+// webhookMaxAttempts is the number of attempts after which a delivery the consumer keeps rejecting fails.
+const webhookMaxAttempts = 8
+
+// webhookRetryDelay is the wait before the second attempt of a delivery, doubled after every later attempt
+// up to webhookMaxRetryDelay: the eight attempts span about two hours.
+const (
+	webhookRetryDelay    = 30 * time.Second
+	webhookMaxRetryDelay = time.Hour
+)
+
+// webhookDeliveryLimit is the number of deliveries listed for a webhook.
+const webhookDeliveryLimit = 50
+
+// WebhookQueue configures the workers sending the webhook deliveries, see StartWebhookQueue.
+type WebhookQueue struct {
+	// Workers is the number of deliveries sent at once by this instance, 1 when not set.
+	Workers int
+	// PollInterval is how often idle workers look for the deliveries due, queued by other instances or to be
+	// retried, 5 seconds when not set. The deliveries queued by this instance wake a worker at once.
+	PollInterval time.Duration
+}
+
+// Changes and actions of the schedule.updated events.
+const (
+	ChangeSlot     = "slot"
+	ChangeDelta    = "delta"
+	ChangeOverride = "override"
+	ActionWritten  = "written"
+	ActionDeleted  = "deleted"
+)
+
+// ScheduleUpdate is the data of the schedule.updated events: which part of the schedule of an employee was
+// written or deleted. ID is the row changed and Date the day of an override; Resource is the row written,
+// absent once deleted.
+type ScheduleUpdate struct {
+	EmployeeID uint        `json:"employeeId"`
+	Change     string      `json:"change"`
+	Action     string      `json:"action"`
+	ID         uint        `json:"id,omitempty"`
+	Date       string      `json:"date,omitempty"`
+	Resource   interface{} `json:"resource,omitempty"`
+}
+
+// StartWebhookQueue starts the workers sending the webhook deliveries, until ctx is done. The deliveries are
+// queued whether it runs or not, and sent by the instances running it.
+func (s *EmployeeService) StartWebhookQueue(ctx context.Context, queue WebhookQueue) {
+	if queue.Workers < 1 {
+		queue.Workers = 1
+	}
+	if queue.PollInterval <= 0 {
+		queue.PollInterval = 5 * time.Second
+	}
+	s.webhookWake = make(chan struct{}, queue.Workers)
+	for i := 0; i < queue.Workers; i++ {
+		go s.webhookWorker(ctx, queue.PollInterval)
+	}
+}
+
+// emit publishes an event about data on the bus and queues it for the webhooks of the tenant of ctx
+// subscribed to its type. The change being already made, a delivery that cannot be queued is logged.
+func (s *EmployeeService) emit(ctx context.Context, eventType string, data interface{}) {
+	event := events.New(eventType, data)
+	event.TenantID, _ = repo.TenantFromContext(ctx)
+	s.events.Publish(event)
+	if err := s.queueWebhooks(ctx, event); err != nil {
+		reqlog.From(ctx).Errorf("Failed to queue the webhook deliveries of event %s %s: %v", event.Type, event.ID, err)
+	}
+}
+
+// emitEmployeesCreated emits an employee.created event for each of the employees, without their schedules.
+func (s *EmployeeService) emitEmployeesCreated(ctx context.Context, employees []*model.Employee) {
+	for _, employee := range employees {
+		created := *employee
+		created.Schedules = nil
+		s.emit(ctx, events.EmployeeCreated, created)
+	}
+}
+
+// queueWebhooks renders event for every webhook subscribed to it and queues the deliveries.
+func (s *EmployeeService) queueWebhooks(ctx context.Context, event events.Event) error {
+	hooks, err := s.repo.WebhookList(ctx)
+	if err != nil {
+		return err
+	}
+	var deliveries []model.WebhookDelivery
+	for i := range hooks {
+		hook := &hooks[i]
+		if !hook.Subscribes(event.Type) {
+			continue
+		}
+		payload, err := renderWebhook(hook, event)
+		if err != nil {
+			log.Errorf("Failed to render event %s %s for webhook %d: %v", event.Type, event.ID, hook.ID, err)
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID: hook.ID, EventID: event.ID, EventType: event.Type, Payload: string(payload),
+			Status: model.DeliveryPending, NextAttemptAt: event.OccurredAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := s.repo.WebhookDeliveryCreate(ctx, deliveries); err != nil {
+		return err
+	}
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+		// Every worker is already awake, or the queue does not run on this instance.
+	}
+	return nil
+}
+
+// webhookWorker sends the deliveries due one after the other, then waits to be woken or polls for new ones.
+func (s *EmployeeService) webhookWorker(ctx context.Context, pollInterval time.Duration) {
+	for {
+		now := time.Now()
+		delivery, err := s.repo.WebhookDeliveryClaim(ctx, now, now.Add(-2*webhookTimeout))
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Failed to claim a webhook delivery: %v", err)
+		}
+		if delivery != nil {
+			s.sendDelivery(ctx, delivery)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.webhookWake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// sendDelivery sends a claimed delivery to its webhook and records the outcome, scheduling the next attempt
+// of a delivery the consumer did not accept.
+func (s *EmployeeService) sendDelivery(ctx context.Context, delivery *model.WebhookDelivery) {
+	if delivery.TenantID != 0 {
+		ctx = repo.WithTenant(ctx, delivery.TenantID)
+	}
+	delivery.Attempts++
+	hook, err := s.repo.WebhookFindByID(ctx, delivery.WebhookID)
+	gone := errors.Is(err, gorm.ErrRecordNotFound)
+	var outcome webhook.Delivery
+	switch {
+	case err == nil:
+		outcome = webhook.DeliverSigned(ctx, s.webhookClient, hook.URL, []byte(delivery.Payload),
+			webhook.Signing{Secret: hook.Secret, Event: delivery.EventType, DeliveryID: delivery.UUID})
+	case gone:
+		outcome.Error = "the webhook was deleted"
+	default:
+		outcome.Error = err.Error()
+	}
+	now := time.Now()
+	delivery.LastStatus, delivery.LastError = outcome.Status, outcome.Error
+	switch {
+	case outcome.Delivered():
+		delivery.Status, delivery.DeliveredAt = model.DeliveryDelivered, &now
+	case gone || delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		log.Warnf("Webhook delivery %d of event %s failed after %d attempts: %s", delivery.ID, delivery.EventType, delivery.Attempts, outcome.Error)
+	default:
+		delivery.Status, delivery.NextAttemptAt = model.DeliveryPending, now.Add(retryDelay(delivery.Attempts))
+	}
+	if err := s.repo.WebhookDeliverySave(ctx, delivery); err != nil {
+		log.Errorf("Failed to save webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retryDelay is the wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := webhookRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+// ListWebhookDeliveries returns the last deliveries of a webhook, newest first.
+func (s *EmployeeService) ListWebhookDeliveries(ctx context.Context, id uint) ([]model.WebhookDelivery, error) {
+	if _, err := s.findWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.WebhookDeliveryList(ctx, id, webhookDeliveryLimit)
+}
+
+// RotateWebhookSecret replaces the secret signing the deliveries of a webhook and returns the webhook with
+// its new secret, which is not shown again.
+func (s *EmployeeService) RotateWebhookSecret(ctx context.Context, id uint) (*model.Webhook, error) {
+	secret := newWebhookSecret()
+	if err := s.repo.WebhookSetSecret(ctx, id, secret); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.NotFound(fmt.Sprintf("webhook %d not found", id)).WithCode(apierror.CodeWebhookNotFound)
+		}
+		return nil, err
+	}
+	return s.findWebhook(ctx, id)
+}
+
+// newWebhookSecret returns a random secret signing the deliveries of a webhook.
+func newWebhookSecret() string {
+	var secret [24]byte
+	rand.Read(secret[:])
+	return "whsec_" + hex.EncodeToString(secret[:])
+}
@@ -0,0 +1,131 @@
+// Package slack posts the roster of the day to a Slack channel through an incoming webhook: every morning
+// at a set time, and on demand.
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/lichensio/api_server/pkg/api/webhook"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Message is the body of a post to a Slack incoming webhook, written in Slack's mrkdwn.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// TodayMessage writes "who works today" for the roster of a day: the slots of every employee working, then
+// the employees on leave. The employees off that day are not listed.
+func TodayMessage(day *service.RosterDay) Message {
+	date, _ := time.Parse("2006-01-02", day.Date)
+	var text strings.Builder
+	fmt.Fprintf(&text, "*Who works today, %s*", date.Format("Monday 2 January 2006"))
+	switch {
+	case day.HolidaySource == model.HolidayCompany:
+		fmt.Fprintf(&text, " (closure day: %s)", day.HolidayName)
+	case day.HolidayName != "":
+		fmt.Fprintf(&text, " (public holiday: %s)", day.HolidayName)
+	}
+	var working, leave []string
+	for _, shift := range day.Employees {
+		if shift.Leave != nil {
+			leave = append(leave, shift.Employee)
+			continue
+		}
+		if len(shift.TimeSlots) == 0 {
+			continue
+		}
+		slots := make([]string, len(shift.TimeSlots))
+		for i, slot := range shift.TimeSlots {
+			slots[i] = slot.Start + "–" + slot.End
+			var details []string
+			if slot.Task != "" {
+				details = append(details, slot.Task)
+			}
+			if slot.Location != "" {
+				details = append(details, "at "+slot.Location)
+			}
+			if len(details) > 0 {
+				slots[i] += " (" + strings.Join(details, ", ") + ")"
+			}
+		}
+		working = append(working, fmt.Sprintf("• *%s* %s", shift.Employee, strings.Join(slots, ", ")))
+	}
+	if len(working) == 0 {
+		text.WriteString("\nNobody is scheduled today.")
+	} else {
+		text.WriteString("\n" + strings.Join(working, "\n"))
+	}
+	if len(leave) > 0 {
+		fmt.Fprintf(&text, "\nOn leave: %s", strings.Join(leave, ", "))
+	}
+	return Message{Text: text.String()}
+}
+
+// Reminder posts the roster of the day to the incoming webhook at URL.
+type Reminder struct {
+	Service *service.EmployeeService
+	URL     string
+	// At is the time of day of the daily post, in the local time of the server, from midnight.
+	At     time.Duration
+	Client *http.Client
+}
+
+// ParseAt reads a time of day written HH:MM as the duration since midnight.
+func ParseAt(value string) (time.Duration, error) {
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// Send posts the roster of date and returns the message posted.
+func (r *Reminder) Send(ctx context.Context, date time.Time) (*Message, error) {
+	day, err := r.Service.DayRoster(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	message := TodayMessage(day)
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	if delivery := webhook.Deliver(ctx, r.Client, r.URL, body); !delivery.Delivered() {
+		return nil, errors.New(delivery.Error)
+	}
+	return &message, nil
+}
+
+// Run posts the roster of the day every day at At until ctx is done. A post that fails is logged and not
+// sent again: the next one is the next morning.
+func (r *Reminder) Run(ctx context.Context) {
+	for {
+		next := r.next(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		if _, err := r.Send(ctx, next); err != nil {
+			log.Errorf("Failed to post the roster of %s to Slack: %v", next.Format("2006-01-02"), err)
+		}
+	}
+}
+
+// next returns the first time of the daily post after now.
+func (r *Reminder) next(now time.Time) time.Time {
+	hour, minute := int(r.At/time.Hour), int(r.At%time.Hour/time.Minute)
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return next
+}
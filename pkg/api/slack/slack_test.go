@@ -0,0 +1,39 @@
+package slack
+
+import (
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTodayMessage(t *testing.T) {
+	day := &service.RosterDay{Date: "2024-04-01", DayName: "Monday", HolidayName: "Lundi de Pâques", Employees: []service.RosterShift{
+		{Employee: "Ines", TimeSlots: []model.TimeSlot{{Start: "09:00", End: "12:00", Task: "lab"}, {Start: "14:00", End: "18:00", Location: "Lyon"}}},
+		{Employee: "Paul", Leave: &model.Leave{Description: "congé"}, TimeSlots: []model.TimeSlot{{Start: "09:00", End: "12:00"}}},
+		{Employee: "Zoe"},
+	}}
+	assert.Equal(t, "*Who works today, Monday 1 April 2024* (public holiday: Lundi de Pâques)\n"+
+		"• *Ines* 09:00–12:00 (lab), 14:00–18:00 (at Lyon)\n"+
+		"On leave: Paul", TodayMessage(day).Text)
+
+	day = &service.RosterDay{Date: "2024-04-07", DayName: "Sunday", Employees: []service.RosterShift{{Employee: "Zoe"}}}
+	assert.Equal(t, "*Who works today, Sunday 7 April 2024*\nNobody is scheduled today.", TodayMessage(day).Text)
+}
+
+func TestReminderNext(t *testing.T) {
+	at, err := ParseAt("7:30")
+	require.NoError(t, err)
+	_, err = ParseAt("half past seven")
+	assert.Error(t, err)
+	reminder := &Reminder{At: at}
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2024, time.April, 1, 7, 30, 0, 0, paris), reminder.next(time.Date(2024, time.April, 1, 6, 0, 0, 0, paris)))
+	assert.Equal(t, time.Date(2024, time.April, 2, 7, 30, 0, 0, paris), reminder.next(time.Date(2024, time.April, 1, 7, 30, 0, 0, paris)))
+	// The morning after the clocks go forward is still at 7:30 local time.
+	assert.Equal(t, time.Date(2024, time.March, 31, 7, 30, 0, 0, paris), reminder.next(time.Date(2024, time.March, 30, 20, 0, 0, 0, paris)))
+}
@@ -0,0 +1,95 @@
+// Package storage keeps the files the API generates, such as the artifacts of print jobs, behind an
+// interface so that a deployment can choose where they live.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when no file is stored under a key.
+var ErrNotFound = errors.New("storage: file not found")
+
+// Store keeps files under keys, slash-separated paths such as "prints/3f2a.csv".
+type Store interface {
+	// Put stores the content of r under key, replacing any file stored there.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns the file stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key; deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Dir is a Store keeping the files in a directory of the local file system.
+type Dir struct {
+	root string
+}
+
+// NewDir returns a Store keeping the files under root, created if missing.
+func NewDir(root string) (*Dir, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &Dir{root: root}, nil
+}
+
+// path returns the file of key, rejecting keys that would escape the root.
+func (d *Dir) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(d.root, clean), nil
+}
+
+// Put writes the file to a temporary name first, so that a file is never read half written.
+func (d *Dir) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	size, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return size, os.Rename(tmp.Name(), path)
+}
+
+func (d *Dir) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (d *Dir) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDir(t.TempDir())
+	require.NoError(t, err)
+
+	size, err := store.Put(ctx, "prints/a.csv", strings.NewReader("first"))
+	require.NoError(t, err)
+	require.EqualValues(t, 5, size)
+	_, err = store.Put(ctx, "prints/a.csv", strings.NewReader("second"))
+	require.NoError(t, err)
+	file, err := store.Open(ctx, "prints/a.csv")
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Equal(t, "second", string(content))
+
+	require.NoError(t, store.Delete(ctx, "prints/a.csv"))
+	require.NoError(t, store.Delete(ctx, "prints/a.csv"))
+	_, err = store.Open(ctx, "prints/a.csv")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", "../outside", "prints/../../outside", "/etc/passwd"} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"))
+		require.Error(t, err, key)
+	}
+}
@@ -0,0 +1,70 @@
+package support
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// LogEntry is a warning or an error logged by the server, redacted.
+type LogEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// LogRecorder is a logrus hook keeping the last warnings and errors logged, for the support bundles. Once
+// full, each new entry replaces the oldest.
+type LogRecorder struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+// NewLogRecorder returns a recorder keeping the last size entries.
+func NewLogRecorder(size int) *LogRecorder {
+	return &LogRecorder{entries: make([]LogEntry, size)}
+}
+
+// Levels are the levels recorded, warnings and worse.
+func (r *LogRecorder) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel, log.WarnLevel}
+}
+
+// Fire records entry, its secrets redacted.
+func (r *LogRecorder) Fire(entry *log.Entry) error {
+	recorded := LogEntry{Time: entry.Time.UTC(), Level: entry.Level.String(), Message: Redact(entry.Message)}
+	if len(entry.Data) > 0 {
+		recorded.Fields = make(map[string]string, len(entry.Data))
+		for key, value := range entry.Data {
+			if IsSecret(key) {
+				recorded.Fields[key] = Redacted
+			} else {
+				recorded.Fields[key] = Redact(fmt.Sprint(value))
+			}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) == 0 {
+		return nil
+	}
+	r.entries[r.next] = recorded
+	if r.next = (r.next + 1) % len(r.entries); r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Entries returns the entries recorded, oldest first.
+func (r *LogRecorder) Entries() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]LogEntry{}, r.entries[:r.next]...)
+	}
+	return append(append([]LogEntry{}, r.entries[r.next:]...), r.entries[:r.next]...)
+}
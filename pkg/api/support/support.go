@@ -0,0 +1,133 @@
+// Package support assembles the support bundles the operators of a self-hosted deployment send along with a
+// problem report: a zip archive of JSON files describing the server, its configuration and its recent
+// errors, with the secrets and credentials redacted so that the bundle can be shared as is.
+package support
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the secrets left out of a bundle.
+const Redacted = "[redacted]"
+
+var (
+	// secretAssignment matches "password=…", "token: …" and the like, as found in DSNs and error messages.
+	secretAssignment = regexp.MustCompile(`(?i)\b([a-z_]*(?:password|secret|token|key))(\s*[=:]\s*)[^\s,;&]+`)
+	// bearerToken matches the credentials of an Authorization header.
+	bearerToken = regexp.MustCompile(`(?i)\bbearer\s+[^\s,;]+`)
+	// urlCredentials matches the user information of a URL.
+	urlCredentials = regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`)
+)
+
+// Redact removes the passwords, tokens and URL credentials found in s.
+func Redact(s string) string {
+	s = secretAssignment.ReplaceAllString(s, "${1}${2}"+Redacted)
+	s = bearerToken.ReplaceAllString(s, "Bearer "+Redacted)
+	return urlCredentials.ReplaceAllString(s, "://"+Redacted+"@")
+}
+
+// IsSecret reports whether a setting or log field of that name holds a secret, whose value is never
+// included in a bundle. The URL of an incoming webhook, such as Slack's, is itself the credential.
+func IsSecret(name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range []string{"PASSWORD", "SECRET", "TOKEN", "KEY", "DSN", "WEBHOOK_URL"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config summarizes the settings read from the environment variables keys: the value of each, Redacted for
+// the secrets that are set, and empty for those left to their default.
+func Config(getenv func(string) string, keys ...string) map[string]string {
+	config := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value := getenv(key)
+		if value != "" && IsSecret(key) {
+			value = Redacted
+		}
+		config[key] = value
+	}
+	return config
+}
+
+// Build describes the binary of the server.
+type Build struct {
+	GoVersion string `json:"goVersion"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	// Modified is set when the binary was built from a tree with uncommitted changes.
+	Modified bool   `json:"modified,omitempty"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+}
+
+// ReadBuild returns the description of the running binary, as far as it was recorded when it was built.
+func ReadBuild() Build {
+	build := Build{GoVersion: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	build.Module, build.Version = info.Main.Path, info.Main.Version
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// Bundle is a support bundle being assembled, a JSON file per part.
+type Bundle struct {
+	GeneratedAt time.Time
+	parts       []part
+}
+
+type part struct {
+	name    string
+	content interface{}
+}
+
+// NewBundle starts a bundle generated now.
+func NewBundle() *Bundle {
+	return &Bundle{GeneratedAt: time.Now().UTC().Truncate(time.Second)}
+}
+
+// Add adds content to the bundle as the JSON file name.json. A part that could not be gathered is added as
+// its error, so that the rest of the bundle still helps.
+func (b *Bundle) Add(name string, content interface{}) {
+	if err, ok := content.(error); ok {
+		content = map[string]string{"error": Redact(err.Error())}
+	}
+	b.parts = append(b.parts, part{name: name, content: content})
+}
+
+// WriteZip writes the bundle as a zip archive.
+func (b *Bundle) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, p := range b.parts {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: p.name + ".json", Method: zip.Deflate, Modified: b.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(p.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,67 @@
+package support
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "host=db user=api password=[redacted] dbname=api", Redact("host=db user=api password=s3cr3t dbname=api"))
+	assert.Equal(t, "dial postgres://[redacted]@db:5432/api: refused", Redact("dial postgres://api:s3cr3t@db:5432/api: refused"))
+	assert.Equal(t, "Authorization: Bearer [redacted]", Redact("Authorization: Bearer eyJhbGciOi.x.y"))
+	assert.Equal(t, "api_key=[redacted]&month=2024-04", Redact("api_key=abc123&month=2024-04"))
+	assert.Equal(t, "employee 12 not found", Redact("employee 12 not found"))
+}
+
+func TestConfig(t *testing.T) {
+	env := map[string]string{"PORT": "8070", "DB_PASSWORD": "s3cr3t", "ADMIN_API_TOKENS": "a,b", "SLACK_WEBHOOK_URL": "https://hooks.slack.com/services/T0/B0/x"}
+	config := Config(func(key string) string { return env[key] }, "PORT", "DB_PASSWORD", "ADMIN_API_TOKENS", "JWT_SECRET", "SLACK_WEBHOOK_URL")
+	assert.Equal(t, map[string]string{"PORT": "8070", "DB_PASSWORD": Redacted, "ADMIN_API_TOKENS": Redacted, "JWT_SECRET": "",
+		"SLACK_WEBHOOK_URL": Redacted}, config)
+}
+
+func TestLogRecorder(t *testing.T) {
+	recorder := NewLogRecorder(2)
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(recorder)
+
+	logger.Info("not recorded")
+	logger.Warn("first")
+	assert.Len(t, recorder.Entries(), 1)
+	logger.WithField("token", "abc").Error("second")
+	logger.WithField("dsn", "x").WithField("table", "schedules").Error("failed: password=s3cr3t")
+
+	// The oldest entry made room for the last one, whose secrets are redacted.
+	entries := recorder.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "second", entries[0].Message)
+	assert.Equal(t, Redacted, entries[0].Fields["token"])
+	assert.Equal(t, "error", entries[1].Level)
+	assert.Equal(t, "failed: password=[redacted]", entries[1].Message)
+	assert.Equal(t, map[string]string{"dsn": Redacted, "table": "schedules"}, entries[1].Fields)
+}
+
+func TestBundleWriteZip(t *testing.T) {
+	bundle := NewBundle()
+	bundle.Add("config", map[string]string{"PORT": "8070"})
+	bundle.Add("row_counts", errors.New("dial postgres://api:s3cr3t@db/api: refused"))
+	var archive bytes.Buffer
+	require.NoError(t, bundle.WriteZip(&archive))
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 2)
+	assert.Equal(t, "config.json", reader.File[0].Name)
+	file, err := reader.File[1].Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"error": "dial postgres://[redacted]@db/api: refused"}`, string(content))
+}
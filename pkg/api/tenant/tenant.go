@@ -0,0 +1,132 @@
+// Package tenant attributes the requests of a multi-tenant deployment to the company they are made for.
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	repo "github.com/lichensio/api_server/db/repo"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/lichensio/api_server/pkg/api/holiday"
+	"gorm.io/gorm"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// APIKeyHeader is the header carrying the API key of a tenant.
+const APIKeyHeader = "X-API-Key"
+
+var subdomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Resolver finds the tenant of a request: by its API key when it has one, else by the subdomain of BaseDomain
+// it was sent to (acme.example.com for the tenant of subdomain acme when BaseDomain is example.com).
+type Resolver struct {
+	repo       repo.Repository
+	baseDomain string
+}
+
+func NewResolver(repo repo.Repository, baseDomain string) *Resolver {
+	return &Resolver{repo: repo, baseDomain: strings.ToLower(strings.Trim(baseDomain, "."))}
+}
+
+// Resolve returns the tenant of the request. Errors are ready to be written.
+func (t *Resolver) Resolve(r *http.Request) (*model.Tenant, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		tenant, err := t.repo.TenantFindByAPIKeyHash(r.Context(), HashAPIKey(key))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apierror.Unauthorized("unknown API key").WithCode(apierror.CodeInvalidAPIKey)
+		}
+		return tenant, err
+	}
+	subdomain, ok := t.subdomain(r.Host)
+	if !ok {
+		return nil, apierror.Unauthorized("missing API key").WithCode(apierror.CodeInvalidAPIKey)
+	}
+	tenant, err := t.repo.TenantFindBySubdomain(r.Context(), subdomain)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apierror.NotFound(fmt.Sprintf("no tenant on subdomain %s", subdomain)).WithCode(apierror.CodeTenantNotFound)
+	}
+	return tenant, err
+}
+
+// subdomain returns the label host has in front of the base domain, if it is a direct subdomain of it.
+func (t *Resolver) subdomain(host string) (string, bool) {
+	if t.baseDomain == "" {
+		return "", false
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	label, found := strings.CutSuffix(strings.ToLower(host), "."+t.baseDomain)
+	if !found || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// Middleware confines the repository to the tenant of the request for the rest of its handling, with the
+// holiday region of the tenant, and rejects the requests whose tenant cannot be resolved.
+func (t *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := t.Resolve(r)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		ctx := repo.WithTenant(r.Context(), tenant.ID)
+		if tenant.HolidayRegion != "" {
+			ctx = repo.WithHolidayRegion(ctx, tenant.HolidayRegion)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Create stores a new tenant served on subdomain, whose stores observe the public holidays of region, one of
+// the regions of holidays (its default region when empty), and returns it along with its API key, which is
+// shown only once: only its hash is stored.
+func Create(ctx context.Context, repository repo.Repository, holidays holiday.Provider, name, subdomain, region string) (*model.Tenant, string, error) {
+	subdomain = strings.ToLower(subdomain)
+	if !subdomainPattern.MatchString(subdomain) {
+		return nil, "", apierror.Validation(fmt.Sprintf("invalid subdomain %q, expected lower-case letters, digits and hyphens", subdomain))
+	}
+	if region == "" {
+		region = holidays.DefaultRegion()
+	}
+	if !holidays.IsRegion(region) {
+		return nil, "", apierror.Validation(fmt.Sprintf("unknown holiday region %q", region)).WithCode(apierror.CodeRegionInvalid)
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, "", apierror.Validation("the tenant name is required")
+	}
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	tenant := &model.Tenant{Name: name, Subdomain: subdomain, APIKeyHash: HashAPIKey(key), HolidayRegion: region}
+	if err := repository.TenantCreate(ctx, tenant); err != nil {
+		return nil, "", err
+	}
+	return tenant, key, nil
+}
+
+// GenerateAPIKey returns a random API key.
+func GenerateAPIKey() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// HashAPIKey returns the hash of key stored in Tenant.APIKeyHash. API keys are random and long, so a plain
+// SHA-256 is enough and lets the tenant be looked up by the hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,246 @@
+// Package validate checks the input structs of the API against the rules of their validate tags, so that
+// malformed input is rejected field by field before the service runs. The rules of a field are separated
+// by commas:
+//
+//	required       not the zero value; a blank string is empty too
+//	date           a date written YYYY-MM-DD
+//	time           a time of day written HH:MM
+//	alphanum       ASCII letters and digits only
+//	email          an email address, at most 254 characters long
+//	phone          a phone number: digits, spaces and . - ( ), with an optional leading +
+//	color          a color written #RRGGBB
+//	oneof=a b      one of the words listed
+//	min=n, max=n   the bounds of a number, or of the length of a string, slice or map
+//	keys ... endkeys   the rules between them apply to every key of a map
+//
+// Except required and min, the rules accept empty strings. The structs held by a field, a slice or a map
+// are checked in turn.
+package validate
+
+import (
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Check returns the fields of v breaking their rules as a 422 error, nil when there are none. The fields
+// are named by their JSON path from name, such as employees[0].weeks.A.Monday[1].start.
+func Check(name string, v interface{}) error {
+	invalid := Fields(name, v)
+	if len(invalid) == 0 {
+		return nil
+	}
+	return apierror.Unprocessable(fmt.Sprintf("%d invalid field(s)", len(invalid)), invalid)
+}
+
+// Fields returns the fields of v breaking their rules, see Check.
+func Fields(name string, v interface{}) []apierror.InvalidParam {
+	var invalid []apierror.InvalidParam
+	walk(name, reflect.ValueOf(v), &invalid)
+	return invalid
+}
+
+// walk checks the fields of the structs within v.
+func walk(path string, v reflect.Value, invalid *[]apierror.InvalidParam) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walk(path, v.Elem(), invalid)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i), invalid)
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			walk(join(path, fmt.Sprint(key.Interface())), v.MapIndex(key), invalid)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			fieldPath := join(path, name)
+			if tag := field.Tag.Get("validate"); tag != "" {
+				check(fieldPath, v.Field(i), tag, invalid)
+			}
+			walk(fieldPath, v.Field(i), invalid)
+		}
+	}
+}
+
+// check applies the rules of tag to the field at path, reporting the first it breaks, then the first
+// broken by each key of a map.
+func check(path string, v reflect.Value, tag string, invalid *[]apierror.InvalidParam) {
+	var rules, keyRules []string
+	inKeys := false
+	for _, rule := range strings.Split(tag, ",") {
+		switch {
+		case rule == "keys":
+			inKeys = true
+		case rule == "endkeys":
+			inKeys = false
+		case inKeys:
+			keyRules = append(keyRules, rule)
+		default:
+			rules = append(rules, rule)
+		}
+	}
+	for _, rule := range rules {
+		if code, reason := apply(rule, v); reason != "" {
+			*invalid = append(*invalid, apierror.InvalidParam{Name: path, Code: code, Reason: reason})
+			break
+		}
+	}
+	if len(keyRules) == 0 || v.Kind() != reflect.Map {
+		return
+	}
+	for _, key := range sortedKeys(v) {
+		for _, rule := range keyRules {
+			if code, reason := apply(rule, key); reason != "" {
+				*invalid = append(*invalid, apierror.InvalidParam{Name: join(path, fmt.Sprint(key.Interface())), Code: code,
+					Reason: "key " + reason})
+				break
+			}
+		}
+	}
+}
+
+var (
+	// phonePattern accepts the numbers as people write them; they must also have from 6 digits to the 15
+	// allowed by E.164.
+	phonePattern = regexp.MustCompile(`^\+?[0-9 .()-]+$`)
+	colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// apply returns the code and reason of the breach of rule by v, an empty reason when v follows it.
+func apply(rule string, v reflect.Value) (apierror.Code, string) {
+	name, param, _ := strings.Cut(rule, "=")
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if name == "required" {
+				return apierror.CodeFieldRequired, "is required"
+			}
+			return "", ""
+		}
+		v = v.Elem()
+	}
+	if name == "required" {
+		if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+			return apierror.CodeFieldRequired, "is required"
+		}
+		return "", ""
+	}
+	if v.Kind() == reflect.String && v.String() == "" && name != "min" {
+		return "", ""
+	}
+	switch name {
+	case "date":
+		if _, err := time.Parse("2006-01-02", v.String()); err != nil {
+			return apierror.CodeDateInvalid, fmt.Sprintf("must be a date written YYYY-MM-DD, got: %q", v.String())
+		}
+	case "time":
+		if _, err := time.Parse("15:04", v.String()); err != nil {
+			return apierror.CodeTimeFormatInvalid, fmt.Sprintf("must be a time written HH:MM, got: %q", v.String())
+		}
+	case "alphanum":
+		for _, r := range v.String() {
+			if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return apierror.CodeValidationFailed, fmt.Sprintf("must be letters and digits only, got: %q", v.String())
+			}
+		}
+	case "email":
+		address, err := mail.ParseAddress(v.String())
+		if err != nil || address.Address != v.String() || len(v.String()) > 254 {
+			return apierror.CodeValidationFailed, fmt.Sprintf("must be an email address, got: %q", v.String())
+		}
+	case "phone":
+		digits := 0
+		for _, r := range v.String() {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if !phonePattern.MatchString(v.String()) || digits < 6 || digits > 15 {
+			return apierror.CodeValidationFailed, fmt.Sprintf("must be a phone number, got: %q", v.String())
+		}
+	case "color":
+		if !colorPattern.MatchString(v.String()) {
+			return apierror.CodeValidationFailed, fmt.Sprintf("must be a color written #RRGGBB, got: %q", v.String())
+		}
+	case "oneof":
+		words := strings.Fields(param)
+		for _, word := range words {
+			if v.String() == word {
+				return "", ""
+			}
+		}
+		return apierror.CodeValidationFailed, fmt.Sprintf("must be one of %s, got: %q", strings.Join(words, ", "), v.String())
+	case "min", "max":
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid bound in rule %q", rule))
+		}
+		size, unit := measure(v)
+		if (name == "min" && size < bound) || (name == "max" && size > bound) {
+			word := map[string]string{"min": "at least", "max": "at most"}[name]
+			return apierror.CodeValidationFailed, strings.TrimSpace(fmt.Sprintf("must be %s %s %s", word, param, unit))
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+	return "", ""
+}
+
+// measure returns the value of a number, or the length of a string, slice or map with its unit.
+func measure(v reflect.Value) (float64, string) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters long"
+	default:
+		return float64(v.Len()), "items long"
+	}
+}
+
+// jsonName returns the name of a field in JSON.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// sortedKeys returns the keys of a map in order, for the errors to be reported in a stable order.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface()) })
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
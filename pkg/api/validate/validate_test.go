@@ -0,0 +1,59 @@
+package validate
+
+import (
+	"errors"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	valid := model.EmployeeInput{Name: "Ines", StartDate: "2024-04-01", Email: "ines@example.com", Phone: "+33 6 12 34 56 78",
+		Color: "#1E88e5", Weeks: map[string]model.WeeklyScheduleInput{
+			"A": {Monday: []model.ScheduleInput{{Start: "9:00", End: "12:00"}}},
+		}}
+	require.NoError(t, Check("employees", []model.EmployeeInput{valid}))
+
+	invalid := model.EmployeeInput{Name: "  ", StartDate: "01/04/2024", HourlyRate: -1, Email: "Ines <ines@example.com>",
+		Phone: "06 12", Color: "red", Weeks: map[string]model.WeeklyScheduleInput{
+			"A":         {Monday: []model.ScheduleInput{{Start: "9:00", End: "12:00"}, {Start: "25:00"}}},
+			"week-B":    {},
+			"fortnight": {},
+		}}
+	err := Check("employees", []model.EmployeeInput{valid, invalid})
+	var apiErr *apierror.Error
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusUnprocessableEntity, apiErr.Status())
+	require.Equal(t, apierror.CodeInputInvalid, apiErr.ErrorCode())
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "employees[1].name", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "employees[1].startDate", Code: apierror.CodeDateInvalid, Reason: `must be a date written YYYY-MM-DD, got: "01/04/2024"`},
+		{Name: "employees[1].hourlyRate", Code: apierror.CodeValidationFailed, Reason: "must be at least 0"},
+		{Name: "employees[1].email", Code: apierror.CodeValidationFailed, Reason: `must be an email address, got: "Ines <ines@example.com>"`},
+		{Name: "employees[1].phone", Code: apierror.CodeValidationFailed, Reason: `must be a phone number, got: "06 12"`},
+		{Name: "employees[1].color", Code: apierror.CodeValidationFailed, Reason: `must be a color written #RRGGBB, got: "red"`},
+		{Name: "employees[1].weeks.fortnight", Code: apierror.CodeValidationFailed, Reason: "key must be at most 8 characters long"},
+		{Name: "employees[1].weeks.week-B", Code: apierror.CodeValidationFailed, Reason: `key must be letters and digits only, got: "week-B"`},
+		{Name: "employees[1].weeks.A.Monday[1].start", Code: apierror.CodeTimeFormatInvalid, Reason: `must be a time written HH:MM, got: "25:00"`},
+		{Name: "employees[1].weeks.A.Monday[1].end", Code: apierror.CodeFieldRequired, Reason: "is required"},
+	}, apiErr.Params)
+}
+
+func TestRules(t *testing.T) {
+	type input struct {
+		Kind  string   `json:"kind" validate:"oneof=pdf csv"`
+		Tags  []string `json:"tags" validate:"min=1,max=2"`
+		Count *int     `json:"count" validate:"required"`
+		Note  string   `validate:"max=3"`
+	}
+	one := 1
+	require.Empty(t, Fields("", input{Kind: "csv", Tags: []string{"a"}, Count: &one, Note: "été"}))
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "kind", Code: apierror.CodeValidationFailed, Reason: `must be one of pdf, csv, got: "xls"`},
+		{Name: "tags", Code: apierror.CodeValidationFailed, Reason: "must be at least 1 items long"},
+		{Name: "count", Code: apierror.CodeFieldRequired, Reason: "is required"},
+		{Name: "Note", Code: apierror.CodeValidationFailed, Reason: "must be at most 3 characters long"},
+	}, Fields("", &input{Kind: "xls", Note: "hiver"}))
+}
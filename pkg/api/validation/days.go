@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"sort"
+	"time"
+)
+
+// Day is a day of an employee in a sequence of consecutive days, with the slots worked that day.
+type Day struct {
+	// Name names the day in the violations, such as "Monday of week A" or "2024-04-08".
+	Name    string
+	Weekday time.Weekday
+	// Slots are ordered by start time; only their times are read.
+	Slots []model.Schedule
+}
+
+// DayRule is a rule on the consecutive days worked by an employee. It checks the rotation cycle of the weekly
+// schedules written as well as the calendar of a month, see CycleDays.
+type DayRule interface {
+	// CheckDays returns the breaches of the rule on days. The days of a cycle repeat, the last followed by
+	// the first again; the other days are checked on their own, without those before or after them.
+	CheckDays(days []Day, cycle bool) []string
+}
+
+var weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// CycleDays returns the days of one cycle of the weekly schedule of employee, the weeks following each other
+// in the order of weeks. Without weeks, the weeks of the slots follow each other in alphabetical order.
+func CycleDays(employee model.Employee, weeks []string) []Day {
+	if len(weeks) == 0 {
+		seen := map[string]bool{}
+		for _, slot := range employee.Schedules {
+			if !seen[slot.WeekType] {
+				weeks, seen[slot.WeekType] = append(weeks, slot.WeekType), true
+			}
+		}
+		sort.Strings(weeks)
+	}
+	days := make([]Day, 0, 7*len(weeks))
+	for _, week := range weeks {
+		for _, weekday := range weekdays {
+			day := Day{Name: fmt.Sprintf("%s of week %s", weekday, week), Weekday: weekday}
+			for _, slot := range employee.Schedules {
+				if slot.WeekType == week && slot.DayName == weekday.String() {
+					day.Slots = append(day.Slots, slot)
+				}
+			}
+			sort.SliceStable(day.Slots, func(i, j int) bool { return day.Slots[i].StartTime.Before(day.Slots[j].StartTime.Time) })
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// checkCycles checks rule on the rotation cycle of every employee of change.
+func checkCycles(name string, rule DayRule, change *Change) []Violation {
+	var violations []Violation
+	for i, employee := range change.Employees {
+		var weeks []string
+		if i < len(change.Weeks) {
+			weeks = change.Weeks[i]
+		}
+		for _, message := range rule.CheckDays(CycleDays(employee, weeks), true) {
+			violations = append(violations, Violation{Rule: name, EmployeeID: employee.ID, Employee: employee.Name, Message: message})
+		}
+	}
+	return violations
+}
+
+// clock returns the time of day of t.
+func clock(t model.CustomTime) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// until returns the time slot ends at from the midnight of its day, past 24 hours for a slot crossing
+// midnight.
+func until(slot model.Schedule) time.Duration {
+	return clock(slot.StartTime) + slot.End().Sub(slot.StartTime.Time)
+}
@@ -0,0 +1,220 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"time"
+)
+
+func init() {
+	// The maximum durations of the French labour code, which agreements may lower but not raise.
+	Register("max-daily-hours", MaxDailyHours{Hours: 10})
+	Register("max-weekly-hours", MaxWeeklyHours{Hours: 48})
+	// The daily rest of the labour code, and the weekly rest that leaves at most six days worked in a row.
+	Register("min-daily-rest", MinDailyRest{Hours: 11})
+	Register("max-consecutive-days", MaxConsecutiveDays{Days: 6})
+	// Sunday is the day of the weekly rest: the shops open on Sundays give every other one off.
+	Register("max-sundays", MaxSundays{Sundays: 1, Weeks: 2})
+}
+
+// MaxDailyHours rejects the days of a rotation week planned for more than Hours.
+type MaxDailyHours struct {
+	Hours float64
+}
+
+// Validate checks every day of every rotation week of the employees.
+func (r MaxDailyHours) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	var violations []Violation
+	for _, employee := range change.Employees {
+		days := map[[2]string]float64{}
+		var order [][2]string
+		for _, slot := range employee.Schedules {
+			day := [2]string{slot.WeekType, slot.DayName}
+			if _, ok := days[day]; !ok {
+				order = append(order, day)
+			}
+			days[day] += slotHours(slot)
+		}
+		for _, day := range order {
+			if hours := days[day]; hours > r.Hours {
+				violations = append(violations, Violation{Rule: "max-daily-hours", EmployeeID: employee.ID, Employee: employee.Name,
+					Message: fmt.Sprintf("%s of week %s is planned for %gh, over the %gh a day", day[1], day[0], hours, r.Hours)})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// CheckDays checks every day on its own.
+func (r MaxDailyHours) CheckDays(days []Day, cycle bool) []string {
+	var breaches []string
+	for _, day := range days {
+		hours := 0.0
+		for _, slot := range day.Slots {
+			hours += slotHours(slot)
+		}
+		if hours > r.Hours {
+			breaches = append(breaches, fmt.Sprintf("%s is planned for %gh, over the %gh a day", day.Name, hours, r.Hours))
+		}
+	}
+	return breaches
+}
+
+// MaxWeeklyHours rejects the rotation weeks planned for more than Hours.
+type MaxWeeklyHours struct {
+	Hours float64
+}
+
+// Validate checks every rotation week of the employees.
+func (r MaxWeeklyHours) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	var violations []Violation
+	for _, employee := range change.Employees {
+		weeks := map[string]float64{}
+		var order []string
+		for _, slot := range employee.Schedules {
+			if _, ok := weeks[slot.WeekType]; !ok {
+				order = append(order, slot.WeekType)
+			}
+			weeks[slot.WeekType] += slotHours(slot)
+		}
+		for _, week := range order {
+			if hours := weeks[week]; hours > r.Hours {
+				violations = append(violations, Violation{Rule: "max-weekly-hours", EmployeeID: employee.ID, Employee: employee.Name,
+					Message: fmt.Sprintf("week %s is planned for %gh, over the %gh a week", week, hours, r.Hours)})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// MinDailyRest rejects the rests shorter than Hours between the last slot of a day and the first slot of
+// the next day. The breaks between the slots of a day are not rests.
+type MinDailyRest struct {
+	Hours float64
+}
+
+// Validate checks the rotation cycle of the employees.
+func (r MinDailyRest) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	return checkCycles("min-daily-rest", r, change), nil
+}
+
+// CheckDays checks every day followed by another day worked.
+func (r MinDailyRest) CheckDays(days []Day, cycle bool) []string {
+	var breaches []string
+	for i, day := range days {
+		next := i + 1
+		if next == len(days) {
+			if !cycle {
+				break
+			}
+			next = 0
+		}
+		if len(day.Slots) == 0 || len(days[next].Slots) == 0 {
+			continue
+		}
+		end := until(day.Slots[0])
+		for _, slot := range day.Slots[1:] {
+			if until(slot) > end {
+				end = until(slot)
+			}
+		}
+		rest := 24*time.Hour - end + clock(days[next].Slots[0].StartTime)
+		if rest.Hours() < r.Hours {
+			breaches = append(breaches, fmt.Sprintf("the rest between %s and %s lasts %gh, under the %gh required",
+				day.Name, days[next].Name, rest.Hours(), r.Hours))
+		}
+	}
+	return breaches
+}
+
+// MaxConsecutiveDays rejects the runs of more than Days days worked in a row.
+type MaxConsecutiveDays struct {
+	Days int
+}
+
+// Validate checks the rotation cycle of the employees.
+func (r MaxConsecutiveDays) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	return checkCycles("max-consecutive-days", r, change), nil
+}
+
+// CheckDays checks every run of days worked. The runs of a cycle are counted from a day off, so that a run
+// spanning the end and the start of the cycle is one run.
+func (r MaxConsecutiveDays) CheckDays(days []Day, cycle bool) []string {
+	first := 0
+	if cycle {
+		first = -1
+		for i, day := range days {
+			if len(day.Slots) == 0 {
+				first = i
+				break
+			}
+		}
+		if first < 0 {
+			if len(days) == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("every day of the cycle is worked, over the %d days in a row allowed", r.Days)}
+		}
+	}
+	var breaches []string
+	run := 0
+	for n := 0; n <= len(days); n++ {
+		if n < len(days) {
+			if day := days[(first+n)%len(days)]; len(day.Slots) > 0 {
+				run++
+				continue
+			}
+		}
+		if run > r.Days {
+			breaches = append(breaches, fmt.Sprintf("%d days are worked in a row from %s to %s, over the %d allowed", run,
+				days[(first+n-run)%len(days)].Name, days[(first+n-1)%len(days)].Name, r.Days))
+		}
+		run = 0
+	}
+	return breaches
+}
+
+// MaxSundays rejects more than Sundays Sundays worked in Weeks weeks in a row.
+type MaxSundays struct {
+	Sundays int
+	Weeks   int
+}
+
+// Validate checks the rotation cycle of the employees.
+func (r MaxSundays) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	return checkCycles("max-sundays", r, change), nil
+}
+
+// CheckDays checks every Weeks Sundays in a row, once a breach is found from the Sunday after them.
+func (r MaxSundays) CheckDays(days []Day, cycle bool) []string {
+	var sundays []Day
+	for _, day := range days {
+		if day.Weekday == time.Sunday {
+			sundays = append(sundays, day)
+		}
+	}
+	var breaches []string
+	for k := 0; k < len(sundays); k++ {
+		if !cycle && k+r.Weeks > len(sundays) {
+			break
+		}
+		worked := 0
+		for i := k; i < k+r.Weeks; i++ {
+			if len(sundays[i%len(sundays)].Slots) > 0 {
+				worked++
+			}
+		}
+		if worked > r.Sundays {
+			breaches = append(breaches, fmt.Sprintf("%d Sundays are worked in the %d weeks from %s, over the %d allowed",
+				worked, r.Weeks, sundays[k].Name, r.Sundays))
+			k += r.Weeks - 1
+		}
+	}
+	return breaches
+}
+
+// slotHours returns the length of a slot in hours.
+func slotHours(slot model.Schedule) float64 {
+	return slot.End().Sub(slot.StartTime.Time).Hours()
+}
@@ -0,0 +1,114 @@
+// Package validation lets a deployment enforce its own rules on the schedules written through the API, such
+// as the limits of a union agreement, on top of the checks of the service: validators compiled into the
+// server register under a name, and a tenant may also have its schedules checked by an external service.
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// Stages of the writes checked by the validators.
+const (
+	// StageImport is an employee import, every employee of it with its weekly schedule.
+	StageImport = "import"
+	// StageUpdate is a slot replaced with PUT, StagePatch a slot assigned to a task with PATCH.
+	StageUpdate = "update"
+	StagePatch  = "patch"
+	// StageReplace is the slots of an employee replaced at once.
+	StageReplace = "replace"
+)
+
+// Change is a write about to be made: the employees concerned, each with the slots of its own weekly
+// schedule as they would be once written. The employees of an import have no ID yet, unless they replace
+// an existing one.
+type Change struct {
+	Stage     string           `json:"stage"`
+	Employees []model.Employee `json:"employees"`
+	// Weeks are the weeks of the rotation of each employee, in the order of Employees and of the cycle.
+	Weeks [][]string `json:"weeks,omitempty"`
+}
+
+// Violation is a rule broken by a change, for one of its employees.
+type Violation struct {
+	Rule       string `json:"rule"`
+	EmployeeID uint   `json:"employeeId,omitempty"`
+	Employee   string `json:"employee"`
+	Message    string `json:"message"`
+}
+
+// Validator checks the changes of schedules against rules of its own.
+type Validator interface {
+	// Validate returns the rules change breaks, none if it may be written. An error means the change could
+	// not be checked, and is not written either.
+	Validate(ctx context.Context, change *Change) ([]Violation, error)
+}
+
+var validators = map[string]Validator{}
+
+// Register makes a validator available under name. It panics if the name is already taken.
+func Register(name string, validator Validator) {
+	if _, ok := validators[name]; ok {
+		panic(fmt.Sprintf("validation: validator %q registered twice", name))
+	}
+	validators[name] = validator
+}
+
+// Lookup returns the validator registered under name.
+func Lookup(name string) (Validator, bool) {
+	validator, ok := validators[name]
+	return validator, ok
+}
+
+// Names returns the names of the registered validators in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(validators))
+	for name := range validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remote is a validator run by an external service: the change is posted to URL as JSON, and the service
+// answers 200 with {"violations": [...]}, empty when the change may be written.
+type Remote struct {
+	URL    string
+	Client *http.Client
+}
+
+// Validate posts change to the external service.
+func (v Remote) Validate(ctx context.Context, change *Change) ([]Violation, error) {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lichensio-validation/1")
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("%s answered %s", v.URL, resp.Status)
+	}
+	var answer struct {
+		Violations []Violation `json:"violations"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("%s answered an invalid body: %w", v.URL, err)
+	}
+	return answer.Violations, nil
+}
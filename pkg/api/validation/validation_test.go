@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func slot(week, day, start, end string) model.Schedule {
+	from, _ := time.Parse("15:04", start)
+	to, _ := time.Parse("15:04", end)
+	return model.Schedule{WeekType: week, DayName: day, StartTime: model.CustomTime{Time: from}, EndTime: model.CustomTime{Time: to}}
+}
+
+// ines works a long Monday and a long week A, within the limits on week B.
+var ines = &Change{Stage: StageImport, Employees: []model.Employee{{Name: "Ines", Schedules: []model.Schedule{
+	slot("A", "Monday", "08:00", "13:00"), slot("A", "Monday", "13:30", "19:30"),
+	slot("A", "Tuesday", "08:00", "18:00"), slot("A", "Wednesday", "08:00", "18:00"),
+	slot("A", "Thursday", "08:00", "18:00"), slot("A", "Friday", "08:00", "16:00"),
+	slot("B", "Monday", "09:00", "17:00"),
+}}}}
+
+func TestBuiltinRules(t *testing.T) {
+	daily, ok := Lookup("max-daily-hours")
+	require.True(t, ok)
+	violations, err := daily.Validate(context.Background(), ines)
+	require.NoError(t, err)
+	require.Equal(t, []Violation{{Rule: "max-daily-hours", Employee: "Ines", Message: "Monday of week A is planned for 11h, over the 10h a day"}}, violations)
+
+	weekly, ok := Lookup("max-weekly-hours")
+	require.True(t, ok)
+	violations, err = weekly.Validate(context.Background(), ines)
+	require.NoError(t, err)
+	require.Equal(t, []Violation{{Rule: "max-weekly-hours", Employee: "Ines", Message: "week A is planned for 49h, over the 48h a week"}}, violations)
+
+	violations, err = MaxWeeklyHours{Hours: 50}.Validate(context.Background(), ines)
+	require.NoError(t, err)
+	require.Empty(t, violations)
+	require.Equal(t, []string{"max-consecutive-days", "max-daily-hours", "max-sundays", "max-weekly-hours", "min-daily-rest"}, Names())
+	require.Panics(t, func() { Register("max-daily-hours", MaxDailyHours{Hours: 8}) })
+}
+
+// paul closes late on Saturday of week B and opens early on Sunday, then works every day from there to Monday
+// of week B again, week A following week B.
+var paul = &Change{Stage: StageReplace, Weeks: [][]string{{"B", "A"}}, Employees: []model.Employee{{Name: "Paul", Schedules: []model.Schedule{
+	slot("A", "Monday", "06:00", "10:00"), slot("A", "Tuesday", "09:00", "12:00"), slot("A", "Wednesday", "09:00", "12:00"),
+	slot("A", "Thursday", "09:00", "12:00"), slot("A", "Friday", "09:00", "12:00"), slot("A", "Saturday", "09:00", "12:00"),
+	slot("A", "Sunday", "09:00", "12:00"),
+	slot("B", "Monday", "09:00", "12:00"), slot("B", "Saturday", "14:00", "22:00"), slot("B", "Saturday", "09:00", "12:00"),
+	slot("B", "Sunday", "08:00", "12:00"),
+}}}}
+
+func TestDayRules(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		message string
+	}{
+		{"min-daily-rest", "the rest between Saturday of week B and Sunday of week B lasts 10h, under the 11h required"},
+		{"max-consecutive-days", "10 days are worked in a row from Saturday of week B to Monday of week B, over the 6 allowed"},
+		{"max-sundays", "2 Sundays are worked in the 2 weeks from Sunday of week B, over the 1 allowed"},
+	} {
+		validator, ok := Lookup(test.name)
+		require.True(t, ok)
+		violations, err := validator.Validate(context.Background(), paul)
+		require.NoError(t, err)
+		require.Equal(t, []Violation{{Rule: test.name, Employee: "Paul", Message: test.message}}, violations, test.name)
+	}
+
+	// Out of a cycle, the days before the first and after the last are unknown.
+	days := CycleDays(paul.Employees[0], []string{"B", "A"})
+	require.Len(t, days, 14)
+	require.Equal(t, []string{"9 days are worked in a row from Saturday of week B to Sunday of week A, over the 6 allowed"},
+		MaxConsecutiveDays{Days: 6}.CheckDays(days, false))
+	require.Empty(t, MaxSundays{Sundays: 1, Weeks: 2}.CheckDays(days[:7], false))
+	require.Equal(t, []string{"Saturday of week B is planned for 11h, over the 10h a day"}, MaxDailyHours{Hours: 10}.CheckDays(days, false))
+	require.Equal(t, []string{"every day of the cycle is worked, over the 6 days in a row allowed"},
+		MaxConsecutiveDays{Days: 6}.CheckDays(days[9:14], true))
+}
+
+func TestRemote(t *testing.T) {
+	var received Change
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Write([]byte(`{"violations": [{"rule": "union-agreement", "employee": "Ines", "message": "too late on Monday"}]}`))
+	}))
+	defer server.Close()
+
+	violations, err := Remote{URL: server.URL, Client: server.Client()}.Validate(context.Background(), ines)
+	require.NoError(t, err)
+	require.Equal(t, []Violation{{Rule: "union-agreement", Employee: "Ines", Message: "too late on Monday"}}, violations)
+	require.Equal(t, StageImport, received.Stage)
+	require.Len(t, received.Employees[0].Schedules, 7)
+
+	_, err = Remote{URL: server.URL + "/down", Client: server.Client()}.Validate(context.Background(), ines)
+	require.ErrorContains(t, err, "503")
+}
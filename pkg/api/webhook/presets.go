@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"sort"
+	"strings"
+)
+
+func init() {
+	Register("json", JSON{})
+	Register("slack", Slack{})
+	Register("zapier", Zapier{})
+}
+
+// JSON sends the event as is, for internal services.
+type JSON struct{}
+
+func (JSON) Render(event events.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// Slack sends a one-line message in the {"text": ...} shape accepted by Slack incoming webhooks and the
+// chat tools compatible with them.
+type Slack struct{}
+
+func (Slack) Render(event events.Event) ([]byte, error) {
+	text := fmt.Sprintf("*%s* at %s", event.Type, event.OccurredAt.Format("2006-01-02 15:04 MST"))
+	if summary := summarize(event.Data); summary != "" {
+		text += ": " + summary
+	}
+	return json.Marshal(map[string]string{"text": text})
+}
+
+// summarize lists the scalar fields of data as "key=value", in key order.
+func summarize(data interface{}) string {
+	fields, err := toMap(data)
+	if err != nil {
+		return ""
+	}
+	flat := make(map[string]interface{})
+	flatten("", fields, flat)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", key, flat[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Zapier sends a flat object, the nested fields of the event joined with "_", which no-code tools map to
+// their form fields without a parsing step.
+type Zapier struct{}
+
+func (Zapier) Render(event events.Event) ([]byte, error) {
+	fields, err := toMap(event)
+	if err != nil {
+		return nil, err
+	}
+	flat := make(map[string]interface{})
+	flatten("", fields, flat)
+	return json.Marshal(flat)
+}
+
+// toMap returns the JSON form of value as generic maps and slices, the form templates see the event in.
+func toMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func flatten(prefix string, value interface{}, into map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			flatten(key, field, into)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(fmt.Sprintf("%s_%d", prefix, i), item, into)
+		}
+	default:
+		into[prefix] = v
+	}
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"text/template"
+	"time"
+)
+
+// Template renders events with a Go template over the JSON form of the event: {{.type}}, {{.occurredAt}},
+// {{.data.employee.name}}... The json function writes a value as JSON, quotes included for strings, so that
+// {"who": {{json .data.employee.name}}} stays valid whatever the name contains.
+type Template struct {
+	tmpl *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a payload template and checks that it renders the sample event to valid JSON.
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("payload").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{tmpl: tmpl}
+	if _, err := t.Render(SampleEvent(time.Time{})); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Template) Render(event events.Event) ([]byte, error) {
+	fields, err := toMap(event)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, fields); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("the template does not render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+// Package webhook renders the events of the API into the payloads webhook consumers expect and delivers them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/events"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Preset renders events in the shape a family of consumers expects.
+type Preset interface {
+	// Render returns the JSON body delivered for event.
+	Render(event events.Event) ([]byte, error)
+}
+
+var presets = map[string]Preset{}
+
+// Register makes a preset available under name. It panics if the name is already taken.
+func Register(name string, preset Preset) {
+	if _, ok := presets[name]; ok {
+		panic(fmt.Sprintf("webhook: preset %q registered twice", name))
+	}
+	presets[name] = preset
+}
+
+// Lookup returns the preset registered under name.
+func Lookup(name string) (Preset, bool) {
+	preset, ok := presets[name]
+	return preset, ok
+}
+
+// Names returns the names of the registered presets in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SampleEvent returns the event sent by test deliveries.
+func SampleEvent(now time.Time) events.Event {
+	return events.Event{
+		ID:         "test",
+		Type:       "webhook.test",
+		OccurredAt: now.UTC().Truncate(time.Second),
+		Data: map[string]interface{}{
+			"message": "This is a test delivery.",
+			"employee": map[string]interface{}{
+				"id":        1,
+				"name":      "Delphine",
+				"startDate": "2024-01-08",
+			},
+		},
+	}
+}
+
+// Delivery is the outcome of sending a payload to a webhook.
+type Delivery struct {
+	// Status is the HTTP status the consumer answered, 0 if it could not be reached.
+	Status int `json:"status"`
+	// Error describes why the delivery failed, empty when the consumer answered 2xx.
+	Error string `json:"error,omitempty"`
+}
+
+// Delivered reports whether the consumer accepted the payload.
+func (d Delivery) Delivered() bool { return d.Error == "" }
+
+// Headers of the signed deliveries.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign returns the signature of body sent at timestamp (Unix seconds): "sha256=" followed by the hex
+// HMAC-SHA256, keyed with secret, of the timestamp, a dot and the body. Consumers compute it again to check
+// that a delivery comes from the API, and compare the timestamp with their clock to reject replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Signing identifies a delivery for its consumer and holds the secret signing it.
+type Signing struct {
+	Secret     string
+	Event      string
+	DeliveryID string
+}
+
+// Deliver posts body as JSON to url.
+func Deliver(ctx context.Context, client *http.Client, url string, body []byte) Delivery {
+	return deliver(ctx, client, url, body, nil)
+}
+
+// DeliverSigned posts body as JSON to url with the event, delivery and signature headers. Without a secret
+// the delivery is not signed.
+func DeliverSigned(ctx context.Context, client *http.Client, url string, body []byte, signing Signing) Delivery {
+	header := http.Header{}
+	header.Set(EventHeader, signing.Event)
+	header.Set(DeliveryHeader, signing.DeliveryID)
+	if signing.Secret != "" {
+		timestamp := time.Now().Unix()
+		header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		header.Set(SignatureHeader, Sign(signing.Secret, timestamp, body))
+	}
+	return deliver(ctx, client, url, body, header)
+}
+
+func deliver(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) Delivery {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Delivery{Error: err.Error()}
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "lichensio-webhooks/1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return Delivery{Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Delivery{Status: resp.StatusCode, Error: fmt.Sprintf("%s answered %s", url, resp.Status)}
+	}
+	return Delivery{Status: resp.StatusCode}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"context"
+	"flag"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the fixtures with the current outputs")
+
+var sample = SampleEvent(time.Date(2024, time.April, 2, 9, 30, 0, 0, time.UTC))
+
+func TestPresets(t *testing.T) {
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			preset, _ := Lookup(name)
+			out, err := preset.Render(sample)
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", "sample."+name+".json")
+			if *update {
+				require.NoError(t, os.WriteFile(path, out, 0o644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "missing fixture, run the test with -update to create it")
+			require.Equal(t, string(want), string(out), "output differs from %s", path)
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(`{"event": {{json .type}}, "who": {{json .data.employee.name}}, "missing": {{json .data.nothing}}}`)
+	require.NoError(t, err)
+	out, err := tmpl.Render(sample)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"event": "webhook.test", "who": "Delphine", "missing": null}`, string(out))
+
+	_, err = ParseTemplate(`{"event": {{.type}`)
+	require.Error(t, err, "syntax error")
+	_, err = ParseTemplate(`{"event": {{.type}}}`)
+	require.ErrorContains(t, err, "valid JSON", "unquoted string")
+}
+
+func TestDeliver(t *testing.T) {
+	var received string
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		if r.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer consumer.Close()
+
+	delivery := Deliver(context.Background(), consumer.Client(), consumer.URL+"/ok", []byte(`{"a":1}`))
+	require.True(t, delivery.Delivered())
+	require.Equal(t, http.StatusOK, delivery.Status)
+	require.Equal(t, `{"a":1}`, received)
+
+	delivery = Deliver(context.Background(), consumer.Client(), consumer.URL+"/reject", []byte(`{}`))
+	require.False(t, delivery.Delivered())
+	require.Equal(t, http.StatusBadRequest, delivery.Status)
+}
+
+func TestDeliverSigned(t *testing.T) {
+	var header http.Header
+	var body []byte
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer consumer.Close()
+
+	delivery := DeliverSigned(context.Background(), consumer.Client(), consumer.URL, []byte(`{"a":1}`),
+		Signing{Secret: "whsec_test", Event: "leave.approved", DeliveryID: "d-1"})
+	require.True(t, delivery.Delivered())
+	require.Equal(t, "leave.approved", header.Get(EventHeader))
+	require.Equal(t, "d-1", header.Get(DeliveryHeader))
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), time.Minute)
+	require.Equal(t, Sign("whsec_test", timestamp, body), header.Get(SignatureHeader))
+	require.NotEqual(t, Sign("other", timestamp, body), header.Get(SignatureHeader))
+	require.Regexp(t, `^sha256=[0-9a-f]{64}$`, header.Get(SignatureHeader))
+
+	// Without a secret the delivery is not signed.
+	DeliverSigned(context.Background(), consumer.Client(), consumer.URL, []byte(`{}`), Signing{Event: "leave.approved"})
+	require.Empty(t, header.Get(SignatureHeader))
+}
@@ -0,0 +1,272 @@
+package xlsx
+
+import (
+	"fmt"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rosterColumns maps the headers of the roster layout, lowercased, to the field they hold.
+var rosterColumns = map[string]string{
+	"name":           "name",
+	"start date":     "startDate",
+	"end date":       "endDate",
+	"department":     "department",
+	"contract hours": "contractWeeklyHours",
+	"hourly rate":    "hourlyRate",
+	"email":          "email",
+	"phone":          "phone",
+	"role":           "role",
+	"color":          "color",
+}
+
+var weekDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// slotPattern matches a slot of a day cell: the times, then the optional task and location.
+var slotPattern = regexp.MustCompile(`^(\d{1,2}:\d{2})\s*-\s*(\d{1,2}:\d{2})(?:\s+([^@]*?))?\s*(?:@\s*(.+?))?$`)
+
+// excelEpoch is the day the serial dates of the 1900 date system count from, for the dates after February
+// 1900: Excel counts a February 29th, 1900 that never was.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// Roster converts a workbook in the roster layout into the employees of the /loadEmployees format, in the
+// order they first appear. The cells that do not follow the layout are returned as invalid fields named
+// by their sheet and cell, such as A!C4; the fields of the employees are checked by the import itself.
+//
+// The roster layout has one sheet per week of the rotation, named by its week type (A and B for the A/B
+// rotation), and one row per employee under a header row. The header names the columns, in any order and
+// case:
+//
+//	Name                  the employee, required
+//	Start date            YYYY-MM-DD or a date cell, required on at least one sheet
+//	End date              the last day worked, optional
+//	Department            optional
+//	Contract hours        the contract weekly hours, optional
+//	Hourly rate           optional
+//	Email, Phone, Role    optional
+//	Color                 the color in calendars, #RRGGBB, optional
+//	Monday ... Sunday     the slots of the day
+//
+// A day cell holds the slots of the day separated by commas or line breaks, each written
+// "HH:MM-HH:MM [task] [@location]", such as "9:00-12:00 cash desk, 14:00-18:00 @Lyon". An employee missing
+// from a sheet works no slot that week. The attributes of an employee may be repeated on every sheet, but
+// must then agree.
+func Roster(workbook *Workbook) (model.EmployeesInput, []apierror.InvalidParam) {
+	var invalid []apierror.InvalidParam
+	report := func(sheet string, row, col int, code apierror.Code, reason string) {
+		invalid = append(invalid, apierror.InvalidParam{Name: sheet + "!" + CellName(row, col), Code: code, Reason: reason})
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, []apierror.InvalidParam{{Name: "workbook", Code: apierror.CodeFieldRequired, Reason: "has no sheet"}}
+	}
+
+	var employees model.EmployeesInput
+	byName := map[string]int{}
+	for _, sheet := range workbook.Sheets {
+		weekType := strings.TrimSpace(sheet.Name)
+		header, nameCol, ok := rosterHeader(sheet, report)
+		if !ok {
+			continue
+		}
+		seen := map[string]int{}
+		for r := 1; r < len(sheet.Rows); r++ {
+			row := sheet.Rows[r]
+			cell := func(col int) string {
+				if col < 0 || col >= len(row) {
+					return ""
+				}
+				return strings.TrimSpace(row[col])
+			}
+			name := cell(nameCol)
+			if name == "" {
+				if strings.TrimSpace(strings.Join(row, "")) != "" {
+					report(sheet.Name, r, nameCol, apierror.CodeFieldRequired, "the name of the employee is required")
+				}
+				continue
+			}
+			if first, ok := seen[name]; ok {
+				report(sheet.Name, r, nameCol, apierror.CodeInputInvalid,
+					fmt.Sprintf("%s is already on row %d of the sheet", name, first+1))
+				continue
+			}
+			seen[name] = r
+
+			i, ok := byName[name]
+			if !ok {
+				i = len(employees)
+				byName[name] = i
+				employees = append(employees, model.EmployeeInput{Name: name, Weeks: map[string]model.WeeklyScheduleInput{}})
+			}
+			employee := &employees[i]
+			week := employee.Weeks[weekType]
+			for col, field := range header {
+				value := cell(col)
+				switch {
+				case field == "" || field == "name":
+				case isWeekDay(field):
+					slots, reason := parseSlots(value)
+					if reason != "" {
+						report(sheet.Name, r, col, apierror.CodeTimeFormatInvalid, reason)
+						continue
+					}
+					setDay(&week, field, slots)
+				case value != "":
+					if reason := setAttribute(employee, field, value); reason != "" {
+						report(sheet.Name, r, col, apierror.CodeInputInvalid, reason)
+					}
+				}
+			}
+			employee.Weeks[weekType] = week
+		}
+	}
+	if len(employees) == 0 && len(invalid) == 0 {
+		invalid = append(invalid, apierror.InvalidParam{Name: "workbook", Code: apierror.CodeFieldRequired, Reason: "has no employee"})
+	}
+	// Every employee follows every week of the workbook, with no slot on the sheets missing it.
+	for i := range employees {
+		for _, sheet := range workbook.Sheets {
+			weekType := strings.TrimSpace(sheet.Name)
+			if _, ok := employees[i].Weeks[weekType]; !ok {
+				employees[i].Weeks[weekType] = model.WeeklyScheduleInput{}
+			}
+		}
+	}
+	return employees, invalid
+}
+
+// rosterHeader returns the field held by each column of a sheet, found by its header row: an attribute of
+// rosterColumns, a week day or nothing. It returns the column of the names too.
+func rosterHeader(sheet Sheet, report func(sheet string, row, col int, code apierror.Code, reason string)) ([]string, int, bool) {
+	if len(sheet.Rows) == 0 {
+		report(sheet.Name, 0, 0, apierror.CodeFieldRequired, "the header row is required")
+		return nil, 0, false
+	}
+	header := make([]string, len(sheet.Rows[0]))
+	nameCol := -1
+	for col, title := range sheet.Rows[0] {
+		title = strings.ToLower(strings.TrimSpace(title))
+		if title == "" {
+			continue
+		}
+		if field, ok := rosterColumns[title]; ok {
+			header[col] = field
+			if field == "name" {
+				nameCol = col
+			}
+			continue
+		}
+		for _, day := range weekDays {
+			if strings.ToLower(day) == title {
+				header[col] = day
+			}
+		}
+		if header[col] == "" {
+			report(sheet.Name, 0, col, apierror.CodeInputInvalid, fmt.Sprintf("unknown column %q", sheet.Rows[0][col]))
+		}
+	}
+	if nameCol < 0 {
+		report(sheet.Name, 0, 0, apierror.CodeFieldRequired, "the header row must have a Name column")
+		return nil, 0, false
+	}
+	return header, nameCol, true
+}
+
+func isWeekDay(field string) bool {
+	for _, day := range weekDays {
+		if field == day {
+			return true
+		}
+	}
+	return false
+}
+
+// setAttribute sets the attribute field of an employee from the text of its cell, returning the reason the
+// value is rejected, if any.
+func setAttribute(employee *model.EmployeeInput, field, value string) string {
+	var current string
+	switch field {
+	case "startDate", "endDate":
+		if serial, err := strconv.ParseFloat(value, 64); err == nil {
+			value = excelEpoch.AddDate(0, 0, int(serial)).Format("2006-01-02")
+		}
+		target := &employee.StartDate
+		if field == "endDate" {
+			target = &employee.EndDate
+		}
+		current = *target
+		*target = value
+	case "department":
+		current = employee.Department
+		employee.Department = value
+	case "email":
+		current = employee.Email
+		employee.Email = value
+	case "phone":
+		current = employee.Phone
+		employee.Phone = value
+	case "role":
+		current = employee.Role
+		employee.Role = value
+	case "color":
+		current = employee.Color
+		employee.Color = value
+	case "contractWeeklyHours", "hourlyRate":
+		number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			return fmt.Sprintf("must be a number, got: %q", value)
+		}
+		target := &employee.ContractWeeklyHours
+		if field == "hourlyRate" {
+			target = &employee.HourlyRate
+		}
+		if *target != 0 {
+			current = strconv.FormatFloat(*target, 'f', -1, 64)
+		}
+		*target = number
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	if current != "" && current != value {
+		return fmt.Sprintf("%s disagrees with %q given on another sheet", value, current)
+	}
+	return ""
+}
+
+// parseSlots returns the slots of a day cell, or the reason the cell is rejected.
+func parseSlots(value string) ([]model.ScheduleInput, string) {
+	var slots []model.ScheduleInput
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' || r == ';' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		match := slotPattern.FindStringSubmatch(item)
+		if match == nil {
+			return nil, fmt.Sprintf("slots must be written HH:MM-HH:MM [task] [@location], got: %q", item)
+		}
+		slots = append(slots, model.ScheduleInput{Start: match[1], End: match[2], Task: match[3], Location: match[4]})
+	}
+	return slots, ""
+}
+
+func setDay(week *model.WeeklyScheduleInput, day string, slots []model.ScheduleInput) {
+	switch day {
+	case "Monday":
+		week.Monday = slots
+	case "Tuesday":
+		week.Tuesday = slots
+	case "Wednesday":
+		week.Wednesday = slots
+	case "Thursday":
+		week.Thursday = slots
+	case "Friday":
+		week.Friday = slots
+	case "Saturday":
+		week.Saturday = slots
+	case "Sunday":
+		week.Sunday = slots
+	}
+}
@@ -0,0 +1,236 @@
+// Package xlsx reads the rosters that managers keep in Excel workbooks. It reads the cell values of the
+// worksheets of an Office Open XML workbook (.xlsx) as text, without their formatting or formulas, and
+// converts the documented roster layout into the employees of the /loadEmployees format.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Workbook is the cell values of the worksheets of a workbook, in the order of its tabs.
+type Workbook struct {
+	Sheets []Sheet
+}
+
+// Sheet is a worksheet: its name and its rows, each with the text of its cells by column from A. The empty
+// rows and trailing cells are kept, so that Rows[r][c] is the cell of row r+1 and column c+1.
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// ErrNotWorkbook is returned by Read when the data is not an .xlsx workbook.
+var ErrNotWorkbook = errors.New("not an xlsx workbook")
+
+// maxCells bounds the cells read from a workbook, against zip bombs.
+const maxCells = 1_000_000
+
+// Read reads the worksheets of an .xlsx workbook.
+func Read(data []byte) (*Workbook, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrNotWorkbook
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+	if files["xl/workbook.xml"] == nil {
+		return nil, ErrNotWorkbook
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decode(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decode(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+	shared, err := sharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Workbook{}
+	cells := 0
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.RID]
+		if !ok {
+			return nil, fmt.Errorf("reading sheet %q: no part for relationship %q", sheet.Name, sheet.RID)
+		}
+		rows, err := readSheet(files, target, shared, &cells)
+		if err != nil {
+			return nil, fmt.Errorf("reading sheet %q: %w", sheet.Name, err)
+		}
+		result.Sheets = append(result.Sheets, Sheet{Name: sheet.Name, Rows: rows})
+	}
+	return result, nil
+}
+
+// decode unmarshals the XML part name of the archive into v.
+func decode(files map[string]*zip.File, name string, v interface{}) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: %s is missing", ErrNotWorkbook, name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrNotWorkbook, name, err)
+	}
+	return nil
+}
+
+// text is a string of the workbook, plain or made of runs of rich text.
+type text struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t text) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// sharedStrings returns the table of the strings the cells refer to by index. A workbook without text has
+// none.
+func sharedStrings(files map[string]*zip.File) ([]string, error) {
+	if files["xl/sharedStrings.xml"] == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []text `xml:"si"`
+	}
+	if err := decode(files, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		strs[i] = item.String()
+	}
+	return strs, nil
+}
+
+// readSheet returns the rows of the worksheet part name, counting its cells in cells.
+func readSheet(files map[string]*zip.File, name string, shared []string, cells *int) ([][]string, error) {
+	var worksheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline text   `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decode(files, name, &worksheet); err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for _, row := range worksheet.Rows {
+		r := len(rows)
+		if row.R > 0 {
+			r = row.R - 1
+		}
+		if r < len(rows) || r >= maxCells {
+			return nil, fmt.Errorf("row %d out of order", r+1)
+		}
+		for len(rows) <= r {
+			rows = append(rows, nil)
+		}
+		var values []string
+		for _, cell := range row.Cells {
+			c := len(values)
+			if cell.R != "" {
+				col, err := column(cell.R)
+				if err != nil {
+					return nil, err
+				}
+				if col < len(values) {
+					return nil, fmt.Errorf("cell %s out of order", cell.R)
+				}
+				c = col
+			}
+			if *cells += c - len(values) + 1; *cells > maxCells {
+				return nil, fmt.Errorf("more than %d cells", maxCells)
+			}
+			for len(values) < c {
+				values = append(values, "")
+			}
+			value := cell.V
+			switch cell.T {
+			case "s":
+				i, err := strconv.Atoi(value)
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("cell %s refers to an unknown shared string %q", cell.R, value)
+				}
+				value = shared[i]
+			case "inlineStr":
+				value = cell.Inline.String()
+			}
+			values = append(values, value)
+		}
+		rows[r] = values
+	}
+	return rows, nil
+}
+
+// column returns the index from 0 of the column of a cell reference such as C12.
+func column(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A') + 1
+	}
+	if i == 0 || i > 3 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// CellName returns the reference of the cell of row and column, both from 0, such as C12.
+func CellName(row, col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row+1)
+}
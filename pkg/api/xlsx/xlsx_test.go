@@ -0,0 +1,110 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/lichensio/api_server/db/model"
+	"github.com/lichensio/api_server/pkg/api/apierror"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// zipParts returns an archive of the parts given.
+func zipParts(t *testing.T, parts map[string]string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	data := zipParts(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="B" sheetId="2" r:id="rId7"/><sheet name="A" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId7" Target="/xl/worksheets/other.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Name</t></si><si><r><t>9:00-</t></r><r><t>12:00</t></r></si></sst>`,
+		// Row 2 is missing, and so are the cells A3 and C3.
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>Monday</t></is></c></row>
+			<row r="3"><c r="B3" t="s"><v>1</v></c><c r="D3"><v>45383</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/other.xml": `<worksheet><sheetData><row><c t="str"><v>Ines</v></c><c><v>2</v></c></row></sheetData></worksheet>`,
+	})
+	workbook, err := Read(data)
+	require.NoError(t, err)
+	require.Equal(t, &Workbook{Sheets: []Sheet{
+		{Name: "B", Rows: [][]string{{"Ines", "2"}}},
+		{Name: "A", Rows: [][]string{{"Name", "Monday"}, nil, {"", "9:00-12:00", "", "45383"}}},
+	}}, workbook)
+
+	_, err = Read([]byte("Name,Monday\n"))
+	require.ErrorIs(t, err, ErrNotWorkbook)
+	_, err = Read(zipParts(t, map[string]string{"word/document.xml": "<document/>"}))
+	require.ErrorIs(t, err, ErrNotWorkbook)
+}
+
+func TestCellName(t *testing.T) {
+	require.Equal(t, "A1", CellName(0, 0))
+	require.Equal(t, "Z3", CellName(2, 25))
+	require.Equal(t, "AA10", CellName(9, 26))
+	col, err := column("AA10")
+	require.NoError(t, err)
+	require.Equal(t, 26, col)
+}
+
+func TestRoster(t *testing.T) {
+	workbook := &Workbook{Sheets: []Sheet{
+		{Name: "A", Rows: [][]string{
+			{"Name", "Start date", "Department", "Contract hours", "Email", "Monday", "Tuesday"},
+			{"Ines", "45383", "shop", "35", "ines@example.com", "9:00-12:00 cash desk, 14:00 - 18:00 @Lyon", ""},
+			nil,
+			{"Paul", "2024-04-15", "", "", "", "", "10:00-16:00\n17:00-19:00 lab @ Lyon"},
+		}},
+		{Name: "B", Rows: [][]string{
+			{"name", "Department", "End date", "saturday"},
+			{"Ines", "shop", "45747", "9:00-13:00"},
+		}},
+	}}
+	employees, invalid := Roster(workbook)
+	require.Empty(t, invalid)
+	require.Equal(t, model.EmployeesInput{
+		{Name: "Ines", StartDate: "2024-04-01", EndDate: "2025-03-31", Department: "shop", ContractWeeklyHours: 35, Email: "ines@example.com", Weeks: map[string]model.WeeklyScheduleInput{
+			"A": {Monday: []model.ScheduleInput{{Start: "9:00", End: "12:00", Task: "cash desk"}, {Start: "14:00", End: "18:00", Location: "Lyon"}}},
+			"B": {Saturday: []model.ScheduleInput{{Start: "9:00", End: "13:00"}}},
+		}},
+		{Name: "Paul", StartDate: "2024-04-15", Weeks: map[string]model.WeeklyScheduleInput{
+			"A": {Tuesday: []model.ScheduleInput{{Start: "10:00", End: "16:00"}, {Start: "17:00", End: "19:00", Task: "lab", Location: "Lyon"}}},
+			"B": {},
+		}},
+	}, employees)
+}
+
+func TestRosterInvalid(t *testing.T) {
+	workbook := &Workbook{Sheets: []Sheet{
+		{Name: "A", Rows: [][]string{
+			{"Name", "Department", "Hours", "Monday"},
+			{"Ines", "shop", "", "9h-12h"},
+			{"", "", "", "9:00-12:00"},
+			{"Ines", "", "", ""},
+		}},
+		{Name: "B", Rows: [][]string{{"Name", "Department"}, {"Ines", "workshop"}}},
+		{Name: "Notes", Rows: [][]string{{"Remember the inventory"}}},
+	}}
+	_, invalid := Roster(workbook)
+	require.Equal(t, []apierror.InvalidParam{
+		{Name: "A!C1", Code: apierror.CodeInputInvalid, Reason: `unknown column "Hours"`},
+		{Name: "A!D2", Code: apierror.CodeTimeFormatInvalid, Reason: `slots must be written HH:MM-HH:MM [task] [@location], got: "9h-12h"`},
+		{Name: "A!A3", Code: apierror.CodeFieldRequired, Reason: "the name of the employee is required"},
+		{Name: "A!A4", Code: apierror.CodeInputInvalid, Reason: "Ines is already on row 2 of the sheet"},
+		{Name: "B!B2", Code: apierror.CodeInputInvalid, Reason: `workshop disagrees with "shop" given on another sheet`},
+		{Name: "Notes!A1", Code: apierror.CodeInputInvalid, Reason: `unknown column "Remember the inventory"`},
+		{Name: "Notes!A1", Code: apierror.CodeFieldRequired, Reason: "the header row must have a Name column"},
+	}, invalid)
+}
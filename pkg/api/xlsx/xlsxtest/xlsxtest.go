@@ -0,0 +1,85 @@
+// Package xlsxtest builds workbooks for the tests of the packages reading them, so that they need no
+// binary fixture.
+package xlsxtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/lichensio/api_server/pkg/api/xlsx"
+	"strings"
+)
+
+// Workbook returns an .xlsx workbook with the sheets given, as Excel writes it: the text of the cells in the
+// table of shared strings, and the numbers as such. The empty cells are left out.
+func Workbook(sheets ...xlsx.Sheet) []byte {
+	var shared []string
+	index := map[string]int{}
+	parts := map[string]string{}
+	var entries, rels strings.Builder
+	for i, sheet := range sheets {
+		var data strings.Builder
+		for r, row := range sheet.Rows {
+			fmt.Fprintf(&data, `<row r="%d">`, r+1)
+			for c, value := range row {
+				if value == "" {
+					continue
+				}
+				if isNumber(value) {
+					fmt.Fprintf(&data, `<c r="%s"><v>%s</v></c>`, xlsx.CellName(r, c), value)
+					continue
+				}
+				n, ok := index[value]
+				if !ok {
+					n = len(shared)
+					index[value] = n
+					shared = append(shared, value)
+				}
+				fmt.Fprintf(&data, `<c r="%s" t="s"><v>%d</v></c>`, xlsx.CellName(r, c), n)
+			}
+			data.WriteString(`</row>`)
+		}
+		parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)] = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			data.String() + `</sheetData></worksheet>`
+		fmt.Fprintf(&entries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(sheet.Name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	parts["xl/workbook.xml"] = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + entries.String() + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + `</Relationships>`
+	var strs strings.Builder
+	for _, value := range shared {
+		fmt.Fprintf(&strs, `<si><t xml:space="preserve">%s</t></si>`, escape(value))
+	}
+	parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + strs.String() + `</sst>`
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			panic(err)
+		}
+		w.Write([]byte(xml.Header + content))
+	}
+	if err := archive.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func isNumber(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}